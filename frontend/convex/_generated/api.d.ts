@@ -10,6 +10,9 @@
 
 import type * as auth from "../auth.js";
 import type * as http from "../http.js";
+import type * as tuiAuth from "../tuiAuth.js";
+import type * as tuiIdempotency from "../tuiIdempotency.js";
+import type * as workflowRuns from "../workflowRuns.js";
 import type * as workflows from "../workflows.js";
 
 import type {
@@ -21,6 +24,9 @@ import type {
 declare const fullApi: ApiFromModules<{
   auth: typeof auth;
   http: typeof http;
+  tuiAuth: typeof tuiAuth;
+  tuiIdempotency: typeof tuiIdempotency;
+  workflowRuns: typeof workflowRuns;
   workflows: typeof workflows;
 }>;
 
@@ -11,12 +11,80 @@ export default defineSchema({
     nodes: v.string(),
     edges: v.string(),
     globalConfig: v.optional(v.string()),
+    // JSON map of target name (staging, production) to the config document
+    // uploaded from a synced project; replaces the generated config.<target>.json.
+    targetConfigs: v.optional(v.string()),
     compiledArtifactStorageId: v.optional(v.id("_storage")),
     compiledArtifactFileName: v.optional(v.string()),
     compiledArtifactFileSize: v.optional(v.number()),
     compiledArtifactFileCount: v.optional(v.number()),
     compiledArtifactCompilerVersion: v.optional(v.string()),
+    compiledArtifactSha256: v.optional(v.string()),
     compiledArtifactUpdatedAt: v.optional(v.number()),
+    compileStatus: v.optional(v.union(v.literal("compiling"), v.literal("compile_failed"))),
+    compileError: v.optional(v.string()),
+    compileStartedAt: v.optional(v.number()),
     updatedAt: v.number(),
   }).index("by_user", ["userId"]),
+  compiledArtifacts: defineTable({
+    workflowId: v.id("workflows"),
+    storageId: v.id("_storage"),
+    fileName: v.string(),
+    fileSize: v.number(),
+    fileCount: v.number(),
+    compilerVersion: v.string(),
+    compiledAt: v.number(),
+    sha256: v.optional(v.string()),
+  }).index("by_workflow_compiled", ["workflowId", "compiledAt"]),
+  workflowRuns: defineTable({
+    workflowId: v.id("workflows"),
+    runId: v.string(),
+    status: v.string(),
+    trigger: v.optional(v.string()),
+    startedAt: v.number(),
+    finishedAt: v.optional(v.number()),
+    error: v.optional(v.string()),
+  })
+    .index("by_workflow_started", ["workflowId", "startedAt"])
+    .index("by_run_id", ["runId"]),
+  tuiAuthCodes: defineTable({
+    userId: v.id("users"),
+    codeHash: v.string(),
+    codeChallenge: v.string(),
+    redirectUri: v.string(),
+    token: v.string(),
+    expiresAt: v.number(),
+  })
+    .index("by_code_hash", ["codeHash"])
+    .index("by_user", ["userId"]),
+  tuiDeviceCodes: defineTable({
+    deviceCodeHash: v.string(),
+    userCode: v.string(),
+    scope: v.string(),
+    status: v.union(v.literal("pending"), v.literal("approved"), v.literal("denied")),
+    userId: v.optional(v.id("users")),
+    token: v.optional(v.string()),
+    expiresAt: v.number(),
+    lastPolledAt: v.optional(v.number()),
+  })
+    .index("by_device_code_hash", ["deviceCodeHash"])
+    .index("by_user_code", ["userCode"]),
+  tuiRevokedTokens: defineTable({
+    userId: v.id("users"),
+    tokenHash: v.string(),
+    expiresAt: v.number(),
+  })
+    .index("by_token_hash", ["tokenHash"])
+    .index("by_expires_at", ["expiresAt"]),
+
+  tuiIdempotencyKeys: defineTable({
+    userId: v.id("users"),
+    key: v.string(),
+    route: v.string(),
+    status: v.number(),
+    body: v.string(),
+    expiresAt: v.number(),
+  })
+    .index("by_user_key", ["userId", "key"])
+    .index("by_expires_at", ["expiresAt"]),
 });
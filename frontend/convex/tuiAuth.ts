@@ -0,0 +1,230 @@
+import { mutation, query } from "./_generated/server";
+import { v } from "convex/values";
+import { getAuthUserId } from "@convex-dev/auth/server";
+
+// Authorization codes of the TUI browser login (RFC 7636 PKCE). The link page
+// trades the browser session for a one-time code bound to the TUI's code
+// challenge; only the TUI, which holds the verifier, can redeem it for the
+// token. The token is kept until then, at most AUTH_CODE_TTL_MS.
+const AUTH_CODE_TTL_MS = 2 * 60 * 1000;
+
+function toBase64Url(bytes: Uint8Array): string {
+  let binary = "";
+  for (const byte of bytes) binary += String.fromCharCode(byte);
+  return btoa(binary).replace(/\+/g, "-").replace(/\//g, "_").replace(/=+$/, "");
+}
+
+function toHex(bytes: Uint8Array): string {
+  return Array.from(bytes, (byte) => byte.toString(16).padStart(2, "0")).join("");
+}
+
+async function sha256(value: string): Promise<Uint8Array> {
+  const digest = await crypto.subtle.digest("SHA-256", new TextEncoder().encode(value));
+  return new Uint8Array(digest);
+}
+
+async function hashSecret(value: string): Promise<string> {
+  return toHex(await sha256(value));
+}
+
+export const createAuthCode = mutation({
+  args: {
+    code: v.string(),
+    codeChallenge: v.string(),
+    redirectUri: v.string(),
+    token: v.string(),
+  },
+  handler: async (ctx, args) => {
+    const userId = await getAuthUserId(ctx);
+    if (!userId) throw new Error("Not authenticated");
+
+    const now = Date.now();
+    const stale = await ctx.db
+      .query("tuiAuthCodes")
+      .withIndex("by_user", (q) => q.eq("userId", userId))
+      .collect();
+    for (const entry of stale) {
+      if (entry.expiresAt <= now) await ctx.db.delete(entry._id);
+    }
+
+    await ctx.db.insert("tuiAuthCodes", {
+      userId,
+      codeHash: await hashSecret(args.code),
+      codeChallenge: args.codeChallenge,
+      redirectUri: args.redirectUri,
+      token: args.token,
+      expiresAt: now + AUTH_CODE_TTL_MS,
+    });
+    return { expiresAt: now + AUTH_CODE_TTL_MS };
+  },
+});
+
+// redeemAuthCode needs no session: the code and its verifier are the
+// credential. A code is deleted on the first attempt, right or wrong.
+export const redeemAuthCode = mutation({
+  args: {
+    code: v.string(),
+    codeVerifier: v.string(),
+    redirectUri: v.string(),
+  },
+  handler: async (ctx, args) => {
+    const codeHash = await hashSecret(args.code);
+    const entry = await ctx.db
+      .query("tuiAuthCodes")
+      .withIndex("by_code_hash", (q) => q.eq("codeHash", codeHash))
+      .unique();
+    if (!entry) return { error: "invalid_grant" as const };
+    await ctx.db.delete(entry._id);
+
+    if (entry.expiresAt <= Date.now()) return { error: "invalid_grant" as const };
+    if (entry.redirectUri !== args.redirectUri) return { error: "invalid_grant" as const };
+    const challenge = toBase64Url(await sha256(args.codeVerifier));
+    if (challenge !== entry.codeChallenge) return { error: "invalid_grant" as const };
+
+    return { token: entry.token };
+  },
+});
+
+// Device authorization grant (RFC 8628) for terminals that cannot receive the
+// loopback callback. The TUI polls with the device code while the user
+// approves the short user code on /tui/device.
+const DEVICE_CODE_TTL_MS = 10 * 60 * 1000;
+const DEVICE_POLL_INTERVAL_S = 5;
+
+export const createDeviceCode = mutation({
+  args: {
+    deviceCode: v.string(),
+    userCode: v.string(),
+    scope: v.string(),
+  },
+  handler: async (ctx, args) => {
+    const existing = await ctx.db
+      .query("tuiDeviceCodes")
+      .withIndex("by_user_code", (q) => q.eq("userCode", args.userCode))
+      .first();
+    if (existing && existing.expiresAt > Date.now()) {
+      throw new Error("User code collision");
+    }
+    if (existing) await ctx.db.delete(existing._id);
+
+    const expiresAt = Date.now() + DEVICE_CODE_TTL_MS;
+    await ctx.db.insert("tuiDeviceCodes", {
+      deviceCodeHash: await hashSecret(args.deviceCode),
+      userCode: args.userCode,
+      scope: args.scope,
+      status: "pending",
+      expiresAt,
+    });
+    return { expiresAt, interval: DEVICE_POLL_INTERVAL_S };
+  },
+});
+
+// decideDeviceCode records the signed-in user's answer for a user code. The
+// browser session token is what the TUI receives once approved.
+export const decideDeviceCode = mutation({
+  args: {
+    userCode: v.string(),
+    approve: v.boolean(),
+    token: v.string(),
+  },
+  handler: async (ctx, args) => {
+    const userId = await getAuthUserId(ctx);
+    if (!userId) throw new Error("Not authenticated");
+
+    const entry = await ctx.db
+      .query("tuiDeviceCodes")
+      .withIndex("by_user_code", (q) => q.eq("userCode", args.userCode))
+      .first();
+    if (!entry || entry.expiresAt <= Date.now()) {
+      return { error: "expired_token" as const };
+    }
+    if (entry.status !== "pending") {
+      return { error: "already_used" as const };
+    }
+    if (args.approve) {
+      await ctx.db.patch(entry._id, { status: "approved", userId, token: args.token });
+    } else {
+      await ctx.db.patch(entry._id, { status: "denied", userId });
+    }
+    return { scope: entry.scope };
+  },
+});
+
+// pollDeviceCode answers the TUI's token polls with the RFC 8628 error codes.
+// An approved code is deleted once its token has been handed out.
+export const pollDeviceCode = mutation({
+  args: { deviceCode: v.string() },
+  handler: async (ctx, args) => {
+    const deviceCodeHash = await hashSecret(args.deviceCode);
+    const entry = await ctx.db
+      .query("tuiDeviceCodes")
+      .withIndex("by_device_code_hash", (q) => q.eq("deviceCodeHash", deviceCodeHash))
+      .unique();
+    if (!entry) return { error: "invalid_grant" as const };
+
+    const now = Date.now();
+    if (entry.expiresAt <= now) {
+      await ctx.db.delete(entry._id);
+      return { error: "expired_token" as const };
+    }
+    if (entry.status === "denied") {
+      await ctx.db.delete(entry._id);
+      return { error: "access_denied" as const };
+    }
+    if (entry.status === "approved" && entry.token) {
+      await ctx.db.delete(entry._id);
+      return { token: entry.token };
+    }
+
+    const tooSoon =
+      entry.lastPolledAt !== undefined &&
+      now - entry.lastPolledAt < DEVICE_POLL_INTERVAL_S * 1000;
+    await ctx.db.patch(entry._id, { lastPolledAt: now });
+    return { error: tooSoon ? ("slow_down" as const) : ("authorization_pending" as const) };
+  },
+});
+
+// Tokens the TUI signed out with. They stay valid JWTs until they expire, so
+// the /api/tui routes check this list through `session`.
+export const session = query({
+  args: { tokenHash: v.string() },
+  handler: async (ctx, args) => {
+    const userId = await getAuthUserId(ctx);
+    if (!userId) return null;
+    const revoked = await ctx.db
+      .query("tuiRevokedTokens")
+      .withIndex("by_token_hash", (q) => q.eq("tokenHash", args.tokenHash))
+      .first();
+    if (revoked) return null;
+    const user = await ctx.db.get(userId);
+    return { userId, name: user?.name ?? user?.email ?? "" };
+  },
+});
+
+export const revokeToken = mutation({
+  args: { tokenHash: v.string(), expiresAt: v.number() },
+  handler: async (ctx, args) => {
+    const userId = await getAuthUserId(ctx);
+    if (!userId) throw new Error("Not authenticated");
+
+    const now = Date.now();
+    const expired = await ctx.db
+      .query("tuiRevokedTokens")
+      .withIndex("by_expires_at", (q) => q.lt("expiresAt", now))
+      .take(100);
+    for (const entry of expired) await ctx.db.delete(entry._id);
+
+    const existing = await ctx.db
+      .query("tuiRevokedTokens")
+      .withIndex("by_token_hash", (q) => q.eq("tokenHash", args.tokenHash))
+      .first();
+    if (!existing) {
+      await ctx.db.insert("tuiRevokedTokens", {
+        userId,
+        tokenHash: args.tokenHash,
+        expiresAt: args.expiresAt,
+      });
+    }
+    return { ok: true };
+  },
+});
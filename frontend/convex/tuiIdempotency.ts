@@ -0,0 +1,61 @@
+import { mutation, query } from "./_generated/server";
+import { v } from "convex/values";
+import { getAuthUserId } from "@convex-dev/auth/server";
+
+// Responses of mutating TUI requests, by the Idempotency-Key the TUI sent. A
+// retry with the same key gets the stored response instead of applying the
+// change again. Keys are per user and kept for IDEMPOTENCY_TTL_MS.
+const IDEMPOTENCY_TTL_MS = 24 * 60 * 60 * 1000;
+
+export const lookup = query({
+  args: { key: v.string() },
+  handler: async (ctx, args) => {
+    const userId = await getAuthUserId(ctx);
+    if (!userId) throw new Error("Not authenticated");
+
+    const entry = await ctx.db
+      .query("tuiIdempotencyKeys")
+      .withIndex("by_user_key", (q) => q.eq("userId", userId).eq("key", args.key))
+      .first();
+    if (!entry || entry.expiresAt < Date.now()) return null;
+    return { route: entry.route, status: entry.status, body: entry.body };
+  },
+});
+
+export const store = mutation({
+  args: {
+    key: v.string(),
+    route: v.string(),
+    status: v.number(),
+    body: v.string(),
+  },
+  handler: async (ctx, args) => {
+    const userId = await getAuthUserId(ctx);
+    if (!userId) throw new Error("Not authenticated");
+
+    const now = Date.now();
+    const expired = await ctx.db
+      .query("tuiIdempotencyKeys")
+      .withIndex("by_expires_at", (q) => q.lt("expiresAt", now))
+      .take(100);
+    for (const entry of expired) await ctx.db.delete(entry._id);
+
+    // The first response wins; a concurrent duplicate does not replace it.
+    const existing = await ctx.db
+      .query("tuiIdempotencyKeys")
+      .withIndex("by_user_key", (q) => q.eq("userId", userId).eq("key", args.key))
+      .first();
+    if (existing && existing.expiresAt >= now) return { ok: true };
+    if (existing) await ctx.db.delete(existing._id);
+
+    await ctx.db.insert("tuiIdempotencyKeys", {
+      userId,
+      key: args.key,
+      route: args.route,
+      status: args.status,
+      body: args.body,
+      expiresAt: now + IDEMPOTENCY_TTL_MS,
+    });
+    return { ok: true };
+  },
+});
@@ -0,0 +1,57 @@
+import { internalMutation, query } from "./_generated/server";
+import { v } from "convex/values";
+import { getAuthUserId } from "@convex-dev/auth/server";
+
+const MAX_RUNS = 100;
+
+export const listForWorkflow = query({
+  args: {
+    id: v.id("workflows"),
+    limit: v.optional(v.number()),
+  },
+  handler: async (ctx, args) => {
+    const userId = await getAuthUserId(ctx);
+    if (!userId) throw new Error("Not authenticated");
+
+    const workflow = await ctx.db.get(args.id);
+    if (!workflow || workflow.userId !== userId) {
+      throw new Error("Workflow not found");
+    }
+
+    const limit = Math.min(Math.max(Math.floor(args.limit ?? 20), 1), MAX_RUNS);
+    return await ctx.db
+      .query("workflowRuns")
+      .withIndex("by_workflow_started", (q) => q.eq("workflowId", args.id))
+      .order("desc")
+      .take(limit);
+  },
+});
+
+// record stores a run reported by the deployment side (the CRE execution
+// webhook); it is internal so only trusted backend code can write history.
+export const record = internalMutation({
+  args: {
+    workflowId: v.id("workflows"),
+    runId: v.string(),
+    status: v.string(),
+    trigger: v.optional(v.string()),
+    startedAt: v.number(),
+    finishedAt: v.optional(v.number()),
+    error: v.optional(v.string()),
+  },
+  handler: async (ctx, args) => {
+    const existing = await ctx.db
+      .query("workflowRuns")
+      .withIndex("by_run_id", (q) => q.eq("runId", args.runId))
+      .first();
+    if (existing) {
+      await ctx.db.patch(existing._id, {
+        status: args.status,
+        finishedAt: args.finishedAt,
+        error: args.error,
+      });
+      return existing._id;
+    }
+    return await ctx.db.insert("workflowRuns", args);
+  },
+});
@@ -64,10 +64,32 @@ export const remove = mutation({
     if (!workflow || workflow.userId !== userId) {
       throw new Error("Workflow not found");
     }
+    const history = await ctx.db
+      .query("compiledArtifacts")
+      .withIndex("by_workflow_compiled", (q) => q.eq("workflowId", args.id))
+      .collect();
+    for (const entry of history) {
+      await ctx.storage.delete(entry.storageId);
+      await ctx.db.delete(entry._id);
+    }
     await ctx.db.delete(args.id);
   },
 });
 
+export const saveTargetConfigs = mutation({
+  args: { id: v.id("workflows"), targetConfigs: v.string() },
+  handler: async (ctx, args) => {
+    const userId = await getAuthUserId(ctx);
+    if (!userId) throw new Error("Not authenticated");
+
+    const workflow = await ctx.db.get(args.id);
+    if (!workflow || workflow.userId !== userId) {
+      throw new Error("Workflow not found");
+    }
+    await ctx.db.patch(args.id, { targetConfigs: args.targetConfigs, updatedAt: Date.now() });
+  },
+});
+
 export const generateCompileUploadUrl = mutation({
   args: {},
   handler: async (ctx) => {
@@ -77,6 +99,9 @@ export const generateCompileUploadUrl = mutation({
   },
 });
 
+// Compiled bundles kept per workflow, the current one included.
+const BUNDLE_HISTORY_LIMIT = 10;
+
 export const saveCompiledArtifact = mutation({
   args: {
     id: v.id("workflows"),
@@ -86,6 +111,8 @@ export const saveCompiledArtifact = mutation({
     fileCount: v.number(),
     compilerVersion: v.string(),
     compiledAt: v.number(),
+    // Hex SHA-256 of the zip, checked by the TUI after downloading it.
+    sha256: v.optional(v.string()),
   },
   handler: async (ctx, args) => {
     const userId = await getAuthUserId(ctx);
@@ -96,8 +123,30 @@ export const saveCompiledArtifact = mutation({
       throw new Error("Workflow not found");
     }
 
-    if (workflow.compiledArtifactStorageId) {
-      await ctx.storage.delete(workflow.compiledArtifactStorageId);
+    await ctx.db.insert("compiledArtifacts", {
+      workflowId: args.id,
+      storageId: args.storageId,
+      fileName: args.fileName,
+      fileSize: args.fileSize,
+      fileCount: args.fileCount,
+      compilerVersion: args.compilerVersion,
+      compiledAt: args.compiledAt,
+      sha256: args.sha256,
+    });
+    const history = await ctx.db
+      .query("compiledArtifacts")
+      .withIndex("by_workflow_compiled", (q) => q.eq("workflowId", args.id))
+      .order("desc")
+      .collect();
+    for (const old of history.slice(BUNDLE_HISTORY_LIMIT)) {
+      await ctx.storage.delete(old.storageId);
+      await ctx.db.delete(old._id);
+    }
+    // Artifacts from before the history table have no row; drop them as the
+    // old code did.
+    const previous = workflow.compiledArtifactStorageId;
+    if (previous && !history.some((entry) => entry.storageId === previous)) {
+      await ctx.storage.delete(previous);
     }
 
     await ctx.db.patch(args.id, {
@@ -106,7 +155,11 @@ export const saveCompiledArtifact = mutation({
       compiledArtifactFileSize: args.fileSize,
       compiledArtifactFileCount: args.fileCount,
       compiledArtifactCompilerVersion: args.compilerVersion,
+      compiledArtifactSha256: args.sha256,
       compiledArtifactUpdatedAt: args.compiledAt,
+      compileStatus: undefined,
+      compileError: undefined,
+      compileStartedAt: undefined,
       updatedAt: Date.now(),
     });
 
@@ -114,9 +167,63 @@ export const saveCompiledArtifact = mutation({
   },
 });
 
+// A compile older than this is assumed to have died with its request.
+const COMPILE_LOCK_MS = 5 * 60 * 1000;
+
+// startCompile marks a server-side compile as running; it returns false while
+// another compile of the same workflow is still in flight.
+export const startCompile = mutation({
+  args: { id: v.id("workflows") },
+  handler: async (ctx, args) => {
+    const userId = await getAuthUserId(ctx);
+    if (!userId) throw new Error("Not authenticated");
+
+    const workflow = await ctx.db.get(args.id);
+    if (!workflow || workflow.userId !== userId) {
+      throw new Error("Workflow not found");
+    }
+
+    const now = Date.now();
+    if (
+      workflow.compileStatus === "compiling" &&
+      workflow.compileStartedAt !== undefined &&
+      now - workflow.compileStartedAt < COMPILE_LOCK_MS
+    ) {
+      return false;
+    }
+    await ctx.db.patch(args.id, {
+      compileStatus: "compiling",
+      compileError: undefined,
+      compileStartedAt: now,
+    });
+    return true;
+  },
+});
+
+export const failCompile = mutation({
+  args: { id: v.id("workflows"), error: v.string() },
+  handler: async (ctx, args) => {
+    const userId = await getAuthUserId(ctx);
+    if (!userId) throw new Error("Not authenticated");
+
+    const workflow = await ctx.db.get(args.id);
+    if (!workflow || workflow.userId !== userId) {
+      throw new Error("Workflow not found");
+    }
+    await ctx.db.patch(args.id, {
+      compileStatus: "compile_failed",
+      compileError: args.error,
+      compileStartedAt: undefined,
+    });
+  },
+});
+
 export const getCompiledArtifactForTui = query({
   args: {
     id: v.id("workflows"),
+    // storageId of an older bundle from listBundleVersions; omitted for the
+    // current one.
+    version: v.optional(v.string()),
   },
   handler: async (ctx, args) => {
     const userId = await getAuthUserId(ctx);
@@ -127,23 +234,88 @@ export const getCompiledArtifactForTui = query({
       throw new Error("Workflow not found");
     }
 
-    if (!workflow.compiledArtifactStorageId) {
+    const defaultFileName = `${workflow.name.toLowerCase().replace(/[^a-z0-9]+/g, "-") || "workflow"}-cre-bundle.zip`;
+    let artifact = workflow.compiledArtifactStorageId
+      ? {
+          storageId: workflow.compiledArtifactStorageId,
+          fileName: workflow.compiledArtifactFileName ?? defaultFileName,
+          compilerVersion: workflow.compiledArtifactCompilerVersion ?? "",
+          sha256: workflow.compiledArtifactSha256 ?? "",
+        }
+      : null;
+    if (args.version && args.version !== workflow.compiledArtifactStorageId) {
+      const entry = (
+        await ctx.db
+          .query("compiledArtifacts")
+          .withIndex("by_workflow_compiled", (q) => q.eq("workflowId", args.id))
+          .collect()
+      ).find((candidate) => candidate.storageId === args.version);
+      artifact = entry
+        ? {
+            storageId: entry.storageId,
+            fileName: entry.fileName,
+            compilerVersion: entry.compilerVersion,
+            sha256: entry.sha256 ?? "",
+          }
+        : null;
+    }
+    if (!artifact) {
       return null;
     }
 
-    const downloadUrl = await ctx.storage.getUrl(workflow.compiledArtifactStorageId);
+    const downloadUrl = await ctx.storage.getUrl(artifact.storageId);
     if (!downloadUrl) {
       return null;
     }
 
     return {
       downloadUrl,
-      fileName:
-        workflow.compiledArtifactFileName ??
-        `${workflow.name.toLowerCase().replace(/[^a-z0-9]+/g, "-") || "workflow"}-cre-bundle.zip`,
-      compilerVersion: workflow.compiledArtifactCompilerVersion ?? "",
+      storageId: artifact.storageId,
+      fileName: artifact.fileName,
+      compilerVersion: artifact.compilerVersion,
+      sha256: artifact.sha256,
       workflowName: workflow.name,
       updatedAt: workflow.updatedAt,
     };
   },
 });
+
+export const listBundleVersions = query({
+  args: { id: v.id("workflows") },
+  handler: async (ctx, args) => {
+    const userId = await getAuthUserId(ctx);
+    if (!userId) throw new Error("Not authenticated");
+
+    const workflow = await ctx.db.get(args.id);
+    if (!workflow || workflow.userId !== userId) {
+      throw new Error("Workflow not found");
+    }
+
+    const history = await ctx.db
+      .query("compiledArtifacts")
+      .withIndex("by_workflow_compiled", (q) => q.eq("workflowId", args.id))
+      .order("desc")
+      .collect();
+    const versions = history.map((entry) => ({
+      id: entry.storageId as string,
+      compilerVersion: entry.compilerVersion,
+      createdAt: entry.compiledAt,
+      sha256: entry.sha256 ?? "",
+      size: entry.fileSize,
+      current: entry.storageId === workflow.compiledArtifactStorageId,
+    }));
+    // A bundle compiled before the history table existed has no row yet.
+    const current = workflow.compiledArtifactStorageId;
+    if (current && !versions.some((version) => version.current)) {
+      versions.unshift({
+        id: current,
+        compilerVersion: workflow.compiledArtifactCompilerVersion ?? "",
+        createdAt: workflow.compiledArtifactUpdatedAt ?? workflow.updatedAt,
+        sha256: workflow.compiledArtifactSha256 ?? "",
+        size: workflow.compiledArtifactFileSize ?? 0,
+        current: true,
+      });
+    }
+    return versions;
+  },
+});
@@ -0,0 +1,56 @@
+import { randomBytes } from "crypto";
+import { fetchMutation } from "convex/nextjs";
+import { NextRequest, NextResponse } from "next/server";
+import { api } from "../../../../../../convex/_generated/api";
+import { getBearerToken, isUnauthorizedError, readJsonBody } from "@/lib/tui-api";
+
+interface AuthCodeRequest {
+  codeChallenge?: string;
+  codeChallengeMethod?: string;
+  redirectUri?: string;
+}
+
+// Called by /tui/link with the browser session: issues the one-time code the
+// page hands to the TUI callback instead of the session token itself.
+export async function POST(request: NextRequest) {
+  const token = getBearerToken(request);
+  if (!token) {
+    return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+  }
+
+  const body = await readJsonBody<AuthCodeRequest>(request);
+  if (!body) {
+    return NextResponse.json({ error: "Invalid JSON body" }, { status: 400 });
+  }
+  const codeChallenge = (body.codeChallenge ?? "").trim();
+  const redirectUri = (body.redirectUri ?? "").trim();
+  if ((body.codeChallengeMethod ?? "S256") !== "S256") {
+    return NextResponse.json({ error: "Only the S256 code challenge method is supported" }, { status: 400 });
+  }
+  // A base64url SHA-256 digest is 43 characters.
+  if (!/^[A-Za-z0-9_-]{43}$/.test(codeChallenge)) {
+    return NextResponse.json({ error: "Invalid code challenge" }, { status: 400 });
+  }
+  if (!redirectUri) {
+    return NextResponse.json({ error: "redirectUri is required" }, { status: 400 });
+  }
+
+  const code = randomBytes(32).toString("base64url");
+  try {
+    const { expiresAt } = await fetchMutation(
+      api.tuiAuth.createAuthCode,
+      { code, codeChallenge, redirectUri, token },
+      { token }
+    );
+    return NextResponse.json(
+      { code, expiresAt },
+      { status: 200, headers: { "Cache-Control": "no-store" } }
+    );
+  } catch (error) {
+    if (isUnauthorizedError(error)) {
+      return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+    }
+    console.error("[tui/auth/code] failed to issue authorization code", error);
+    return NextResponse.json({ error: "Failed to issue authorization code" }, { status: 500 });
+  }
+}
@@ -0,0 +1,14 @@
+import { NextRequest, NextResponse } from "next/server";
+import { authorizeTuiRequest } from "@/lib/tui-api";
+
+// A cheap authenticated request the TUI sends between user actions to notice
+// an expired or revoked token early.
+export async function GET(request: NextRequest) {
+  const session = await authorizeTuiRequest(request);
+  if (session instanceof NextResponse) return session;
+
+  return NextResponse.json(
+    { ok: true, userId: session.userId },
+    { status: 200, headers: { "Cache-Control": "no-store" } }
+  );
+}
@@ -0,0 +1,27 @@
+import { fetchMutation } from "convex/nextjs";
+import { NextRequest, NextResponse } from "next/server";
+import { api } from "../../../../../../convex/_generated/api";
+import { authorizeTuiRequest, hashToken, isUnauthorizedError, tokenExpiresAt } from "@/lib/tui-api";
+
+// Called by `tui logout`: the bearer token stops working on every /api/tui
+// route. Revoking an already revoked token answers 401, which the TUI treats
+// as done.
+export async function POST(request: NextRequest) {
+  const session = await authorizeTuiRequest(request);
+  if (session instanceof NextResponse) return session;
+
+  try {
+    await fetchMutation(
+      api.tuiAuth.revokeToken,
+      { tokenHash: hashToken(session.token), expiresAt: tokenExpiresAt(session.token) },
+      { token: session.token }
+    );
+    return NextResponse.json({ ok: true }, { status: 200 });
+  } catch (error) {
+    if (isUnauthorizedError(error)) {
+      return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+    }
+    console.error("[tui/auth/revoke] failed to revoke token", error);
+    return NextResponse.json({ error: "Failed to revoke token" }, { status: 500 });
+  }
+}
@@ -0,0 +1,50 @@
+import { fetchMutation } from "convex/nextjs";
+import { NextRequest, NextResponse } from "next/server";
+import { api } from "../../../../../../convex/_generated/api";
+import { readJsonBody } from "@/lib/tui-api";
+
+interface TokenRequest {
+  grantType?: string;
+  code?: string;
+  codeVerifier?: string;
+  redirectUri?: string;
+}
+
+// The TUI redeems the code its callback received, proving with the PKCE
+// verifier that it started the login.
+export async function POST(request: NextRequest) {
+  const body = await readJsonBody<TokenRequest>(request);
+  if (!body) {
+    return NextResponse.json({ error: "Invalid JSON body" }, { status: 400 });
+  }
+  if (body.grantType !== "authorization_code") {
+    return NextResponse.json({ error: "unsupported_grant_type" }, { status: 400 });
+  }
+  const code = (body.code ?? "").trim();
+  const codeVerifier = (body.codeVerifier ?? "").trim();
+  const redirectUri = (body.redirectUri ?? "").trim();
+  if (!code || !codeVerifier || !redirectUri) {
+    return NextResponse.json({ error: "invalid_request" }, { status: 400 });
+  }
+
+  try {
+    const result = await fetchMutation(api.tuiAuth.redeemAuthCode, {
+      code,
+      codeVerifier,
+      redirectUri,
+    });
+    if ("error" in result) {
+      return NextResponse.json(
+        { error: result.error },
+        { status: 400, headers: { "Cache-Control": "no-store" } }
+      );
+    }
+    return NextResponse.json(
+      { token: result.token },
+      { status: 200, headers: { "Cache-Control": "no-store" } }
+    );
+  } catch (error) {
+    console.error("[tui/auth/token] failed to redeem authorization code", error);
+    return NextResponse.json({ error: "Failed to redeem authorization code" }, { status: 500 });
+  }
+}
@@ -0,0 +1,83 @@
+import { NextRequest, NextResponse } from "next/server";
+import { verifyBundleDownload } from "@/lib/tui-api";
+
+interface ByteRange {
+  start: number;
+  end: number;
+}
+
+// parseRange reads a single "bytes=" range; multipart ranges are not served.
+// It returns undefined for a header it ignores and null when the range cannot
+// be satisfied.
+function parseRange(header: string, total: number): ByteRange | null | undefined {
+  const match = /^bytes=(\d*)-(\d*)$/.exec(header.trim());
+  if (!match || (match[1] === "" && match[2] === "")) return undefined;
+  if (match[1] === "") {
+    const suffix = Number(match[2]);
+    if (suffix === 0) return null;
+    return { start: Math.max(total - suffix, 0), end: total - 1 };
+  }
+  const start = Number(match[1]);
+  const end = match[2] === "" ? total - 1 : Math.min(Number(match[2]), total - 1);
+  if (start >= total || end < start) return null;
+  return { start, end };
+}
+
+// Serves a compiled bundle from storage with Range support so an interrupted
+// TUI download resumes where it stopped. Stored bundles never change, so the
+// storage id is a strong validator for If-Range.
+export async function GET(
+  request: NextRequest,
+  context: { params: { storageId: string } | Promise<{ storageId: string }> }
+) {
+  const resolvedParams = await Promise.resolve(context.params);
+  const storageId = resolvedParams?.storageId?.trim() ?? "";
+  const download = verifyBundleDownload(storageId, request.nextUrl.searchParams);
+  if (!download) {
+    return NextResponse.json({ error: "Download link is invalid or expired", code: "bundle_expired" }, { status: 403 });
+  }
+
+  try {
+    const upstream = await fetch(download.upstream);
+    if (!upstream.ok) {
+      return NextResponse.json({ error: "Compiled artifact not found" }, { status: 404 });
+    }
+    const content = new Uint8Array(await upstream.arrayBuffer());
+    const total = content.byteLength;
+
+    const etag = `"${storageId}"`;
+    const headers: Record<string, string> = {
+      "Accept-Ranges": "bytes",
+      "Cache-Control": "private, no-store",
+      "Content-Type": "application/zip",
+      ETag: etag,
+    };
+    if (download.fileName) {
+      headers["Content-Disposition"] = `attachment; filename="${download.fileName.replace(/["\\]/g, "")}"`;
+    }
+
+    const rangeHeader = request.headers.get("range");
+    const ifRange = request.headers.get("if-range");
+    const range = rangeHeader && (!ifRange || ifRange === etag) ? parseRange(rangeHeader, total) : undefined;
+    if (range === null) {
+      return new NextResponse(null, { status: 416, headers: { ...headers, "Content-Range": `bytes */${total}` } });
+    }
+    if (range) {
+      return new NextResponse(content.slice(range.start, range.end + 1), {
+        status: 206,
+        headers: {
+          ...headers,
+          "Content-Range": `bytes ${range.start}-${range.end}/${total}`,
+          "Content-Length": String(range.end - range.start + 1),
+        },
+      });
+    }
+    return new NextResponse(content, {
+      status: 200,
+      headers: { ...headers, "Content-Length": String(total) },
+    });
+  } catch (error) {
+    console.error("[tui/bundles/:storageId] failed to serve bundle", error);
+    return NextResponse.json({ error: "Failed to download compiled bundle" }, { status: 500 });
+  }
+}
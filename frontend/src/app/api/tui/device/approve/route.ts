@@ -0,0 +1,46 @@
+import { fetchMutation } from "convex/nextjs";
+import { NextRequest, NextResponse } from "next/server";
+import { api } from "../../../../../../convex/_generated/api";
+import { getBearerToken, isUnauthorizedError, readJsonBody } from "@/lib/tui-api";
+
+interface DeviceApproveRequest {
+  userCode?: string;
+  approve?: boolean;
+}
+
+// Called by /tui/device with the browser session once the user confirms or
+// rejects the code shown in their terminal.
+export async function POST(request: NextRequest) {
+  const token = getBearerToken(request);
+  if (!token) {
+    return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+  }
+
+  const body = await readJsonBody<DeviceApproveRequest>(request);
+  const userCode = (body?.userCode ?? "").trim().toUpperCase();
+  if (!/^[A-Z]{4}-[A-Z]{4}$/.test(userCode) || typeof body?.approve !== "boolean") {
+    return NextResponse.json({ error: "Invalid device approval request" }, { status: 400 });
+  }
+
+  try {
+    const result = await fetchMutation(
+      api.tuiAuth.decideDeviceCode,
+      { userCode, approve: body.approve, token },
+      { token }
+    );
+    if ("error" in result) {
+      const message =
+        result.error === "expired_token"
+          ? "This code has expired or does not exist"
+          : "This code has already been used";
+      return NextResponse.json({ error: message }, { status: 400 });
+    }
+    return NextResponse.json({ ok: true, scope: result.scope }, { status: 200 });
+  } catch (error) {
+    if (isUnauthorizedError(error)) {
+      return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+    }
+    console.error("[tui/device/approve] failed to record device approval", error);
+    return NextResponse.json({ error: "Failed to record device approval" }, { status: 500 });
+  }
+}
@@ -0,0 +1,61 @@
+import { randomBytes, randomInt } from "crypto";
+import { fetchMutation } from "convex/nextjs";
+import { NextRequest, NextResponse } from "next/server";
+import { api } from "../../../../../../convex/_generated/api";
+import { readJsonBody } from "@/lib/tui-api";
+
+interface DeviceCodeRequest {
+  client?: string;
+  scope?: string;
+}
+
+const KNOWN_SCOPES = new Set(["tui:read", "tui:write"]);
+// No vowels or look-alike characters, so a user code never spells a word and
+// survives being read aloud.
+const USER_CODE_ALPHABET = "BCDFGHJKLMNPQRSTVWXZ";
+
+function newUserCode(): string {
+  let code = "";
+  for (let i = 0; i < 8; i++) {
+    code += USER_CODE_ALPHABET[randomInt(USER_CODE_ALPHABET.length)];
+  }
+  return `${code.slice(0, 4)}-${code.slice(4)}`;
+}
+
+// Starts a device code login (RFC 8628) for a TUI that cannot receive the
+// loopback callback.
+export async function POST(request: NextRequest) {
+  const body = (await readJsonBody<DeviceCodeRequest>(request)) ?? {};
+  const requested = (body.scope ?? "").split(/\s+/).filter((entry) => KNOWN_SCOPES.has(entry));
+  const scope = requested.length > 0 ? requested.join(" ") : "tui:read tui:write";
+
+  const deviceCode = randomBytes(32).toString("base64url");
+  try {
+    // A fresh user code is drawn when one is still live for another login.
+    let userCode = newUserCode();
+    const create = () =>
+      fetchMutation(api.tuiAuth.createDeviceCode, { deviceCode, userCode, scope });
+    const result = await create().catch(() => {
+      userCode = newUserCode();
+      return create();
+    });
+
+    const verificationUri = new URL("/tui/device", request.nextUrl.origin);
+    const verificationUriComplete = new URL(verificationUri);
+    verificationUriComplete.searchParams.set("user_code", userCode);
+    return NextResponse.json(
+      {
+        deviceCode,
+        userCode,
+        verificationUri: verificationUri.toString(),
+        verificationUriComplete: verificationUriComplete.toString(),
+        expiresIn: Math.floor((result.expiresAt - Date.now()) / 1000),
+        interval: result.interval,
+      },
+      { status: 200, headers: { "Cache-Control": "no-store" } }
+    );
+  } catch (error) {
+    console.error("[tui/device/code] failed to create device code", error);
+    return NextResponse.json({ error: "Failed to create device code" }, { status: 500 });
+  }
+}
@@ -0,0 +1,35 @@
+import { fetchMutation } from "convex/nextjs";
+import { NextRequest, NextResponse } from "next/server";
+import { api } from "../../../../../../convex/_generated/api";
+import { readJsonBody } from "@/lib/tui-api";
+
+interface DeviceTokenRequest {
+  deviceCode?: string;
+}
+
+// Polled by the TUI until the user approves or denies its user code. Errors
+// use the RFC 8628 codes the client switches on.
+export async function POST(request: NextRequest) {
+  const body = await readJsonBody<DeviceTokenRequest>(request);
+  const deviceCode = (body?.deviceCode ?? "").trim();
+  if (!deviceCode) {
+    return NextResponse.json({ error: "invalid_request" }, { status: 400 });
+  }
+
+  try {
+    const result = await fetchMutation(api.tuiAuth.pollDeviceCode, { deviceCode });
+    if ("error" in result) {
+      return NextResponse.json(
+        { error: result.error },
+        { status: 400, headers: { "Cache-Control": "no-store" } }
+      );
+    }
+    return NextResponse.json(
+      { token: result.token },
+      { status: 200, headers: { "Cache-Control": "no-store" } }
+    );
+  } catch (error) {
+    console.error("[tui/device/token] failed to poll device code", error);
+    return NextResponse.json({ error: "Failed to poll device code" }, { status: 500 });
+  }
+}
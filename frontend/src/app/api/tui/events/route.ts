@@ -0,0 +1,120 @@
+import { fetchQuery } from "convex/nextjs";
+import { NextRequest, NextResponse } from "next/server";
+import { api } from "../../../../../convex/_generated/api";
+import {
+  authorizeTuiRequest,
+  hashToken,
+  isUnauthorizedError,
+  toTuiWorkflow,
+  TuiWorkflowDto,
+} from "@/lib/tui-api";
+
+export const dynamic = "force-dynamic";
+
+const POLL_INTERVAL_MS = 5_000;
+const KEEP_ALIVE_MS = 15_000;
+
+function sameWorkflow(a: TuiWorkflowDto, b: TuiWorkflowDto): boolean {
+  return (
+    a.name === b.name &&
+    a.updatedAt === b.updatedAt &&
+    a.nodeCount === b.nodeCount &&
+    a.status === b.status &&
+    a.compilerVersion === b.compilerVersion
+  );
+}
+
+// Server-sent events for the TUI workflow list. The route polls the user's
+// workflows and pushes the difference as workflow.created, workflow.updated
+// and workflow.deleted events; comment lines keep idle proxies from closing
+// the connection. The session is re-checked on every poll so a revoked token
+// loses the stream too.
+export async function GET(request: NextRequest) {
+  const session = await authorizeTuiRequest(request);
+  if (session instanceof NextResponse) return session;
+  const { token } = session;
+  const tokenHash = hashToken(token);
+
+  const encoder = new TextEncoder();
+  let timer: ReturnType<typeof setInterval> | undefined;
+  let closed = false;
+
+  const stream = new ReadableStream<Uint8Array>({
+    async start(controller) {
+      let known: Map<string, TuiWorkflowDto> | null = null;
+      let lastSentAt = Date.now();
+
+      const send = (chunk: string) => {
+        if (closed) return;
+        controller.enqueue(encoder.encode(chunk));
+        lastSentAt = Date.now();
+      };
+      const sendEvent = (type: string, data: unknown) => {
+        send(`event: ${type}\ndata: ${JSON.stringify(data)}\n\n`);
+      };
+      const close = () => {
+        if (closed) return;
+        closed = true;
+        clearInterval(timer);
+        controller.close();
+      };
+
+      const poll = async () => {
+        if (closed) return;
+        let workflows;
+        try {
+          const current = await fetchQuery(api.tuiAuth.session, { tokenHash }, { token });
+          if (!current) {
+            close();
+            return;
+          }
+          workflows = await fetchQuery(api.workflows.list, {}, { token });
+        } catch (error) {
+          if (isUnauthorizedError(error)) {
+            close();
+            return;
+          }
+          console.error("[tui/events] failed to poll workflows", error);
+          return;
+        }
+
+        const next = new Map(workflows.map((workflow) => [workflow._id as string, toTuiWorkflow(workflow)]));
+        if (known) {
+          for (const [id, workflow] of next) {
+            const previous = known.get(id);
+            if (!previous) {
+              sendEvent("workflow.created", workflow);
+            } else if (!sameWorkflow(previous, workflow)) {
+              sendEvent("workflow.updated", workflow);
+            }
+          }
+          for (const id of known.keys()) {
+            if (!next.has(id)) sendEvent("workflow.deleted", { id });
+          }
+        }
+        known = next;
+
+        if (Date.now() - lastSentAt >= KEEP_ALIVE_MS) send(": keep-alive\n\n");
+      };
+
+      send("retry: 5000\n\n");
+      await poll();
+      timer = setInterval(() => void poll(), POLL_INTERVAL_MS);
+      request.signal.addEventListener("abort", close);
+    },
+    cancel() {
+      closed = true;
+      clearInterval(timer);
+    },
+  });
+
+  return new Response(stream, {
+    status: 200,
+    headers: {
+      "Content-Type": "text/event-stream; charset=utf-8",
+      "Cache-Control": "no-cache, no-transform",
+      Connection: "keep-alive",
+      "X-Accel-Buffering": "no",
+    },
+  });
+}
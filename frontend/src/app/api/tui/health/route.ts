@@ -0,0 +1,10 @@
+import { NextResponse } from "next/server";
+import packageJson from "../../../../../package.json";
+
+// Unauthenticated liveness check for `tui doctor` and uptime probes.
+export async function GET() {
+  return NextResponse.json(
+    { status: "ok", version: packageJson.version },
+    { status: 200, headers: { "Cache-Control": "no-store" } }
+  );
+}
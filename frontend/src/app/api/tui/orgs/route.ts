@@ -0,0 +1,12 @@
+import { NextRequest, NextResponse } from "next/server";
+import { authorizeTuiRequest, sessionOrganizations } from "@/lib/tui-api";
+
+export async function GET(request: NextRequest) {
+  const session = await authorizeTuiRequest(request);
+  if (session instanceof NextResponse) return session;
+
+  return NextResponse.json(
+    { organizations: sessionOrganizations(session) },
+    { status: 200, headers: { "Cache-Control": "no-store" } }
+  );
+}
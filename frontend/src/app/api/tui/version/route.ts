@@ -0,0 +1,14 @@
+import { NextResponse } from "next/server";
+import { minTuiClientVersion, TUI_API_VERSION, TUI_CAPABILITIES } from "@/lib/tui-api";
+
+// Unauthenticated: the TUI checks compatibility before it has a session.
+export async function GET() {
+  return NextResponse.json(
+    {
+      apiVersion: TUI_API_VERSION,
+      minClientVersion: minTuiClientVersion(),
+      capabilities: TUI_CAPABILITIES,
+    },
+    { status: 200, headers: { "Cache-Control": "no-store" } }
+  );
+}
@@ -2,40 +2,20 @@ import { fetchQuery } from "convex/nextjs";
 import { NextRequest, NextResponse } from "next/server";
 import { Id } from "../../../../../../../convex/_generated/dataModel";
 import { api } from "../../../../../../../convex/_generated/api";
-
-function getBearerToken(request: NextRequest): string | null {
-  const header = request.headers.get("authorization");
-  if (!header) return null;
-
-  const [scheme, token] = header.split(" ");
-  if (scheme !== "Bearer" || !token) return null;
-
-  return token.trim();
-}
-
-function isUnauthorizedError(error: unknown): boolean {
-  if (!(error instanceof Error)) return false;
-  const message = error.message.toLowerCase();
-  return (
-    message.includes("unauth") ||
-    message.includes("not authenticated") ||
-    message.includes("invalid token")
-  );
-}
-
-function isNotFoundError(error: unknown): boolean {
-  if (!(error instanceof Error)) return false;
-  return error.message.toLowerCase().includes("not found");
-}
+import {
+  authorizeTuiRequest,
+  bundleDownloadUrl,
+  isNotFoundError,
+  isUnauthorizedError,
+} from "@/lib/tui-api";
 
 export async function GET(
   request: NextRequest,
   context: { params: { id: string } | Promise<{ id: string }> }
 ) {
-  const token = getBearerToken(request);
-  if (!token) {
-    return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
-  }
+  const session = await authorizeTuiRequest(request);
+  if (session instanceof NextResponse) return session;
+  const { token } = session;
 
   const resolvedParams = await Promise.resolve(context.params);
   let id = resolvedParams?.id?.trim() ?? "";
@@ -51,10 +31,12 @@ export async function GET(
     return NextResponse.json({ error: "Workflow id is required" }, { status: 400 });
   }
 
+  const version = request.nextUrl.searchParams.get("version")?.trim() || undefined;
+
   try {
     const artifact = await fetchQuery(
       api.workflows.getCompiledArtifactForTui,
-      { id: id as Id<"workflows"> },
+      { id: id as Id<"workflows">, version },
       { token }
     );
 
@@ -67,9 +49,15 @@ export async function GET(
 
     return NextResponse.json(
       {
-        downloadUrl: artifact.downloadUrl,
+        downloadUrl: bundleDownloadUrl(
+          request.nextUrl.origin,
+          artifact.storageId,
+          artifact.downloadUrl,
+          artifact.fileName
+        ),
         fileName: artifact.fileName,
         compilerVersion: artifact.compilerVersion,
+        sha256: artifact.sha256,
       },
       {
         status: 200,
@@ -0,0 +1,44 @@
+import { fetchQuery } from "convex/nextjs";
+import { NextRequest, NextResponse } from "next/server";
+import { Id } from "../../../../../../../convex/_generated/dataModel";
+import { api } from "../../../../../../../convex/_generated/api";
+import { authorizeTuiRequest, isNotFoundError, isUnauthorizedError } from "@/lib/tui-api";
+
+// Lists the compiled bundles kept for the workflow, newest first. Each id can
+// be passed as ?version= to the bundle route.
+export async function GET(
+  request: NextRequest,
+  context: { params: { id: string } | Promise<{ id: string }> }
+) {
+  const session = await authorizeTuiRequest(request);
+  if (session instanceof NextResponse) return session;
+  const { token } = session;
+
+  const resolvedParams = await Promise.resolve(context.params);
+  const id = resolvedParams?.id?.trim() ?? "";
+  if (!id) {
+    return NextResponse.json({ error: "Workflow id is required" }, { status: 400 });
+  }
+
+  try {
+    const versions = await fetchQuery(
+      api.workflows.listBundleVersions,
+      { id: id as Id<"workflows"> },
+      { token }
+    );
+    return NextResponse.json(
+      { versions },
+      { status: 200, headers: { "Cache-Control": "no-store" } }
+    );
+  } catch (error) {
+    if (isUnauthorizedError(error)) {
+      return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+    }
+    if (isNotFoundError(error)) {
+      return NextResponse.json({ error: "Workflow not found", code: "workflow_not_found" }, { status: 404 });
+    }
+
+    console.error("[tui/workflows/:id/bundles] failed to list bundle versions", error);
+    return NextResponse.json({ error: "Failed to list bundle versions" }, { status: 500 });
+  }
+}
@@ -0,0 +1,152 @@
+import { createHash } from "crypto";
+import { fetchMutation, fetchQuery } from "convex/nextjs";
+import { NextRequest, NextResponse } from "next/server";
+import { Id } from "../../../../../../../convex/_generated/dataModel";
+import { api } from "../../../../../../../convex/_generated/api";
+import {
+  authorizeTuiRequest,
+  CONFIG_TARGETS,
+  isNotFoundError,
+  isUnauthorizedError,
+  parseTargetConfigs,
+  withIdempotency,
+} from "@/lib/tui-api";
+import { buildWorkflowInput } from "@/lib/compiler/build-workflow-input";
+import { buildCompiledZipBytes, getCompiledZipFileName } from "@/lib/compiler/download-compiled-zip";
+import { compileWorkflowOnServer, getServerCompilerVersion } from "@/lib/compiler/server-compiler";
+import { toReactFlowNodes } from "@/lib/workflow-convert";
+import { sanitizeGlobalConfig } from "@/lib/workflow-global-config";
+import { cloneGlobalConfig, DEFAULT_WORKFLOW_GLOBAL_CONFIG } from "@/lib/workflow-defaults";
+
+export const runtime = "nodejs";
+
+// Compiles the stored workflow with the same WASM compiler the editor uses and
+// uploads the bundle like the editor's Compile button. The workflow shows as
+// "compiling" meanwhile and "compile_failed" afterwards if the compiler
+// rejected it; a compile already in flight answers 409.
+export async function POST(
+  request: NextRequest,
+  context: { params: { id: string } | Promise<{ id: string }> }
+) {
+  const session = await authorizeTuiRequest(request);
+  if (session instanceof NextResponse) return session;
+
+  const resolvedParams = await Promise.resolve(context.params);
+  const id = resolvedParams?.id?.trim() ?? "";
+  if (!id) {
+    return NextResponse.json({ error: "Workflow id is required" }, { status: 400 });
+  }
+
+  return withIdempotency(request, session, `workflows/${id}/compile`, () =>
+    compileWorkflow(session.token, id as Id<"workflows">)
+  );
+}
+
+async function compileWorkflow(token: string, workflowId: Id<"workflows">): Promise<NextResponse> {
+  let started = false;
+  try {
+    const workflow = await fetchQuery(api.workflows.load, { id: workflowId }, { token });
+    if (!workflow) {
+      return NextResponse.json({ error: "Workflow not found", code: "workflow_not_found" }, { status: 404 });
+    }
+
+    started = await fetchMutation(api.workflows.startCompile, { id: workflowId }, { token });
+    if (!started) {
+      return NextResponse.json(
+        { error: "A compile of this workflow is already running", code: "compile_in_progress" },
+        { status: 409 }
+      );
+    }
+
+    let globalConfig = cloneGlobalConfig(DEFAULT_WORKFLOW_GLOBAL_CONFIG);
+    try {
+      if (workflow.globalConfig) globalConfig = sanitizeGlobalConfig(JSON.parse(workflow.globalConfig));
+    } catch {
+      // Keep the defaults, as the editor does.
+    }
+    const input = buildWorkflowInput({
+      workflowId: workflow._id,
+      workflowName: workflow.name,
+      workflowCreatedAt: new Date(workflow._creationTime).toISOString(),
+      workflowGlobalConfig: globalConfig,
+      nodes: toReactFlowNodes(JSON.parse(workflow.nodes)),
+      edges: JSON.parse(workflow.edges),
+    });
+
+    const result = await compileWorkflowOnServer(JSON.stringify(input));
+    if (result.status === "errors") {
+      const message = `Compile failed with ${result.errors.length} error(s)`;
+      await fetchMutation(api.workflows.failCompile, { id: workflowId, error: message }, { token });
+      started = false;
+      return NextResponse.json(
+        { error: message, code: "compile_failed", errors: result.errors },
+        { status: 422 }
+      );
+    }
+
+    // Configs uploaded from a synced project replace the generated ones.
+    const targetConfigs = parseTargetConfigs(workflow.targetConfigs);
+    const files = result.files.filter(
+      (file) => !CONFIG_TARGETS.some((target) => targetConfigs[target] !== undefined && file.path === `config.${target}.json`)
+    );
+    for (const target of CONFIG_TARGETS) {
+      if (targetConfigs[target] !== undefined) {
+        files.push({ path: `config.${target}.json`, content: `${JSON.stringify(targetConfigs[target], null, 2)}\n` });
+      }
+    }
+
+    const zip = await buildCompiledZipBytes(files);
+    const uploadUrl = await fetchMutation(api.workflows.generateCompileUploadUrl, {}, { token });
+    const uploadResponse = await fetch(uploadUrl, {
+      method: "POST",
+      headers: { "Content-Type": "application/zip" },
+      body: zip,
+    });
+    if (!uploadResponse.ok) {
+      throw new Error(`Storage upload failed (${uploadResponse.status})`);
+    }
+    const uploadPayload = (await uploadResponse.json()) as { storageId?: string };
+    if (!uploadPayload.storageId) {
+      throw new Error("Storage upload returned no storageId");
+    }
+
+    const compilerVersion = await getServerCompilerVersion();
+    await fetchMutation(
+      api.workflows.saveCompiledArtifact,
+      {
+        id: workflowId,
+        storageId: uploadPayload.storageId as Id<"_storage">,
+        fileName: getCompiledZipFileName(workflow.name),
+        fileSize: zip.byteLength,
+        fileCount: files.length,
+        compilerVersion,
+        compiledAt: Date.now(),
+        sha256: createHash("sha256").update(zip).digest("hex"),
+      },
+      { token }
+    );
+    started = false;
+
+    return NextResponse.json(
+      { ok: true, status: "ready", compilerVersion, fileCount: files.length },
+      { status: 200 }
+    );
+  } catch (error) {
+    if (started) {
+      const message = error instanceof Error ? error.message : "Unknown error";
+      await fetchMutation(api.workflows.failCompile, { id: workflowId, error: message }, { token }).catch(
+        () => undefined
+      );
+    }
+    if (isUnauthorizedError(error)) {
+      return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+    }
+    if (isNotFoundError(error)) {
+      return NextResponse.json({ error: "Workflow not found", code: "workflow_not_found" }, { status: 404 });
+    }
+
+    const detail = error instanceof Error ? error.message : "Unknown error";
+    console.error("[tui/workflows/:id/compile] failed to compile workflow", error);
+    return NextResponse.json({ error: "Failed to compile workflow", detail }, { status: 500 });
+  }
+}
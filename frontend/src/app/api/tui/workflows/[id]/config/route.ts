@@ -0,0 +1,151 @@
+import JSZip from "jszip";
+import { fetchMutation, fetchQuery } from "convex/nextjs";
+import { NextRequest, NextResponse } from "next/server";
+import { Id } from "../../../../../../../convex/_generated/dataModel";
+import { api } from "../../../../../../../convex/_generated/api";
+import {
+  authorizeTuiRequest,
+  CONFIG_TARGETS,
+  ConfigTarget,
+  isNotFoundError,
+  isUnauthorizedError,
+  parseTargetConfigs,
+  readJsonBody,
+} from "@/lib/tui-api";
+
+const MAX_CONFIG_BYTES = 64 * 1024;
+
+async function resolveWorkflowId(
+  context: { params: { id: string } | Promise<{ id: string }> }
+): Promise<string> {
+  const resolvedParams = await Promise.resolve(context.params);
+  return resolvedParams?.id?.trim() ?? "";
+}
+
+// bundledConfigs reads the config.<target>.json files of the current compiled
+// bundle, for targets that have no uploaded config.
+async function bundledConfigs(downloadUrl: string): Promise<Partial<Record<ConfigTarget, unknown>>> {
+  const response = await fetch(downloadUrl);
+  if (!response.ok) return {};
+  const zip = await JSZip.loadAsync(await response.arrayBuffer());
+  const configs: Partial<Record<ConfigTarget, unknown>> = {};
+  for (const target of CONFIG_TARGETS) {
+    const entry = zip.file(new RegExp(`(^|/)config\\.${target}\\.json$`))[0];
+    if (!entry) continue;
+    try {
+      configs[target] = JSON.parse(await entry.async("string"));
+    } catch {
+      // A bundle config that is not JSON is reported as missing.
+    }
+  }
+  return configs;
+}
+
+export async function GET(
+  request: NextRequest,
+  context: { params: { id: string } | Promise<{ id: string }> }
+) {
+  const session = await authorizeTuiRequest(request);
+  if (session instanceof NextResponse) return session;
+  const { token } = session;
+
+  const id = await resolveWorkflowId(context);
+  if (!id) {
+    return NextResponse.json({ error: "Workflow id is required" }, { status: 400 });
+  }
+
+  try {
+    const workflow = await fetchQuery(api.workflows.load, { id: id as Id<"workflows"> }, { token });
+    if (!workflow) {
+      return NextResponse.json({ error: "Workflow not found", code: "workflow_not_found" }, { status: 404 });
+    }
+
+    const configs = parseTargetConfigs(workflow.targetConfigs);
+    if (CONFIG_TARGETS.some((target) => configs[target] === undefined)) {
+      const artifact = await fetchQuery(
+        api.workflows.getCompiledArtifactForTui,
+        { id: workflow._id },
+        { token }
+      );
+      if (artifact) {
+        const bundled = await bundledConfigs(artifact.downloadUrl).catch(
+          (): Partial<Record<ConfigTarget, unknown>> => ({})
+        );
+        for (const target of CONFIG_TARGETS) {
+          configs[target] ??= bundled[target];
+        }
+      }
+    }
+
+    return NextResponse.json(
+      {
+        configs: Object.fromEntries(CONFIG_TARGETS.map((target) => [target, configs[target] ?? null])),
+      },
+      { status: 200, headers: { "Cache-Control": "no-store" } }
+    );
+  } catch (error) {
+    if (isUnauthorizedError(error)) {
+      return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+    }
+    if (isNotFoundError(error)) {
+      return NextResponse.json({ error: "Workflow not found", code: "workflow_not_found" }, { status: 404 });
+    }
+
+    console.error("[tui/workflows/:id/config] failed to load configs", error);
+    return NextResponse.json({ error: "Failed to load workflow config" }, { status: 500 });
+  }
+}
+
+// Replaces the config of the targets in the body; the others keep their
+// current value. Server-side compiles write them into the bundle.
+export async function PUT(
+  request: NextRequest,
+  context: { params: { id: string } | Promise<{ id: string }> }
+) {
+  const session = await authorizeTuiRequest(request);
+  if (session instanceof NextResponse) return session;
+  const { token } = session;
+
+  const id = await resolveWorkflowId(context);
+  if (!id) {
+    return NextResponse.json({ error: "Workflow id is required" }, { status: 400 });
+  }
+
+  const body = await readJsonBody<{ configs?: Record<string, unknown> }>(request);
+  if (!body || !body.configs || typeof body.configs !== "object" || Array.isArray(body.configs)) {
+    return NextResponse.json({ error: "configs is required" }, { status: 400 });
+  }
+  for (const [name, value] of Object.entries(body.configs)) {
+    if (!CONFIG_TARGETS.includes(name as ConfigTarget)) {
+      return NextResponse.json({ error: `Unknown config target: ${name}` }, { status: 400 });
+    }
+    if (!value || typeof value !== "object" || Array.isArray(value)) {
+      return NextResponse.json({ error: `The ${name} config must be a JSON object` }, { status: 400 });
+    }
+  }
+
+  try {
+    const workflow = await fetchQuery(api.workflows.load, { id: id as Id<"workflows"> }, { token });
+    if (!workflow) {
+      return NextResponse.json({ error: "Workflow not found", code: "workflow_not_found" }, { status: 404 });
+    }
+
+    const targetConfigs = JSON.stringify({ ...parseTargetConfigs(workflow.targetConfigs), ...body.configs });
+    if (targetConfigs.length > MAX_CONFIG_BYTES) {
+      return NextResponse.json({ error: "Workflow config is too large" }, { status: 413 });
+    }
+    await fetchMutation(api.workflows.saveTargetConfigs, { id: workflow._id, targetConfigs }, { token });
+
+    return NextResponse.json({ ok: true, targets: Object.keys(body.configs) }, { status: 200 });
+  } catch (error) {
+    if (isUnauthorizedError(error)) {
+      return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+    }
+    if (isNotFoundError(error)) {
+      return NextResponse.json({ error: "Workflow not found", code: "workflow_not_found" }, { status: 404 });
+    }
+
+    console.error("[tui/workflows/:id/config] failed to save configs", error);
+    return NextResponse.json({ error: "Failed to save workflow config" }, { status: 500 });
+  }
+}
@@ -0,0 +1,86 @@
+import { fetchQuery } from "convex/nextjs";
+import { NextRequest, NextResponse } from "next/server";
+import { NODE_TYPE_TO_CATEGORY, NodeType } from "@6flow/shared/model/node";
+import { Id } from "../../../../../../convex/_generated/dataModel";
+import { api } from "../../../../../../convex/_generated/api";
+import {
+  authorizeTuiRequest,
+  isNotFoundError,
+  isUnauthorizedError,
+  parseGlobalConfig,
+  toTuiWorkflow,
+} from "@/lib/tui-api";
+
+interface TuiWorkflowNodeDto {
+  id: string;
+  type: string;
+  label: string;
+}
+
+function parseNodes(nodesJson: string): TuiWorkflowNodeDto[] {
+  try {
+    const parsed = JSON.parse(nodesJson) as unknown;
+    if (!Array.isArray(parsed)) return [];
+    return parsed
+      .filter((node): node is { id: string; type: string; data?: { label?: unknown } } =>
+        Boolean(node && typeof node.id === "string" && typeof node.type === "string")
+      )
+      .map((node) => ({
+        id: node.id,
+        type: node.type,
+        label: typeof node.data?.label === "string" ? node.data.label : "",
+      }));
+  } catch {
+    return [];
+  }
+}
+
+export async function GET(
+  request: NextRequest,
+  context: { params: { id: string } | Promise<{ id: string }> }
+) {
+  const session = await authorizeTuiRequest(request);
+  if (session instanceof NextResponse) return session;
+  const { token } = session;
+
+  const resolvedParams = await Promise.resolve(context.params);
+  const id = resolvedParams?.id?.trim() ?? "";
+  if (!id) {
+    return NextResponse.json({ error: "Workflow id is required" }, { status: 400 });
+  }
+
+  try {
+    const workflow = await fetchQuery(api.workflows.load, { id: id as Id<"workflows"> }, { token });
+    if (!workflow) {
+      return NextResponse.json({ error: "Workflow not found", code: "workflow_not_found" }, { status: 404 });
+    }
+
+    const nodes = parseNodes(workflow.nodes);
+    const globalConfig = parseGlobalConfig(workflow.globalConfig);
+    return NextResponse.json(
+      {
+        workflow: {
+          ...toTuiWorkflow(workflow),
+          description: workflow.description ?? "",
+          nodes,
+          triggers: nodes
+            .filter((node) => NODE_TYPE_TO_CATEGORY[node.type as NodeType] === "trigger")
+            .map((node) => node.type),
+          secrets: globalConfig.secrets.map((secret) => secret.name),
+          configSchema: null,
+        },
+      },
+      { status: 200, headers: { "Cache-Control": "no-store" } }
+    );
+  } catch (error) {
+    if (isUnauthorizedError(error)) {
+      return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+    }
+    if (isNotFoundError(error)) {
+      return NextResponse.json({ error: "Workflow not found", code: "workflow_not_found" }, { status: 404 });
+    }
+
+    console.error("[tui/workflows/:id] failed to fetch workflow", error);
+    return NextResponse.json({ error: "Failed to load workflow" }, { status: 500 });
+  }
+}
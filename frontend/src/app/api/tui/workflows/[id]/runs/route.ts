@@ -0,0 +1,56 @@
+import { fetchQuery } from "convex/nextjs";
+import { NextRequest, NextResponse } from "next/server";
+import { Id } from "../../../../../../../convex/_generated/dataModel";
+import { api } from "../../../../../../../convex/_generated/api";
+import { authorizeTuiRequest, isNotFoundError, isUnauthorizedError } from "@/lib/tui-api";
+
+export async function GET(
+  request: NextRequest,
+  context: { params: { id: string } | Promise<{ id: string }> }
+) {
+  const session = await authorizeTuiRequest(request);
+  if (session instanceof NextResponse) return session;
+  const { token } = session;
+
+  const resolvedParams = await Promise.resolve(context.params);
+  const id = resolvedParams?.id?.trim() ?? "";
+  if (!id) {
+    return NextResponse.json({ error: "Workflow id is required" }, { status: 400 });
+  }
+
+  const limitParam = Number.parseInt(request.nextUrl.searchParams.get("limit") ?? "", 10);
+  const limit = Number.isFinite(limitParam) && limitParam > 0 ? limitParam : undefined;
+
+  try {
+    const runs = await fetchQuery(
+      api.workflowRuns.listForWorkflow,
+      { id: id as Id<"workflows">, limit },
+      { token }
+    );
+
+    return NextResponse.json(
+      {
+        runs: runs.map((run) => ({
+          id: run.runId,
+          status: run.status,
+          trigger: run.trigger ?? "",
+          startedAt: run.startedAt,
+          finishedAt: run.finishedAt ?? 0,
+          durationMs: run.finishedAt ? run.finishedAt - run.startedAt : 0,
+          error: run.error ?? "",
+        })),
+      },
+      { status: 200, headers: { "Cache-Control": "no-store" } }
+    );
+  } catch (error) {
+    if (isUnauthorizedError(error)) {
+      return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+    }
+    if (isNotFoundError(error)) {
+      return NextResponse.json({ error: "Workflow not found", code: "workflow_not_found" }, { status: 404 });
+    }
+
+    console.error("[tui/workflows/:id/runs] failed to fetch runs", error);
+    return NextResponse.json({ error: "Failed to load workflow runs" }, { status: 500 });
+  }
+}
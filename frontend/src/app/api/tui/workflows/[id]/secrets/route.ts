@@ -2,98 +2,67 @@ import { fetchMutation, fetchQuery } from "convex/nextjs";
 import { NextRequest, NextResponse } from "next/server";
 import { Id } from "../../../../../../../convex/_generated/dataModel";
 import { api } from "../../../../../../../convex/_generated/api";
+import {
+  authorizeTuiRequest,
+  isNotFoundError,
+  isUnauthorizedError,
+  parseGlobalConfig,
+  withIdempotency,
+} from "@/lib/tui-api";
+
+// Lists the secret names configured for the workflow; values are never
+// stored here.
+export async function GET(
+  request: NextRequest,
+  context: { params: { id: string } | Promise<{ id: string }> }
+) {
+  const session = await authorizeTuiRequest(request);
+  if (session instanceof NextResponse) return session;
+  const { token } = session;
 
-function getBearerToken(request: NextRequest): string | null {
-  const header = request.headers.get("authorization");
-  if (!header) return null;
-
-  const [scheme, token] = header.split(" ");
-  if (scheme !== "Bearer" || !token) return null;
-
-  return token.trim();
-}
-
-function isUnauthorizedError(error: unknown): boolean {
-  if (!(error instanceof Error)) return false;
-  const message = error.message.toLowerCase();
-  return (
-    message.includes("unauth") ||
-    message.includes("not authenticated") ||
-    message.includes("invalid token")
-  );
-}
-
-function isNotFoundError(error: unknown): boolean {
-  if (!(error instanceof Error)) return false;
-  return error.message.toLowerCase().includes("not found");
-}
+  const resolvedParams = await Promise.resolve(context.params);
+  const id = resolvedParams?.id?.trim() ?? "";
+  if (!id) {
+    return NextResponse.json({ error: "Workflow id is required" }, { status: 400 });
+  }
 
-interface SecretReference {
-  name: string;
-}
+  try {
+    const workflow = await fetchQuery(
+      api.workflows.load,
+      { id: id as Id<"workflows"> },
+      { token }
+    );
+    if (!workflow) {
+      return NextResponse.json({ error: "Workflow not found", code: "workflow_not_found" }, { status: 404 });
+    }
 
-interface WorkflowGlobalConfig {
-  isTestnet: boolean;
-  defaultChainSelector: string;
-  secrets: SecretReference[];
-  rpcs: Array<{ chainName: string; url: string }>;
-}
+    const { secrets } = parseGlobalConfig(workflow.globalConfig);
+    return NextResponse.json(
+      { secrets: secrets.map((secret) => secret.name) },
+      { status: 200, headers: { "Cache-Control": "no-store" } }
+    );
+  } catch (error) {
+    if (isUnauthorizedError(error)) {
+      return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+    }
+    if (isNotFoundError(error)) {
+      return NextResponse.json({ error: "Workflow not found", code: "workflow_not_found" }, { status: 404 });
+    }
 
-function parseGlobalConfig(raw: string | undefined): WorkflowGlobalConfig {
-  if (!raw) {
-    return {
-      isTestnet: true,
-      defaultChainSelector: "ethereum-testnet-sepolia",
-      secrets: [],
-      rpcs: [],
-    };
-  }
-  try {
-    const parsed = JSON.parse(raw) as Partial<WorkflowGlobalConfig>;
-    const secrets = Array.isArray(parsed.secrets)
-      ? parsed.secrets
-          .filter((s): s is SecretReference => Boolean(s && typeof s.name === "string"))
-          .map((s) => ({ name: s.name.trim() }))
-          .filter((s) => s.name.length > 0)
-      : [];
-    const rpcs = Array.isArray(parsed.rpcs)
-      ? parsed.rpcs.filter(
-          (r): r is { chainName: string; url: string } =>
-            Boolean(
-              r &&
-                typeof r.chainName === "string" &&
-                typeof r.url === "string"
-            )
-        )
-      : [];
-    return {
-      isTestnet: typeof parsed.isTestnet === "boolean" ? parsed.isTestnet : true,
-      defaultChainSelector:
-        typeof parsed.defaultChainSelector === "string" &&
-        parsed.defaultChainSelector.trim().length > 0
-          ? parsed.defaultChainSelector
-          : "ethereum-testnet-sepolia",
-      secrets,
-      rpcs,
-    };
-  } catch {
-    return {
-      isTestnet: true,
-      defaultChainSelector: "ethereum-testnet-sepolia",
-      secrets: [],
-      rpcs: [],
-    };
+    console.error("[tui/workflows/:id/secrets] failed to list secrets", error);
+    return NextResponse.json({ error: "Failed to load workflow secrets" }, { status: 500 });
   }
 }
 
+// Adds or removes a secret name. Adding a name that exists answers 409
+// secret_exists and removing a missing one 404 secret_not_found, so a retried
+// request whose first attempt was applied can tell it succeeded.
 export async function POST(
   request: NextRequest,
   context: { params: { id: string } | Promise<{ id: string }> }
 ) {
-  const token = getBearerToken(request);
-  if (!token) {
-    return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
-  }
+  const session = await authorizeTuiRequest(request);
+  if (session instanceof NextResponse) return session;
 
   const resolvedParams = await Promise.resolve(context.params);
   const id = resolvedParams?.id?.trim() ?? "";
@@ -101,6 +70,12 @@ export async function POST(
     return NextResponse.json({ error: "Workflow id is required" }, { status: 400 });
   }
 
+  return withIdempotency(request, session, `workflows/${id}/secrets`, () =>
+    updateSecret(request, session.token, id)
+  );
+}
+
+async function updateSecret(request: NextRequest, token: string, id: string): Promise<NextResponse> {
   let body: { action?: string; secretName?: string };
   try {
     body = (await request.json()) as { action?: string; secretName?: string };
@@ -124,14 +99,20 @@ export async function POST(
       { token }
     );
     if (!workflow) {
-      return NextResponse.json({ error: "Workflow not found" }, { status: 404 });
+      return NextResponse.json({ error: "Workflow not found", code: "workflow_not_found" }, { status: 404 });
     }
 
     const globalConfig = parseGlobalConfig(workflow.globalConfig);
     const secretSet = new Set(globalConfig.secrets.map((s) => s.name));
     if (action === "add") {
+      if (secretSet.has(secretName)) {
+        return NextResponse.json({ error: "Secret already exists", code: "secret_exists" }, { status: 409 });
+      }
       secretSet.add(secretName);
     } else {
+      if (!secretSet.has(secretName)) {
+        return NextResponse.json({ error: "Secret not found", code: "secret_not_found" }, { status: 404 });
+      }
       secretSet.delete(secretName);
     }
 
@@ -161,7 +142,7 @@ export async function POST(
       return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
     }
     if (isNotFoundError(error)) {
-      return NextResponse.json({ error: "Workflow not found" }, { status: 404 });
+      return NextResponse.json({ error: "Workflow not found", code: "workflow_not_found" }, { status: 404 });
     }
 
     const detail = error instanceof Error ? error.message : "Unknown error";
@@ -1,64 +1,111 @@
+import { createHash } from "crypto";
 import { fetchQuery } from "convex/nextjs";
 import { NextRequest, NextResponse } from "next/server";
 import { api } from "../../../../../convex/_generated/api";
+import { authorizeTuiRequest, isUnauthorizedError, toTuiWorkflow, TuiWorkflowDto } from "@/lib/tui-api";
 
-interface TuiWorkflowDto {
-  id: string;
-  name: string;
+const MAX_PAGE_SIZE = 200;
+
+// Pages are ordered by updatedAt, newest first, with the id breaking ties. A
+// cursor names the last workflow of the previous page, so inserts and edits
+// between requests never repeat or skip a workflow that kept its position.
+interface WorkflowCursor {
   updatedAt: number;
-  nodeCount: number;
-  status: "ready" | "draft";
-  compilerVersion: string;
+  id: string;
 }
 
-function getBearerToken(request: NextRequest): string | null {
-  const header = request.headers.get("authorization");
-  if (!header) return null;
-
-  const [scheme, token] = header.split(" ");
-  if (scheme !== "Bearer" || !token) return null;
-
-  return token.trim();
+function encodeCursor(workflow: TuiWorkflowDto): string {
+  return Buffer.from(JSON.stringify({ updatedAt: workflow.updatedAt, id: workflow.id })).toString("base64url");
 }
 
-function parseNodeCount(nodesJson: string): number {
+function decodeCursor(raw: string): WorkflowCursor | null {
   try {
-    const parsed = JSON.parse(nodesJson);
-    return Array.isArray(parsed) ? parsed.length : 0;
+    const parsed = JSON.parse(Buffer.from(raw, "base64url").toString("utf8")) as Partial<WorkflowCursor>;
+    if (typeof parsed.updatedAt !== "number" || typeof parsed.id !== "string") return null;
+    return { updatedAt: parsed.updatedAt, id: parsed.id };
   } catch {
-    return 0;
+    return null;
   }
 }
 
-function isUnauthorizedError(error: unknown): boolean {
-  if (!(error instanceof Error)) return false;
-  const message = error.message.toLowerCase();
-  return (
-    message.includes("unauth") ||
-    message.includes("not authenticated") ||
-    message.includes("invalid token")
-  );
+function compareWorkflows(a: { updatedAt: number; id: string }, b: { updatedAt: number; id: string }): number {
+  if (a.updatedAt !== b.updatedAt) return b.updatedAt - a.updatedAt;
+  return a.id < b.id ? 1 : a.id > b.id ? -1 : 0;
+}
+
+function matchesETag(ifNoneMatch: string | null, etag: string): boolean {
+  if (!ifNoneMatch) return false;
+  return ifNoneMatch
+    .split(",")
+    .map((candidate) => candidate.trim().replace(/^W\//, ""))
+    .some((candidate) => candidate === "*" || candidate === etag);
 }
 
 export async function GET(request: NextRequest) {
-  const token = getBearerToken(request);
-  if (!token) {
-    return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+  const session = await authorizeTuiRequest(request);
+  if (session instanceof NextResponse) return session;
+  const { token } = session;
+
+  const params = request.nextUrl.searchParams;
+  // Without a limit the whole list is returned, as before pagination.
+  const limitParam = params.get("limit");
+  const limit = limitParam === null ? undefined : Number.parseInt(limitParam, 10);
+  if (limit !== undefined && (!Number.isFinite(limit) || limit <= 0)) {
+    return NextResponse.json({ error: "limit must be a positive integer" }, { status: 400 });
+  }
+  // Filters apply before pagination, so cursors page through the filtered list.
+  const search = params.get("search")?.trim().toLowerCase() ?? "";
+  const status = params.get("status") ?? "";
+  if (status !== "" && status !== "ready" && status !== "draft") {
+    return NextResponse.json({ error: "status must be ready or draft" }, { status: 400 });
+  }
+  const updatedSinceParam = params.get("updatedSince");
+  const updatedSince = updatedSinceParam === null ? undefined : Number(updatedSinceParam);
+  if (updatedSince !== undefined && (!Number.isFinite(updatedSince) || updatedSince < 0)) {
+    return NextResponse.json({ error: "updatedSince must be a timestamp in milliseconds" }, { status: 400 });
+  }
+  const cursorParam = params.get("cursor");
+  const cursor = cursorParam ? decodeCursor(cursorParam) : null;
+  if (cursorParam && !cursor) {
+    return NextResponse.json({ error: "Invalid cursor", code: "invalid_cursor" }, { status: 400 });
   }
 
   try {
     const workflows = await fetchQuery(api.workflows.list, {}, { token });
 
-    const normalized: TuiWorkflowDto[] = workflows.map((workflow) => ({
-      id: workflow._id,
-      name: workflow.name,
-      updatedAt: workflow.updatedAt,
-      nodeCount: parseNodeCount(workflow.nodes),
-      status: workflow.compiledArtifactStorageId ? "ready" : "draft",
-      compilerVersion: workflow.compiledArtifactCompilerVersion ?? "",
-    }));
+    let normalized = workflows
+      .map(toTuiWorkflow)
+      .filter(
+        (workflow) =>
+          (search === "" || workflow.name.toLowerCase().includes(search)) &&
+          (status === "" || workflow.status === status) &&
+          (updatedSince === undefined || workflow.updatedAt >= updatedSince)
+      )
+      .sort(compareWorkflows);
+    if (cursor) {
+      normalized = normalized.filter((workflow) => compareWorkflows(cursor, workflow) < 0);
+    }
+    let nextCursor = "";
+    if (limit !== undefined) {
+      const pageSize = Math.min(limit, MAX_PAGE_SIZE);
+      if (normalized.length > pageSize) {
+        normalized = normalized.slice(0, pageSize);
+        nextCursor = encodeCursor(normalized[normalized.length - 1]);
+      }
+    }
 
-    return NextResponse.json({ workflows: normalized }, { status: 200 });
+    // The ETag covers the exact page, so a 304 is only sent when nothing the
+    // TUI would show has changed.
+    const body = JSON.stringify({ workflows: normalized, nextCursor });
+    const etag = `"${createHash("sha256").update(body).digest("base64url")}"`;
+    const headers = { ETag: etag, "Cache-Control": "private, no-cache" };
+    if (matchesETag(request.headers.get("if-none-match"), etag)) {
+      return new NextResponse(null, { status: 304, headers });
+    }
+    return new NextResponse(body, {
+      status: 200,
+      headers: { ...headers, "Content-Type": "application/json" },
+    });
   } catch (error) {
     if (isUnauthorizedError(error)) {
       return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
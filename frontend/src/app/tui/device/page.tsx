@@ -0,0 +1,149 @@
+"use client";
+
+import { useAuthActions, useAuthToken } from "@convex-dev/auth/react";
+import { useConvexAuth } from "convex/react";
+import { Suspense, useEffect, useRef, useState } from "react";
+import { useSearchParams } from "next/navigation";
+
+type DeviceState = "loading" | "authenticating" | "confirm" | "sending" | "success" | "error";
+
+function normalizeUserCode(value: string): string {
+  const letters = value.toUpperCase().replace(/[^A-Z]/g, "").slice(0, 8);
+  return letters.length > 4 ? `${letters.slice(0, 4)}-${letters.slice(4)}` : letters;
+}
+
+function TuiDeviceContent() {
+  const params = useSearchParams();
+  const [userCode, setUserCode] = useState(() => normalizeUserCode(params.get("user_code") ?? ""));
+
+  const { isLoading, isAuthenticated } = useConvexAuth();
+  const token = useAuthToken();
+  const { signIn } = useAuthActions();
+
+  const [state, setState] = useState<DeviceState>("loading");
+  const [message, setMessage] = useState("Preparing TUI authentication...");
+  const startedSignIn = useRef(false);
+  const confirmShown = useRef(false);
+
+  useEffect(() => {
+    if (isLoading) {
+      setState("loading");
+      setMessage("Checking authentication...");
+      return;
+    }
+
+    if (!isAuthenticated) {
+      setState("authenticating");
+      setMessage("Redirecting to GitHub sign-in...");
+      if (!startedSignIn.current) {
+        startedSignIn.current = true;
+        void signIn("github", { redirectTo: window.location.href }).catch((error) => {
+          setState("error");
+          setMessage(error instanceof Error ? error.message : "Failed to start sign-in");
+        });
+      }
+      return;
+    }
+
+    if (!token) {
+      setState("loading");
+      setMessage("Finalizing authenticated session...");
+      return;
+    }
+
+    if (confirmShown.current) return;
+    confirmShown.current = true;
+    setState("confirm");
+    setMessage("Check that this code matches the one in your terminal.");
+  }, [isAuthenticated, isLoading, signIn, token]);
+
+  const decide = (approve: boolean) => {
+    if (!token) return;
+    setState("sending");
+    setMessage(approve ? "Linking the TUI..." : "Denying the login...");
+    void fetch("/api/tui/device/approve", {
+      method: "POST",
+      headers: {
+        "Content-Type": "application/json",
+        Authorization: `Bearer ${token}`,
+      },
+      body: JSON.stringify({ userCode, approve }),
+    })
+      .then(async (response) => {
+        if (!response.ok) {
+          let errorMessage = `Request failed (${response.status})`;
+          try {
+            const payload = (await response.json()) as { error?: string };
+            if (payload.error) errorMessage = payload.error;
+          } catch {
+            // Ignore parse errors.
+          }
+          throw new Error(errorMessage);
+        }
+        setState("success");
+        setMessage(
+          approve
+            ? "TUI linked successfully. You can return to the terminal."
+            : "Login denied. You can close this tab."
+        );
+      })
+      .catch((error) => {
+        setState("error");
+        setMessage(error instanceof Error ? error.message : "Failed to link TUI");
+      });
+  };
+
+  const codeComplete = /^[A-Z]{4}-[A-Z]{4}$/.test(userCode);
+
+  return (
+    <div className="min-h-screen bg-surface-0 flex items-center justify-center p-6">
+      <div className="w-full max-w-md rounded-xl border border-edge-dim bg-surface-1 p-6 space-y-4">
+        <h1 className="text-zinc-100 text-lg font-semibold tracking-tight">TUI Device Login</h1>
+        <p className="text-zinc-400 text-sm">{message}</p>
+        {(state === "confirm" || state === "error") && (
+          <div className="space-y-3">
+            <input
+              value={userCode}
+              onChange={(event) => {
+                setUserCode(normalizeUserCode(event.target.value));
+                if (state === "error") setState("confirm");
+              }}
+              placeholder="XXXX-XXXX"
+              aria-label="Device code"
+              className="w-full rounded-md border border-edge-dim bg-surface-0 px-3 py-2 font-mono text-lg tracking-widest text-zinc-100"
+            />
+            <div className="flex gap-2">
+              <button
+                type="button"
+                disabled={!codeComplete || !token}
+                onClick={() => decide(true)}
+                className="rounded-md bg-zinc-100 px-3 py-1.5 text-sm font-medium text-zinc-900 disabled:opacity-50"
+              >
+                Approve
+              </button>
+              <button
+                type="button"
+                disabled={!codeComplete || !token}
+                onClick={() => decide(false)}
+                className="rounded-md border border-edge-dim px-3 py-1.5 text-sm text-zinc-300 disabled:opacity-50"
+              >
+                Deny
+              </button>
+            </div>
+          </div>
+        )}
+        <div className="text-xs text-zinc-500">
+          State: <span className="text-zinc-300">{state}</span>
+        </div>
+      </div>
+    </div>
+  );
+}
+
+export default function TuiDevicePage() {
+  return (
+    <Suspense>
+      <TuiDeviceContent />
+    </Suspense>
+  );
+}
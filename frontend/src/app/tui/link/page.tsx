@@ -18,6 +18,13 @@ function TuiLinkContent() {
   const params = useSearchParams();
   const callback = params.get("callback");
   const nonce = params.get("nonce");
+  // TUIs with PKCE send a code challenge; the page then hands them a one-time
+  // code instead of the session token.
+  const codeChallenge = params.get("code_challenge");
+  const codeChallengeMethod = params.get("code_challenge_method") ?? "S256";
+  // Shown in the terminal too, so the user can tell they are linking the
+  // session they started.
+  const pairingCode = params.get("pairing_code");
 
   const { isLoading, isAuthenticated } = useConvexAuth();
   const token = useAuthToken();
@@ -80,13 +87,42 @@ function TuiLinkContent() {
     setState("sending");
     setMessage("Sending token back to TUI...");
 
-    void fetch(callbackUrl.toString(), {
-      method: "POST",
-      headers: {
-        "Content-Type": "application/json",
-      },
-      body: JSON.stringify({ token, nonce }),
-    })
+    const callbackBody = async (): Promise<Record<string, string | null>> => {
+      if (!codeChallenge) {
+        return { token, nonce };
+      }
+      const response = await fetch("/api/tui/auth/code", {
+        method: "POST",
+        headers: {
+          "Content-Type": "application/json",
+          Authorization: `Bearer ${token}`,
+        },
+        body: JSON.stringify({
+          codeChallenge,
+          codeChallengeMethod,
+          redirectUri: callback,
+        }),
+      });
+      const payload = (await response.json().catch(() => ({}))) as {
+        code?: string;
+        error?: string;
+      };
+      if (!response.ok || !payload.code) {
+        throw new Error(payload.error ?? `Could not issue a login code (${response.status})`);
+      }
+      return { code: payload.code, nonce };
+    };
+
+    void callbackBody()
+      .then((body) =>
+        fetch(callbackUrl.toString(), {
+          method: "POST",
+          headers: {
+            "Content-Type": "application/json",
+          },
+          body: JSON.stringify(body),
+        })
+      )
       .then(async (response) => {
         if (!response.ok) {
           let errorMessage = `Callback failed (${response.status})`;
@@ -105,13 +141,29 @@ function TuiLinkContent() {
         setState("error");
         setMessage(error instanceof Error ? error.message : "Failed to link TUI");
       });
-  }, [callbackUrl, isAuthenticated, isLoading, nonce, signIn, token]);
+  }, [
+    callback,
+    callbackUrl,
+    codeChallenge,
+    codeChallengeMethod,
+    isAuthenticated,
+    isLoading,
+    nonce,
+    signIn,
+    token,
+  ]);
 
   return (
     <div className="min-h-screen bg-surface-0 flex items-center justify-center p-6">
       <div className="w-full max-w-md rounded-xl border border-edge-dim bg-surface-1 p-6 space-y-4">
         <h1 className="text-zinc-100 text-lg font-semibold tracking-tight">TUI Link</h1>
         <p className="text-zinc-400 text-sm">{message}</p>
+        {pairingCode && (
+          <p className="text-zinc-400 text-sm">
+            Pairing code: <span className="font-mono text-zinc-100 tracking-widest">{pairingCode}</span>. Check that
+            it matches the one in your terminal.
+          </p>
+        )}
         <div className="text-xs text-zinc-500">
           State: <span className="text-zinc-300">{state}</span>
         </div>
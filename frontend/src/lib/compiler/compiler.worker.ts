@@ -4,12 +4,7 @@ import type {
   CompilerWorkerRequestUnion,
   CompilerWorkerResponse,
 } from "./worker-messages";
-import type {
-  CompileWorkflowResult,
-  CompiledFile,
-  CompilerUiError,
-} from "./compiler-types";
-import { toCompilerUiError } from "./compiler-types";
+import { normalizeCompileResult, normalizeErrors } from "./normalize-compile-result";
 
 type CompilerWasmModule = {
   default?: (input?: unknown) => Promise<unknown>;
@@ -74,158 +69,6 @@ function toErrorMessage(error: unknown): string {
   return "Unknown worker error";
 }
 
-function normalizeError(raw: unknown): CompilerUiError {
-  if (!raw || typeof raw !== "object") {
-    return toCompilerUiError("Unknown compiler error payload");
-  }
-
-  const maybeError = raw as Partial<CompilerUiError>;
-  return {
-    code: typeof maybeError.code === "string" ? maybeError.code : "W000",
-    phase: typeof maybeError.phase === "string" ? maybeError.phase : "Worker",
-    message:
-      typeof maybeError.message === "string"
-        ? maybeError.message
-        : "Unknown compiler error",
-    node_id: typeof maybeError.node_id === "string" ? maybeError.node_id : null,
-  };
-}
-
-function normalizeErrors(raw: unknown): CompilerUiError[] {
-  if (Array.isArray(raw)) {
-    return raw.map(normalizeError);
-  }
-
-  if (raw && typeof raw === "object") {
-    const maybeObj = raw as Record<string, unknown>;
-    if (Array.isArray(maybeObj.errors)) {
-      return maybeObj.errors.map(normalizeError);
-    }
-  }
-
-  return [];
-}
-
-function normalizeFile(raw: unknown): CompiledFile | null {
-  if (!raw || typeof raw !== "object") {
-    return null;
-  }
-
-  const maybeFile = raw as Partial<CompiledFile>;
-  if (typeof maybeFile.path !== "string" || typeof maybeFile.content !== "string") {
-    return null;
-  }
-
-  return {
-    path: maybeFile.path,
-    content: maybeFile.content,
-  };
-}
-
-function extractArrayCandidates(raw: Record<string, unknown>): unknown[] {
-  const knownKeys = [
-    "files",
-    "errors",
-    "data",
-    "payload",
-    "success",
-    "value",
-    "0",
-  ];
-
-  const candidates: unknown[] = [];
-  for (const key of knownKeys) {
-    const value = raw[key];
-    if (Array.isArray(value)) {
-      candidates.push(value);
-    }
-  }
-
-  if (candidates.length > 0) {
-    return candidates;
-  }
-
-  for (const value of Object.values(raw)) {
-    if (Array.isArray(value)) {
-      candidates.push(value);
-    }
-  }
-
-  return candidates;
-}
-
-function normalizeCompileResult(raw: unknown): CompileWorkflowResult {
-  if (typeof raw === "string") {
-    try {
-      return normalizeCompileResult(JSON.parse(raw));
-    } catch {
-      return {
-        status: "errors",
-        errors: [toCompilerUiError("Compiler returned non-JSON string output", "W006")],
-      };
-    }
-  }
-
-  if (!raw || typeof raw !== "object") {
-    return {
-      status: "errors",
-      errors: [toCompilerUiError("Compiler returned an empty result", "W002")],
-    };
-  }
-
-  const obj = raw as Record<string, unknown>;
-  const status = typeof obj.status === "string" ? obj.status : null;
-
-  if (status === "success") {
-    const candidates = extractArrayCandidates(obj);
-    const files = candidates
-      .flatMap((value) => value)
-      .map(normalizeFile)
-      .filter((file): file is CompiledFile => file !== null);
-
-    if (files.length === 0) {
-      return {
-        status: "errors",
-        errors: [
-          toCompilerUiError(
-            "Compiler reported success but returned no files",
-            "W003"
-          ),
-        ],
-      };
-    }
-
-    return {
-      status: "success",
-      files,
-    };
-  }
-
-  if (status === "errors") {
-    const candidates = extractArrayCandidates(obj);
-    const errors = candidates
-      .flatMap((value) => value)
-      .map(normalizeError);
-
-    if (errors.length > 0) {
-      return {
-        status: "errors",
-        errors,
-      };
-    }
-
-    return {
-      status: "errors",
-      errors: [toCompilerUiError("Compiler returned an unknown error payload", "W004")],
-    };
-  }
-
-  return {
-    status: "errors",
-    errors: [toCompilerUiError("Compiler returned unsupported response format", "W005")],
-  };
-}
-
 async function handleRequest(request: CompilerWorkerRequestUnion): Promise<unknown> {
   const compiler = await ensureCompilerModule();
 
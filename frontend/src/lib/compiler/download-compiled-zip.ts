@@ -22,3 +22,13 @@ export async function buildCompiledZipBlob(files: CompiledFile[]): Promise<Blob>
 
   return zip.generateAsync({ type: "blob" });
 }
+
+export async function buildCompiledZipBytes(files: CompiledFile[]): Promise<Uint8Array> {
+  const zip = new JSZip();
+
+  for (const file of files) {
+    zip.file(file.path, file.content);
+  }
+
+  return zip.generateAsync({ type: "uint8array" });
+}
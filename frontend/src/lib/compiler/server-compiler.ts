@@ -0,0 +1,74 @@
+import { readFile } from "fs/promises";
+import path from "path";
+import { pathToFileURL } from "url";
+import type { CompileWorkflowResult } from "./compiler-types";
+import { normalizeCompileResult } from "./normalize-compile-result";
+
+// The same wasm-pack build the browser worker loads from /compiler, read from
+// public/ on disk so routes can compile without a browser.
+
+type CompilerWasmModule = {
+  default?: (input?: unknown) => Promise<unknown>;
+  compile_workflow: (json: string) => unknown;
+};
+
+let compilerModulePromise: Promise<CompilerWasmModule> | null = null;
+let compilerVersionPromise: Promise<string> | null = null;
+
+function compilerDir(): string {
+  return path.join(process.cwd(), "public", "compiler");
+}
+
+async function ensureCompilerModule(): Promise<CompilerWasmModule> {
+  if (compilerModulePromise) {
+    return compilerModulePromise;
+  }
+
+  compilerModulePromise = (async () => {
+    const moduleUrl = pathToFileURL(path.join(compilerDir(), "sixflow_compiler.js")).href;
+    const module = (await import(/* webpackIgnore: true */ moduleUrl)) as CompilerWasmModule;
+
+    if (typeof module.default === "function") {
+      // Node has no URL to fetch the .wasm from; hand over the bytes instead.
+      await module.default(await readFile(path.join(compilerDir(), "sixflow_compiler_bg.wasm")));
+    }
+    if (typeof module.compile_workflow !== "function") {
+      throw new Error("Compiler WASM module is missing required exports");
+    }
+    return module;
+  })();
+
+  // A failed load is retried on the next request rather than cached.
+  compilerModulePromise.catch(() => {
+    compilerModulePromise = null;
+  });
+  return compilerModulePromise;
+}
+
+export async function compileWorkflowOnServer(workflowJson: string): Promise<CompileWorkflowResult> {
+  const compiler = await ensureCompilerModule();
+  return normalizeCompileResult(compiler.compile_workflow(workflowJson));
+}
+
+export function getServerCompilerVersion(): Promise<string> {
+  if (compilerVersionPromise) {
+    return compilerVersionPromise;
+  }
+
+  compilerVersionPromise = (async () => {
+    try {
+      const payload = JSON.parse(
+        await readFile(path.join(compilerDir(), "package.json"), "utf8")
+      ) as { version?: unknown };
+      if (typeof payload.version !== "string") {
+        return "unknown";
+      }
+      const version = payload.version.trim();
+      return version === "" ? "unknown" : version;
+    } catch {
+      return "unknown";
+    }
+  })();
+
+  return compilerVersionPromise;
+}
@@ -0,0 +1,347 @@
+import { createHash, createHmac, timingSafeEqual } from "crypto";
+import { fetchMutation, fetchQuery } from "convex/nextjs";
+import { NextRequest, NextResponse } from "next/server";
+import { api } from "../../convex/_generated/api";
+import { Doc } from "../../convex/_generated/dataModel";
+
+// Helpers shared by the /api/tui routes the 6flow TUI (tools/tui) calls.
+
+export function getBearerToken(request: NextRequest): string | null {
+  const header = request.headers.get("authorization");
+  if (!header) return null;
+
+  const [scheme, token] = header.split(" ");
+  if (scheme !== "Bearer" || !token) return null;
+
+  return token.trim();
+}
+
+export function isUnauthorizedError(error: unknown): boolean {
+  if (!(error instanceof Error)) return false;
+  const message = error.message.toLowerCase();
+  return (
+    message.includes("unauth") ||
+    message.includes("not authenticated") ||
+    message.includes("invalid token")
+  );
+}
+
+export function isNotFoundError(error: unknown): boolean {
+  if (!(error instanceof Error)) return false;
+  return error.message.toLowerCase().includes("not found");
+}
+
+export async function readJsonBody<T>(request: NextRequest): Promise<T | null> {
+  try {
+    return (await request.json()) as T;
+  } catch {
+    return null;
+  }
+}
+
+export function hashToken(token: string): string {
+  return createHash("sha256").update(token).digest("hex");
+}
+
+// tokenExpiresAt reads the exp claim of a JWT, in milliseconds; tokens it
+// cannot parse are assumed to live for 30 days.
+export function tokenExpiresAt(token: string): number {
+  try {
+    const payload = JSON.parse(Buffer.from(token.split(".")[1] ?? "", "base64url").toString("utf8")) as {
+      exp?: unknown;
+    };
+    if (typeof payload.exp === "number") return payload.exp * 1000;
+  } catch {
+    // Fall through to the default lifetime.
+  }
+  return Date.now() + 30 * 24 * 60 * 60 * 1000;
+}
+
+export interface TuiSession {
+  token: string;
+  userId: string;
+  userName: string;
+}
+
+// Every user has a single personal organization whose id is their user id;
+// the TUI sends the one it picked in this header.
+export const ORGANIZATION_HEADER = "x-6flow-org-id";
+
+export interface TuiOrganization {
+  id: string;
+  name: string;
+  role: "owner" | "admin" | "member";
+}
+
+export function sessionOrganizations(session: TuiSession): TuiOrganization[] {
+  return [{ id: session.userId, name: session.userName || "Personal", role: "owner" }];
+}
+
+// authorizeTuiRequest resolves the bearer token to a session, rejecting
+// missing, invalid and revoked tokens and organizations the user does not
+// belong to. Routes return the response as is when they get one back.
+export async function authorizeTuiRequest(
+  request: NextRequest
+): Promise<TuiSession | NextResponse> {
+  const token = getBearerToken(request);
+  if (!token) {
+    return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+  }
+  try {
+    const session = await fetchQuery(api.tuiAuth.session, { tokenHash: hashToken(token) }, { token });
+    if (!session) {
+      return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+    }
+    const resolved: TuiSession = { token, userId: session.userId, userName: session.name };
+    const organizationId = (request.headers.get(ORGANIZATION_HEADER) ?? "").trim();
+    if (organizationId && !sessionOrganizations(resolved).some((org) => org.id === organizationId)) {
+      return NextResponse.json(
+        { error: "Not a member of this organization", code: "forbidden" },
+        { status: 403 }
+      );
+    }
+    return resolved;
+  } catch (error) {
+    if (isUnauthorizedError(error)) {
+      return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+    }
+    console.error("[tui] failed to resolve session", error);
+    return NextResponse.json({ error: "Failed to resolve session" }, { status: 500 });
+  }
+}
+
+export interface TuiWorkflowDto {
+  id: string;
+  name: string;
+  updatedAt: number;
+  nodeCount: number;
+  status: "ready" | "draft" | "compiling" | "compile_failed";
+  compilerVersion: string;
+}
+
+function parseNodeCount(nodesJson: string): number {
+  try {
+    const parsed = JSON.parse(nodesJson);
+    return Array.isArray(parsed) ? parsed.length : 0;
+  } catch {
+    return 0;
+  }
+}
+
+export function toTuiWorkflow(workflow: Doc<"workflows">): TuiWorkflowDto {
+  return {
+    id: workflow._id,
+    name: workflow.name,
+    updatedAt: workflow.updatedAt,
+    nodeCount: parseNodeCount(workflow.nodes),
+    status: workflow.compileStatus ?? (workflow.compiledArtifactStorageId ? "ready" : "draft"),
+    compilerVersion: workflow.compiledArtifactCompilerVersion ?? "",
+  };
+}
+
+interface SecretReference {
+  name: string;
+}
+
+export interface WorkflowGlobalConfig {
+  isTestnet: boolean;
+  defaultChainSelector: string;
+  secrets: SecretReference[];
+  rpcs: Array<{ chainName: string; url: string }>;
+}
+
+export function parseGlobalConfig(raw: string | undefined): WorkflowGlobalConfig {
+  if (!raw) {
+    return {
+      isTestnet: true,
+      defaultChainSelector: "ethereum-testnet-sepolia",
+      secrets: [],
+      rpcs: [],
+    };
+  }
+  try {
+    const parsed = JSON.parse(raw) as Partial<WorkflowGlobalConfig>;
+    const secrets = Array.isArray(parsed.secrets)
+      ? parsed.secrets
+          .filter((s): s is SecretReference => Boolean(s && typeof s.name === "string"))
+          .map((s) => ({ name: s.name.trim() }))
+          .filter((s) => s.name.length > 0)
+      : [];
+    const rpcs = Array.isArray(parsed.rpcs)
+      ? parsed.rpcs.filter(
+          (r): r is { chainName: string; url: string } =>
+            Boolean(
+              r &&
+                typeof r.chainName === "string" &&
+                typeof r.url === "string"
+            )
+        )
+      : [];
+    return {
+      isTestnet: typeof parsed.isTestnet === "boolean" ? parsed.isTestnet : true,
+      defaultChainSelector:
+        typeof parsed.defaultChainSelector === "string" &&
+        parsed.defaultChainSelector.trim().length > 0
+          ? parsed.defaultChainSelector
+          : "ethereum-testnet-sepolia",
+      secrets,
+      rpcs,
+    };
+  } catch {
+    return {
+      isTestnet: true,
+      defaultChainSelector: "ethereum-testnet-sepolia",
+      secrets: [],
+      rpcs: [],
+    };
+  }
+}
+
+// The /api/tui contract version; bump it when a route changes shape in a way
+// older TUIs cannot read.
+export const TUI_API_VERSION = 1;
+
+// Optional routes this frontend serves, advertised by /api/tui/version.
+export const TUI_CAPABILITIES = [
+  "pkce",
+  "device_code",
+  "revoke",
+  "ping",
+  "events",
+  "runs",
+  "workflow_detail",
+  "organizations",
+  "compile",
+  "bundle_versions",
+  "config",
+  "bundle_ranges",
+  "idempotency_keys",
+];
+
+// TUIs older than TUI_MIN_CLIENT_VERSION are told to upgrade.
+export function minTuiClientVersion(): string {
+  return (process.env.TUI_MIN_CLIENT_VERSION ?? "").trim();
+}
+
+// Deployment targets whose config.<target>.json the TUI can replace.
+export const CONFIG_TARGETS = ["staging", "production"] as const;
+export type ConfigTarget = (typeof CONFIG_TARGETS)[number];
+
+export function parseTargetConfigs(raw: string | undefined): Partial<Record<ConfigTarget, unknown>> {
+  if (!raw) return {};
+  try {
+    const parsed = JSON.parse(raw) as Record<string, unknown>;
+    const configs: Partial<Record<ConfigTarget, unknown>> = {};
+    for (const target of CONFIG_TARGETS) {
+      if (parsed[target] !== undefined) configs[target] = parsed[target];
+    }
+    return configs;
+  } catch {
+    return {};
+  }
+}
+
+// Bundle downloads go through /api/tui/bundles/<storageId>, which supports
+// Range requests. The TUI fetches downloadUrl without its bearer token, so
+// the URL carries an HMAC over the storage id, upstream URL and expiry made
+// with TUI_DOWNLOAD_SIGNING_SECRET. Without the secret the storage URL is
+// handed out directly.
+const BUNDLE_URL_TTL_MS = 15 * 60 * 1000;
+
+function downloadSigningSecret(): string {
+  return (process.env.TUI_DOWNLOAD_SIGNING_SECRET ?? "").trim();
+}
+
+function bundleSignature(secret: string, storageId: string, upstream: string, expires: number, fileName: string): string {
+  return createHmac("sha256", secret)
+    .update(`${storageId}\n${upstream}\n${expires}\n${fileName}`)
+    .digest("base64url");
+}
+
+export function bundleDownloadUrl(origin: string, storageId: string, upstream: string, fileName: string): string {
+  const secret = downloadSigningSecret();
+  if (!secret) return upstream;
+
+  const expires = Date.now() + BUNDLE_URL_TTL_MS;
+  const url = new URL(`/api/tui/bundles/${encodeURIComponent(storageId)}`, origin);
+  url.searchParams.set("u", Buffer.from(upstream).toString("base64url"));
+  url.searchParams.set("name", fileName);
+  url.searchParams.set("exp", String(expires));
+  url.searchParams.set("sig", bundleSignature(secret, storageId, upstream, expires, fileName));
+  return url.toString();
+}
+
+export interface BundleDownload {
+  upstream: string;
+  fileName: string;
+}
+
+// verifyBundleDownload checks a URL made by bundleDownloadUrl and returns
+// what it points at, or null when it is forged or expired.
+export function verifyBundleDownload(storageId: string, params: URLSearchParams): BundleDownload | null {
+  const secret = downloadSigningSecret();
+  if (!secret) return null;
+
+  const upstream = Buffer.from(params.get("u") ?? "", "base64url").toString("utf8");
+  const fileName = params.get("name") ?? "";
+  const expires = Number(params.get("exp"));
+  const signature = Buffer.from(params.get("sig") ?? "");
+  if (!upstream || !Number.isFinite(expires) || expires < Date.now()) return null;
+
+  const expected = Buffer.from(bundleSignature(secret, storageId, upstream, expires, fileName));
+  if (signature.length !== expected.length || !timingSafeEqual(signature, expected)) return null;
+  return { upstream, fileName };
+}
+
+const IDEMPOTENCY_KEY_HEADER = "idempotency-key";
+const MAX_IDEMPOTENCY_KEY_LENGTH = 255;
+
+// withIdempotency runs a mutating route once per Idempotency-Key. A repeat of
+// the key gets the first response back; the same key on another route is a
+// client bug and answers 422. Server errors and 401s are not stored, so the
+// request can be retried. Requests without the header run as usual.
+export async function withIdempotency(
+  request: NextRequest,
+  session: TuiSession,
+  route: string,
+  handler: () => Promise<NextResponse>
+): Promise<NextResponse> {
+  const key = request.headers.get(IDEMPOTENCY_KEY_HEADER)?.trim() ?? "";
+  if (!key) return handler();
+  if (key.length > MAX_IDEMPOTENCY_KEY_LENGTH) {
+    return NextResponse.json({ error: "Idempotency-Key is too long" }, { status: 400 });
+  }
+
+  const { token } = session;
+  let stored: { route: string; status: number; body: string } | null;
+  try {
+    stored = await fetchQuery(api.tuiIdempotency.lookup, { key }, { token });
+  } catch (error) {
+    if (isUnauthorizedError(error)) {
+      return NextResponse.json({ error: "Unauthorized" }, { status: 401 });
+    }
+    console.error("[tui] failed to look up idempotency key", error);
+    return NextResponse.json({ error: "Failed to check Idempotency-Key" }, { status: 500 });
+  }
+  if (stored) {
+    if (stored.route !== route) {
+      return NextResponse.json(
+        { error: "Idempotency-Key was already used for another request", code: "idempotency_key_reused" },
+        { status: 422 }
+      );
+    }
+    return new NextResponse(stored.body, {
+      status: stored.status,
+      headers: { "Content-Type": "application/json", "Idempotent-Replayed": "true" },
+    });
+  }
+
+  const response = await handler();
+  if (response.status < 500 && response.status !== 401) {
+    const body = await response.clone().text();
+    await fetchMutation(api.tuiIdempotency.store, { key, route, status: response.status, body }, { token }).catch(
+      (error) => console.error("[tui] failed to store idempotent response", error)
+    );
+  }
+  return response;
+}
@@ -40,6 +40,11 @@ interface UseCompilerResult {
   onCloseCompileModal: () => void;
 }
 
+async function sha256Hex(blob: Blob): Promise<string> {
+  const digest = await crypto.subtle.digest("SHA-256", await blob.arrayBuffer());
+  return Array.from(new Uint8Array(digest), (byte) => byte.toString(16).padStart(2, "0")).join("");
+}
+
 function toMessage(error: unknown): string {
   if (error instanceof Error) {
     return error.message;
@@ -341,6 +346,7 @@ export function useCompiler(): UseCompilerResult {
         fileCount: result.files.length,
         compilerVersion: await getCompilerVersion(),
         compiledAt: Date.now(),
+        sha256: await sha256Hex(blob),
       });
 
       clearCompilerErrors();
@@ -0,0 +1,1386 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	core "github.com/6flow/6flow-convergence/tools/tui/internal/tui"
+)
+
+// Exit codes are part of the scripting contract; keep existing values stable.
+const (
+	exitOK               = 0
+	exitError            = 1
+	exitUsage            = 2
+	exitAuth             = 3
+	exitCRECLIMissing    = 4
+	exitSyncFailed       = 5
+	exitSimulateFailed   = 6
+	exitSecretsPreflight = 7
+)
+
+var errNoAuthSession = errors.New("no valid auth session. Run the interactive TUI to log in first")
+
+type headlessSecret struct {
+	ID       string `json:"id"`
+	EnvVar   string `json:"envVar"`
+	HasValue bool   `json:"hasValue"`
+	Action   string `json:"action,omitempty"`
+	// Location is set by `secrets reconcile`: both, local-only or frontend-only.
+	Location string `json:"location,omitempty"`
+	// RotatedAt and Stale are set by `secrets list` when the value's age is
+	// known.
+	RotatedAt *time.Time `json:"rotatedAt,omitempty"`
+	Stale     bool       `json:"stale,omitempty"`
+}
+
+type headlessResult struct {
+	Command   string           `json:"command"`
+	OK        bool             `json:"ok"`
+	ExitCode  int              `json:"exitCode"`
+	Error     string           `json:"error,omitempty"`
+	Logs      []string         `json:"logs"`
+	OutputDir string           `json:"outputDir,omitempty"`
+	Workflow  string           `json:"workflowId,omitempty"`
+	Result    string           `json:"result,omitempty"`
+	Secrets   []headlessSecret `json:"secrets,omitempty"`
+	Steps     []*batchStep     `json:"steps,omitempty"`
+	Status    *headlessStatus  `json:"status,omitempty"`
+}
+
+type headlessStatus struct {
+	Healthy   bool                    `json:"healthy"`
+	Auth      headlessAuthStatus      `json:"auth"`
+	CRE       headlessCREStatus       `json:"cre"`
+	Frontend  headlessFrontendStatus  `json:"frontend"`
+	Workflows []headlessLocalWorkflow `json:"workflows"`
+}
+
+type headlessAuthStatus struct {
+	Valid     bool   `json:"valid"`
+	Source    string `json:"source,omitempty"`
+	OrgID     string `json:"orgId,omitempty"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type headlessCREStatus struct {
+	Installed    bool   `json:"installed"`
+	Path         string `json:"path,omitempty"`
+	LoggedIn     bool   `json:"loggedIn"`
+	Identity     string `json:"identity,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	// Mismatch explains how the CRE login differs from the 6flow account.
+	Mismatch string `json:"mismatch,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type headlessFrontendStatus struct {
+	URL           string `json:"url"`
+	Proxy         string `json:"proxy,omitempty"`
+	Reachable     bool   `json:"reachable"`
+	Version       string `json:"version,omitempty"`
+	LatencyMs     *int64 `json:"latencyMs,omitempty"`
+	APIVersion    int    `json:"apiVersion,omitempty"`
+	Incompatible  string `json:"incompatible,omitempty"`
+	Authorized    *bool  `json:"authorized,omitempty"`
+	WorkflowCount *int   `json:"workflowCount,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+type headlessLocalWorkflow struct {
+	ID       string   `json:"id"`
+	Name     string   `json:"name"`
+	Path     string   `json:"path"`
+	Modified []string `json:"modified,omitempty"`
+	Deleted  []string `json:"deleted,omitempty"`
+}
+
+type batchStep struct {
+	Line    int             `json:"line"`
+	Command string          `json:"command"`
+	Result  *headlessResult `json:"result"`
+}
+
+type headlessContext struct {
+	stdout     io.Writer
+	stderr     io.Writer
+	jsonOutput bool
+	trustHost  bool
+}
+
+type headlessCommand struct {
+	usage string
+	run   func(hc *headlessContext, args []string) *headlessResult
+}
+
+var headlessCommands map[string]headlessCommand
+
+func init() {
+	headlessCommands = map[string]headlessCommand{
+		"sync":     {usage: "sync <workflow-id-or-name> [--compile [--compile-timeout 5m]] [--version <id|compiler-version|date>] [--json]", run: runHeadlessSync},
+		"simulate": {usage: "simulate <workflow-id-or-name> [--target staging-settings] [--evm-tx-hash 0x.. --evm-event-index 0 | --payload file.json] [--json]", run: runHeadlessSimulate},
+		"secrets":  {usage: "secrets list|add|remove|rename|import|prefill|clone|reconcile <workflow-id-or-name> [KEY=VALUE|KEY=@file|KEY|OLD_KEY NEW_KEY|SOURCE_WORKFLOW] [--from file] [--dry-run] [--values] [--target staging-settings] [--frontend] [--purge] [--json]", run: runHeadlessSecrets},
+		"batch":    {usage: "batch [file|-] [--keep-going] [--json]", run: runHeadlessBatch},
+		"status":   {usage: "status [--json]", run: runHeadlessStatus},
+		"logout":   {usage: "logout [--json]", run: runHeadlessLogout},
+		"watch":    {usage: "watch <workflow-id-or-name> [--interval 10s] [--json]", run: runHeadlessWatch},
+		"config":   {usage: "config push <workflow-id-or-name> [--dry-run] [--json]", run: runHeadlessConfig},
+		"cre":      {usage: "cre install [--json]", run: runHeadlessCRE},
+	}
+}
+
+func isHeadlessCommand(name string) bool {
+	_, ok := headlessCommands[name]
+	return ok || name == "help" || name == "--help" || name == "-h"
+}
+
+func headlessUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: 6flow-tui [--profile <name>] [--token <token>] [--http-trace <file>] [--workflow <id-or-name> [--action simulate|secrets]]")
+	fmt.Fprintln(w, "       6flow-tui <command> [args] [--profile <name>] [--token <token>] [--org <id>] [--http-trace <file>] [--trust-host]")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Without a command the interactive TUI starts. Set "+core.AuthTokenEnv+" or pass --token")
+	fmt.Fprintln(w, "to skip the browser login (e.g. in CI). Set SIXFLOW_HTTP_TRACE=1 or pass --http-trace to log")
+	fmt.Fprintln(w, "frontend requests with tokens and secret values redacted. Set SIXFLOW_DRY_RUN=1 to print the")
+	fmt.Fprintln(w, "commands, file writes and frontend secret updates of simulate and secrets instead. Commands:")
+	names := []string{"status", "logout", "sync", "watch", "simulate", "secrets", "config", "cre", "batch"}
+	for _, name := range names {
+		fmt.Fprintln(w, "  "+headlessCommands[name].usage)
+	}
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Exit codes:")
+	fmt.Fprintf(w, "  %d ok, %d error, %d usage, %d auth failure, %d cre CLI missing,\n", exitOK, exitError, exitUsage, exitAuth, exitCRECLIMissing)
+	fmt.Fprintf(w, "  %d sync failed, %d simulation failed, %d secrets preflight failed\n", exitSyncFailed, exitSimulateFailed, exitSecretsPreflight)
+}
+
+// parseInterspersed lets flags appear before or after positional arguments,
+// e.g. `sync wf-123 --json`.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+func newHeadlessFlagSet(hc *headlessContext, name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&hc.jsonOutput, "json", hc.jsonOutput, "emit a JSON result instead of human-readable text")
+	fs.Func("token", "auth token to use instead of the saved session", func(value string) error {
+		core.SetAuthTokenOverride(value)
+		return nil
+	})
+	fs.Func("profile", "named auth profile to use", core.SetAuthProfile)
+	fs.Func("org", "organization id for multi-org accounts", func(value string) error {
+		core.SetActiveOrganization(value)
+		return nil
+	})
+	fs.Func("http-trace", "append a redacted trace of frontend requests to this file", core.EnableHTTPTrace)
+	fs.BoolVar(&hc.trustHost, "trust-host", hc.trustHost, "approve a non-default frontend host before sending the token")
+	return fs
+}
+
+func usageResult(command, message string) *headlessResult {
+	return &headlessResult{
+		Command:  command,
+		ExitCode: exitUsage,
+		Error:    message + "\nusage: 6flow-tui " + headlessCommands[command].usage,
+		Logs:     []string{},
+	}
+}
+
+func exitCodeFor(command string, err error) int {
+	var preflightErr *core.SecretsPreflightError
+	switch {
+	case errors.Is(err, errNoAuthSession), errors.Is(err, core.ErrFrontendUnauthorized), errors.Is(err, core.ErrUntrustedHost),
+		errors.Is(err, core.ErrReadOnlySession):
+		return exitAuth
+	case errors.Is(err, core.ErrCRECLINotFound):
+		return exitCRECLIMissing
+	case errors.As(err, &preflightErr),
+		errors.Is(err, core.ErrSecretsNotConfigured),
+		errors.Is(err, core.ErrKeystoreLocked),
+		errors.Is(err, core.ErrKeystorePassphrase):
+		return exitSecretsPreflight
+	}
+	switch command {
+	case "sync":
+		return exitSyncFailed
+	case "simulate":
+		return exitSimulateFailed
+	default:
+		return exitError
+	}
+}
+
+func failedResult(command string, logs []string, err error) *headlessResult {
+	if logs == nil {
+		logs = []string{}
+	}
+	return &headlessResult{
+		Command:  command,
+		ExitCode: exitCodeFor(command, err),
+		Error:    describeFrontendError(err),
+		Logs:     logs,
+	}
+}
+
+// defaultWebBaseURL prefers SIXFLOW_WEB_URL, then the frontend saved with the
+// active profile's session.
+func defaultWebBaseURL() string {
+	base := os.Getenv("SIXFLOW_WEB_URL")
+	if strings.TrimSpace(base) == "" {
+		base = core.SavedSessionWebBaseURL()
+	}
+	if strings.TrimSpace(base) == "" {
+		base = "https://6flow.studio"
+	}
+	return base
+}
+
+// loadHeadlessToken refuses to hand out a token for a frontend host the user
+// has not approved, since headless commands cannot show the trust prompt.
+func loadHeadlessToken(hc *headlessContext) (string, error) {
+	baseURL := defaultWebBaseURL()
+	if !core.IsTrustedHost(baseURL) {
+		if !hc.trustHost {
+			return "", fmt.Errorf("%w: %s (approve it in the TUI or pass --trust-host)", core.ErrUntrustedHost, baseURL)
+		}
+		if err := core.TrustHost(baseURL, core.InspectHost(baseURL)); err != nil {
+			return "", err
+		}
+	}
+	session, err := core.LoadAuthSession()
+	if err != nil {
+		return "", fmt.Errorf("%w (%v)", errNoAuthSession, err)
+	}
+	if !core.IsSessionValid(session) {
+		return "", errNoAuthSession
+	}
+	if core.ActiveOrganization() == "" {
+		core.SetActiveOrganization(session.OrgID)
+	}
+	return session.Token, nil
+}
+
+// requireWritableSession blocks secret changes when the saved session is a
+// read-only token. Without a session, local-only changes stay allowed.
+func requireWritableSession() error {
+	session, err := core.LoadAuthSession()
+	if err == nil && core.IsSessionValid(session) && core.TokenIsReadOnly(session.Token) {
+		return core.ErrReadOnlySession
+	}
+	return nil
+}
+
+func findFrontendWorkflow(workflows []core.FrontendWorkflow, ref string) (*core.FrontendWorkflow, error) {
+	trimmed := strings.TrimSpace(ref)
+	var byName []core.FrontendWorkflow
+	for _, wf := range workflows {
+		if wf.ID == trimmed {
+			return &wf, nil
+		}
+		if strings.EqualFold(wf.Name, trimmed) {
+			byName = append(byName, wf)
+		}
+	}
+	switch len(byName) {
+	case 0:
+		return nil, fmt.Errorf("workflow %q not found in frontend workflow list", trimmed)
+	case 1:
+		return &byName[0], nil
+	default:
+		return nil, fmt.Errorf("%q matches %d workflows; use the workflow id", trimmed, len(byName))
+	}
+}
+
+func runHeadlessSync(hc *headlessContext, args []string) *headlessResult {
+	fs := newHeadlessFlagSet(hc, "sync")
+	compile := fs.Bool("compile", false, "request a compile and wait when the workflow is not compiled yet")
+	compileTimeout := fs.Duration("compile-timeout", core.DefaultCompileTimeout, "how long --compile waits for the workflow to become ready")
+	versionRef := fs.String("version", "", "sync an older bundle by id, compiler version or date")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return usageResult("sync", err.Error())
+	}
+	if len(positional) != 1 {
+		return usageResult("sync", "expected exactly one workflow id or name")
+	}
+
+	token, err := loadHeadlessToken(hc)
+	if err != nil {
+		return failedResult("sync", nil, err)
+	}
+	baseURL := defaultWebBaseURL()
+	workflows, err := core.FetchFrontendWorkflows(baseURL, token, core.WorkflowFilter{})
+	if err != nil {
+		return failedResult("sync", nil, err)
+	}
+	workflow, err := findFrontendWorkflow(workflows, positional[0])
+	if err != nil {
+		return failedResult("sync", nil, err)
+	}
+	logs := []string{}
+	version := ""
+	if strings.TrimSpace(*versionRef) != "" {
+		// Older artifacts stay downloadable while the workflow is a draft.
+		versions, err := core.FetchBundleVersions(baseURL, token, workflow.ID)
+		if err != nil {
+			return failedResult("sync", nil, err)
+		}
+		picked, err := core.FindBundleVersion(versions, *versionRef)
+		if err != nil {
+			return failedResult("sync", nil, err)
+		}
+		version = picked.ID
+		logs = append(logs, fmt.Sprintf("Using bundle version %s (compiler %s, built %s).",
+			picked.ID, picked.CompilerVersion, time.UnixMilli(picked.CreatedAt).UTC().Format(time.RFC3339)))
+	} else if workflow.Status != "ready" {
+		if !*compile {
+			return failedResult("sync", nil, fmt.Errorf("workflow %q is not compiled yet (status %s); pass --compile to compile it", workflow.Name, workflow.Status))
+		}
+		if err := core.RequestWorkflowCompile(baseURL, token, workflow.ID); err != nil {
+			return failedResult("sync", nil, fmt.Errorf("compile request failed: %w", err))
+		}
+		logs = append(logs, "Compilation requested for "+workflow.Name+".")
+		compiled, err := core.WaitForWorkflowCompiled(baseURL, token, workflow.ID, core.DefaultCompilePollInterval, *compileTimeout, func(status string) {
+			logs = append(logs, "status: "+status)
+		})
+		if err != nil {
+			out := failedResult("sync", logs, err)
+			out.Workflow = workflow.ID
+			return out
+		}
+		workflow = compiled
+	}
+
+	result, err := core.SyncWorkflowVersionToLocal(baseURL, token, workflow.ID, workflow.Name, version)
+	if result != nil {
+		logs = append(logs, result.Logs...)
+	}
+	if err != nil {
+		out := failedResult("sync", logs, err)
+		out.Workflow = workflow.ID
+		return out
+	}
+	return &headlessResult{
+		Command:   "sync",
+		OK:        true,
+		Logs:      logs,
+		OutputDir: result.OutputDir,
+		Workflow:  workflow.ID,
+	}
+}
+
+func runHeadlessConfig(hc *headlessContext, args []string) *headlessResult {
+	fs := newHeadlessFlagSet(hc, "config")
+	dryRun := fs.Bool("dry-run", false, "show the differences without uploading")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return usageResult("config", err.Error())
+	}
+	if len(positional) != 2 || positional[0] != "push" {
+		return usageResult("config", "expected push and one workflow id or name")
+	}
+
+	workflow, err := core.ResolveLocalWorkflow(positional[1])
+	if err != nil {
+		return failedResult("config", nil, err)
+	}
+	if !*dryRun {
+		if err := requireWritableSession(); err != nil {
+			return failedResult("config", nil, err)
+		}
+	}
+	token, err := loadHeadlessToken(hc)
+	if err != nil {
+		return failedResult("config", nil, err)
+	}
+	result, err := core.PushWorkflowConfig(defaultWebBaseURL(), token, workflow.ID, workflow.Name, !*dryRun)
+	var logs []string
+	if result != nil {
+		logs = result.Logs
+	}
+	if err != nil {
+		out := failedResult("config", logs, err)
+		out.Workflow = workflow.ID
+		return out
+	}
+	if !result.HasChanges() {
+		logs = append(logs, "Local config matches the frontend; nothing to push.")
+	}
+	return &headlessResult{
+		Command:   "config",
+		OK:        true,
+		Logs:      logs,
+		OutputDir: workflow.ProjectRoot,
+		Workflow:  workflow.ID,
+	}
+}
+
+func runHeadlessCRE(hc *headlessContext, args []string) *headlessResult {
+	fs := newHeadlessFlagSet(hc, "cre")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return usageResult("cre", err.Error())
+	}
+	if len(positional) != 1 || positional[0] != "install" {
+		return usageResult("cre", "expected install")
+	}
+	result, err := core.InstallCRECLI()
+	var logs []string
+	if result != nil {
+		logs = result.Logs
+	}
+	if err != nil {
+		return failedResult("cre", logs, err)
+	}
+	if result.Version != "" {
+		logs = append(logs, "version: "+result.Version)
+	}
+	return &headlessResult{
+		Command:   "cre",
+		OK:        true,
+		Logs:      logs,
+		OutputDir: filepath.Dir(result.Path),
+	}
+}
+
+func runHeadlessSimulate(hc *headlessContext, args []string) *headlessResult {
+	fs := newHeadlessFlagSet(hc, "simulate")
+	target := fs.String("target", "staging-settings", "workflow.yaml target")
+	evmTxHash := fs.String("evm-tx-hash", "", "transaction hash for EVM log triggers")
+	evmEventIndex := fs.Int("evm-event-index", 0, "log index for EVM log triggers")
+	payload := fs.String("payload", "", "JSON file passed as the HTTP trigger payload")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return usageResult("simulate", err.Error())
+	}
+	if *payload != "" && *evmTxHash != "" {
+		return usageResult("simulate", "--payload and --evm-tx-hash cannot be combined")
+	}
+	if len(positional) != 1 {
+		return usageResult("simulate", "expected exactly one workflow id or name")
+	}
+
+	workflow, err := core.ResolveLocalWorkflow(positional[0])
+	if err != nil {
+		return failedResult("simulate", nil, err)
+	}
+	result, err := core.RunWorkflowSimulateLocal(workflow.ID, workflow.Name, *target, *evmTxHash, *evmEventIndex, *payload, nil)
+	var logs []string
+	var simulated string
+	if result != nil {
+		logs = result.Logs
+		simulated = result.Result
+	}
+	if err != nil {
+		out := failedResult("simulate", logs, err)
+		out.Workflow = workflow.ID
+		out.OutputDir = workflow.ProjectRoot
+		out.Result = simulated
+		return out
+	}
+	return &headlessResult{
+		Command:   "simulate",
+		OK:        true,
+		Logs:      logs,
+		OutputDir: workflow.ProjectRoot,
+		Workflow:  workflow.ID,
+		Result:    simulated,
+	}
+}
+
+func runHeadlessSecrets(hc *headlessContext, args []string) *headlessResult {
+	fs := newHeadlessFlagSet(hc, "secrets")
+	target := fs.String("target", "staging-settings", "workflow.yaml target")
+	syncFrontend := fs.Bool("frontend", false, "also add/remove/rename the secret name in the frontend workflow config")
+	importFrom := fs.String("from", "", "dotenv file to import with `secrets import`")
+	dryRun := fs.Bool("dry-run", false, "show what `secrets import` or `secrets prefill` would change without writing")
+	purge := fs.Bool("purge", false, "with `secrets remove`, also delete the declaration from secrets.yaml")
+	withValues := fs.Bool("values", false, "with `secrets clone`, also copy the values this workflow is missing")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return usageResult("secrets", err.Error())
+	}
+	if len(positional) < 2 {
+		return usageResult("secrets", "expected a subcommand and a workflow id or name")
+	}
+
+	action := positional[0]
+	workflow, err := core.ResolveLocalWorkflow(positional[1])
+	if err != nil {
+		return failedResult("secrets", nil, err)
+	}
+	if action != "list" && action != "reconcile" && !((action == "import" || action == "prefill") && *dryRun) {
+		if err := requireWritableSession(); err != nil {
+			return failedResult("secrets", nil, err)
+		}
+	}
+
+	var (
+		result        *core.SecretsCommandResult
+		secretName    string
+		newSecretName string
+	)
+	switch action {
+	case "list":
+		if len(positional) != 2 {
+			return usageResult("secrets", "list takes no extra arguments")
+		}
+		listed, err := core.ListLocalSecrets(workflow.ID, workflow.Name, *target)
+		if err != nil {
+			var logs []string
+			if listed != nil {
+				logs = listed.Logs
+			}
+			return failedResult("secrets", logs, err)
+		}
+		secrets := make([]headlessSecret, 0, len(listed.Entries))
+		for _, entry := range listed.Entries {
+			secret := headlessSecret{ID: entry.ID, EnvVar: entry.EnvVar, HasValue: entry.HasValue}
+			if !entry.RotatedAt.IsZero() {
+				rotatedAt := entry.RotatedAt
+				secret.RotatedAt = &rotatedAt
+				secret.Stale = core.IsSecretStale(rotatedAt)
+			}
+			secrets = append(secrets, secret)
+		}
+		return &headlessResult{
+			Command:   "secrets",
+			OK:        true,
+			Logs:      listed.Logs,
+			OutputDir: workflow.ProjectRoot,
+			Workflow:  workflow.ID,
+			Secrets:   secrets,
+		}
+	case "add":
+		if len(positional) != 3 || !strings.Contains(positional[2], "=") {
+			return usageResult("secrets", "add expects KEY=VALUE")
+		}
+		parts := strings.SplitN(positional[2], "=", 2)
+		secretName = parts[0]
+		if err := core.ValidateSecretID(secretName); err != nil {
+			return usageResult("secrets", err.Error())
+		}
+		value, expandErr := core.ExpandSecretValueFile(parts[1])
+		if expandErr != nil {
+			return failedResult("secrets", nil, expandErr)
+		}
+		if err := core.ValidateSecretValue(value); err != nil {
+			return usageResult("secrets", err.Error())
+		}
+		result, err = core.CreateLocalSecret(workflow.ID, workflow.Name, *target, secretName, value)
+	case "import":
+		return runHeadlessSecretsImport(workflow, positional, *target, *importFrom, *dryRun)
+	case "prefill":
+		return runHeadlessSecretsPrefill(workflow, positional, *target, *dryRun)
+	case "clone":
+		return runHeadlessSecretsClone(workflow, positional, *target, *withValues)
+	case "reconcile":
+		return runHeadlessSecretsReconcile(hc, workflow, positional, *target)
+	case "remove":
+		if len(positional) != 3 {
+			return usageResult("secrets", "remove expects KEY")
+		}
+		secretName = positional[2]
+		if *purge {
+			result, err = core.PurgeLocalSecret(workflow.ID, workflow.Name, *target, secretName)
+		} else {
+			result, err = core.DeleteLocalSecret(workflow.ID, workflow.Name, *target, secretName)
+		}
+	case "rename":
+		if len(positional) != 4 {
+			return usageResult("secrets", "rename expects OLD_KEY NEW_KEY")
+		}
+		secretName, newSecretName = positional[2], positional[3]
+		if err := core.ValidateSecretID(newSecretName); err != nil {
+			return usageResult("secrets", err.Error())
+		}
+		result, err = core.RenameLocalSecret(workflow.ID, workflow.Name, *target, secretName, newSecretName)
+	default:
+		return usageResult("secrets", fmt.Sprintf("unknown secrets subcommand %q", action))
+	}
+
+	var logs []string
+	if result != nil {
+		logs = result.Logs
+	}
+	if err == nil && *syncFrontend {
+		var token string
+		token, err = loadHeadlessToken(hc)
+		if err == nil && action == "rename" {
+			err = core.UpdateWorkflowSecretInFrontend(defaultWebBaseURL(), token, workflow.ID, "remove", secretName)
+			if err == nil {
+				err = core.UpdateWorkflowSecretInFrontend(defaultWebBaseURL(), token, workflow.ID, "add", newSecretName)
+			}
+		} else if err == nil {
+			err = core.UpdateWorkflowSecretInFrontend(defaultWebBaseURL(), token, workflow.ID, action, secretName)
+		}
+		if err != nil {
+			err = fmt.Errorf("local update succeeded but frontend sync failed: %w", err)
+		} else if action == "rename" {
+			logs = append(logs, fmt.Sprintf("Renamed secret %s to %s in frontend workflow config.", secretName, newSecretName))
+		} else {
+			logs = append(logs, fmt.Sprintf("Synced secret %s to frontend workflow config (%s).", secretName, action))
+		}
+	}
+	if err != nil {
+		out := failedResult("secrets", logs, err)
+		out.Workflow = workflow.ID
+		return out
+	}
+	return &headlessResult{
+		Command:   "secrets",
+		OK:        true,
+		Logs:      logs,
+		OutputDir: workflow.ProjectRoot,
+		Workflow:  workflow.ID,
+	}
+}
+
+func runHeadlessSecretsReconcile(hc *headlessContext, workflow *core.LocalWorkflow, positional []string, target string) *headlessResult {
+	if len(positional) != 2 {
+		return usageResult("secrets", "reconcile takes no extra arguments")
+	}
+	token, err := loadHeadlessToken(hc)
+	if err != nil {
+		return failedResult("secrets", nil, err)
+	}
+	result, err := core.ReconcileWorkflowSecrets(defaultWebBaseURL(), token, workflow.ID, workflow.Name, target)
+	var logs []string
+	if result != nil {
+		logs = result.Logs
+	}
+	if err != nil {
+		out := failedResult("secrets", logs, err)
+		out.Workflow = workflow.ID
+		return out
+	}
+	secrets := make([]headlessSecret, 0, len(result.Entries))
+	for _, entry := range result.Entries {
+		secrets = append(secrets, headlessSecret{ID: entry.Name, EnvVar: entry.EnvVar, HasValue: entry.HasValue, Location: entry.Location})
+		if entry.Location != core.SecretInBoth {
+			logs = append(logs, fmt.Sprintf("  %s\t%s", entry.Name, entry.Location))
+		}
+	}
+	return &headlessResult{
+		Command:   "secrets",
+		OK:        true,
+		Logs:      logs,
+		OutputDir: workflow.ProjectRoot,
+		Workflow:  workflow.ID,
+		Secrets:   secrets,
+	}
+}
+
+func runHeadlessSecretsImport(workflow *core.LocalWorkflow, positional []string, target, from string, dryRun bool) *headlessResult {
+	if len(positional) != 2 {
+		return usageResult("secrets", "import takes no extra arguments")
+	}
+	if strings.TrimSpace(from) == "" {
+		return usageResult("secrets", "import requires --from <file>")
+	}
+	entries, err := core.ParseDotEnvFile(from)
+	if err != nil {
+		return failedResult("secrets", nil, err)
+	}
+
+	result, err := core.ImportLocalSecrets(workflow.ID, workflow.Name, target, entries, dryRun)
+	var (
+		logs    []string
+		secrets []headlessSecret
+	)
+	if result != nil {
+		logs = result.Logs
+		for _, change := range result.Changes {
+			secrets = append(secrets, headlessSecret{
+				ID:       change.ID,
+				EnvVar:   change.EnvVar,
+				HasValue: change.Action != core.SecretImportSkipped,
+				Action:   change.Action,
+			})
+		}
+	}
+	if err != nil {
+		out := failedResult("secrets", logs, err)
+		out.Workflow = workflow.ID
+		return out
+	}
+	return &headlessResult{
+		Command:   "secrets",
+		OK:        true,
+		Logs:      logs,
+		OutputDir: workflow.ProjectRoot,
+		Workflow:  workflow.ID,
+		Secrets:   secrets,
+	}
+}
+
+// runHeadlessSecretsClone copies the secrets of another synced workflow,
+// e.g. `secrets clone payments-v2 payments-v1 --values`.
+func runHeadlessSecretsClone(workflow *core.LocalWorkflow, positional []string, target string, withValues bool) *headlessResult {
+	if len(positional) != 3 {
+		return usageResult("secrets", "clone expects the workflow to copy secrets from")
+	}
+	source, err := core.ResolveLocalWorkflow(positional[2])
+	if err != nil {
+		return failedResult("secrets", nil, err)
+	}
+	result, err := core.CloneLocalSecrets(source.ID, source.Name, workflow.ID, workflow.Name, target, withValues)
+	var (
+		logs    []string
+		secrets []headlessSecret
+	)
+	if result != nil {
+		logs = result.Logs
+		hasValue := map[string]bool{}
+		if listed, err := core.ListLocalSecrets(workflow.ID, workflow.Name, target); err == nil {
+			for _, entry := range listed.Entries {
+				hasValue[entry.ID] = entry.HasValue
+			}
+		}
+		for _, change := range result.Changes {
+			secrets = append(secrets, headlessSecret{
+				ID:       change.ID,
+				EnvVar:   change.EnvVar,
+				HasValue: hasValue[change.ID],
+				Action:   change.Action,
+			})
+		}
+	}
+	if err != nil {
+		out := failedResult("secrets", logs, err)
+		out.Workflow = workflow.ID
+		return out
+	}
+	return &headlessResult{
+		Command:   "secrets",
+		OK:        true,
+		Logs:      logs,
+		OutputDir: workflow.ProjectRoot,
+		Workflow:  workflow.ID,
+		Secrets:   secrets,
+	}
+}
+
+// runHeadlessSecretsPrefill fills missing secrets from this process's
+// environment; --dry-run only lists them.
+func runHeadlessSecretsPrefill(workflow *core.LocalWorkflow, positional []string, target string, dryRun bool) *headlessResult {
+	if len(positional) != 2 {
+		return usageResult("secrets", "prefill takes no extra arguments")
+	}
+	result, err := core.PlanEnvPrefill(workflow.ID, workflow.Name, target)
+	if err == nil && !dryRun && len(result.Prefills) > 0 {
+		ids := make([]string, 0, len(result.Prefills))
+		for _, prefill := range result.Prefills {
+			ids = append(ids, prefill.ID)
+		}
+		result, err = core.ApplyEnvPrefill(workflow.ID, workflow.Name, target, ids)
+	}
+	var (
+		logs    []string
+		secrets []headlessSecret
+	)
+	if result != nil {
+		logs = result.Logs
+		for _, prefill := range result.Prefills {
+			secrets = append(secrets, headlessSecret{ID: prefill.ID, EnvVar: prefill.EnvVar, HasValue: !dryRun, Action: core.SecretImportUpdate})
+		}
+	}
+	if err != nil {
+		out := failedResult("secrets", logs, err)
+		out.Workflow = workflow.ID
+		return out
+	}
+	return &headlessResult{
+		Command:   "secrets",
+		OK:        true,
+		Logs:      logs,
+		OutputDir: workflow.ProjectRoot,
+		Workflow:  workflow.ID,
+		Secrets:   secrets,
+	}
+}
+
+// splitCommandLine tokenizes a batch line, honoring single and double quotes
+// so values containing spaces can be passed through.
+func splitCommandLine(line string) ([]string, error) {
+	var (
+		tokens  []string
+		current strings.Builder
+		quote   rune
+		inToken bool
+	)
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+				continue
+			}
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inToken = true
+		case r == ' ' || r == '\t':
+			if inToken {
+				tokens = append(tokens, current.String())
+				current.Reset()
+				inToken = false
+			}
+		default:
+			current.WriteRune(r)
+			inToken = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inToken {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+// redactCommandTokens renders a batch line for logs with the --token value
+// and the values of secrets KEY=VALUE arguments masked.
+func redactCommandTokens(tokens []string) string {
+	out := make([]string, len(tokens))
+	for i, token := range tokens {
+		out[i] = token
+		if i > 0 && isTokenFlag(tokens[i-1]) {
+			out[i] = "***"
+			continue
+		}
+		if strings.HasPrefix(token, "-") {
+			if name, _, ok := strings.Cut(token, "="); ok && isTokenFlag(name) {
+				out[i] = name + "=***"
+			}
+			continue
+		}
+		if len(tokens) > 1 && tokens[0] == "secrets" && i >= 2 {
+			if eq := strings.Index(token, "="); eq > 0 {
+				out[i] = token[:eq+1] + "***"
+			}
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func isTokenFlag(arg string) bool {
+	return arg == "--token" || arg == "-token"
+}
+
+// batchIdentity is the token, profile and organization that --token,
+// --profile and --org on a batch line change for the whole process.
+type batchIdentity struct {
+	token   string
+	profile string
+	org     string
+}
+
+func currentBatchIdentity() batchIdentity {
+	return batchIdentity{
+		token:   core.AuthTokenOverride(),
+		profile: core.ActiveAuthProfile(),
+		org:     core.ActiveOrganization(),
+	}
+}
+
+func (id batchIdentity) restore() {
+	core.SetAuthTokenOverride(id.token)
+	_ = core.SetAuthProfile(id.profile)
+	core.SetActiveOrganization(id.org)
+}
+
+type batchLine struct {
+	lineNo int
+	tokens []string
+}
+
+func runHeadlessBatch(hc *headlessContext, args []string) *headlessResult {
+	fs := newHeadlessFlagSet(hc, "batch")
+	keepGoing := fs.Bool("keep-going", false, "continue after a failed command")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return usageResult("batch", err.Error())
+	}
+	if len(positional) > 1 {
+		return usageResult("batch", "expected at most one script path")
+	}
+
+	var input io.Reader = os.Stdin
+	source := "stdin"
+	if len(positional) == 1 && positional[0] != "-" {
+		file, err := os.Open(positional[0])
+		if err != nil {
+			return failedResult("batch", nil, err)
+		}
+		defer file.Close()
+		input = file
+		source = positional[0]
+	}
+	raw, err := io.ReadAll(input)
+	if err != nil {
+		return failedResult("batch", nil, err)
+	}
+
+	// The whole script is checked before the first line runs.
+	lines := []batchLine{}
+	for idx, line := range strings.Split(string(raw), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		tokens, err := splitCommandLine(trimmed)
+		if err != nil {
+			return usageResult("batch", fmt.Sprintf("line %d: %v", idx+1, err))
+		}
+		if tokens[0] == "watch" {
+			return usageResult("batch", fmt.Sprintf("line %d: watch runs until interrupted and cannot be batched", idx+1))
+		}
+		lines = append(lines, batchLine{lineNo: idx + 1, tokens: tokens})
+	}
+
+	out := &headlessResult{Command: "batch", OK: true, Logs: []string{"Running batch script from " + source + "."}}
+	failed := 0
+	identity := currentBatchIdentity()
+	defer identity.restore()
+	for _, line := range lines {
+		lineNo, tokens := line.lineNo, line.tokens
+		display := redactCommandTokens(tokens)
+
+		var stepResult *headlessResult
+		command, ok := headlessCommands[tokens[0]]
+		switch {
+		case !ok:
+			stepResult = usageResult("batch", fmt.Sprintf("line %d: unknown command %q", lineNo, tokens[0]))
+		case tokens[0] == "batch":
+			stepResult = usageResult("batch", fmt.Sprintf("line %d: nested batch scripts are not supported", lineNo))
+		default:
+			stepHC := &headlessContext{stdout: hc.stdout, stderr: hc.stderr}
+			stepResult = command.run(stepHC, tokens[1:])
+			// --token, --profile and --org only apply to their own line.
+			identity.restore()
+		}
+		if stepResult.OK {
+			stepResult.ExitCode = exitOK
+		}
+		out.Steps = append(out.Steps, &batchStep{Line: lineNo, Command: display, Result: stepResult})
+
+		out.Logs = append(out.Logs, fmt.Sprintf("[line %d] $ %s", lineNo, display))
+		for _, logLine := range stepResult.Logs {
+			out.Logs = append(out.Logs, fmt.Sprintf("[line %d] %s", lineNo, logLine))
+		}
+		if !stepResult.OK {
+			failed++
+			out.Logs = append(out.Logs, fmt.Sprintf("[line %d] failed: %s", lineNo, stepResult.Error))
+			out.OK = false
+			out.ExitCode = stepResult.ExitCode
+			if !*keepGoing {
+				out.Error = fmt.Sprintf("batch stopped at line %d: %s", lineNo, stepResult.Error)
+				return out
+			}
+		}
+	}
+
+	out.Logs = append(out.Logs, fmt.Sprintf("Batch finished: %d command(s), %d failed.", len(out.Steps), failed))
+	if failed > 0 {
+		out.Error = fmt.Sprintf("%d batch command(s) failed", failed)
+	}
+	return out
+}
+
+func runHeadlessStatus(hc *headlessContext, args []string) *headlessResult {
+	fs := newHeadlessFlagSet(hc, "status")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return usageResult("status", err.Error())
+	}
+	if len(positional) != 0 {
+		return usageResult("status", "status takes no arguments")
+	}
+
+	status := &headlessStatus{Workflows: []headlessLocalWorkflow{}}
+	logs := []string{}
+
+	session, err := core.LoadAuthSession()
+	switch {
+	case err != nil:
+		status.Auth.Error = err.Error()
+		logs = append(logs, "auth: invalid ("+err.Error()+")")
+	case core.IsSessionValid(session):
+		status.Auth.Valid = true
+		status.Auth.Source = session.Source
+		if session.Exp != nil {
+			status.Auth.ExpiresAt = time.Unix(*session.Exp, 0).UTC().Format(time.RFC3339)
+		}
+		logs = append(logs, "auth: valid (source "+session.Source+")")
+		if core.ActiveOrganization() == "" {
+			core.SetActiveOrganization(session.OrgID)
+		}
+		if status.Auth.OrgID = core.ActiveOrganization(); status.Auth.OrgID != "" {
+			logs = append(logs, "auth: organization "+status.Auth.OrgID)
+		}
+	default:
+		logs = append(logs, "auth: not logged in")
+	}
+
+	creBinary, creSource := core.ResolveCREBinary()
+	if whoami, err := core.GetCREWhoAmI(); err != nil {
+		status.CRE.Installed = !errors.Is(err, core.ErrCRECLINotFound)
+		status.CRE.Error = err.Error()
+		switch {
+		case status.CRE.Installed:
+			logs = append(logs, "cre: not logged in")
+		case errors.As(err, new(*core.CREBinaryError)):
+			logs = append(logs, "cre: "+err.Error())
+		default:
+			logs = append(logs, "cre: not installed (run `6flow-tui cre install`)")
+		}
+	} else {
+		status.CRE.Installed = true
+		status.CRE.LoggedIn = true
+		status.CRE.Identity = whoami.Identity
+		status.CRE.Organization = whoami.Organization
+		logs = append(logs, "cre: logged in as "+whoami.Identity)
+		if status.Auth.Valid {
+			status.CRE.Mismatch = core.IdentityMismatch(session.Token, whoami)
+			if status.CRE.Mismatch != "" {
+				logs = append(logs, "warning: "+status.CRE.Mismatch)
+			}
+		}
+	}
+	if status.CRE.Installed {
+		status.CRE.Path = creBinary
+		logs = append(logs, fmt.Sprintf("cre: using %s (from %s)", creBinary, creSource))
+	}
+
+	status.Frontend.URL = core.NormalizeBaseURL(defaultWebBaseURL())
+	status.Frontend.Proxy = core.FrontendProxy(status.Frontend.URL)
+	if status.Auth.Valid {
+		workflows, err := core.FetchFrontendWorkflows(status.Frontend.URL, session.Token, core.WorkflowFilter{})
+		authorized := err == nil
+		switch {
+		case err == nil:
+			status.Frontend.Reachable = true
+			count := len(workflows)
+			status.Frontend.WorkflowCount = &count
+		case errors.Is(err, core.ErrFrontendUnauthorized):
+			status.Frontend.Reachable = true
+			status.Frontend.Error = err.Error()
+		default:
+			status.Frontend.Error = err.Error()
+		}
+		if status.Frontend.Reachable {
+			status.Frontend.Authorized = &authorized
+		}
+	} else if err := core.CheckFrontendReachable(status.Frontend.URL); err != nil {
+		status.Frontend.Error = err.Error()
+	} else {
+		status.Frontend.Reachable = true
+	}
+	if status.Frontend.Reachable {
+		logs = append(logs, "frontend: reachable ("+status.Frontend.URL+")")
+		if health, err := core.CheckFrontendHealth(status.Frontend.URL); err == nil {
+			latency := health.Latency.Milliseconds()
+			status.Frontend.Version = health.Version
+			status.Frontend.LatencyMs = &latency
+			line := fmt.Sprintf("frontend: latency %dms", latency)
+			if health.Version != "" {
+				line += ", version " + health.Version
+			}
+			logs = append(logs, line)
+			if !health.Healthy {
+				logs = append(logs, fmt.Sprintf("warning: frontend reports status %q", health.Status))
+			}
+		}
+		capabilities, err := core.CheckFrontendCompatibility(status.Frontend.URL)
+		if capabilities != nil {
+			status.Frontend.APIVersion = capabilities.APIVersion
+		}
+		if errors.Is(err, core.ErrIncompatibleClient) {
+			status.Frontend.Incompatible = err.Error()
+			logs = append(logs, "warning: "+err.Error())
+		}
+	} else {
+		logs = append(logs, "frontend: unreachable ("+status.Frontend.URL+")")
+	}
+
+	local, err := core.ListLocalWorkflows()
+	if err != nil {
+		return failedResult("status", logs, err)
+	}
+	// Drift is informational; status still reports the projects without it.
+	drifts, _ := core.DetectAllLocalDrift()
+	for _, wf := range local {
+		entry := headlessLocalWorkflow{ID: wf.ID, Name: wf.Name, Path: wf.ProjectRoot}
+		if drift := drifts[wf.ID]; drift != nil {
+			entry.Modified = drift.Modified
+			entry.Deleted = drift.Deleted
+		}
+		status.Workflows = append(status.Workflows, entry)
+	}
+	logs = append(logs, fmt.Sprintf("local workflows: %d", len(local)))
+	for _, wf := range status.Workflows {
+		line := fmt.Sprintf("  %s\t%s\t%s", wf.ID, wf.Name, wf.Path)
+		if changed := len(wf.Modified) + len(wf.Deleted); changed > 0 {
+			line += fmt.Sprintf("\t(modified locally: %d)", changed)
+		}
+		logs = append(logs, line)
+	}
+
+	status.Healthy = status.Auth.Valid && status.CRE.LoggedIn && status.Frontend.Reachable &&
+		(status.Frontend.Authorized == nil || *status.Frontend.Authorized) && status.Frontend.Incompatible == ""
+	return &headlessResult{
+		Command: "status",
+		OK:      true,
+		Logs:    logs,
+		Status:  status,
+	}
+}
+
+func runHeadlessLogout(hc *headlessContext, args []string) *headlessResult {
+	fs := newHeadlessFlagSet(hc, "logout")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return usageResult("logout", err.Error())
+	}
+	if len(positional) != 0 {
+		return usageResult("logout", "logout takes no arguments")
+	}
+
+	// An unreadable session can still be removed locally.
+	session, _ := core.LoadAuthSession()
+	if session != nil && session.Source != core.SessionSourceFile {
+		return failedResult("logout", nil, errors.New("token comes from --token or "+core.AuthTokenEnv+"; nothing saved to log out of"))
+	}
+
+	token := ""
+	if session != nil {
+		token = session.Token
+	}
+	logs := []string{"profile: " + core.ActiveAuthProfile()}
+	baseURL := defaultWebBaseURL()
+	if token != "" && !core.IsTrustedHost(baseURL) && !hc.trustHost {
+		if err := core.ClearAuthSession(); err != nil {
+			return failedResult("logout", logs, err)
+		}
+		logs = append(logs, "Session cleared locally; not revoked because "+baseURL+" is not a trusted host.")
+		return &headlessResult{Command: "logout", OK: true, Logs: logs}
+	}
+	err = core.RevokeAndClearAuthSession(baseURL, token)
+	switch {
+	case err == nil && token == "":
+		logs = append(logs, "No saved session; nothing to revoke.")
+	case err == nil:
+		logs = append(logs, "Session token revoked and cleared.")
+	case errors.Is(err, core.ErrRevocationUnsupported):
+		logs = append(logs, "Session cleared locally (frontend has no revocation endpoint).")
+	default:
+		logs = append(logs, "Session cleared locally; server-side revocation failed: "+err.Error())
+	}
+	return &headlessResult{Command: "logout", OK: true, Logs: logs}
+}
+
+type watchEvent struct {
+	Time     string   `json:"time"`
+	Event    string   `json:"event"`
+	Workflow string   `json:"workflowId"`
+	Message  string   `json:"message"`
+	Logs     []string `json:"logs,omitempty"`
+}
+
+func workflowFingerprint(wf *core.FrontendWorkflow) string {
+	return fmt.Sprintf("%d|%s|%s", wf.UpdatedAt, wf.CompilerVersion, wf.Status)
+}
+
+// runHeadlessWatch polls the frontend and re-syncs whenever the compiled
+// bundle changes. Progress is streamed as it happens (one JSON object per line
+// with --json) and the command runs until interrupted.
+func runHeadlessWatch(hc *headlessContext, args []string) *headlessResult {
+	fs := newHeadlessFlagSet(hc, "watch")
+	interval := fs.Duration("interval", 10*time.Second, "how often to poll the frontend API")
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return usageResult("watch", err.Error())
+	}
+	if len(positional) != 1 {
+		return usageResult("watch", "expected exactly one workflow id or name")
+	}
+	if *interval < time.Second {
+		return usageResult("watch", "--interval must be at least 1s")
+	}
+
+	token, err := loadHeadlessToken(hc)
+	if err != nil {
+		return failedResult("watch", nil, err)
+	}
+	baseURL := defaultWebBaseURL()
+
+	var workflowID string
+	emit := func(event, message string, logs []string) {
+		if hc.jsonOutput {
+			line, _ := json.Marshal(watchEvent{
+				Time:     time.Now().UTC().Format(time.RFC3339),
+				Event:    event,
+				Workflow: workflowID,
+				Message:  message,
+				Logs:     logs,
+			})
+			fmt.Fprintln(hc.stdout, string(line))
+			return
+		}
+		fmt.Fprintln(hc.stdout, withTimestamp(message))
+		for _, l := range logs {
+			fmt.Fprintln(hc.stdout, "  "+l)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var (
+		lastSynced string
+		lastSeen   string
+		syncs      int
+		failures   int
+	)
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+	for {
+		workflows, err := core.FetchFrontendWorkflows(baseURL, token, core.WorkflowFilter{})
+		switch {
+		case errors.Is(err, core.ErrFrontendUnauthorized):
+			out := failedResult("watch", nil, err)
+			out.Workflow = workflowID
+			return out
+		case err != nil:
+			emit("error", "Poll failed: "+err.Error(), nil)
+		default:
+			workflow, findErr := findFrontendWorkflow(workflows, positional[0])
+			if findErr != nil {
+				return failedResult("watch", nil, findErr)
+			}
+			if workflowID == "" {
+				workflowID = workflow.ID
+				emit("start", fmt.Sprintf("Watching %s (%s) every %s. Press Ctrl+C to stop.", workflow.Name, workflow.ID, *interval), nil)
+			}
+			fingerprint := workflowFingerprint(workflow)
+			switch {
+			case fingerprint == lastSynced:
+			case workflow.Status != "ready":
+				if fingerprint == lastSeen {
+					break
+				}
+				emit("pending", fmt.Sprintf("Workflow changed but is not compiled yet (status %s).", workflow.Status), nil)
+			default:
+				result, syncErr := core.SyncWorkflowToLocal(baseURL, token, workflow.ID, workflow.Name)
+				var logs []string
+				if result != nil {
+					logs = result.Logs
+				}
+				if syncErr != nil {
+					failures++
+					emit("error", "Sync failed: "+syncErr.Error(), logs)
+				} else {
+					syncs++
+					lastSynced = fingerprint
+					emit("synced", fmt.Sprintf("Synced %s to %s.", workflow.Name, result.OutputDir), logs)
+				}
+			}
+			lastSeen = fingerprint
+		}
+
+		select {
+		case <-ctx.Done():
+			return &headlessResult{
+				Command:  "watch",
+				OK:       true,
+				Workflow: workflowID,
+				Logs:     []string{fmt.Sprintf("Watch stopped: %d sync(s), %d failure(s).", syncs, failures)},
+			}
+		case <-ticker.C:
+		}
+	}
+}
+
+func writeHeadlessResult(hc *headlessContext, result *headlessResult) {
+	if result.Logs == nil {
+		result.Logs = []string{}
+	}
+	if hc.jsonOutput {
+		enc := json.NewEncoder(hc.stdout)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(result)
+		return
+	}
+	for _, line := range result.Logs {
+		fmt.Fprintln(hc.stdout, line)
+	}
+	for _, secret := range result.Secrets {
+		status := "missing"
+		if secret.HasValue {
+			status = "set"
+		}
+		if secret.Action != "" {
+			status = secret.Action
+		}
+		fmt.Fprintf(hc.stdout, "%s\t%s\t%s\n", secret.ID, secret.EnvVar, status)
+	}
+	if result.Error != "" {
+		fmt.Fprintln(hc.stderr, "error: "+result.Error)
+	}
+}
+
+func runHeadless(args []string, stdout, stderr io.Writer) int {
+	hc := &headlessContext{stdout: stdout, stderr: stderr}
+	// Progress notes go to stderr so stdout stays machine-readable.
+	core.SetFrontendNotice(func(message string) {
+		fmt.Fprintln(stderr, "note: "+message)
+	})
+	if err := core.SetAuthProfile(""); err != nil {
+		fmt.Fprintln(stderr, "error: "+err.Error())
+		return exitUsage
+	}
+	if _, err := core.ConfigureHTTPTrace(); err != nil {
+		fmt.Fprintln(stderr, "warning: HTTP trace is off: "+err.Error())
+	}
+	if core.ConfigureDryRun() {
+		fmt.Fprintln(stderr, "note: dry run: commands, file writes and frontend secret updates are only printed")
+	}
+	name := args[0]
+	command, ok := headlessCommands[name]
+	if !ok {
+		headlessUsage(stdout)
+		return exitOK
+	}
+
+	result := command.run(hc, args[1:])
+	if result.OK {
+		result.ExitCode = exitOK
+	} else if result.ExitCode == exitOK {
+		result.ExitCode = exitError
+	}
+	writeHeadlessResult(hc, result)
+	return result.ExitCode
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	core "github.com/6flow/6flow-convergence/tools/tui/internal/tui"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{line: "", want: nil},
+		{line: "   \t ", want: nil},
+		{line: "sync wf-1", want: []string{"sync", "wf-1"}},
+		{line: "  sync\t wf-1  ", want: []string{"sync", "wf-1"}},
+		{line: `secrets wf "NAME=a b c"`, want: []string{"secrets", "wf", "NAME=a b c"}},
+		{line: `secrets wf NAME='x "y" z'`, want: []string{"secrets", "wf", `NAME=x "y" z`}},
+		{line: `simulate "" next`, want: []string{"simulate", "", "next"}},
+		{line: `a"b c"d`, want: []string{"ab cd"}},
+		{line: `sync "wf`, wantErr: true},
+		{line: `sync 'wf`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := splitCommandLine(tt.line)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("splitCommandLine(%q) error = %v, wantErr %v", tt.line, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommandLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestRedactCommandTokens(t *testing.T) {
+	tests := []struct {
+		tokens []string
+		want   string
+	}{
+		{[]string{"sync", "wf-1"}, "sync wf-1"},
+		{[]string{"sync", "wf-1", "--token", "secret"}, "sync wf-1 --token ***"},
+		{[]string{"status", "-token", "secret", "--json"}, "status -token *** --json"},
+		{[]string{"simulate", "wf", "--token=secret"}, "simulate wf --token=***"},
+		{[]string{"status", "-token=secret"}, "status -token=***"},
+		{[]string{"secrets", "add", "wf", "API_KEY=value", "--target=staging-settings"}, "secrets add wf API_KEY=*** --target=staging-settings"},
+		{[]string{"secrets", "add", "wf", "KEY=v", "--token", "t"}, "secrets add wf KEY=*** --token ***"},
+		{[]string{"sync", "wf", "--tokenizer=x"}, "sync wf --tokenizer=x"},
+	}
+	for _, tt := range tests {
+		if got := redactCommandTokens(tt.tokens); got != tt.want {
+			t.Errorf("redactCommandTokens(%q) = %q, want %q", tt.tokens, got, tt.want)
+		}
+	}
+}
+
+func writeBatchScript(t *testing.T, script string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "script.txt")
+	if err := os.WriteFile(path, []byte(script), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRunHeadlessBatchRejectsWatch(t *testing.T) {
+	hc := &headlessContext{stdout: os.Stdout, stderr: os.Stderr}
+	result := runHeadlessBatch(hc, []string{writeBatchScript(t, "sync\nwatch wf-1\n")})
+	if result.ExitCode != exitUsage || len(result.Steps) != 0 {
+		t.Fatalf("batch with watch = exit %d after %d step(s), want a usage error before any step", result.ExitCode, len(result.Steps))
+	}
+}
+
+func TestRunHeadlessBatchScopesIdentityToLine(t *testing.T) {
+	core.SetAuthTokenOverride("outer-token")
+	core.SetActiveOrganization("outer-org")
+	t.Cleanup(func() {
+		core.SetAuthTokenOverride("")
+		core.SetActiveOrganization("")
+	})
+
+	// sync without a workflow fails after its flags were applied.
+	script := "sync --token line-token --org line-org\nsync --profile other\n"
+	hc := &headlessContext{stdout: os.Stdout, stderr: os.Stderr}
+	result := runHeadlessBatch(hc, []string{"--keep-going", writeBatchScript(t, script)})
+	if len(result.Steps) != 2 {
+		t.Fatalf("batch ran %d step(s), want 2", len(result.Steps))
+	}
+	if got := result.Steps[0].Command; got != "sync --token *** --org line-org" {
+		t.Fatalf("step command = %q", got)
+	}
+	if core.AuthTokenOverride() != "outer-token" || core.ActiveOrganization() != "outer-org" || core.ActiveAuthProfile() != core.DefaultAuthProfile {
+		t.Fatalf("identity after batch = %q, %q, %q", core.AuthTokenOverride(), core.ActiveOrganization(), core.ActiveAuthProfile())
+	}
+}
@@ -2,12 +2,16 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -33,10 +37,15 @@ const (
 	phaseCheckingAuth appPhase = "checkingAuth"
 	phaseAuthGate     appPhase = "authGate"
 	phaseLinking      appPhase = "linking"
+	phaseTrustHost    appPhase = "trustHost"
 	phaseReady        appPhase = "ready"
 )
 
-const workflowSyncListItemID = "__sync_list__"
+const (
+	workflowSyncListItemID = "__sync_list__"
+	workflowLoadMoreItemID = "__load_more__"
+	workflowSyncAllItemID  = "__sync_all__"
+)
 
 const (
 	focusWorkflows focusPane = iota
@@ -58,6 +67,7 @@ type authState string
 const (
 	authConnected    authState = "connected"
 	authDisconnected authState = "disconnected"
+	authUnreachable  authState = "unreachable"
 )
 
 type workflowItem struct {
@@ -65,10 +75,18 @@ type workflowItem struct {
 	title       string
 	description string
 	status      string
+	// modified counts the files of the synced project edited by hand since
+	// the last sync.
+	modified int
 }
 
-func (i workflowItem) Title() string       { return i.title }
-func (i workflowItem) Description() string { return i.description }
+func (i workflowItem) Title() string { return i.title }
+func (i workflowItem) Description() string {
+	if i.modified > 0 {
+		return fmt.Sprintf("✎ modified locally (%d) • %s", i.modified, i.description)
+	}
+	return i.description
+}
 func (i workflowItem) FilterValue() string { return i.title }
 
 type actionItem struct {
@@ -81,6 +99,9 @@ func (i actionItem) Title() string       { return i.title }
 func (i actionItem) Description() string { return i.description }
 func (i actionItem) FilterValue() string { return i.title }
 
+// secretMask stands in for secret values on screen and in the console.
+const secretMask = "••••"
+
 type secretPickItem struct {
 	id           string
 	key          string
@@ -89,25 +110,124 @@ type secretPickItem struct {
 	currentValue string
 	description  string
 	selectable   bool
+	revealed     bool
 }
 
-func (i secretPickItem) Title() string       { return i.id }
-func (i secretPickItem) Description() string { return i.description }
+func (i secretPickItem) Title() string { return i.id }
+
+// Description appends the current value, masked unless the picker is
+// revealing values.
+func (i secretPickItem) Description() string {
+	if i.currentValue == "" {
+		return i.description
+	}
+	if i.revealed {
+		if lines := strings.Count(i.currentValue, "\n") + 1; lines > 1 {
+			first, _, _ := strings.Cut(i.currentValue, "\n")
+			return fmt.Sprintf("%s · %s … (%d lines)", i.description, first, lines)
+		}
+		return i.description + " · " + i.currentValue
+	}
+	return i.description + " · " + secretMask
+}
 func (i secretPickItem) FilterValue() string { return i.id }
 
+// secretDiffItem is one row of the secrets diff: where the secret is declared,
+// configured and set, and what Enter and d do about it.
+type secretDiffItem struct {
+	entry      core.SecretDiffEntry
+	dotEnvName string
+}
+
+func (i secretDiffItem) Title() string { return i.entry.Name }
+
+func (i secretDiffItem) Description() string {
+	mark := func(ok bool) string {
+		if ok {
+			return "✓"
+		}
+		return "✗"
+	}
+	description := fmt.Sprintf("secrets.yaml %s · frontend %s · %s %s",
+		mark(i.entry.Local), mark(i.entry.Frontend), i.dotEnvName, mark(i.entry.HasValue))
+	if i.entry.InSync() {
+		return description + " · in sync"
+	}
+	if fix := secretDiffFix(i.entry); fix != "" {
+		description += " · enter: " + secretDiffActionLabels[fix]
+	}
+	if fix := secretDiffDelete(i.entry); fix != "" {
+		description += " · d: " + secretDiffActionLabels[fix]
+	}
+	return description
+}
+
+func (i secretDiffItem) FilterValue() string { return i.entry.Name }
+
+var secretDiffActionLabels = map[string]string{
+	"declare":   "declare in secrets.yaml",
+	"add":       "add locally with a value",
+	"push":      "add to the frontend",
+	"update":    "set the value",
+	"purge":     "delete locally",
+	"drop":      "remove from .env",
+	"unpublish": "remove from the frontend",
+}
+
+// secretDiffFix is what Enter does for a diff row: fill in the missing side.
+func secretDiffFix(entry core.SecretDiffEntry) string {
+	switch {
+	case entry.InSync():
+		return ""
+	case !entry.Local && entry.HasValue:
+		return "declare"
+	case !entry.Local:
+		return "add"
+	case !entry.Frontend:
+		return "push"
+	default:
+		return "update"
+	}
+}
+
+// secretDiffDelete is what d does for a diff row: drop the side the secret
+// only exists on.
+func secretDiffDelete(entry core.SecretDiffEntry) string {
+	switch {
+	case entry.Local && !entry.Frontend:
+		return "purge"
+	case !entry.Local && !entry.Frontend && entry.HasValue:
+		return "drop"
+	case !entry.Local && entry.Frontend && !entry.HasValue:
+		return "unpublish"
+	}
+	return ""
+}
+
 type keyMap struct {
-	Pane1  key.Binding
-	Pane2  key.Binding
-	Pane3  key.Binding
-	Next   key.Binding
-	Up     key.Binding
-	Down   key.Binding
-	Run    key.Binding
-	Top    key.Binding
-	Bottom key.Binding
-	Clear  key.Binding
-	Login  key.Binding
-	Quit   key.Binding
+	Pane1   key.Binding
+	Pane2   key.Binding
+	Pane3   key.Binding
+	Next    key.Binding
+	Up      key.Binding
+	Down    key.Binding
+	Run     key.Binding
+	Top     key.Binding
+	Bottom  key.Binding
+	Clear   key.Binding
+	Login   key.Binding
+	Profile key.Binding
+	Account key.Binding
+	Org     key.Binding
+	Filter  key.Binding
+	CRE     key.Binding
+	Install key.Binding
+	DryRun  key.Binding
+	Target  key.Binding
+	Reauth  key.Binding
+	Logout  key.Binding
+	Cancel  key.Binding
+	Quit    key.Binding
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
@@ -117,24 +237,35 @@ func (k keyMap) ShortHelp() []key.Binding {
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Pane1, k.Pane2, k.Pane3, k.Next},
-		{k.Up, k.Down, k.Run, k.Clear},
-		{k.Top, k.Bottom, k.Login, k.Quit},
+		{k.Up, k.Down, k.Run, k.Cancel, k.Filter, k.Clear},
+		{k.Top, k.Bottom, k.Login, k.Profile, k.Account, k.Org, k.CRE, k.Install, k.DryRun, k.Target, k.Reauth, k.Logout, k.Quit},
 	}
 }
 
 var keys = keyMap{
-	Pane1:  key.NewBinding(key.WithKeys("1"), key.WithHelp("1", "workflows")),
-	Pane2:  key.NewBinding(key.WithKeys("2"), key.WithHelp("2", "actions")),
-	Pane3:  key.NewBinding(key.WithKeys("3"), key.WithHelp("3", "console")),
-	Next:   key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "next pane")),
-	Up:     key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
-	Down:   key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
-	Run:    key.NewBinding(key.WithKeys("enter", "space"), key.WithHelp("enter", "run/select")),
-	Top:    key.NewBinding(key.WithKeys("g"), key.WithHelp("g", "console top")),
-	Bottom: key.NewBinding(key.WithKeys("G"), key.WithHelp("G", "console bottom")),
-	Clear:  key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "copy selected line")),
-	Login:  key.NewBinding(key.WithKeys("y", "n"), key.WithHelp("y/n", "login or quit")),
-	Quit:   key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
+	Pane1:   key.NewBinding(key.WithKeys("1"), key.WithHelp("1", "workflows")),
+	Pane2:   key.NewBinding(key.WithKeys("2"), key.WithHelp("2", "actions")),
+	Pane3:   key.NewBinding(key.WithKeys("3"), key.WithHelp("3", "console")),
+	Next:    key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "next pane")),
+	Up:      key.NewBinding(key.WithKeys("up", "k"), key.WithHelp("↑/k", "up")),
+	Down:    key.NewBinding(key.WithKeys("down", "j"), key.WithHelp("↓/j", "down")),
+	Run:     key.NewBinding(key.WithKeys("enter", "space"), key.WithHelp("enter", "run/select")),
+	Top:     key.NewBinding(key.WithKeys("g"), key.WithHelp("g", "console top")),
+	Bottom:  key.NewBinding(key.WithKeys("G"), key.WithHelp("G", "console bottom")),
+	Clear:   key.NewBinding(key.WithKeys("c"), key.WithHelp("c", "copy selected line")),
+	Login:   key.NewBinding(key.WithKeys("y", "n"), key.WithHelp("y/n", "login or quit")),
+	Profile: key.NewBinding(key.WithKeys("P"), key.WithHelp("P", "switch profile")),
+	Account: key.NewBinding(key.WithKeys("A"), key.WithHelp("A", "accounts")),
+	Org:     key.NewBinding(key.WithKeys("O"), key.WithHelp("O", "organization")),
+	Filter:  key.NewBinding(key.WithKeys("/"), key.WithHelp("/", "filter workflows")),
+	CRE:     key.NewBinding(key.WithKeys("C"), key.WithHelp("C", "cre login")),
+	Install: key.NewBinding(key.WithKeys("I"), key.WithHelp("I", "install cre")),
+	DryRun:  key.NewBinding(key.WithKeys("D"), key.WithHelp("D", "toggle dry run")),
+	Target:  key.NewBinding(key.WithKeys("T"), key.WithHelp("T", "switch target")),
+	Reauth:  key.NewBinding(key.WithKeys("R"), key.WithHelp("R", "re-authenticate")),
+	Logout:  key.NewBinding(key.WithKeys("L"), key.WithHelp("L", "log out")),
+	Cancel:  key.NewBinding(key.WithKeys("ctrl+x"), key.WithHelp("ctrl+x", "cancel running action")),
+	Quit:    key.NewBinding(key.WithKeys("q", "ctrl+c"), key.WithHelp("q", "quit")),
 }
 
 type loadedSessionMsg struct {
@@ -143,10 +274,30 @@ type loadedSessionMsg struct {
 }
 
 type workflowsLoadedMsg struct {
-	workflows []core.FrontendWorkflow
+	workflows  []core.FrontendWorkflow
+	nextCursor string
+	// appended marks a "Load more" page rather than a fresh first page.
+	appended bool
+	// stale is set when the list came from the on-disk cache because the
+	// frontend could not be reached.
+	stale     bool
+	fetchedAt time.Time
 	err       error
 }
 
+type creLoginFinishedMsg struct {
+	err error
+}
+
+type creInstalledMsg struct {
+	result *core.CREInstallResult
+	err    error
+}
+
+type hostInspectedMsg struct {
+	info *core.HostTrustInfo
+}
+
 type loginFinishedMsg struct {
 	token string
 	err   error
@@ -158,10 +309,16 @@ type actionFinishedMsg struct {
 }
 
 type preSimulateReadyMsg struct {
-	logs        []string
-	projectRoot string
-	cmdArgs     []string
-	err         error
+	projectRoot     string
+	cmdArgs         []string
+	needsPassphrase bool
+	env             []string
+	err             error
+}
+
+type keystoreUnlockedMsg struct {
+	env []string
+	err error
 }
 
 type syncLocalFinishedMsg struct {
@@ -169,12 +326,39 @@ type syncLocalFinishedMsg struct {
 	err  error
 }
 
-type creWhoAmIFinishedMsg struct {
-	identity string
-	raw      string
+type syncAllFinishedMsg struct {
+	result *core.SyncAllResult
+	err    error
+}
+
+type orphanedProjectsMsg struct {
+	removals []core.LocalProjectRemoval
+	skipped  int
 	err      error
 }
 
+type localProjectsRemovedMsg struct {
+	result *core.LocalProjectsRemoveResult
+	err    error
+}
+
+type localDriftMsg struct {
+	drift map[string]*core.LocalDrift
+	err   error
+}
+
+type syncPreviewMsg struct {
+	preview *core.SyncPreview
+	err     error
+}
+
+type creWhoAmIFinishedMsg struct {
+	identity     string
+	organization string
+	raw          string
+	err          error
+}
+
 type secretsCmdFinishedMsg struct {
 	logs  []string
 	label string
@@ -188,6 +372,22 @@ type secretOptionsLoadedMsg struct {
 	err      error
 }
 
+type secretsDiffLoadedMsg struct {
+	logs   []string
+	result *core.SecretsDiffResult
+	// fix is set when the message follows a single-key fix.
+	fix string
+	err error
+}
+
+type secretsPrefillMsg struct {
+	logs     []string
+	prefills []core.SecretPrefill
+	// applied is false for the plan shown before confirming.
+	applied bool
+	err     error
+}
+
 type variableOptionsLoadedMsg struct {
 	logs    []string
 	options []core.LocalVariableEntry
@@ -198,6 +398,138 @@ type copyNoticeClearedMsg struct {
 	id int
 }
 
+type secretCopiedMsg struct {
+	name  string
+	value string
+	err   error
+}
+
+type clipboardClearedMsg struct {
+	cleared bool
+	err     error
+}
+
+type sessionTickMsg struct{}
+
+type healthTickMsg struct{}
+
+type creSessionTickMsg struct{}
+
+// creSessionCheckedMsg is a quiet `cre whoami` re-check. resume runs the
+// selected action again once the login is confirmed.
+type creSessionCheckedMsg struct {
+	result *core.CREWhoAmIResult
+	err    error
+	resume bool
+}
+
+type connectivityMsg struct {
+	err error
+}
+
+type healthPingMsg struct {
+	token string
+	err   error
+}
+
+type sessionRevokedMsg struct {
+	err error
+}
+
+type loginStartedMsg struct {
+	ch <-chan tea.Msg
+}
+
+type loginPromptMsg struct {
+	prompt core.LoginPrompt
+}
+
+// workflowEventMsg and workflowEventsClosedMsg carry the generation of the
+// stream they belong to so events from a stopped stream are dropped.
+type workflowEventMsg struct {
+	gen   int
+	event core.WorkflowEvent
+}
+
+type workflowEventsClosedMsg struct {
+	gen int
+	err error
+}
+
+type workflowEventsRetryMsg struct {
+	gen int
+}
+
+// frontendNoticeMsg is a progress note from the frontend client, e.g. a rate
+// limit wait, delivered while the request is still running.
+type frontendNoticeMsg struct {
+	text string
+}
+
+// organizationsLoadedMsg answers an org list request; pick opens the picker,
+// otherwise it only hints when the account has several organizations.
+type organizationsLoadedMsg struct {
+	token string
+	orgs  []core.FrontendOrganization
+	pick  bool
+	err   error
+}
+
+type frontendHealthMsg struct {
+	baseURL string
+	health  *core.FrontendHealth
+	err     error
+}
+
+type compatibilityMsg struct {
+	baseURL string
+	err     error
+}
+
+type compileRequestedMsg struct {
+	token string
+	id    string
+	name  string
+	err   error
+}
+
+type compilePollMsg struct {
+	id string
+}
+
+type compileStatusMsg struct {
+	token    string
+	id       string
+	name     string
+	workflow *core.FrontendWorkflow
+	err      error
+}
+
+type workflowDetailLoadedMsg struct {
+	detail *core.WorkflowDetail
+	err    error
+}
+
+type workflowRunsLoadedMsg struct {
+	workflowID string
+	runs       []core.WorkflowRun
+	err        error
+}
+
+type bundleVersionsLoadedMsg struct {
+	workflowID string
+	versions   []core.BundleVersion
+	err        error
+}
+
+type configPushedMsg struct {
+	workflowID string
+	name       string
+	result     *core.ConfigPushResult
+	applied    bool
+	err        error
+}
+
 type simulateStreamStartedMsg struct {
 	ch <-chan tea.Msg
 }
@@ -210,10 +542,112 @@ type simulateStreamDoneMsg struct {
 	err error
 }
 
+type deployPreflightMsg struct {
+	preflight *core.DeployPreflight
+	err       error
+}
+
+type walletLoadedMsg struct {
+	workflowID string
+	target     string
+	wallet     *core.WalletInfo
+	err        error
+}
+
+type rollbackSyncedMsg struct {
+	logs   []string
+	target string
+	err    error
+}
+
+type deploymentOpFinishedMsg struct {
+	op     core.DeploymentOp
+	target string
+	err    error
+}
+
+type deployFinishedMsg struct {
+	result *core.DeployCommandResult
+	err    error
+}
+
 type model struct {
-	phase     appPhase
-	authState authState
-	token     string
+	phase         appPhase
+	authState     authState
+	token         string
+	sessionSource string
+	sessionExp    *int64
+	reauthPrompt  bool
+	reauthing     bool
+	profile       string
+	account       string
+	addingAccount bool
+	loginCh       <-chan tea.Msg
+	loginPrompt   *core.LoginPrompt
+	hostTrust     *core.HostTrustInfo
+
+	// accountWorkflows keeps the last workflow list per profile so switching
+	// back shows it immediately while the refresh runs.
+	accountWorkflows map[string][]core.FrontendWorkflow
+	accountPickOpen  bool
+	accountList      list.Model
+	orgID            string
+	orgName          string
+	orgPickOpen      bool
+	orgList          list.Model
+	workflowsCursor  string
+	compilePromptID  string
+	compilingID      string
+	compilingName    string
+	compileDeadline  time.Time
+	configPushID     string
+	workflowFilter   core.WorkflowFilter
+	filterOpen       bool
+	filterInput      textinput.Model
+	releaseInstance  func()
+
+	// localWorkflows holds the synced projects behind an offline list.
+	localWorkflows map[string]core.LocalWorkflow
+	// localDrift counts the hand-edited files of each synced project, by
+	// workflow ID; see localDriftCmd.
+	localDrift map[string]int
+
+	// eventsCancel stops the live workflow event stream; eventsGen changes
+	// whenever a stream is started or stopped.
+	eventsCancel      context.CancelFunc
+	eventsCh          <-chan tea.Msg
+	eventsGen         int
+	eventsUnsupported bool
+
+	detailOpen bool
+	detailView viewport.Model
+	detailName string
+
+	runsOpen         bool
+	runsWorkflowName string
+	runsList         list.Model
+	runs             []core.WorkflowRun
+	versionsOpen     bool
+	versionsID       string
+	versionsName     string
+	versionsList     list.Model
+	versions         []core.BundleVersion
+
+	deploymentsOpen bool
+	deploymentsID   string
+	deploymentsName string
+	deploymentsList list.Model
+	// deployments is newest first, in list order.
+	deployments []core.DeploymentRecord
+	// deploymentOp is the pause/activate/delete waiting for confirmation.
+	deploymentOp       core.DeploymentOp
+	deploymentOpRecord core.DeploymentRecord
+	deploymentOpInput  textinput.Model
+	deploymentOpError  string
+
+	// wallet is the CRE_ETH_PRIVATE_KEY account shown in the secrets screens.
+	wallet    *core.WalletInfo
+	walletErr string
 
 	busy          bool
 	lastSyncAt    string
@@ -222,6 +656,27 @@ type model struct {
 	workflowCount int
 	creLoggedIn   bool
 	creIdentity   string
+	creChecked    bool
+	creMissing    bool
+	creWhoAmI     *core.CREWhoAmIResult
+	// creCheckedAt is when `cre whoami` last succeeded; creExpired is set
+	// when a later check found the CLI session gone.
+	creCheckedAt time.Time
+	creExpired   bool
+	// sessionCREIdentity is the CRE login last used with this account.
+	sessionCREIdentity string
+	identityMismatch   string
+	// incompatible is set when the frontend requires a newer TUI.
+	incompatible string
+	// offline is set while an expired session is kept for local work because
+	// the frontend is unreachable; expiredSession is revoked once it is back.
+	offline        bool
+	expiredSession *core.AuthSession
+
+	workflowsLoaded  bool
+	startupWorkflow  string
+	startupAction    string
+	startupLinkReady bool
 
 	width  int
 	height int
@@ -237,11 +692,18 @@ type model struct {
 	secretsMenuOpen         bool
 	secretsWorkflowID       string
 	secretsWorkflowName     string
-	secretsTargets          []string
-	secretsTargetIndex      int
+	targets                 []string
+	targetIndex             int
 	secretPickOpen          bool
 	secretPickAction        string
 	secretPickList          list.Model
+	secretsDiffOpen         bool
+	secretsDiffList         list.Model
+	secretsCloneOpen        bool
+	secretsCloneList        list.Model
+	secretsCloneValues      bool
+	secretsDiffArmed        string
+	secretsPrefillIDs       []string
 	variablePickerOpen      bool
 	variablePickerFocus     int
 	systemVariableList      list.Model
@@ -252,19 +714,49 @@ type model struct {
 	secretFormVariableKey   string
 	secretIDInput           textinput.Model
 	secretValueInput        textinput.Model
+	secretValueBlock        string
 	secretFormActiveField   int
 	secretFormError         string
 	secretIDLocked          bool
 	secretRemoveFromConvex  bool
+	secretRemoveDeclaration bool
+	secretRemoveArmed       bool
+	secretsRevealed         bool
+	secretsBackend          string
+	// secretValues are masked in every console line; see appendLog.
+	secretValues            []string
 	simulateFormOpen        bool
 	simulateTxHashInput     textinput.Model
 	simulateEventIndexInput textinput.Model
 	simulateFormActiveField int
 	simulateFormError       string
 	simulateNeedsEVMFlags   bool
+	simulateNeedsPayload    bool
+	simulatePayloadOpen     bool
+	simulatePayloads        []string
+	simulatePayloadIndex    int
 	simulatePendingRoot     string
 	simulatePendingArgs     []string
+	simulateWorkflowID      string
+	simulateWorkflowName    string
+	simulatePassphraseOpen  bool
+	tokenPasteOpen          bool
+	tokenPasteInput         textinput.Model
+	tokenPasteError         string
+	simulatePassphraseInput textinput.Model
+	simulatePassphraseError string
+	simulateExtraEnv        []string
 	simulateStreamCh        <-chan tea.Msg
+	deployConfirmOpen       bool
+	deployWorkflowID        string
+	deployWorkflowName      string
+	deployPreflight         *core.DeployPreflight
+	syncPreviewOpen         bool
+	syncPreview             *core.SyncPreview
+	syncPreviewArmed        bool
+	localRemoveOpen         bool
+	localRemoveTitle        string
+	localRemovals           []core.LocalProjectRemoval
 	consoleLines            []string
 	consoleSelected         int
 	copyNotice              string
@@ -323,19 +815,60 @@ func newVariableList(title string, items []list.Item) list.Model {
 func buildSecretsActions() []list.Item {
 	coreActions := []list.Item{
 		actionItem{id: "read", title: "READ", description: "Inspect local secrets from secrets.yaml + .env"},
+		actionItem{id: "reconcile", title: "RECONCILE", description: "Compare local secrets.yaml with the secrets configured in the frontend"},
+		actionItem{id: "diff", title: "DIFF", description: "Secrets in secrets.yaml, the frontend and .env side by side, with per-key fixes"},
 		actionItem{id: "update", title: "UPDATE", description: "Update system/environment variable values"},
 		actionItem{id: "add", title: "ADD", description: "Add secret key+value locally and to frontend config"},
+		actionItem{id: "clone", title: "CLONE FROM WORKFLOW", description: "Copy secret declarations, and optionally values, from another synced workflow"},
+		actionItem{id: "prefill", title: "PREFILL FROM ENV", description: "Fill missing .env values from variables exported in this shell, after confirming the list"},
 		actionItem{id: "remove", title: "REMOVE", description: "Clear local value (optional frontend removal)"},
+		actionItem{id: "rename", title: "RENAME", description: "Rename a secret in secrets.yaml, .env and the frontend config"},
+		actionItem{id: "keystore", title: "ENCRYPT KEY", description: "Move CRE_ETH_PRIVATE_KEY into a passphrase-protected keystore"},
+		actionItem{id: "envenc", title: "ENCRYPT .ENV", description: "Keep the .env of the current target encrypted, or unlock it for this session"},
+		actionItem{id: "export", title: "EXPORT TEMPLATE", description: "Write .env.example with every secret key and no values"},
 	}
 	backAction := actionItem{id: "back", title: "Back", description: "Close secrets submenu"}
 	return append(coreActions, backAction)
 }
 
-func initialModel() model {
-	base := os.Getenv("SIXFLOW_WEB_URL")
-	if strings.TrimSpace(base) == "" {
-		base = "https://6flow.studio"
+type startupOptions struct {
+	workflow  string
+	action    string
+	token     string
+	profile   string
+	httpTrace string
+}
+
+func parseStartupOptions(args []string) (startupOptions, error) {
+	var opts startupOptions
+	fs := flag.NewFlagSet("6flow-tui", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&opts.workflow, "workflow", "", "workflow id or name to select on startup")
+	fs.StringVar(&opts.action, "action", "", "action to open for --workflow (simulate|secrets)")
+	fs.StringVar(&opts.token, "token", "", "auth token to use instead of the browser login flow")
+	fs.StringVar(&opts.profile, "profile", "", "named auth profile (default: $SIXFLOW_PROFILE or \"default\")")
+	fs.StringVar(&opts.httpTrace, "http-trace", "", "append a redacted trace of frontend requests to this file")
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() > 0 {
+		return opts, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	opts.workflow = strings.TrimSpace(opts.workflow)
+	opts.action = strings.TrimSpace(strings.ToLower(opts.action))
+	switch opts.action {
+	case "", "simulate", "secrets":
+	default:
+		return opts, fmt.Errorf("--action must be simulate or secrets, got %q", opts.action)
+	}
+	if opts.action != "" && opts.workflow == "" {
+		return opts, errors.New("--action requires --workflow")
 	}
+	return opts, nil
+}
+
+func initialModel(opts startupOptions) model {
+	base := defaultWebBaseURL()
 
 	user := os.Getenv("USER")
 	if strings.TrimSpace(user) == "" {
@@ -345,7 +878,14 @@ func initialModel() model {
 	actions := []list.Item{
 		actionItem{id: "simulate", title: "Simulate", description: "Run local simulation of the workflow (using local secrets)"},
 		actionItem{id: "secrets", title: "Secrets", description: "Manage secrets in local environment"},
-		actionItem{id: "deploy", title: "Deploy (Unavailable)", description: "Not available in current CLI version"},
+		actionItem{id: "details", title: "Details", description: "Nodes, triggers, secrets and config schema from the frontend"},
+		actionItem{id: "runs", title: "Run history", description: "Recent executions recorded by the frontend"},
+		actionItem{id: "versions", title: "Bundle versions", description: "Sync an older compiled artifact to reproduce a prior build"},
+		actionItem{id: "config", title: "Push config", description: "Upload edited config.staging.json/config.production.json to the frontend"},
+		actionItem{id: "deploy", title: "Deploy", description: "Run cre workflow deploy for the synced project (asks to confirm first)"},
+		actionItem{id: "deployments", title: "Deployments", description: "Deploys recorded on this machine; pause, resume or delete them"},
+		actionItem{id: "remove-local", title: "Delete local project", description: "Remove the synced project directory, .env and keystore included (asks to confirm first)"},
+		actionItem{id: "prune", title: "Prune orphaned projects", description: "Find local projects whose workflow no longer exists in the frontend"},
 	}
 	secretsActions := buildSecretsActions()
 	secretPickList := newList("Select secret", []list.Item{})
@@ -361,11 +901,18 @@ func initialModel() model {
 	secretIDInput.CharLimit = 120
 	secretIDInput.Width = 70
 
+	deploymentOpInput := textinput.New()
+	deploymentOpInput.Prompt = "workflow name> "
+	deploymentOpInput.CharLimit = 200
+	deploymentOpInput.Width = 70
+
 	secretValueInput := textinput.New()
 	secretValueInput.Placeholder = "secret value"
 	secretValueInput.Prompt = "secret value> "
-	secretValueInput.CharLimit = 512
+	secretValueInput.CharLimit = core.MaxSecretValueLength
 	secretValueInput.Width = 70
+	secretValueInput.EchoMode = textinput.EchoPassword
+	secretValueInput.EchoCharacter = '•'
 
 	simulateTxHashInput := textinput.New()
 	simulateTxHashInput.Placeholder = "0x..."
@@ -379,128 +926,619 @@ func initialModel() model {
 	simulateEventIndexInput.CharLimit = 12
 	simulateEventIndexInput.Width = 30
 
+	simulatePassphraseInput := textinput.New()
+	simulatePassphraseInput.Placeholder = "keystore passphrase"
+	simulatePassphraseInput.Prompt = "passphrase> "
+	simulatePassphraseInput.CharLimit = 256
+	simulatePassphraseInput.Width = 60
+	simulatePassphraseInput.EchoMode = textinput.EchoPassword
+
+	filterInput := textinput.New()
+	filterInput.Placeholder = "name status:ready since:7d"
+	filterInput.Prompt = "filter> "
+	filterInput.CharLimit = 200
+	filterInput.Width = 40
+
+	tokenPasteInput := textinput.New()
+	tokenPasteInput.Placeholder = "paste token from the web UI"
+	tokenPasteInput.Prompt = "token> "
+	tokenPasteInput.CharLimit = 8192
+	tokenPasteInput.Width = 70
+	tokenPasteInput.EchoMode = textinput.EchoPassword
+
 	v := viewport.New(40, 10)
-	v.SetContent(withTimestamp(fmt.Sprintf("Frontend API mode enabled (%s).", base)) + "\n" + withTimestamp("Checking local authentication session..."))
+	v.SetContent(withTimestamp(fmt.Sprintf("Checking frontend %s ...", base)) + "\n" + withTimestamp("Checking local authentication session..."))
 	v.GotoBottom()
 
+	phase := phaseCheckingAuth
+	if !core.IsTrustedHost(base) {
+		phase = phaseTrustHost
+	}
+
 	return model{
-		phase:                   phaseCheckingAuth,
+		phase:                   phase,
 		authState:               authDisconnected,
 		lastSyncAt:              "never",
 		user:                    user,
 		webBaseURL:              base,
+		profile:                 core.ActiveAuthProfile(),
+		accountWorkflows:        map[string][]core.FrontendWorkflow{},
+		accountList:             newList("Accounts", []list.Item{}),
+		orgList:                 newList("Organizations", []list.Item{}),
+		runsList:                newList("Run history", []list.Item{}),
+		versionsList:            newList("Bundle versions", []list.Item{}),
+		deploymentsList:         newList("Deployments", []list.Item{}),
+		deploymentOpInput:       deploymentOpInput,
+		detailView:              viewport.New(40, 10),
 		focus:                   focusWorkflows,
 		workflowList:            newList("Workflows", []list.Item{}),
 		actionList:              newList("Actions", actions),
 		secretsMenu:             newList("Secrets submenu", secretsActions),
 		secretPickList:          secretPickList,
+		secretsDiffList:         newList("Secrets diff", []list.Item{}),
+		secretsCloneList:        newList("Clone secrets from", []list.Item{}),
 		systemVariableList:      systemVariableList,
 		environmentVariableList: environmentVariableList,
-		secretsTargets:          []string{"staging-settings"},
+		targets:                 []string{"staging-settings"},
 		secretIDInput:           secretIDInput,
 		secretValueInput:        secretValueInput,
 		simulateTxHashInput:     simulateTxHashInput,
 		simulateEventIndexInput: simulateEventIndexInput,
+		simulatePassphraseInput: simulatePassphraseInput,
+		tokenPasteInput:         tokenPasteInput,
+		filterInput:             filterInput,
+		startupWorkflow:         opts.workflow,
+		startupAction:           opts.action,
+		startupLinkReady:        opts.workflow != "",
 		console:                 v,
 		help:                    help.New(),
 		spinner:                 sp,
 		logs: []string{
-			withTimestamp(fmt.Sprintf("Frontend API mode enabled (%s).", base)),
+			withTimestamp(fmt.Sprintf("Checking frontend %s ...", base)),
 			withTimestamp("Checking local authentication session..."),
 			withTimestamp("Checking CRE CLI identity (`cre whoami`) ..."),
 		},
 	}
 }
 
-func initSessionCmd() tea.Cmd {
-	return func() tea.Msg {
-		session, err := core.LoadAuthSession()
-		return loadedSessionMsg{session: session, err: err}
+// switchToNextProfile cycles to the next saved auth profile and reloads its
+// session without touching the one it leaves.
+func (m *model) switchToNextProfile() tea.Cmd {
+	profiles := core.ListAuthProfiles()
+	if len(profiles) < 2 {
+		m.appendLog("Only one auth profile exists. Start with --profile <name> to create another.")
+		return nil
+	}
+	next := profiles[0]
+	for i, name := range profiles {
+		if name == m.profile {
+			next = profiles[(i+1)%len(profiles)]
+			break
+		}
 	}
+	return m.switchToProfile(next)
 }
 
-func refreshWorkflowsCmd(baseURL, token string) tea.Cmd {
-	return func() tea.Msg {
-		workflows, err := core.FetchFrontendWorkflows(baseURL, token)
-		return workflowsLoadedMsg{workflows: workflows, err: err}
+// switchToProfile loads another profile's session, showing its cached
+// workflow list until the refresh completes, and re-checks `cre whoami` since
+// each account usually pairs with its own CRE login.
+func (m *model) switchToProfile(name string) tea.Cmd {
+	if err := core.SetAuthProfile(name); err != nil {
+		m.appendLog("Profile switch failed: " + err.Error())
+		return nil
+	}
+
+	m.profile = name
+	m.claimInstance()
+	m.account = ""
+	m.token = ""
+	m.sessionSource = ""
+	m.sessionExp = nil
+	m.reauthPrompt = false
+	m.authState = authDisconnected
+	m.phase = phaseCheckingAuth
+	m.webBaseURL = defaultWebBaseURL()
+	m.workflowsLoaded = false
+	m.workflowsCursor = ""
+	m.stopWorkflowEvents()
+	m.eventsUnsupported = false
+	m.runsOpen = false
+	m.versionsOpen = false
+	m.deploymentsOpen = false
+	m.detailOpen = false
+	m.clearOrganization()
+	m.setWorkflows(m.accountWorkflows[name])
+	m.creChecked = false
+	m.creLoggedIn = false
+	m.creIdentity = ""
+	m.creWhoAmI = nil
+	m.sessionCREIdentity = ""
+	m.identityMismatch = ""
+	m.offline = false
+	m.expiredSession = nil
+	m.incompatible = ""
+	m.appendLog(fmt.Sprintf("Switched to profile %q (%s). Checking session...", name, m.webBaseURL))
+	m.appendLog("Checking CRE CLI identity (`cre whoami`) ...")
+	return tea.Batch(m.sessionCheckCmd(), creWhoAmICmd(), compatibilityCmd(m.webBaseURL))
+}
+
+// claimInstance registers this TUI for the active profile, warning when
+// another instance already uses it since both would refresh the same token
+// and sync into the same folders.
+func (m *model) claimInstance() {
+	if m.releaseInstance != nil {
+		m.releaseInstance()
+		m.releaseInstance = nil
+	}
+	other, release, err := core.RegisterTUIInstance()
+	if err != nil {
+		m.appendLog("Could not register TUI instance: " + err.Error())
+		return
+	}
+	if other != nil {
+		m.appendLog(fmt.Sprintf(
+			"Warning: another 6flow TUI (pid %d, started %s) is using profile %q. Session saves and syncs are locked, but avoid working in both.",
+			other.PID, other.StartedAt, other.Profile,
+		))
+		return
+	}
+	m.releaseInstance = release
+}
+
+func (m *model) openAccountPicker() {
+	items := []list.Item{}
+	selected := 0
+	for _, saved := range core.ListAuthAccounts() {
+		label := saved.Account
+		if label == "" {
+			label = "(unknown account)"
+		}
+		state := "session valid"
+		if !saved.Valid {
+			state = "login required"
+		}
+		if saved.Profile == m.profile {
+			selected = len(items)
+			state += " • active"
+		}
+		items = append(items, actionItem{
+			id:          saved.Profile,
+			title:       label,
+			description: fmt.Sprintf("profile %s • %s • %s", saved.Profile, saved.WebBaseURL, state),
+		})
 	}
+	items = append(items, actionItem{
+		id:          "",
+		title:       "+ Add account",
+		description: "Log in with another account; it is saved as its own profile",
+	})
+	m.accountList.SetItems(items)
+	m.accountList.Select(selected)
+	m.accountPickOpen = true
+	m.focus = focusActions
 }
 
-func loginCmd(baseURL string) tea.Cmd {
+func organizationsCmd(baseURL, token string, pick bool) tea.Cmd {
 	return func() tea.Msg {
-		result, err := core.RunBrowserLoginFlow(core.BrowserLoginOptions{WebBaseURL: baseURL})
-		if err != nil {
-			return loginFinishedMsg{err: err}
+		orgs, err := core.FetchFrontendOrganizations(baseURL, token)
+		return organizationsLoadedMsg{token: token, orgs: orgs, pick: pick, err: err}
+	}
+}
+
+// applySessionOrganization restores the organization saved with the session;
+// SIXFLOW_ORG still applies when none was picked.
+func (m *model) applySessionOrganization(session *core.AuthSession) {
+	m.orgID = session.OrgID
+	m.orgName = session.OrgName
+	core.SetActiveOrganization(session.OrgID)
+}
+
+func (m *model) clearOrganization() {
+	m.orgID = ""
+	m.orgName = ""
+	m.orgPickOpen = false
+	core.SetActiveOrganization("")
+}
+
+func (m *model) openOrganizationPicker(orgs []core.FrontendOrganization) {
+	items := make([]list.Item, 0, len(orgs))
+	selected := 0
+	active := core.ActiveOrganization()
+	for _, org := range orgs {
+		description := org.ID
+		if org.Role != "" {
+			description = org.Role + " • " + description
+		}
+		if org.ID == active {
+			selected = len(items)
+			description += " • active"
+		}
+		name := org.Name
+		if name == "" {
+			name = org.ID
 		}
-		return loginFinishedMsg{token: result.Token}
+		items = append(items, actionItem{id: org.ID, title: name, description: description})
 	}
+	m.orgList.SetItems(items)
+	m.orgList.Select(selected)
+	m.orgPickOpen = true
+	m.focus = focusActions
 }
 
-func actionCmd(actionID, workflowID, workflowName, evmTxHash string, evmEventIndex int) tea.Cmd {
+// selectOrganization switches every later request to the chosen organization
+// and reloads its workflows.
+func (m *model) selectOrganization(id, name string) tea.Cmd {
+	core.SetActiveOrganization(id)
+	m.orgID = id
+	m.orgName = name
+	if m.sessionSource == core.SessionSourceFile {
+		if err := core.RecordSessionOrganization(id, name); err != nil {
+			m.appendLog("Could not save organization choice: " + err.Error())
+		}
+	}
+	m.stopWorkflowEvents()
+	m.runsOpen = false
+	m.versionsOpen = false
+	m.deploymentsOpen = false
+	m.detailOpen = false
+	m.workflowsCursor = ""
+	m.workflowsLoaded = false
+	delete(m.accountWorkflows, m.profile)
+	m.setWorkflows(nil)
+	m.busy = true
+	m.appendLog(fmt.Sprintf("Switched to organization %s. Loading workflows...", name))
+	return refreshWorkflowsCmd(m.webBaseURL, m.token, m.workflowFilter)
+}
+
+// promptCompile handles enter on a workflow that is not compiled: the first
+// press explains, a second press on the same workflow requests the compile.
+func (m *model) promptCompile(item workflowItem) tea.Cmd {
+	if m.compilingID == item.id {
+		m.appendLog(fmt.Sprintf("%s is still compiling. It can be synced once it is ready.", item.title))
+		return nil
+	}
+	if m.compilePromptID != item.id {
+		m.compilePromptID = item.id
+		m.appendLog(fmt.Sprintf("Workflow %s is not compiled yet (status %s). Press enter again to compile it on the frontend.", item.title, item.status))
+		return nil
+	}
+	m.compilePromptID = ""
+	if m.readOnly() {
+		m.appendLog("Compiling is disabled in a read-only session.")
+		return nil
+	}
+	m.busy = true
+	m.appendLog(fmt.Sprintf("Requesting compilation of %s...", item.title))
+	return requestCompileCmd(m.webBaseURL, m.token, item.id, item.title)
+}
+
+// applyWorkflowFilter reloads the list from the first page with the new
+// filter; the frontend does the filtering.
+func (m *model) applyWorkflowFilter(filter core.WorkflowFilter) tea.Cmd {
+	if filter == m.workflowFilter {
+		return nil
+	}
+	m.workflowFilter = filter
+	m.workflowsCursor = ""
+	m.busy = true
+	if filter.IsZero() {
+		m.appendLog("Workflow filter cleared. Reloading workflows...")
+	} else {
+		m.appendLog("Filtering workflows: " + filter.String())
+	}
+	return refreshWorkflowsCmd(m.webBaseURL, m.token, filter)
+}
+
+func (m *model) startAddAccount() tea.Cmd {
+	if m.reauthing || m.addingAccount {
+		return nil
+	}
+	m.addingAccount = true
+	m.appendLog("Adding an account: complete the login in your browser. The current session stays active until it succeeds.")
+	return loginCmd(m.webBaseURL, core.PreferredLoginMethod())
+}
+
+// revokeSessionCmd invalidates the token on the frontend and, for saved
+// sessions, removes it from disk/keyring.
+func revokeSessionCmd(baseURL, token string, clearSaved bool) tea.Cmd {
 	return func() tea.Msg {
-		var logs []string
-		var err error
-		switch actionID {
-		case "simulate":
-			result, runErr := core.RunWorkflowSimulateLocal(workflowID, workflowName, "staging-settings", evmTxHash, evmEventIndex)
-			if result != nil {
-				logs = append(logs, result.Logs...)
-			}
-			err = runErr
-		case "deploy":
-			time.Sleep(300 * time.Millisecond)
-			logs = append(logs, "Deploy is not available in the current CI version.")
-			time.Sleep(250 * time.Millisecond)
-			logs = append(logs, "Use local simulation/sync flows for now.")
+		if clearSaved {
+			return sessionRevokedMsg{err: core.RevokeAndClearAuthSession(baseURL, token)}
 		}
-		return actionFinishedMsg{logs: logs, err: err}
+		return sessionRevokedMsg{err: core.RevokeFrontendToken(baseURL, token)}
+	}
+}
+
+func (m *model) logout() tea.Cmd {
+	token := m.token
+	clearSaved := m.sessionSource == core.SessionSourceFile
+	if !clearSaved && m.sessionSource != "" {
+		m.appendLog("Token was provided via --token/" + core.AuthTokenEnv + "; unset it to stay logged out.")
+	}
+	if m.expiredSession != nil {
+		token = m.expiredSession.Token
+		clearSaved = true
+		m.expiredSession = nil
+		m.offline = false
 	}
+	m.token = ""
+	m.account = ""
+	m.sessionSource = ""
+	m.sessionCREIdentity = ""
+	m.identityMismatch = ""
+	m.sessionExp = nil
+	m.reauthPrompt = false
+	m.authState = authDisconnected
+	m.phase = phaseAuthGate
+	m.workflowsLoaded = false
+	m.workflowsCursor = ""
+	m.stopWorkflowEvents()
+	m.runsOpen = false
+	m.versionsOpen = false
+	m.deploymentsOpen = false
+	m.detailOpen = false
+	m.clearOrganization()
+	m.setWorkflows(nil)
+	delete(m.accountWorkflows, m.profile)
+	m.appendLog("Logging out...")
+	return revokeSessionCmd(m.webBaseURL, token, clearSaved)
 }
 
-func preSimulateCmd(workflowID, workflowName string) tea.Cmd {
+// sessionCheckCmd loads the saved session, first asking the user to approve
+// the frontend host when no token has ever been sent to it.
+func (m *model) sessionCheckCmd() tea.Cmd {
+	if core.IsTrustedHost(m.webBaseURL) {
+		return initSessionCmd()
+	}
+	m.phase = phaseTrustHost
+	m.hostTrust = nil
+	m.appendLog("Frontend " + m.webBaseURL + " has not been approved yet. Inspecting its certificate...")
+	return inspectHostCmd(m.webBaseURL)
+}
+
+func inspectHostCmd(baseURL string) tea.Cmd {
+	return func() tea.Msg {
+		return hostInspectedMsg{info: core.InspectHost(baseURL)}
+	}
+}
+
+func initSessionCmd() tea.Cmd {
 	return func() tea.Msg {
-		result, err := core.PreSimulateLocal(workflowID, workflowName, "staging-settings")
-		if result == nil {
-			return preSimulateReadyMsg{err: err}
+		session, err := core.LoadAuthSession()
+		return loadedSessionMsg{session: session, err: err}
+	}
+}
+
+func refreshWorkflowsCmd(baseURL, token string, filter core.WorkflowFilter) tea.Cmd {
+	return func() tea.Msg {
+		page, err := core.FetchCachedFrontendWorkflowPage(baseURL, token, "", core.DefaultWorkflowPageSize, filter)
+		if err != nil {
+			return workflowsLoadedMsg{err: err}
 		}
-		return preSimulateReadyMsg{
-			logs:        result.Logs,
-			projectRoot: result.ProjectRoot,
-			cmdArgs:     result.CmdArgs,
-			err:         err,
+		return workflowsLoadedMsg{
+			workflows:  page.Workflows,
+			nextCursor: page.NextCursor,
+			stale:      page.Stale,
+			fetchedAt:  page.FetchedAt,
 		}
 	}
 }
 
-func waitForSimulateStreamCmd(ch <-chan tea.Msg) tea.Cmd {
+func loadMoreWorkflowsCmd(baseURL, token, cursor string, filter core.WorkflowFilter) tea.Cmd {
 	return func() tea.Msg {
-		msg, ok := <-ch
-		if !ok {
-			return simulateStreamDoneMsg{}
+		page, err := core.FetchFrontendWorkflowPage(baseURL, token, cursor, core.DefaultWorkflowPageSize, filter)
+		if err != nil {
+			return workflowsLoadedMsg{appended: true, err: err}
 		}
-		return msg
+		return workflowsLoadedMsg{workflows: page.Workflows, nextCursor: page.NextCursor, appended: true}
 	}
 }
 
-func runPreparedSimulateCmd(projectRoot string, cmdArgs []string, stdinData string) tea.Cmd {
+func loginCmd(baseURL, method string) tea.Cmd {
 	return func() tea.Msg {
-		ch := make(chan tea.Msg, 64)
+		ch := make(chan tea.Msg, 2)
 		go func() {
 			defer close(ch)
-
-			cmd := exec.Command("cre", cmdArgs...)
-			cmd.Dir = projectRoot
-			if strings.TrimSpace(stdinData) != "" {
-				cmd.Stdin = strings.NewReader(stdinData)
+			run := core.RunBrowserLoginFlow
+			if method == core.LoginMethodDeviceCode {
+				run = core.RunDeviceCodeLoginFlow
 			}
-
-			stdout, err := cmd.StdoutPipe()
+			result, err := run(core.BrowserLoginOptions{
+				WebBaseURL:           baseURL,
+				FallbackToDeviceCode: true,
+				OnPrompt: func(prompt core.LoginPrompt) {
+					ch <- loginPromptMsg{prompt: prompt}
+				},
+			})
 			if err != nil {
-				ch <- simulateStreamDoneMsg{err: err}
+				ch <- loginFinishedMsg{err: err}
 				return
 			}
-			stderr, err := cmd.StderrPipe()
-			if err != nil {
+			ch <- loginFinishedMsg{token: result.Token}
+		}()
+		return loginStartedMsg{ch: ch}
+	}
+}
+
+func waitForLoginCmd(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-ch
+		if !ok {
+			return loginFinishedMsg{err: errors.New("login flow ended unexpectedly")}
+		}
+		return msg
+	}
+}
+
+func actionCmd(actionID, workflowID, workflowName, evmTxHash string, evmEventIndex int) tea.Cmd {
+	return func() tea.Msg {
+		var logs []string
+		var err error
+		switch actionID {
+		case "simulate":
+			result, runErr := core.RunWorkflowSimulateLocal(workflowID, workflowName, "staging-settings", evmTxHash, evmEventIndex, "", nil)
+			if result != nil {
+				logs = append(logs, result.Logs...)
+			}
+			err = runErr
+		}
+		return actionFinishedMsg{logs: logs, err: err}
+	}
+}
+
+// preSimulateCmd streams the checks and dependency install output into the
+// console as they happen; the final preSimulateReadyMsg carries no logs.
+func preSimulateCmd(workflowID, workflowName, target string) tea.Cmd {
+	return func() tea.Msg {
+		ch := make(chan tea.Msg, 64)
+		go func() {
+			defer close(ch)
+			result, err := core.PreSimulateLocal(workflowID, workflowName, target, func(line string) {
+				ch <- simulateStreamLineMsg{line: line}
+			})
+			if result == nil {
+				ch <- preSimulateReadyMsg{err: err}
+				return
+			}
+			ch <- preSimulateReadyMsg{
+				projectRoot:     result.ProjectRoot,
+				cmdArgs:         result.CmdArgs,
+				needsPassphrase: result.NeedsPassphrase,
+				env:             result.Env,
+				err:             err,
+			}
+		}()
+		return simulateStreamStartedMsg{ch: ch}
+	}
+}
+
+// deployCmd streams the checks, install and cre output like a simulation;
+// the final deployFinishedMsg carries no logs.
+func deployCmd(workflowID, workflowName, target string) tea.Cmd {
+	return func() tea.Msg {
+		ch := make(chan tea.Msg, 64)
+		go func() {
+			defer close(ch)
+			result, err := core.RunWorkflowDeploy(workflowID, workflowName, target, func(line string) {
+				ch <- simulateStreamLineMsg{line: line}
+			})
+			ch <- deployFinishedMsg{result: result, err: err}
+		}()
+		return simulateStreamStartedMsg{ch: ch}
+	}
+}
+
+func walletCmd(workflowID, workflowName, target string) tea.Cmd {
+	return func() tea.Msg {
+		wallet, err := core.LoadWorkflowWallet(workflowID, workflowName, target)
+		return walletLoadedMsg{workflowID: workflowID, target: target, wallet: wallet, err: err}
+	}
+}
+
+// refreshWallet reloads the wallet of the workflow open in the secrets menu.
+func (m *model) refreshWallet() tea.Cmd {
+	if !m.secretsMenuOpen || m.secretsWorkflowID == "" {
+		return nil
+	}
+	return walletCmd(m.secretsWorkflowID, m.secretsWorkflowName, m.currentTarget())
+}
+
+func (m model) walletLine() string {
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	switch {
+	case m.wallet != nil && m.wallet.Demo:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("wallet " + m.wallet.Summary())
+	case m.wallet != nil:
+		return style.Render("wallet " + m.wallet.Summary())
+	case m.walletErr != "":
+		return style.Render("wallet: " + m.walletErr)
+	}
+	return style.Render("wallet: loading...")
+}
+
+func rollbackSyncCmd(baseURL, token, workflowID, workflowName string, record core.DeploymentRecord) tea.Cmd {
+	return func() tea.Msg {
+		result, err := core.SyncDeploymentBundle(baseURL, token, workflowID, workflowName, record)
+		msg := rollbackSyncedMsg{target: record.Target, err: err}
+		if result != nil {
+			msg.logs = result.Logs
+		}
+		return msg
+	}
+}
+
+func deploymentOpCmd(workflowID, workflowName, target string, op core.DeploymentOp) tea.Cmd {
+	return func() tea.Msg {
+		ch := make(chan tea.Msg, 64)
+		go func() {
+			defer close(ch)
+			_, err := core.ManageDeployment(workflowID, workflowName, target, op, func(line string) {
+				ch <- simulateStreamLineMsg{line: line}
+			})
+			ch <- deploymentOpFinishedMsg{op: op, target: target, err: err}
+		}()
+		return simulateStreamStartedMsg{ch: ch}
+	}
+}
+
+func deployPreflightCmd(workflowID, workflowName, target string) tea.Cmd {
+	return func() tea.Msg {
+		preflight, err := core.RunDeployPreflight(workflowID, workflowName, target)
+		return deployPreflightMsg{preflight: preflight, err: err}
+	}
+}
+
+func unlockKeystoreCmd(workflowID, workflowName, passphrase string) tea.Cmd {
+	return func() tea.Msg {
+		privateKey, err := core.DecryptWorkflowPrivateKey(workflowID, workflowName, passphrase)
+		if err != nil {
+			return keystoreUnlockedMsg{err: err}
+		}
+		return keystoreUnlockedMsg{env: core.PrivateKeyEnv(privateKey)}
+	}
+}
+
+func waitForSimulateStreamCmd(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-ch
+		if !ok {
+			return simulateStreamDoneMsg{}
+		}
+		return msg
+	}
+}
+
+func runPreparedSimulateCmd(projectRoot string, cmdArgs []string, stdinData string, extraEnv []string) tea.Cmd {
+	return func() tea.Msg {
+		ch := make(chan tea.Msg, 64)
+		go func() {
+			defer close(ch)
+
+			if core.DryRun() {
+				line := core.DescribeCommand(projectRoot, extraEnv, strings.TrimSpace(stdinData) != "", core.CREBinary(), cmdArgs)
+				ch <- simulateStreamLineMsg{line: "[dry-run] would run " + line}
+				ch <- simulateStreamDoneMsg{}
+				return
+			}
+
+			timeout := core.SimulateTimeout()
+			ctx, cancel := timeout.Context()
+			defer cancel()
+			cmd := exec.CommandContext(ctx, core.CREBinary(), cmdArgs...)
+			cmd.WaitDelay = 5 * time.Second
+			cmd.Dir = projectRoot
+			cmd.Env = core.CommandEnv(extraEnv)
+			if strings.TrimSpace(stdinData) != "" {
+				cmd.Stdin = strings.NewReader(stdinData)
+			}
+
+			stdout, err := cmd.StdoutPipe()
+			if err != nil {
+				ch <- simulateStreamDoneMsg{err: err}
+				return
+			}
+			stderr, err := cmd.StderrPipe()
+			if err != nil {
 				ch <- simulateStreamDoneMsg{err: err}
 				return
 			}
@@ -510,7 +1548,8 @@ func runPreparedSimulateCmd(projectRoot string, cmdArgs []string, stdinData stri
 				return
 			}
 
-			streamPipe := func(r io.Reader, wg *sync.WaitGroup) {
+			output := core.NewSimulateOutput()
+			streamPipe := func(r io.Reader, stderr bool, wg *sync.WaitGroup) {
 				defer wg.Done()
 				scanner := bufio.NewScanner(r)
 				scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
@@ -519,20 +1558,23 @@ func runPreparedSimulateCmd(projectRoot string, cmdArgs []string, stdinData stri
 					if line == "" {
 						continue
 					}
-					ch <- simulateStreamLineMsg{line: "[cre] " + line}
+					for _, formatted := range output.Lines(line, stderr) {
+						ch <- simulateStreamLineMsg{line: formatted}
+					}
 				}
 				if err := scanner.Err(); err != nil {
-					ch <- simulateStreamLineMsg{line: "[cre] stream read error: " + err.Error()}
+					ch <- simulateStreamLineMsg{line: core.StreamTag("cre", true) + "stream read error: " + err.Error()}
 				}
 			}
 
 			var wg sync.WaitGroup
 			wg.Add(2)
-			go streamPipe(stdout, &wg)
-			go streamPipe(stderr, &wg)
+			go streamPipe(stdout, false, &wg)
+			go streamPipe(stderr, true, &wg)
 			wg.Wait()
 
-			ch <- simulateStreamDoneMsg{err: cmd.Wait()}
+			err = timeout.Err(ctx, "cre workflow simulate", cmd.Wait())
+			ch <- simulateStreamDoneMsg{err: err}
 		}()
 		return simulateStreamStartedMsg{ch: ch}
 	}
@@ -545,11 +1587,56 @@ func (m *model) resetSimulateFlow() {
 	m.simulateEventIndexInput.Blur()
 	m.simulateFormActiveField = 0
 	m.simulateNeedsEVMFlags = false
+	m.simulateNeedsPayload = false
+	m.simulatePayloadOpen = false
+	m.simulatePayloads = nil
+	m.simulatePayloadIndex = 0
 	m.simulatePendingRoot = ""
 	m.simulatePendingArgs = nil
+	m.simulateWorkflowID = ""
+	m.simulateWorkflowName = ""
+	m.simulatePassphraseOpen = false
+	m.simulatePassphraseError = ""
+	m.simulatePassphraseInput.SetValue("")
+	m.simulatePassphraseInput.Blur()
+	m.simulateExtraEnv = nil
 	m.simulateStreamCh = nil
 }
 
+func (m *model) continueSimulate(projectRoot string, cmdArgs []string) tea.Cmd {
+	if m.simulateNeedsEVMFlags {
+		m.busy = false
+		m.simulateFormOpen = true
+		m.simulatePendingRoot = projectRoot
+		m.simulatePendingArgs = append([]string(nil), cmdArgs...)
+		m.simulateFormError = ""
+		m.simulateFormActiveField = 0
+		m.simulateTxHashInput.SetValue("")
+		m.simulateEventIndexInput.SetValue("0")
+		m.simulateTxHashInput.Focus()
+		m.simulateEventIndexInput.Blur()
+		m.appendLog("Pre-simulation ready. Enter EVM trigger input.")
+		return nil
+	}
+	if m.simulateNeedsPayload {
+		payloads, err := core.ListSimulatePayloads(m.simulateWorkflowID, m.simulateWorkflowName)
+		if err != nil {
+			m.appendLog("Could not list payload files: " + err.Error())
+		}
+		m.busy = false
+		m.simulatePayloadOpen = true
+		m.simulatePendingRoot = projectRoot
+		m.simulatePendingArgs = append([]string(nil), cmdArgs...)
+		m.simulatePayloads = payloads
+		m.simulatePayloadIndex = 0
+		m.appendLog(fmt.Sprintf("Pre-simulation ready. Pick an HTTP trigger payload (%d file(s) found).", len(payloads)))
+		return nil
+	}
+	m.busy = true
+	m.appendLog("Pre-simulation ready. Running cre simulate (no stdin required).")
+	return runPreparedSimulateCmd(projectRoot, cmdArgs, "", m.simulateExtraEnv)
+}
+
 func (m *model) handleSimulateDone(err error) {
 	if err != nil {
 		m.appendLog("simulate exited: " + err.Error())
@@ -568,10 +1655,74 @@ func (m *model) handleSimulateDone(err error) {
 	m.resetSimulateFlow()
 }
 
-func syncLocalCmd(baseURL, token, workflowID, workflowName string) tea.Cmd {
+func requestCompileCmd(baseURL, token, workflowID, workflowName string) tea.Cmd {
+	return func() tea.Msg {
+		err := core.RequestWorkflowCompile(baseURL, token, workflowID)
+		return compileRequestedMsg{token: token, id: workflowID, name: workflowName, err: err}
+	}
+}
+
+func compilePollCmd(workflowID string) tea.Cmd {
+	return tea.Tick(core.DefaultCompilePollInterval, func(_ time.Time) tea.Msg {
+		return compilePollMsg{id: workflowID}
+	})
+}
+
+func compileStatusCmd(baseURL, token, workflowID, workflowName string) tea.Cmd {
+	return func() tea.Msg {
+		wf, err := core.FetchWorkflowStatus(baseURL, token, workflowID)
+		return compileStatusMsg{token: token, id: workflowID, name: workflowName, workflow: wf, err: err}
+	}
+}
+
+// syncPreviewCmd downloads the current bundle, or the given older version,
+// and diffs it against the local project before anything is written.
+func syncPreviewCmd(baseURL, token, workflowID, workflowName, version string) tea.Cmd {
+	return func() tea.Msg {
+		preview, err := core.PreviewWorkflowSync(baseURL, token, workflowID, workflowName, version)
+		return syncPreviewMsg{preview: preview, err: err}
+	}
+}
+
+func syncAllCmd(baseURL, token string) tea.Cmd {
+	return func() tea.Msg {
+		result, err := core.SyncAllWorkflows(baseURL, token)
+		return syncAllFinishedMsg{result: result, err: err}
+	}
+}
+
+func orphanedProjectsCmd(baseURL, token string) tea.Cmd {
+	return func() tea.Msg {
+		removals, skipped, err := core.FindOrphanedLocalProjects(baseURL, token)
+		return orphanedProjectsMsg{removals: removals, skipped: skipped, err: err}
+	}
+}
+
+func removeLocalProjectsCmd(removals []core.LocalProjectRemoval) tea.Cmd {
+	return func() tea.Msg {
+		result, err := core.RemoveLocalProjects(removals)
+		return localProjectsRemovedMsg{result: result, err: err}
+	}
+}
+
+// localDriftCmd hashes the synced projects against their last sync for the
+// "modified locally" badge. It runs after syncs and on every health tick, so
+// edits made while the TUI is open show up too.
+func localDriftCmd() tea.Cmd {
+	return func() tea.Msg {
+		drift, err := core.DetectAllLocalDrift()
+		return localDriftMsg{drift: drift, err: err}
+	}
+}
+
+// syncLocalCmd writes a previewed bundle into the local project.
+func syncLocalCmd(preview *core.SyncPreview) tea.Cmd {
 	return func() tea.Msg {
-		result, err := core.SyncWorkflowToLocal(baseURL, token, workflowID, workflowName)
+		result, err := core.ApplyWorkflowSync(preview)
 		if err != nil {
+			if result != nil {
+				return syncLocalFinishedMsg{logs: result.Logs, err: err}
+			}
 			return syncLocalFinishedMsg{err: err}
 		}
 		return syncLocalFinishedMsg{
@@ -581,6 +1732,24 @@ func syncLocalCmd(baseURL, token, workflowID, workflowName string) tea.Cmd {
 	}
 }
 
+// creLoginCmd suspends the TUI and hands the terminal to `cre auth login`.
+func creLoginCmd() tea.Cmd {
+	cmd, err := core.CRELoginCommand()
+	if err != nil {
+		return func() tea.Msg { return creLoginFinishedMsg{err: err} }
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return creLoginFinishedMsg{err: err}
+	})
+}
+
+func installCRECmd() tea.Cmd {
+	return func() tea.Msg {
+		result, err := core.InstallCRECLI()
+		return creInstalledMsg{result: result, err: err}
+	}
+}
+
 func creWhoAmICmd() tea.Cmd {
 	return func() tea.Msg {
 		result, err := core.GetCREWhoAmI()
@@ -588,9 +1757,10 @@ func creWhoAmICmd() tea.Cmd {
 			return creWhoAmIFinishedMsg{err: err}
 		}
 		return creWhoAmIFinishedMsg{
-			identity: result.Identity,
-			raw:      result.Raw,
-			err:      nil,
+			identity:     result.Identity,
+			organization: result.Organization,
+			raw:          result.Raw,
+			err:          nil,
 		}
 	}
 }
@@ -618,6 +1788,21 @@ func secretsCommandCmd(baseURL, token, actionID, workflowID, workflowName, targe
 		case "remove":
 			label = "Secrets remove"
 			result, err = core.DeleteLocalSecret(workflowID, workflowName, target, secretID)
+		case "purge":
+			label = "Secrets delete"
+			result, err = core.PurgeLocalSecret(workflowID, workflowName, target, secretID)
+		case "rename":
+			label = "Secrets rename"
+			result, err = core.RenameLocalSecret(workflowID, workflowName, target, secretID, secretValue)
+		case "keystore":
+			label = "Secrets keystore"
+			result, err = core.EncryptWorkflowPrivateKey(workflowID, workflowName, target, secretValue)
+		case "envenc", "envunlock":
+			label = "Secrets .env encryption"
+			result, err = core.EncryptWorkflowDotEnv(workflowID, workflowName, target, secretValue)
+		case "export":
+			label = "Secrets export"
+			result, err = core.ExportSecretsTemplate(workflowID, workflowName, target)
 		default:
 			return secretsCmdFinishedMsg{
 				label: "Secrets",
@@ -642,6 +1827,27 @@ func secretsCommandCmd(baseURL, token, actionID, workflowID, workflowName, targe
 					err:   errors.New("cannot sync workflow secret to frontend without auth session"),
 				}
 			}
+			if syncAction == "rename" {
+				// The frontend has no rename; remove the old name, then add
+				// the new one.
+				oldName, newName := normalizeSecretNameInput(secretID), normalizeSecretNameInput(secretValue)
+				if err := core.UpdateWorkflowSecretInFrontend(baseURL, token, workflowID, "remove", oldName); err != nil {
+					return secretsCmdFinishedMsg{
+						logs:  logs,
+						label: label,
+						err:   fmt.Errorf("local rename succeeded but removing %s from the frontend failed: %w", oldName, err),
+					}
+				}
+				if err := core.UpdateWorkflowSecretInFrontend(baseURL, token, workflowID, "add", newName); err != nil {
+					return secretsCmdFinishedMsg{
+						logs:  logs,
+						label: label,
+						err:   fmt.Errorf("local rename succeeded and %s was removed from the frontend, but adding %s failed: %w", oldName, newName, err),
+					}
+				}
+				logs = append(logs, fmt.Sprintf("Renamed secret %s to %s in the frontend workflow config.", oldName, newName))
+				return secretsCmdFinishedMsg{logs: logs, label: label, err: nil}
+			}
 			if err := core.UpdateWorkflowSecretInFrontend(baseURL, token, workflowID, syncAction, normalizeSecretNameInput(secretID)); err != nil {
 				return secretsCmdFinishedMsg{
 					logs:  logs,
@@ -656,6 +1862,126 @@ func secretsCommandCmd(baseURL, token, actionID, workflowID, workflowName, targe
 	}
 }
 
+// secretsReconcileCmd lists secrets that exist only locally or only in the
+// frontend workflow config.
+func secretsReconcileCmd(baseURL, token, workflowID, workflowName, target string) tea.Cmd {
+	return func() tea.Msg {
+		const label = "Secrets reconcile"
+		result, err := core.ReconcileWorkflowSecrets(baseURL, token, workflowID, workflowName, target)
+		var logs []string
+		if result != nil {
+			logs = append(logs, result.Logs...)
+		}
+		if err != nil {
+			if errors.Is(err, core.ErrFrontendSecretsUnsupported) {
+				err = errors.New("this frontend does not expose workflow secrets")
+			}
+			return secretsCmdFinishedMsg{logs: logs, label: label, err: err}
+		}
+		for _, entry := range result.Entries {
+			switch entry.Location {
+			case core.SecretInBoth:
+				logs = append(logs, "  ✓ "+entry.Name)
+			case core.SecretOnlyLocal:
+				state := "no value in .env"
+				if entry.HasValue {
+					state = "value in .env"
+				}
+				logs = append(logs, fmt.Sprintf("  ! %s only in local secrets.yaml (%s, %s); ADD syncs it to the frontend", entry.Name, entry.EnvVar, state))
+			case core.SecretOnlyInFrontend:
+				logs = append(logs, fmt.Sprintf("  ! %s only in the frontend; ADD it locally before simulating", entry.Name))
+			}
+		}
+		if !result.Drifted() {
+			logs = append(logs, "Local and frontend secrets match.")
+		}
+		return secretsCmdFinishedMsg{logs: logs, label: label}
+	}
+}
+
+// secretsDiffCmd applies fix to entry, when set, and then loads the secrets
+// diff, so the list always shows the state after the fix.
+func secretsDiffCmd(baseURL, token, workflowID, workflowName, target, fix string, entry core.SecretDiffEntry) tea.Cmd {
+	return func() tea.Msg {
+		var (
+			logs   []string
+			result *core.SecretsCommandResult
+			err    error
+		)
+		switch fix {
+		case "":
+		case "push", "unpublish":
+			action := "add"
+			if fix == "unpublish" {
+				action = "remove"
+			}
+			err = core.UpdateWorkflowSecretInFrontend(baseURL, token, workflowID, action, entry.Name)
+			if err == nil {
+				logs = append(logs, fmt.Sprintf("Synced secret %s to frontend workflow config (%s).", entry.Name, action))
+			}
+		case "declare":
+			result, err = core.DeclareDotEnvSecret(workflowID, workflowName, target, entry.EnvVar)
+		case "drop":
+			result, err = core.RemoveDotEnvSecretValue(workflowID, workflowName, target, entry.EnvVar)
+		case "purge":
+			result, err = core.PurgeLocalSecret(workflowID, workflowName, target, entry.ID)
+		default:
+			err = fmt.Errorf("unknown secrets diff fix %q", fix)
+		}
+		if result != nil {
+			logs = append(result.Logs, logs...)
+		}
+		if err != nil {
+			return secretsDiffLoadedMsg{logs: logs, fix: fix, err: err}
+		}
+
+		diff, err := core.DiffWorkflowSecrets(baseURL, token, workflowID, workflowName, target)
+		if diff != nil && fix == "" {
+			logs = append(logs, diff.Logs...)
+		}
+		if err != nil {
+			if errors.Is(err, core.ErrFrontendSecretsUnsupported) {
+				err = errors.New("this frontend does not expose workflow secrets")
+			}
+			return secretsDiffLoadedMsg{logs: logs, err: err}
+		}
+		return secretsDiffLoadedMsg{logs: logs, result: diff, fix: fix}
+	}
+}
+
+func secretsCloneCmd(sourceID, sourceName, workflowID, workflowName, target string, withValues bool) tea.Cmd {
+	return func() tea.Msg {
+		result, err := core.CloneLocalSecrets(sourceID, sourceName, workflowID, workflowName, target, withValues)
+		var logs []string
+		if result != nil {
+			logs = result.Logs
+		}
+		return secretsCmdFinishedMsg{logs: logs, label: "Secrets clone", err: err}
+	}
+}
+
+// secretsPrefillCmd plans a prefill from the environment, or applies it to
+// the confirmed ids.
+func secretsPrefillCmd(workflowID, workflowName, target string, ids []string) tea.Cmd {
+	return func() tea.Msg {
+		var (
+			result *core.SecretsPrefillResult
+			err    error
+		)
+		if len(ids) == 0 {
+			result, err = core.PlanEnvPrefill(workflowID, workflowName, target)
+		} else {
+			result, err = core.ApplyEnvPrefill(workflowID, workflowName, target, ids)
+		}
+		msg := secretsPrefillMsg{applied: len(ids) > 0, err: err}
+		if result != nil {
+			msg.logs = result.Logs
+			msg.prefills = result.Prefills
+		}
+		return msg
+	}
+}
+
 func secretOptionsCmd(actionID, workflowID, workflowName, target string) tea.Cmd {
 	return func() tea.Msg {
 		result, err := core.ListLocalSecrets(workflowID, workflowName, target)
@@ -706,16 +2032,80 @@ func updateVariableCmd(workflowID, workflowName, target, kind, key, value string
 }
 
 func (m model) Init() tea.Cmd {
-	return tea.Batch(m.spinner.Tick, initSessionCmd(), creWhoAmICmd(), tea.HideCursor)
+	sessionCmd := initSessionCmd()
+	if m.phase == phaseTrustHost {
+		sessionCmd = inspectHostCmd(m.webBaseURL)
+	}
+	return tea.Batch(m.spinner.Tick, sessionCmd, creWhoAmICmd(), sessionTickCmd(), healthTickCmd(), creSessionTickCmd(), frontendHealthCmd(m.webBaseURL), compatibilityCmd(m.webBaseURL), tea.HideCursor)
+}
+
+// frontendHealthCmd probes the frontend once at startup so connection
+// problems show up before the first real action.
+func frontendHealthCmd(baseURL string) tea.Cmd {
+	return func() tea.Msg {
+		health, err := core.CheckFrontendHealth(baseURL)
+		return frontendHealthMsg{baseURL: baseURL, health: health, err: err}
+	}
+}
+
+// compatibilityCmd asks the frontend which TUI versions it accepts. It sends
+// no credentials, so it runs before the host trust prompt is answered.
+func compatibilityCmd(baseURL string) tea.Cmd {
+	return func() tea.Msg {
+		_, err := core.CheckFrontendCompatibility(baseURL)
+		return compatibilityMsg{baseURL: baseURL, err: err}
+	}
+}
+
+// describeFrontendError explains coded frontend errors with a next step and
+// keeps the original text for reference; other errors are returned as is.
+func describeFrontendError(err error) string {
+	apiErr, ok := core.AsAPIError(err)
+	if !ok {
+		return err.Error()
+	}
+	hint := ""
+	switch apiErr.Kind() {
+	case core.APIErrorWorkflowNotFound:
+		hint = "the workflow no longer exists on the frontend (deleted?); refresh the list"
+	case core.APIErrorBundleExpired:
+		hint = "the compiled bundle has expired; recompile the workflow and sync again"
+	case core.APIErrorQuotaExceeded:
+		hint = "the plan quota is exceeded; upgrade the plan or wait for the quota to reset"
+	case core.APIErrorForbidden:
+		hint = "this account is not allowed to do that; ask an organization admin for access"
+	default:
+		return err.Error()
+	}
+	return hint + " (" + err.Error() + ")"
 }
 
 func classifyLogColor(line string) lipgloss.Color {
 	lower := strings.ToLower(line)
 	switch {
+	case strings.Contains(line, core.SimulateHeadingPrefix):
+		return lipgloss.Color("14")
+	case strings.HasPrefix(line, "[FAIL] "):
+		return lipgloss.Color("9")
+	case strings.HasPrefix(line, "[ok] "):
+		return lipgloss.Color("10")
+	case strings.Contains(lower, ":err] "):
+		return lipgloss.Color("9")
+	case strings.Contains(lower, "[cre:trigger]"):
+		return lipgloss.Color("13")
+	case strings.Contains(lower, "[cre:capability]"):
+		return lipgloss.Color("6")
+	case strings.Contains(lower, "[cre:log]"):
+		return lipgloss.Color("15")
+	case strings.Contains(lower, "[cre:result]"):
+		return lipgloss.Color("10")
 	case strings.Contains(lower, "[cre]"):
 		return lipgloss.Color("12")
-	case strings.Contains(lower, "[bun]"):
+	case strings.Contains(lower, "[bun]") || strings.Contains(lower, "[npm]") ||
+		strings.Contains(lower, "[pnpm]") || strings.Contains(lower, "[yarn]"):
 		return lipgloss.Color("10")
+	case strings.Contains(lower, " only in "):
+		return lipgloss.Color("11")
 	case strings.Contains(lower, "frontend"):
 		return lipgloss.Color("6")
 	case strings.Contains(lower, "convex"):
@@ -792,6 +2182,7 @@ func (m *model) refreshConsoleContent() {
 }
 
 func (m *model) appendLog(line string) {
+	line = m.maskSecretValues(line)
 	atBottom := m.console.AtBottom() || len(m.consoleLines) == 0 || m.consoleSelected >= len(m.consoleLines)-1
 	m.logs = append(m.logs, withTimestamp(line))
 	if atBottom {
@@ -803,6 +2194,80 @@ func (m *model) appendLog(line string) {
 	}
 }
 
+// minMaskedSecretLength keeps short values such as "true" from masking
+// ordinary words in the console.
+const minMaskedSecretLength = 6
+
+// rememberSecretValue masks value in console lines from now on.
+func (m *model) rememberSecretValue(value string) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "\n") {
+		// Tools may echo a PEM key or JSON document one line at a time.
+		for _, line := range strings.Split(value, "\n") {
+			m.rememberSecretValue(line)
+		}
+	}
+	if len(value) < minMaskedSecretLength || slices.Contains(m.secretValues, value) {
+		return
+	}
+	m.secretValues = append(m.secretValues, value)
+}
+
+// setSecretFormValue fills the value field. Multi-line values, which a text
+// input would flatten, are held in secretValueBlock instead.
+func (m *model) setSecretFormValue(value string) {
+	m.secretValueBlock = ""
+	if strings.ContainsAny(value, "\n\r") {
+		m.secretValueBlock = value
+		value = ""
+	}
+	m.secretValueInput.SetValue(value)
+}
+
+// secretFormValue is the value the form would submit.
+func (m model) secretFormValue() string {
+	if m.secretValueBlock != "" {
+		return m.secretValueBlock
+	}
+	return m.secretValueInput.Value()
+}
+
+// secretFormTakesBlock reports whether the value field accepts multi-line
+// pastes and @path files: secret values, not passphrases, IDs or RPC URLs.
+func (m model) secretFormTakesBlock() bool {
+	if m.secretFormMode != "add" && m.secretFormMode != "update" {
+		return false
+	}
+	return m.secretFormVariableKind != "rpc"
+}
+
+func (m *model) maskSecretValues(line string) string {
+	for _, value := range m.secretValues {
+		line = strings.ReplaceAll(line, value, secretMask)
+	}
+	return line
+}
+
+// setSecretsRevealed shows or masks the values in the variable picker and the
+// secret form. Closing the form masks them again.
+func (m *model) setSecretsRevealed(revealed bool) {
+	m.secretsRevealed = revealed
+	m.secretValueInput.EchoMode = textinput.EchoPassword
+	if revealed {
+		m.secretValueInput.EchoMode = textinput.EchoNormal
+	}
+	for _, picker := range []*list.Model{&m.systemVariableList, &m.environmentVariableList} {
+		items := picker.Items()
+		for i, item := range items {
+			if pick, ok := item.(secretPickItem); ok {
+				pick.revealed = revealed
+				items[i] = pick
+			}
+		}
+		picker.SetItems(items)
+	}
+}
+
 func (m *model) ensureConsoleSelectionVisible() {
 	if len(m.consoleLines) == 0 {
 		return
@@ -828,27 +2293,377 @@ func copyToClipboard(value string) error {
 	if text == "" {
 		return errors.New("nothing to copy")
 	}
-	var cmd *exec.Cmd
+	cmd, err := clipboardWriteCommand(false)
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
+
+// clipboardWriteCommand returns the command that sets the clipboard from its
+// stdin, or that empties it when clear is set.
+func clipboardWriteCommand(clear bool) (*exec.Cmd, error) {
 	switch runtime.GOOS {
 	case "darwin":
-		cmd = exec.Command("pbcopy")
+		return exec.Command("pbcopy"), nil
 	case "linux":
 		if _, err := exec.LookPath("wl-copy"); err == nil {
-			cmd = exec.Command("wl-copy")
+			if clear {
+				return exec.Command("wl-copy", "--clear"), nil
+			}
+			return exec.Command("wl-copy"), nil
+		}
+		if _, err := exec.LookPath("xclip"); err == nil {
+			return exec.Command("xclip", "-selection", "clipboard"), nil
+		}
+		if _, err := exec.LookPath("xsel"); err == nil {
+			return exec.Command("xsel", "--clipboard", "--input"), nil
+		}
+		return nil, errors.New("no clipboard tool found (install wl-copy/xclip/xsel)")
+	case "windows":
+		return exec.Command("cmd", "/c", "clip"), nil
+	}
+	return nil, errors.New("unsupported platform for clipboard copy")
+}
+
+func readClipboard() (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("pbpaste")
+	case "linux":
+		if _, err := exec.LookPath("wl-paste"); err == nil {
+			cmd = exec.Command("wl-paste", "--no-newline")
 		} else if _, err := exec.LookPath("xclip"); err == nil {
-			cmd = exec.Command("xclip", "-selection", "clipboard")
+			cmd = exec.Command("xclip", "-selection", "clipboard", "-o")
 		} else if _, err := exec.LookPath("xsel"); err == nil {
-			cmd = exec.Command("xsel", "--clipboard", "--input")
+			cmd = exec.Command("xsel", "--clipboard", "--output")
 		} else {
-			return errors.New("no clipboard tool found (install wl-copy/xclip/xsel)")
+			return "", errors.New("no clipboard tool found (install wl-paste/xclip/xsel)")
 		}
 	case "windows":
-		cmd = exec.Command("cmd", "/c", "clip")
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", "Get-Clipboard")
 	default:
-		return errors.New("unsupported platform for clipboard copy")
+		return "", errors.New("unsupported platform for clipboard paste")
 	}
-	cmd.Stdin = strings.NewReader(text)
-	return cmd.Run()
+	out, err := cmd.Output()
+	return string(out), err
+}
+
+// clearClipboard empties the clipboard unless something else was copied
+// since value. When the clipboard cannot be read back it is emptied anyway.
+func clearClipboard(value string) (bool, error) {
+	if current, err := readClipboard(); err == nil && strings.TrimSpace(current) != strings.TrimSpace(value) {
+		return false, nil
+	}
+	cmd, err := clipboardWriteCommand(true)
+	if err != nil {
+		return false, err
+	}
+	cmd.Stdin = strings.NewReader("")
+	if err := cmd.Run(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// copySecretValueCmd copies the value load returns; loading can resolve a
+// secret reference, which may run op, aws or gcloud.
+func copySecretValueCmd(name string, load func() (string, error)) tea.Cmd {
+	return func() tea.Msg {
+		value, err := load()
+		if err == nil {
+			err = copyToClipboard(value)
+		}
+		if err != nil {
+			return secretCopiedMsg{name: name, err: err}
+		}
+		return secretCopiedMsg{name: name, value: value}
+	}
+}
+
+func clearClipboardCmd(value string) tea.Cmd {
+	delay := core.ClipboardClearDelay()
+	if delay <= 0 {
+		return nil
+	}
+	return tea.Tick(delay, func(_ time.Time) tea.Msg {
+		cleared, err := clearClipboard(value)
+		return clipboardClearedMsg{cleared: cleared, err: err}
+	})
+}
+
+const (
+	sessionTickInterval    = 30 * time.Second
+	reauthWarnMinutesEnv   = "SIXFLOW_REAUTH_WARN_MINUTES"
+	defaultReauthWarnAfter = 10 * time.Minute
+	healthIntervalEnv      = "SIXFLOW_HEALTH_INTERVAL"
+	defaultHealthInterval  = time.Minute
+	creCheckIntervalEnv    = "SIXFLOW_CRE_CHECK_INTERVAL"
+	defaultCRECheckEvery   = 5 * time.Minute
+	// creRecheckAfter is how old the last whoami may be before a
+	// CRE-dependent action checks the login again first.
+	creRecheckAfter = time.Minute
+)
+
+// healthInterval reads SIXFLOW_HEALTH_INTERVAL (e.g. "30s"); "0" turns the
+// pinger off.
+func healthInterval() time.Duration {
+	raw := strings.TrimSpace(os.Getenv(healthIntervalEnv))
+	if raw == "" {
+		return defaultHealthInterval
+	}
+	if parsed, err := time.ParseDuration(raw); err == nil && parsed >= 0 {
+		if parsed > 0 && parsed < 5*time.Second {
+			return 5 * time.Second
+		}
+		return parsed
+	}
+	return defaultHealthInterval
+}
+
+func healthTickCmd() tea.Cmd {
+	interval := healthInterval()
+	if interval == 0 {
+		return nil
+	}
+	return tea.Tick(interval, func(_ time.Time) tea.Msg {
+		return healthTickMsg{}
+	})
+}
+
+// creCheckInterval reads SIXFLOW_CRE_CHECK_INTERVAL (e.g. "2m"); "0" turns
+// the periodic `cre whoami` off.
+func creCheckInterval() time.Duration {
+	raw := strings.TrimSpace(os.Getenv(creCheckIntervalEnv))
+	if raw == "" {
+		return defaultCRECheckEvery
+	}
+	if parsed, err := time.ParseDuration(raw); err == nil && parsed >= 0 {
+		if parsed > 0 && parsed < 30*time.Second {
+			return 30 * time.Second
+		}
+		return parsed
+	}
+	return defaultCRECheckEvery
+}
+
+func creSessionTickCmd() tea.Cmd {
+	interval := creCheckInterval()
+	if interval == 0 {
+		return nil
+	}
+	return tea.Tick(interval, func(_ time.Time) tea.Msg {
+		return creSessionTickMsg{}
+	})
+}
+
+func creSessionCheckCmd(resume bool) tea.Cmd {
+	return func() tea.Msg {
+		result, err := core.GetCREWhoAmI()
+		return creSessionCheckedMsg{result: result, err: err, resume: resume}
+	}
+}
+
+func connectivityCmd(baseURL string) tea.Cmd {
+	return func() tea.Msg {
+		return connectivityMsg{err: core.CheckFrontendReachable(baseURL)}
+	}
+}
+
+// enterOfflineMode keeps local workflows, secrets and simulation usable with
+// an expired session until the frontend can be reached to log in again.
+func (m *model) enterOfflineMode(reason error) {
+	m.offline = true
+	m.phase = phaseReady
+	m.authState = authUnreachable
+	m.account = m.expiredSession.Account
+	m.appendLog("Frontend unreachable (" + reason.Error() + "). Continuing offline with local workflows.")
+	m.appendLog("Sync and frontend secret updates are disabled; you will be asked to log in once the connection returns.")
+	m.showLocalWorkflows()
+}
+
+// enterLocalOnlyMode is offline mode for a valid session whose workflow list
+// could not be fetched and has no cached copy: the list comes from the
+// projects synced to this machine until a refresh reaches the frontend.
+func (m *model) enterLocalOnlyMode(reason error) {
+	m.offline = true
+	m.authState = authUnreachable
+	m.appendLog("Frontend unreachable (" + reason.Error() + "). Listing the workflows synced to this machine.")
+	m.appendLog("Secrets and simulate work on local projects; sync and frontend updates return with the connection, or choose 'Sync list' to retry.")
+	m.showLocalWorkflows()
+}
+
+// showLocalWorkflows fills the workflow list from the synced projects under
+// the workflows directory, marked "local".
+func (m *model) showLocalWorkflows() {
+	local, err := core.ListLocalWorkflows()
+	if err != nil {
+		m.appendLog("Could not list local workflows: " + err.Error())
+		return
+	}
+	m.localWorkflows = map[string]core.LocalWorkflow{}
+	workflows := make([]core.FrontendWorkflow, 0, len(local))
+	for _, wf := range local {
+		m.localWorkflows[wf.ID] = wf
+		workflow := core.FrontendWorkflow{ID: wf.ID, Name: wf.Name, Status: "local"}
+		if !wf.SyncedAt.IsZero() {
+			workflow.UpdatedAt = wf.SyncedAt.UnixMilli()
+		}
+		workflows = append(workflows, workflow)
+	}
+	m.workflowsCursor = ""
+	m.stopWorkflowEvents()
+	m.setWorkflows(workflows)
+	m.workflowsLoaded = true
+	m.appendLog(fmt.Sprintf("Loaded %d local workflow(s).", len(workflows)))
+}
+
+const workflowEventsRetryDelay = 15 * time.Second
+
+// startWorkflowEvents subscribes to live workflow changes so the list follows
+// edits made in the browser without "Sync list".
+func (m *model) startWorkflowEvents() tea.Cmd {
+	if m.eventsCancel != nil || m.eventsUnsupported || m.offline || strings.TrimSpace(m.token) == "" {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.eventsCancel = cancel
+	m.eventsGen++
+	ch := make(chan tea.Msg, 16)
+	m.eventsCh = ch
+	return tea.Batch(
+		workflowEventsCmd(ctx, m.eventsGen, m.webBaseURL, m.token, ch),
+		waitForWorkflowEventCmd(ch),
+	)
+}
+
+func (m *model) stopWorkflowEvents() {
+	if m.eventsCancel != nil {
+		m.eventsCancel()
+		m.eventsCancel = nil
+	}
+	m.eventsCh = nil
+	m.eventsGen++
+}
+
+func workflowEventsCmd(ctx context.Context, gen int, baseURL, token string, ch chan<- tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		err := core.SubscribeWorkflowEvents(ctx, baseURL, token, func(ev core.WorkflowEvent) {
+			select {
+			case ch <- workflowEventMsg{gen: gen, event: ev}:
+			case <-ctx.Done():
+			}
+		})
+		close(ch)
+		return workflowEventsClosedMsg{gen: gen, err: err}
+	}
+}
+
+// waitForWorkflowEventCmd delivers one event and is re-armed by the handler;
+// it returns nothing once the stream is closed.
+func waitForWorkflowEventCmd(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
+
+func (m *model) applyWorkflowEvent(ev core.WorkflowEvent) {
+	current := m.accountWorkflows[m.profile]
+	name := ev.Workflow.Name
+	previous := ""
+	for _, wf := range current {
+		if wf.ID == ev.Workflow.ID {
+			previous = wf.Status
+			if name == "" {
+				name = wf.Name
+			}
+			break
+		}
+	}
+	if name == "" {
+		name = ev.Workflow.ID
+	}
+	workflows := core.MergeWorkflowEvent(current, ev)
+	if !m.workflowFilter.IsZero() {
+		filtered := workflows[:0]
+		for _, wf := range workflows {
+			if wf.ID != ev.Workflow.ID || m.workflowFilter.Matches(wf) {
+				filtered = append(filtered, wf)
+			}
+		}
+		workflows = filtered
+	}
+	m.accountWorkflows[m.profile] = workflows
+	m.setWorkflows(workflows)
+
+	switch {
+	case ev.Type == core.WorkflowEventDeleted:
+		m.appendLog(fmt.Sprintf("Workflow %s was deleted in the browser.", name))
+	case previous != "" && ev.Workflow.Status != "" && previous != ev.Workflow.Status:
+		m.appendLog(fmt.Sprintf("Workflow %s: %s → %s", name, previous, ev.Workflow.Status))
+	}
+}
+
+func healthPingCmd(baseURL, token string) tea.Cmd {
+	return func() tea.Msg {
+		return healthPingMsg{token: token, err: core.PingFrontendSession(baseURL, token)}
+	}
+}
+
+func sessionTickCmd() tea.Cmd {
+	return tea.Tick(sessionTickInterval, func(_ time.Time) tea.Msg {
+		return sessionTickMsg{}
+	})
+}
+
+func reauthWarnThreshold() time.Duration {
+	raw := strings.TrimSpace(os.Getenv(reauthWarnMinutesEnv))
+	if minutes, err := strconv.Atoi(raw); err == nil && minutes >= 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+	return defaultReauthWarnAfter
+}
+
+// sessionRemaining reports how long the active token stays valid; ok is
+// false when there is no token or it carries no exp claim.
+func (m model) sessionRemaining() (time.Duration, bool) {
+	if strings.TrimSpace(m.token) == "" || m.sessionExp == nil {
+		return 0, false
+	}
+	return time.Until(time.Unix(*m.sessionExp, 0)), true
+}
+
+func formatRemaining(d time.Duration) string {
+	if d <= 0 {
+		return "expired"
+	}
+	if d < time.Minute {
+		return "<1m"
+	}
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	if d < 48*time.Hour {
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	return fmt.Sprintf("%dd", int(d.Hours())/24)
+}
+
+func (m *model) startReauth() tea.Cmd {
+	if m.reauthing || m.addingAccount {
+		return nil
+	}
+	m.reauthing = true
+	m.reauthPrompt = false
+	m.appendLog("Re-authenticating in the background; you can keep working.")
+	return loginCmd(m.webBaseURL, core.PreferredLoginMethod())
 }
 
 func clearCopyNoticeCmd(id int) tea.Cmd {
@@ -871,6 +2686,12 @@ func (m *model) setWorkflows(items []core.FrontendWorkflow) {
 			updated = time.UnixMilli(item.UpdatedAt).Local().Format("2006-01-02 15:04")
 		}
 		description := fmt.Sprintf("%s • %d nodes • %s", item.Status, item.NodeCount, updated)
+		if item.Status == "local" {
+			description = "local only • synced " + updated
+			if name := m.localWorkflows[item.ID].WorkflowName; name != "" {
+				description = fmt.Sprintf("local only • %s • synced %s", name, updated)
+			}
+		}
 		if item.Status == "ready" {
 			compilerVersion := strings.TrimSpace(item.CompilerVersion)
 			if compilerVersion == "" {
@@ -889,11 +2710,28 @@ func (m *model) setWorkflows(items []core.FrontendWorkflow) {
 			title:       item.Name,
 			description: description,
 			status:      item.Status,
+			modified:    m.localDrift[item.ID],
 		})
 		if item.ID == prev {
 			selected = idx
 		}
 	}
+	if m.workflowsCursor != "" {
+		listItems = append(listItems, workflowItem{
+			id:          workflowLoadMoreItemID,
+			title:       "⬇ Load more",
+			description: "Fetch the next page of workflows",
+			status:      "meta",
+		})
+	}
+	if len(items) > 0 && !m.offline {
+		listItems = append(listItems, workflowItem{
+			id:          workflowSyncAllItemID,
+			title:       "⬇ Sync all",
+			description: "Sync every ready workflow to local, e.g. on a new machine",
+			status:      "meta",
+		})
+	}
 	listItems = append(listItems, workflowItem{
 		id:          workflowSyncListItemID,
 		title:       "🔄 Sync list",
@@ -904,6 +2742,9 @@ func (m *model) setWorkflows(items []core.FrontendWorkflow) {
 	m.workflowList.SetItems(listItems)
 	m.workflowCount = len(items)
 	m.workflowList.Title = "Workflows (Enter: sync selected, choose 'Sync list' to refresh)"
+	if !m.workflowFilter.IsZero() {
+		m.workflowList.Title = fmt.Sprintf("Workflows [%s] (/ to change)", m.workflowFilter.String())
+	}
 	if len(listItems) > 0 {
 		m.workflowList.Select(selected)
 	}
@@ -952,8 +2793,19 @@ func (m *model) resize() {
 
 	m.workflowList.SetSize(max(10, leftPaneW-4), max(layoutMinPaneHeight, middlePaneH-2))
 	m.actionList.SetSize(max(10, rightPaneW-4), max(layoutMinPaneHeight, middlePaneH-2))
-	m.secretsMenu.SetSize(max(10, rightPaneW-4), max(layoutMinPaneHeight, middlePaneH-2))
+	// One line is left for the wallet above the menu.
+	m.secretsMenu.SetSize(max(10, rightPaneW-4), max(layoutMinPaneHeight, middlePaneH-3))
 	m.secretPickList.SetSize(max(10, rightPaneW-4), max(layoutMinPaneHeight, middlePaneH-2))
+	m.secretsDiffList.SetSize(max(10, rightPaneW-4), max(layoutMinPaneHeight, middlePaneH-2))
+	m.secretsCloneList.SetSize(max(10, rightPaneW-4), max(layoutMinPaneHeight, middlePaneH-2))
+	m.accountList.SetSize(max(10, rightPaneW-4), max(layoutMinPaneHeight, middlePaneH-2))
+	m.orgList.SetSize(max(10, rightPaneW-4), max(layoutMinPaneHeight, middlePaneH-2))
+	m.runsList.SetSize(max(10, rightPaneW-4), max(layoutMinPaneHeight, middlePaneH-2))
+	m.versionsList.SetSize(max(10, rightPaneW-4), max(layoutMinPaneHeight, middlePaneH-2))
+	m.deploymentsList.SetSize(max(10, rightPaneW-4), max(layoutMinPaneHeight, middlePaneH-2))
+	// One line is left for the detail title.
+	m.detailView.Width = max(10, rightPaneW-4)
+	m.detailView.Height = max(layoutMinPaneHeight, middlePaneH-3)
 	m.systemVariableList.SetSize(max(20, (m.width/2)-10), max(8, middlePaneH))
 	m.environmentVariableList.SetSize(max(20, (m.width/2)-10), max(8, middlePaneH))
 
@@ -963,21 +2815,59 @@ func (m *model) resize() {
 	m.refreshConsoleContent()
 }
 
-func (m model) currentSecretsTarget() string {
-	if len(m.secretsTargets) == 0 {
+func (m model) currentTarget() string {
+	if len(m.targets) == 0 {
 		return "staging-settings"
 	}
-	if m.secretsTargetIndex < 0 || m.secretsTargetIndex >= len(m.secretsTargets) {
-		return m.secretsTargets[0]
+	if m.targetIndex < 0 || m.targetIndex >= len(m.targets) {
+		return m.targets[0]
+	}
+	return m.targets[m.targetIndex]
+}
+
+func (m *model) nextTarget() {
+	if len(m.targets) == 0 {
+		return
+	}
+	m.targetIndex = (m.targetIndex + 1) % len(m.targets)
+}
+
+// loadTargets reads the targets of the workflow's workflow.yaml and keeps the
+// current one selected when the workflow defines it too.
+func (m *model) loadTargets(workflowID, workflowName string) {
+	current := m.currentTarget()
+	targets, err := core.ListWorkflowTargets(workflowID, workflowName)
+	if err != nil || len(targets) == 0 {
+		targets = []string{"staging-settings"}
+	}
+	m.targets = targets
+	m.targetIndex = 0
+	for idx, target := range targets {
+		if target == current {
+			m.targetIndex = idx
+		}
 	}
-	return m.secretsTargets[m.secretsTargetIndex]
 }
 
-func (m *model) nextSecretsTarget() {
-	if len(m.secretsTargets) == 0 {
+// switchTarget moves simulate and the secrets submenu to the next target of
+// the workflow in use.
+func (m *model) switchTarget() {
+	workflowID, workflowName := m.secretsWorkflowID, m.secretsWorkflowName
+	if !m.secretsMenuOpen {
+		workflow := m.selectedWorkflow()
+		if workflow == nil {
+			m.appendLog("Select a workflow first.")
+			return
+		}
+		workflowID, workflowName = workflow.id, workflow.title
+	}
+	m.loadTargets(workflowID, workflowName)
+	if len(m.targets) < 2 {
+		m.appendLog(fmt.Sprintf("%s only defines %s.", workflowName, m.currentTarget()))
 		return
 	}
-	m.secretsTargetIndex = (m.secretsTargetIndex + 1) % len(m.secretsTargets)
+	m.nextTarget()
+	m.appendLog(fmt.Sprintf("Target for %s is now %s.", workflowName, m.currentTarget()))
 }
 
 func (m model) selectedWorkflow() *workflowItem {
@@ -985,7 +2875,7 @@ func (m model) selectedWorkflow() *workflowItem {
 	if !ok {
 		return nil
 	}
-	if item.id == workflowSyncListItemID {
+	if item.status == "meta" {
 		return nil
 	}
 	return &item
@@ -1016,11 +2906,58 @@ func compactIdentity(identity string) string {
 	return trimmed[:21] + "..."
 }
 
+// secretFormCanGenerate reports whether the form edits a secret value that a
+// random string can stand in for; keys and RPC URLs have a format of their own.
+func (m model) secretFormCanGenerate() bool {
+	if m.secretFormMode != "add" && m.secretFormMode != "update" {
+		return false
+	}
+	return m.secretFormVariableKind != "private_key" && m.secretFormVariableKind != "rpc"
+}
+
+// readOnly is true for tokens issued without the write scope; such sessions
+// may list, sync and simulate but not change secrets.
+func (m model) readOnly() bool {
+	return m.token != "" && core.TokenIsReadOnly(m.token)
+}
+
+// checkIdentity runs once both the frontend session and `cre whoami` are
+// known and warns when they belong to different people or organizations.
+func (m *model) checkIdentity() {
+	if m.token == "" || !m.creChecked {
+		return
+	}
+	if !m.creLoggedIn {
+		if m.sessionCREIdentity != "" {
+			m.appendLog("Last CRE login used with this account: " + m.sessionCREIdentity)
+		}
+		return
+	}
+	m.identityMismatch = core.IdentityMismatch(m.token, m.creWhoAmI)
+	if m.identityMismatch != "" {
+		m.appendLog("WARNING: " + m.identityMismatch + ". Simulations would run as the CRE login; press C to log in to CRE again or A to switch accounts.")
+		return
+	}
+	if m.sessionSource == core.SessionSourceFile && m.creWhoAmI.Identity != m.sessionCREIdentity {
+		if err := core.RecordSessionCREIdentity(m.creWhoAmI.Identity); err == nil {
+			m.sessionCREIdentity = m.creWhoAmI.Identity
+		}
+	}
+}
+
 func (m *model) guardCRELoggedIn() bool {
 	if m.creLoggedIn {
 		return true
 	}
-	m.appendLog("CRE CLI login required. Run `cre auth login` first, then use Sync list.")
+	if m.creMissing {
+		m.appendLog("CRE CLI is not installed. Press I to download it into " + core.ManagedCREPath() + ".")
+		return false
+	}
+	if m.creExpired {
+		m.appendLog("CRE CLI session expired. Press C to run `cre auth login` again.")
+		return false
+	}
+	m.appendLog("CRE CLI login required. Press C to run `cre auth login` here, then use Sync list.")
 	return false
 }
 
@@ -1028,6 +2965,93 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmds []tea.Cmd
 
 	switch msg := msg.(type) {
+	case sessionRevokedMsg:
+		switch {
+		case msg.err == nil:
+			m.appendLog("Session token revoked and cleared.")
+		case errors.Is(msg.err, core.ErrRevocationUnsupported):
+			m.appendLog("Session cleared locally (frontend has no revocation endpoint).")
+		default:
+			m.appendLog("Session cleared locally; server-side revocation failed: " + msg.err.Error())
+		}
+		return m, nil
+
+	case sessionTickMsg:
+		remaining, ok := m.sessionRemaining()
+		if ok && m.phase == phaseReady && !m.reauthing && !m.reauthPrompt && remaining <= reauthWarnThreshold() {
+			m.reauthPrompt = true
+			m.appendLog(fmt.Sprintf("Session expires in %s. Press R to re-authenticate now.", formatRemaining(remaining)))
+		}
+		return m, sessionTickCmd()
+
+	case connectivityMsg:
+		if m.expiredSession == nil {
+			if m.offline && msg.err == nil && !m.busy && strings.TrimSpace(m.token) != "" {
+				// Local-only mode: the frontend is back, so reload the list.
+				m.busy = true
+				return m, refreshWorkflowsCmd(m.webBaseURL, m.token, m.workflowFilter)
+			}
+			return m, nil
+		}
+		if msg.err != nil {
+			if !m.offline {
+				m.enterOfflineMode(msg.err)
+				return m, localDriftCmd()
+			}
+			return m, nil
+		}
+		expired := m.expiredSession
+		m.expiredSession = nil
+		if m.offline {
+			m.offline = false
+			m.workflowsLoaded = false
+			m.setWorkflows(nil)
+			m.appendLog("Connection to the frontend restored. Log in again to continue online.")
+		} else {
+			m.appendLog("Frontend is reachable. Login required.")
+		}
+		m.phase = phaseAuthGate
+		m.authState = authDisconnected
+		return m, revokeSessionCmd(m.webBaseURL, expired.Token, true)
+
+	case healthTickMsg:
+		if m.offline {
+			return m, tea.Batch(connectivityCmd(m.webBaseURL), localDriftCmd(), healthTickCmd())
+		}
+		if m.phase != phaseReady || strings.TrimSpace(m.token) == "" {
+			return m, healthTickCmd()
+		}
+		return m, tea.Batch(healthPingCmd(m.webBaseURL, m.token), localDriftCmd(), healthTickCmd())
+
+	case healthPingMsg:
+		if msg.token != m.token || m.phase != phaseReady {
+			return m, nil
+		}
+		switch {
+		case msg.err == nil:
+			if m.authState == authUnreachable {
+				m.authState = authConnected
+				m.appendLog("Frontend is reachable again.")
+			}
+		case errors.Is(msg.err, core.ErrFrontendUnauthorized):
+			m.appendLog("Session is no longer accepted by the frontend (revoked or expired). Login required.")
+			m.stopWorkflowEvents()
+			var revokeCmd tea.Cmd
+			if m.sessionSource == core.SessionSourceFile {
+				revokeCmd = revokeSessionCmd(m.webBaseURL, m.token, true)
+			}
+			m.token = ""
+			m.authState = authDisconnected
+			m.phase = phaseAuthGate
+			return m, revokeCmd
+		default:
+			if m.authState != authUnreachable {
+				m.authState = authUnreachable
+				m.appendLog("Frontend unreachable: " + msg.err.Error())
+			}
+		}
+		return m, nil
+
 	case spinner.TickMsg:
 		if m.phase != phaseReady {
 			var cmd tea.Cmd
@@ -1046,28 +3070,61 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.err != nil {
 			m.phase = phaseAuthGate
 			m.authState = authDisconnected
-			m.appendLog("Failed to read session. Login required.")
+			if errors.Is(msg.err, core.ErrAuthTokenExpired) {
+				m.appendLog("Token from --token/" + core.AuthTokenEnv + " is expired. Login required.")
+				return m, nil
+			}
+			if errors.Is(msg.err, core.ErrAuthTokenNoExpiry) {
+				m.appendLog("Token from --token/" + core.AuthTokenEnv + " has no readable expiry. Login required.")
+				return m, nil
+			}
+			m.appendLog("Failed to read session (" + msg.err.Error() + "). Login required.")
 			return m, nil
 		}
 
 		if core.IsSessionValid(msg.session) {
 			m.token = msg.session.Token
+			m.account = msg.session.Account
+			m.sessionCREIdentity = msg.session.CREIdentity
+			m.sessionSource = msg.session.Source
+			m.sessionExp = msg.session.Exp
+			m.reauthPrompt = false
 			m.authState = authConnected
 			m.phase = phaseReady
 			m.busy = true
-			m.appendLog("Found valid local session.")
+			switch msg.session.Source {
+			case core.SessionSourceFlag:
+				m.appendLog("Using auth token from --token.")
+			case core.SessionSourceEnv:
+				m.appendLog("Using auth token from " + core.AuthTokenEnv + ".")
+			default:
+				if msg.session.Account != "" {
+					m.appendLog("Found valid local session for " + msg.session.Account + ".")
+				} else {
+					m.appendLog("Found valid local session.")
+				}
+			}
+			if m.readOnly() {
+				m.appendLog("Read-only session: secret changes are disabled.")
+			}
+			m.checkIdentity()
+			m.applySessionOrganization(msg.session)
 			m.appendLog("Loading workflows from frontend API...")
-			return m, refreshWorkflowsCmd(m.webBaseURL, m.token)
+			return m, tea.Batch(refreshWorkflowsCmd(m.webBaseURL, m.token, m.workflowFilter), organizationsCmd(m.webBaseURL, m.token, false))
 		}
 
-		if msg.session != nil {
-			_ = core.ClearAuthSession()
-			m.appendLog("Saved session is expired. Login required.")
-		} else {
-			m.appendLog("No saved session found.")
+		if msg.session != nil && core.WithinOfflineGrace(msg.session) {
+			m.expiredSession = msg.session
+			m.appendLog("Saved session is expired. Checking connectivity...")
+			return m, connectivityCmd(m.webBaseURL)
 		}
 		m.phase = phaseAuthGate
 		m.authState = authDisconnected
+		if msg.session != nil {
+			m.appendLog("Saved session is expired. Login required.")
+			return m, revokeSessionCmd(m.webBaseURL, msg.session.Token, true)
+		}
+		m.appendLog("No saved session found.")
 		return m, nil
 
 	case workflowsLoadedMsg:
@@ -1075,46 +3132,279 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.err != nil {
 			if errors.Is(msg.err, core.ErrFrontendUnauthorized) {
 				m.appendLog("Session rejected by frontend API. Login required.")
-				_ = core.ClearAuthSession()
+				m.stopWorkflowEvents()
+				var revokeCmd tea.Cmd
+				if m.sessionSource == core.SessionSourceFile {
+					revokeCmd = revokeSessionCmd(m.webBaseURL, m.token, true)
+				}
 				m.token = ""
 				m.authState = authDisconnected
 				m.phase = phaseAuthGate
-				return m, nil
+				return m, revokeCmd
 			}
-			m.appendLog("Workflow fetch failed: " + msg.err.Error())
+			if core.IsFrontendUnreachable(msg.err) && !msg.appended {
+				if m.offline {
+					m.appendLog("Frontend still unreachable (" + msg.err.Error() + "); keeping the local workflow list.")
+					return m, nil
+				}
+				m.enterLocalOnlyMode(msg.err)
+				return m, localDriftCmd()
+			}
+			m.appendLog("Workflow fetch failed: " + msg.err.Error())
 			return m, nil
 		}
+		if msg.stale && m.offline {
+			m.appendLog("Frontend still unreachable; keeping the local workflow list.")
+			return m, nil
+		}
+		if m.offline {
+			m.offline = false
+			m.localWorkflows = nil
+			m.appendLog("Connection to the frontend restored.")
+		}
 
-		m.setWorkflows(msg.workflows)
+		workflows := msg.workflows
+		if msg.appended {
+			workflows = append(append([]core.FrontendWorkflow{}, m.accountWorkflows[m.profile]...), msg.workflows...)
+		}
+		m.workflowsCursor = msg.nextCursor
+		m.setWorkflows(workflows)
+		m.workflowsLoaded = true
+		m.accountWorkflows[m.profile] = workflows
+		if msg.stale {
+			m.authState = authUnreachable
+			m.lastSyncAt = msg.fetchedAt.Local().Format("2006-01-02 15:04:05")
+			m.appendLog(fmt.Sprintf("Frontend unreachable; showing %d cached workflow(s) from %s.", len(workflows), m.lastSyncAt))
+			return m, m.applyStartupDeepLink()
+		}
+		if m.authState == authUnreachable {
+			m.authState = authConnected
+		}
 		m.lastSyncAt = time.Now().Local().Format("2006-01-02 15:04:05")
-		m.appendLog(fmt.Sprintf("Fetched %d workflow(s) from frontend API.", len(msg.workflows)))
-		return m, nil
+		if msg.appended {
+			m.appendLog(fmt.Sprintf("Loaded %d more workflow(s); %d shown.", len(msg.workflows), len(workflows)))
+		} else {
+			m.appendLog(fmt.Sprintf("Fetched %d workflow(s) from frontend API.", len(msg.workflows)))
+		}
+		if m.workflowsCursor != "" {
+			m.appendLog("More workflows are available. Choose 'Load more' in the list to fetch them.")
+		}
+		return m, tea.Batch(m.applyStartupDeepLink(), m.startWorkflowEvents(), localDriftCmd())
+
+	case workflowEventMsg:
+		if msg.gen != m.eventsGen {
+			return m, nil
+		}
+		m.applyWorkflowEvent(msg.event)
+		if m.eventsCh == nil {
+			return m, nil
+		}
+		return m, waitForWorkflowEventCmd(m.eventsCh)
+
+	case workflowEventsClosedMsg:
+		if msg.gen != m.eventsGen {
+			return m, nil
+		}
+		m.eventsCancel = nil
+		m.eventsCh = nil
+		switch {
+		case errors.Is(msg.err, core.ErrEventsUnsupported):
+			m.eventsUnsupported = true
+			return m, nil
+		case errors.Is(msg.err, core.ErrFrontendUnauthorized):
+			// The health ping reports the rejected session.
+			return m, nil
+		}
+		gen := m.eventsGen
+		return m, tea.Tick(workflowEventsRetryDelay, func(_ time.Time) tea.Msg {
+			return workflowEventsRetryMsg{gen: gen}
+		})
+
+	case workflowEventsRetryMsg:
+		if msg.gen != m.eventsGen || m.phase != phaseReady {
+			return m, nil
+		}
+		return m, m.startWorkflowEvents()
 
 	case creWhoAmIFinishedMsg:
+		m.creChecked = true
 		if msg.err != nil {
 			m.creLoggedIn = false
 			m.creIdentity = ""
-			m.appendLog("CRE CLI not logged in. Run `cre auth login` to use workflow/actions.")
+			m.creWhoAmI = nil
+			m.identityMismatch = ""
+			m.creMissing = errors.Is(msg.err, core.ErrCRECLINotFound)
+			if errors.As(msg.err, new(*core.CREBinaryError)) {
+				// A configured cre path that does not exist; installing would not help.
+				m.appendLog(msg.err.Error())
+				m.checkIdentity()
+				return m, m.applyStartupDeepLink()
+			}
+			if m.creMissing {
+				m.appendLog("CRE CLI is not installed. Press I to download it into " + core.ManagedCREPath() + ".")
+				m.checkIdentity()
+				return m, m.applyStartupDeepLink()
+			}
+			m.appendLog("CRE CLI not logged in. Press C to run `cre auth login` and use workflow/actions.")
 			m.appendLog("CRE whoami: " + msg.err.Error())
-			return m, nil
+			m.checkIdentity()
+			return m, m.applyStartupDeepLink()
 		}
+		m.creMissing = false
 		m.creLoggedIn = true
+		m.creExpired = false
+		m.creCheckedAt = time.Now()
 		m.creIdentity = compactIdentity(msg.identity)
+		m.creWhoAmI = &core.CREWhoAmIResult{Identity: msg.identity, Organization: msg.organization, Raw: msg.raw}
 		if strings.TrimSpace(msg.raw) != "" {
 			m.appendLog("CRE CLI logged in as " + msg.identity)
 		}
+		m.checkIdentity()
+		return m, m.applyStartupDeepLink()
+
+	case creSessionTickMsg:
+		if !m.creLoggedIn || m.busy {
+			return m, creSessionTickCmd()
+		}
+		return m, tea.Batch(creSessionCheckCmd(false), creSessionTickCmd())
+
+	case creSessionCheckedMsg:
+		if msg.resume {
+			m.busy = false
+		}
+		if msg.err != nil {
+			if !m.creLoggedIn {
+				return m, nil
+			}
+			m.creLoggedIn = false
+			m.creWhoAmI = nil
+			m.identityMismatch = ""
+			m.creMissing = errors.Is(msg.err, core.ErrCRECLINotFound)
+			if m.creMissing {
+				m.appendLog("CRE CLI is no longer available. Press I to download it into " + core.ManagedCREPath() + ".")
+				return m, nil
+			}
+			m.creExpired = true
+			m.appendLog("CRE CLI session expired. Press C to run `cre auth login` again.")
+			m.appendLog("CRE whoami: " + msg.err.Error())
+			return m, nil
+		}
+		m.creCheckedAt = time.Now()
+		if identity := compactIdentity(msg.result.Identity); identity != m.creIdentity {
+			m.creIdentity = identity
+			m.creWhoAmI = msg.result
+			m.appendLog("CRE CLI now logged in as " + msg.result.Identity)
+			m.checkIdentity()
+		}
+		if msg.resume {
+			return m, m.runSelectedAction()
+		}
+		return m, nil
+
+	case creLoginFinishedMsg:
+		if msg.err != nil {
+			m.appendLog("`cre auth login` failed: " + msg.err.Error())
+		} else {
+			m.appendLog("`cre auth login` finished.")
+		}
+		m.appendLog("Checking CRE CLI identity (`cre whoami`) ...")
+		return m, creWhoAmICmd()
+
+	case creInstalledMsg:
+		m.busy = false
+		if msg.result != nil {
+			for _, line := range msg.result.Logs {
+				m.appendLog(line)
+			}
+		}
+		if msg.err != nil {
+			m.appendLog("CRE CLI install failed: " + msg.err.Error())
+			m.appendLog("Install it manually from https://docs.chain.link/cre or set SIXFLOW_CRE_RELEASE_URL to a mirror.")
+			return m, nil
+		}
+		m.creMissing = false
+		if msg.result.Version != "" {
+			m.appendLog("CRE CLI " + msg.result.Version + " is ready. Press C to run `cre auth login`.")
+		}
+		m.appendLog("Checking CRE CLI identity (`cre whoami`) ...")
+		return m, creWhoAmICmd()
+
+	case hostInspectedMsg:
+		m.hostTrust = msg.info
 		return m, nil
 
+	case loginStartedMsg:
+		m.loginCh = msg.ch
+		return m, waitForLoginCmd(m.loginCh)
+
+	case loginPromptMsg:
+		prompt := msg.prompt
+		m.loginPrompt = &prompt
+		if prompt.Fallback != "" {
+			m.appendLog("Switched to device code login: " + prompt.Fallback + ".")
+		}
+		if prompt.CallbackPort == 0 {
+			m.appendLog("Open " + prompt.URL + " on any device and enter code: " + prompt.PairingCode)
+		} else if prompt.BrowserOpened {
+			m.appendLog("Opened browser for login. If nothing appeared, open: " + prompt.URL)
+		} else {
+			m.appendLog("Could not open a browser (" + prompt.BrowserSkipped + "). Open this URL on any machine:")
+			m.appendLog(prompt.URL)
+			m.appendLog("Pairing code: " + prompt.PairingCode + " (confirm it matches the login page)")
+			m.appendLog(fmt.Sprintf("From another machine, forward the callback first: ssh -L %d:127.0.0.1:%d <this-host>", prompt.CallbackPort, prompt.CallbackPort))
+		}
+		if m.loginCh == nil {
+			return m, nil
+		}
+		return m, waitForLoginCmd(m.loginCh)
+
 	case loginFinishedMsg:
+		m.loginCh = nil
+		m.loginPrompt = nil
+		if msg.err != nil && m.reauthing {
+			m.reauthing = false
+			m.appendLog("Re-authentication failed: " + msg.err.Error())
+			m.appendLog("Current session is kept until it expires. Press R to try again.")
+			return m, nil
+		}
+		m.reauthing = false
+		if m.addingAccount {
+			m.addingAccount = false
+			if msg.err != nil {
+				m.appendLog("Adding account failed: " + msg.err.Error())
+				return m, nil
+			}
+			account := core.TokenAccountLabel(msg.token)
+			profile := core.ProfileForAccount(account, m.webBaseURL)
+			if err := core.SetAuthProfile(profile); err != nil {
+				m.appendLog("Adding account failed: " + err.Error())
+				return m, nil
+			}
+			m.profile = profile
+			m.claimInstance()
+			m.workflowsLoaded = false
+			m.workflowsCursor = ""
+			m.stopWorkflowEvents()
+			m.eventsUnsupported = false
+			m.clearOrganization()
+			m.setWorkflows(m.accountWorkflows[profile])
+			m.creChecked = false
+			m.creLoggedIn = false
+			m.creIdentity = ""
+			m.creWhoAmI = nil
+			m.identityMismatch = ""
+			m.appendLog(fmt.Sprintf("Saving account %s as profile %q.", account, profile))
+		}
 		if msg.err != nil {
 			m.phase = phaseAuthGate
 			m.authState = authDisconnected
 			m.busy = false
 			m.appendLog("Login flow failed: " + msg.err.Error())
+			m.appendLog("Press T to paste a token copied from the web UI instead.")
 			return m, nil
 		}
 
-		session, err := core.SaveAuthSession(msg.token)
+		session, err := core.SaveAuthSession(msg.token, m.webBaseURL)
 		if err != nil || !core.IsSessionValid(session) {
 			m.phase = phaseAuthGate
 			m.authState = authDisconnected
@@ -1127,40 +3417,62 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
+		if session.StoreWarning != "" {
+			m.appendLog("Warning: " + session.StoreWarning)
+		}
 		m.token = msg.token
+		m.account = session.Account
+		m.sessionCREIdentity = session.CREIdentity
+		m.sessionSource = session.Source
+		m.sessionExp = session.Exp
+		m.reauthPrompt = false
 		m.authState = authConnected
 		m.phase = phaseReady
 		m.busy = true
 		m.appendLog("Authentication completed. Loading workflows...")
+		if m.readOnly() {
+			m.appendLog("Read-only session: secret changes are disabled.")
+		}
+		m.applySessionOrganization(session)
 		m.appendLog("Loading workflows from frontend API...")
-		return m, tea.Batch(refreshWorkflowsCmd(m.webBaseURL, m.token), creWhoAmICmd())
+		return m, tea.Batch(refreshWorkflowsCmd(m.webBaseURL, m.token, m.workflowFilter), creWhoAmICmd(), organizationsCmd(m.webBaseURL, m.token, false))
 
 	case preSimulateReadyMsg:
-		for _, line := range msg.logs {
-			m.appendLog(line)
-		}
+		m.simulateStreamCh = nil
 		if msg.err != nil {
 			m.appendLog("Pre-simulation failed: " + msg.err.Error())
 			m.busy = false
 			return m, nil
 		}
-		if m.simulateNeedsEVMFlags {
+		m.simulateExtraEnv = msg.env
+		if msg.needsPassphrase {
 			m.busy = false
-			m.simulateFormOpen = true
+			m.simulatePassphraseOpen = true
 			m.simulatePendingRoot = msg.projectRoot
 			m.simulatePendingArgs = append([]string(nil), msg.cmdArgs...)
-			m.simulateFormError = ""
-			m.simulateFormActiveField = 0
-			m.simulateTxHashInput.SetValue("")
-			m.simulateEventIndexInput.SetValue("0")
-			m.simulateTxHashInput.Focus()
-			m.simulateEventIndexInput.Blur()
-			m.appendLog("Pre-simulation ready. Enter EVM trigger input.")
+			m.simulatePassphraseError = ""
+			m.simulatePassphraseInput.SetValue("")
+			m.simulatePassphraseInput.Focus()
+			m.appendLog("Pre-simulation ready. Enter keystore passphrase to unlock CRE_ETH_PRIVATE_KEY.")
 			return m, nil
 		}
-		m.busy = true
-		m.appendLog("Pre-simulation ready. Running cre simulate (no stdin required).")
-		return m, runPreparedSimulateCmd(msg.projectRoot, msg.cmdArgs, "")
+		return m, m.continueSimulate(msg.projectRoot, msg.cmdArgs)
+
+	case keystoreUnlockedMsg:
+		m.busy = false
+		if msg.err != nil {
+			m.simulatePassphraseError = msg.err.Error()
+			m.simulatePassphraseInput.SetValue("")
+			m.appendLog("Keystore unlock failed: " + msg.err.Error())
+			return m, nil
+		}
+		m.simulatePassphraseOpen = false
+		m.simulatePassphraseError = ""
+		m.simulatePassphraseInput.SetValue("")
+		m.simulatePassphraseInput.Blur()
+		m.simulateExtraEnv = append(m.simulateExtraEnv, msg.env...)
+		m.appendLog("Keystore unlocked. Private key is passed to cre via environment for this run only.")
+		return m, m.continueSimulate(m.simulatePendingRoot, m.simulatePendingArgs)
 
 	case simulateStreamStartedMsg:
 		m.simulateStreamCh = msg.ch
@@ -1177,966 +3489,3160 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.handleSimulateDone(msg.err)
 		return m, nil
 
-	case actionFinishedMsg:
+	case deployPreflightMsg:
+		m.busy = false
+		if msg.err != nil {
+			m.appendLog("Deploy preflight failed: " + msg.err.Error())
+			return m, nil
+		}
+		m.deployPreflight = msg.preflight
+		for _, check := range msg.preflight.Checks {
+			m.appendLog(deployCheckLine(check))
+		}
+		if msg.preflight.Fee != nil {
+			m.appendLog("Estimated deploy fee: " + msg.preflight.Fee.Summary())
+		}
+		m.deployConfirmOpen = true
+		if msg.preflight.Passed() {
+			m.appendLog(fmt.Sprintf("Preflight passed. Confirm deploying %s to %s: y deploys, T switches target, esc cancels.", m.deployWorkflowName, msg.preflight.Target))
+		} else {
+			m.appendLog("Preflight found problems; deploy is blocked until they are fixed. r re-runs the checks, esc cancels.")
+		}
+		return m, nil
+
+	case walletLoadedMsg:
+		if msg.workflowID != m.secretsWorkflowID || msg.target != m.currentTarget() {
+			return m, nil
+		}
+		m.wallet = msg.wallet
+		m.walletErr = ""
+		if msg.err != nil {
+			m.walletErr = msg.err.Error()
+		}
+		if m.wallet != nil {
+			m.appendLog(fmt.Sprintf("Wallet for %s: %s", msg.target, m.wallet.Address))
+			for _, balance := range m.wallet.Balances {
+				if balance.Err != nil {
+					m.appendLog(fmt.Sprintf("  %s: balance unavailable: %v", balance.ChainName, balance.Err))
+					continue
+				}
+				m.appendLog(fmt.Sprintf("  %s: %s native", balance.ChainName, core.FormatWei(balance.Balance)))
+			}
+			if m.wallet.Demo {
+				m.appendLog("  This is the generated demo key; it is unfunded. Set your own key with UPDATE.")
+			}
+		}
+		return m, nil
+
+	case rollbackSyncedMsg:
 		for _, line := range msg.logs {
 			m.appendLog(line)
 		}
 		if msg.err != nil {
-			m.appendLog("Action failed: " + msg.err.Error())
 			m.busy = false
+			m.appendLog("Rollback failed: " + describeFrontendError(msg.err))
 			return m, nil
 		}
-		if action := m.selectedAction(); action != nil {
-			m.appendLog(fmt.Sprintf("Action %q completed.", action.title))
+		m.deploymentsOpen = false
+		m.deployWorkflowID = m.deploymentsID
+		m.deployWorkflowName = m.deploymentsName
+		m.loadTargets(m.deploymentsID, m.deploymentsName)
+		for idx, target := range m.targets {
+			if target == msg.target {
+				m.targetIndex = idx
+			}
 		}
+		m.deployPreflight = nil
+		m.appendLog(fmt.Sprintf("Older bundle synced. Running deploy preflight to redeploy it to %s...", m.currentTarget()))
+		return m, deployPreflightCmd(m.deployWorkflowID, m.deployWorkflowName, m.currentTarget())
+
+	case deploymentOpFinishedMsg:
+		m.simulateStreamCh = nil
 		m.busy = false
+		if msg.err != nil {
+			m.appendLog(fmt.Sprintf("Deployment %s failed: %s", msg.op, msg.err.Error()))
+			return m, nil
+		}
+		m.appendLog(fmt.Sprintf("Deployment %s of %s on %s finished.", msg.op, m.deploymentsName, msg.target))
+		if m.deploymentsOpen {
+			m.openDeployments(m.deploymentsID, m.deploymentsName)
+		}
 		return m, nil
 
-	case syncLocalFinishedMsg:
+	case deployFinishedMsg:
+		m.simulateStreamCh = nil
+		m.busy = false
 		if msg.err != nil {
-			m.appendLog("Sync to local failed: " + msg.err.Error())
-			m.busy = false
+			m.appendLog("Deploy failed: " + msg.err.Error())
 			return m, nil
 		}
-		for _, line := range msg.logs {
-			m.appendLog(line)
+		if msg.result == nil || msg.result.Deployment == nil {
+			m.appendLog("Deploy finished (dry run, nothing recorded).")
+			return m, nil
 		}
-		m.appendLog("Action \"Sync to local\" completed.")
-		m.busy = false
+		if msg.result.Deployment.WorkflowID == "" {
+			m.appendLog("Deploy finished, but the CLI output had no workflow ID; check the lines above.")
+			return m, nil
+		}
+		m.appendLog(fmt.Sprintf("Deployed %s to %s as %s.", m.deployWorkflowName, msg.result.Deployment.Target, msg.result.Deployment.WorkflowID))
 		return m, nil
 
-	case secretsCmdFinishedMsg:
+	case actionFinishedMsg:
 		for _, line := range msg.logs {
 			m.appendLog(line)
 		}
 		if msg.err != nil {
-			if msg.label == "Update value" || strings.HasPrefix(msg.label, "Secrets ") {
-				m.secretFormError = msg.err.Error()
-				m.secretFormOpen = m.secretFormMode != ""
-			}
-			m.appendLog(msg.label + " failed: " + msg.err.Error())
+			m.appendLog("Action failed: " + msg.err.Error())
 			m.busy = false
 			return m, nil
 		}
-		if msg.label == "Update value" || strings.HasPrefix(msg.label, "Secrets ") {
-			m.secretFormOpen = false
-			m.secretFormMode = ""
-			m.secretFormVariableKind = ""
-			m.secretFormVariableKey = ""
-			m.secretFormError = ""
-			m.secretIDLocked = false
-			m.secretRemoveFromConvex = false
-			m.secretIDInput.SetValue("")
-			m.secretValueInput.SetValue("")
+		if action := m.selectedAction(); action != nil {
+			m.appendLog(fmt.Sprintf("Action %q completed.", action.title))
 		}
-		m.appendLog("Action \"" + msg.label + "\" completed.")
 		m.busy = false
 		return m, nil
 
-	case secretOptionsLoadedMsg:
-		for _, line := range msg.logs {
-			m.appendLog(line)
+	case frontendNoticeMsg:
+		m.appendLog(msg.text)
+		return m, nil
+
+	case organizationsLoadedMsg:
+		if msg.token != m.token {
+			return m, nil
 		}
-		if msg.err != nil {
-			m.appendLog("Unable to list secrets: " + msg.err.Error())
+		if msg.pick {
 			m.busy = false
-			return m, nil
 		}
-
-		items := make([]list.Item, 0, len(msg.options))
-		for _, option := range msg.options {
-			switch msg.actionID {
-			case "add":
-				if option.HasValue {
-					continue
-				}
+		if msg.err != nil {
+			if !msg.pick {
+				return m, nil
 			}
-			status := "missing in .env"
-			if option.HasValue {
-				status = "present in .env"
+			if errors.Is(msg.err, core.ErrOrganizationsUnsupported) {
+				m.appendLog("This frontend does not support organizations.")
+			} else {
+				m.appendLog("Fetching organizations failed: " + describeFrontendError(msg.err))
 			}
-			description := status
-			if strings.TrimSpace(option.EnvVar) != "" {
-				description = fmt.Sprintf("%s (%s)", option.EnvVar, status)
+			return m, nil
+		}
+		if len(msg.orgs) <= 1 {
+			if msg.pick {
+				m.appendLog("This account belongs to a single organization.")
 			}
-			items = append(items, secretPickItem{
-				id:          option.ID,
-				key:         option.ID,
-				kind:        "secret_env",
-				section:     "environment",
-				description: description,
-				selectable:  true,
-			})
+			return m, nil
 		}
-
-		if len(items) == 0 {
-			switch msg.actionID {
-			case "add":
-				m.appendLog("No missing secrets to add.")
-			case "update":
-				m.appendLog("No secrets available to update.")
-			case "remove":
-				m.appendLog("No configured secrets to remove.")
+		if !msg.pick {
+			if m.orgID == "" {
+				m.appendLog(fmt.Sprintf("This account belongs to %d organizations. Press O to choose which one to work in.", len(msg.orgs)))
 			}
-			m.busy = false
 			return m, nil
 		}
-
-		m.secretPickAction = msg.actionID
-		m.secretPickOpen = true
-		m.secretPickList.SetItems(items)
-		m.secretPickList.Select(0)
-		m.busy = false
-		m.appendLog("Pick a secret from the list and press Enter.")
+		m.openOrganizationPicker(msg.orgs)
 		return m, nil
 
-	case variableOptionsLoadedMsg:
-		for _, line := range msg.logs {
-			m.appendLog(line)
+	case frontendHealthMsg:
+		if msg.baseURL != m.webBaseURL {
+			return m, nil
 		}
 		if msg.err != nil {
-			m.appendLog("Unable to list variables: " + msg.err.Error())
-			m.busy = false
+			m.appendLog(fmt.Sprintf("WARNING: Frontend %s is unreachable: %v. Check the URL, proxy and network; cached workflows are used where available.", m.webBaseURL, msg.err))
 			return m, nil
 		}
-		systemItems := make([]list.Item, 0)
-		environmentItems := make([]list.Item, 0)
-		for _, option := range msg.options {
-			switch option.Section {
-			case "system":
-				systemItems = append(systemItems, secretPickItem{
-					id:           option.Label,
-					key:          option.Key,
-					kind:         option.Kind,
-					section:      option.Section,
-					currentValue: option.CurrentValue,
-					description:  option.Description,
-					selectable:   true,
-				})
-			case "environment":
-				environmentItems = append(environmentItems, secretPickItem{
-					id:           option.Label,
-					key:          option.Key,
-					kind:         option.Kind,
-					section:      option.Section,
-					currentValue: option.CurrentValue,
-					description:  option.Description,
-					selectable:   true,
-				})
-			}
+		health := msg.health
+		details := fmt.Sprintf("latency %dms", health.Latency.Milliseconds())
+		if health.Version != "" {
+			details = "version " + health.Version + ", " + details
 		}
-		if len(systemItems) == 0 && len(environmentItems) == 0 {
-			m.appendLog("No variables available to update.")
-			m.busy = false
+		switch {
+		case !health.Supported:
+			m.appendLog(fmt.Sprintf("Frontend API mode enabled (%s): reachable, %s (no health endpoint).", m.webBaseURL, details))
+		case health.Healthy:
+			m.appendLog(fmt.Sprintf("Frontend API mode enabled (%s): healthy, %s.", m.webBaseURL, details))
+		default:
+			m.appendLog(fmt.Sprintf("WARNING: Frontend %s reports status %q (%s). Requests may fail.", m.webBaseURL, health.Status, details))
+		}
+		return m, nil
+
+	case compileRequestedMsg:
+		if msg.token != m.token {
 			return m, nil
 		}
-		m.secretPickAction = "update"
-		m.variablePickerOpen = true
-		m.systemVariableList.SetItems(systemItems)
-		m.environmentVariableList.SetItems(environmentItems)
-		if len(systemItems) > 0 {
-			m.systemVariableList.Select(0)
+		m.busy = false
+		if msg.err != nil {
+			switch {
+			case errors.Is(msg.err, core.ErrCompileUnsupported):
+				m.appendLog("This frontend cannot compile workflows from the TUI. Compile " + msg.name + " in the browser.")
+			case errors.Is(msg.err, core.ErrFrontendUnauthorized):
+				m.appendLog("Compile request was rejected. Press R to re-authenticate.")
+			default:
+				m.appendLog("Compile request failed: " + describeFrontendError(msg.err))
+			}
+			return m, nil
 		}
-		if len(environmentItems) > 0 {
-			m.environmentVariableList.Select(0)
+		m.compilingID = msg.id
+		m.compilingName = msg.name
+		m.compileDeadline = time.Now().Add(core.DefaultCompileTimeout)
+		m.appendLog(fmt.Sprintf("Compilation of %s requested. Waiting for it to become ready...", msg.name))
+		return m, compilePollCmd(msg.id)
+
+	case compilePollMsg:
+		if msg.id != m.compilingID || m.offline {
+			return m, nil
 		}
-		if len(systemItems) > 0 {
-			m.variablePickerFocus = 0
+		return m, compileStatusCmd(m.webBaseURL, m.token, m.compilingID, m.compilingName)
+
+	case compileStatusMsg:
+		if msg.token != m.token || msg.id != m.compilingID {
+			return m, nil
+		}
+		if msg.err != nil {
+			if errors.Is(msg.err, core.ErrFrontendUnauthorized) {
+				m.compilingID = ""
+				m.appendLog("Stopped waiting for " + msg.name + ": the session was rejected.")
+				return m, nil
+			}
+			m.appendLog("Checking compile status failed: " + describeFrontendError(msg.err))
 		} else {
-			m.variablePickerFocus = 1
+			// Logs status transitions like a live update would.
+			wf := msg.workflow
+			m.applyWorkflowEvent(core.WorkflowEvent{Type: core.WorkflowEventUpdated, Workflow: *wf})
+			switch {
+			case wf.Status == "ready":
+				m.compilingID = ""
+				m.appendLog(fmt.Sprintf("%s compiled (compiler %s). Press enter to sync it.", msg.name, wf.CompilerVersion))
+				return m, nil
+			case core.CompileFailed(wf.Status):
+				m.compilingID = ""
+				m.appendLog(fmt.Sprintf("Compilation of %s failed (status %s). Check the workflow in the browser.", msg.name, wf.Status))
+				return m, nil
+			}
 		}
-		m.busy = false
-		m.appendLog("Update value picker opened. Choose from System (left) or Environment (right).")
-		return m, nil
+		if time.Now().After(m.compileDeadline) {
+			m.compilingID = ""
+			m.appendLog(fmt.Sprintf("Gave up waiting for %s to compile after %s.", msg.name, core.DefaultCompileTimeout))
+			return m, nil
+		}
+		return m, compilePollCmd(msg.id)
 
-	case copyNoticeClearedMsg:
-		if msg.id == m.copyNoticeID {
-			m.copyNotice = ""
+	case compatibilityMsg:
+		if msg.baseURL != m.webBaseURL {
+			return m, nil
+		}
+		// Unreachable or unversioned frontends are reported elsewhere.
+		if errors.Is(msg.err, core.ErrIncompatibleClient) {
+			m.incompatible = msg.err.Error()
+			m.appendLog("WARNING: " + m.incompatible + ". Requests may fail until the TUI is upgraded.")
 		}
 		return m, nil
 
-	case tea.KeyMsg:
-		if key.Matches(msg, keys.Quit) {
-			return m, tea.Quit
+	case workflowDetailLoadedMsg:
+		m.busy = false
+		if msg.err != nil {
+			switch {
+			case errors.Is(msg.err, core.ErrWorkflowDetailUnsupported):
+				m.appendLog("Workflow details are not available on this frontend.")
+			case errors.Is(msg.err, core.ErrFrontendUnauthorized):
+				m.appendLog("Workflow details request was rejected. Press R to re-authenticate.")
+			default:
+				m.appendLog("Workflow details failed: " + describeFrontendError(msg.err))
+			}
+			return m, nil
 		}
+		m.detailView.SetContent(renderWorkflowDetail(msg.detail))
+		m.detailView.GotoTop()
+		m.detailOpen = true
+		m.focus = focusActions
+		return m, nil
 
-		if m.phase == phaseAuthGate {
-			switch strings.ToLower(msg.String()) {
-			case "y":
-				m.phase = phaseLinking
-				m.busy = true
-				m.appendLog("Starting browser login flow...")
-				m.appendLog("Waiting for browser authentication...")
-				return m, loginCmd(m.webBaseURL)
-			case "n":
-				return m, tea.Quit
+	case workflowRunsLoadedMsg:
+		m.busy = false
+		if msg.err != nil {
+			switch {
+			case errors.Is(msg.err, core.ErrRunHistoryUnsupported):
+				m.appendLog("Run history is not available on this frontend.")
+			case errors.Is(msg.err, core.ErrFrontendUnauthorized):
+				m.appendLog("Run history request was rejected. Press R to re-authenticate.")
 			default:
-				return m, nil
+				m.appendLog("Run history failed: " + describeFrontendError(msg.err))
 			}
+			return m, nil
 		}
+		m.openRunHistory(msg.runs)
+		return m, nil
 
-		if m.phase != phaseReady {
+	case bundleVersionsLoadedMsg:
+		m.busy = false
+		if msg.workflowID != m.versionsID {
 			return m, nil
 		}
-
-		if m.secretFormOpen {
-			if m.secretFormMode == "remove" {
-				switch msg.String() {
-				case "t", "T", "ctrl+t":
-					m.secretRemoveFromConvex = !m.secretRemoveFromConvex
-					if m.secretRemoveFromConvex {
-						m.appendLog("REMOVE mode: Convex removal enabled.")
-					} else {
-						m.appendLog("REMOVE mode: Convex removal disabled (clear local value only).")
-					}
-					return m, nil
-				}
+		if msg.err != nil {
+			switch {
+			case errors.Is(msg.err, core.ErrBundleVersionsUnsupported):
+				m.appendLog("Bundle versions are not available on this frontend.")
+			case errors.Is(msg.err, core.ErrFrontendUnauthorized):
+				m.appendLog("Bundle versions request was rejected. Press R to re-authenticate.")
+			default:
+				m.appendLog("Bundle versions failed: " + describeFrontendError(msg.err))
 			}
+			return m, nil
+		}
+		m.openBundleVersions(msg.versions)
+		return m, nil
 
-			switch msg.String() {
-			case "esc":
-				m.secretFormOpen = false
-				m.secretFormMode = ""
-				m.secretFormVariableKind = ""
-				m.secretFormVariableKey = ""
-				m.secretFormError = ""
-				m.secretIDLocked = false
-				m.secretRemoveFromConvex = false
-				m.secretIDInput.SetValue("")
-				m.secretValueInput.SetValue("")
-				m.appendLog("Secrets form canceled.")
-				return m, nil
-			case "enter":
-				if m.busy {
-					return m, nil
-				}
-				id := normalizeSecretNameInput(m.secretIDInput.Value())
-				value := strings.TrimSpace(m.secretValueInput.Value())
-				if m.secretFormMode != "update" && id == "" {
-					m.secretFormError = "Secret ID is required."
-					return m, nil
-				}
-				if !m.secretIDLocked && m.secretFormMode != "remove" && m.secretFormMode != "update" && m.secretFormActiveField == 0 {
-					m.secretFormActiveField = 1
-					m.secretIDInput.Blur()
-					m.secretValueInput.Focus()
-					return m, nil
-				}
-				if m.secretFormMode != "remove" && value == "" {
-					m.secretFormError = "Secret value is required."
-					return m, nil
-				}
-				m.busy = true
-				m.secretFormError = ""
-				m.appendLog(fmt.Sprintf("Applying %s for %s...", m.secretFormMode, m.secretsWorkflowName))
-				if m.secretFormMode == "update" {
-					return m, updateVariableCmd(
-						m.secretsWorkflowID,
-						m.secretsWorkflowName,
-						m.currentSecretsTarget(),
-						m.secretFormVariableKind,
-						m.secretFormVariableKey,
-						value,
-					)
-				}
-				frontendSyncAction := ""
-				if m.secretFormMode == "add" {
-					frontendSyncAction = "add"
-				}
-				if m.secretFormMode == "remove" && m.secretRemoveFromConvex {
-					frontendSyncAction = "remove"
-				}
-				return m, secretsCommandCmd(
-					m.webBaseURL,
-					m.token,
-					m.secretFormMode,
-					m.secretsWorkflowID,
-					m.secretsWorkflowName,
-					m.currentSecretsTarget(),
-					id,
-					value,
-					frontendSyncAction,
-				)
-			case "tab", "shift+tab", "up", "down":
-				if m.secretIDLocked || m.secretFormMode == "remove" || m.secretFormMode == "update" {
-					return m, nil
-				}
-				if m.secretFormActiveField == 0 {
-					m.secretFormActiveField = 1
-					m.secretIDInput.Blur()
-					m.secretValueInput.Focus()
-				} else {
-					m.secretFormActiveField = 0
-					m.secretValueInput.Blur()
-					m.secretIDInput.Focus()
-				}
-				return m, nil
+	case configPushedMsg:
+		m.busy = false
+		if msg.result != nil {
+			for _, line := range msg.result.Logs {
+				m.appendLog(line)
 			}
-
-			var cmd tea.Cmd
-			if !m.secretIDLocked && (m.secretFormMode == "remove" || m.secretFormActiveField == 0) {
-				m.secretIDInput, cmd = m.secretIDInput.Update(msg)
-			} else {
-				m.secretValueInput, cmd = m.secretValueInput.Update(msg)
+		}
+		if msg.err != nil {
+			switch {
+			case errors.Is(msg.err, core.ErrConfigPushUnsupported):
+				m.appendLog("This frontend does not accept config uploads yet.")
+			case errors.Is(msg.err, core.ErrFrontendUnauthorized):
+				m.appendLog("Config request was rejected. Press R to re-authenticate.")
+			default:
+				m.appendLog("Push config failed: " + describeFrontendError(msg.err))
 			}
-			return m, cmd
+			return m, nil
+		}
+		switch {
+		case msg.result.Pushed:
+			m.appendLog(fmt.Sprintf("Action \"Push config\" completed for %s.", msg.name))
+		case !msg.result.HasChanges():
+			m.appendLog("Local config matches the frontend; nothing to push.")
+		default:
+			m.configPushID = msg.workflowID
+			m.appendLog("Run Push config again to upload these changes.")
 		}
+		return m, nil
 
-		if m.simulateFormOpen {
-			switch msg.String() {
-			case "esc", "backspace", "b":
-				m.simulateFormOpen = false
-				m.simulateFormError = ""
-				m.simulateTxHashInput.Blur()
-				m.simulateEventIndexInput.Blur()
-				m.simulateNeedsEVMFlags = false
-				m.simulatePendingRoot = ""
-				m.simulatePendingArgs = nil
-				m.simulateFormActiveField = 0
-				return m, nil
+	case syncPreviewMsg:
+		if msg.preview != nil {
+			for _, line := range msg.preview.Logs {
+				m.appendLog(line)
 			}
+		}
+		if msg.err != nil {
+			m.appendLog("Sync to local failed: " + describeFrontendError(msg.err))
+			m.busy = false
+			return m, nil
+		}
+		if !msg.preview.Exists {
+			m.appendLog("No local project yet; writing it.")
+			return m, syncLocalCmd(msg.preview)
+		}
+		m.busy = false
+		m.syncPreview = msg.preview
+		m.syncPreviewOpen = true
+		m.syncPreviewArmed = false
+		m.appendLog(fmt.Sprintf("Sync would change the local project: %s.", msg.preview.Summary()))
+		if lost := msg.preview.LostEdits(); len(lost) > 0 {
+			m.appendLog(fmt.Sprintf("%d locally modified file(s) would be overwritten. y twice syncs anyway, esc cancels.", len(lost)))
+		} else {
+			m.appendLog("Confirm the sync: y syncs, esc cancels.")
+		}
+		return m, nil
 
-			if key.Matches(msg, keys.Run) {
-				tx := strings.TrimSpace(m.simulateTxHashInput.Value())
-				if m.simulateFormActiveField == 0 {
-					if tx == "" {
-						m.simulateFormError = "EVM tx hash is required."
-						return m, nil
-					}
-					m.simulateFormActiveField = 1
-					m.simulateFormError = ""
-					m.simulateTxHashInput.Blur()
-					m.simulateEventIndexInput.Focus()
-					return m, nil
-				}
-
-				if tx == "" {
-					m.simulateFormError = "EVM tx hash is required."
-					return m, nil
-				}
-				if !strings.HasPrefix(tx, "0x") {
-					m.simulateFormError = "EVM tx hash must start with 0x."
-					return m, nil
-				}
-				eventIndexRaw := strings.TrimSpace(m.simulateEventIndexInput.Value())
-				eventIndex, err := strconv.Atoi(eventIndexRaw)
-				if err != nil || eventIndex < 0 {
-					m.simulateFormError = "EVM event index must be a non-negative integer."
-					return m, nil
-				}
-
-				cmdArgs := append([]string(nil), m.simulatePendingArgs...)
-				cmdArgs = append(
-					cmdArgs,
-					"--non-interactive",
-					"--trigger-index", "0",
-					"--evm-tx-hash", tx,
-					"--evm-event-index", strconv.Itoa(eventIndex),
-				)
+	case orphanedProjectsMsg:
+		m.busy = false
+		if msg.skipped > 0 {
+			m.appendLog(fmt.Sprintf("Skipped %d local project(s) synced under another profile or organization, or by an older version; re-sync them to include them.", msg.skipped))
+		}
+		if msg.err != nil {
+			m.appendLog("Finding orphaned projects failed: " + describeFrontendError(msg.err))
+			return m, nil
+		}
+		if len(msg.removals) == 0 {
+			m.appendLog("No orphaned local projects: every synced workflow still exists in the frontend.")
+			return m, nil
+		}
+		m.openLocalRemove("Prune orphaned projects", msg.removals)
+		return m, nil
 
-				m.simulateFormOpen = false
-				m.simulateFormError = ""
-				m.simulateTxHashInput.Blur()
-				m.simulateEventIndexInput.Blur()
-				m.simulateFormActiveField = 0
-				m.busy = true
-				m.appendLog(fmt.Sprintf("Running cre simulate with EVM flags (tx=%s, index=%d)...", tx, eventIndex))
-				return m, runPreparedSimulateCmd(m.simulatePendingRoot, cmdArgs, "")
+	case localProjectsRemovedMsg:
+		m.busy = false
+		if msg.result != nil {
+			for _, line := range msg.result.Logs {
+				m.appendLog(line)
 			}
-
-			switch msg.String() {
-			case "tab":
-				if m.simulateFormActiveField == 0 {
-					m.simulateFormActiveField = 1
-					m.simulateTxHashInput.Blur()
-					m.simulateEventIndexInput.Focus()
-				} else {
-					m.simulateFormActiveField = 0
-					m.simulateEventIndexInput.Blur()
-					m.simulateTxHashInput.Focus()
-				}
-				return m, nil
+			if m.offline && len(msg.result.Removed) > 0 {
+				m.showLocalWorkflows()
 			}
+		}
+		if msg.err != nil {
+			m.appendLog("Deleting local projects failed: " + msg.err.Error())
+		}
+		return m, localDriftCmd()
 
-			var cmd tea.Cmd
-			if m.simulateFormActiveField == 0 {
-				m.simulateTxHashInput, cmd = m.simulateTxHashInput.Update(msg)
-			} else {
-				m.simulateEventIndexInput, cmd = m.simulateEventIndexInput.Update(msg)
+	case localDriftMsg:
+		if msg.err != nil {
+			return m, nil
+		}
+		m.localDrift = map[string]int{}
+		for id, drift := range msg.drift {
+			m.localDrift[id] = drift.Count()
+		}
+		items := m.workflowList.Items()
+		for idx, item := range items {
+			if wf, ok := item.(workflowItem); ok && wf.status != "meta" {
+				wf.modified = m.localDrift[wf.id]
+				items[idx] = wf
 			}
-			return m, cmd
 		}
+		m.workflowList.SetItems(items)
+		return m, nil
 
-		if m.variablePickerOpen {
-			switch msg.String() {
-			case "esc", "backspace", "b":
-				m.variablePickerOpen = false
-				m.secretPickAction = ""
-				m.secretFormVariableKind = ""
-				m.secretFormVariableKey = ""
-				m.appendLog("Update value picker canceled.")
-				return m, nil
-			case "tab", "left", "right":
-				if m.variablePickerFocus == 0 {
-					if len(m.environmentVariableList.Items()) > 0 {
-						m.variablePickerFocus = 1
-					}
-				} else if len(m.systemVariableList.Items()) > 0 {
-					m.variablePickerFocus = 0
+	case syncAllFinishedMsg:
+		m.busy = false
+		if msg.err != nil {
+			m.appendLog("Sync all failed: " + describeFrontendError(msg.err))
+			return m, nil
+		}
+		for _, outcome := range msg.result.Outcomes {
+			if outcome.Err != nil {
+				for _, line := range outcome.Logs {
+					m.appendLog(outcome.Name + ": " + line)
 				}
-				return m, nil
 			}
+		}
+		for _, line := range msg.result.Logs {
+			m.appendLog(line)
+		}
+		return m, localDriftCmd()
 
-			if key.Matches(msg, keys.Run) {
-				if m.busy {
-					return m, nil
-				}
-				var selectedItem list.Item
-				var ok bool
-				if m.variablePickerFocus == 0 {
-					selectedItem = m.systemVariableList.SelectedItem()
-				} else {
-					selectedItem = m.environmentVariableList.SelectedItem()
-				}
-				selected, castOK := selectedItem.(secretPickItem)
-				ok = castOK
-				if !ok {
-					m.appendLog("Select a variable first.")
-					return m, nil
-				}
-				m.variablePickerOpen = false
-				m.secretIDInput.SetValue(selected.id)
-				m.secretValueInput.SetValue(selected.currentValue)
-				m.secretFormError = ""
-				m.secretIDLocked = true
-				m.secretRemoveFromConvex = false
-				m.secretFormVariableKind = selected.kind
-				m.secretFormVariableKey = selected.key
-				m.secretFormOpen = true
-				m.secretFormMode = "update"
-				m.secretFormActiveField = 1
-				m.secretIDInput.Blur()
-				m.secretValueInput.Focus()
-				m.appendLog(fmt.Sprintf("Selected %s for update.", selected.id))
-				return m, nil
-			}
+	case syncLocalFinishedMsg:
+		for _, line := range msg.logs {
+			m.appendLog(line)
+		}
+		if msg.err != nil {
+			m.appendLog("Sync to local failed: " + describeFrontendError(msg.err))
+			m.busy = false
+			return m, nil
+		}
+		m.appendLog("Action \"Sync to local\" completed.")
+		m.busy = false
+		return m, localDriftCmd()
 
-			var cmd tea.Cmd
-			if m.variablePickerFocus == 0 {
-				m.systemVariableList, cmd = m.systemVariableList.Update(msg)
-			} else {
-				m.environmentVariableList, cmd = m.environmentVariableList.Update(msg)
+	case secretsCmdFinishedMsg:
+		for _, line := range msg.logs {
+			m.appendLog(line)
+		}
+		if msg.err != nil {
+			if msg.label == "Update value" || strings.HasPrefix(msg.label, "Secrets ") {
+				m.secretFormError = msg.err.Error()
+				m.secretFormOpen = m.secretFormMode != ""
 			}
-			return m, cmd
+			m.appendLog(msg.label + " failed: " + describeFrontendError(msg.err))
+			m.busy = false
+			return m, nil
+		}
+		if msg.label == "Update value" || strings.HasPrefix(msg.label, "Secrets ") {
+			m.secretFormOpen = false
+			m.secretFormMode = ""
+			m.secretFormVariableKind = ""
+			m.secretFormVariableKey = ""
+			m.secretFormError = ""
+			m.secretIDLocked = false
+			m.secretRemoveFromConvex = false
+			m.secretRemoveDeclaration = false
+			m.secretRemoveArmed = false
+			m.secretIDInput.SetValue("")
+			m.setSecretFormValue("")
+			m.setSecretsRevealed(false)
 		}
+		m.appendLog("Action \"" + msg.label + "\" completed.")
+		m.busy = false
+		// The private key may have changed.
+		return m, m.refreshWallet()
 
-		if m.secretPickOpen {
-			if msg.String() == "esc" || msg.String() == "backspace" || msg.String() == "b" {
-				m.secretPickOpen = false
-				m.secretPickAction = ""
-				m.secretFormVariableKind = ""
-				m.secretFormVariableKey = ""
-				m.appendLog("Secret picker canceled.")
+	case secretsPrefillMsg:
+		for _, line := range msg.logs {
+			m.appendLog(line)
+		}
+		m.busy = false
+		m.secretsPrefillIDs = nil
+		if msg.err != nil {
+			m.appendLog("Secrets prefill failed: " + msg.err.Error())
+			return m, nil
+		}
+		if !msg.applied && len(msg.prefills) > 0 {
+			for _, prefill := range msg.prefills {
+				m.secretsPrefillIDs = append(m.secretsPrefillIDs, prefill.ID)
+			}
+			m.appendLog(fmt.Sprintf("Press Enter on PREFILL FROM ENV again to write these %d value(s) to %s.",
+				len(msg.prefills), core.TargetDotEnvName(m.currentTarget())))
+		}
+		return m, nil
+	case secretsDiffLoadedMsg:
+		for _, line := range msg.logs {
+			m.appendLog(line)
+		}
+		m.busy = false
+		if msg.err != nil {
+			if msg.fix != "" {
+				m.appendLog(fmt.Sprintf("Secrets diff fix failed: %s", describeFrontendError(msg.err)))
 				return m, nil
 			}
+			m.secretsDiffOpen = false
+			m.appendLog("Secrets diff failed: " + describeFrontendError(msg.err))
+			return m, nil
+		}
+		items := make([]list.Item, 0, len(msg.result.Entries))
+		drifted := 0
+		for _, entry := range msg.result.Entries {
+			items = append(items, secretDiffItem{entry: entry, dotEnvName: msg.result.DotEnvName})
+			if !entry.InSync() {
+				drifted++
+			}
+		}
+		if len(items) == 0 {
+			m.secretsDiffOpen = false
+			m.appendLog("No secrets declared locally, in the frontend or in " + msg.result.DotEnvName + ".")
+			return m, nil
+		}
+		selected := m.secretsDiffList.Index()
+		m.secretsDiffList.SetItems(items)
+		if !m.secretsDiffOpen || selected >= len(items) {
+			selected = 0
+		}
+		m.secretsDiffList.Select(selected)
+		m.secretsDiffOpen = true
+		m.secretsDiffArmed = ""
+		if drifted == 0 {
+			m.appendLog("Secrets are in sync across secrets.yaml, the frontend and " + msg.result.DotEnvName + ".")
+		} else if msg.fix == "" {
+			m.appendLog("Pick a secret: Enter fills in the missing side, d deletes the lone copy.")
+		}
+		return m, nil
 
-			if key.Matches(msg, keys.Run) {
-				if m.busy {
-					return m, nil
-				}
-				selected, ok := m.secretPickList.SelectedItem().(secretPickItem)
-				if !ok {
-					return m, nil
-				}
-				if !selected.selectable {
-					m.appendLog("Select a variable row, not a section header.")
-					return m, nil
-				}
-				m.secretPickOpen = false
-				m.secretIDInput.SetValue(selected.id)
-				m.secretValueInput.SetValue(selected.currentValue)
-				m.secretFormError = ""
-				m.secretIDLocked = true
-				m.secretRemoveFromConvex = false
-				m.secretFormVariableKind = selected.kind
-				m.secretFormVariableKey = selected.key
+	case secretOptionsLoadedMsg:
+		for _, line := range msg.logs {
+			m.appendLog(line)
+		}
+		if msg.err != nil {
+			m.appendLog("Unable to list secrets: " + msg.err.Error())
+			m.busy = false
+			return m, nil
+		}
 
-				m.secretFormOpen = true
-				m.secretFormMode = m.secretPickAction
-				if m.secretPickAction == "remove" {
-					m.secretFormActiveField = 0
-					m.secretIDInput.Blur()
-					m.secretValueInput.Blur()
-				} else {
-					m.secretFormActiveField = 1
-					m.secretIDInput.Blur()
-					m.secretValueInput.Focus()
+		items := make([]list.Item, 0, len(msg.options))
+		for _, option := range msg.options {
+			switch msg.actionID {
+			case "add":
+				if option.HasValue {
+					continue
 				}
-				m.appendLog(fmt.Sprintf("Selected %s for %s.", selected.id, m.secretPickAction))
-				return m, nil
 			}
-
-			var cmd tea.Cmd
-			m.secretPickList, cmd = m.secretPickList.Update(msg)
-			return m, cmd
+			status := "missing in .env"
+			if option.HasValue {
+				status = "present in .env"
+			}
+			description := status
+			if strings.TrimSpace(option.EnvVar) != "" {
+				description = fmt.Sprintf("%s (%s)", option.EnvVar, status)
+			}
+			if age := core.SecretAgeLabel(option.RotatedAt); age != "" {
+				description += " · " + age
+			}
+			items = append(items, secretPickItem{
+				id:          option.ID,
+				key:         option.ID,
+				kind:        "secret_env",
+				section:     "environment",
+				description: description,
+				selectable:  true,
+			})
 		}
 
-		if m.secretsMenuOpen {
-			if msg.String() == "esc" || msg.String() == "backspace" || msg.String() == "b" {
-				m.secretsMenuOpen = false
-				m.secretPickOpen = false
-				m.variablePickerOpen = false
-				m.secretPickAction = ""
-				m.secretFormVariableKind = ""
-				m.secretFormVariableKey = ""
-				m.secretsWorkflowID = ""
-				m.secretsWorkflowName = ""
-				m.appendLog("Closed secrets submenu.")
-				return m, nil
+		if len(items) == 0 {
+			switch msg.actionID {
+			case "add":
+				m.appendLog("No missing secrets to add.")
+			case "update":
+				m.appendLog("No secrets available to update.")
+			case "remove":
+				m.appendLog("No configured secrets to remove.")
+			case "rename":
+				m.appendLog("No secrets to rename.")
 			}
+			m.busy = false
+			return m, nil
+		}
 
-			if key.Matches(msg, keys.Run) {
-				if m.busy {
-					return m, nil
-				}
-				selected, ok := m.secretsMenu.SelectedItem().(actionItem)
-				if !ok {
-					return m, nil
-				}
-				if selected.id == "back" {
-					m.secretsMenuOpen = false
-					m.secretPickOpen = false
-					m.variablePickerOpen = false
-					m.secretPickAction = ""
-					m.secretFormVariableKind = ""
-					m.secretFormVariableKey = ""
-					m.secretsWorkflowID = ""
-					m.secretsWorkflowName = ""
-					m.appendLog("Closed secrets submenu.")
-					return m, nil
-				}
-				if selected.id == "add" || selected.id == "update" || selected.id == "remove" {
-					if selected.id == "add" {
-						m.secretFormOpen = true
-						m.secretFormMode = "add"
-						m.secretFormError = ""
-						m.secretIDLocked = false
-						m.secretRemoveFromConvex = false
-						m.secretFormActiveField = 0
-						m.secretIDInput.SetValue("")
-						m.secretValueInput.SetValue("")
-						m.secretIDInput.Focus()
-						m.secretValueInput.Blur()
-						m.appendLog("Secrets add form opened. New key will be added to local secrets.yaml and frontend config.")
-						return m, nil
-					}
-					if selected.id == "update" {
-						m.busy = true
-						m.appendLog("Loading variables for UPDATE VALUE...")
-						return m, variableOptionsCmd(m.secretsWorkflowID, m.secretsWorkflowName, m.currentSecretsTarget())
-					}
-					m.busy = true
-					m.appendLog(fmt.Sprintf("Loading secrets list for %s...", strings.ToUpper(selected.id)))
-					return m, secretOptionsCmd(selected.id, m.secretsWorkflowID, m.secretsWorkflowName, m.currentSecretsTarget())
-				}
-
-				m.busy = true
-				m.appendLog(fmt.Sprintf("Starting %s for %s...", selected.title, m.secretsWorkflowName))
-				return m, secretsCommandCmd(
-					m.webBaseURL,
-					m.token,
-					selected.id,
-					m.secretsWorkflowID,
-					m.secretsWorkflowName,
-					m.currentSecretsTarget(),
-					"",
-					"",
-					"",
-				)
-			}
+		m.secretPickAction = msg.actionID
+		m.secretPickOpen = true
+		m.secretPickList.SetItems(items)
+		m.secretPickList.Select(0)
+		m.busy = false
+		m.appendLog("Pick a secret from the list and press Enter.")
+		return m, nil
 
-			var cmd tea.Cmd
-			m.secretsMenu, cmd = m.secretsMenu.Update(msg)
-			return m, cmd
+	case variableOptionsLoadedMsg:
+		for _, line := range msg.logs {
+			m.appendLog(line)
 		}
-
-		switch {
-		case key.Matches(msg, keys.Pane1):
-			m.focus = focusWorkflows
-			return m, nil
-		case key.Matches(msg, keys.Pane2):
-			m.focus = focusActions
-			return m, nil
-		case key.Matches(msg, keys.Pane3):
-			m.focus = focusConsole
-			return m, nil
-		case key.Matches(msg, keys.Next):
-			m.focus = (m.focus + 1) % 3
+		if msg.err != nil {
+			m.appendLog("Unable to list variables: " + msg.err.Error())
+			m.busy = false
 			return m, nil
 		}
+		systemItems := make([]list.Item, 0)
+		environmentItems := make([]list.Item, 0)
+		for _, option := range msg.options {
+			switch option.Section {
+			case "system":
+				systemItems = append(systemItems, secretPickItem{
+					id:           option.Label,
+					key:          option.Key,
+					kind:         option.Kind,
+					section:      option.Section,
+					currentValue: option.CurrentValue,
+					description:  option.Description,
+					selectable:   true,
+				})
+			case "environment":
+				environmentItems = append(environmentItems, secretPickItem{
+					id:           option.Label,
+					key:          option.Key,
+					kind:         option.Kind,
+					section:      option.Section,
+					currentValue: option.CurrentValue,
+					description:  option.Description,
+					selectable:   true,
+				})
+			}
+		}
+		if len(systemItems) == 0 && len(environmentItems) == 0 {
+			m.appendLog("No variables available to update.")
+			m.busy = false
+			return m, nil
+		}
+		for _, option := range msg.options {
+			if option.Kind != "rpc" {
+				m.rememberSecretValue(option.CurrentValue)
+			}
+		}
+		m.secretPickAction = "update"
+		m.variablePickerOpen = true
+		m.systemVariableList.SetItems(systemItems)
+		m.environmentVariableList.SetItems(environmentItems)
+		m.setSecretsRevealed(false)
+		if len(systemItems) > 0 {
+			m.systemVariableList.Select(0)
+		}
+		if len(environmentItems) > 0 {
+			m.environmentVariableList.Select(0)
+		}
+		if len(systemItems) > 0 {
+			m.variablePickerFocus = 0
+		} else {
+			m.variablePickerFocus = 1
+		}
+		m.busy = false
+		m.appendLog("Update value picker opened. Choose from System (left) or Environment (right).")
+		return m, nil
 
-		if m.focus == focusConsole {
+	case secretCopiedMsg:
+		m.busy = false
+		if msg.err != nil {
+			m.appendLog(fmt.Sprintf("Copy of %s failed: %s", msg.name, msg.err.Error()))
+			return m, nil
+		}
+		m.rememberSecretValue(msg.value)
+		m.copyNoticeID++
+		m.copyNotice = "Copied " + msg.name
+		if delay := core.ClipboardClearDelay(); delay > 0 {
+			m.appendLog(fmt.Sprintf("Copied %s to the clipboard; it is cleared in %s.", msg.name, delay))
+		} else {
+			m.appendLog(fmt.Sprintf("Copied %s to the clipboard.", msg.name))
+		}
+		return m, tea.Batch(clearCopyNoticeCmd(m.copyNoticeID), clearClipboardCmd(msg.value))
+
+	case clipboardClearedMsg:
+		if msg.err != nil {
+			m.appendLog("Could not clear the clipboard: " + msg.err.Error())
+		} else if msg.cleared {
+			m.appendLog("Cleared the copied secret from the clipboard.")
+		}
+		return m, nil
+
+	case copyNoticeClearedMsg:
+		if msg.id == m.copyNoticeID {
+			m.copyNotice = ""
+		}
+		return m, nil
+
+	case tea.KeyMsg:
+		// The paste input must see every key (tokens can contain "q").
+		if m.phase == phaseAuthGate && m.tokenPasteOpen {
 			switch msg.String() {
-			case "up", "k":
-				if m.consoleSelected > 0 {
-					m.consoleSelected--
-				}
-				m.refreshConsoleContent()
-			case "down", "j":
-				if m.consoleSelected < len(m.consoleLines)-1 {
-					m.consoleSelected++
-				}
-				m.refreshConsoleContent()
-			case "g":
-				m.consoleSelected = 0
-				m.refreshConsoleContent()
-			case "G":
-				if len(m.consoleLines) > 0 {
-					m.consoleSelected = len(m.consoleLines) - 1
-				}
-				m.refreshConsoleContent()
-			case "c":
-				if len(m.consoleLines) == 0 {
-					m.appendLog("No logs to copy.")
+			case "ctrl+c":
+				return m, tea.Quit
+			case "esc":
+				m.tokenPasteOpen = false
+				m.tokenPasteError = ""
+				m.tokenPasteInput.Reset()
+				m.tokenPasteInput.Blur()
+				return m, nil
+			case "enter":
+				token, err := core.NormalizeManualToken(m.tokenPasteInput.Value())
+				if err != nil {
+					m.tokenPasteError = err.Error()
 					return m, nil
 				}
-				selected := m.consoleLines[m.consoleSelected]
-				if err := copyToClipboard(selected); err != nil {
-					m.appendLog("Copy failed: " + err.Error())
+				m.tokenPasteOpen = false
+				m.tokenPasteError = ""
+				m.tokenPasteInput.Reset()
+				m.tokenPasteInput.Blur()
+				m.phase = phaseLinking
+				m.busy = true
+				m.appendLog("Validating pasted token...")
+				return m, func() tea.Msg { return loginFinishedMsg{token: token} }
+			}
+			var cmd tea.Cmd
+			m.tokenPasteInput, cmd = m.tokenPasteInput.Update(msg)
+			return m, cmd
+		}
+
+		// Like the paste input, the filter input sees every key.
+		if m.phase == phaseReady && m.filterOpen {
+			switch msg.String() {
+			case "ctrl+c":
+				return m, tea.Quit
+			case "esc":
+				m.filterOpen = false
+				m.filterInput.Blur()
+				return m, nil
+			case "enter":
+				filter, err := core.ParseWorkflowFilter(m.filterInput.Value())
+				if err != nil {
+					m.appendLog("Invalid filter: " + err.Error())
 					return m, nil
 				}
-				m.copyNoticeID++
-				m.copyNotice = "Copied to clipboard"
-				return m, clearCopyNoticeCmd(m.copyNoticeID)
-			case "Y":
-				if len(m.logs) == 0 {
-					m.appendLog("No logs to copy.")
+				m.filterOpen = false
+				m.filterInput.Blur()
+				return m, m.applyWorkflowFilter(filter)
+			}
+			var cmd tea.Cmd
+			m.filterInput, cmd = m.filterInput.Update(msg)
+			return m, cmd
+		}
+
+		// The delete confirmation takes typed input, so it runs before the
+		// global keys.
+		if m.deploymentOp != "" {
+			return m, m.handleDeploymentOpKey(msg)
+		}
+
+		// The secret and keystore passphrase forms take typed input too;
+		// ctrl+c still quits and ctrl+x still cancels a running action.
+		if m.phase == phaseReady && (m.secretFormOpen || m.simulatePassphraseOpen) && msg.String() != "ctrl+c" && !key.Matches(msg, keys.Cancel) {
+			if m.secretFormOpen {
+				return m, m.handleSecretFormKey(msg)
+			}
+			return m, m.handlePassphraseKey(msg)
+		}
+
+		if key.Matches(msg, keys.Quit) {
+			return m, tea.Quit
+		}
+
+		// Cancelling kills the running command or aborts the request; the
+		// action then finishes through its usual failure path.
+		if key.Matches(msg, keys.Cancel) {
+			if !m.busy {
+				m.appendLog("Nothing is running.")
+				return m, nil
+			}
+			core.CancelOperation()
+			m.appendLog("Cancelling the running action...")
+			return m, nil
+		}
+
+		if m.phase == phaseTrustHost {
+			if key.Matches(msg, keys.Profile) {
+				return m, m.switchToNextProfile()
+			}
+			switch strings.ToLower(msg.String()) {
+			case "y":
+				if m.hostTrust == nil {
 					return m, nil
 				}
-				all := strings.Join(m.logs, "\n")
-				if err := copyToClipboard(all); err != nil {
-					m.appendLog("Copy failed: " + err.Error())
+				if err := core.TrustHost(m.webBaseURL, m.hostTrust); err != nil {
+					m.appendLog("Could not save host approval: " + err.Error())
 					return m, nil
 				}
-				m.appendLog("Copied all log lines to clipboard.")
+				m.appendLog("Approved " + m.hostTrust.Origin + ". Checking local authentication session...")
+				m.phase = phaseCheckingAuth
+				return m, initSessionCmd()
+			case "n":
+				return m, tea.Quit
 			}
 			return m, nil
 		}
 
-		if m.focus == focusWorkflows {
-			if key.Matches(msg, keys.Run) {
-				if m.busy {
-					return m, nil
+		if m.phase == phaseAuthGate {
+			if key.Matches(msg, keys.Profile) {
+				return m, m.switchToNextProfile()
+			}
+			switch strings.ToLower(msg.String()) {
+			case "y":
+				m.phase = phaseLinking
+				m.busy = true
+				method := core.PreferredLoginMethod()
+				if method == core.LoginMethodDeviceCode {
+					m.appendLog("Starting device code login flow...")
+				} else {
+					m.appendLog("Starting browser login flow...")
 				}
-				item, ok := m.workflowList.SelectedItem().(workflowItem)
+				m.appendLog("Waiting for browser authentication...")
+				return m, loginCmd(m.webBaseURL, method)
+			case "d":
+				m.phase = phaseLinking
+				m.busy = true
+				m.appendLog("Starting device code login flow...")
+				m.appendLog("Waiting for browser authentication...")
+				return m, loginCmd(m.webBaseURL, core.LoginMethodDeviceCode)
+			case "t":
+				m.tokenPasteOpen = true
+				m.tokenPasteError = ""
+				m.tokenPasteInput.Reset()
+				return m, m.tokenPasteInput.Focus()
+			case "n":
+				return m, tea.Quit
+			default:
+				return m, nil
+			}
+		}
+
+		if m.phase != phaseReady {
+			return m, nil
+		}
+
+		if m.accountPickOpen {
+			switch msg.String() {
+			case "esc":
+				m.accountPickOpen = false
+				return m, nil
+			case "enter":
+				selected, ok := m.accountList.SelectedItem().(actionItem)
 				if !ok {
 					return m, nil
 				}
-				if item.id == workflowSyncListItemID {
-					if strings.TrimSpace(m.token) == "" {
-						m.phase = phaseAuthGate
-						m.authState = authDisconnected
-						m.appendLog("No active session. Please log in first.")
-						return m, nil
-					}
-					m.busy = true
-					m.appendLog("Refreshing workflows from frontend API...")
-					return m, tea.Batch(refreshWorkflowsCmd(m.webBaseURL, m.token), creWhoAmICmd())
-				}
-				if !m.guardCRELoggedIn() {
-					return m, creWhoAmICmd()
+				m.accountPickOpen = false
+				if selected.id == "" {
+					return m, m.startAddAccount()
 				}
-				if strings.TrimSpace(m.token) == "" {
-					m.phase = phaseAuthGate
-					m.authState = authDisconnected
-					m.appendLog("No active session. Please log in first.")
+				if selected.id == m.profile {
 					return m, nil
 				}
-				if item.status != "ready" {
-					m.appendLog("Workflow is not compiled yet. Compile first before syncing.")
+				return m, m.switchToProfile(selected.id)
+			}
+			var cmd tea.Cmd
+			m.accountList, cmd = m.accountList.Update(msg)
+			return m, cmd
+		}
+
+		if m.orgPickOpen {
+			switch msg.String() {
+			case "esc":
+				m.orgPickOpen = false
+				return m, nil
+			case "enter":
+				selected, ok := m.orgList.SelectedItem().(actionItem)
+				m.orgPickOpen = false
+				if !ok || selected.id == m.orgID {
 					return m, nil
 				}
-				m.busy = true
-				m.appendLog(fmt.Sprintf("Starting sync to local for %s...", item.title))
-				return m, syncLocalCmd(m.webBaseURL, m.token, item.id, item.title)
+				return m, m.selectOrganization(selected.id, selected.title)
 			}
+			var cmd tea.Cmd
+			m.orgList, cmd = m.orgList.Update(msg)
+			return m, cmd
+		}
 
+		if m.detailOpen {
+			switch msg.String() {
+			case "esc", "backspace", "b":
+				m.detailOpen = false
+				return m, nil
+			}
 			var cmd tea.Cmd
-			m.workflowList, cmd = m.workflowList.Update(msg)
-			cmds = append(cmds, cmd)
-			return m, tea.Batch(cmds...)
+			m.detailView, cmd = m.detailView.Update(msg)
+			return m, cmd
 		}
 
-		if m.focus == focusActions {
-			if key.Matches(msg, keys.Run) {
-				if m.busy {
-					return m, nil
-				}
-				action := m.selectedAction()
-				if action == nil {
+		if m.versionsOpen {
+			switch msg.String() {
+			case "esc", "backspace", "b":
+				m.versionsOpen = false
+				m.versions = nil
+				return m, nil
+			case "enter":
+				idx := m.versionsList.Index()
+				if m.busy || idx < 0 || idx >= len(m.versions) {
 					return m, nil
 				}
 				if !m.guardCRELoggedIn() {
 					return m, creWhoAmICmd()
 				}
-				if action.id == "secrets" {
-					workflow := m.selectedWorkflow()
-					if workflow == nil {
-						m.appendLog("Select a workflow first.")
-						return m, nil
-					}
-					m.secretsMenuOpen = true
-					m.secretPickOpen = false
-					m.variablePickerOpen = false
-					m.secretPickAction = ""
-					m.secretsWorkflowID = workflow.id
-					m.secretsWorkflowName = workflow.title
-					m.refreshSecretsMenu()
-					m.focus = focusActions
-					m.appendLog(fmt.Sprintf("Opened secrets submenu for %s. Press esc to go back.", workflow.title))
-					return m, nil
-				}
+				version := m.versions[idx]
+				m.versionsOpen = false
+				m.busy = true
+				m.appendLog(fmt.Sprintf("Starting sync to local for %s at bundle version %s (compiler %s, built %s)...",
+					m.versionsName, version.ID, version.CompilerVersion, formatRunTime(version.CreatedAt)))
+				return m, syncPreviewCmd(m.webBaseURL, m.token, m.versionsID, m.versionsName, version.ID)
+			}
+			var cmd tea.Cmd
+			m.versionsList, cmd = m.versionsList.Update(msg)
+			return m, cmd
+		}
 
-				if action.id == "simulate" {
-					workflow := m.selectedWorkflow()
-					if workflow == nil {
-						m.appendLog("Select a workflow first.")
-						return m, nil
-					}
-					m.resetSimulateFlow()
-					m.simulateNeedsEVMFlags = core.IsEvmLogTriggerWorkflow(workflow.id, workflow.title)
-					m.busy = true
-					m.appendLog(fmt.Sprintf("Action %q started for %s.", action.title, workflow.title))
-					return m, preSimulateCmd(workflow.id, workflow.title)
-				}
+		if m.deploymentsOpen {
+			switch msg.String() {
+			case "esc", "backspace", "b":
+				m.deploymentsOpen = false
+				m.deployments = nil
+				return m, nil
+			case "r":
+				m.openDeployments(m.deploymentsID, m.deploymentsName)
+				return m, nil
+			case "p":
+				return m, m.startDeploymentOp(core.DeploymentPause)
+			case "a":
+				return m, m.startDeploymentOp(core.DeploymentActivate)
+			case "x":
+				return m, m.startDeploymentOp(core.DeploymentDelete)
+			case "v":
+				return m, m.startRollback()
+			}
+			var cmd tea.Cmd
+			m.deploymentsList, cmd = m.deploymentsList.Update(msg)
+			return m, cmd
+		}
 
-				workflow := m.selectedWorkflow()
-				if workflow == nil {
-					m.appendLog("Select a workflow first.")
-					return m, nil
+		if m.runsOpen {
+			switch msg.String() {
+			case "esc", "backspace", "b":
+				m.runsOpen = false
+				m.runs = nil
+				return m, nil
+			case "enter":
+				idx := m.runsList.Index()
+				if idx >= 0 && idx < len(m.runs) {
+					m.logRunDetails(m.runs[idx])
 				}
-
-				m.busy = true
-				m.appendLog(fmt.Sprintf("Action %q started for %s.", action.title, workflow.title))
-				return m, preSimulateCmd(workflow.id, workflow.title)
+				return m, nil
 			}
+			var cmd tea.Cmd
+			m.runsList, cmd = m.runsList.Update(msg)
+			return m, cmd
+		}
+
+		if m.localRemoveOpen {
+			switch {
+			case strings.ToLower(msg.String()) == "y":
+				removals := m.localRemovals
+				m.localRemoveOpen = false
+				m.localRemovals = nil
+				m.busy = true
+				m.appendLog(fmt.Sprintf("Deleting %d local project(s)...", len(removals)))
+				return m, removeLocalProjectsCmd(removals)
+			case msg.String() == "esc" || msg.String() == "backspace" || strings.ToLower(msg.String()) == "n":
+				m.localRemoveOpen = false
+				m.localRemovals = nil
+				m.appendLog("Nothing was deleted.")
+			}
+			return m, nil
+		}
+
+		if m.syncPreviewOpen {
+			switch {
+			case msg.String() == "enter" || strings.ToLower(msg.String()) == "y":
+				preview := m.syncPreview
+				if lost := preview.LostEdits(); len(lost) > 0 && !m.syncPreviewArmed {
+					m.syncPreviewArmed = true
+					for _, change := range lost {
+						m.appendLog("! " + change.Line())
+					}
+					m.appendLog("These local modifications will be lost. Press y again to sync anyway.")
+					return m, nil
+				}
+				m.syncPreviewOpen = false
+				m.syncPreview = nil
+				m.syncPreviewArmed = false
+				m.busy = true
+				m.appendLog(fmt.Sprintf("Writing the bundle into the local project of %s...", preview.WorkflowName))
+				return m, syncLocalCmd(preview)
+			case msg.String() == "esc" || msg.String() == "backspace" || strings.ToLower(msg.String()) == "n":
+				m.syncPreviewOpen = false
+				m.syncPreview = nil
+				m.syncPreviewArmed = false
+				m.appendLog("Sync cancelled; the local project was left untouched.")
+			}
+			return m, nil
+		}
+
+		if m.deployConfirmOpen {
+			switch {
+			case strings.ToLower(msg.String()) == "y":
+				if m.deployPreflight == nil || !m.deployPreflight.Passed() {
+					m.appendLog("Deploy blocked: fix the failed preflight checks, then press r to re-run them.")
+					return m, nil
+				}
+				m.deployConfirmOpen = false
+				m.busy = true
+				m.appendLog(fmt.Sprintf("Deploying %s to %s...", m.deployWorkflowName, m.currentTarget()))
+				return m, deployCmd(m.deployWorkflowID, m.deployWorkflowName, m.currentTarget())
+			case key.Matches(msg, keys.Target) || strings.ToLower(msg.String()) == "r":
+				if key.Matches(msg, keys.Target) {
+					m.switchTarget()
+				}
+				m.deployConfirmOpen = false
+				m.deployPreflight = nil
+				m.busy = true
+				m.appendLog(fmt.Sprintf("Running deploy preflight for %s (target %s)...", m.deployWorkflowName, m.currentTarget()))
+				return m, deployPreflightCmd(m.deployWorkflowID, m.deployWorkflowName, m.currentTarget())
+			case msg.String() == "esc" || msg.String() == "backspace" || strings.ToLower(msg.String()) == "n":
+				m.deployConfirmOpen = false
+				m.appendLog("Deploy cancelled.")
+			}
+			return m, nil
+		}
+
+		if m.simulatePayloadOpen {
+			switch {
+			case msg.String() == "esc" || msg.String() == "backspace" || msg.String() == "b":
+				m.resetSimulateFlow()
+				m.appendLog("Simulation cancelled.")
+			case key.Matches(msg, keys.Up):
+				if m.simulatePayloadIndex > 0 {
+					m.simulatePayloadIndex--
+				}
+			case key.Matches(msg, keys.Down):
+				if m.simulatePayloadIndex < len(m.simulatePayloads) {
+					m.simulatePayloadIndex++
+				}
+			case key.Matches(msg, keys.Run):
+				cmdArgs := append([]string(nil), m.simulatePendingArgs...)
+				m.simulatePayloadOpen = false
+				m.busy = true
+				if m.simulatePayloadIndex == 0 {
+					m.appendLog("Running cre simulate without a payload...")
+				} else {
+					payload := m.simulatePayloads[m.simulatePayloadIndex-1]
+					cmdArgs = append(cmdArgs, core.SimulatePayloadArgs(payload)...)
+					m.appendLog("Running cre simulate with HTTP payload " + filepath.Base(payload) + "...")
+				}
+				return m, runPreparedSimulateCmd(m.simulatePendingRoot, cmdArgs, "", m.simulateExtraEnv)
+			}
+			return m, nil
+		}
+
+		if m.simulateFormOpen {
+			switch msg.String() {
+			case "esc", "backspace", "b":
+				m.simulateFormOpen = false
+				m.simulateFormError = ""
+				m.simulateTxHashInput.Blur()
+				m.simulateEventIndexInput.Blur()
+				m.simulateNeedsEVMFlags = false
+				m.simulatePendingRoot = ""
+				m.simulatePendingArgs = nil
+				m.simulateFormActiveField = 0
+				return m, nil
+			}
+
+			if key.Matches(msg, keys.Run) {
+				tx := strings.TrimSpace(m.simulateTxHashInput.Value())
+				if m.simulateFormActiveField == 0 {
+					if tx == "" {
+						m.simulateFormError = "EVM tx hash is required."
+						return m, nil
+					}
+					m.simulateFormActiveField = 1
+					m.simulateFormError = ""
+					m.simulateTxHashInput.Blur()
+					m.simulateEventIndexInput.Focus()
+					return m, nil
+				}
+
+				if tx == "" {
+					m.simulateFormError = "EVM tx hash is required."
+					return m, nil
+				}
+				if !strings.HasPrefix(tx, "0x") {
+					m.simulateFormError = "EVM tx hash must start with 0x."
+					return m, nil
+				}
+				eventIndexRaw := strings.TrimSpace(m.simulateEventIndexInput.Value())
+				eventIndex, err := strconv.Atoi(eventIndexRaw)
+				if err != nil || eventIndex < 0 {
+					m.simulateFormError = "EVM event index must be a non-negative integer."
+					return m, nil
+				}
+
+				cmdArgs := append([]string(nil), m.simulatePendingArgs...)
+				cmdArgs = append(
+					cmdArgs,
+					"--non-interactive",
+					"--trigger-index", "0",
+					"--evm-tx-hash", tx,
+					"--evm-event-index", strconv.Itoa(eventIndex),
+				)
+
+				m.simulateFormOpen = false
+				m.simulateFormError = ""
+				m.simulateTxHashInput.Blur()
+				m.simulateEventIndexInput.Blur()
+				m.simulateFormActiveField = 0
+				m.busy = true
+				m.appendLog(fmt.Sprintf("Running cre simulate with EVM flags (tx=%s, index=%d)...", tx, eventIndex))
+				return m, runPreparedSimulateCmd(m.simulatePendingRoot, cmdArgs, "", m.simulateExtraEnv)
+			}
+
+			switch msg.String() {
+			case "tab":
+				if m.simulateFormActiveField == 0 {
+					m.simulateFormActiveField = 1
+					m.simulateTxHashInput.Blur()
+					m.simulateEventIndexInput.Focus()
+				} else {
+					m.simulateFormActiveField = 0
+					m.simulateEventIndexInput.Blur()
+					m.simulateTxHashInput.Focus()
+				}
+				return m, nil
+			}
+
+			var cmd tea.Cmd
+			if m.simulateFormActiveField == 0 {
+				m.simulateTxHashInput, cmd = m.simulateTxHashInput.Update(msg)
+			} else {
+				m.simulateEventIndexInput, cmd = m.simulateEventIndexInput.Update(msg)
+			}
+			return m, cmd
+		}
+
+		if m.variablePickerOpen {
+			switch msg.String() {
+			case "esc", "backspace", "b":
+				m.variablePickerOpen = false
+				m.secretPickAction = ""
+				m.secretFormVariableKind = ""
+				m.secretFormVariableKey = ""
+				m.setSecretsRevealed(false)
+				m.appendLog("Update value picker canceled.")
+				return m, nil
+			case "ctrl+r":
+				m.setSecretsRevealed(!m.secretsRevealed)
+				return m, nil
+			case "c":
+				if m.busy {
+					return m, nil
+				}
+				var selectedItem list.Item
+				if m.variablePickerFocus == 0 {
+					selectedItem = m.systemVariableList.SelectedItem()
+				} else {
+					selectedItem = m.environmentVariableList.SelectedItem()
+				}
+				selected, ok := selectedItem.(secretPickItem)
+				if !ok || strings.TrimSpace(selected.currentValue) == "" {
+					m.appendLog("The selected variable has no value to copy.")
+					return m, nil
+				}
+				m.busy = true
+				value := selected.currentValue
+				return m, copySecretValueCmd(selected.id, func() (string, error) {
+					return core.ResolveSecretValue(value)
+				})
+			case "tab", "left", "right":
+				if m.variablePickerFocus == 0 {
+					if len(m.environmentVariableList.Items()) > 0 {
+						m.variablePickerFocus = 1
+					}
+				} else if len(m.systemVariableList.Items()) > 0 {
+					m.variablePickerFocus = 0
+				}
+				return m, nil
+			}
+
+			if key.Matches(msg, keys.Run) {
+				if m.busy {
+					return m, nil
+				}
+				var selectedItem list.Item
+				var ok bool
+				if m.variablePickerFocus == 0 {
+					selectedItem = m.systemVariableList.SelectedItem()
+				} else {
+					selectedItem = m.environmentVariableList.SelectedItem()
+				}
+				selected, castOK := selectedItem.(secretPickItem)
+				ok = castOK
+				if !ok {
+					m.appendLog("Select a variable first.")
+					return m, nil
+				}
+				m.variablePickerOpen = false
+				m.secretIDInput.SetValue(selected.id)
+				m.setSecretFormValue(selected.currentValue)
+				m.secretFormError = ""
+				m.secretIDLocked = true
+				m.secretRemoveFromConvex = false
+				m.secretRemoveDeclaration = false
+				m.secretRemoveArmed = false
+				m.secretFormVariableKind = selected.kind
+				m.secretFormVariableKey = selected.key
+				m.secretFormOpen = true
+				m.secretFormMode = "update"
+				m.secretFormActiveField = 1
+				m.secretIDInput.Blur()
+				m.secretValueInput.Focus()
+				m.appendLog(fmt.Sprintf("Selected %s for update.", selected.id))
+				return m, nil
+			}
+
+			var cmd tea.Cmd
+			if m.variablePickerFocus == 0 {
+				m.systemVariableList, cmd = m.systemVariableList.Update(msg)
+			} else {
+				m.environmentVariableList, cmd = m.environmentVariableList.Update(msg)
+			}
+			return m, cmd
+		}
+
+		if m.secretPickOpen {
+			if msg.String() == "esc" || msg.String() == "backspace" || msg.String() == "b" {
+				m.secretPickOpen = false
+				m.secretPickAction = ""
+				m.secretFormVariableKind = ""
+				m.secretFormVariableKey = ""
+				m.appendLog("Secret picker canceled.")
+				return m, nil
+			}
+
+			if msg.String() == "c" {
+				if m.busy {
+					return m, nil
+				}
+				selected, ok := m.secretPickList.SelectedItem().(secretPickItem)
+				if !ok || !selected.selectable {
+					return m, nil
+				}
+				m.busy = true
+				workflowID, workflowName, target := m.secretsWorkflowID, m.secretsWorkflowName, m.currentTarget()
+				return m, copySecretValueCmd(selected.id, func() (string, error) {
+					return core.ReadLocalSecretValue(workflowID, workflowName, target, selected.key)
+				})
+			}
+
+			if key.Matches(msg, keys.Run) {
+				if m.busy {
+					return m, nil
+				}
+				selected, ok := m.secretPickList.SelectedItem().(secretPickItem)
+				if !ok {
+					return m, nil
+				}
+				if !selected.selectable {
+					m.appendLog("Select a variable row, not a section header.")
+					return m, nil
+				}
+				m.secretPickOpen = false
+				m.secretIDInput.SetValue(selected.id)
+				m.setSecretFormValue(selected.currentValue)
+				m.secretFormError = ""
+				m.secretIDLocked = true
+				m.secretRemoveFromConvex = false
+				m.secretRemoveDeclaration = false
+				m.secretRemoveArmed = false
+				m.secretFormVariableKind = selected.kind
+				m.secretFormVariableKey = selected.key
+
+				m.secretFormOpen = true
+				m.secretFormMode = m.secretPickAction
+				if m.secretPickAction == "remove" {
+					m.secretFormActiveField = 0
+					m.secretIDInput.Blur()
+					m.secretValueInput.Blur()
+				} else if m.secretPickAction == "rename" {
+					// The new name is not a secret.
+					m.secretValueInput.EchoMode = textinput.EchoNormal
+					m.secretFormActiveField = 1
+					m.secretIDInput.Blur()
+					m.secretValueInput.Focus()
+				} else {
+					m.secretFormActiveField = 1
+					m.secretIDInput.Blur()
+					m.secretValueInput.Focus()
+				}
+				m.appendLog(fmt.Sprintf("Selected %s for %s.", selected.id, m.secretPickAction))
+				return m, nil
+			}
+
+			var cmd tea.Cmd
+			m.secretPickList, cmd = m.secretPickList.Update(msg)
+			return m, cmd
+		}
+
+		if m.secretsCloneOpen {
+			switch msg.String() {
+			case "esc", "backspace", "b":
+				m.secretsCloneOpen = false
+				m.appendLog("Closed secrets clone.")
+				return m, nil
+			case "v", "V":
+				m.secretsCloneValues = !m.secretsCloneValues
+				if m.secretsCloneValues {
+					m.appendLog("CLONE: values missing here are copied too.")
+				} else {
+					m.appendLog("CLONE: only the declarations are copied.")
+				}
+				return m, nil
+			}
+			if key.Matches(msg, keys.Run) {
+				if m.busy {
+					return m, nil
+				}
+				source, ok := m.secretsCloneList.SelectedItem().(actionItem)
+				if !ok {
+					return m, nil
+				}
+				m.secretsCloneOpen = false
+				m.busy = true
+				m.appendLog(fmt.Sprintf("Cloning secrets from %s into %s...", source.title, m.secretsWorkflowName))
+				return m, secretsCloneCmd(source.id, source.title, m.secretsWorkflowID, m.secretsWorkflowName, m.currentTarget(), m.secretsCloneValues)
+			}
+			var cmd tea.Cmd
+			m.secretsCloneList, cmd = m.secretsCloneList.Update(msg)
+			return m, cmd
+		}
+
+		if m.secretsDiffOpen {
+			if msg.String() == "esc" || msg.String() == "backspace" || msg.String() == "b" {
+				m.secretsDiffOpen = false
+				m.secretsDiffArmed = ""
+				m.appendLog("Closed secrets diff.")
+				return m, nil
+			}
+			if key.Matches(msg, keys.Run) || msg.String() == "d" {
+				if m.busy {
+					return m, nil
+				}
+				selected, ok := m.secretsDiffList.SelectedItem().(secretDiffItem)
+				if !ok {
+					return m, nil
+				}
+				entry := selected.entry
+				fix := secretDiffFix(entry)
+				if msg.String() == "d" {
+					fix = secretDiffDelete(entry)
+				}
+				if fix == "" {
+					if entry.InSync() {
+						m.appendLog(entry.Name + " is in sync.")
+					} else {
+						m.appendLog(fmt.Sprintf("Nothing to delete for %s; REMOVE deletes a secret everywhere.", entry.Name))
+					}
+					return m, nil
+				}
+				if m.readOnly() {
+					m.appendLog("Secrets diff fixes are disabled in a read-only session.")
+					return m, nil
+				}
+				if fix == "add" || fix == "update" {
+					// Both need a value, which goes through the secrets form.
+					m.secretsDiffOpen = false
+					m.secretFormOpen = true
+					m.secretFormMode = fix
+					m.secretFormError = ""
+					m.secretIDLocked = true
+					m.secretRemoveFromConvex = false
+					m.secretRemoveDeclaration = false
+					m.secretRemoveArmed = false
+					m.secretFormVariableKind = "secret_env"
+					m.secretFormVariableKey = entry.ID
+					m.secretIDInput.SetValue(entry.ID)
+					m.setSecretFormValue("")
+					m.setSecretsRevealed(false)
+					m.secretFormActiveField = 1
+					m.secretIDInput.Blur()
+					m.secretValueInput.Focus()
+					m.appendLog(fmt.Sprintf("Enter a value for %s. Run DIFF again afterwards to review.", entry.ID))
+					return m, nil
+				}
+				if fix == "purge" && m.secretsDiffArmed != entry.Name {
+					m.secretsDiffArmed = entry.Name
+					m.appendLog(fmt.Sprintf("Press d again to delete %s from secrets.yaml and %s.", entry.ID, selected.dotEnvName))
+					return m, nil
+				}
+				m.secretsDiffArmed = ""
+				m.busy = true
+				m.appendLog(fmt.Sprintf("%s: %s...", entry.Name, secretDiffActionLabels[fix]))
+				return m, secretsDiffCmd(m.webBaseURL, m.token, m.secretsWorkflowID, m.secretsWorkflowName, m.currentTarget(), fix, entry)
+			}
+
+			m.secretsDiffArmed = ""
+			var cmd tea.Cmd
+			m.secretsDiffList, cmd = m.secretsDiffList.Update(msg)
+			return m, cmd
+		}
+
+		if m.secretsMenuOpen {
+			if msg.String() == "esc" || msg.String() == "backspace" || msg.String() == "b" {
+				m.secretsMenuOpen = false
+				m.secretsPrefillIDs = nil
+				m.secretPickOpen = false
+				m.secretsDiffOpen = false
+				m.secretsCloneOpen = false
+				m.variablePickerOpen = false
+				m.secretPickAction = ""
+				m.secretFormVariableKind = ""
+				m.secretFormVariableKey = ""
+				m.secretsWorkflowID = ""
+				m.secretsWorkflowName = ""
+				m.appendLog("Closed secrets submenu.")
+				return m, nil
+			}
+
+			if key.Matches(msg, keys.Target) {
+				if m.busy {
+					return m, nil
+				}
+				m.switchTarget()
+				m.wallet = nil
+				m.walletErr = ""
+				return m, m.refreshWallet()
+			}
+
+			if key.Matches(msg, keys.Run) {
+				if m.busy {
+					return m, nil
+				}
+				selected, ok := m.secretsMenu.SelectedItem().(actionItem)
+				if !ok {
+					return m, nil
+				}
+				if selected.id == "back" {
+					m.secretsMenuOpen = false
+					m.secretsPrefillIDs = nil
+					m.secretPickOpen = false
+					m.secretsDiffOpen = false
+					m.secretsCloneOpen = false
+					m.variablePickerOpen = false
+					m.secretPickAction = ""
+					m.secretFormVariableKind = ""
+					m.secretFormVariableKey = ""
+					m.secretsWorkflowID = ""
+					m.secretsWorkflowName = ""
+					m.appendLog("Closed secrets submenu.")
+					return m, nil
+				}
+				if selected.id == "prefill" {
+					ids := m.secretsPrefillIDs
+					m.secretsPrefillIDs = nil
+					if m.readOnly() {
+						m.appendLog(fmt.Sprintf("%s is disabled in a read-only session.", selected.title))
+						return m, nil
+					}
+					m.busy = true
+					if len(ids) == 0 {
+						m.appendLog(fmt.Sprintf("Looking for missing secrets of %s in this environment...", m.secretsWorkflowName))
+					} else {
+						m.appendLog(fmt.Sprintf("Writing %d value(s) from the environment...", len(ids)))
+					}
+					return m, secretsPrefillCmd(m.secretsWorkflowID, m.secretsWorkflowName, m.currentTarget(), ids)
+				}
+				// Any other action cancels a pending prefill confirmation.
+				m.secretsPrefillIDs = nil
+				envEncrypted := core.IsWorkflowDotEnvEncrypted(m.secretsWorkflowID, m.secretsWorkflowName, m.currentTarget())
+				if m.readOnly() && selected.id != "read" && selected.id != "reconcile" && selected.id != "diff" && !(selected.id == "envenc" && envEncrypted) {
+					m.appendLog(fmt.Sprintf("%s is disabled in a read-only session.", selected.title))
+					return m, nil
+				}
+				if selected.id == "reconcile" {
+					if m.offline || strings.TrimSpace(m.token) == "" {
+						m.appendLog("RECONCILE needs a frontend session.")
+						return m, nil
+					}
+					m.busy = true
+					m.appendLog(fmt.Sprintf("Comparing local and frontend secrets for %s...", m.secretsWorkflowName))
+					return m, secretsReconcileCmd(m.webBaseURL, m.token, m.secretsWorkflowID, m.secretsWorkflowName, m.currentTarget())
+				}
+				if selected.id == "diff" {
+					if m.offline || strings.TrimSpace(m.token) == "" {
+						m.appendLog("DIFF needs a frontend session.")
+						return m, nil
+					}
+					m.busy = true
+					m.appendLog(fmt.Sprintf("Comparing secrets.yaml, the frontend and %s for %s...", core.TargetDotEnvName(m.currentTarget()), m.secretsWorkflowName))
+					return m, secretsDiffCmd(m.webBaseURL, m.token, m.secretsWorkflowID, m.secretsWorkflowName, m.currentTarget(), "", core.SecretDiffEntry{})
+				}
+				if selected.id == "clone" {
+					workflows, err := core.ListLocalWorkflows()
+					if err != nil {
+						m.appendLog("Cannot list synced workflows: " + err.Error())
+						return m, nil
+					}
+					items := []list.Item{}
+					for _, workflow := range workflows {
+						if workflow.ID != m.secretsWorkflowID {
+							items = append(items, actionItem{id: workflow.ID, title: workflow.Name, description: workflow.ProjectRoot})
+						}
+					}
+					if len(items) == 0 {
+						m.appendLog("No other workflow is synced locally to clone secrets from.")
+						return m, nil
+					}
+					m.secretsCloneList.SetItems(items)
+					m.secretsCloneList.Select(0)
+					m.secretsCloneOpen = true
+					m.secretsCloneValues = false
+					m.appendLog("Pick the workflow to clone secrets from. V toggles copying values as well.")
+					return m, nil
+				}
+				if selected.id == "keystore" {
+					m.secretFormOpen = true
+					m.secretFormMode = "keystore"
+					m.secretFormError = ""
+					m.secretIDLocked = true
+					m.secretRemoveFromConvex = false
+					m.secretRemoveDeclaration = false
+					m.secretRemoveArmed = false
+					m.secretFormActiveField = 1
+					m.secretIDInput.SetValue("CRE_ETH_PRIVATE_KEY")
+					m.setSecretFormValue("")
+					m.setSecretsRevealed(false)
+					m.secretIDInput.Blur()
+					m.secretValueInput.Focus()
+					m.appendLog("Keystore form opened. Choose a passphrase to encrypt CRE_ETH_PRIVATE_KEY.")
+					return m, nil
+				}
+				if selected.id == "envenc" {
+					m.secretFormOpen = true
+					m.secretFormMode = "envenc"
+					if envEncrypted {
+						m.secretFormMode = "envunlock"
+					}
+					m.secretFormError = ""
+					m.secretIDLocked = true
+					m.secretRemoveFromConvex = false
+					m.secretRemoveDeclaration = false
+					m.secretRemoveArmed = false
+					m.secretFormActiveField = 1
+					dotEnvName := core.TargetDotEnvName(m.currentTarget())
+					m.secretIDInput.SetValue(dotEnvName)
+					m.setSecretFormValue("")
+					m.setSecretsRevealed(false)
+					m.secretIDInput.Blur()
+					m.secretValueInput.Focus()
+					if envEncrypted {
+						m.appendLog(fmt.Sprintf("%s is encrypted. Enter its passphrase to unlock it for this session.", dotEnvName))
+					} else {
+						m.appendLog(fmt.Sprintf("Choose a passphrase to encrypt %s into %s.enc.", dotEnvName, dotEnvName))
+					}
+					return m, nil
+				}
+				if selected.id == "add" || selected.id == "update" || selected.id == "remove" || selected.id == "rename" {
+					if selected.id == "add" {
+						m.secretFormOpen = true
+						m.secretFormMode = "add"
+						m.secretFormError = ""
+						m.secretIDLocked = false
+						m.secretRemoveFromConvex = false
+						m.secretRemoveDeclaration = false
+						m.secretRemoveArmed = false
+						m.secretFormActiveField = 0
+						m.secretIDInput.SetValue("")
+						m.setSecretFormValue("")
+						m.secretIDInput.Focus()
+						m.secretValueInput.Blur()
+						m.appendLog("Secrets add form opened. New key will be added to local secrets.yaml and frontend config.")
+						return m, nil
+					}
+					if selected.id == "update" {
+						m.busy = true
+						m.appendLog("Loading variables for UPDATE VALUE...")
+						return m, variableOptionsCmd(m.secretsWorkflowID, m.secretsWorkflowName, m.currentTarget())
+					}
+					m.busy = true
+					m.appendLog(fmt.Sprintf("Loading secrets list for %s...", strings.ToUpper(selected.id)))
+					return m, secretOptionsCmd(selected.id, m.secretsWorkflowID, m.secretsWorkflowName, m.currentTarget())
+				}
+
+				m.busy = true
+				m.appendLog(fmt.Sprintf("Starting %s for %s...", selected.title, m.secretsWorkflowName))
+				return m, secretsCommandCmd(
+					m.webBaseURL,
+					m.token,
+					selected.id,
+					m.secretsWorkflowID,
+					m.secretsWorkflowName,
+					m.currentTarget(),
+					"",
+					"",
+					"",
+				)
+			}
+
+			var cmd tea.Cmd
+			m.secretsMenu, cmd = m.secretsMenu.Update(msg)
+			return m, cmd
+		}
+
+		switch {
+		case key.Matches(msg, keys.Pane1):
+			m.focus = focusWorkflows
+			return m, nil
+		case key.Matches(msg, keys.Pane2):
+			m.focus = focusActions
+			return m, nil
+		case key.Matches(msg, keys.Pane3):
+			m.focus = focusConsole
+			return m, nil
+		case key.Matches(msg, keys.Next):
+			m.focus = (m.focus + 1) % 3
+			return m, nil
+		case key.Matches(msg, keys.Profile):
+			if m.busy {
+				return m, nil
+			}
+			return m, m.switchToNextProfile()
+		case key.Matches(msg, keys.Account):
+			if m.busy {
+				return m, nil
+			}
+			m.openAccountPicker()
+			return m, nil
+		case key.Matches(msg, keys.Filter):
+			if m.busy || m.offline || strings.TrimSpace(m.token) == "" {
+				return m, nil
+			}
+			m.focus = focusWorkflows
+			m.filterOpen = true
+			m.filterInput.SetValue(m.workflowFilter.String())
+			m.filterInput.CursorEnd()
+			return m, m.filterInput.Focus()
+		case key.Matches(msg, keys.Org):
+			if m.busy || m.offline || strings.TrimSpace(m.token) == "" {
+				return m, nil
+			}
+			m.busy = true
+			m.appendLog("Fetching organizations...")
+			return m, organizationsCmd(m.webBaseURL, m.token, true)
+		case key.Matches(msg, keys.CRE):
+			if m.busy {
+				return m, nil
+			}
+			m.appendLog("Running `cre auth login`; the TUI resumes when it exits.")
+			return m, creLoginCmd()
+		case key.Matches(msg, keys.Install):
+			if m.busy {
+				return m, nil
+			}
+			if !m.creMissing {
+				m.appendLog("CRE CLI is already installed: " + core.CREBinary())
+				return m, nil
+			}
+			m.busy = true
+			return m, installCRECmd()
+		case key.Matches(msg, keys.Target):
+			if m.busy {
+				return m, nil
+			}
+			m.switchTarget()
+			return m, nil
+		case key.Matches(msg, keys.DryRun):
+			if m.busy {
+				return m, nil
+			}
+			core.SetDryRun(!core.DryRun())
+			if core.DryRun() {
+				m.appendLog("Dry run on: simulate and secrets actions only log the commands, file writes and frontend updates they would make.")
+			} else {
+				m.appendLog("Dry run off.")
+			}
+			return m, nil
+		case key.Matches(msg, keys.Reauth):
+			return m, m.startReauth()
+		case key.Matches(msg, keys.Logout):
+			if m.busy {
+				return m, nil
+			}
+			return m, m.logout()
+		}
+
+		if m.focus == focusConsole {
+			switch msg.String() {
+			case "up", "k":
+				if m.consoleSelected > 0 {
+					m.consoleSelected--
+				}
+				m.refreshConsoleContent()
+			case "down", "j":
+				if m.consoleSelected < len(m.consoleLines)-1 {
+					m.consoleSelected++
+				}
+				m.refreshConsoleContent()
+			case "g":
+				m.consoleSelected = 0
+				m.refreshConsoleContent()
+			case "G":
+				if len(m.consoleLines) > 0 {
+					m.consoleSelected = len(m.consoleLines) - 1
+				}
+				m.refreshConsoleContent()
+			case "c":
+				if len(m.consoleLines) == 0 {
+					m.appendLog("No logs to copy.")
+					return m, nil
+				}
+				selected := m.consoleLines[m.consoleSelected]
+				if err := copyToClipboard(selected); err != nil {
+					m.appendLog("Copy failed: " + err.Error())
+					return m, nil
+				}
+				m.copyNoticeID++
+				m.copyNotice = "Copied to clipboard"
+				return m, clearCopyNoticeCmd(m.copyNoticeID)
+			case "Y":
+				if len(m.logs) == 0 {
+					m.appendLog("No logs to copy.")
+					return m, nil
+				}
+				all := strings.Join(m.logs, "\n")
+				if err := copyToClipboard(all); err != nil {
+					m.appendLog("Copy failed: " + err.Error())
+					return m, nil
+				}
+				m.appendLog("Copied all log lines to clipboard.")
+			}
+			return m, nil
+		}
+
+		if m.focus == focusWorkflows {
+			if key.Matches(msg, keys.Run) {
+				if m.busy {
+					return m, nil
+				}
+				item, ok := m.workflowList.SelectedItem().(workflowItem)
+				if !ok {
+					return m, nil
+				}
+				if m.offline && (item.id != workflowSyncListItemID || m.expiredSession != nil) {
+					m.appendLog("Offline: syncing from the frontend is unavailable. Use the actions pane for local simulate/secrets.")
+					return m, nil
+				}
+				if item.id == workflowLoadMoreItemID {
+					m.busy = true
+					m.appendLog("Loading more workflows...")
+					return m, loadMoreWorkflowsCmd(m.webBaseURL, m.token, m.workflowsCursor, m.workflowFilter)
+				}
+				if item.id == workflowSyncAllItemID {
+					if strings.TrimSpace(m.token) == "" {
+						m.phase = phaseAuthGate
+						m.authState = authDisconnected
+						m.appendLog("No active session. Please log in first.")
+						return m, nil
+					}
+					if !m.guardCRELoggedIn() {
+						return m, creWhoAmICmd()
+					}
+					m.busy = true
+					m.appendLog("Syncing every ready workflow to local...")
+					return m, syncAllCmd(m.webBaseURL, m.token)
+				}
+				if item.id == workflowSyncListItemID {
+					if strings.TrimSpace(m.token) == "" {
+						m.phase = phaseAuthGate
+						m.authState = authDisconnected
+						m.appendLog("No active session. Please log in first.")
+						return m, nil
+					}
+					m.busy = true
+					m.appendLog("Refreshing workflows from frontend API...")
+					return m, tea.Batch(refreshWorkflowsCmd(m.webBaseURL, m.token, m.workflowFilter), creWhoAmICmd())
+				}
+				if !m.guardCRELoggedIn() {
+					return m, creWhoAmICmd()
+				}
+				if strings.TrimSpace(m.token) == "" {
+					m.phase = phaseAuthGate
+					m.authState = authDisconnected
+					m.appendLog("No active session. Please log in first.")
+					return m, nil
+				}
+				if item.status != "ready" {
+					return m, m.promptCompile(item)
+				}
+				m.busy = true
+				m.appendLog(fmt.Sprintf("Starting sync to local for %s...", item.title))
+				return m, syncPreviewCmd(m.webBaseURL, m.token, item.id, item.title, "")
+			}
+
+			var cmd tea.Cmd
+			m.workflowList, cmd = m.workflowList.Update(msg)
+			cmds = append(cmds, cmd)
+			return m, tea.Batch(cmds...)
+		}
+
+		if m.focus == focusActions {
+			if key.Matches(msg, keys.Run) {
+				return m, m.runSelectedAction()
+			}
+
+			var cmd tea.Cmd
+			m.actionList, cmd = m.actionList.Update(msg)
+			cmds = append(cmds, cmd)
+			return m, tea.Batch(cmds...)
+		}
+	}
+
+	return m, tea.Batch(cmds...)
+}
+
+// applyStartupDeepLink consumes --workflow/--action once both the workflow list
+// and the CRE identity check have completed.
+func (m *model) applyStartupDeepLink() tea.Cmd {
+	if !m.startupLinkReady || !m.workflowsLoaded || !m.creChecked || m.phase != phaseReady {
+		return nil
+	}
+	m.startupLinkReady = false
+
+	ref := m.startupWorkflow
+	found := -1
+	for idx, item := range m.workflowList.Items() {
+		wf, ok := item.(workflowItem)
+		if !ok || wf.status == "meta" {
+			continue
+		}
+		if wf.id == ref {
+			found = idx
+			break
+		}
+		if found < 0 && strings.EqualFold(wf.title, ref) {
+			found = idx
+		}
+	}
+	if found < 0 {
+		m.appendLog(fmt.Sprintf("--workflow %q not found in workflow list.", ref))
+		return nil
+	}
+	m.workflowList.Select(found)
+	m.focus = focusActions
+	if m.startupAction == "" {
+		m.appendLog("Selected workflow from --workflow flag.")
+		return nil
+	}
+	for idx, item := range m.actionList.Items() {
+		if action, ok := item.(actionItem); ok && action.id == m.startupAction {
+			m.actionList.Select(idx)
+			break
+		}
+	}
+	return m.runSelectedAction()
+}
+
+func (m *model) runSelectedAction() tea.Cmd {
+	if m.busy {
+		return nil
+	}
+	action := m.selectedAction()
+	if action == nil {
+		return nil
+	}
+	if action.id == "details" {
+		workflow := m.selectedWorkflow()
+		if workflow == nil {
+			m.appendLog("Select a workflow first.")
+			return nil
+		}
+		if m.offline {
+			m.appendLog("Workflow details need the frontend; they are unavailable offline.")
+			return nil
+		}
+		m.busy = true
+		m.detailName = workflow.title
+		m.appendLog(fmt.Sprintf("Fetching details for %s...", workflow.title))
+		return workflowDetailCmd(m.webBaseURL, m.token, workflow.id)
+	}
+	if action.id == "runs" {
+		// Run history comes from the frontend and does not need the CRE CLI.
+		workflow := m.selectedWorkflow()
+		if workflow == nil {
+			m.appendLog("Select a workflow first.")
+			return nil
+		}
+		if m.offline {
+			m.appendLog("Run history needs the frontend; it is unavailable offline.")
+			return nil
+		}
+		m.busy = true
+		m.runsWorkflowName = workflow.title
+		m.appendLog(fmt.Sprintf("Fetching run history for %s...", workflow.title))
+		return workflowRunsCmd(m.webBaseURL, m.token, workflow.id)
+	}
+	if action.id == "versions" {
+		workflow := m.selectedWorkflow()
+		if workflow == nil {
+			m.appendLog("Select a workflow first.")
+			return nil
+		}
+		if m.offline {
+			m.appendLog("Bundle versions need the frontend; they are unavailable offline.")
+			return nil
+		}
+		m.busy = true
+		m.versionsID = workflow.id
+		m.versionsName = workflow.title
+		m.appendLog(fmt.Sprintf("Fetching bundle versions for %s...", workflow.title))
+		return bundleVersionsCmd(m.webBaseURL, m.token, workflow.id)
+	}
+	if action.id == "config" {
+		workflow := m.selectedWorkflow()
+		if workflow == nil {
+			m.appendLog("Select a workflow first.")
+			return nil
+		}
+		if m.offline {
+			m.appendLog("Pushing config needs the frontend; it is unavailable offline.")
+			return nil
+		}
+		// The first run shows the diff; running it again on the same
+		// workflow uploads.
+		apply := m.configPushID == workflow.id
+		m.configPushID = ""
+		if apply && m.readOnly() {
+			m.appendLog("Pushing config is disabled in a read-only session.")
+			return nil
+		}
+		m.busy = true
+		if apply {
+			m.appendLog(fmt.Sprintf("Uploading local config of %s...", workflow.title))
+		} else {
+			m.appendLog(fmt.Sprintf("Comparing local config of %s with the frontend...", workflow.title))
+		}
+		return configPushCmd(m.webBaseURL, m.token, workflow.id, workflow.title, apply)
+	}
+	if action.id == "remove-local" {
+		workflow := m.selectedWorkflow()
+		if workflow == nil {
+			m.appendLog("Select a workflow first.")
+			return nil
+		}
+		removal, err := core.PlanLocalProjectRemoval(workflow.id, workflow.title)
+		if err != nil {
+			m.appendLog(err.Error())
+			return nil
+		}
+		m.openLocalRemove("Delete local project", []core.LocalProjectRemoval{*removal})
+		return nil
+	}
+	if action.id == "prune" {
+		if m.offline {
+			m.appendLog("Finding orphaned projects needs the frontend workflow list; it is unavailable offline.")
+			return nil
+		}
+		m.busy = true
+		m.appendLog("Comparing local projects with the frontend workflow list...")
+		return orphanedProjectsCmd(m.webBaseURL, m.token)
+	}
+	if !m.guardCRELoggedIn() {
+		return creWhoAmICmd()
+	}
+	if time.Since(m.creCheckedAt) > creRecheckAfter {
+		// The CLI session may have lapsed since the last whoami; confirm it
+		// before cre fails with a less helpful error.
+		m.busy = true
+		return creSessionCheckCmd(true)
+	}
+	if action.id == "secrets" {
+		workflow := m.selectedWorkflow()
+		if workflow == nil {
+			m.appendLog("Select a workflow first.")
+			return nil
+		}
+		m.secretsMenuOpen = true
+		m.secretPickOpen = false
+		m.secretsDiffOpen = false
+		m.secretsCloneOpen = false
+		m.variablePickerOpen = false
+		m.secretPickAction = ""
+		m.secretsWorkflowID = workflow.id
+		m.secretsWorkflowName = workflow.title
+		m.loadTargets(workflow.id, workflow.title)
+		m.refreshSecretsMenu()
+		m.focus = focusActions
+		m.wallet = nil
+		m.walletErr = ""
+		m.secretsBackend = core.SecretsBackendLabel()
+		m.appendLog(fmt.Sprintf("Opened secrets submenu for %s (target %s, T switches). Press esc to go back.", workflow.title, m.currentTarget()))
+		if m.secretsBackend != "" {
+			m.appendLog(fmt.Sprintf("Secret values are stored in %s; .env keeps references.", m.secretsBackend))
+		}
+		return m.refreshWallet()
+	}
+
+	if action.id == "simulate" {
+		workflow := m.selectedWorkflow()
+		if workflow == nil {
+			m.appendLog("Select a workflow first.")
+			return nil
+		}
+		m.resetSimulateFlow()
+		m.simulateWorkflowID = workflow.id
+		m.simulateWorkflowName = workflow.title
+		m.simulateNeedsEVMFlags = core.IsEvmLogTriggerWorkflow(workflow.id, workflow.title)
+		m.simulateNeedsPayload = !m.simulateNeedsEVMFlags && core.IsHTTPTriggerWorkflow(workflow.id, workflow.title)
+		m.loadTargets(workflow.id, workflow.title)
+		m.busy = true
+		m.appendLog(fmt.Sprintf("Action %q started for %s (target %s).", action.title, workflow.title, m.currentTarget()))
+		return preSimulateCmd(workflow.id, workflow.title, m.currentTarget())
+	}
+
+	if action.id == "deployments" {
+		workflow := m.selectedWorkflow()
+		if workflow == nil {
+			m.appendLog("Select a workflow first.")
+			return nil
+		}
+		m.openDeployments(workflow.id, workflow.title)
+		return nil
+	}
+
+	if action.id == "deploy" {
+		workflow := m.selectedWorkflow()
+		if workflow == nil {
+			m.appendLog("Select a workflow first.")
+			return nil
+		}
+		if m.readOnly() {
+			m.appendLog("Deploying is disabled in a read-only session.")
+			return nil
+		}
+		m.deployWorkflowID = workflow.id
+		m.deployWorkflowName = workflow.title
+		m.loadTargets(workflow.id, workflow.title)
+		m.deployPreflight = nil
+		m.busy = true
+		m.appendLog(fmt.Sprintf("Running deploy preflight for %s (target %s)...", workflow.title, m.currentTarget()))
+		return deployPreflightCmd(workflow.id, workflow.title, m.currentTarget())
+	}
+
+	m.appendLog(fmt.Sprintf("Action %q is not available.", action.title))
+	return nil
+}
+
+func paneStyle(focused bool) lipgloss.Style {
+	border := lipgloss.Color("8")
+	if focused {
+		border = lipgloss.Color("14")
+	}
+	return lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(border)
+}
+
+func (m model) headerView() string {
+	state := string(m.authState)
+	if m.offline {
+		state += "(offline)"
+	}
+	if m.readOnly() {
+		state += "(read-only)"
+	}
+	if m.incompatible != "" {
+		state += "(upgrade required)"
+	}
+	if m.busy {
+		state += " • busy"
+	}
+	if core.DryRun() {
+		state += " • DRY RUN"
+	}
+	creState := "login-required"
+	if m.creLoggedIn {
+		creState = "connected:" + m.creIdentity
+	} else if m.creExpired {
+		creState = "expired(press C)"
+	}
+	if m.identityMismatch != "" {
+		creState += "(MISMATCH)"
+	}
+	sessionState := "n/a"
+	if remaining, ok := m.sessionRemaining(); ok {
+		sessionState = formatRemaining(remaining)
+	}
+	switch {
+	case m.reauthing:
+		sessionState += "(re-authenticating)"
+	case m.reauthPrompt:
+		sessionState += "(press R to re-auth)"
+	}
+	accountState := m.account
+	if accountState == "" {
+		accountState = "-"
+	}
+	if m.orgName != "" {
+		accountState += "@" + m.orgName
+	}
+	if m.addingAccount {
+		accountState += "(adding account)"
+	}
+	head := lipgloss.NewStyle().Bold(true).Render("六 6FLOW")
+	subText := fmt.Sprintf(
+		"user=%s  auth=%s  account=%s  profile=%s  session=%s  cre=%s  target=%s  workflows=%d",
+		m.user,
+		state,
+		accountState,
+		m.profile,
+		sessionState,
+		creState,
+		m.currentTarget(),
+		m.workflowCount,
+	)
+	wrapWidth := m.width - 2
+	if wrapWidth < 40 {
+		wrapWidth = 40
+	}
+	subLines := wrapLine(subText, wrapWidth)
+	sub := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(strings.Join(subLines, "\n"))
+	return lipgloss.JoinVertical(lipgloss.Left, head, sub)
+}
+
+func (m model) authView() string {
+	panel := paneStyle(true).Padding(1, 2)
+	lines := []string{"Authentication"}
+	if m.phase == phaseCheckingAuth || m.phase == phaseLinking {
+		lines = append(lines, fmt.Sprintf("%s %s", m.spinner.View(), "Checking/processing authentication..."))
+	}
+	if m.phase == phaseLinking && m.loginPrompt != nil && (!m.loginPrompt.BrowserOpened || m.loginPrompt.CallbackPort == 0) {
+		lines = append(lines, "")
+		lines = append(lines, "Open on any machine: "+m.loginPrompt.URL)
+		codeLabel := "Pairing code: "
+		if m.loginPrompt.CallbackPort == 0 {
+			codeLabel = "Enter code: "
+		}
+		lines = append(lines, codeLabel+lipgloss.NewStyle().Bold(true).Render(m.loginPrompt.PairingCode))
+		lines = append(lines, fmt.Sprintf("Link expires at %s.", m.loginPrompt.ExpiresAt.Local().Format("15:04:05")))
+	}
+	if m.phase == phaseTrustHost {
+		lines = append(lines, m.hostTrustLines()...)
+	}
+	if m.phase == phaseAuthGate {
+		lines = append(lines, fmt.Sprintf("Log in now? (profile %s, %s)", m.profile, m.webBaseURL))
+		lines = append(lines, "Press Y to start login flow, D for device code login, T to paste a token, P to switch profile, or N to quit.")
+		if m.tokenPasteOpen {
+			lines = append(lines, "", "Paste the token from the web UI. Enter saves it. Esc cancels.", m.tokenPasteInput.View())
+			if strings.TrimSpace(m.tokenPasteError) != "" {
+				lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(m.tokenPasteError))
+			}
+		}
+	}
+	lines = append(lines, "")
+	start := len(m.logs) - 10
+	if start < 0 {
+		start = 0
+	}
+	lines = append(lines, m.logs[start:]...)
+
+	return panel.Width(max(50, m.width-2)).Render(strings.Join(lines, "\n"))
+}
+
+func (m model) hostTrustLines() []string {
+	warn := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+	lines := []string{"", warn.Render("Unrecognized frontend host: " + m.webBaseURL)}
+	info := m.hostTrust
+	if info == nil {
+		return append(lines, fmt.Sprintf("%s %s", m.spinner.View(), "Inspecting host..."))
+	}
+	switch {
+	case !info.Secure:
+		lines = append(lines, warn.Render("Plain HTTP: your token would be sent unencrypted."))
+	case info.TLSError != "":
+		lines = append(lines, warn.Render("TLS handshake failed: "+info.TLSError))
+	default:
+		lines = append(lines,
+			"TLS:         "+info.TLSVersion,
+			"Certificate: "+info.Subject,
+			"Issuer:      "+info.Issuer,
+			"Expires:     "+info.NotAfter.Local().Format("2006-01-02"),
+			"SHA-256:     "+info.Fingerprint,
+		)
+	}
+	return append(lines, "", "No token is sent until you approve. Press Y to trust this host, P to switch profile, or N to quit.")
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// isPassphraseForm reports whether the secret form asks for a passphrase,
+// which is taken verbatim rather than trimmed.
+func isPassphraseForm(mode string) bool {
+	return mode == "keystore" || mode == "envenc" || mode == "envunlock"
+}
+
+// renderSecretValueField is the value input, or a summary of a pasted
+// multi-line value that shows its lines only while values are revealed.
+func (m model) renderSecretValueField() string {
+	if m.secretValueBlock == "" {
+		return m.secretValueInput.View()
+	}
+	summary := fmt.Sprintf("%s[%d lines, %d bytes; Backspace clears]", m.secretValueInput.Prompt,
+		strings.Count(m.secretValueBlock, "\n")+1, len(m.secretValueBlock))
+	if !m.secretsRevealed {
+		return summary
+	}
+	return summary + "\n" + lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(m.secretValueBlock)
+}
+
+func (m model) renderSecretFormPrompt() string {
+	modeTitle := strings.ToUpper(m.secretFormMode)
+	switch m.secretFormMode {
+	case "envenc":
+		modeTitle = "ENCRYPT .ENV"
+	case "envunlock":
+		modeTitle = "UNLOCK .ENV"
+	}
+	title := lipgloss.NewStyle().Bold(true).Render("Secrets " + modeTitle)
+	noticeText := "Local simulation mode: updates only secrets.yaml and workflow .env."
+	if m.secretFormMode == "update" {
+		noticeText = "Update selected variable in local .env or project.yaml."
+	}
+	if m.secretsBackend != "" && (m.secretFormMode == "add" || m.secretFormVariableKind == "secret_env") {
+		noticeText = fmt.Sprintf("Stores the value in %s and a reference to it in workflow .env.", m.secretsBackend)
+	}
+	if m.secretFormMode == "keystore" {
+		noticeText = "Encrypts CRE_ETH_PRIVATE_KEY into .keystore.json and removes the plaintext value from .env."
+	}
+	if m.secretFormMode == "envenc" {
+		noticeText = fmt.Sprintf("Encrypts %s into %s.enc and removes the plaintext file. Runs decrypt it in memory only.", m.secretIDInput.Value(), m.secretIDInput.Value())
+	}
+	if m.secretFormMode == "envunlock" {
+		noticeText = fmt.Sprintf("%s is kept as %s.enc. The passphrase is held in memory until the TUI exits.", m.secretIDInput.Value(), m.secretIDInput.Value())
+	}
+	if m.secretFormMode == "rename" {
+		noticeText = "Renames the secret in secrets.yaml, moves its .env value and renames it in the frontend config."
+	}
+	notice := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render(noticeText)
+	target := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(
+		fmt.Sprintf("workflow: %s | target: %s", m.secretsWorkflowName, m.currentTarget()),
+	)
+	hints := "Enter submits. Esc cancels."
+	if m.secretFormMode != "remove" && !m.secretIDLocked {
+		hints = "Tab to switch fields. Enter on value submits. Esc cancels."
+	}
+	if m.secretIDLocked {
+		hints = "Secret ID is selected from list. Enter submits. Esc cancels."
+	}
+	if m.secretFormMode == "update" {
+		hints = "Variable is selected from list. Enter submits. Esc cancels."
+	}
+	if m.secretFormMode == "remove" {
+		hints = "Enter clears local value. Press T to toggle removing from frontend config, D to toggle deleting the declaration. Esc cancels."
+	}
+	if m.secretFormMode == "keystore" || m.secretFormMode == "envenc" {
+		hints = "Passphrase must be at least 8 characters. Enter encrypts. Esc cancels."
+	}
+	if m.secretFormMode == "envunlock" {
+		hints = "Enter unlocks. Esc cancels."
+	}
+	if m.secretFormMode == "rename" {
+		hints = "Type the new secret ID. Enter renames. Esc cancels."
+	}
+	if m.secretFormMode != "remove" && m.secretFormMode != "rename" {
+		if m.secretsRevealed {
+			hints += " Ctrl+R hides the value."
+		} else {
+			hints += " Ctrl+R reveals the value."
+		}
+	}
+	if m.secretFormCanGenerate() {
+		hints += " Ctrl+G generates a random value."
+	}
+	if m.secretFormTakesBlock() {
+		hints += " Paste multi-line values as they are, or type @path to use a file's contents."
+	}
+	hintsView := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(hints)
+
+	secretIDLabel := "Secret ID"
+	secretValueLabel := "Secret value"
+	if m.secretFormMode == "update" {
+		secretIDLabel = "Variable"
+		secretValueLabel = "Value"
+	}
+	if m.secretFormMode == "keystore" {
+		secretIDLabel = "Key"
+		secretValueLabel = "Passphrase"
+	}
+	if m.secretFormMode == "envenc" || m.secretFormMode == "envunlock" {
+		secretIDLabel = "File"
+		secretValueLabel = "Passphrase"
+	}
+	if m.secretFormMode == "rename" {
+		secretValueLabel = "New secret ID"
+	}
+	if m.secretFormMode != "remove" && !m.secretIDLocked {
+		if m.secretFormActiveField == 0 {
+			secretIDLabel = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Render(secretIDLabel)
+		} else {
+			secretValueLabel = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Render(secretValueLabel)
+		}
+	} else {
+		secretIDLabel = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Render(secretIDLabel)
+	}
+
+	lines := []string{
+		title,
+		notice,
+		target,
+		"",
+		secretIDLabel,
+	}
+	if m.secretIDLocked {
+		lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Render(m.secretIDInput.Value()))
+	} else {
+		lines = append(lines, m.secretIDInput.View())
+	}
+	if m.secretFormMode != "remove" {
+		lines = append(lines, "", secretValueLabel, m.renderSecretValueField())
+	} else {
+		removeMode := "OFF (default: clear local value only)"
+		if m.secretRemoveFromConvex {
+			removeMode = "ON (also remove from web)"
+		}
+		declarationMode := "OFF (keep the declaration in secrets.yaml)"
+		if m.secretRemoveDeclaration {
+			declarationMode = "ON (delete from secrets.yaml and .env)"
+		}
+		lines = append(lines, "", "Remove from web", removeMode, "", "Delete declaration", declarationMode)
+		if m.secretRemoveArmed {
+			lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true).Render(
+				fmt.Sprintf("Delete %s for good? Press Enter again to confirm.", m.secretIDInput.Value())))
+		}
+	}
+	lines = append(lines, hintsView)
+
+	if strings.TrimSpace(m.secretFormError) != "" {
+		lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(m.secretFormError))
+	}
+
+	panel := paneStyle(true).Padding(1, 2).Width(max(70, m.width-2))
+	return panel.Render(strings.Join(lines, "\n"))
+}
+
+func (m model) renderVariablePickerPrompt() string {
+	title := lipgloss.NewStyle().Bold(true).Render("Update Value")
+	subtitle := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(
+		"Select from System Variables (left) or Environment Variables (right). Tab/Left/Right to switch panel, Enter to edit, Ctrl+R to reveal values, C to copy a value, Esc to close.",
+	)
+
+	systemList := m.systemVariableList
+	environmentList := m.environmentVariableList
+	panelWidth := max(90, m.width-2)
+	listWidth := (panelWidth - 12) / 2
+	listHeight := max(10, m.height/3)
+	systemList.SetSize(listWidth, max(6, listHeight-2))
+	environmentList.SetSize(listWidth, max(6, listHeight-2))
+	systemList.Title = ""
+	environmentList.Title = ""
+
+	activeHeader := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("14")).
+		Underline(true)
+	inactiveHeader := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(lipgloss.Color("8"))
+	leftHeader := inactiveHeader.Render("System Variables")
+	rightHeader := inactiveHeader.Render("Environment Variables")
+	if m.variablePickerFocus == 0 {
+		leftHeader = activeHeader.Render("System Variables")
+	} else {
+		rightHeader = activeHeader.Render("Environment Variables")
+	}
+
+	leftBody := lipgloss.JoinVertical(lipgloss.Left, leftHeader, systemList.View())
+	rightBody := lipgloss.JoinVertical(lipgloss.Left, rightHeader, environmentList.View())
+	leftStyle := lipgloss.NewStyle().Width(listWidth+2).Padding(0, 1)
+	rightStyle := lipgloss.NewStyle().Width(listWidth+2).Padding(0, 1)
+	lists := lipgloss.JoinHorizontal(
+		lipgloss.Top,
+		leftStyle.Render(leftBody),
+		"  ",
+		rightStyle.Render(rightBody),
+	)
+
+	panel := paneStyle(true).Padding(1, 2).Width(panelWidth)
+	return panel.Render(lipgloss.JoinVertical(lipgloss.Left, title, subtitle, m.walletLine(), "", lists))
+}
+
+func (m model) renderSimulatePassphrasePrompt() string {
+	title := lipgloss.NewStyle().Bold(true).Render("Unlock Keystore")
+	notice := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render(
+		fmt.Sprintf("CRE_ETH_PRIVATE_KEY for %s is encrypted. It is decrypted in memory for this run only.", m.simulateWorkflowName),
+	)
+	hints := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("Enter unlocks and runs simulation. Esc cancels.")
+	lines := []string{title, notice, hints, "", m.simulatePassphraseInput.View()}
+	if strings.TrimSpace(m.simulatePassphraseError) != "" {
+		lines = append(lines, "", lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(m.simulatePassphraseError))
+	}
+	panel := paneStyle(true).Padding(1, 2).Width(max(90, m.width-2))
+	return panel.Render(strings.Join(lines, "\n"))
+}
+
+func (m model) renderSimulateFormPrompt() string {
+	title := lipgloss.NewStyle().Bold(true).Render("Simulation Input (EVM)")
+	notice := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("Provide tx hash and event index for non-interactive simulate.")
+	target := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("Enter on tx hash moves to index. Enter on index runs. Tab switches field. Esc cancels.")
+	txLabel := "EVM tx hash"
+	indexLabel := "EVM event index"
+	if m.simulateFormActiveField == 0 {
+		txLabel = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Render(txLabel)
+	} else {
+		indexLabel = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Render(indexLabel)
+	}
+	lines := []string{
+		title,
+		notice,
+		target,
+		"",
+		txLabel,
+		m.simulateTxHashInput.View(),
+		"",
+		indexLabel,
+		m.simulateEventIndexInput.View(),
+	}
+	if strings.TrimSpace(m.simulateFormError) != "" {
+		lines = append(lines, "", lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(m.simulateFormError))
+	}
+	panel := paneStyle(true).Padding(1, 2).Width(max(90, m.width-2))
+	return panel.Render(strings.Join(lines, "\n"))
+}
+
+func (m model) renderSimulatePayloadPrompt() string {
+	title := lipgloss.NewStyle().Bold(true).Render("Simulation Input (HTTP trigger)")
+	notice := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("Pick the JSON payload the HTTP trigger receives.")
+	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("JSON files in payloads/ or fixtures/ of the workflow are listed. ↑/↓ select, enter runs, esc cancels.")
+	options := []string{"Run without a payload"}
+	for _, payload := range m.simulatePayloads {
+		rel, err := filepath.Rel(m.simulatePendingRoot, payload)
+		if err != nil {
+			rel = payload
+		}
+		options = append(options, rel)
+	}
+	lines := []string{title, notice, hint, ""}
+	for idx, option := range options {
+		if idx == m.simulatePayloadIndex {
+			lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Render("> "+option))
+			continue
+		}
+		lines = append(lines, "  "+option)
+	}
+	panel := paneStyle(true).Padding(1, 2).Width(max(90, m.width-2))
+	return panel.Render(strings.Join(lines, "\n"))
+}
+
+func (m *model) openDeployments(workflowID, workflowName string) {
+	m.deploymentsID = workflowID
+	m.deploymentsName = workflowName
+	records, err := core.LoadDeployments(workflowID, workflowName)
+	if err != nil {
+		m.appendLog("Could not read deployments: " + err.Error())
+	}
+	m.deployments = make([]core.DeploymentRecord, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		m.deployments = append(m.deployments, records[i])
+	}
+	items := make([]list.Item, 0, len(m.deployments))
+	for _, record := range m.deployments {
+		status := record.Status
+		if status == "" {
+			status = "unknown"
+		}
+		description := "deployed " + record.DeployedAt.Local().Format("2006-01-02 15:04:05")
+		if record.WorkflowID != "" {
+			description += " • id " + record.WorkflowID
+		}
+		switch {
+		case record.BundleVersion != "":
+			description += " • bundle " + record.BundleVersion
+		case len(record.BundleSHA256) >= 12:
+			description += " • bundle sha256:" + record.BundleSHA256[:12]
+		}
+		if record.TxHash != "" {
+			description += " • tx " + record.TxHash
+		}
+		items = append(items, actionItem{
+			id:          record.WorkflowID,
+			title:       fmt.Sprintf("%s • %s", record.Target, status),
+			description: description,
+		})
+	}
+	m.deploymentsList.SetItems(items)
+	m.deploymentsList.Select(0)
+	m.deploymentsOpen = true
+	m.focus = focusActions
+	if len(m.deployments) == 0 {
+		m.appendLog(fmt.Sprintf("No deployments of %s recorded on this machine. Use Deploy first.", workflowName))
+	}
+}
+
+// startDeploymentOp opens the confirmation for op on the selected deployment.
+func (m *model) startDeploymentOp(op core.DeploymentOp) tea.Cmd {
+	idx := m.deploymentsList.Index()
+	if m.busy || idx < 0 || idx >= len(m.deployments) {
+		return nil
+	}
+	if m.readOnly() {
+		m.appendLog("Managing deployments is disabled in a read-only session.")
+		return nil
+	}
+	if !m.guardCRELoggedIn() {
+		return creWhoAmICmd()
+	}
+	record := m.deployments[idx]
+	if record.Status == "deleted" {
+		m.appendLog(fmt.Sprintf("The %s deployment was deleted; deploy again to manage it.", record.Target))
+		return nil
+	}
+	m.deploymentOp = op
+	m.deploymentOpRecord = record
+	m.deploymentOpError = ""
+	m.deploymentOpInput.SetValue("")
+	if op == core.DeploymentDelete {
+		return m.deploymentOpInput.Focus()
+	}
+	return nil
+}
+
+// startRollback syncs the bundle version the selected deployment shipped and
+// then walks through the usual deploy preflight and confirmation.
+func (m *model) startRollback() tea.Cmd {
+	idx := m.deploymentsList.Index()
+	if m.busy || idx < 0 || idx >= len(m.deployments) {
+		return nil
+	}
+	if m.offline {
+		m.appendLog("Rolling back needs the frontend to fetch the older bundle; it is unavailable offline.")
+		return nil
+	}
+	if m.readOnly() {
+		m.appendLog("Managing deployments is disabled in a read-only session.")
+		return nil
+	}
+	if !m.guardCRELoggedIn() {
+		return creWhoAmICmd()
+	}
+	record := m.deployments[idx]
+	m.busy = true
+	m.appendLog(fmt.Sprintf("Fetching the bundle deployed to %s on %s...", record.Target, record.DeployedAt.Local().Format("2006-01-02 15:04")))
+	return rollbackSyncCmd(m.webBaseURL, m.token, m.deploymentsID, m.deploymentsName, record)
+}
+
+func (m *model) handleDeploymentOpKey(msg tea.KeyMsg) tea.Cmd {
+	cancel := func() {
+		m.appendLog(fmt.Sprintf("Deployment %s cancelled.", m.deploymentOp))
+		m.deploymentOp = ""
+		m.deploymentOpInput.Blur()
+	}
+	run := func() tea.Cmd {
+		op, target := m.deploymentOp, m.deploymentOpRecord.Target
+		m.deploymentOp = ""
+		m.deploymentOpInput.Blur()
+		m.busy = true
+		m.appendLog(fmt.Sprintf("Running cre workflow %s for %s on %s...", op, m.deploymentsName, target))
+		return deploymentOpCmd(m.deploymentsID, m.deploymentsName, target, op)
+	}
+	if msg.String() == "esc" {
+		cancel()
+		return nil
+	}
+	if m.deploymentOp != core.DeploymentDelete {
+		switch strings.ToLower(msg.String()) {
+		case "y":
+			return run()
+		case "n", "backspace", "b":
+			cancel()
+		}
+		return nil
+	}
+	// Deleting cannot be undone, so the workflow name has to be typed.
+	if key.Matches(msg, keys.Run) {
+		if strings.TrimSpace(m.deploymentOpInput.Value()) != m.deploymentsName {
+			m.deploymentOpError = "Name does not match; type it exactly to delete."
+			return nil
+		}
+		return run()
+	}
+	var cmd tea.Cmd
+	m.deploymentOpInput, cmd = m.deploymentOpInput.Update(msg)
+	return cmd
+}
+
+// handleSecretFormKey runs before the global keys: the form takes typed
+// values, and those can contain "q".
+func (m *model) handleSecretFormKey(msg tea.KeyMsg) tea.Cmd {
+	if m.secretFormMode == "remove" {
+		switch msg.String() {
+		case "t", "T", "ctrl+t":
+			m.secretRemoveFromConvex = !m.secretRemoveFromConvex
+			m.secretRemoveArmed = false
+			if m.secretRemoveFromConvex {
+				m.appendLog("REMOVE mode: Convex removal enabled.")
+			} else {
+				m.appendLog("REMOVE mode: Convex removal disabled (clear local value only).")
+			}
+			return nil
+		case "d", "D":
+			m.secretRemoveDeclaration = !m.secretRemoveDeclaration
+			m.secretRemoveArmed = false
+			if m.secretRemoveDeclaration {
+				m.appendLog("REMOVE mode: the declaration is deleted from secrets.yaml too.")
+			} else {
+				m.appendLog("REMOVE mode: the declaration is kept in secrets.yaml.")
+			}
+			return nil
+		}
+	}
+
+	if m.secretFormTakesBlock() && (m.secretIDLocked || m.secretFormMode == "update" || m.secretFormActiveField == 1) {
+		pasted := strings.ReplaceAll(string(msg.Runes), "\r\n", "\n")
+		switch {
+		case msg.Paste && strings.ContainsAny(strings.TrimRight(pasted, "\n"), "\n\r"):
+			pasted = strings.TrimRight(pasted, "\n")
+			m.rememberSecretValue(pasted)
+			m.setSecretFormValue(pasted)
+			m.secretFormError = ""
+			m.appendLog(fmt.Sprintf("Pasted a %d-line value. Enter saves it, Backspace clears it.", strings.Count(pasted, "\n")+1))
+			return nil
+		case m.secretValueBlock != "" && (msg.String() == "backspace" || msg.String() == "ctrl+u"):
+			m.setSecretFormValue("")
+			return nil
+		case m.secretValueBlock != "" && msg.Type == tea.KeyRunes:
+			// Typing must not silently append to a pasted block.
+			return nil
+		}
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.secretFormOpen = false
+		m.secretFormMode = ""
+		m.secretFormVariableKind = ""
+		m.secretFormVariableKey = ""
+		m.secretFormError = ""
+		m.secretIDLocked = false
+		m.secretRemoveFromConvex = false
+		m.secretRemoveDeclaration = false
+		m.secretRemoveArmed = false
+		m.secretIDInput.SetValue("")
+		m.setSecretFormValue("")
+		m.setSecretsRevealed(false)
+		m.appendLog("Secrets form canceled.")
+		return nil
+	case "ctrl+r":
+		if m.secretFormMode != "remove" && m.secretFormMode != "rename" {
+			m.setSecretsRevealed(!m.secretsRevealed)
+		}
+		return nil
+	case "ctrl+g":
+		if !m.secretFormCanGenerate() {
+			return nil
+		}
+		value, err := core.GenerateSecretValue()
+		if err != nil {
+			m.secretFormError = err.Error()
+			return nil
+		}
+		m.rememberSecretValue(value)
+		m.setSecretFormValue(value)
+		m.secretValueInput.CursorEnd()
+		m.secretFormActiveField = 1
+		m.secretIDInput.Blur()
+		m.secretValueInput.Focus()
+		m.secretFormError = ""
+		m.appendLog(fmt.Sprintf("Generated a random %d-character value. Enter saves it.", len(value)))
+		return nil
+	case "enter":
+		if m.busy {
+			return nil
+		}
+		id := normalizeSecretNameInput(m.secretIDInput.Value())
+		value := strings.TrimSpace(m.secretFormValue())
+		if isPassphraseForm(m.secretFormMode) {
+			value = m.secretValueInput.Value()
+		}
+		if m.secretFormMode != "update" && id == "" {
+			m.secretFormError = "Secret ID is required."
+			return nil
+		}
+		if !m.secretIDLocked && m.secretFormMode != "remove" && m.secretFormMode != "update" && m.secretFormActiveField == 0 {
+			m.secretFormActiveField = 1
+			m.secretIDInput.Blur()
+			m.secretValueInput.Focus()
+			return nil
+		}
+		if m.secretFormMode == "rename" {
+			if err := core.ValidateSecretID(value); err != nil {
+				m.secretFormError = err.Error()
+				return nil
+			}
+		}
+		if m.secretFormMode != "remove" && value == "" {
+			m.secretFormError = "Secret value is required."
+			return nil
+		}
+		if m.secretFormMode == "add" {
+			if err := core.ValidateSecretID(id); err != nil {
+				m.secretFormError = err.Error()
+				return nil
+			}
+		}
+		if m.secretFormTakesBlock() {
+			contents, err := core.ExpandSecretValueFile(value)
+			if err != nil {
+				m.secretFormError = err.Error()
+				return nil
+			}
+			if core.IsSecretValueFile(value) {
+				m.appendLog(fmt.Sprintf("Read the value from %s (%d bytes).", strings.TrimSpace(value[1:]), len(contents)))
+			}
+			value = contents
+		}
+		if m.secretFormMode == "add" || m.secretFormMode == "update" {
+			if err := core.ValidateSecretValue(value); err != nil {
+				m.secretFormError = err.Error()
+				return nil
+			}
+		}
+		actionID := m.secretFormMode
+		if m.secretFormMode == "remove" && m.secretRemoveDeclaration {
+			// Deleting the declaration cannot be undone from the TUI,
+			// so it takes a second Enter.
+			if !m.secretRemoveArmed {
+				m.secretRemoveArmed = true
+				m.appendLog(fmt.Sprintf("Press Enter again to delete %s from secrets.yaml and .env.", id))
+				return nil
+			}
+			actionID = "purge"
+		}
+		if m.secretFormVariableKind != "rpc" && m.secretFormMode != "rename" {
+			m.rememberSecretValue(value)
+		}
+		m.busy = true
+		m.secretFormError = ""
+		m.appendLog(fmt.Sprintf("Applying %s for %s...", m.secretFormMode, m.secretsWorkflowName))
+		if m.secretFormMode == "update" {
+			return updateVariableCmd(
+				m.secretsWorkflowID,
+				m.secretsWorkflowName,
+				m.currentTarget(),
+				m.secretFormVariableKind,
+				m.secretFormVariableKey,
+				value,
+			)
+		}
+		frontendSyncAction := ""
+		if m.secretFormMode == "add" {
+			frontendSyncAction = "add"
+		}
+		if m.secretFormMode == "remove" && m.secretRemoveFromConvex {
+			frontendSyncAction = "remove"
+		}
+		if m.secretFormMode == "rename" {
+			frontendSyncAction = "rename"
+		}
+		if m.offline && frontendSyncAction != "" {
+			frontendSyncAction = ""
+			m.appendLog("Offline: only the local project is updated; the frontend config is left unchanged.")
+		}
+		return secretsCommandCmd(
+			m.webBaseURL,
+			m.token,
+			actionID,
+			m.secretsWorkflowID,
+			m.secretsWorkflowName,
+			m.currentTarget(),
+			id,
+			value,
+			frontendSyncAction,
+		)
+	case "tab", "shift+tab", "up", "down":
+		if m.secretIDLocked || m.secretFormMode == "remove" || m.secretFormMode == "update" {
+			return nil
+		}
+		if m.secretFormActiveField == 0 {
+			m.secretFormActiveField = 1
+			m.secretIDInput.Blur()
+			m.secretValueInput.Focus()
+		} else {
+			m.secretFormActiveField = 0
+			m.secretValueInput.Blur()
+			m.secretIDInput.Focus()
+		}
+		return nil
+	}
+
+	var cmd tea.Cmd
+	if !m.secretIDLocked && (m.secretFormMode == "remove" || m.secretFormActiveField == 0) {
+		m.secretIDInput, cmd = m.secretIDInput.Update(msg)
+	} else {
+		m.secretValueInput, cmd = m.secretValueInput.Update(msg)
+	}
+	return cmd
+}
 
-			var cmd tea.Cmd
-			m.actionList, cmd = m.actionList.Update(msg)
-			cmds = append(cmds, cmd)
-			return m, tea.Batch(cmds...)
+func (m *model) handlePassphraseKey(msg tea.KeyMsg) tea.Cmd {
+	switch msg.String() {
+	case "esc":
+		m.resetSimulateFlow()
+		m.appendLog("Keystore unlock canceled.")
+		return nil
+	case "enter":
+		if m.busy {
+			return nil
+		}
+		passphrase := m.simulatePassphraseInput.Value()
+		if passphrase == "" {
+			m.simulatePassphraseError = "Passphrase is required."
+			return nil
 		}
+		m.busy = true
+		m.simulatePassphraseError = ""
+		m.appendLog("Unlocking keystore...")
+		return unlockKeystoreCmd(m.simulateWorkflowID, m.simulateWorkflowName, passphrase)
 	}
+	var cmd tea.Cmd
+	m.simulatePassphraseInput, cmd = m.simulatePassphraseInput.Update(msg)
+	return cmd
+}
 
-	return m, tea.Batch(cmds...)
+func (m model) renderDeploymentOpPrompt() string {
+	record := m.deploymentOpRecord
+	verb := map[core.DeploymentOp]string{
+		core.DeploymentPause:    "Pause",
+		core.DeploymentActivate: "Resume",
+		core.DeploymentDelete:   "Delete",
+	}[m.deploymentOp]
+	effect := map[core.DeploymentOp]string{
+		core.DeploymentPause:    "Triggers stop firing until the workflow is resumed.",
+		core.DeploymentActivate: "Triggers fire again.",
+		core.DeploymentDelete:   "The workflow is removed from the registry. This cannot be undone.",
+	}[m.deploymentOp]
+	title := lipgloss.NewStyle().Bold(true).Render(verb + " deployed workflow")
+	lines := []string{
+		title,
+		"workflow: " + m.deploymentsName,
+		"target:   " + record.Target,
+	}
+	if record.WorkflowID != "" {
+		lines = append(lines, "id:       "+record.WorkflowID)
+	}
+	lines = append(lines, "command:  cre workflow "+string(m.deploymentOp), "")
+	lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render(effect))
+	hint := "y confirms, n/esc cancels."
+	if m.deploymentOp == core.DeploymentDelete {
+		hint = "Type the workflow name and press enter to delete; esc cancels."
+		lines = append(lines, "", m.deploymentOpInput.View())
+	}
+	lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(hint))
+	if m.deploymentOpError != "" {
+		lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(m.deploymentOpError))
+	}
+	panel := paneStyle(true).Padding(1, 2).Width(max(90, m.width-2))
+	return panel.Render(strings.Join(lines, "\n"))
 }
 
-func paneStyle(focused bool) lipgloss.Style {
-	border := lipgloss.Color("8")
-	if focused {
-		border = lipgloss.Color("14")
+func deployCheckLine(check core.DeployCheck) string {
+	mark := "ok"
+	if !check.OK {
+		mark = "FAIL"
 	}
-	return lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(border)
+	return fmt.Sprintf("[%s] %s: %s", mark, check.Name, check.Detail)
 }
 
-func (m model) headerView() string {
-	state := string(m.authState)
-	if m.busy {
-		state += " • busy"
+func (m model) renderDeployConfirmPrompt() string {
+	title := lipgloss.NewStyle().Bold(true).Render("Deploy workflow")
+	notice := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render(
+		fmt.Sprintf("Deploy %s to %s with `cre workflow deploy`? This sends a registry transaction signed by CRE_ETH_PRIVATE_KEY.", m.deployWorkflowName, m.currentTarget()))
+	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("y deploys, r re-runs the checks, T switches target, esc/n cancels.")
+	lines := []string{title, notice, hint, ""}
+	if m.deployPreflight != nil && m.deployPreflight.Fee != nil {
+		lines = append(lines, "Estimated fee: "+m.deployPreflight.Fee.Summary(), "")
 	}
-	creState := "login-required"
-	if m.creLoggedIn {
-		creState = "connected:" + m.creIdentity
+	if m.deployPreflight != nil {
+		for _, check := range m.deployPreflight.Checks {
+			color := lipgloss.Color("10")
+			if !check.OK {
+				color = lipgloss.Color("9")
+			}
+			lines = append(lines, lipgloss.NewStyle().Foreground(color).Render(deployCheckLine(check)))
+		}
+		if !m.deployPreflight.Passed() {
+			lines = append(lines, "", lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render("Deploy is blocked until every check passes."))
+		}
 	}
-	head := lipgloss.NewStyle().Bold(true).Render("六 6FLOW")
-	subText := fmt.Sprintf(
-		"user=%s  cre=%s  workflows=%d",
-		m.user,
-		creState,
-		m.workflowCount,
-	)
-	wrapWidth := m.width - 2
-	if wrapWidth < 40 {
-		wrapWidth = 40
+	if core.DryRun() {
+		lines = append(lines, "", "Dry run is on: the command is only logged.")
 	}
-	subLines := wrapLine(subText, wrapWidth)
-	sub := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(strings.Join(subLines, "\n"))
-	return lipgloss.JoinVertical(lipgloss.Left, head, sub)
+	panel := paneStyle(true).Padding(1, 2).Width(max(90, m.width-2))
+	return panel.Render(strings.Join(lines, "\n"))
 }
 
-func (m model) authView() string {
-	panel := paneStyle(true).Padding(1, 2)
-	lines := []string{"Authentication"}
-	if m.phase == phaseCheckingAuth || m.phase == phaseLinking {
-		lines = append(lines, fmt.Sprintf("%s %s", m.spinner.View(), "Checking/processing authentication..."))
+// syncPreviewMaxLines caps the files listed in the sync preview; locally
+// modified ones are always listed first.
+const syncPreviewMaxLines = 20
+
+func (m model) renderSyncPreviewPrompt() string {
+	preview := m.syncPreview
+	title := lipgloss.NewStyle().Bold(true).Render("Sync to local")
+	notice := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render(
+		fmt.Sprintf("Sync %s into %s? %s.", preview.WorkflowName, preview.OutputDir, preview.Summary()))
+	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("y/enter syncs, esc/n cancels. Local files the bundle does not have are kept.")
+	lines := []string{title, notice, hint, ""}
+
+	lost := preview.LostEdits()
+	lostPaths := map[string]bool{}
+	for _, change := range lost {
+		lostPaths[change.Path] = true
 	}
-	if m.phase == phaseAuthGate {
-		lines = append(lines, "Log in now?")
-		lines = append(lines, "Press Y to start login flow, or N to quit.")
+	ordered := append([]core.SyncFileChange{}, lost...)
+	for _, change := range preview.Changes {
+		if !lostPaths[change.Path] {
+			ordered = append(ordered, change)
+		}
 	}
-	lines = append(lines, "")
-	start := len(m.logs) - 10
-	if start < 0 {
-		start = 0
+	for i, change := range ordered {
+		if i == syncPreviewMaxLines {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(ordered)-i))
+			break
+		}
+		line := change.Line()
+		switch {
+		case lostPaths[change.Path]:
+			line = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(line)
+		case change.Kind == core.SyncFileKept:
+			line = lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Render(line)
+		}
+		lines = append(lines, line)
 	}
-	lines = append(lines, m.logs[start:]...)
+	if len(ordered) == 0 {
+		lines = append(lines, "The local project already matches the bundle.")
+	}
+	if len(lost) > 0 {
+		warning := fmt.Sprintf("%d locally modified file(s) will be overwritten.", len(lost))
+		if preview.Untracked {
+			warning = fmt.Sprintf("The last sync recorded no file hashes, so %d changed file(s) may hold local edits.", len(lost))
+		}
+		if m.syncPreviewArmed {
+			warning += " Press y again to sync anyway."
+		} else {
+			warning += " Syncing takes y twice."
+		}
+		lines = append(lines, "", lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(warning))
+	}
+	panel := paneStyle(true).Padding(1, 2).Width(max(90, m.width-2))
+	return panel.Render(strings.Join(lines, "\n"))
+}
 
-	return panel.Width(max(50, m.width-2)).Render(strings.Join(lines, "\n"))
+func (m *model) openLocalRemove(title string, removals []core.LocalProjectRemoval) {
+	m.localRemoveOpen = true
+	m.localRemoveTitle = title
+	m.localRemovals = removals
+	for _, removal := range removals {
+		m.appendLog("- " + removal.ProjectRoot)
+	}
+	m.appendLog(fmt.Sprintf("Delete these %d local project(s)? y deletes, esc cancels.", len(removals)))
 }
 
-func max(a, b int) int {
-	if a > b {
-		return a
+func (m model) renderLocalRemovePrompt() string {
+	title := lipgloss.NewStyle().Bold(true).Render(m.localRemoveTitle)
+	notice := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render(
+		"Delete these local project directories? Workflows in the frontend are not touched.")
+	hint := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("y deletes, esc/n cancels.")
+	lines := []string{title, notice, hint, ""}
+	secretFiles := 0
+	for _, removal := range m.localRemovals {
+		lines = append(lines, removal.ProjectRoot)
+		if len(removal.SecretFiles) > 0 {
+			secretFiles += len(removal.SecretFiles)
+			lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(
+				"  secret files: "+strings.Join(removal.SecretFiles, ", ")))
+		}
 	}
-	return b
+	if secretFiles > 0 {
+		lines = append(lines, "", lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(fmt.Sprintf(
+			"%d secret file(s) are deleted for good: no backup is kept. Copy out any value or keystore you still need.", secretFiles)))
+	}
+	if core.DryRun() {
+		lines = append(lines, "", "Dry run is on: the deletions are only logged.")
+	}
+	panel := paneStyle(true).Padding(1, 2).Width(max(90, m.width-2))
+	return panel.Render(strings.Join(lines, "\n"))
 }
 
-func (m model) renderSecretFormPrompt() string {
-	modeTitle := strings.ToUpper(m.secretFormMode)
-	title := lipgloss.NewStyle().Bold(true).Render("Secrets " + modeTitle)
-	noticeText := "Local simulation mode: updates only secrets.yaml and workflow .env."
-	if m.secretFormMode == "update" {
-		noticeText = "Update selected variable in local .env or project.yaml."
+func workflowDetailCmd(baseURL, token, workflowID string) tea.Cmd {
+	return func() tea.Msg {
+		detail, err := core.FetchWorkflowDetail(baseURL, token, workflowID)
+		return workflowDetailLoadedMsg{detail: detail, err: err}
 	}
-	notice := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render(noticeText)
-	target := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(
-		fmt.Sprintf("workflow: %s | target: %s", m.secretsWorkflowName, m.currentSecretsTarget()),
-	)
-	hints := "Enter submits. Esc cancels."
-	if m.secretFormMode != "remove" && !m.secretIDLocked {
-		hints = "Tab to switch fields. Enter on value submits. Esc cancels."
+}
+
+func renderWorkflowDetail(detail *core.WorkflowDetail) string {
+	heading := lipgloss.NewStyle().Bold(true)
+	lines := []string{
+		fmt.Sprintf("id: %s", detail.ID),
+		fmt.Sprintf("status: %s", detail.Status),
 	}
-	if m.secretIDLocked {
-		hints = "Secret ID is selected from list. Enter submits. Esc cancels."
+	if detail.CompilerVersion != "" {
+		lines = append(lines, "compiler: "+detail.CompilerVersion)
 	}
-	if m.secretFormMode == "update" {
-		hints = "Variable is selected from list. Enter submits. Esc cancels."
+	if detail.UpdatedAt > 0 {
+		lines = append(lines, "updated: "+time.UnixMilli(detail.UpdatedAt).Local().Format("2006-01-02 15:04"))
 	}
-	if m.secretFormMode == "remove" {
-		hints = "Enter clears local value. Press T to toggle removing from frontend config. Esc cancels."
+	if strings.TrimSpace(detail.Description) != "" {
+		lines = append(lines, "", strings.TrimSpace(detail.Description))
 	}
-	hintsView := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(hints)
 
-	secretIDLabel := "Secret ID"
-	secretValueLabel := "Secret value"
-	if m.secretFormMode == "update" {
-		secretIDLabel = "Variable"
-		secretValueLabel = "Value"
+	lines = append(lines, "", heading.Render(fmt.Sprintf("Triggers (%d)", len(detail.Triggers))))
+	for _, trigger := range detail.Triggers {
+		lines = append(lines, "  "+trigger)
 	}
-	if m.secretFormMode != "remove" && !m.secretIDLocked {
-		if m.secretFormActiveField == 0 {
-			secretIDLabel = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Render(secretIDLabel)
-		} else {
-			secretValueLabel = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Render(secretValueLabel)
+
+	lines = append(lines, "", heading.Render(fmt.Sprintf("Nodes (%d)", len(detail.Nodes))))
+	for _, node := range detail.Nodes {
+		label := node.Label
+		if label == "" {
+			label = node.ID
 		}
-	} else {
-		secretIDLabel = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Render(secretIDLabel)
+		lines = append(lines, fmt.Sprintf("  %s [%s]", label, node.Type))
 	}
 
-	lines := []string{
-		title,
-		notice,
-		target,
-		"",
-		secretIDLabel,
-	}
-	if m.secretIDLocked {
-		lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Render(m.secretIDInput.Value()))
-	} else {
-		lines = append(lines, m.secretIDInput.View())
+	lines = append(lines, "", heading.Render(fmt.Sprintf("Secrets (%d)", len(detail.Secrets))))
+	for _, secret := range detail.Secrets {
+		lines = append(lines, "  "+secret)
 	}
-	if m.secretFormMode != "remove" {
-		lines = append(lines, "", secretValueLabel, m.secretValueInput.View())
-	} else {
-		removeMode := "OFF (default: clear local value only)"
-		if m.secretRemoveFromConvex {
-			removeMode = "ON (also remove from web)"
+
+	lines = append(lines, "", heading.Render("Config schema"))
+	if schema := detail.ConfigSchemaText(); schema != "" {
+		for _, line := range strings.Split(schema, "\n") {
+			lines = append(lines, "  "+line)
 		}
-		lines = append(lines, "", "Remove from web", removeMode)
+	} else {
+		lines = append(lines, "  (none)")
 	}
-	lines = append(lines, hintsView)
+	return strings.Join(lines, "\n")
+}
 
-	if strings.TrimSpace(m.secretFormError) != "" {
-		lines = append(lines, lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(m.secretFormError))
+func workflowRunsCmd(baseURL, token, workflowID string) tea.Cmd {
+	return func() tea.Msg {
+		runs, err := core.FetchWorkflowRuns(baseURL, token, workflowID, core.DefaultWorkflowRunLimit)
+		return workflowRunsLoadedMsg{workflowID: workflowID, runs: runs, err: err}
 	}
-
-	panel := paneStyle(true).Padding(1, 2).Width(max(70, m.width-2))
-	return panel.Render(strings.Join(lines, "\n"))
 }
 
-func (m model) renderVariablePickerPrompt() string {
-	title := lipgloss.NewStyle().Bold(true).Render("Update Value")
-	subtitle := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render(
-		"Select from System Variables (left) or Environment Variables (right). Tab/Left/Right to switch panel, Enter to edit, Esc to close.",
-	)
+func bundleVersionsCmd(baseURL, token, workflowID string) tea.Cmd {
+	return func() tea.Msg {
+		versions, err := core.FetchBundleVersions(baseURL, token, workflowID)
+		return bundleVersionsLoadedMsg{workflowID: workflowID, versions: versions, err: err}
+	}
+}
 
-	systemList := m.systemVariableList
-	environmentList := m.environmentVariableList
-	panelWidth := max(90, m.width-2)
-	listWidth := (panelWidth - 12) / 2
-	listHeight := max(10, m.height/3)
-	systemList.SetSize(listWidth, max(6, listHeight-2))
-	environmentList.SetSize(listWidth, max(6, listHeight-2))
-	systemList.Title = ""
-	environmentList.Title = ""
+func configPushCmd(baseURL, token, workflowID, workflowName string, apply bool) tea.Cmd {
+	return func() tea.Msg {
+		result, err := core.PushWorkflowConfig(baseURL, token, workflowID, workflowName, apply)
+		return configPushedMsg{workflowID: workflowID, name: workflowName, result: result, applied: apply, err: err}
+	}
+}
 
-	activeHeader := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("14")).
-		Underline(true)
-	inactiveHeader := lipgloss.NewStyle().
-		Bold(true).
-		Foreground(lipgloss.Color("8"))
-	leftHeader := inactiveHeader.Render("System Variables")
-	rightHeader := inactiveHeader.Render("Environment Variables")
-	if m.variablePickerFocus == 0 {
-		leftHeader = activeHeader.Render("System Variables")
-	} else {
-		rightHeader = activeHeader.Render("Environment Variables")
+func (m *model) openBundleVersions(versions []core.BundleVersion) {
+	m.versions = versions
+	items := make([]list.Item, 0, len(versions))
+	for _, version := range versions {
+		title := fmt.Sprintf("compiler %s • %s", version.CompilerVersion, formatRunTime(version.CreatedAt))
+		if version.Current {
+			title += " • current"
+		}
+		description := version.ID
+		if version.Size > 0 {
+			description += " • " + core.FormatBytes(version.Size)
+		}
+		items = append(items, actionItem{id: version.ID, title: title, description: description})
+	}
+	m.versionsList.SetItems(items)
+	m.versionsList.Select(0)
+	m.versionsOpen = true
+	m.focus = focusActions
+	if len(versions) == 0 {
+		m.appendLog(fmt.Sprintf("No compiled bundles recorded for %s.", m.versionsName))
+		return
 	}
+	m.appendLog(fmt.Sprintf("Loaded %d bundle version(s) for %s. Press enter to sync one.", len(versions), m.versionsName))
+}
 
-	leftBody := lipgloss.JoinVertical(lipgloss.Left, leftHeader, systemList.View())
-	rightBody := lipgloss.JoinVertical(lipgloss.Left, rightHeader, environmentList.View())
-	leftStyle := lipgloss.NewStyle().Width(listWidth+2).Padding(0, 1)
-	rightStyle := lipgloss.NewStyle().Width(listWidth+2).Padding(0, 1)
-	lists := lipgloss.JoinHorizontal(
-		lipgloss.Top,
-		leftStyle.Render(leftBody),
-		"  ",
-		rightStyle.Render(rightBody),
-	)
+func formatRunTime(ms int64) string {
+	if ms <= 0 {
+		return "-"
+	}
+	return time.UnixMilli(ms).Local().Format("2006-01-02 15:04:05")
+}
 
-	panel := paneStyle(true).Padding(1, 2).Width(panelWidth)
-	return panel.Render(lipgloss.JoinVertical(lipgloss.Left, title, subtitle, "", lists))
+func formatRunDuration(run core.WorkflowRun) string {
+	if d, ok := run.Duration(); ok {
+		return d.Round(time.Millisecond).String()
+	}
+	if run.FinishedAt == 0 && run.StartedAt > 0 {
+		return "running"
+	}
+	return "-"
 }
 
-func (m model) renderSimulateFormPrompt() string {
-	title := lipgloss.NewStyle().Bold(true).Render("Simulation Input (EVM)")
-	notice := lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Render("Provide tx hash and event index for non-interactive simulate.")
-	target := lipgloss.NewStyle().Foreground(lipgloss.Color("8")).Render("Enter on tx hash moves to index. Enter on index runs. Tab switches field. Esc cancels.")
-	txLabel := "EVM tx hash"
-	indexLabel := "EVM event index"
-	if m.simulateFormActiveField == 0 {
-		txLabel = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Render(txLabel)
-	} else {
-		indexLabel = lipgloss.NewStyle().Foreground(lipgloss.Color("14")).Render(indexLabel)
+func (m *model) openRunHistory(runs []core.WorkflowRun) {
+	m.runs = runs
+	items := make([]list.Item, 0, len(runs))
+	for _, run := range runs {
+		description := "took " + formatRunDuration(run)
+		if run.Trigger != "" {
+			description += " • " + run.Trigger
+		}
+		if summary := run.ErrorSummary(80); summary != "" {
+			description += " • " + summary
+		}
+		items = append(items, actionItem{
+			id:          run.ID,
+			title:       fmt.Sprintf("%s • %s", run.Status, formatRunTime(run.StartedAt)),
+			description: description,
+		})
 	}
-	lines := []string{
-		title,
-		notice,
-		target,
-		"",
-		txLabel,
-		m.simulateTxHashInput.View(),
-		"",
-		indexLabel,
-		m.simulateEventIndexInput.View(),
+	m.runsList.SetItems(items)
+	m.runsList.Select(0)
+	m.runsOpen = true
+	m.focus = focusActions
+	if len(runs) == 0 {
+		m.appendLog(fmt.Sprintf("No recorded runs for %s.", m.runsWorkflowName))
+		return
 	}
-	if strings.TrimSpace(m.simulateFormError) != "" {
-		lines = append(lines, "", lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Render(m.simulateFormError))
+	m.appendLog(fmt.Sprintf("Loaded %d run(s) for %s. Press enter on a run for details.", len(runs), m.runsWorkflowName))
+}
+
+func (m *model) logRunDetails(run core.WorkflowRun) {
+	m.appendLog(fmt.Sprintf("Run %s: %s", run.ID, run.Status))
+	m.appendLog(fmt.Sprintf("  started %s, finished %s, duration %s", formatRunTime(run.StartedAt), formatRunTime(run.FinishedAt), formatRunDuration(run)))
+	if run.Trigger != "" {
+		m.appendLog("  trigger: " + run.Trigger)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(run.Error), "\n") {
+		if line != "" {
+			m.appendLog("  error: " + line)
+		}
 	}
-	panel := paneStyle(true).Padding(1, 2).Width(max(90, m.width-2))
-	return panel.Render(strings.Join(lines, "\n"))
 }
 
 func (m model) View() string {
@@ -2155,19 +6661,60 @@ func (m model) View() string {
 		rightW += m.width - totalMiddleW
 	}
 
-	wf := paneStyle(m.focus == focusWorkflows).Width(leftW).Render(m.workflowList.View())
+	workflowsPane := m.workflowList.View()
+	if m.filterOpen {
+		// The input takes the place of the list title.
+		workflowList := m.workflowList
+		workflowList.SetShowTitle(false)
+		workflowsPane = lipgloss.JoinVertical(lipgloss.Left, m.filterInput.View(), workflowList.View())
+	}
+	wf := paneStyle(m.focus == focusWorkflows).Width(leftW).Render(workflowsPane)
 	actionsPane := m.actionList.View()
-	if m.secretsMenuOpen {
-		if m.secretPickOpen {
+	if m.accountPickOpen {
+		m.accountList.Title = "Accounts (enter switch, esc back)"
+		actionsPane = m.accountList.View()
+	} else if m.orgPickOpen {
+		m.orgList.Title = "Organizations (enter switch, esc back)"
+		actionsPane = m.orgList.View()
+	} else if m.detailOpen {
+		title := lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("Details: %s (↑/↓ scroll, esc back)", m.detailName))
+		actionsPane = lipgloss.JoinVertical(lipgloss.Left, title, m.detailView.View())
+	} else if m.versionsOpen {
+		m.versionsList.Title = fmt.Sprintf("Bundle versions: %s (enter sync, esc back)", m.versionsName)
+		actionsPane = m.versionsList.View()
+	} else if m.deploymentsOpen {
+		m.deploymentsList.Title = fmt.Sprintf("Deployments: %s (p pause, a resume, x delete, v redeploy bundle, r reload, esc back)", m.deploymentsName)
+		if len(m.deployments) == 0 {
+			m.deploymentsList.Title = fmt.Sprintf("Deployments: %s (none recorded, esc back)", m.deploymentsName)
+		}
+		actionsPane = m.deploymentsList.View()
+	} else if m.runsOpen {
+		m.runsList.Title = fmt.Sprintf("Runs: %s (enter details, esc back)", m.runsWorkflowName)
+		if len(m.runs) == 0 {
+			m.runsList.Title = fmt.Sprintf("Runs: %s (none recorded, esc back)", m.runsWorkflowName)
+		}
+		actionsPane = m.runsList.View()
+	} else if m.secretsMenuOpen {
+		if m.secretsDiffOpen {
+			m.secretsDiffList.Title = fmt.Sprintf("Secrets diff: %s | target=%s (enter fix, d delete, esc back)", m.secretsWorkflowName, m.currentTarget())
+			actionsPane = m.secretsDiffList.View()
+		} else if m.secretsCloneOpen {
+			values := "off"
+			if m.secretsCloneValues {
+				values = "on"
+			}
+			m.secretsCloneList.Title = fmt.Sprintf("Clone secrets into %s | target=%s | values %s (enter clone, v values, esc back)", m.secretsWorkflowName, m.currentTarget(), values)
+			actionsPane = m.secretsCloneList.View()
+		} else if m.secretPickOpen {
 			pickLabel := "secret"
 			if m.secretPickAction == "update" {
 				pickLabel = "variable"
 			}
-			m.secretPickList.Title = fmt.Sprintf("Pick %s for %s: %s (esc back)", pickLabel, strings.ToUpper(m.secretPickAction), m.secretsWorkflowName)
+			m.secretPickList.Title = fmt.Sprintf("Pick %s for %s: %s (c copy value, esc back)", pickLabel, strings.ToUpper(m.secretPickAction), m.secretsWorkflowName)
 			actionsPane = m.secretPickList.View()
 		} else {
-			m.secretsMenu.Title = fmt.Sprintf("Secrets submenu: %s | target=%s (esc back)", m.secretsWorkflowName, m.currentSecretsTarget())
-			actionsPane = m.secretsMenu.View()
+			m.secretsMenu.Title = fmt.Sprintf("Secrets submenu: %s | target=%s (esc back)", m.secretsWorkflowName, m.currentTarget())
+			actionsPane = lipgloss.JoinVertical(lipgloss.Left, m.walletLine(), m.secretsMenu.View())
 		}
 	} else {
 		m.actionList.Title = "Actions"
@@ -2199,16 +6746,74 @@ func (m model) View() string {
 	if m.secretFormOpen {
 		sections = append(sections, m.renderSecretFormPrompt())
 	}
+	if m.simulatePassphraseOpen {
+		sections = append(sections, m.renderSimulatePassphrasePrompt())
+	}
 	if m.simulateFormOpen {
 		sections = append(sections, m.renderSimulateFormPrompt())
 	}
+	if m.simulatePayloadOpen {
+		sections = append(sections, m.renderSimulatePayloadPrompt())
+	}
+	if m.deployConfirmOpen {
+		sections = append(sections, m.renderDeployConfirmPrompt())
+	}
+	if m.syncPreviewOpen {
+		sections = append(sections, m.renderSyncPreviewPrompt())
+	}
+	if m.localRemoveOpen {
+		sections = append(sections, m.renderLocalRemovePrompt())
+	}
+	if m.deploymentOp != "" {
+		sections = append(sections, m.renderDeploymentOpPrompt())
+	}
 	sections = append(sections, footer)
 	return lipgloss.JoinVertical(lipgloss.Left, sections...)
 }
 
 func main() {
-	p := tea.NewProgram(initialModel(), tea.WithAltScreen())
-	if _, err := p.Run(); err != nil {
+	if len(os.Args) > 1 && isHeadlessCommand(os.Args[1]) {
+		os.Exit(runHeadless(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
+	opts, err := parseStartupOptions(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		headlessUsage(os.Stderr)
+		os.Exit(exitUsage)
+	}
+
+	core.SetAuthTokenOverride(opts.token)
+	if err := core.SetAuthProfile(opts.profile); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(exitUsage)
+	}
+
+	tracePath, err := core.ConfigureHTTPTrace()
+	if err == nil && opts.httpTrace != "" {
+		err = core.EnableHTTPTrace(opts.httpTrace)
+		tracePath = core.HTTPTracePath()
+	}
+
+	m := initialModel(opts)
+	if err != nil {
+		m.appendLog("WARNING: HTTP trace is off: " + err.Error())
+	} else if tracePath != "" {
+		m.appendLog("HTTP trace enabled, writing to " + tracePath + " (tokens and secret values are redacted).")
+	}
+	if core.ConfigureDryRun() {
+		m.appendLog("Dry run on (SIXFLOW_DRY_RUN): simulate and secrets actions only log what they would do. Press D to turn it off.")
+	}
+	m.claimInstance()
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	core.SetFrontendNotice(func(text string) {
+		p.Send(frontendNoticeMsg{text: text})
+	})
+	final, err := p.Run()
+	if fm, ok := final.(model); ok && fm.releaseInstance != nil {
+		fm.releaseInstance()
+	}
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Codes the frontend sets in the "code" field of error bodies.
+const (
+	APIErrorWorkflowNotFound = "workflow_not_found"
+	APIErrorBundleExpired    = "bundle_expired"
+	APIErrorQuotaExceeded    = "quota_exceeded"
+	APIErrorForbidden        = "forbidden"
+	APIErrorSecretExists     = "secret_exists"
+	APIErrorSecretNotFound   = "secret_not_found"
+)
+
+// APIError is a non-2xx answer from the frontend API. Code is empty for
+// frontends that only send a message.
+type APIError struct {
+	Status  int
+	Code    string
+	Message string
+	Detail  string
+}
+
+func (e *APIError) Error() string {
+	message := e.Message
+	if message == "" {
+		message = fmt.Sprintf("request failed with status %d", e.Status)
+	}
+	if e.Detail != "" {
+		message += ": " + e.Detail
+	}
+	return message
+}
+
+// Kind classifies the error by code, falling back to the HTTP status for
+// frontends that do not send codes.
+func (e *APIError) Kind() string {
+	if e.Code != "" {
+		return e.Code
+	}
+	switch e.Status {
+	case http.StatusNotFound:
+		return APIErrorWorkflowNotFound
+	case http.StatusGone:
+		return APIErrorBundleExpired
+	case http.StatusPaymentRequired:
+		return APIErrorQuotaExceeded
+	case http.StatusForbidden:
+		return APIErrorForbidden
+	}
+	return ""
+}
+
+// AsAPIError unwraps err to an *APIError.
+func AsAPIError(err error) (*APIError, bool) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr, true
+	}
+	return nil, false
+}
+
+// apiErrorBody is embedded in response payloads to pick up the error fields
+// the frontend sends alongside a non-2xx status.
+type apiErrorBody struct {
+	Error  string `json:"error"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+}
+
+func (b apiErrorBody) hasError() bool {
+	return strings.TrimSpace(b.Error) != "" || strings.TrimSpace(b.Code) != ""
+}
+
+func (b apiErrorBody) apiError(status int) *APIError {
+	return &APIError{
+		Status:  status,
+		Code:    strings.TrimSpace(b.Code),
+		Message: strings.TrimSpace(b.Error),
+		Detail:  strings.TrimSpace(b.Detail),
+	}
+}
@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// AuthAccount describes a saved session without loading its token, so the
+// account switcher never has to touch the keyring or a passphrase.
+type AuthAccount struct {
+	Profile    string
+	Account    string
+	WebBaseURL string
+	Valid      bool
+}
+
+var accountSlugPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+func readSessionFile(path string) (*AuthSession, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var session AuthSession
+	if err := json.Unmarshal(content, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ListAuthAccounts returns one entry per profile that has a saved session.
+func ListAuthAccounts() []AuthAccount {
+	accounts := []AuthAccount{}
+	for _, profile := range ListAuthProfiles() {
+		session, err := readSessionFile(profileSessionFilePath(profile))
+		if err != nil {
+			continue
+		}
+		// The token may live in the keyring or be encrypted; validity only
+		// depends on exp/savedAt.
+		probe := *session
+		probe.Token = "saved"
+		accounts = append(accounts, AuthAccount{
+			Profile:    profile,
+			Account:    session.Account,
+			WebBaseURL: session.WebBaseURL,
+			Valid:      IsSessionValid(&probe),
+		})
+	}
+	return accounts
+}
+
+// ProfileForAccount returns the profile already holding this account on the
+// given frontend, or a new profile name derived from the account label.
+func ProfileForAccount(account, webBaseURL string) string {
+	webBaseURL = NormalizeBaseURL(webBaseURL)
+	for _, saved := range ListAuthAccounts() {
+		if account != "" && saved.Account == account && NormalizeBaseURL(saved.WebBaseURL) == webBaseURL {
+			return saved.Profile
+		}
+	}
+	taken := map[string]bool{}
+	for _, profile := range ListAuthProfiles() {
+		taken[profile] = true
+	}
+
+	base := strings.Trim(accountSlugPattern.ReplaceAllString(strings.ToLower(account), "-"), "-")
+	if base == "" {
+		base = "account"
+	}
+	if len(base) > 32 {
+		base = strings.Trim(base[:32], "-")
+	}
+	name := base
+	for i := 2; taken[name] || ValidateProfileName(name) != nil; i++ {
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+	return name
+}
@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	DefaultAuthProfile = "default"
+	authProfileEnv     = "SIXFLOW_PROFILE"
+)
+
+var profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,39}$`)
+
+var activeAuthProfile = ""
+
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid profile name %q: use lowercase letters, digits, '-' and '_'", name)
+	}
+	return nil
+}
+
+// SetAuthProfile selects which saved session LoadAuthSession/SaveAuthSession
+// use. An empty name falls back to SIXFLOW_PROFILE, then "default".
+func SetAuthProfile(name string) error {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = strings.ToLower(strings.TrimSpace(os.Getenv(authProfileEnv)))
+	}
+	if name == "" {
+		name = DefaultAuthProfile
+	}
+	if err := ValidateProfileName(name); err != nil {
+		return err
+	}
+	activeAuthProfile = name
+	return nil
+}
+
+func ActiveAuthProfile() string {
+	if activeAuthProfile == "" {
+		return DefaultAuthProfile
+	}
+	return activeAuthProfile
+}
+
+func sixflowHomeDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".6flow"
+	}
+	return filepath.Join(home, ".6flow")
+}
+
+func profilesRootDir() string {
+	return filepath.Join(sixflowHomeDir(), "profiles")
+}
+
+// ListAuthProfiles returns "default" plus every profile that has a directory
+// under ~/.6flow/profiles.
+func ListAuthProfiles() []string {
+	profiles := []string{DefaultAuthProfile}
+	entries, err := os.ReadDir(profilesRootDir())
+	if err == nil {
+		names := []string{}
+		for _, entry := range entries {
+			if entry.IsDir() && entry.Name() != DefaultAuthProfile && ValidateProfileName(entry.Name()) == nil {
+				names = append(names, entry.Name())
+			}
+		}
+		sort.Strings(names)
+		profiles = append(profiles, names...)
+	}
+	if active := ActiveAuthProfile(); !containsString(profiles, active) {
+		profiles = append(profiles, active)
+	}
+	return profiles
+}
+
+// SavedSessionWebBaseURL returns the frontend recorded with the active
+// profile's saved session without touching the keyring.
+func SavedSessionWebBaseURL() string {
+	session, err := readSessionFile(sessionFilePath())
+	if err != nil {
+		return ""
+	}
+	return session.WebBaseURL
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
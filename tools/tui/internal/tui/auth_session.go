@@ -3,6 +3,8 @@ package tui
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -13,19 +15,75 @@ type AuthSession struct {
 	Token   string `json:"token"`
 	Exp     *int64 `json:"exp"`
 	SavedAt string `json:"savedAt"`
+	Store   string `json:"store,omitempty"`
+	// Encryption is "machine" or "passphrase" when EncryptedToken holds the
+	// token instead of the plaintext Token field.
+	Encryption     string          `json:"encryption,omitempty"`
+	EncryptedToken *encryptedToken `json:"encryptedToken,omitempty"`
+	// WebBaseURL is the frontend the token was issued by, so each profile can
+	// point at a different deployment.
+	WebBaseURL string `json:"webBaseUrl,omitempty"`
+	// Account is the identity the token was issued to (email or subject).
+	Account string `json:"account,omitempty"`
+	// CREIdentity is the `cre whoami` identity last used with this account.
+	CREIdentity string `json:"creIdentity,omitempty"`
+	// OrgID and OrgName are the organization picked for multi-org accounts.
+	OrgID   string `json:"orgId,omitempty"`
+	OrgName string `json:"orgName,omitempty"`
+	Source  string `json:"-"`
+	// StoreWarning explains why the token could not go to the OS keyring.
+	StoreWarning string `json:"-"`
 }
 
-const localSessionFallbackTTL = 90 * 24 * time.Hour
+const (
+	SessionSourceFile = "file"
+	SessionSourceEnv  = "env"
+	SessionSourceFlag = "flag"
+)
+
+const (
+	AuthTokenEnv     = "SIXFLOW_TOKEN"
+	authStoreEnv     = "SIXFLOW_AUTH_STORE"
+	authStoreKeyring = "keyring"
+	authStoreFile    = "file"
+)
+
+var ErrAuthTokenExpired = errors.New("provided auth token is expired")
+
+// ErrAuthTokenNoExpiry rejects tokens whose exp claim cannot be read; without
+// it there is no telling how long the server still accepts them.
+var ErrAuthTokenNoExpiry = errors.New("not a valid session token (could not read its expiry)")
+
+var authTokenOverride string
 
+// SetAuthTokenOverride makes LoadAuthSession use the given token (e.g. from
+// --token) instead of SIXFLOW_TOKEN or the saved session file.
+func SetAuthTokenOverride(token string) {
+	authTokenOverride = strings.TrimSpace(token)
+}
+
+// AuthTokenOverride returns the token set with SetAuthTokenOverride.
+func AuthTokenOverride() string {
+	return authTokenOverride
+}
+
+// The default profile keeps the original ~/.6flow/tui-auth.json location.
 func sessionFilePath() string {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return ".6flow/tui-auth.json"
+	return profileSessionFilePath(ActiveAuthProfile())
+}
+
+func profileSessionFilePath(profile string) string {
+	if profile == DefaultAuthProfile {
+		return filepath.Join(sixflowHomeDir(), "tui-auth.json")
 	}
-	return filepath.Join(home, ".6flow", "tui-auth.json")
+	return filepath.Join(profilesRootDir(), profile, "tui-auth.json")
 }
 
-func decodeJWTExp(token string) *int64 {
+func keyringSessionAccount() string {
+	return ActiveAuthProfile()
+}
+
+func decodeJWTClaims(token string) map[string]any {
 	parts := strings.Split(token, ".")
 	if len(parts) < 2 {
 		return nil
@@ -45,8 +103,11 @@ func decodeJWTExp(token string) *int64 {
 	if err := json.Unmarshal(decoded, &payload); err != nil {
 		return nil
 	}
+	return payload
+}
 
-	expFloat, ok := payload["exp"].(float64)
+func decodeJWTExp(token string) *int64 {
+	expFloat, ok := decodeJWTClaims(token)["exp"].(float64)
 	if !ok {
 		return nil
 	}
@@ -55,29 +116,129 @@ func decodeJWTExp(token string) *int64 {
 	return &exp
 }
 
+const (
+	ScopeRead  = "tui:read"
+	ScopeWrite = "tui:write"
+)
+
+var ErrReadOnlySession = errors.New("session is read-only (token lacks the " + ScopeWrite + " scope)")
+
+// TokenScopes returns the scopes granted to the token, from either a
+// space-separated "scope" claim or an "scp" array. Nil means the token
+// predates scoping.
+func TokenScopes(token string) []string {
+	claims := decodeJWTClaims(token)
+	if raw, ok := claims["scope"].(string); ok {
+		return strings.Fields(raw)
+	}
+	if raw, ok := claims["scp"].([]any); ok {
+		scopes := []string{}
+		for _, value := range raw {
+			if scope, ok := value.(string); ok {
+				scopes = append(scopes, scope)
+			}
+		}
+		return scopes
+	}
+	return nil
+}
+
+// TokenIsReadOnly reports whether the token was issued without write access.
+// Unscoped tokens keep full access.
+func TokenIsReadOnly(token string) bool {
+	scopes := TokenScopes(token)
+	return scopes != nil && !containsString(scopes, ScopeWrite)
+}
+
+// TokenAccountLabel names the account a token belongs to, preferring the
+// email claim over the raw subject.
+func TokenAccountLabel(token string) string {
+	claims := decodeJWTClaims(token)
+	for _, claim := range []string{"email", "preferred_username", "name", "sub"} {
+		if value, ok := claims[claim].(string); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
 func IsSessionValid(session *AuthSession) bool {
 	if session == nil || strings.TrimSpace(session.Token) == "" {
 		return false
 	}
+	return checkTokenExpiry(session.Exp) == nil
+}
 
-	nowMs := time.Now().UnixMilli()
-	if session.Exp != nil && (*session.Exp)*1000 > nowMs+5000 {
-		return true
+// checkTokenExpiry requires an exp claim at least a few seconds in the future.
+func checkTokenExpiry(exp *int64) error {
+	if exp == nil {
+		return ErrAuthTokenNoExpiry
+	}
+	if (*exp)*1000 <= time.Now().UnixMilli()+5000 {
+		return ErrAuthTokenExpired
 	}
+	return nil
+}
+
+const (
+	offlineGraceEnv     = "SIXFLOW_OFFLINE_GRACE"
+	defaultOfflineGrace = 72 * time.Hour
+)
 
-	savedAt := strings.TrimSpace(session.SavedAt)
-	if savedAt == "" {
+// WithinOfflineGrace reports whether an expired session is recent enough to
+// keep working locally while the frontend cannot be reached. The window comes
+// from SIXFLOW_OFFLINE_GRACE (e.g. "24h"; "0" disables offline mode).
+func WithinOfflineGrace(session *AuthSession) bool {
+	if session == nil || session.Exp == nil {
 		return false
 	}
-	parsed, err := time.Parse(time.RFC3339, savedAt)
-	if err != nil {
-		return false
+	grace := defaultOfflineGrace
+	if raw := strings.TrimSpace(os.Getenv(offlineGraceEnv)); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil && parsed >= 0 {
+			grace = parsed
+		}
 	}
+	return time.Now().Before(time.Unix(*session.Exp, 0).Add(grace))
+}
 
-	return time.Since(parsed) <= localSessionFallbackTTL
+func sessionFromToken(token, source string) (*AuthSession, error) {
+	session := &AuthSession{
+		Token:   token,
+		Exp:     decodeJWTExp(token),
+		SavedAt: time.Now().UTC().Format(time.RFC3339),
+		Account: TokenAccountLabel(token),
+		Source:  source,
+	}
+	if err := checkTokenExpiry(session.Exp); err != nil {
+		return nil, err
+	}
+	return session, nil
 }
 
+// NormalizeManualToken checks a token pasted from the web UI before it is
+// saved: it must be a JWT whose exp claim is still in the future.
+func NormalizeManualToken(raw string) (string, error) {
+	token := strings.TrimSpace(raw)
+	token = strings.TrimSpace(strings.TrimPrefix(token, "Bearer "))
+	if token == "" {
+		return "", errors.New("token is required")
+	}
+	if err := checkTokenExpiry(decodeJWTExp(token)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// LoadAuthSession resolves the active session: --token first, then
+// SIXFLOW_TOKEN, then the session saved by the browser login flow.
 func LoadAuthSession() (*AuthSession, error) {
+	if authTokenOverride != "" {
+		return sessionFromToken(authTokenOverride, SessionSourceFlag)
+	}
+	if token := strings.TrimSpace(os.Getenv(AuthTokenEnv)); token != "" {
+		return sessionFromToken(token, SessionSourceEnv)
+	}
+
 	content, err := os.ReadFile(sessionFilePath())
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -90,6 +251,21 @@ func LoadAuthSession() (*AuthSession, error) {
 	if err := json.Unmarshal(content, &session); err != nil {
 		return nil, nil
 	}
+	if session.Store == authStoreKeyring {
+		token, err := keyringGet(keyringSessionAccount())
+		if err != nil {
+			return nil, fmt.Errorf("read session token from OS keyring: %w", err)
+		}
+		session.Token = token
+	}
+	if session.EncryptedToken != nil {
+		token, err := decryptSessionToken(session.Encryption, session.EncryptedToken)
+		if err != nil {
+			return nil, err
+		}
+		session.Token = token
+		session.EncryptedToken = nil
+	}
 	if session.Token == "" {
 		return nil, nil
 	}
@@ -99,24 +275,74 @@ func LoadAuthSession() (*AuthSession, error) {
 	if session.SavedAt == "" {
 		session.SavedAt = time.Now().UTC().Format(time.RFC3339)
 	}
+	if session.Account == "" {
+		session.Account = TokenAccountLabel(session.Token)
+	}
+	session.Source = SessionSourceFile
 
 	return &session, nil
 }
 
-func SaveAuthSession(token string) (*AuthSession, error) {
+// SaveAuthSession keeps the token in the OS keyring when one is available
+// (SIXFLOW_AUTH_STORE=file opts out); the session file then only holds
+// metadata. Without a keyring the token is written to the file, encrypted if
+// SIXFLOW_AUTH_ENCRYPT asks for it, and StoreWarning is set.
+func SaveAuthSession(token, webBaseURL string) (*AuthSession, error) {
 	exp := decodeJWTExp(token)
 	session := &AuthSession{
-		Token:   token,
-		Exp:     exp,
-		SavedAt: time.Now().UTC().Format(time.RFC3339),
+		Token:      token,
+		Exp:        exp,
+		SavedAt:    time.Now().UTC().Format(time.RFC3339),
+		WebBaseURL: NormalizeBaseURL(strings.TrimSpace(webBaseURL)),
+		Account:    TokenAccountLabel(token),
+		Source:     SessionSourceFile,
 	}
 
 	file := sessionFilePath()
 	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
 		return nil, err
 	}
+	release, err := acquireFileLock(file)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if previous, err := readSessionFile(file); err == nil && previous.Account == session.Account {
+		session.CREIdentity = previous.CREIdentity
+		session.OrgID = previous.OrgID
+		session.OrgName = previous.OrgName
+	}
+
+	encryptMode, err := sessionEncryptionMode()
+	if err != nil {
+		return nil, err
+	}
+
+	onDisk := *session
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv(authStoreEnv)))
+	if mode == authStoreFile {
+		_ = keyringDelete(keyringSessionAccount())
+	} else if err := keyringSet(keyringSessionAccount(), token); err != nil {
+		if encryptMode == "" {
+			session.StoreWarning = fmt.Sprintf("OS keyring unavailable (%v); session token saved in plaintext at %s", err, file)
+		}
+	} else {
+		onDisk.Token = ""
+		onDisk.Store = authStoreKeyring
+		session.Store = authStoreKeyring
+	}
+	if onDisk.Token != "" && encryptMode != "" {
+		enc, err := encryptSessionToken(encryptMode, token)
+		if err != nil {
+			return nil, fmt.Errorf("encrypt session: %w", err)
+		}
+		onDisk.Token = ""
+		onDisk.Encryption = encryptMode
+		onDisk.EncryptedToken = enc
+		session.Encryption = encryptMode
+	}
 
-	content, err := json.MarshalIndent(session, "", "  ")
+	content, err := json.MarshalIndent(&onDisk, "", "  ")
 	if err != nil {
 		return nil, err
 	}
@@ -129,9 +355,31 @@ func SaveAuthSession(token string) (*AuthSession, error) {
 }
 
 func ClearAuthSession() error {
-	err := os.Remove(sessionFilePath())
+	file := sessionFilePath()
+	release, err := acquireFileLock(file)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if keyringAvailable() {
+		_ = keyringDelete(keyringSessionAccount())
+	}
+	ClearWorkflowCache()
+	err = os.Remove(file)
 	if err != nil && !os.IsNotExist(err) {
 		return err
 	}
 	return nil
 }
+
+// RevokeAndClearAuthSession invalidates the token server-side (best effort)
+// and then removes the saved session. The local session is cleared even when
+// revocation fails; the revocation error is returned for reporting.
+func RevokeAndClearAuthSession(baseURL, token string) error {
+	revokeErr := RevokeFrontendToken(baseURL, token)
+	if err := ClearAuthSession(); err != nil {
+		return err
+	}
+	return revokeErr
+}
@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func testJWT(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc := base64.RawURLEncoding.EncodeToString
+	return enc([]byte(`{"alg":"none"}`)) + "." + enc(payload) + ".sig"
+}
+
+func TestSessionTokensNeedAnExpiry(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"valid", testJWT(t, map[string]any{"exp": now.Add(time.Hour).Unix()}), nil},
+		{"expired", testJWT(t, map[string]any{"exp": now.Add(-time.Hour).Unix()}), ErrAuthTokenExpired},
+		{"no exp claim", testJWT(t, map[string]any{"sub": "user"}), ErrAuthTokenNoExpiry},
+		{"opaque", "not-a-jwt", ErrAuthTokenNoExpiry},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session, err := sessionFromToken(tt.token, SessionSourceFlag)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("sessionFromToken error = %v, want %v", err, tt.want)
+			}
+			if _, err := NormalizeManualToken(tt.token); !errors.Is(err, tt.want) {
+				t.Fatalf("NormalizeManualToken error = %v, want %v", err, tt.want)
+			}
+			// A saved session from an older build may lack exp; it must not
+			// stay valid on the strength of its save time.
+			saved := &AuthSession{Token: tt.token, Exp: decodeJWTExp(tt.token), SavedAt: now.UTC().Format(time.RFC3339)}
+			if got := IsSessionValid(saved); got != (tt.want == nil) {
+				t.Fatalf("IsSessionValid = %v, want %v", got, tt.want == nil)
+			}
+			if tt.want == nil && session == nil {
+				t.Fatal("sessionFromToken returned no session")
+			}
+		})
+	}
+}
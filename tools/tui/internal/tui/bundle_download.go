@@ -0,0 +1,255 @@
+package tui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var ErrBundleChecksumMismatch = errors.New("bundle checksum mismatch")
+
+// VerifyChecksum compares the downloaded content with the digest from the
+// bundle metadata. A mismatch means a corrupted or tampered artifact, so it
+// must never be extracted.
+func (b *WorkflowBundle) VerifyChecksum() error {
+	sum := sha256.Sum256(b.Content)
+	actual := hex.EncodeToString(sum[:])
+	if actual != b.SHA256 {
+		return fmt.Errorf("%w: expected sha256 %s, got %s; the download is corrupted or was modified, refusing to extract it", ErrBundleChecksumMismatch, b.SHA256, actual)
+	}
+	return nil
+}
+
+// Interrupted downloads are resumed this many times within one sync; the
+// partial file also survives for the next sync of the same compiled version.
+const maxBundleResumes = 5
+
+// bundlePartial describes a partially downloaded artifact. The download URL
+// is usually pre-signed and changes every time, so the ETag and compiler
+// version decide whether the bytes on disk still belong to the same file.
+type bundlePartial struct {
+	CompilerVersion string `json:"compilerVersion"`
+	ETag            string `json:"etag"`
+	Total           int64  `json:"total"`
+}
+
+func bundlePartialPath(workflowID string) string {
+	return filepath.Join(sixflowHomeDir(), "downloads", slugify(workflowID)+".zip.part")
+}
+
+func loadBundlePartial(partPath, compilerVersion string) (int64, *bundlePartial) {
+	content, err := os.ReadFile(partPath + ".json")
+	if err != nil {
+		return 0, nil
+	}
+	var partial bundlePartial
+	if json.Unmarshal(content, &partial) != nil || partial.ETag == "" || partial.CompilerVersion != compilerVersion {
+		return 0, nil
+	}
+	info, err := os.Stat(partPath)
+	if err != nil {
+		return 0, nil
+	}
+	return info.Size(), &partial
+}
+
+func saveBundlePartial(partPath string, partial *bundlePartial) {
+	content, err := json.Marshal(partial)
+	if err != nil {
+		return
+	}
+	_ = os.WriteFile(partPath+".json", content, 0o600)
+}
+
+func removeBundlePartial(partPath string) {
+	_ = os.Remove(partPath)
+	_ = os.Remove(partPath + ".json")
+}
+
+// parseContentRange reads "bytes start-end/total"; total is -1 when the
+// server reports "*".
+func parseContentRange(header string) (start, total int64, ok bool) {
+	spec, found := strings.CutPrefix(strings.TrimSpace(header), "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rangePart, totalPart, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+	startPart, _, found := strings.Cut(rangePart, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(startPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	total = -1
+	if totalPart != "*" {
+		if total, err = strconv.ParseInt(totalPart, 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+	return start, total, true
+}
+
+// downloadBundleResumable fetches the compiled artifact into a partial file,
+// continuing with Range requests after interruptions. It returns the content
+// and the headers of the last response.
+func downloadBundleResumable(client *http.Client, downloadURL, workflowID, compilerVersion string) ([]byte, http.Header, error) {
+	partPath := bundlePartialPath(workflowID)
+	if err := os.MkdirAll(filepath.Dir(partPath), 0o700); err != nil {
+		return nil, nil, err
+	}
+	offset, partial := loadBundlePartial(partPath, compilerVersion)
+	if partial == nil {
+		removeBundlePartial(partPath)
+		partial = &bundlePartial{CompilerVersion: compilerVersion, Total: -1}
+	} else if offset > 0 {
+		notifyFrontend(fmt.Sprintf("Resuming bundle download at %s.", FormatBytes(offset)))
+	}
+
+	// Resumes must not outlive a CancelOperation, which would hand them a
+	// fresh context.
+	ctx := OperationContext()
+	var lastErr error
+	for attempt := 0; attempt <= maxBundleResumes; attempt++ {
+		if ctx.Err() != nil {
+			return nil, nil, ErrOperationCancelled
+		}
+		if attempt > 0 {
+			notifyFrontend(fmt.Sprintf("Bundle download interrupted at %s (%v); resuming...", FormatBytes(offset), lastErr))
+		}
+		header, done, err := fetchBundleRange(client, downloadURL, partPath, partial, &offset)
+		if err == nil && done {
+			content, readErr := os.ReadFile(partPath)
+			removeBundlePartial(partPath)
+			if readErr != nil {
+				return nil, nil, readErr
+			}
+			return content, header, nil
+		}
+		if err != nil {
+			var statusErr *bundleStatusError
+			if errors.As(err, &statusErr) {
+				return nil, nil, err
+			}
+			lastErr = err
+		}
+	}
+	return nil, nil, timeoutHint(lastErr, bundleTimeoutEnv)
+}
+
+type bundleStatusError struct {
+	status int
+}
+
+func (e *bundleStatusError) Error() string {
+	return fmt.Sprintf("failed to fetch compiled artifact zip (status %d)", e.status)
+}
+
+// fetchBundleRange performs one request, appending to the partial file from
+// *offset. done reports that the whole artifact is on disk.
+func fetchBundleRange(client *http.Client, downloadURL, partPath string, partial *bundlePartial, offset *int64) (http.Header, bool, error) {
+	req, err := http.NewRequest(http.MethodGet, downloadURL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Accept", "application/zip")
+	if *offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", *offset))
+		// If the artifact changed, the server answers 200 with the new file.
+		req.Header.Set("If-Range", partial.ETag)
+	}
+
+	resp, err := doFrontendRequest(client, req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		start, total, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != *offset {
+			// Unusable range answer: start over on the next attempt.
+			removeBundlePartial(partPath)
+			*offset = 0
+			return nil, false, errors.New("server returned an unexpected byte range")
+		}
+		if total >= 0 {
+			partial.Total = total
+		}
+		flags |= os.O_APPEND
+	case http.StatusRequestedRangeNotSatisfiable:
+		if partial.Total >= 0 && *offset == partial.Total {
+			return resp.Header, true, nil
+		}
+		removeBundlePartial(partPath)
+		*offset = 0
+		return nil, false, errors.New("saved partial download no longer matches the artifact")
+	default:
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, false, &bundleStatusError{status: resp.StatusCode}
+		}
+		// Full response: the server ignored Range or the artifact changed.
+		*offset = 0
+		flags |= os.O_TRUNC
+		partial.Total = resp.ContentLength
+	}
+	partial.ETag = resp.Header.Get("ETag")
+	if strings.HasPrefix(partial.ETag, "W/") {
+		// Weak validators cannot be used with If-Range.
+		partial.ETag = ""
+	}
+	if partial.ETag != "" {
+		saveBundlePartial(partPath, partial)
+	}
+
+	file, err := os.OpenFile(partPath, flags, 0o600)
+	if err != nil {
+		return nil, false, err
+	}
+	written, copyErr := io.Copy(file, resp.Body)
+	closeErr := file.Close()
+	*offset += written
+	if copyErr != nil {
+		if partial.ETag == "" {
+			// Without a validator the bytes cannot be resumed safely.
+			removeBundlePartial(partPath)
+			*offset = 0
+		}
+		return nil, false, copyErr
+	}
+	if closeErr != nil {
+		return nil, false, closeErr
+	}
+	if partial.Total >= 0 && *offset != partial.Total {
+		return nil, false, fmt.Errorf("received %d of %d bytes", *offset, partial.Total)
+	}
+	return resp.Header, true, nil
+}
+
+// FormatBytes renders a size with binary units, e.g. "1.5 MiB".
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for value := n / unit; value >= unit; value /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
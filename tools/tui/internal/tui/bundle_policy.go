@@ -0,0 +1,210 @@
+package tui
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type bundlePolicy struct {
+	RequiredProjectFiles  []string
+	RequiredWorkflowFiles []string
+	BlockedExtensions     map[string]bool
+	MaxEntries            int
+	MaxUncompressedBytes  uint64
+}
+
+var defaultBundlePolicy = bundlePolicy{
+	RequiredProjectFiles:  []string{"project.yaml"},
+	RequiredWorkflowFiles: []string{"workflow.yaml", "package.json", "main.ts"},
+	BlockedExtensions: map[string]bool{
+		".sh": true, ".bash": true, ".zsh": true, ".command": true,
+		".exe": true, ".bat": true, ".cmd": true, ".ps1": true, ".com": true, ".msi": true,
+		".bin": true, ".so": true, ".dylib": true, ".dll": true, ".app": true,
+	},
+	MaxEntries:           2000,
+	MaxUncompressedBytes: 512 << 20,
+}
+
+// BundleValidationError is returned when a downloaded bundle violates the
+// install policy. Violations holds one human-readable line per finding.
+type BundleValidationError struct {
+	Violations []string
+}
+
+func (e *BundleValidationError) Error() string {
+	if len(e.Violations) == 1 {
+		return "bundle rejected: " + e.Violations[0]
+	}
+	return fmt.Sprintf("bundle rejected: %d policy violation(s)", len(e.Violations))
+}
+
+var executableMagic = [][]byte{
+	{0x7f, 'E', 'L', 'F'},    // ELF
+	{'M', 'Z'},               // PE / DOS
+	{0xfe, 0xed, 0xfa, 0xce}, // Mach-O 32-bit
+	{0xfe, 0xed, 0xfa, 0xcf}, // Mach-O 64-bit
+	{0xce, 0xfa, 0xed, 0xfe}, // Mach-O 32-bit (reversed)
+	{0xcf, 0xfa, 0xed, 0xfe}, // Mach-O 64-bit (reversed)
+	{0xca, 0xfe, 0xba, 0xbe}, // Mach-O universal
+}
+
+var absoluteScriptPathPattern = regexp.MustCompile(`(^|[\s;&|('"=])(/[^\s;&|)'"]+|~/[^\s;&|)'"]*|[A-Za-z]:\\)`)
+
+func isExecutableContent(head []byte) bool {
+	for _, magic := range executableMagic {
+		if bytes.HasPrefix(head, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+func readEntryHead(f *zip.File, n int) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	head := make([]byte, n)
+	read, err := io.ReadFull(rc, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return head[:read], nil
+}
+
+func validatePackageJSONScripts(packageJSONPath string) []string {
+	raw, err := os.ReadFile(packageJSONPath)
+	if err != nil {
+		return []string{"package.json could not be read: " + err.Error()}
+	}
+
+	var pkg struct {
+		Scripts map[string]string `json:"scripts"`
+	}
+	if err := json.Unmarshal(raw, &pkg); err != nil {
+		return []string{"package.json is not valid JSON: " + err.Error()}
+	}
+
+	names := make([]string, 0, len(pkg.Scripts))
+	for name := range pkg.Scripts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	violations := []string{}
+	for _, name := range names {
+		if absoluteScriptPathPattern.MatchString(pkg.Scripts[name]) {
+			violations = append(violations, fmt.Sprintf("package.json script %q references an absolute path: %s", name, pkg.Scripts[name]))
+		}
+	}
+	return violations
+}
+
+// validateBundleArchive checks the zip entries against the policy before
+// anything is extracted, so an oversized or hostile archive never reaches the
+// disk. The sizes are the ones the headers claim; unzipToDir enforces the
+// limit on the bytes it actually writes.
+func validateBundleArchive(zipBytes []byte, policy bundlePolicy) error {
+	zr, err := zip.NewReader(bytes.NewReader(zipBytes), int64(len(zipBytes)))
+	if err != nil {
+		return err
+	}
+
+	violations := []string{}
+	if policy.MaxEntries > 0 && len(zr.File) > policy.MaxEntries {
+		violations = append(violations, fmt.Sprintf("bundle has %d entries (limit %d)", len(zr.File), policy.MaxEntries))
+	}
+
+	var total uint64
+	for _, f := range zr.File {
+		name := f.Name
+		total += f.UncompressedSize64
+
+		if strings.HasPrefix(name, "/") || filepath.IsAbs(name) || path.IsAbs(name) {
+			violations = append(violations, "absolute entry path: "+name)
+			continue
+		}
+		if cleaned := path.Clean(strings.ReplaceAll(name, "\\", "/")); cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+			violations = append(violations, "entry path escapes the bundle: "+name)
+			continue
+		}
+		mode := f.Mode()
+		if mode&os.ModeSymlink != 0 {
+			violations = append(violations, "symbolic link entry: "+name)
+			continue
+		}
+		if mode.IsDir() {
+			continue
+		}
+		if policy.BlockedExtensions[strings.ToLower(path.Ext(name))] {
+			violations = append(violations, "blocked file type: "+name)
+			continue
+		}
+		if mode&0o111 != 0 {
+			violations = append(violations, fmt.Sprintf("executable permission bits set (%s): %s", mode.Perm(), name))
+			continue
+		}
+		head, err := readEntryHead(f, 4)
+		if err != nil {
+			violations = append(violations, fmt.Sprintf("unreadable entry %s: %v", name, err))
+			continue
+		}
+		if isExecutableContent(head) {
+			violations = append(violations, "native executable content: "+name)
+		}
+	}
+	if policy.MaxUncompressedBytes > 0 && total > policy.MaxUncompressedBytes {
+		violations = append(violations, fmt.Sprintf("bundle expands to %d bytes (limit %d)", total, policy.MaxUncompressedBytes))
+	}
+
+	if len(violations) > 0 {
+		return &BundleValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// validateBundleTree checks the extracted project for the files the policy
+// requires and for package.json scripts that reach outside of it.
+func validateBundleTree(extractedDir string, policy bundlePolicy) error {
+	violations := []string{}
+	for _, name := range policy.RequiredProjectFiles {
+		if _, err := findFirstFile(extractedDir, name); err != nil {
+			violations = append(violations, "missing required file: "+name)
+		}
+	}
+
+	workflowYamlPath, err := findFirstFile(extractedDir, "workflow.yaml")
+	if err == nil {
+		workflowDir := filepath.Dir(workflowYamlPath)
+		for _, name := range policy.RequiredWorkflowFiles {
+			if name == "workflow.yaml" {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(workflowDir, name)); err != nil {
+				violations = append(violations, "missing required workflow file: "+name)
+			}
+		}
+		packageJSONPath := filepath.Join(workflowDir, "package.json")
+		if _, err := os.Stat(packageJSONPath); err == nil {
+			violations = append(violations, validatePackageJSONScripts(packageJSONPath)...)
+		}
+	} else {
+		violations = append(violations, "missing required file: workflow.yaml")
+	}
+
+	if len(violations) > 0 {
+		return &BundleValidationError{Violations: violations}
+	}
+	return nil
+}
@@ -0,0 +1,244 @@
+package tui
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type testZipEntry struct {
+	name    string
+	mode    os.FileMode
+	content string
+}
+
+func buildTestZip(t *testing.T, entries []testZipEntry) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate}
+		mode := entry.mode
+		if mode == 0 {
+			mode = 0o644
+		}
+		header.SetMode(mode)
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(entry.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func violationsOf(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var validationErr *BundleValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("error = %v, want *BundleValidationError", err)
+	}
+	return validationErr.Violations
+}
+
+func TestValidateBundleArchive(t *testing.T) {
+	smallPolicy := defaultBundlePolicy
+	smallPolicy.MaxEntries = 2
+	smallPolicy.MaxUncompressedBytes = 10
+
+	tests := []struct {
+		name    string
+		entries []testZipEntry
+		policy  bundlePolicy
+		want    string
+	}{
+		{
+			name: "clean bundle",
+			entries: []testZipEntry{
+				{name: "project/", mode: os.ModeDir | 0o755},
+				{name: "project/project.yaml", content: "name: x\n"},
+				{name: "project/wf/main.ts", content: "export {}\n"},
+			},
+			policy: defaultBundlePolicy,
+		},
+		{
+			name:    "absolute path",
+			entries: []testZipEntry{{name: "/etc/passwd", content: "x"}},
+			policy:  defaultBundlePolicy,
+			want:    "absolute entry path",
+		},
+		{
+			name:    "parent traversal",
+			entries: []testZipEntry{{name: "project/../../evil.ts", content: "x"}},
+			policy:  defaultBundlePolicy,
+			want:    "escapes the bundle",
+		},
+		{
+			name:    "backslash traversal",
+			entries: []testZipEntry{{name: `..\evil.ts`, content: "x"}},
+			policy:  defaultBundlePolicy,
+			want:    "escapes the bundle",
+		},
+		{
+			name:    "symlink",
+			entries: []testZipEntry{{name: "project/link", mode: os.ModeSymlink | 0o777, content: "/etc"}},
+			policy:  defaultBundlePolicy,
+			want:    "symbolic link",
+		},
+		{
+			name:    "blocked extension",
+			entries: []testZipEntry{{name: "project/install.SH", content: "echo hi"}},
+			policy:  defaultBundlePolicy,
+			want:    "blocked file type",
+		},
+		{
+			name:    "executable bits",
+			entries: []testZipEntry{{name: "project/tool.ts", mode: 0o755, content: "x"}},
+			policy:  defaultBundlePolicy,
+			want:    "executable permission bits",
+		},
+		{
+			name:    "native executable content",
+			entries: []testZipEntry{{name: "project/data.txt", content: "\x7fELF\x02\x01"}},
+			policy:  defaultBundlePolicy,
+			want:    "native executable content",
+		},
+		{
+			name: "too many entries",
+			entries: []testZipEntry{
+				{name: "a.ts", content: "1"},
+				{name: "b.ts", content: "2"},
+				{name: "c.ts", content: "3"},
+			},
+			policy: smallPolicy,
+			want:   "3 entries (limit 2)",
+		},
+		{
+			name:    "too large",
+			entries: []testZipEntry{{name: "a.ts", content: strings.Repeat("x", 11)}},
+			policy:  smallPolicy,
+			want:    "expands to 11 bytes (limit 10)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := violationsOf(t, validateBundleArchive(buildTestZip(t, tt.entries), tt.policy))
+			if tt.want == "" {
+				if len(violations) > 0 {
+					t.Fatalf("violations = %q, want none", violations)
+				}
+				return
+			}
+			for _, violation := range violations {
+				if strings.Contains(violation, tt.want) {
+					return
+				}
+			}
+			t.Fatalf("violations = %q, want one containing %q", violations, tt.want)
+		})
+	}
+}
+
+func TestValidateBundleArchiveRejectsNonZip(t *testing.T) {
+	err := validateBundleArchive([]byte("not a zip"), defaultBundlePolicy)
+	if err == nil {
+		t.Fatal("validateBundleArchive() error = nil, want zip error")
+	}
+	var validationErr *BundleValidationError
+	if errors.As(err, &validationErr) {
+		t.Fatalf("error = %v, want a zip read error", err)
+	}
+}
+
+func TestValidateBundleTree(t *testing.T) {
+	validPackage := `{"scripts": {"build": "tsc -p .", "start": "bun run main.ts"}}`
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  []string
+	}{
+		{
+			name: "complete project",
+			files: map[string]string{
+				"project.yaml":           "",
+				"wf/workflow.yaml":       "",
+				"wf/package.json":        validPackage,
+				"wf/main.ts":             "",
+				"wf/config.staging.json": "{}",
+			},
+		},
+		{
+			name: "missing project and workflow files",
+			files: map[string]string{
+				"wf/workflow.yaml": "",
+				"wf/package.json":  validPackage,
+			},
+			want: []string{"missing required file: project.yaml", "missing required workflow file: main.ts"},
+		},
+		{
+			name:  "no workflow.yaml",
+			files: map[string]string{"project.yaml": ""},
+			want:  []string{"missing required file: workflow.yaml"},
+		},
+		{
+			name: "script with absolute path",
+			files: map[string]string{
+				"project.yaml":     "",
+				"wf/workflow.yaml": "",
+				"wf/main.ts":       "",
+				"wf/package.json":  `{"scripts": {"postinstall": "node /tmp/steal.js", "build": "tsc"}}`,
+			},
+			want: []string{`package.json script "postinstall" references an absolute path: node /tmp/steal.js`},
+		},
+		{
+			name: "script with home path",
+			files: map[string]string{
+				"project.yaml":     "",
+				"wf/workflow.yaml": "",
+				"wf/main.ts":       "",
+				"wf/package.json":  `{"scripts": {"prepare": "cat ~/.ssh/id_rsa"}}`,
+			},
+			want: []string{`package.json script "prepare" references an absolute path: cat ~/.ssh/id_rsa`},
+		},
+		{
+			name: "invalid package.json",
+			files: map[string]string{
+				"project.yaml":     "",
+				"wf/workflow.yaml": "",
+				"wf/main.ts":       "",
+				"wf/package.json":  "{",
+			},
+			want: []string{"package.json is not valid JSON: unexpected end of JSON input"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				filePath := filepath.Join(dir, filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filePath, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			violations := violationsOf(t, validateBundleTree(dir, defaultBundlePolicy))
+			if strings.Join(violations, "\n") != strings.Join(tt.want, "\n") {
+				t.Fatalf("violations = %q, want %q", violations, tt.want)
+			}
+		})
+	}
+}
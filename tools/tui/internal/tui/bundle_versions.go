@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+var ErrBundleVersionsUnsupported = errors.New("frontend does not list bundle versions")
+
+// BundleVersion is one compiled artifact kept by the frontend. CreatedAt is in
+// milliseconds like FrontendWorkflow.UpdatedAt; Current marks the artifact a
+// plain sync downloads.
+type BundleVersion struct {
+	ID              string `json:"id"`
+	CompilerVersion string `json:"compilerVersion"`
+	CreatedAt       int64  `json:"createdAt"`
+	SHA256          string `json:"sha256"`
+	Size            int64  `json:"size"`
+	Current         bool   `json:"current"`
+}
+
+type bundleVersionsResponse struct {
+	Versions []BundleVersion `json:"versions"`
+	apiErrorBody
+}
+
+// FetchBundleVersions lists the compiled artifacts of a workflow, newest
+// first.
+func FetchBundleVersions(baseURL, token, workflowID string) ([]BundleVersion, error) {
+	endpoint := fmt.Sprintf("%s/api/tui/workflows/%s/bundles", NormalizeBaseURL(baseURL), url.PathEscape(workflowID))
+
+	client := newHTTPClient(listTimeout())
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := doFrontendRequest(client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var payload bundleVersionsResponse
+	_ = json.NewDecoder(resp.Body).Decode(&payload)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, ErrFrontendUnauthorized
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		return nil, ErrBundleVersionsUnsupported
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, payload.apiError(resp.StatusCode)
+	}
+	if payload.Versions == nil {
+		return nil, errors.New("invalid API response from /api/tui/workflows/{id}/bundles")
+	}
+	versions := payload.Versions
+	sort.SliceStable(versions, func(i, j int) bool { return versions[i].CreatedAt > versions[j].CreatedAt })
+	return versions, nil
+}
+
+// FindBundleVersion picks a version by id, by compiler version (the newest
+// build with it), or by time: a date or "2006-01-02 15:04" selects the newest
+// build created at or before then.
+func FindBundleVersion(versions []BundleVersion, ref string) (*BundleVersion, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, errors.New("empty bundle version")
+	}
+	for i := range versions {
+		if versions[i].ID == ref {
+			return &versions[i], nil
+		}
+	}
+	for i := range versions {
+		if strings.TrimPrefix(versions[i].CompilerVersion, "v") == strings.TrimPrefix(ref, "v") {
+			return &versions[i], nil
+		}
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04", "2006-01-02T15:04", "2006-01-02"} {
+		at, err := time.ParseInLocation(layout, ref, time.Local)
+		if err != nil {
+			continue
+		}
+		if layout == "2006-01-02" {
+			at = at.AddDate(0, 0, 1).Add(-time.Millisecond)
+		}
+		for i := range versions {
+			if versions[i].CreatedAt <= at.UnixMilli() {
+				return &versions[i], nil
+			}
+		}
+		return nil, fmt.Errorf("no bundle version was built before %s", ref)
+	}
+	return nil, fmt.Errorf("no bundle version matches %q (use an id, a compiler version or a date)", ref)
+}
@@ -0,0 +1,299 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var ErrConfigPushUnsupported = errors.New("frontend does not accept workflow config uploads")
+
+// configTargets pairs the workflow.yaml targets with the config names the
+// frontend uses.
+var configTargets = []struct {
+	target string
+	name   string
+}{
+	{"staging-settings", "staging"},
+	{"production-settings", "production"},
+}
+
+type workflowConfigResponse struct {
+	Configs map[string]json.RawMessage `json:"configs"`
+	apiErrorBody
+}
+
+type workflowConfigUpdateRequest struct {
+	Configs map[string]json.RawMessage `json:"configs"`
+}
+
+// FetchWorkflowConfigs returns the server copy of the workflow configs keyed
+// by "staging" and "production". A config the frontend never stored is
+// missing from the map.
+func FetchWorkflowConfigs(baseURL, token, workflowID string) (map[string]json.RawMessage, error) {
+	endpoint := fmt.Sprintf("%s/api/tui/workflows/%s/config", NormalizeBaseURL(baseURL), url.PathEscape(workflowID))
+
+	client := newHTTPClient(listTimeout())
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := doFrontendRequest(client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var payload workflowConfigResponse
+	_ = json.NewDecoder(resp.Body).Decode(&payload)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, ErrFrontendUnauthorized
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		return nil, ErrConfigPushUnsupported
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, payload.apiError(resp.StatusCode)
+	}
+	if payload.Configs == nil {
+		payload.Configs = map[string]json.RawMessage{}
+	}
+	return payload.Configs, nil
+}
+
+// uploadWorkflowConfigs replaces the given configs on the frontend. PUT of
+// the full document is idempotent, so the request is retried like a read.
+func uploadWorkflowConfigs(baseURL, token, workflowID string, configs map[string]json.RawMessage) error {
+	endpoint := fmt.Sprintf("%s/api/tui/workflows/%s/config", NormalizeBaseURL(baseURL), url.PathEscape(workflowID))
+	body, err := json.Marshal(workflowConfigUpdateRequest{Configs: configs})
+	if err != nil {
+		return err
+	}
+
+	client := newHTTPClient(listTimeout())
+	req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := doFrontendRequest(client, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result apiErrorBody
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return ErrFrontendUnauthorized
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		return ErrConfigPushUnsupported
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return result.apiError(resp.StatusCode)
+	}
+	return nil
+}
+
+// ConfigChange lists the keys of one config that differ from the server
+// copy, as dotted paths.
+type ConfigChange struct {
+	Name    string
+	Path    string
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+func (c ConfigChange) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+type ConfigPushResult struct {
+	Logs    []string
+	Changes []ConfigChange
+	Pushed  bool
+}
+
+// HasChanges reports whether any local config differs from the frontend.
+func (r *ConfigPushResult) HasChanges() bool {
+	for _, change := range r.Changes {
+		if !change.Empty() {
+			return true
+		}
+	}
+	return false
+}
+
+type localConfig struct {
+	path    string
+	content json.RawMessage
+	value   any
+}
+
+// readLocalConfigs loads the config file of each target named in the synced
+// workflow.yaml.
+func readLocalConfigs(workflowID, workflowName string) (map[string]localConfig, error) {
+	workflowDir := localWorkflowDir(workflowID, workflowName)
+	raw, err := os.ReadFile(filepath.Join(workflowDir, "workflow.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("local workflow project not found. Run sync to local first")
+		}
+		return nil, err
+	}
+	var data workflowYAML
+	if err := yaml.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("parse workflow.yaml: %w", err)
+	}
+
+	configs := map[string]localConfig{}
+	for _, target := range configTargets {
+		configPath := strings.TrimSpace(data[target.target].WorkflowArtifacts.ConfigPath)
+		if configPath == "" {
+			continue
+		}
+		path := filepath.Join(workflowDir, strings.TrimPrefix(configPath, "./"))
+		content, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		var value any
+		if err := json.Unmarshal(content, &value); err != nil {
+			return nil, fmt.Errorf("%s is not valid JSON: %w", filepath.Base(path), err)
+		}
+		configs[target.name] = localConfig{path: path, content: bytes.TrimSpace(content), value: value}
+	}
+	return configs, nil
+}
+
+// diffConfigValues walks both documents and records keys that only exist
+// locally (added), only on the server (removed) or hold different values.
+func diffConfigValues(prefix string, local, remote any, change *ConfigChange) {
+	localMap, localIsMap := local.(map[string]any)
+	remoteMap, remoteIsMap := remote.(map[string]any)
+	if !localIsMap || !remoteIsMap {
+		if !reflect.DeepEqual(local, remote) {
+			change.Changed = append(change.Changed, configKey(prefix))
+		}
+		return
+	}
+	for key, value := range localMap {
+		other, ok := remoteMap[key]
+		if !ok {
+			change.Added = append(change.Added, joinConfigKey(prefix, key))
+			continue
+		}
+		diffConfigValues(joinConfigKey(prefix, key), value, other, change)
+	}
+	for key := range remoteMap {
+		if _, ok := localMap[key]; !ok {
+			change.Removed = append(change.Removed, joinConfigKey(prefix, key))
+		}
+	}
+}
+
+func joinConfigKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func configKey(path string) string {
+	if path == "" {
+		return "(whole config)"
+	}
+	return path
+}
+
+// PushWorkflowConfig compares the local config.staging.json and
+// config.production.json with the frontend copy and, when apply is set,
+// uploads the configs that changed. Without apply it only reports the diff.
+func PushWorkflowConfig(baseURL, token, workflowID, workflowName string, apply bool) (*ConfigPushResult, error) {
+	local, err := readLocalConfigs(workflowID, workflowName)
+	if err != nil {
+		return nil, err
+	}
+	if len(local) == 0 {
+		return nil, errors.New("no config files found in the local workflow project")
+	}
+	remote, err := FetchWorkflowConfigs(baseURL, token, workflowID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ConfigPushResult{}
+	upload := map[string]json.RawMessage{}
+	for _, target := range configTargets {
+		config, ok := local[target.name]
+		if !ok {
+			continue
+		}
+		change := ConfigChange{Name: target.name, Path: config.path}
+		var serverValue any = map[string]any{}
+		if serverCopy := bytes.TrimSpace(remote[target.name]); len(serverCopy) > 0 && string(serverCopy) != "null" {
+			if err := json.Unmarshal(serverCopy, &serverValue); err != nil {
+				return nil, fmt.Errorf("frontend returned an invalid %s config: %w", target.name, err)
+			}
+		}
+		diffConfigValues("", config.value, serverValue, &change)
+		sort.Strings(change.Added)
+		sort.Strings(change.Removed)
+		sort.Strings(change.Changed)
+		result.Changes = append(result.Changes, change)
+
+		if change.Empty() {
+			result.Logs = append(result.Logs, fmt.Sprintf("%s: matches the frontend.", filepath.Base(config.path)))
+			continue
+		}
+		upload[target.name] = config.content
+		result.Logs = append(result.Logs, fmt.Sprintf("%s: %d added, %d removed, %d changed.",
+			filepath.Base(config.path), len(change.Added), len(change.Removed), len(change.Changed)))
+		for _, key := range change.Added {
+			result.Logs = append(result.Logs, "  + "+key)
+		}
+		for _, key := range change.Removed {
+			result.Logs = append(result.Logs, "  - "+key)
+		}
+		for _, key := range change.Changed {
+			result.Logs = append(result.Logs, "  ~ "+key)
+		}
+	}
+
+	if !apply || len(upload) == 0 {
+		return result, nil
+	}
+	if err := uploadWorkflowConfigs(baseURL, token, workflowID, upload); err != nil {
+		return result, err
+	}
+	result.Pushed = true
+	names := make([]string, 0, len(upload))
+	for _, target := range configTargets {
+		if _, ok := upload[target.name]; ok {
+			names = append(names, target.name)
+		}
+	}
+	result.Logs = append(result.Logs, "Uploaded "+strings.Join(names, " and ")+" config to the frontend.")
+	return result, nil
+}
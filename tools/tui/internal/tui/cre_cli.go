@@ -1,10 +1,12 @@
 package tui
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"os/exec"
@@ -12,6 +14,8 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -21,18 +25,23 @@ type SecretsCommandResult struct {
 }
 
 type CREWhoAmIResult struct {
-	Identity string
-	Raw      string
+	Identity     string
+	Organization string
+	Raw          string
 }
 
 type SimulateCommandResult struct {
 	Logs []string
+	// Result is the workflow result the simulation printed, if any.
+	Result string
 }
 
 type LocalSecretEntry struct {
 	ID       string
 	EnvVar   string
 	HasValue bool
+	// RotatedAt is when the value last changed, zero when unknown.
+	RotatedAt time.Time
 }
 
 type LocalSecretsListResult struct {
@@ -78,6 +87,15 @@ type secretsManifest struct {
 
 var emailLinePattern = regexp.MustCompile(`(?i)Email:\s*([^\s|]+@[^\s|]+)`)
 
+var organizationLinePattern = regexp.MustCompile(`(?im)Organi[sz]ation(?: Name)?:\s*([^│|\n]+)`)
+
+func parseCREWhoAmIOrganization(output string) string {
+	if match := organizationLinePattern.FindStringSubmatch(output); len(match) > 1 {
+		return strings.TrimSpace(match[1])
+	}
+	return ""
+}
+
 func parseCREWhoAmIIdentity(output string) string {
 	trimmed := strings.TrimSpace(output)
 	if trimmed == "" {
@@ -104,8 +122,54 @@ func parseCREWhoAmIIdentity(output string) string {
 	return ""
 }
 
+var (
+	ErrCRECLINotFound       = errors.New("cre CLI not found in PATH or ~/.6flow/bin. Install it from https://docs.chain.link/cre")
+	ErrSecretsNotConfigured = errors.New("cannot simulate until all secrets are configured")
+)
+
+// SecretsPreflightError marks a failure to locate or validate the local
+// project files that secrets and simulation depend on.
+type SecretsPreflightError struct {
+	Err error
+}
+
+func (e *SecretsPreflightError) Error() string { return e.Err.Error() }
+
+func (e *SecretsPreflightError) Unwrap() error { return e.Err }
+
+// CREBinaryError reports a cre path from SIXFLOW_CRE_BIN or the config file
+// that cannot be run. Installing the CLI would not help, so it is kept apart
+// from the plain not-found message.
+type CREBinaryError struct {
+	Path   string
+	Source string
+}
+
+func (e *CREBinaryError) Error() string {
+	return fmt.Sprintf("cre CLI %s set in %s is not executable", e.Path, e.Source)
+}
+
+func (e *CREBinaryError) Unwrap() error {
+	return ErrCRECLINotFound
+}
+
+func requireCRECLI() error {
+	binary, source := ResolveCREBinary()
+	if _, err := exec.LookPath(binary); err != nil {
+		if source == creBinEnv || source == tuiConfigPath() {
+			return &CREBinaryError{Path: binary, Source: source}
+		}
+		return ErrCRECLINotFound
+	}
+	return nil
+}
+
 func GetCREWhoAmI() (*CREWhoAmIResult, error) {
-	cmd := exec.Command("cre", "whoami")
+	if err := requireCRECLI(); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(CREBinary(), "whoami")
+	cmd.Env = CommandEnv(nil)
 	output, err := cmd.CombinedOutput()
 	raw := strings.TrimSpace(string(output))
 	if err != nil {
@@ -119,11 +183,23 @@ func GetCREWhoAmI() (*CREWhoAmIResult, error) {
 		identity = "logged-in"
 	}
 	return &CREWhoAmIResult{
-		Identity: identity,
-		Raw:      raw,
+		Identity:     identity,
+		Organization: parseCREWhoAmIOrganization(raw),
+		Raw:          raw,
 	}, nil
 }
 
+// CRELoginCommand returns `cre auth login` ready to run attached to the
+// user's terminal, since it prompts and may open a browser.
+func CRELoginCommand() (*exec.Cmd, error) {
+	if err := requireCRECLI(); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(CREBinary(), "auth", "login")
+	cmd.Env = CommandEnv(nil)
+	return cmd, nil
+}
+
 func splitOutputLines(raw string) []string {
 	trimmed := strings.TrimSpace(raw)
 	if trimmed == "" {
@@ -141,11 +217,40 @@ func splitOutputLines(raw string) []string {
 	return out
 }
 
-func runCommand(cwd string, name string, args ...string) ([]string, error) {
-	cmd := exec.Command(name, args...)
+// StreamTag is the console prefix for a line of tool output, e.g. "[cre] ".
+// Lines from stderr get "[cre:err] " so real errors stand out from the
+// progress chatter on stdout.
+func StreamTag(tool string, stderr bool) string {
+	if stderr {
+		return "[" + tool + ":err] "
+	}
+	return "[" + tool + "] "
+}
+
+// runCommandStream runs a command and collects its stdout and stderr lines in
+// the order they arrive. onLine, when set, sees every non-empty output line
+// as soon as it is written, tagged with the stream it came from, so long
+// installs and simulations show progress instead of all output at the end.
+// The command is killed once timeout passes.
+func runCommandStream(cwd string, extraEnv []string, stdin io.Reader, timeout CommandTimeout, onLine func(line string, stderr bool), name string, args ...string) ([]string, error) {
+	if DryRun() {
+		dryRunNote("would run %s", DescribeCommand(cwd, extraEnv, stdin != nil, name, args))
+		return nil, nil
+	}
+	ctx, cancel := timeout.Context()
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.WaitDelay = commandWaitDelay
 	cmd.Dir = cwd
-	out, err := cmd.CombinedOutput()
-	lines := splitOutputLines(string(out))
+	cmd.Env = CommandEnv(extraEnv)
+	cmd.Stdin = stdin
+	output := &commandOutput{onLine: onLine}
+	cmd.Stdout = &lineWriter{output: output}
+	cmd.Stderr = &lineWriter{output: output, stderr: true}
+	err := timeout.Err(ctx, commandLabel(name, args), cmd.Run())
+	cmd.Stdout.(*lineWriter).flush()
+	cmd.Stderr.(*lineWriter).flush()
+	lines := output.lines
 	if err != nil {
 		if len(lines) == 0 {
 			lines = []string{err.Error()}
@@ -155,19 +260,55 @@ func runCommand(cwd string, name string, args ...string) ([]string, error) {
 	return lines, nil
 }
 
-func runCommandWithStdin(cwd string, stdinData string, name string, args ...string) ([]string, error) {
-	cmd := exec.Command(name, args...)
-	cmd.Dir = cwd
-	cmd.Stdin = strings.NewReader(stdinData)
-	out, err := cmd.CombinedOutput()
-	lines := splitOutputLines(string(out))
-	if err != nil {
-		if len(lines) == 0 {
-			lines = []string{err.Error()}
+// commandOutput gathers the lines of both streams of one command. exec
+// copies each stream in its own goroutine, so emit is serialized.
+type commandOutput struct {
+	mu     sync.Mutex
+	onLine func(line string, stderr bool)
+	lines  []string
+}
+
+func (o *commandOutput) emit(line string, stderr bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.lines = append(o.lines, line)
+	if o.onLine != nil {
+		o.onLine(line, stderr)
+	}
+}
+
+// lineWriter splits one output stream into trimmed, non-empty lines.
+type lineWriter struct {
+	output  *commandOutput
+	stderr  bool
+	partial []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.partial = append(w.partial, p...)
+	for {
+		idx := bytes.IndexByte(w.partial, '\n')
+		if idx < 0 {
+			return len(p), nil
 		}
-		return lines, err
+		w.emit(string(w.partial[:idx]))
+		w.partial = w.partial[idx+1:]
 	}
-	return lines, nil
+}
+
+func (w *lineWriter) flush() {
+	if len(w.partial) > 0 {
+		w.emit(string(w.partial))
+		w.partial = nil
+	}
+}
+
+func (w *lineWriter) emit(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	w.output.emit(line, w.stderr)
 }
 
 func localWorkflowProjectRoot(workflowID, workflowName string) string {
@@ -180,48 +321,86 @@ func localWorkflowDir(workflowID, workflowName string) string {
 }
 
 func readDotEnvValue(dotEnvPath, key string) (string, error) {
-	raw, err := os.ReadFile(dotEnvPath)
+	raw, err := readDotEnvContent(dotEnvPath)
 	if err != nil {
 		return "", err
 	}
 
 	lines := strings.Split(string(raw), "\n")
 	prefix := key + "="
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
 		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
+		span := dotEnvEntryLines(lines[i:])
 		if strings.HasPrefix(line, prefix) {
-			return strings.TrimSpace(strings.TrimPrefix(line, prefix)), nil
+			value := strings.TrimPrefix(line, prefix)
+			if span > 1 {
+				value = strings.TrimPrefix(strings.TrimSpace(strings.Join(lines[i:i+span], "\n")), prefix)
+			}
+			if decoded, err := decodeDotEnvValue(value); err == nil {
+				return decoded, nil
+			}
+			return strings.TrimSpace(value), nil
 		}
+		i += span - 1
 	}
 
 	return "", nil
 }
 
+// lockDotEnv serializes read-modify-write cycles on a workflow .env, its
+// .enc and its age sidecar across TUI instances, headless runs and watch-mode
+// resyncs of the project.
+func lockDotEnv(dotEnvPath string) (func(), error) {
+	if DryRun() {
+		return func() {}, nil
+	}
+	projectRoot := filepath.Dir(filepath.Dir(dotEnvPath))
+	return acquireFileLock(projectSyncLockPath(projectRoot))
+}
+
 func setDotEnvValue(dotEnvPath, key, value string) error {
-	raw, _ := os.ReadFile(dotEnvPath)
+	if err := validateDotEnvEntry(key, value); err != nil {
+		return err
+	}
+	release, err := lockDotEnv(dotEnvPath)
+	if err != nil {
+		return err
+	}
+	defer release()
+	raw, _ := readDotEnvContent(dotEnvPath)
 	lines := []string{}
 	if len(raw) > 0 {
 		lines = strings.Split(strings.TrimRight(string(raw), "\n"), "\n")
 	}
 
+	previous, _ := readDotEnvValue(dotEnvPath, key)
 	prefix := key + "="
 	updated := false
-	for i, line := range lines {
-		trimmed := strings.TrimSpace(line)
+	out := make([]string, 0, len(lines)+3)
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		span := 1
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			span = dotEnvEntryLines(lines[i:])
+		}
 		if strings.HasPrefix(trimmed, prefix) {
-			lines[i] = prefix + value
+			out = append(out, prefix+encodeDotEnvValue(value))
 			updated = true
+		} else {
+			out = append(out, lines[i:i+span]...)
 		}
+		i += span - 1
 	}
+	lines = out
 	if !updated {
 		if len(lines) > 0 {
 			lines = append(lines, "")
 		}
 		lines = append(lines, "# Required for CRE simulation secrets")
-		lines = append(lines, prefix+value)
+		lines = append(lines, prefix+encodeDotEnvValue(value))
 	}
 
 	if err := ensureParent(dotEnvPath); err != nil {
@@ -231,11 +410,25 @@ func setDotEnvValue(dotEnvPath, key, value string) error {
 	if !strings.HasSuffix(content, "\n") {
 		content += "\n"
 	}
-	return os.WriteFile(dotEnvPath, []byte(content), 0o600)
+	if err := writeDotEnvContent(dotEnvPath, []byte(content)); err != nil {
+		return err
+	}
+	switch {
+	case strings.TrimSpace(value) == "":
+		_ = setSecretRotatedAt(dotEnvPath, key, time.Time{})
+	case !updated || previous != value:
+		_ = setSecretRotatedAt(dotEnvPath, key, time.Now())
+	}
+	return nil
 }
 
 func removeDotEnvValue(dotEnvPath, key string) error {
-	raw, err := os.ReadFile(dotEnvPath)
+	release, err := lockDotEnv(dotEnvPath)
+	if err != nil {
+		return err
+	}
+	defer release()
+	raw, err := readDotEnvContent(dotEnvPath)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil
@@ -246,19 +439,27 @@ func removeDotEnvValue(dotEnvPath, key string) error {
 	lines := strings.Split(strings.TrimRight(string(raw), "\n"), "\n")
 	prefix := key + "="
 	out := make([]string, 0, len(lines))
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, prefix) {
-			continue
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		span := 1
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			span = dotEnvEntryLines(lines[i:])
 		}
-		out = append(out, line)
+		if !strings.HasPrefix(trimmed, prefix) {
+			out = append(out, lines[i:i+span]...)
+		}
+		i += span - 1
 	}
 
 	content := strings.Join(out, "\n")
 	if content != "" && !strings.HasSuffix(content, "\n") {
 		content += "\n"
 	}
-	return os.WriteFile(dotEnvPath, []byte(content), 0o600)
+	if err := writeDotEnvContent(dotEnvPath, []byte(content)); err != nil {
+		return err
+	}
+	_ = setSecretRotatedAt(dotEnvPath, key, time.Time{})
+	return nil
 }
 
 func isValidPrivateKey(value string) bool {
@@ -286,42 +487,102 @@ func workflowHasTarget(workflowYamlPath, target string) (bool, error) {
 	return ok, nil
 }
 
+// ListWorkflowTargets returns the targets defined in the synced workflow.yaml:
+// staging-settings and production-settings first, then any others by name.
+func ListWorkflowTargets(workflowID, workflowName string) ([]string, error) {
+	raw, err := os.ReadFile(filepath.Join(localWorkflowDir(workflowID, workflowName), "workflow.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	var parsed map[string]any
+	if err := yaml.Unmarshal(raw, &parsed); err != nil {
+		return nil, err
+	}
+	rank := func(target string) int {
+		switch target {
+		case "staging-settings":
+			return 0
+		case "production-settings":
+			return 1
+		}
+		return 2
+	}
+	targets := make([]string, 0, len(parsed))
+	for target := range parsed {
+		targets = append(targets, target)
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if rank(targets[i]) != rank(targets[j]) {
+			return rank(targets[i]) < rank(targets[j])
+		}
+		return targets[i] < targets[j]
+	})
+	return targets, nil
+}
+
+func preflightError(err error) error {
+	return &SecretsPreflightError{Err: err}
+}
+
 func preflightWorkflowSecrets(workflowID, workflowName, target string) (projectRoot string, secretsYamlPath string, dotEnvPath string, logs []string, err error) {
 	projectRoot = localWorkflowProjectRoot(workflowID, workflowName)
 	workflowDir := localWorkflowDir(workflowID, workflowName)
 	workflowYamlPath := filepath.Join(workflowDir, "workflow.yaml")
 	projectYamlPath := filepath.Join(projectRoot, "project.yaml")
 	secretsYamlPath = filepath.Join(projectRoot, "secrets.yaml")
-	dotEnvPath = filepath.Join(workflowDir, ".env")
+	dotEnvPath = workflowDotEnvPath(workflowDir, target)
 
 	if _, err := os.Stat(projectRoot); err != nil {
 		if os.IsNotExist(err) {
-			return "", "", "", nil, errors.New("local workflow project not found. Run sync to local first")
+			return "", "", "", nil, preflightError(errors.New("local workflow project not found. Run sync to local first"))
 		}
-		return "", "", "", nil, err
+		return "", "", "", nil, preflightError(err)
 	}
 	if _, err := os.Stat(projectYamlPath); err != nil {
-		return "", "", "", nil, errors.New("missing project.yaml in synced workflow project")
+		return "", "", "", nil, preflightError(errors.New("missing project.yaml in synced workflow project"))
 	}
 	if _, err := os.Stat(secretsYamlPath); err != nil {
-		return "", "", "", nil, errors.New("missing secrets.yaml in synced workflow project")
+		return "", "", "", nil, preflightError(errors.New("missing secrets.yaml in synced workflow project"))
 	}
 	if _, err := os.Stat(workflowYamlPath); err != nil {
-		return "", "", "", nil, errors.New("missing workflow.yaml in synced workflow directory")
+		return "", "", "", nil, preflightError(errors.New("missing workflow.yaml in synced workflow directory"))
 	}
 
 	hasTarget, err := workflowHasTarget(workflowYamlPath, target)
 	if err != nil {
-		return "", "", "", nil, err
+		return "", "", "", nil, preflightError(err)
 	}
 	if !hasTarget {
-		return "", "", "", nil, fmt.Errorf("workflow.yaml does not define target %q", target)
+		return "", "", "", nil, preflightError(fmt.Errorf("workflow.yaml does not define target %q", target))
+	}
+
+	if err := requireDotEnvUnlocked(dotEnvPath); err != nil {
+		return "", "", "", nil, preflightError(err)
 	}
 
+	mode := fmt.Sprintf("secrets mode: local simulation (%s + secrets.yaml)", filepath.Base(dotEnvPath))
+	if isDotEnvEncrypted(dotEnvPath) {
+		mode = fmt.Sprintf("secrets mode: local simulation (encrypted %s + secrets.yaml)", filepath.Base(encryptedDotEnvPath(dotEnvPath)))
+	}
 	logs = []string{
 		"project: " + projectRoot,
 		"target: " + target,
-		"secrets mode: local simulation (.env + secrets.yaml)",
+		mode,
+	}
+	logs = append(logs, secretFileGitWarnings(projectRoot)...)
+	return projectRoot, secretsYamlPath, dotEnvPath, logs, nil
+}
+
+// preflightWorkflowSecretsForWrite is preflightWorkflowSecrets for commands
+// that change the .env of target: a legacy .env is first migrated into it.
+func preflightWorkflowSecretsForWrite(workflowID, workflowName, target string) (projectRoot string, secretsYamlPath string, dotEnvPath string, logs []string, err error) {
+	projectRoot, secretsYamlPath, _, logs, err = preflightWorkflowSecrets(workflowID, workflowName, target)
+	if err != nil {
+		return "", "", "", nil, err
+	}
+	dotEnvPath, err = migrateWorkflowDotEnv(localWorkflowDir(workflowID, workflowName), target)
+	if err != nil {
+		return "", "", "", nil, preflightError(err)
 	}
 	return projectRoot, secretsYamlPath, dotEnvPath, logs, nil
 }
@@ -334,6 +595,8 @@ func ensurePrivateKeyConfigured(dotEnvPath string) (bool, string, error) {
 
 	if envValue, err := readDotEnvValue(dotEnvPath, "CRE_ETH_PRIVATE_KEY"); err == nil && isValidPrivateKey(envValue) {
 		return true, "CRE_ETH_PRIVATE_KEY found in workflow .env.", nil
+	} else if err == nil && IsSecretReference(envValue) {
+		return true, "CRE_ETH_PRIVATE_KEY is a secret reference in workflow .env; it is resolved for each run.", nil
 	}
 
 	return false, "CRE_ETH_PRIVATE_KEY is not configured. Use Secrets -> UPDATE VALUE in the TUI.", nil
@@ -408,7 +671,7 @@ func setProjectRPC(projectYamlPath, target, stagingRPCURL string) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(projectYamlPath, updated, 0o644)
+	return writeProjectFile(projectYamlPath, updated, 0o644)
 }
 
 func normalizeRPCURL(raw string) (string, error) {
@@ -496,7 +759,7 @@ func setProjectTargetRPC(projectYamlPath, target, chainName, rpcURL string) erro
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(projectYamlPath, updatedYAML, 0o644)
+	return writeProjectFile(projectYamlPath, updatedYAML, 0o644)
 }
 
 func ListLocalVariableOptions(workflowID, workflowName, target string) (*LocalVariableListResult, error) {
@@ -514,16 +777,22 @@ func ListLocalVariableOptions(workflowID, workflowName, target string) (*LocalVa
 	entries := []LocalVariableEntry{}
 	privateKey, _ := readDotEnvValue(dotEnvPath, "CRE_ETH_PRIVATE_KEY")
 	privateKey = strings.TrimSpace(privateKey)
-	if !isValidPrivateKey(privateKey) {
+	if !isValidPrivateKey(privateKey) && !IsSecretReference(privateKey) {
 		privateKey = demoPrivateKeyForProject(workflowID)
 	}
+	privateKeyDescription := "System private key for simulation"
+	if HasWorkflowKeystore(workflowID, workflowName) {
+		privateKeyDescription = "Encrypted in keystore (updating writes a plaintext .env value)"
+	} else if age := SecretAgeLabel(loadSecretsMeta(dotEnvPath).rotatedAt("CRE_ETH_PRIVATE_KEY")); age != "" {
+		privateKeyDescription += " · " + age
+	}
 	entries = append(entries, LocalVariableEntry{
 		Section:      "system",
 		Kind:         "private_key",
 		ID:           "CRE_ETH_PRIVATE_KEY",
 		Key:          "CRE_ETH_PRIVATE_KEY",
 		Label:        "CRE_ETH_PRIVATE_KEY",
-		Description:  "System private key for simulation",
+		Description:  privateKeyDescription,
 		CurrentValue: privateKey,
 	})
 
@@ -566,6 +835,9 @@ func ListLocalVariableOptions(workflowID, workflowName, target string) (*LocalVa
 		if strings.TrimSpace(entry.EnvVar) != "" {
 			desc = fmt.Sprintf("%s (%s)", entry.EnvVar, status)
 		}
+		if age := SecretAgeLabel(entry.RotatedAt); age != "" {
+			desc += " · " + age
+		}
 		entries = append(entries, LocalVariableEntry{
 			Section:      "environment",
 			Kind:         "secret_env",
@@ -587,7 +859,7 @@ func UpdateLocalVariable(workflowID, workflowName, target, kind, key, value stri
 	logs := []string{}
 	appendLog := func(msg string) { logs = append(logs, msg) }
 
-	projectRoot, secretsYamlPath, dotEnvPath, preflightLogs, err := preflightWorkflowSecrets(workflowID, workflowName, target)
+	projectRoot, secretsYamlPath, dotEnvPath, preflightLogs, err := preflightWorkflowSecretsForWrite(workflowID, workflowName, target)
 	if err != nil {
 		return nil, err
 	}
@@ -599,11 +871,14 @@ func UpdateLocalVariable(workflowID, workflowName, target, kind, key, value stri
 	if value == "" {
 		return &SecretsCommandResult{Logs: logs}, errors.New("value is required")
 	}
+	if err := ValidateSecretValue(value); err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
 
 	switch strings.TrimSpace(kind) {
 	case "private_key":
-		if !isValidPrivateKey(value) {
-			return &SecretsCommandResult{Logs: logs}, errors.New("invalid private key format (expected 64 hex chars, optional 0x)")
+		if !isValidPrivateKey(value) && !IsSecretReference(value) {
+			return &SecretsCommandResult{Logs: logs}, errors.New("invalid private key format (expected 64 hex chars, optional 0x, or an op:// or keychain:// reference)")
 		}
 		normalizedKey := value
 		if strings.HasPrefix(normalizedKey, "0x") {
@@ -651,7 +926,11 @@ func UpdateLocalVariable(workflowID, workflowName, target, kind, key, value stri
 		if envVar == "" {
 			return &SecretsCommandResult{Logs: logs}, fmt.Errorf("secret %q has no env var mapping", secretID)
 		}
-		if err := setDotEnvValue(dotEnvPath, envVar, value); err != nil {
+		stored, err := storeSecretValue(workflowName, envVar, value, appendLog)
+		if err != nil {
+			return &SecretsCommandResult{Logs: logs}, err
+		}
+		if err := setDotEnvValue(dotEnvPath, envVar, stored); err != nil {
 			return &SecretsCommandResult{Logs: logs}, err
 		}
 		appendLog(fmt.Sprintf("Updated secret value for %s in .env", secretID))
@@ -674,7 +953,7 @@ func SaveWorkflowSecretsSetup(workflowID, workflowName, target, privateKey, rpcU
 	logs := []string{}
 	appendLog := func(msg string) { logs = append(logs, msg) }
 
-	projectRoot, _, dotEnvPath, preflightLogs, err := preflightWorkflowSecrets(workflowID, workflowName, target)
+	projectRoot, _, dotEnvPath, preflightLogs, err := preflightWorkflowSecretsForWrite(workflowID, workflowName, target)
 	if err != nil {
 		return nil, err
 	}
@@ -713,7 +992,7 @@ func SaveWorkflowSecretsSetup(workflowID, workflowName, target, privateKey, rpcU
 }
 
 func IsWorkflowSecretsSetupReady(workflowID, workflowName, target string) (bool, error) {
-	dotEnvPath := filepath.Join(localWorkflowDir(workflowID, workflowName), ".env")
+	dotEnvPath := workflowDotEnvPath(localWorkflowDir(workflowID, workflowName), target)
 	privateKeyConfigured, _, err := ensurePrivateKeyConfigured(dotEnvPath)
 	if err != nil {
 		return false, err
@@ -744,7 +1023,7 @@ func saveSecretsManifest(secretsYamlPath string, manifest *secretsManifest) erro
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(secretsYamlPath, updated, 0o644)
+	return writeProjectFile(secretsYamlPath, updated, 0o644)
 }
 
 func normalizeSecretID(secretID string) string {
@@ -766,7 +1045,7 @@ func resolveSecretByID(manifest *secretsManifest, secretID string) (string, []st
 }
 
 func defaultEnvVarForSecret(secretID string) string {
-	raw := strings.TrimSpace(secretID)
+	raw := strings.ToUpper(strings.TrimSpace(secretID))
 	if raw == "" {
 		raw = "SECRET"
 	}
@@ -780,6 +1059,7 @@ func listLocalSecretEntries(manifest *secretsManifest, dotEnvPath string) []Loca
 	}
 	sort.Strings(ids)
 
+	meta := loadSecretsMeta(dotEnvPath)
 	entries := make([]LocalSecretEntry, 0, len(ids))
 	for _, id := range ids {
 		envVar := ""
@@ -790,11 +1070,15 @@ func listLocalSecretEntries(manifest *secretsManifest, dotEnvPath string) []Loca
 		if envVar != "" {
 			value, _ = readDotEnvValue(dotEnvPath, envVar)
 		}
-		entries = append(entries, LocalSecretEntry{
+		entry := LocalSecretEntry{
 			ID:       id,
 			EnvVar:   envVar,
 			HasValue: strings.TrimSpace(value) != "",
-		})
+		}
+		if entry.HasValue {
+			entry.RotatedAt = meta.rotatedAt(envVar)
+		}
+		entries = append(entries, entry)
 	}
 	return entries
 }
@@ -837,6 +1121,30 @@ func InspectLocalSecrets(workflowID, workflowName, target string) (*SecretsComma
 		return &SecretsCommandResult{Logs: logs}, err
 	}
 
+	uses, scanErr := scanSecretUses(filepath.Dir(dotEnvPath))
+	used := map[string]bool{}
+	undeclared := []SecretUse{}
+	for _, use := range uses {
+		if id, _, ok := resolveSecretByID(manifest, use.ID); ok {
+			used[id] = true
+		} else {
+			undeclared = append(undeclared, use)
+		}
+	}
+	defer func() {
+		if scanErr != nil {
+			appendLog("Could not scan the workflow source for secret usage: " + scanErr.Error())
+			return
+		}
+		for _, use := range undeclared {
+			where := use.File
+			if use.Line > 0 {
+				where = fmt.Sprintf("%s:%d", use.File, use.Line)
+			}
+			appendLog(fmt.Sprintf("! %s reads %s, which secrets.yaml does not declare; ADD it before simulating.", where, use.ID))
+		}
+	}()
+
 	if len(manifest.SecretsNames) == 0 {
 		appendLog("No secrets declared in secrets.yaml")
 		return &SecretsCommandResult{Logs: logs}, nil
@@ -848,8 +1156,14 @@ func InspectLocalSecrets(workflowID, workflowName, target string) (*SecretsComma
 	}
 	sort.Strings(ids)
 
+	meta := loadSecretsMeta(dotEnvPath)
+	stale := 0
+	unused := []string{}
 	appendLog("Declared secrets:")
 	for _, id := range ids {
+		if scanErr == nil && !used[id] && id != "CRE_ETH_PRIVATE_KEY" {
+			unused = append(unused, id)
+		}
 		envVars := manifest.SecretsNames[id]
 		if len(envVars) == 0 {
 			appendLog("- " + id + " => (no env var mapping)")
@@ -860,9 +1174,22 @@ func InspectLocalSecrets(workflowID, workflowName, target string) (*SecretsComma
 		status := "missing in .env"
 		if strings.TrimSpace(value) != "" {
 			status = "present in .env"
+			rotatedAt := meta.rotatedAt(envVar)
+			if age := SecretAgeLabel(rotatedAt); age != "" {
+				status += ", " + age
+			}
+			if IsSecretStale(rotatedAt) {
+				stale++
+			}
 		}
 		appendLog(fmt.Sprintf("- %s => %s (%s)", id, envVar, status))
 	}
+	if stale > 0 {
+		appendLog(fmt.Sprintf("%d secret value(s) are older than %d days; rotate them with UPDATE.", stale, int(RotateAfter().Hours()/24)))
+	}
+	if len(unused) > 0 {
+		appendLog(fmt.Sprintf("! Declared but never read by the workflow source: %s.", strings.Join(unused, ", ")))
+	}
 
 	return &SecretsCommandResult{Logs: logs}, nil
 }
@@ -879,7 +1206,7 @@ func upsertLocalSecret(workflowID, workflowName, target, secretID, secretValue s
 	logs := []string{}
 	appendLog := func(msg string) { logs = append(logs, msg) }
 
-	_, secretsYamlPath, dotEnvPath, preflightLogs, err := preflightWorkflowSecrets(workflowID, workflowName, target)
+	_, secretsYamlPath, dotEnvPath, preflightLogs, err := preflightWorkflowSecretsForWrite(workflowID, workflowName, target)
 	if err != nil {
 		return nil, err
 	}
@@ -894,6 +1221,14 @@ func upsertLocalSecret(workflowID, workflowName, target, secretID, secretValue s
 	if strings.TrimSpace(secretValue) == "" {
 		return &SecretsCommandResult{Logs: logs}, errors.New("secret value is required")
 	}
+	if !mustExist {
+		if err := ValidateSecretID(id); err != nil {
+			return &SecretsCommandResult{Logs: logs}, err
+		}
+	}
+	if err := ValidateSecretValue(strings.TrimSpace(secretValue)); err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
 
 	manifest, err := loadSecretsManifest(secretsYamlPath)
 	if err != nil {
@@ -917,12 +1252,19 @@ func upsertLocalSecret(workflowID, workflowName, target, secretID, secretValue s
 	}
 	if envVar == "" {
 		envVar = defaultEnvVarForSecret(id)
+		if err := ValidateEnvVarName(envVar); err != nil {
+			return &SecretsCommandResult{Logs: logs}, err
+		}
 		manifest.SecretsNames[id] = []string{envVar}
 		if err := saveSecretsManifest(secretsYamlPath, manifest); err != nil {
 			return &SecretsCommandResult{Logs: logs}, err
 		}
 	}
-	if err := setDotEnvValue(dotEnvPath, envVar, strings.TrimSpace(secretValue)); err != nil {
+	stored, err := storeSecretValue(workflowName, envVar, strings.TrimSpace(secretValue), appendLog)
+	if err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	if err := setDotEnvValue(dotEnvPath, envVar, stored); err != nil {
 		return &SecretsCommandResult{Logs: logs}, err
 	}
 
@@ -938,7 +1280,7 @@ func DeleteLocalSecret(workflowID, workflowName, target, secretID string) (*Secr
 	logs := []string{}
 	appendLog := func(msg string) { logs = append(logs, msg) }
 
-	_, secretsYamlPath, dotEnvPath, preflightLogs, err := preflightWorkflowSecrets(workflowID, workflowName, target)
+	_, secretsYamlPath, dotEnvPath, preflightLogs, err := preflightWorkflowSecretsForWrite(workflowID, workflowName, target)
 	if err != nil {
 		return nil, err
 	}
@@ -971,58 +1313,194 @@ func DeleteLocalSecret(workflowID, workflowName, target, secretID string) (*Secr
 	return &SecretsCommandResult{Logs: logs}, nil
 }
 
-type PreSimulateResult struct {
-	Logs        []string
-	ProjectRoot string
-	CmdArgs     []string
+// PurgeLocalSecret deletes a secret outright: its declaration in secrets.yaml
+// and its lines in .env, so it no longer shows up in pickers or blocks runs.
+func PurgeLocalSecret(workflowID, workflowName, target, secretID string) (*SecretsCommandResult, error) {
+	logs := []string{}
+	appendLog := func(msg string) { logs = append(logs, msg) }
+
+	_, secretsYamlPath, dotEnvPath, preflightLogs, err := preflightWorkflowSecretsForWrite(workflowID, workflowName, target)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range preflightLogs {
+		appendLog(l)
+	}
+
+	id := strings.TrimSpace(secretID)
+	if id == "" {
+		return &SecretsCommandResult{Logs: logs}, errors.New("secret id is required")
+	}
+	manifest, err := loadSecretsManifest(secretsYamlPath)
+	if err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	resolvedID, envVars, exists := resolveSecretByID(manifest, id)
+	if !exists {
+		return &SecretsCommandResult{Logs: logs}, fmt.Errorf("secret %q does not exist", id)
+	}
+
+	delete(manifest.SecretsNames, resolvedID)
+	if err := saveSecretsManifest(secretsYamlPath, manifest); err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	for _, envVar := range envVars {
+		if err := removeDotEnvValue(dotEnvPath, strings.TrimSpace(envVar)); err != nil {
+			return &SecretsCommandResult{Logs: logs}, err
+		}
+	}
+
+	appendLog(fmt.Sprintf("Deleted secret %s from secrets.yaml and .env", resolvedID))
+	return &SecretsCommandResult{Logs: logs}, nil
 }
 
-func PreSimulateLocal(workflowID, workflowName, target string) (*PreSimulateResult, error) {
+// RenameLocalSecret renames a secret in secrets.yaml and moves its .env value
+// to the env var of the new name. A value that is a reference moves as is.
+func RenameLocalSecret(workflowID, workflowName, target, secretID, newSecretID string) (*SecretsCommandResult, error) {
 	logs := []string{}
 	appendLog := func(msg string) { logs = append(logs, msg) }
 
+	_, secretsYamlPath, dotEnvPath, preflightLogs, err := preflightWorkflowSecretsForWrite(workflowID, workflowName, target)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range preflightLogs {
+		appendLog(l)
+	}
+
+	newID := normalizeSecretID(newSecretID)
+	if err := ValidateSecretID(newID); err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	manifest, err := loadSecretsManifest(secretsYamlPath)
+	if err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	oldID, envVars, exists := resolveSecretByID(manifest, secretID)
+	if !exists {
+		return &SecretsCommandResult{Logs: logs}, fmt.Errorf("secret %q does not exist", strings.TrimSpace(secretID))
+	}
+	if oldID == newID {
+		return &SecretsCommandResult{Logs: logs}, errors.New("the new name is the same as the current one")
+	}
+	if _, _, taken := resolveSecretByID(manifest, newID); taken {
+		return &SecretsCommandResult{Logs: logs}, fmt.Errorf("secret %q already exists", newID)
+	}
+	newEnvVar := defaultEnvVarForSecret(newID)
+	if err := ValidateEnvVarName(newEnvVar); err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+
+	value := ""
+	rotatedAt := time.Time{}
+	if len(envVars) > 0 {
+		value, _ = readDotEnvValue(dotEnvPath, strings.TrimSpace(envVars[0]))
+		rotatedAt = loadSecretsMeta(dotEnvPath).rotatedAt(strings.TrimSpace(envVars[0]))
+	}
+	if value != "" {
+		if err := setDotEnvValue(dotEnvPath, newEnvVar, value); err != nil {
+			return &SecretsCommandResult{Logs: logs}, err
+		}
+		// The value did not change, so neither does its age.
+		_ = setSecretRotatedAt(dotEnvPath, newEnvVar, rotatedAt)
+	}
+	delete(manifest.SecretsNames, oldID)
+	manifest.SecretsNames[newID] = []string{newEnvVar}
+	if err := saveSecretsManifest(secretsYamlPath, manifest); err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	for _, envVar := range envVars {
+		if envVar = strings.TrimSpace(envVar); envVar != "" && envVar != newEnvVar {
+			if err := removeDotEnvValue(dotEnvPath, envVar); err != nil {
+				return &SecretsCommandResult{Logs: logs}, err
+			}
+		}
+	}
+
+	if value != "" {
+		appendLog(fmt.Sprintf("Renamed secret %s to %s in secrets.yaml and moved its .env value to %s", oldID, newID, newEnvVar))
+	} else {
+		appendLog(fmt.Sprintf("Renamed secret %s to %s in secrets.yaml (no .env value to move)", oldID, newID))
+	}
+	return &SecretsCommandResult{Logs: logs}, nil
+}
+
+type PreSimulateResult struct {
+	Logs            []string
+	ProjectRoot     string
+	CmdArgs         []string
+	NeedsPassphrase bool
+	// Env holds the resolved .env secret references for the cre process.
+	Env []string
+}
+
+// localWorkflowRun is a local workflow project that passed the checks shared
+// by simulate and deploy.
+type localWorkflowRun struct {
+	projectRoot     string
+	workflowDirName string
+	workflowDir     string
+	dotEnvPath      string
+	needsPassphrase bool
+	// env is the resolved .env secret references.
+	env []string
+}
+
+// checkLocalWorkflowRun verifies the synced project has the target and all
+// secrets set, then installs dependencies. purpose names the command in the
+// log lines, e.g. "simulation".
+func checkLocalWorkflowRun(workflowID, workflowName, target, purpose string, appendLog func(string)) (*localWorkflowRun, error) {
 	projectRoot := localWorkflowProjectRoot(workflowID, workflowName)
 	workflowDirName := slugify(workflowName)
 	workflowDir := filepath.Join(projectRoot, workflowDirName)
 	workflowYamlPath := filepath.Join(workflowDir, "workflow.yaml")
 	secretsYamlPath := filepath.Join(projectRoot, "secrets.yaml")
-	dotEnvPath := filepath.Join(workflowDir, ".env")
+	dotEnvPath := workflowDotEnvPath(workflowDir, target)
 	packageJSONPath := filepath.Join(workflowDir, "package.json")
 
 	if _, err := os.Stat(projectRoot); err != nil {
 		if os.IsNotExist(err) {
-			return &PreSimulateResult{Logs: logs}, errors.New("local workflow project not found. Run sync to local first")
+			return nil, errors.New("local workflow project not found. Run sync to local first")
 		}
-		return &PreSimulateResult{Logs: logs}, err
+		return nil, err
 	}
 	if _, err := os.Stat(workflowDir); err != nil {
-		return &PreSimulateResult{Logs: logs}, errors.New("workflow directory not found in local sync. Run sync to local again")
+		return nil, errors.New("workflow directory not found in local sync. Run sync to local again")
 	}
 	if _, err := os.Stat(packageJSONPath); err != nil {
-		return &PreSimulateResult{Logs: logs}, errors.New("missing workflow package.json. Run sync to local again")
+		return nil, errors.New("missing workflow package.json. Run sync to local again")
 	}
 	if _, err := os.Stat(secretsYamlPath); err != nil {
-		return &PreSimulateResult{Logs: logs}, errors.New("missing secrets.yaml in local workflow project. Run sync to local again")
+		return nil, errors.New("missing secrets.yaml in local workflow project. Run sync to local again")
 	}
 
 	hasTarget, err := workflowHasTarget(workflowYamlPath, target)
 	if err != nil {
-		return &PreSimulateResult{Logs: logs}, err
+		return nil, err
 	}
 	if !hasTarget {
-		return &PreSimulateResult{Logs: logs}, fmt.Errorf("workflow.yaml does not define target %q", target)
+		return nil, fmt.Errorf("workflow.yaml does not define target %q", target)
+	}
+	if err := requireDotEnvUnlocked(dotEnvPath); err != nil {
+		return nil, err
 	}
 
 	appendLog("project: " + projectRoot)
 	appendLog("workflow: " + workflowDirName)
 	appendLog("target: " + target)
-	appendLog("Validating local secrets before simulation...")
+	appendLog("Validating local secrets before " + purpose + "...")
 
 	privateKeyReady, privateKeyMsg, _ := ensurePrivateKeyConfigured(dotEnvPath)
+	needsPassphrase := false
+	if !privateKeyReady && HasWorkflowKeystore(workflowID, workflowName) {
+		privateKeyReady = true
+		needsPassphrase = true
+		privateKeyMsg = "CRE_ETH_PRIVATE_KEY is stored in an encrypted keystore; passphrase required."
+	}
 	appendLog(privateKeyMsg)
 	manifest, err := loadSecretsManifest(secretsYamlPath)
 	if err != nil {
-		return &PreSimulateResult{Logs: logs}, err
+		return nil, err
 	}
 	entries := listLocalSecretEntries(manifest, dotEnvPath)
 	missing := make([]LocalSecretEntry, 0)
@@ -1032,7 +1510,7 @@ func PreSimulateLocal(workflowID, workflowName, target string) (*PreSimulateResu
 		}
 	}
 	if !privateKeyReady || len(missing) > 0 {
-		appendLog("Simulation blocked. Missing required local secret setup:")
+		appendLog(strings.ToUpper(purpose[:1]) + purpose[1:] + " blocked. Missing required local secret setup:")
 		if !privateKeyReady {
 			appendLog("- CRE_ETH_PRIVATE_KEY is missing. Open Secrets -> UPDATE VALUE.")
 		}
@@ -1041,28 +1519,53 @@ func PreSimulateLocal(workflowID, workflowName, target string) (*PreSimulateResu
 				appendLog(fmt.Sprintf("- %s has no env var mapping in secrets.yaml", entry.ID))
 				continue
 			}
-			appendLog(fmt.Sprintf("- %s (%s) is missing in .env", entry.ID, entry.EnvVar))
+			appendLog(fmt.Sprintf("- %s (%s) is missing in %s", entry.ID, entry.EnvVar, filepath.Base(dotEnvPath)))
 		}
-		return &PreSimulateResult{Logs: logs}, errors.New("cannot simulate until all secrets are configured")
+		return nil, ErrSecretsNotConfigured
 	}
 	appendLog("All required secrets are configured.")
+	env, err := dotEnvRunEnv(dotEnvPath, appendLog)
+	if err != nil {
+		return nil, err
+	}
 
-	appendLog("Running dependency setup: bun install")
-	installLines, installErr := runCommand(workflowDir, "bun", "install")
-	for _, line := range installLines {
-		appendLog("[bun] " + line)
+	if err := installDependencies(workflowDir, appendLog); err != nil {
+		return nil, err
 	}
-	if installErr != nil {
-		return &PreSimulateResult{Logs: logs}, fmt.Errorf("bun install failed: %w", installErr)
+	return &localWorkflowRun{
+		projectRoot:     projectRoot,
+		workflowDirName: workflowDirName,
+		workflowDir:     workflowDir,
+		dotEnvPath:      dotEnvPath,
+		needsPassphrase: needsPassphrase,
+		env:             env,
+	}, nil
+}
+
+// PreSimulateLocal checks the local project and installs dependencies before
+// a simulation. onLog, when set, receives each log line as it is produced,
+// including live dependency install output; Logs still holds all of them.
+func PreSimulateLocal(workflowID, workflowName, target string, onLog func(string)) (*PreSimulateResult, error) {
+	logs := []string{}
+	appendLog := func(msg string) {
+		logs = append(logs, msg)
+		if onLog != nil {
+			onLog(msg)
+		}
 	}
 
-	envArg := filepath.ToSlash(filepath.Join(workflowDirName, ".env"))
-	cmdArgs := []string{"workflow", "simulate", workflowDirName, "--target", target, "-e", envArg}
+	run, err := checkLocalWorkflowRun(workflowID, workflowName, target, "simulation", appendLog)
+	if err != nil {
+		return &PreSimulateResult{Logs: logs}, err
+	}
+	cmdArgs := append([]string{"workflow", "simulate", run.workflowDirName, "--target", target}, dotEnvRunArgs(run.workflowDirName, run.dotEnvPath)...)
 
 	return &PreSimulateResult{
-		Logs:        logs,
-		ProjectRoot: projectRoot,
-		CmdArgs:     cmdArgs,
+		Logs:            logs,
+		ProjectRoot:     run.projectRoot,
+		CmdArgs:         cmdArgs,
+		NeedsPassphrase: run.needsPassphrase,
+		Env:             run.env,
 	}, nil
 }
 
@@ -1075,16 +1578,31 @@ func IsEvmLogTriggerWorkflow(workflowID, workflowName string) bool {
 	return strings.Contains(string(raw), "onLogTrigger")
 }
 
-func RunWorkflowSimulateLocal(workflowID, workflowName, target, evmTxHash string, evmEventIndex int) (*SimulateCommandResult, error) {
+// RunWorkflowSimulateLocal installs dependencies and runs `cre workflow
+// simulate`. payloadFile, when set, is a JSON file passed to an HTTP trigger.
+// onLog works as in PreSimulateLocal.
+func RunWorkflowSimulateLocal(workflowID, workflowName, target, evmTxHash string, evmEventIndex int, payloadFile string, onLog func(string)) (*SimulateCommandResult, error) {
 	logs := []string{}
-	appendLog := func(msg string) { logs = append(logs, msg) }
+	appendLog := func(msg string) {
+		logs = append(logs, msg)
+		if onLog != nil {
+			onLog(msg)
+		}
+	}
+	if strings.TrimSpace(payloadFile) != "" {
+		resolved, err := resolvePayloadFile(payloadFile)
+		if err != nil {
+			return &SimulateCommandResult{Logs: logs}, err
+		}
+		payloadFile = resolved
+	}
 
 	projectRoot := localWorkflowProjectRoot(workflowID, workflowName)
 	workflowDirName := slugify(workflowName)
 	workflowDir := filepath.Join(projectRoot, workflowDirName)
 	workflowYamlPath := filepath.Join(workflowDir, "workflow.yaml")
 	secretsYamlPath := filepath.Join(projectRoot, "secrets.yaml")
-	dotEnvPath := filepath.Join(workflowDir, ".env")
+	dotEnvPath := workflowDotEnvPath(workflowDir, target)
 	packageJSONPath := filepath.Join(workflowDir, "package.json")
 
 	if _, err := os.Stat(projectRoot); err != nil {
@@ -1110,6 +1628,9 @@ func RunWorkflowSimulateLocal(workflowID, workflowName, target, evmTxHash string
 	if !hasTarget {
 		return &SimulateCommandResult{Logs: logs}, fmt.Errorf("workflow.yaml does not define target %q", target)
 	}
+	if err := requireDotEnvUnlocked(dotEnvPath); err != nil {
+		return &SimulateCommandResult{Logs: logs}, err
+	}
 
 	appendLog("project: " + projectRoot)
 	appendLog("workflow: " + workflowDirName)
@@ -1117,6 +1638,20 @@ func RunWorkflowSimulateLocal(workflowID, workflowName, target, evmTxHash string
 	appendLog("Validating local secrets before simulation...")
 
 	privateKeyReady, privateKeyMsg, _ := ensurePrivateKeyConfigured(dotEnvPath)
+	var extraEnv []string
+	if !privateKeyReady && HasWorkflowKeystore(workflowID, workflowName) {
+		passphrase := os.Getenv(keystorePassphraseEnv)
+		if passphrase == "" {
+			return &SimulateCommandResult{Logs: logs}, fmt.Errorf("%w (set %s)", ErrKeystoreLocked, keystorePassphraseEnv)
+		}
+		privateKey, err := DecryptWorkflowPrivateKey(workflowID, workflowName, passphrase)
+		if err != nil {
+			return &SimulateCommandResult{Logs: logs}, err
+		}
+		extraEnv = PrivateKeyEnv(privateKey)
+		privateKeyReady = true
+		privateKeyMsg = "CRE_ETH_PRIVATE_KEY decrypted from keystore for this run."
+	}
 	appendLog(privateKeyMsg)
 	manifest, err := loadSecretsManifest(secretsYamlPath)
 	if err != nil {
@@ -1139,43 +1674,47 @@ func RunWorkflowSimulateLocal(workflowID, workflowName, target, evmTxHash string
 				appendLog(fmt.Sprintf("- %s has no env var mapping in secrets.yaml", entry.ID))
 				continue
 			}
-			appendLog(fmt.Sprintf("- %s (%s) is missing in .env", entry.ID, entry.EnvVar))
+			appendLog(fmt.Sprintf("- %s (%s) is missing in %s", entry.ID, entry.EnvVar, filepath.Base(dotEnvPath)))
 		}
-		return &SimulateCommandResult{Logs: logs}, errors.New("cannot simulate until all secrets are configured")
+		return &SimulateCommandResult{Logs: logs}, ErrSecretsNotConfigured
 	}
 	appendLog("All required secrets are configured.")
-
-	appendLog("Running dependency setup: bun install")
-	installLines, installErr := runCommand(workflowDir, "bun", "install")
-	for _, line := range installLines {
-		appendLog("[bun] " + line)
+	refEnv, err := dotEnvRunEnv(dotEnvPath, appendLog)
+	if err != nil {
+		return &SimulateCommandResult{Logs: logs}, err
 	}
-	if installErr != nil {
-		return &SimulateCommandResult{Logs: logs}, fmt.Errorf("bun install failed: %w", installErr)
+	extraEnv = append(extraEnv, refEnv...)
+	if err := requireCRECLI(); err != nil {
+		return &SimulateCommandResult{Logs: logs}, err
 	}
 
-	envArg := filepath.ToSlash(filepath.Join(workflowDirName, ".env"))
-	cmdArgs := []string{"workflow", "simulate", workflowDirName, "--target", target, "-e", envArg}
+	if err := installDependencies(workflowDir, appendLog); err != nil {
+		return &SimulateCommandResult{Logs: logs}, err
+	}
 
-	var simulateLines []string
-	var simulateErr error
+	cmdArgs := append([]string{"workflow", "simulate", workflowDirName, "--target", target}, dotEnvRunArgs(workflowDirName, dotEnvPath)...)
 
-	if strings.TrimSpace(evmTxHash) != "" {
-		stdinData := fmt.Sprintf("%s\n%d\n", strings.TrimSpace(evmTxHash), evmEventIndex)
+	var stdin io.Reader
+	if payloadFile != "" {
+		cmdArgs = append(cmdArgs, SimulatePayloadArgs(payloadFile)...)
+		appendLog(fmt.Sprintf("Running simulation: cre %s (HTTP payload from %s)", strings.Join(cmdArgs, " "), filepath.Base(payloadFile)))
+	} else if strings.TrimSpace(evmTxHash) != "" {
+		stdin = strings.NewReader(fmt.Sprintf("%s\n%d\n", strings.TrimSpace(evmTxHash), evmEventIndex))
 		appendLog(fmt.Sprintf("Running simulation: cre %s (EVM stdin: tx=%s, index=%d)",
 			strings.Join(cmdArgs, " "), strings.TrimSpace(evmTxHash), evmEventIndex))
-		simulateLines, simulateErr = runCommandWithStdin(projectRoot, stdinData, "cre", cmdArgs...)
 	} else {
 		appendLog("Running simulation: cre " + strings.Join(cmdArgs, " "))
-		simulateLines, simulateErr = runCommand(projectRoot, "cre", cmdArgs...)
-	}
-	for _, line := range simulateLines {
-		appendLog("[cre] " + line)
 	}
+	output := NewSimulateOutput()
+	_, simulateErr := runCommandStream(projectRoot, extraEnv, stdin, SimulateTimeout(), func(line string, stderr bool) {
+		for _, formatted := range output.Lines(line, stderr) {
+			appendLog(formatted)
+		}
+	}, CREBinary(), cmdArgs...)
 	if simulateErr != nil {
-		return &SimulateCommandResult{Logs: logs}, fmt.Errorf("simulate failed: %w", simulateErr)
+		return &SimulateCommandResult{Logs: logs, Result: output.Result()}, fmt.Errorf("simulate failed: %w", simulateErr)
 	}
 
 	appendLog("Simulation completed.")
-	return &SimulateCommandResult{Logs: logs}, nil
+	return &SimulateCommandResult{Logs: logs, Result: output.Result()}, nil
 }
@@ -0,0 +1,270 @@
+package tui
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// creReleaseURLEnv overrides where the CRE CLI is downloaded from, e.g. an
+// internal mirror. {os}, {arch} and {ext} are replaced for the current
+// platform.
+const creReleaseURLEnv = "SIXFLOW_CRE_RELEASE_URL"
+
+const defaultCREReleaseURL = "https://github.com/smartcontractkit/cre-cli/releases/latest/download/cre_{os}_{arch}.{ext}"
+
+// maxCREDownload bounds the release archive so a wrong URL cannot fill the
+// disk.
+const maxCREDownload = 256 << 20
+
+type CREInstallResult struct {
+	Path    string
+	Version string
+	Logs    []string
+}
+
+func creBinaryName() string {
+	if runtime.GOOS == "windows" {
+		return "cre.exe"
+	}
+	return "cre"
+}
+
+// ManagedCREPath is where InstallCRECLI puts the CLI: ~/.6flow/bin/cre.
+func ManagedCREPath() string {
+	return filepath.Join(sixflowHomeDir(), "bin", creBinaryName())
+}
+
+// creBinEnv points at a specific cre executable, e.g. to pin one of several
+// installed CLI versions.
+const creBinEnv = "SIXFLOW_CRE_BIN"
+
+// ResolveCREBinary returns the cre executable to run and where the choice
+// came from: SIXFLOW_CRE_BIN, then creBin in ~/.6flow/config.json, then
+// PATH, then the copy installed by InstallCRECLI. Without any it returns
+// plain "cre" and an empty source so errors name the command.
+func ResolveCREBinary() (string, string) {
+	if configured := expandHome(os.Getenv(creBinEnv)); configured != "" {
+		return configured, creBinEnv
+	}
+	if config, err := LoadTUIConfig(); err == nil {
+		if configured := expandHome(config.CREBin); configured != "" {
+			return configured, tuiConfigPath()
+		}
+	}
+	if found, err := exec.LookPath("cre"); err == nil {
+		return found, "PATH"
+	}
+	managed := ManagedCREPath()
+	if info, err := os.Stat(managed); err == nil && !info.IsDir() {
+		return managed, managed
+	}
+	return "cre", ""
+}
+
+func CREBinary() string {
+	binary, _ := ResolveCREBinary()
+	return binary
+}
+
+func creReleaseURL() string {
+	template := strings.TrimSpace(os.Getenv(creReleaseURLEnv))
+	if template == "" {
+		template = defaultCREReleaseURL
+	}
+	ext := "tar.gz"
+	if runtime.GOOS == "windows" {
+		ext = "zip"
+	}
+	return strings.NewReplacer("{os}", runtime.GOOS, "{arch}", runtime.GOARCH, "{ext}", ext).Replace(template)
+}
+
+// releaseHTTPClient talks to the release host rather than the frontend, so it
+// skips the frontend headers and CA settings but honours the proxy.
+func releaseHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = proxyForRequest
+	return &http.Client{Timeout: 5 * time.Minute, Transport: transport}
+}
+
+func fetchRelease(client *http.Client, url string) ([]byte, int, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		return nil, resp.StatusCode, fmt.Errorf("GET %s failed with status %d", url, resp.StatusCode)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxCREDownload+1))
+	if err != nil {
+		return nil, resp.StatusCode, err
+	}
+	if len(content) > maxCREDownload {
+		return nil, resp.StatusCode, fmt.Errorf("download from %s exceeds %s", url, FormatBytes(maxCREDownload))
+	}
+	return content, resp.StatusCode, nil
+}
+
+// releaseChecksum looks the asset up in the checksums.txt published next to
+// it. ok is false when the release has no checksum file.
+func releaseChecksum(client *http.Client, assetURL string) (string, bool, error) {
+	asset := path.Base(assetURL)
+	checksumsURL := strings.TrimSuffix(assetURL, asset) + "checksums.txt"
+	content, status, err := fetchRelease(client, checksumsURL)
+	if status == http.StatusNotFound {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return strings.ToLower(fields[0]), true, nil
+		}
+	}
+	return "", false, fmt.Errorf("checksums.txt does not list %s", asset)
+}
+
+// extractCREBinary returns the cre executable from a tar.gz or zip release,
+// or the download itself when the release is a bare binary.
+func extractCREBinary(content []byte) ([]byte, error) {
+	name := creBinaryName()
+	switch {
+	case bytes.HasPrefix(content, []byte("PK\x03\x04")):
+		zr, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() || path.Base(f.Name) != name {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			return io.ReadAll(io.LimitReader(rc, maxCREDownload))
+		}
+	case bytes.HasPrefix(content, []byte{0x1f, 0x8b}):
+		gz, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return nil, err
+		}
+		tr := tar.NewReader(gz)
+		for {
+			header, err := tr.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if header.Typeflag == tar.TypeReg && path.Base(header.Name) == name {
+				return io.ReadAll(io.LimitReader(tr, maxCREDownload))
+			}
+		}
+	default:
+		return content, nil
+	}
+	return nil, fmt.Errorf("release archive does not contain %s", name)
+}
+
+// InstallCRECLI downloads the CRE CLI release for this OS and architecture
+// into ~/.6flow/bin. CREBinary picks it up when cre is not on PATH.
+func InstallCRECLI() (*CREInstallResult, error) {
+	logs := []string{}
+	appendLog := func(msg string) { logs = append(logs, msg) }
+
+	client := releaseHTTPClient()
+	url := creReleaseURL()
+	appendLog(fmt.Sprintf("Downloading CRE CLI for %s/%s from %s ...", runtime.GOOS, runtime.GOARCH, url))
+	content, _, err := fetchRelease(client, url)
+	if err != nil {
+		return &CREInstallResult{Logs: logs}, err
+	}
+	appendLog(fmt.Sprintf("Downloaded %s.", FormatBytes(int64(len(content)))))
+
+	expected, ok, err := releaseChecksum(client, url)
+	if err != nil {
+		return &CREInstallResult{Logs: logs}, fmt.Errorf("could not verify the download: %w", err)
+	}
+	if ok {
+		sum := sha256.Sum256(content)
+		if actual := hex.EncodeToString(sum[:]); actual != expected {
+			return &CREInstallResult{Logs: logs}, fmt.Errorf("checksum mismatch for %s: expected %s, got %s", path.Base(url), expected, actual)
+		}
+		appendLog("Verified release SHA-256 checksum.")
+	} else {
+		appendLog("Release has no checksums.txt; skipping verification.")
+	}
+
+	binary, err := extractCREBinary(content)
+	if err != nil {
+		return &CREInstallResult{Logs: logs}, err
+	}
+
+	target := ManagedCREPath()
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return &CREInstallResult{Logs: logs}, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".cre-*")
+	if err != nil {
+		return &CREInstallResult{Logs: logs}, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(binary); err != nil {
+		tmp.Close()
+		return &CREInstallResult{Logs: logs}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return &CREInstallResult{Logs: logs}, err
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return &CREInstallResult{Logs: logs}, err
+	}
+
+	// Check the binary runs before it replaces a working install.
+	check := exec.Command(tmp.Name(), "version")
+	check.Env = CommandEnv(nil)
+	output, err := check.CombinedOutput()
+	if err != nil {
+		lines := splitOutputLines(string(output))
+		if len(lines) > 0 {
+			err = fmt.Errorf("%w: %s", err, lines[0])
+		}
+		return &CREInstallResult{Logs: logs}, fmt.Errorf("downloaded CRE CLI does not run: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return &CREInstallResult{Logs: logs}, err
+	}
+
+	version := ""
+	if lines := splitOutputLines(string(output)); len(lines) > 0 {
+		version = lines[0]
+	}
+	appendLog("Installed CRE CLI to " + target + ".")
+	if binary, source := ResolveCREBinary(); binary != target {
+		appendLog(fmt.Sprintf("Note: %s (from %s) takes precedence over the installed copy.", binary, source))
+	}
+	return &CREInstallResult{Path: target, Version: version, Logs: logs}, nil
+}
@@ -0,0 +1,160 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultDeployGas approximates the registry transaction of a deploy;
+	// the calldata is built by the CRE CLI, so eth_estimateGas cannot be used.
+	defaultDeployGas = 600_000
+	deployGasEnv     = "SIXFLOW_DEPLOY_GAS"
+	// priceURLEnv overrides the USD price source; "off" disables it.
+	priceURLEnv     = "SIXFLOW_PRICE_URL"
+	defaultPriceURL = "https://api.coingecko.com/api/v3/simple/price?ids=ethereum&vs_currencies=usd"
+)
+
+// DeployFeeEstimate is the expected cost of the deploy transaction.
+type DeployFeeEstimate struct {
+	GasPrice *big.Int
+	GasUnits uint64
+	CostWei  *big.Int
+	// USD is set when the price source answered and the chain is not a
+	// testnet; PriceNote says why it is missing otherwise.
+	USD       *float64
+	PriceNote string
+}
+
+func deployGasUnits(config TUIConfig) uint64 {
+	if raw := strings.TrimSpace(os.Getenv(deployGasEnv)); raw != "" {
+		if parsed, err := strconv.ParseUint(raw, 10, 64); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	if config.DeployGas > 0 {
+		return config.DeployGas
+	}
+	return defaultDeployGas
+}
+
+func priceURL(config TUIConfig) string {
+	if raw := strings.TrimSpace(os.Getenv(priceURLEnv)); raw != "" {
+		return raw
+	}
+	if strings.TrimSpace(config.PriceURL) != "" {
+		return strings.TrimSpace(config.PriceURL)
+	}
+	return defaultPriceURL
+}
+
+// EstimateDeployFee prices the deploy at the node's current gas price. The
+// USD figure comes from the configured price source; failures there only
+// leave it out.
+func EstimateDeployFee(rpcURL, target string) (*DeployFeeEstimate, error) {
+	gasPrice, err := ethQuantity(rpcURL, "eth_gasPrice")
+	if err != nil {
+		return nil, err
+	}
+	config, _ := LoadTUIConfig()
+	estimate := &DeployFeeEstimate{GasPrice: gasPrice, GasUnits: deployGasUnits(config)}
+	estimate.CostWei = new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(estimate.GasUnits))
+
+	source := priceURL(config)
+	switch {
+	case targetIsTestnet(target):
+		estimate.PriceNote = "testnet funds have no USD value"
+	case strings.EqualFold(source, "off"):
+		estimate.PriceNote = "price source disabled"
+	default:
+		price, err := fetchUSDPrice(source)
+		if err != nil {
+			estimate.PriceNote = "price unavailable: " + err.Error()
+			break
+		}
+		eth, _ := new(big.Rat).SetFrac(estimate.CostWei, new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)).Float64()
+		usd := eth * price
+		estimate.USD = &usd
+	}
+	return estimate, nil
+}
+
+// Summary renders the estimate for the confirmation screen, e.g.
+// "~0.0123 native (~$36.90) at 20.5 gwei x 600000 gas".
+func (e *DeployFeeEstimate) Summary() string {
+	gwei := new(big.Rat).SetFrac(e.GasPrice, big.NewInt(1_000_000_000))
+	line := fmt.Sprintf("~%s native", FormatWei(e.CostWei))
+	if e.USD != nil {
+		line += fmt.Sprintf(" (~$%.2f)", *e.USD)
+	}
+	line += fmt.Sprintf(" at %s gwei x %d gas", strings.TrimSuffix(strings.TrimRight(gwei.FloatString(2), "0"), "."), e.GasUnits)
+	if e.PriceNote != "" {
+		line += "; " + e.PriceNote
+	}
+	return line
+}
+
+// fetchUSDPrice reads the first "usd", "price" or "amount" number in the
+// response, which covers CoinGecko, Coinbase and most simple price APIs.
+func fetchUSDPrice(source string) (float64, error) {
+	client := &http.Client{Timeout: envTimeout(rpcTimeoutEnv, defaultRPCTimeout)}
+	resp, err := client.Get(source)
+	if err != nil {
+		return 0, timeoutHint(err, rpcTimeoutEnv)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+	var payload any
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return 0, err
+	}
+	if price, ok := findPrice(payload); ok {
+		return price, nil
+	}
+	return 0, errors.New("no usd/price/amount field in response")
+}
+
+func findPrice(value any) (float64, bool) {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, inner := range typed {
+			switch strings.ToLower(key) {
+			case "usd", "price", "amount":
+				if price, ok := priceNumber(inner); ok {
+					return price, true
+				}
+			}
+		}
+		for _, inner := range typed {
+			if price, ok := findPrice(inner); ok {
+				return price, true
+			}
+		}
+	case []any:
+		for _, inner := range typed {
+			if price, ok := findPrice(inner); ok {
+				return price, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func priceNumber(value any) (float64, bool) {
+	switch typed := value.(type) {
+	case float64:
+		return typed, true
+	case string:
+		parsed, err := strconv.ParseFloat(typed, 64)
+		return parsed, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,187 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DeployCheck is one line of the preflight checklist.
+type DeployCheck struct {
+	Name   string
+	OK     bool
+	Detail string
+}
+
+// DeployPreflight is what a deploy would run with and whether it is likely to
+// get through: the checks cover what otherwise fails only after compiling and
+// uploading.
+type DeployPreflight struct {
+	Target  string
+	RPCURL  string
+	ChainID *big.Int
+	Address string
+	Balance *big.Int
+	Fee     *DeployFeeEstimate
+	Checks  []DeployCheck
+}
+
+func (p *DeployPreflight) Passed() bool {
+	for _, check := range p.Checks {
+		if !check.OK {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *DeployPreflight) add(name string, ok bool, format string, args ...any) {
+	p.Checks = append(p.Checks, DeployCheck{Name: name, OK: ok, Detail: fmt.Sprintf(format, args...)})
+}
+
+// RunDeployPreflight checks secrets completeness, the deploy key, the target
+// RPC and the key's balance on it. Failed checks are part of the result; an
+// error means the local project could not be inspected at all.
+func RunDeployPreflight(workflowID, workflowName, target string) (*DeployPreflight, error) {
+	projectRoot := localWorkflowProjectRoot(workflowID, workflowName)
+	workflowDir := localWorkflowDir(workflowID, workflowName)
+	if _, err := os.Stat(workflowDir); err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.New("local workflow project not found. Run sync to local first")
+		}
+		return nil, err
+	}
+	dotEnvPath := workflowDotEnvPath(workflowDir, target)
+	preflight := &DeployPreflight{Target: target}
+
+	manifest, err := loadSecretsManifest(filepath.Join(projectRoot, "secrets.yaml"))
+	if err != nil {
+		preflight.add("secrets", false, "cannot read secrets.yaml: %v", err)
+	} else {
+		entries := listLocalSecretEntries(manifest, dotEnvPath)
+		missing := []string{}
+		for _, entry := range entries {
+			if !entry.HasValue {
+				missing = append(missing, entry.ID)
+			}
+		}
+		if len(missing) > 0 {
+			preflight.add("secrets", false, "%d of %d missing in %s: %s", len(missing), len(entries), filepath.Base(dotEnvPath), strings.Join(missing, ", "))
+		} else {
+			preflight.add("secrets", true, "%d secret(s) set", len(entries))
+		}
+	}
+
+	preflight.checkPrivateKey(workflowID, workflowName, dotEnvPath)
+
+	rpcURL, err := deployRPC(filepath.Join(projectRoot, "project.yaml"), target)
+	switch {
+	case err != nil:
+		preflight.add("rpc", false, "cannot read project.yaml: %v", err)
+	case rpcURL == "":
+		preflight.add("rpc", false, "no RPC configured for %s in project.yaml", target)
+	default:
+		preflight.RPCURL = rpcURL
+		chainID, err := RPCChainID(rpcURL)
+		if err != nil {
+			preflight.add("rpc", false, "%s unreachable: %v", rpcHost(rpcURL), err)
+		} else {
+			preflight.ChainID = chainID
+			preflight.add("rpc", true, "%s answers for chain %s", rpcHost(rpcURL), chainID)
+			if fee, err := EstimateDeployFee(rpcURL, target); err == nil {
+				preflight.Fee = fee
+			}
+		}
+	}
+
+	switch {
+	case preflight.Address == "":
+		preflight.add("balance", false, "skipped: no usable private key")
+	case preflight.ChainID == nil:
+		preflight.add("balance", false, "skipped: RPC not reachable")
+	default:
+		balance, err := RPCBalance(preflight.RPCURL, preflight.Address)
+		if err != nil {
+			preflight.add("balance", false, "%v", err)
+			break
+		}
+		preflight.Balance = balance
+		if balance.Sign() == 0 {
+			preflight.add("balance", false, "%s has no funds on chain %s", preflight.Address, preflight.ChainID)
+		} else if preflight.Fee != nil && balance.Cmp(preflight.Fee.CostWei) < 0 {
+			preflight.add("balance", false, "%s native on %s is below the estimated fee of %s", FormatWei(balance), preflight.Address, FormatWei(preflight.Fee.CostWei))
+		} else {
+			preflight.add("balance", true, "%s native on %s", FormatWei(balance), preflight.Address)
+		}
+	}
+	return preflight, nil
+}
+
+func (p *DeployPreflight) checkPrivateKey(workflowID, workflowName, dotEnvPath string) {
+	privateKey, _ := readDotEnvValue(dotEnvPath, "CRE_ETH_PRIVATE_KEY")
+	source := ".env"
+	if IsSecretReference(privateKey) {
+		resolved, err := resolveSecretReference(privateKey)
+		if err != nil {
+			p.add("private key", false, "%s: %v", privateKey, err)
+			return
+		}
+		privateKey, source = resolved, privateKey
+	}
+	if !isValidPrivateKey(privateKey) && HasWorkflowKeystore(workflowID, workflowName) {
+		passphrase := os.Getenv(keystorePassphraseEnv)
+		if passphrase == "" {
+			p.add("private key", false, "in the encrypted keystore; set %s to check it", keystorePassphraseEnv)
+			return
+		}
+		decrypted, err := DecryptWorkflowPrivateKey(workflowID, workflowName, passphrase)
+		if err != nil {
+			p.add("private key", false, "%v", err)
+			return
+		}
+		privateKey, source = decrypted, "keystore"
+	}
+	if strings.TrimSpace(privateKey) == "" {
+		p.add("private key", false, "CRE_ETH_PRIVATE_KEY is not set. Open Secrets -> UPDATE VALUE")
+		return
+	}
+	address, err := PrivateKeyAddress(privateKey)
+	if err != nil {
+		p.add("private key", false, "%v", err)
+		return
+	}
+	p.Address = address
+	if strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(privateKey), "0x"), demoPrivateKeyForProject(workflowID)) {
+		p.add("private key", false, "%s is the generated demo key; set your own funded key", address)
+		return
+	}
+	p.add("private key", true, "%s (from %s)", address, source)
+}
+
+// deployRPC picks the RPC of the chain the workflow registry lives on:
+// Sepolia for testnet targets, Ethereum mainnet otherwise.
+func deployRPC(projectYamlPath, target string) (string, error) {
+	rpcs, err := readProjectRPCMap(projectYamlPath, target)
+	if err != nil {
+		return "", err
+	}
+	registryChain := mainnetChainName
+	if targetIsTestnet(target) {
+		registryChain = stagingChainName
+	}
+	if rpcURL := rpcs[registryChain]; rpcURL != "" {
+		return rpcURL, nil
+	}
+	return readProjectRPC(projectYamlPath, target)
+}
+
+func rpcHost(rpcURL string) string {
+	if parsed, err := url.Parse(rpcURL); err == nil && parsed.Host != "" {
+		return parsed.Host
+	}
+	return rpcURL
+}
@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// bundleStampFile records which compiled artifact a sync wrote, so deploys
+// can name the bundle version they shipped.
+const bundleStampFile = ".6flow-bundle.json"
+
+var ErrRollbackVersionUnknown = errors.New("the bundle version of this deployment is not known")
+
+// BundleStamp describes the compiled artifact of the local project. Version
+// is empty when the current artifact was synced without naming one.
+type BundleStamp struct {
+	Version         string    `json:"version,omitempty"`
+	SHA256          string    `json:"sha256"`
+	CompilerVersion string    `json:"compilerVersion,omitempty"`
+	SyncedAt        time.Time `json:"syncedAt"`
+	// Files holds the SHA-256 of each bundle file the sync wrote, by path
+	// relative to the project root, so the next sync can tell local edits.
+	Files map[string]string `json:"files,omitempty"`
+	// Profile and Organization are the auth profile and organization the
+	// bundle was synced under; the workflow lists of others do not cover it.
+	Profile      string `json:"profile,omitempty"`
+	Organization string `json:"organization,omitempty"`
+}
+
+// DeploymentEvent is a registry transaction made for a deployment after the
+// deploy itself, e.g. a pause.
+type DeploymentEvent struct {
+	Op     DeploymentOp `json:"op"`
+	TxHash string       `json:"txHash,omitempty"`
+	At     time.Time    `json:"at"`
+}
+
+func bundleSHA256(bundle *WorkflowBundle) string {
+	sum := sha256.Sum256(bundle.Content)
+	return hex.EncodeToString(sum[:])
+}
+
+func writeBundleStamp(projectRoot string, stamp BundleStamp) error {
+	content, err := json.MarshalIndent(stamp, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(projectRoot, bundleStampFile), append(content, '\n'), 0o644)
+}
+
+// readBundleStamp returns nil for projects synced before stamps existed.
+func readBundleStamp(projectRoot string) *BundleStamp {
+	content, err := os.ReadFile(filepath.Join(projectRoot, bundleStampFile))
+	if err != nil {
+		return nil
+	}
+	var stamp BundleStamp
+	if err := json.Unmarshal(content, &stamp); err != nil {
+		return nil
+	}
+	return &stamp
+}
+
+// ResolveDeploymentBundle finds the frontend bundle version a recorded
+// deployment shipped, by id or, for deploys of the then-current artifact, by
+// checksum.
+func ResolveDeploymentBundle(baseURL, token, workflowID string, record DeploymentRecord) (*BundleVersion, error) {
+	if record.BundleVersion == "" && record.BundleSHA256 == "" {
+		return nil, ErrRollbackVersionUnknown
+	}
+	versions, err := FetchBundleVersions(baseURL, token, workflowID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range versions {
+		if record.BundleVersion != "" && versions[i].ID == record.BundleVersion {
+			return &versions[i], nil
+		}
+		if record.BundleVersion == "" && versions[i].SHA256 == record.BundleSHA256 {
+			return &versions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("bundle version of the %s deployment from %s is no longer kept by the frontend",
+		record.Target, record.DeployedAt.Local().Format("2006-01-02 15:04"))
+}
+
+// SyncDeploymentBundle syncs the bundle version a recorded deployment shipped,
+// the first half of a rollback; deploying it again is the second.
+func SyncDeploymentBundle(baseURL, token, workflowID, workflowName string, record DeploymentRecord) (*SyncLocalResult, error) {
+	version, err := ResolveDeploymentBundle(baseURL, token, workflowID, record)
+	if err != nil {
+		return nil, err
+	}
+	result, err := SyncWorkflowVersionToLocal(baseURL, token, workflowID, workflowName, version.ID)
+	if result != nil {
+		result.Logs = append([]string{fmt.Sprintf("Rolling back to bundle version %s (compiler %s).", version.ID, version.CompilerVersion)}, result.Logs...)
+	}
+	return result, err
+}
@@ -0,0 +1,171 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	loginMethodEnv          = "SIXFLOW_LOGIN_METHOD"
+	readOnlyEnv             = "SIXFLOW_READ_ONLY"
+	LoginMethodCallback     = "callback"
+	LoginMethodDeviceCode   = "device"
+	defaultDevicePollPeriod = 5 * time.Second
+)
+
+type deviceCodeResponse struct {
+	DeviceCode              string `json:"deviceCode"`
+	UserCode                string `json:"userCode"`
+	VerificationURI         string `json:"verificationUri"`
+	VerificationURIComplete string `json:"verificationUriComplete"`
+	ExpiresIn               int    `json:"expiresIn"`
+	Interval                int    `json:"interval"`
+	Error                   string `json:"error"`
+}
+
+type deviceTokenResponse struct {
+	Token string `json:"token"`
+	Error string `json:"error"`
+}
+
+// PreferredLoginMethod reads SIXFLOW_LOGIN_METHOD; anything other than
+// "device" means the loopback callback flow.
+func PreferredLoginMethod() string {
+	if strings.EqualFold(strings.TrimSpace(os.Getenv(loginMethodEnv)), LoginMethodDeviceCode) {
+		return LoginMethodDeviceCode
+	}
+	return LoginMethodCallback
+}
+
+// RequestedLoginScope asks for a read-only token when SIXFLOW_READ_ONLY is
+// set, e.g. for auditors who only list and simulate.
+func RequestedLoginScope() string {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(readOnlyEnv))) {
+	case "1", "true", "yes", "on":
+		return ScopeRead
+	}
+	return ScopeRead + " " + ScopeWrite
+}
+
+func postDeviceJSON(client *http.Client, endpoint string, body any, out any) (int, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if err := incompatibleResponse(resp); err != nil {
+		return resp.StatusCode, err
+	}
+	_ = json.NewDecoder(resp.Body).Decode(out)
+	return resp.StatusCode, nil
+}
+
+// RunDeviceCodeLoginFlow follows RFC 8628: request a user code, show it, then
+// poll the frontend until the token is issued. Nothing listens locally, so it
+// works where binding a loopback port is not allowed.
+func RunDeviceCodeLoginFlow(options BrowserLoginOptions) (BrowserLoginResult, error) {
+	if options.Timeout <= 0 {
+		options.Timeout = defaultLoginTimeout
+	}
+	base := NormalizeBaseURL(options.WebBaseURL)
+	if base == "" {
+		base = "https://6flow.studio"
+	}
+	client := newHTTPClient(20 * time.Second)
+
+	var code deviceCodeResponse
+	status, err := postDeviceJSON(client, base+"/api/tui/device/code", map[string]string{"client": "6flow-tui", "scope": RequestedLoginScope()}, &code)
+	if err != nil {
+		return BrowserLoginResult{}, err
+	}
+	if status == http.StatusNotFound {
+		return BrowserLoginResult{}, errors.New("frontend does not support device code login")
+	}
+	if status < 200 || status >= 300 || code.DeviceCode == "" || code.UserCode == "" {
+		if strings.TrimSpace(code.Error) != "" {
+			return BrowserLoginResult{}, fmt.Errorf("device code request failed: %s", code.Error)
+		}
+		return BrowserLoginResult{}, fmt.Errorf("device code request failed with status %d", status)
+	}
+
+	interval := time.Duration(code.Interval) * time.Second
+	if interval <= 0 {
+		interval = defaultDevicePollPeriod
+	}
+	deadline := time.Now().Add(options.Timeout)
+	if code.ExpiresIn > 0 {
+		if expires := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second); expires.Before(deadline) {
+			deadline = expires
+		}
+	}
+
+	verificationURL := code.VerificationURIComplete
+	if verificationURL == "" {
+		verificationURL = code.VerificationURI
+	}
+	if verificationURL == "" {
+		verificationURL = base + "/tui/device"
+	}
+	prompt := LoginPrompt{
+		URL:            verificationURL,
+		PairingCode:    code.UserCode,
+		BrowserSkipped: browserUnavailableReason(),
+		ExpiresAt:      deadline,
+	}
+	if prompt.BrowserSkipped == "" {
+		if err := tryOpenBrowser(verificationURL); err != nil {
+			prompt.BrowserSkipped = "could not launch browser: " + err.Error()
+		} else {
+			prompt.BrowserOpened = true
+		}
+	}
+	if options.OnPrompt != nil {
+		options.OnPrompt(prompt)
+	}
+
+	for {
+		time.Sleep(interval)
+		if time.Now().After(deadline) {
+			return BrowserLoginResult{}, errLoginLinkExpired
+		}
+
+		var tokenResp deviceTokenResponse
+		status, err := postDeviceJSON(client, base+"/api/tui/device/token", map[string]string{"deviceCode": code.DeviceCode}, &tokenResp)
+		if err != nil {
+			// Transient network errors should not abort a login the user is
+			// completing on another device.
+			continue
+		}
+		if status >= 200 && status < 300 && strings.TrimSpace(tokenResp.Token) != "" {
+			return BrowserLoginResult{Token: tokenResp.Token}, nil
+		}
+		switch tokenResp.Error {
+		case "authorization_pending", "":
+		case "slow_down":
+			interval += 5 * time.Second
+		case "expired_token":
+			return BrowserLoginResult{}, errLoginLinkExpired
+		case "access_denied":
+			return BrowserLoginResult{}, errors.New("login was denied in the browser")
+		default:
+			return BrowserLoginResult{}, fmt.Errorf("device login failed: %s", tokenResp.Error)
+		}
+	}
+}
@@ -7,18 +7,51 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
 	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 type BrowserLoginOptions struct {
 	WebBaseURL string
 	Timeout    time.Duration
+	// NonceTTL is how long the login link stays valid; it defaults to
+	// Timeout so the link lasts as long as the flow waits for it.
+	NonceTTL       time.Duration
+	AllowedOrigins []string
+	// CallbackHost and CallbackPort pin the loopback listener, e.g. to match a
+	// firewall exception or a pre-registered redirect URI. Defaults come from
+	// SIXFLOW_CALLBACK_HOST / SIXFLOW_CALLBACK_PORT, then 127.0.0.1 and a
+	// random port.
+	CallbackHost string
+	CallbackPort int
+	// FallbackToDeviceCode switches to the device code flow when the callback
+	// listener cannot be started on a random port; otherwise that is an error.
+	// A configured port is never replaced.
+	FallbackToDeviceCode bool
+	// OnPrompt is called once the login URL is ready, whether or not a browser
+	// could be opened, so callers can show it to the user.
+	OnPrompt func(LoginPrompt)
+}
+
+type LoginPrompt struct {
+	URL            string
+	PairingCode    string
+	CallbackPort   int
+	BrowserOpened  bool
+	BrowserSkipped string
+	// Fallback says why the device code flow runs instead of the callback
+	// one, empty when it was not a fallback.
+	Fallback  string
+	ExpiresAt time.Time
 }
 
 type BrowserLoginResult struct {
@@ -27,14 +60,29 @@ type BrowserLoginResult struct {
 
 type callbackBody struct {
 	Token string `json:"token"`
+	// Code is the PKCE authorization code; frontends that predate PKCE send
+	// Token instead.
+	Code  string `json:"code"`
 	Nonce string `json:"nonce"`
 }
 
 type callbackResult struct {
 	Token string
+	Code  string
 	Err   error
 }
 
+const (
+	defaultLoginTimeout       = 3 * time.Minute
+	maxCallbackBodyBytes      = 16 << 10
+	callbackAllowedOriginsEnv = "SIXFLOW_CALLBACK_ALLOWED_ORIGINS"
+	callbackHostEnv           = "SIXFLOW_CALLBACK_HOST"
+	callbackPortEnv           = "SIXFLOW_CALLBACK_PORT"
+	defaultCallbackHost       = "127.0.0.1"
+)
+
+var errLoginLinkExpired = errors.New("login link expired before the browser completed authentication")
+
 func randomNonce() (string, error) {
 	b := make([]byte, 16)
 	if _, err := rand.Read(b); err != nil {
@@ -43,7 +91,37 @@ func randomNonce() (string, error) {
 	return hex.EncodeToString(b), nil
 }
 
-func tryOpenBrowser(link string) {
+// pairingCodeFromNonce derives a short code shown both in the terminal and on
+// the login page so the user can confirm they are linking the right session.
+func pairingCodeFromNonce(nonce string) string {
+	code := strings.ToUpper(nonce)
+	if len(code) > 8 {
+		code = code[:8]
+	}
+	if len(code) <= 4 {
+		return code
+	}
+	return code[:4] + "-" + code[4:]
+}
+
+// browserUnavailableReason reports why a local browser is unlikely to work,
+// or "" when it is worth trying to open one.
+func browserUnavailableReason() string {
+	if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+		return "SSH session detected"
+	}
+	if runtime.GOOS == "linux" {
+		if _, err := os.Stat("/.dockerenv"); err == nil {
+			return "container detected"
+		}
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return "no graphical display available"
+		}
+	}
+	return ""
+}
+
+func tryOpenBrowser(link string) error {
 	var cmd *exec.Cmd
 	switch runtime.GOOS {
 	case "darwin":
@@ -53,71 +131,276 @@ func tryOpenBrowser(link string) {
 	default:
 		cmd = exec.Command("xdg-open", link)
 	}
-	_ = cmd.Start()
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go func() { _ = cmd.Wait() }()
+	return nil
+}
+
+// callbackListenAddress returns the address the callback listener binds and
+// whether its port was configured rather than left to the system.
+func callbackListenAddress(options BrowserLoginOptions) (string, bool, error) {
+	host := strings.TrimSpace(options.CallbackHost)
+	if host == "" {
+		host = strings.TrimSpace(os.Getenv(callbackHostEnv))
+	}
+	if host == "" {
+		host = defaultCallbackHost
+	}
+
+	port := options.CallbackPort
+	if port == 0 {
+		if raw := strings.TrimSpace(os.Getenv(callbackPortEnv)); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil {
+				return "", false, fmt.Errorf("%s must be a number, got %q", callbackPortEnv, raw)
+			}
+			port = parsed
+		}
+	}
+	if port < 0 || port > 65535 {
+		return "", false, fmt.Errorf("callback port %d is out of range", port)
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port)), port != 0, nil
+}
+
+// callbackURLHost is the host the browser should call back to; a wildcard
+// bind address is not routable, so it is replaced with loopback.
+func callbackURLHost(listenAddr net.Addr, configuredHost string) string {
+	_, port, _ := net.SplitHostPort(listenAddr.String())
+	host := strings.TrimSpace(configuredHost)
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = defaultCallbackHost
+	}
+	return net.JoinHostPort(host, port)
+}
+
+func originOf(rawURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return ""
+	}
+	return strings.ToLower(parsed.Scheme + "://" + parsed.Host)
+}
+
+func callbackAllowedOrigins(base string, configured []string) map[string]bool {
+	allowed := map[string]bool{}
+	if origin := originOf(base); origin != "" {
+		allowed[origin] = true
+	}
+	extra := append([]string(nil), configured...)
+	extra = append(extra, strings.Split(os.Getenv(callbackAllowedOriginsEnv), ",")...)
+	for _, value := range extra {
+		if origin := originOf(value); origin != "" {
+			allowed[origin] = true
+		}
+	}
+	return allowed
 }
 
-func sendJSON(w http.ResponseWriter, status int, body any) {
+func sendJSON(w http.ResponseWriter, origin string, status int, body any) {
 	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if origin != "" {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Vary", "Origin")
+	}
 	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
 	w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(body)
 }
 
+// sendCallbackPage answers a redirect-style callback with a page the user
+// sees in the browser tab.
+func sendCallbackPage(w http.ResponseWriter, status int, message string) {
+	title := "6flow TUI linked"
+	detail := "You can close this tab and return to the terminal."
+	if status != http.StatusOK {
+		title = "6flow TUI login failed"
+		detail = message + ". Return to the terminal and start the login again."
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(status)
+	fmt.Fprintf(w,
+		"<!doctype html><html><head><meta charset=\"utf-8\"><title>%s</title></head>"+
+			"<body style=\"font-family:sans-serif;margin:4rem auto;max-width:32rem\"><h1>%s</h1><p>%s</p></body></html>",
+		html.EscapeString(title), html.EscapeString(title), html.EscapeString(detail))
+}
+
 func RunBrowserLoginFlow(options BrowserLoginOptions) (BrowserLoginResult, error) {
 	if options.Timeout <= 0 {
-		options.Timeout = 3 * time.Minute
+		options.Timeout = defaultLoginTimeout
+	}
+	if options.NonceTTL <= 0 || options.NonceTTL > options.Timeout {
+		options.NonceTTL = options.Timeout
 	}
 
+	base := NormalizeBaseURL(options.WebBaseURL)
+	if base == "" {
+		base = "https://6flow.studio"
+	}
+	allowedOrigins := callbackAllowedOrigins(base, options.AllowedOrigins)
+
 	nonce, err := randomNonce()
 	if err != nil {
 		return BrowserLoginResult{}, err
 	}
+	nonceExpiresAt := time.Now().Add(options.NonceTTL)
+	pkce, err := newPKCEPair()
+	if err != nil {
+		return BrowserLoginResult{}, err
+	}
+	requirePKCE := pkceRequired()
 
-	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	listenAddr, pinnedPort, err := callbackListenAddress(options)
 	if err != nil {
 		return BrowserLoginResult{}, err
 	}
+	ln, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		if pinnedPort {
+			return BrowserLoginResult{}, fmt.Errorf("callback port configured for login is unavailable: %w", err)
+		}
+		if !options.FallbackToDeviceCode {
+			return BrowserLoginResult{}, fmt.Errorf("start login callback listener: %w", err)
+		}
+		reason := fmt.Sprintf("callback address %s unavailable (%v)", listenAddr, err)
+		onPrompt := options.OnPrompt
+		options.OnPrompt = func(prompt LoginPrompt) {
+			prompt.Fallback = reason
+			if onPrompt != nil {
+				onPrompt(prompt)
+			}
+		}
+		result, deviceErr := RunDeviceCodeLoginFlow(options)
+		if deviceErr != nil {
+			return BrowserLoginResult{}, fmt.Errorf("%s; device code login failed: %w", reason, deviceErr)
+		}
+		return result, nil
+	}
+	listenHost, _, _ := net.SplitHostPort(listenAddr)
 	defer ln.Close()
 
 	resultCh := make(chan callbackResult, 1)
 
+	var (
+		mu       sync.Mutex
+		consumed bool
+		server   *http.Server
+	)
+
+	// complete validates a callback and, on success, hands the token or code to
+	// the waiting flow. It returns the HTTP status and an error message.
+	complete := func(body callbackBody) (int, string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if consumed {
+			return http.StatusConflict, "Login already completed"
+		}
+		if time.Now().After(nonceExpiresAt) {
+			return http.StatusGone, "Login link expired"
+		}
+		if body.Nonce != nonce {
+			return http.StatusBadRequest, "Invalid nonce"
+		}
+		code := strings.TrimSpace(body.Code)
+		if code == "" && requirePKCE {
+			return http.StatusBadRequest, "Authorization code is required"
+		}
+		if code == "" && strings.TrimSpace(body.Token) == "" {
+			return http.StatusBadRequest, "Token is required"
+		}
+
+		consumed = true
+		select {
+		case resultCh <- callbackResult{Token: body.Token, Code: code}:
+		default:
+		}
+
+		// Stop accepting connections right away; in-flight responses still drain.
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			_ = server.Shutdown(ctx)
+		}()
+		return http.StatusOK, ""
+	}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
-		if r.Method == http.MethodOptions {
-			sendJSON(w, http.StatusNoContent, map[string]any{})
+		if r.Method == http.MethodGet {
+			// SSO redirect chains end in a top-level navigation, which sends no
+			// Origin header; when one is sent it must still be allowed. A URL
+			// ends up in history and logs, so it may only carry a code, which is
+			// worthless without the PKCE verifier, never a token.
+			if origin := strings.ToLower(strings.TrimSpace(r.Header.Get("Origin"))); origin != "" && !allowedOrigins[origin] {
+				sendCallbackPage(w, http.StatusForbidden, "Origin not allowed")
+				return
+			}
+			query := r.URL.Query()
+			if query.Has("token") {
+				sendCallbackPage(w, http.StatusBadRequest, "Tokens are not accepted in the callback URL")
+				return
+			}
+			if strings.TrimSpace(query.Get("code")) == "" {
+				sendCallbackPage(w, http.StatusBadRequest, "Authorization code is required")
+				return
+			}
+			body := callbackBody{Code: query.Get("code"), Nonce: query.Get("nonce")}
+			if body.Nonce == "" {
+				body.Nonce = query.Get("state")
+			}
+			status, message := complete(body)
+			sendCallbackPage(w, status, message)
 			return
 		}
-		if r.Method != http.MethodPost {
-			sendJSON(w, http.StatusNotFound, map[string]string{"error": "Not found"})
+
+		origin := strings.ToLower(strings.TrimSpace(r.Header.Get("Origin")))
+		if !allowedOrigins[origin] {
+			sendJSON(w, "", http.StatusForbidden, map[string]string{"error": "Origin not allowed"})
 			return
 		}
-
-		var body callbackBody
-		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 32_000)).Decode(&body); err != nil {
-			sendJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid JSON payload"})
+		if r.Method == http.MethodOptions {
+			sendJSON(w, origin, http.StatusNoContent, map[string]any{})
 			return
 		}
-
-		if body.Nonce != nonce {
-			sendJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid nonce"})
+		if r.Method != http.MethodPost {
+			sendJSON(w, origin, http.StatusNotFound, map[string]string{"error": "Not found"})
 			return
 		}
-		if strings.TrimSpace(body.Token) == "" {
-			sendJSON(w, http.StatusBadRequest, map[string]string{"error": "Token is required"})
+		if r.ContentLength > maxCallbackBodyBytes {
+			sendJSON(w, origin, http.StatusRequestEntityTooLarge, map[string]string{"error": "Payload too large"})
 			return
 		}
 
-		sendJSON(w, http.StatusOK, map[string]bool{"ok": true})
+		var body callbackBody
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxCallbackBodyBytes)).Decode(&body); err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				sendJSON(w, origin, http.StatusRequestEntityTooLarge, map[string]string{"error": "Payload too large"})
+				return
+			}
+			sendJSON(w, origin, http.StatusBadRequest, map[string]string{"error": "Invalid JSON payload"})
+			return
+		}
 
-		select {
-		case resultCh <- callbackResult{Token: body.Token}:
-		default:
+		status, message := complete(body)
+		if status != http.StatusOK {
+			sendJSON(w, origin, status, map[string]string{"error": message})
+			return
 		}
+		sendJSON(w, origin, http.StatusOK, map[string]bool{"ok": true})
 	})
 
-	server := &http.Server{Handler: mux}
+	server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		MaxHeaderBytes:    maxCallbackBodyBytes,
+	}
 	go func() {
 		if err := server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			select {
@@ -129,39 +412,70 @@ func RunBrowserLoginFlow(options BrowserLoginOptions) (BrowserLoginResult, error
 
 	callbackURL := url.URL{
 		Scheme: "http",
-		Host:   ln.Addr().String(),
+		Host:   callbackURLHost(ln.Addr(), listenHost),
 		Path:   "/callback",
 	}
 
-	base := NormalizeBaseURL(options.WebBaseURL)
-	if base == "" {
-		base = "https://6flow.studio"
-	}
-
+	pairingCode := pairingCodeFromNonce(nonce)
 	browserURL := fmt.Sprintf(
-		"%s/tui/link?callback=%s&nonce=%s",
+		"%s/tui/link?callback=%s&nonce=%s&pairing_code=%s&code_challenge=%s&code_challenge_method=%s&scope=%s",
 		base,
 		url.QueryEscape(callbackURL.String()),
 		url.QueryEscape(nonce),
+		url.QueryEscape(pairingCode),
+		url.QueryEscape(pkce.Challenge),
+		pkceChallengeMethod,
+		url.QueryEscape(RequestedLoginScope()),
 	)
-	tryOpenBrowser(browserURL)
+
+	prompt := LoginPrompt{
+		URL:            browserURL,
+		PairingCode:    pairingCode,
+		CallbackPort:   ln.Addr().(*net.TCPAddr).Port,
+		BrowserSkipped: browserUnavailableReason(),
+		ExpiresAt:      nonceExpiresAt,
+	}
+	if prompt.BrowserSkipped == "" {
+		if err := tryOpenBrowser(browserURL); err != nil {
+			prompt.BrowserSkipped = "could not launch browser: " + err.Error()
+		} else {
+			prompt.BrowserOpened = true
+		}
+	}
+	if options.OnPrompt != nil {
+		options.OnPrompt(prompt)
+	}
 
 	timer := time.NewTimer(options.Timeout)
 	defer timer.Stop()
+	nonceTimer := time.NewTimer(time.Until(nonceExpiresAt))
+	defer nonceTimer.Stop()
 
-	select {
-	case result := <-resultCh:
+	shutdown := func() {
 		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 		defer cancel()
 		_ = server.Shutdown(ctx)
+	}
+
+	select {
+	case result := <-resultCh:
+		shutdown()
 		if result.Err != nil {
 			return BrowserLoginResult{}, result.Err
 		}
+		if result.Code != "" {
+			token, err := exchangePKCECode(base, result.Code, pkce.Verifier, callbackURL.String())
+			if err != nil {
+				return BrowserLoginResult{}, err
+			}
+			return BrowserLoginResult{Token: token}, nil
+		}
 		return BrowserLoginResult{Token: result.Token}, nil
+	case <-nonceTimer.C:
+		shutdown()
+		return BrowserLoginResult{}, errLoginLinkExpired
 	case <-timer.C:
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-		_ = server.Shutdown(ctx)
+		shutdown()
 		return BrowserLoginResult{}, errors.New("authentication timed out")
 	}
 }
@@ -0,0 +1,84 @@
+package tui
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRunBrowserLoginFlowBusyCallbackPort(t *testing.T) {
+	busy, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	port := busy.Addr().(*net.TCPAddr).Port
+
+	tests := []struct {
+		name     string
+		options  BrowserLoginOptions
+		portEnv  string
+		wantText string
+	}{
+		{
+			name:     "configured port is not replaced",
+			options:  BrowserLoginOptions{CallbackPort: port, FallbackToDeviceCode: true},
+			wantText: "callback port configured for login is unavailable",
+		},
+		{
+			name:     "port from the environment is not replaced",
+			options:  BrowserLoginOptions{FallbackToDeviceCode: true},
+			portEnv:  strconv.Itoa(port),
+			wantText: "callback port configured for login is unavailable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(callbackPortEnv, tt.portEnv)
+			tt.options.WebBaseURL = "http://127.0.0.1:1"
+			prompted := false
+			tt.options.OnPrompt = func(LoginPrompt) { prompted = true }
+
+			_, err := RunBrowserLoginFlow(tt.options)
+			if err == nil || !strings.Contains(err.Error(), tt.wantText) {
+				t.Fatalf("RunBrowserLoginFlow() error = %v, want %q", err, tt.wantText)
+			}
+			if prompted {
+				t.Fatal("RunBrowserLoginFlow() prompted for another login flow")
+			}
+		})
+	}
+}
+
+func TestCallbackListenAddress(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    BrowserLoginOptions
+		portEnv    string
+		wantAddr   string
+		wantPinned bool
+		wantErr    bool
+	}{
+		{name: "defaults", wantAddr: "127.0.0.1:0"},
+		{name: "option port", options: BrowserLoginOptions{CallbackPort: 8765}, wantAddr: "127.0.0.1:8765", wantPinned: true},
+		{name: "env port", portEnv: "9000", wantAddr: "127.0.0.1:9000", wantPinned: true},
+		{name: "option wins over env", options: BrowserLoginOptions{CallbackPort: 8765}, portEnv: "9000", wantAddr: "127.0.0.1:8765", wantPinned: true},
+		{name: "host", options: BrowserLoginOptions{CallbackHost: "::1"}, wantAddr: "[::1]:0"},
+		{name: "bad env port", portEnv: "http", wantErr: true},
+		{name: "out of range", options: BrowserLoginOptions{CallbackPort: 70000}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(callbackPortEnv, tt.portEnv)
+			t.Setenv(callbackHostEnv, "")
+			addr, pinned, err := callbackListenAddress(tt.options)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("callbackListenAddress() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (addr != tt.wantAddr || pinned != tt.wantPinned) {
+				t.Fatalf("callbackListenAddress() = %q, %v; want %q, %v", addr, pinned, tt.wantAddr, tt.wantPinned)
+			}
+		})
+	}
+}
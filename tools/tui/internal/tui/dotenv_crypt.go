@@ -0,0 +1,314 @@
+package tui
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// A target .env can be kept encrypted at rest, e.g. as .env.staging.enc.
+// Reads and writes through readDotEnvValue and setDotEnvValue decrypt and
+// re-encrypt it in memory, and runs pass its entries to cre through the
+// environment, so the plaintext never lands on disk.
+const (
+	encryptedDotEnvSuffix = ".enc"
+	dotEnvPassphraseEnv   = "SIXFLOW_ENV_PASSPHRASE"
+	dotEnvCipher          = "aes-256-gcm"
+)
+
+var (
+	ErrDotEnvLocked     = errors.New("workflow .env is encrypted; passphrase required")
+	ErrDotEnvPassphrase = errors.New("could not decrypt the encrypted .env: wrong passphrase")
+)
+
+type encryptedDotEnvFile struct {
+	Version    int                `json:"version"`
+	KDF        string             `json:"kdf"`
+	KDFParams  keystoreScryptArgs `json:"kdfparams"`
+	Cipher     string             `json:"cipher"`
+	Nonce      string             `json:"nonce"`
+	CipherText string             `json:"ciphertext"`
+}
+
+var (
+	// dotEnvPassphrases holds the passphrases entered in this session, by
+	// encrypted file path. They are never written anywhere.
+	dotEnvPassphrases sync.Map
+	// dotEnvKeys caches scrypt output, which takes about a second to derive,
+	// by passphrase and salt.
+	dotEnvKeys sync.Map
+)
+
+func encryptedDotEnvPath(dotEnvPath string) string {
+	return dotEnvPath + encryptedDotEnvSuffix
+}
+
+func isDotEnvEncrypted(dotEnvPath string) bool {
+	exists, err := fileExists(encryptedDotEnvPath(dotEnvPath))
+	return err == nil && exists
+}
+
+func dotEnvPassphrase(dotEnvPath string) (string, bool) {
+	if passphrase, ok := dotEnvPassphrases.Load(encryptedDotEnvPath(dotEnvPath)); ok {
+		return passphrase.(string), true
+	}
+	if passphrase := os.Getenv(dotEnvPassphraseEnv); passphrase != "" {
+		return passphrase, true
+	}
+	return "", false
+}
+
+func dotEnvKey(passphrase string, params keystoreScryptArgs) ([]byte, error) {
+	if err := params.check(); err != nil {
+		return nil, err
+	}
+	cacheKey := fmt.Sprintf("%s\x00%s\x00%d/%d/%d/%d", passphrase, params.Salt, params.N, params.R, params.P, params.DKLen)
+	if key, ok := dotEnvKeys.Load(cacheKey); ok {
+		return key.([]byte), nil
+	}
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil {
+		return nil, err
+	}
+	key, err := scrypt.Key([]byte(passphrase), salt, params.N, params.R, params.P, params.DKLen)
+	if err != nil {
+		return nil, err
+	}
+	dotEnvKeys.Store(cacheKey, key)
+	return key, nil
+}
+
+func readEncryptedDotEnvFile(path string) (*encryptedDotEnvFile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file encryptedDotEnvFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", filepath.Base(path), err)
+	}
+	if file.Cipher != dotEnvCipher {
+		return nil, fmt.Errorf("%s: unsupported cipher %q", filepath.Base(path), file.Cipher)
+	}
+	return &file, nil
+}
+
+func decryptDotEnv(file *encryptedDotEnvFile, passphrase string) ([]byte, error) {
+	key, err := dotEnvKey(passphrase, file.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hex.DecodeString(file.Nonce)
+	if err != nil {
+		return nil, err
+	}
+	cipherText, err := hex.DecodeString(file.CipherText)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newDotEnvGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, nonce, cipherText, nil)
+	if err != nil {
+		return nil, ErrDotEnvPassphrase
+	}
+	return plain, nil
+}
+
+func newDotEnvGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptDotEnv seals content, keeping the salt of previous when there is one
+// so the cached key still applies; every write gets a fresh nonce.
+func encryptDotEnv(content []byte, passphrase string, previous *encryptedDotEnvFile) (*encryptedDotEnvFile, error) {
+	params := keystoreScryptArgs{DKLen: 32, N: keystoreScryptN, R: keystoreScryptR, P: keystoreScryptP}
+	if previous != nil {
+		params = previous.KDFParams
+	} else {
+		salt := make([]byte, 32)
+		if _, err := rand.Read(salt); err != nil {
+			return nil, err
+		}
+		params.Salt = hex.EncodeToString(salt)
+	}
+	key, err := dotEnvKey(passphrase, params)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newDotEnvGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return &encryptedDotEnvFile{
+		Version:    1,
+		KDF:        "scrypt",
+		KDFParams:  params,
+		Cipher:     dotEnvCipher,
+		Nonce:      hex.EncodeToString(nonce),
+		CipherText: hex.EncodeToString(gcm.Seal(nil, nonce, content, nil)),
+	}, nil
+}
+
+// readDotEnvContent returns the plaintext of a target .env, decrypting its
+// .enc counterpart when the workflow uses one.
+func readDotEnvContent(dotEnvPath string) ([]byte, error) {
+	if !isDotEnvEncrypted(dotEnvPath) {
+		return os.ReadFile(dotEnvPath)
+	}
+	passphrase, ok := dotEnvPassphrase(dotEnvPath)
+	if !ok {
+		return nil, ErrDotEnvLocked
+	}
+	file, err := readEncryptedDotEnvFile(encryptedDotEnvPath(dotEnvPath))
+	if err != nil {
+		return nil, err
+	}
+	return decryptDotEnv(file, passphrase)
+}
+
+// writeDotEnvContent is the counterpart of readDotEnvContent.
+func writeDotEnvContent(dotEnvPath string, content []byte) error {
+	if !isDotEnvEncrypted(dotEnvPath) {
+		if err := requireSecretFileUntracked(dotEnvPath); err != nil {
+			return err
+		}
+		return writeProjectFile(dotEnvPath, content, 0o600)
+	}
+	passphrase, ok := dotEnvPassphrase(dotEnvPath)
+	if !ok {
+		return ErrDotEnvLocked
+	}
+	encPath := encryptedDotEnvPath(dotEnvPath)
+	if err := requireSecretFileUntracked(encPath); err != nil {
+		return err
+	}
+	previous, err := readEncryptedDotEnvFile(encPath)
+	if err != nil {
+		return err
+	}
+	if _, err := decryptDotEnv(previous, passphrase); err != nil {
+		return err
+	}
+	sealed, err := encryptDotEnv(content, passphrase, previous)
+	if err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(sealed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeProjectFile(encPath, append(raw, '\n'), 0o600)
+}
+
+// requireDotEnvUnlocked fails early, with a hint, for an encrypted .env whose
+// passphrase is not known yet.
+func requireDotEnvUnlocked(dotEnvPath string) error {
+	if !isDotEnvEncrypted(dotEnvPath) {
+		return nil
+	}
+	if _, ok := dotEnvPassphrase(dotEnvPath); !ok {
+		return fmt.Errorf("%w (set %s or use Secrets -> ENCRYPT .ENV to unlock it)", ErrDotEnvLocked, dotEnvPassphraseEnv)
+	}
+	return nil
+}
+
+// IsWorkflowDotEnvEncrypted reports whether the workflow keeps the .env of
+// target encrypted.
+func IsWorkflowDotEnvEncrypted(workflowID, workflowName, target string) bool {
+	return isDotEnvEncrypted(workflowDotEnvPath(localWorkflowDir(workflowID, workflowName), target))
+}
+
+// EncryptWorkflowDotEnv moves the .env of target into its .enc counterpart.
+// When it is already encrypted it unlocks it instead: the passphrase is
+// checked and kept in memory for the rest of the session.
+func EncryptWorkflowDotEnv(workflowID, workflowName, target, passphrase string) (*SecretsCommandResult, error) {
+	logs := []string{}
+	appendLog := func(msg string) { logs = append(logs, msg) }
+
+	workflowDir := localWorkflowDir(workflowID, workflowName)
+	if _, err := os.Stat(workflowDir); err != nil {
+		return &SecretsCommandResult{Logs: logs}, errors.New("local workflow project not found. Run sync to local first")
+	}
+	dotEnvPath, err := migrateWorkflowDotEnv(workflowDir, target)
+	if err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	encPath := encryptedDotEnvPath(dotEnvPath)
+	dotEnvName, encName := filepath.Base(dotEnvPath), filepath.Base(encPath)
+
+	if isDotEnvEncrypted(dotEnvPath) {
+		file, err := readEncryptedDotEnvFile(encPath)
+		if err != nil {
+			return &SecretsCommandResult{Logs: logs}, err
+		}
+		if _, err := decryptDotEnv(file, passphrase); err != nil {
+			return &SecretsCommandResult{Logs: logs}, err
+		}
+		dotEnvPassphrases.Store(encPath, passphrase)
+		appendLog(fmt.Sprintf("Unlocked %s for this session.", encName))
+		return &SecretsCommandResult{Logs: logs}, nil
+	}
+
+	if len(passphrase) < keystoreMinPassphrase {
+		return &SecretsCommandResult{Logs: logs}, fmt.Errorf("passphrase must be at least %d characters", keystoreMinPassphrase)
+	}
+	release, err := lockDotEnv(dotEnvPath)
+	if err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	defer release()
+	content, err := os.ReadFile(dotEnvPath)
+	if err != nil && !os.IsNotExist(err) {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	sealed, err := encryptDotEnv(content, passphrase, nil)
+	if err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	raw, err := json.MarshalIndent(sealed, "", "  ")
+	if err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	if err := writeProjectFile(encPath, append(raw, '\n'), 0o600); err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	if !DryRun() {
+		if err := os.Remove(dotEnvPath); err != nil && !os.IsNotExist(err) {
+			return &SecretsCommandResult{Logs: logs}, err
+		}
+		dotEnvPassphrases.Store(encPath, passphrase)
+	}
+	appendLog(fmt.Sprintf("Encrypted %s into %s and removed the plaintext file.", dotEnvName, encName))
+	appendLog(fmt.Sprintf("Runs decrypt it in memory; set %s for headless use.", dotEnvPassphraseEnv))
+	appendLog(encName + " path: " + encPath)
+	return &SecretsCommandResult{Logs: logs}, nil
+}
+
+// dotEnvRunArgs is cre's `-e` flag for the target .env; it is left out for
+// an encrypted .env, whose entries go through the environment instead.
+func dotEnvRunArgs(workflowDirName, dotEnvPath string) []string {
+	if isDotEnvEncrypted(dotEnvPath) {
+		return nil
+	}
+	return []string{"-e", filepath.ToSlash(filepath.Join(workflowDirName, filepath.Base(dotEnvPath)))}
+}
@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fastDotEnvFile carries cheap scrypt parameters for encryptDotEnv to reuse,
+// so the tests do not pay for keystoreScryptN on every case.
+func fastDotEnvFile() *encryptedDotEnvFile {
+	return &encryptedDotEnvFile{
+		KDFParams: keystoreScryptArgs{DKLen: 32, N: 1 << 10, R: 8, P: 1, Salt: strings.Repeat("ab", 32)},
+	}
+}
+
+func TestDotEnvEncryptionRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"empty", ""},
+		{"single entry", "API_KEY=abc\n"},
+		{"multi-line value", "PEM=\"-----BEGIN-----\\nAAAA\\n-----END-----\"\nNEXT=1\n"},
+		{"binary-ish", "A=\x00\xff\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := encryptDotEnv([]byte(tt.content), "correct horse", fastDotEnvFile())
+			if err != nil {
+				t.Fatalf("encryptDotEnv() error = %v", err)
+			}
+			if file.Cipher != dotEnvCipher || file.KDF != "scrypt" || file.Version != 1 {
+				t.Fatalf("encryptDotEnv() header = %+v", file)
+			}
+			if tt.content != "" && strings.Contains(file.CipherText, tt.content) {
+				t.Fatal("ciphertext contains the plaintext")
+			}
+			plain, err := decryptDotEnv(file, "correct horse")
+			if err != nil {
+				t.Fatalf("decryptDotEnv() error = %v", err)
+			}
+			if !bytes.Equal(plain, []byte(tt.content)) {
+				t.Fatalf("decryptDotEnv() = %q, want %q", plain, tt.content)
+			}
+		})
+	}
+}
+
+func TestDecryptDotEnvFailures(t *testing.T) {
+	sealed, err := encryptDotEnv([]byte("API_KEY=abc\n"), "correct horse", fastDotEnvFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		mutate     func(*encryptedDotEnvFile)
+		passphrase string
+		wantErr    error
+	}{
+		{name: "wrong passphrase", passphrase: "battery staple", wantErr: ErrDotEnvPassphrase},
+		{
+			name:       "tampered ciphertext",
+			passphrase: "correct horse",
+			mutate: func(f *encryptedDotEnvFile) {
+				last := f.CipherText[len(f.CipherText)-1]
+				flipped := byte('0')
+				if last == '0' {
+					flipped = '1'
+				}
+				f.CipherText = f.CipherText[:len(f.CipherText)-1] + string(flipped)
+			},
+			wantErr: ErrDotEnvPassphrase,
+		},
+		{
+			name:       "oversized scrypt parameters",
+			passphrase: "correct horse",
+			mutate:     func(f *encryptedDotEnvFile) { f.KDFParams.N = 1 << 30 },
+		},
+		{
+			name:       "non-hex nonce",
+			passphrase: "correct horse",
+			mutate:     func(f *encryptedDotEnvFile) { f.Nonce = "zz" },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file := *sealed
+			if tt.mutate != nil {
+				tt.mutate(&file)
+			}
+			plain, err := decryptDotEnv(&file, tt.passphrase)
+			if err == nil {
+				t.Fatalf("decryptDotEnv() = %q, want an error", plain)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("decryptDotEnv() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEncryptDotEnvNonces(t *testing.T) {
+	previous := fastDotEnvFile()
+	first, err := encryptDotEnv([]byte("A=1\n"), "pass phrase", previous)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := encryptDotEnv([]byte("A=1\n"), "pass phrase", first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.KDFParams != second.KDFParams {
+		t.Fatalf("re-encryption changed the KDF parameters: %+v != %+v", first.KDFParams, second.KDFParams)
+	}
+	if first.Nonce == second.Nonce || first.CipherText == second.CipherText {
+		t.Fatal("re-encryption reused the nonce")
+	}
+}
+
+func TestEncryptDotEnvFreshSalt(t *testing.T) {
+	if testing.Short() {
+		t.Skip("derives a key with the production scrypt parameters")
+	}
+	file, err := encryptDotEnv([]byte("A=1\n"), "pass phrase", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.KDFParams.N != keystoreScryptN || len(file.KDFParams.Salt) != 64 {
+		t.Fatalf("fresh KDF parameters = %+v", file.KDFParams)
+	}
+	plain, err := decryptDotEnv(file, "pass phrase")
+	if err != nil || string(plain) != "A=1\n" {
+		t.Fatalf("decryptDotEnv() = %q, %v", plain, err)
+	}
+}
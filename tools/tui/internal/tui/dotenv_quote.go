@@ -0,0 +1,109 @@
+package tui
+
+import (
+	"errors"
+	"strings"
+)
+
+// Multi-line values such as PEM keys or JSON service accounts are written as
+// one double-quoted line with escapes, the form cre's dotenv loader expands:
+//
+//	SERVICE_ACCOUNT="{\n  \"type\": \"service_account\",\n ...}"
+//
+// "$" is escaped as well so the loader does not expand it as a variable.
+// Single-line values are written verbatim, as before.
+
+var errUnterminatedQuote = errors.New("unterminated quoted value")
+
+// encodeDotEnvValue returns value as it is written after KEY=.
+func encodeDotEnvValue(value string) string {
+	if !strings.ContainsAny(value, "\n\r") && !strings.HasPrefix(value, `"`) && !strings.HasPrefix(value, "'") {
+		return value
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '"', '\\', '$':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// decodeDotEnvValue is the inverse of encodeDotEnvValue for the text after
+// KEY=, which may span several lines when a quoted value holds literal
+// newlines. Double-quoted values expand \n, \r and \t and drop the backslash
+// before any other character; single-quoted values are taken as they are.
+func decodeDotEnvValue(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || (raw[0] != '"' && raw[0] != '\'') {
+		return raw, nil
+	}
+	quote := raw[0]
+	if len(raw) < 2 || raw[len(raw)-1] != quote || (quote == '"' && escapedAt(raw, len(raw)-1)) {
+		return "", errUnterminatedQuote
+	}
+	body := raw[1 : len(raw)-1]
+	if quote == '\'' {
+		return body, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' || i == len(body)-1 {
+			b.WriteByte(body[i])
+			continue
+		}
+		i++
+		switch body[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		default:
+			b.WriteByte(body[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// escapedAt reports whether s[i] follows an odd number of backslashes.
+func escapedAt(s string, i int) bool {
+	n := 0
+	for j := i - 1; j >= 0 && s[j] == '\\'; j-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// dotEnvEntryLines returns how many of lines, starting with the KEY=VALUE line
+// at lines[0], belong to that entry: more than one when a quoted value holds
+// literal newlines. An unterminated quote claims only its own line.
+func dotEnvEntryLines(lines []string) int {
+	_, value, _ := strings.Cut(lines[0], "=")
+	value = strings.TrimSpace(value)
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		return 1
+	}
+	joined := value
+	for n := 1; n <= len(lines); n++ {
+		if _, err := decodeDotEnvValue(joined); err == nil {
+			return n
+		}
+		if n == len(lines) {
+			break
+		}
+		joined += "\n" + strings.TrimRight(lines[n], "\r")
+	}
+	return 1
+}
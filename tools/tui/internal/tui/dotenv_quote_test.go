@@ -0,0 +1,78 @@
+package tui
+
+import "testing"
+
+func TestEncodeDotEnvValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{"plain", "abc123", "abc123"},
+		{"empty", "", ""},
+		{"dollar on one line", "a$b", "a$b"},
+		{"newline", "line1\nline2", `"line1\nline2"`},
+		{"carriage return", "a\r\nb", `"a\r\nb"`},
+		{"escapes quote backslash and dollar", "\"x\\$y\n", `"\"x\\\$y\n"`},
+		{"leading double quote", `"quoted"`, `"\"quoted\""`},
+		{"leading single quote", `'quoted'`, `"'quoted'"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := encodeDotEnvValue(tt.value); got != tt.want {
+				t.Fatalf("encodeDotEnvValue(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeDotEnvValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    string
+		wantErr bool
+	}{
+		{"plain", "abc", "abc", false},
+		{"trims spaces", "  abc  ", "abc", false},
+		{"empty", "", "", false},
+		{"double quoted escapes", `"a\nb\rc\td"`, "a\nb\rc\td", false},
+		{"escaped quote and dollar", `"say \"hi\" \$HOME"`, `say "hi" $HOME`, false},
+		{"literal newline", "\"line1\nline2\"", "line1\nline2", false},
+		{"single quoted is verbatim", `'a\nb'`, `a\nb`, false},
+		{"unterminated double", `"abc`, "", true},
+		{"unterminated single", `'abc`, "", true},
+		{"escaped closing quote", `"abc\"`, "", true},
+		{"lone quote", `"`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeDotEnvValue(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeDotEnvValue(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Fatalf("decodeDotEnvValue(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDotEnvValueRoundTrip(t *testing.T) {
+	values := []string{
+		"plain",
+		"-----BEGIN KEY-----\nAAAA\n-----END KEY-----\n",
+		`{"type": "service_account", "key": "a\\nb"}` + "\n",
+		`"starts with a quote`,
+		"cost: $5 \\ \"quoted\"\r\n",
+	}
+	for _, value := range values {
+		got, err := decodeDotEnvValue(encodeDotEnvValue(value))
+		if err != nil {
+			t.Fatalf("round trip of %q: %v", value, err)
+		}
+		if got != value {
+			t.Fatalf("round trip of %q = %q", value, got)
+		}
+	}
+}
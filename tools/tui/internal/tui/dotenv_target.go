@@ -0,0 +1,79 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Each workflow.yaml target keeps its own .env next to workflow.yaml:
+// staging-settings reads .env.staging and production-settings .env.production,
+// so switching targets keeps both sets of values.
+const legacyDotEnvName = ".env"
+
+// TargetDotEnvName is the .env file name used for target.
+func TargetDotEnvName(target string) string {
+	name := strings.TrimSuffix(strings.TrimSpace(target), "-settings")
+	if name == "" {
+		return legacyDotEnvName
+	}
+	return legacyDotEnvName + "." + slugify(name)
+}
+
+func dotEnvExists(dotEnvPath string) bool {
+	exists, err := fileExists(dotEnvPath)
+	return (err == nil && exists) || isDotEnvEncrypted(dotEnvPath)
+}
+
+// workflowDotEnvPath returns the .env file for target in workflowDir. It only
+// looks at the disk: in projects synced before per-target files, the single
+// .env stands in for the target file until migrateWorkflowDotEnv seeds it.
+func workflowDotEnvPath(workflowDir, target string) string {
+	legacyPath := filepath.Join(workflowDir, legacyDotEnvName)
+	path := filepath.Join(workflowDir, TargetDotEnvName(target))
+	if path == legacyPath || dotEnvExists(path) || !dotEnvExists(legacyPath) {
+		return path
+	}
+	return legacyPath
+}
+
+// migrateWorkflowDotEnv seeds the .env file of target from the legacy .env,
+// encrypted or not, along with its age sidecar, so existing values carry over,
+// and returns the path to write to. Secrets writes call it first; it holds the
+// project lock while copying and leaves the project alone in a dry run.
+func migrateWorkflowDotEnv(workflowDir, target string) (string, error) {
+	legacyPath := filepath.Join(workflowDir, legacyDotEnvName)
+	path := filepath.Join(workflowDir, TargetDotEnvName(target))
+	if workflowDotEnvPath(workflowDir, target) != legacyPath || path == legacyPath {
+		return path, nil
+	}
+	if DryRun() {
+		dryRunNote("would copy %s to %s", legacyPath, path)
+		return legacyPath, nil
+	}
+	release, err := lockDotEnv(path)
+	if err != nil {
+		return "", err
+	}
+	defer release()
+	if dotEnvExists(path) {
+		return path, nil
+	}
+	for _, suffix := range []string{"", encryptedDotEnvSuffix, secretsMetaSuffix} {
+		raw, err := os.ReadFile(legacyPath + suffix)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", fmt.Errorf("read %s: %w", legacyPath+suffix, err)
+		}
+		if err := os.WriteFile(path+suffix, raw, 0o600); err != nil {
+			return "", fmt.Errorf("seed %s: %w", path+suffix, err)
+		}
+	}
+	if passphrase, ok := dotEnvPassphrases.Load(encryptedDotEnvPath(legacyPath)); ok {
+		dotEnvPassphrases.Store(encryptedDotEnvPath(path), passphrase)
+	}
+	return path, nil
+}
@@ -0,0 +1,60 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWorkflowDotEnvMigration(t *testing.T) {
+	root := t.TempDir()
+	workflowDir := filepath.Join(root, "workflows", "project", "workflow")
+	if err := os.MkdirAll(workflowDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	legacyPath := filepath.Join(workflowDir, legacyDotEnvName)
+	targetPath := filepath.Join(workflowDir, ".env.staging")
+	for name, content := range map[string]string{
+		legacyPath:                     "API_KEY=legacy\n",
+		legacyPath + secretsMetaSuffix: `{"rotatedAt":{}}`,
+	} {
+		if err := os.WriteFile(name, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := snapshotTree(t, root)
+
+	// Resolving the path is read-only; the legacy file stands in.
+	if got := workflowDotEnvPath(workflowDir, "staging-settings"); got != legacyPath {
+		t.Fatalf("workflowDotEnvPath = %s, want %s", got, legacyPath)
+	}
+	SetDryRun(true)
+	got, err := migrateWorkflowDotEnv(workflowDir, "staging-settings")
+	SetDryRun(false)
+	if err != nil || got != legacyPath {
+		t.Fatalf("dry-run migrateWorkflowDotEnv = %s, %v; want %s", got, err, legacyPath)
+	}
+	if after := snapshotTree(t, root); !reflect.DeepEqual(before, after) {
+		t.Fatalf("project changed without a migration:\nbefore %v\nafter  %v", before, after)
+	}
+
+	got, err = migrateWorkflowDotEnv(workflowDir, "staging-settings")
+	if err != nil || got != targetPath {
+		t.Fatalf("migrateWorkflowDotEnv = %s, %v; want %s", got, err, targetPath)
+	}
+	for _, suffix := range []string{"", secretsMetaSuffix} {
+		want, _ := os.ReadFile(legacyPath + suffix)
+		have, err := os.ReadFile(targetPath + suffix)
+		if err != nil || string(have) != string(want) {
+			t.Fatalf("%s = %q, %v; want a copy of the legacy file", targetPath+suffix, have, err)
+		}
+	}
+	if got := workflowDotEnvPath(workflowDir, "staging-settings"); got != targetPath {
+		t.Fatalf("workflowDotEnvPath after migration = %s, want %s", got, targetPath)
+	}
+	// The project lock is released again.
+	if _, err := os.Stat(projectSyncLockPath(filepath.Dir(workflowDir))); !os.IsNotExist(err) {
+		t.Fatalf("project lock left behind: %v", err)
+	}
+}
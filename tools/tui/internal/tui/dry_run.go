@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// dryRunEnv starts with dry run on, e.g. SIXFLOW_DRY_RUN=1 for a headless
+// run that should only report what it would do.
+const dryRunEnv = "SIXFLOW_DRY_RUN"
+
+var dryRun atomic.Bool
+
+// SetDryRun switches dry run on or off. While on, simulate and secrets
+// actions report the commands, file writes and frontend updates they would
+// make through the frontend notice callback instead of performing them.
+func SetDryRun(on bool) {
+	dryRun.Store(on)
+}
+
+func DryRun() bool {
+	return dryRun.Load()
+}
+
+// ConfigureDryRun turns dry run on when SIXFLOW_DRY_RUN is set to a true
+// value and reports the resulting state.
+func ConfigureDryRun() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(dryRunEnv))) {
+	case "1", "true", "yes", "on":
+		SetDryRun(true)
+	}
+	return DryRun()
+}
+
+func dryRunNote(format string, args ...any) {
+	notifyFrontend("[dry-run] " + fmt.Sprintf(format, args...))
+}
+
+// shellQuote quotes an argument only when a shell would split or expand it.
+func shellQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n'\"\\$`*?[]{}()<>|&;#~!") {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// DescribeCommand renders a command line the way it would be typed in a
+// shell. Only the names of extra environment variables are shown since they
+// carry keys.
+func DescribeCommand(dir string, extraEnv []string, withStdin bool, name string, args []string) string {
+	parts := []string{}
+	for _, entry := range extraEnv {
+		key, _, _ := strings.Cut(entry, "=")
+		parts = append(parts, key+"="+redacted)
+	}
+	parts = append(parts, shellQuote(name))
+	for _, arg := range args {
+		parts = append(parts, shellQuote(arg))
+	}
+	line := strings.Join(parts, " ")
+	if withStdin {
+		line += " < (stdin)"
+	}
+	if dir != "" {
+		line = "(cd " + shellQuote(dir) + " && " + line + ")"
+	}
+	return line
+}
+
+// writeProjectFile writes a file of the local workflow project atomically,
+// or only reports the write in dry run.
+func writeProjectFile(path string, data []byte, perm os.FileMode) error {
+	if DryRun() {
+		dryRunNote("would write %s (%s)", path, FormatBytes(int64(len(data))))
+		return nil
+	}
+	return writeFileAtomic(path, data, perm)
+}
@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"os"
+	"path"
+	"runtime"
+	"strings"
+)
+
+// EnvPolicy decides which variables of the TUI's environment reach cre and
+// the package manager. It is read from "env" in ~/.6flow/config.json.
+type EnvPolicy struct {
+	// InheritAll forwards the whole environment except Deny, as older
+	// versions did.
+	InheritAll bool `json:"inheritAll,omitempty"`
+	// Allow adds names to defaultEnvAllow. Entries may end in "*".
+	Allow []string `json:"allow,omitempty"`
+	// Deny wins over Allow and InheritAll.
+	Deny []string `json:"deny,omitempty"`
+}
+
+// defaultEnvAllow is what the tools need to find binaries, write caches,
+// reach the network through a proxy and open a browser for `cre auth login`.
+var defaultEnvAllow = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "COLORTERM", "TZ",
+	"LANG", "LC_*", "TMPDIR", "TMP", "TEMP", "XDG_*",
+	"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "ALL_PROXY",
+	"http_proxy", "https_proxy", "no_proxy", "all_proxy",
+	"SSL_CERT_FILE", "SSL_CERT_DIR", "NODE_EXTRA_CA_CERTS",
+	"NODE_*", "NPM_CONFIG_*", "npm_config_*", "BUN_*", "PNPM_*", "YARN_*", "COREPACK_*",
+	"CRE_*", "DISPLAY", "WAYLAND_DISPLAY", "DBUS_SESSION_BUS_ADDRESS", "BROWSER",
+	"SYSTEMROOT", "SYSTEMDRIVE", "WINDIR", "COMSPEC", "PATHEXT", "USERPROFILE",
+	"APPDATA", "LOCALAPPDATA", "PROGRAMDATA", "PROGRAMFILES", "PROGRAMFILES(X86)",
+}
+
+// secretsCLIEnvAllow is what aws and gcloud read their credentials, profile
+// and region from; only the secret backends get it.
+var secretsCLIEnvAllow = []string{"AWS_*", "GOOGLE_*", "CLOUDSDK_*"}
+
+// defaultEnvDeny keeps the TUI's own settings, which include the auth token,
+// away from subprocesses.
+var defaultEnvDeny = []string{"SIXFLOW_*"}
+
+func loadEnvPolicy() EnvPolicy {
+	config, err := LoadTUIConfig()
+	if err != nil || config.Env == nil {
+		return EnvPolicy{}
+	}
+	return *config.Env
+}
+
+func envNameMatches(name string, patterns []string) bool {
+	if runtime.GOOS == "windows" {
+		name = strings.ToUpper(name)
+	}
+	for _, pattern := range patterns {
+		if runtime.GOOS == "windows" {
+			pattern = strings.ToUpper(pattern)
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filter returns the entries of environ the policy lets through, allowing
+// extraAllow on top of it.
+func (p EnvPolicy) filter(environ []string, extraAllow ...string) []string {
+	deny := append(append([]string{}, defaultEnvDeny...), p.Deny...)
+	allow := append(append(append([]string{}, defaultEnvAllow...), p.Allow...), extraAllow...)
+	out := make([]string, 0, len(environ))
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		if name == "" || envNameMatches(name, deny) {
+			continue
+		}
+		if p.InheritAll || envNameMatches(name, allow) {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
+
+// CommandEnv is the environment for a cre or package manager run: the
+// variables the policy forwards plus extra, which always passes.
+func CommandEnv(extra []string) []string {
+	return append(loadEnvPolicy().filter(os.Environ()), extra...)
+}
+
+// secretsCLIEnv is the environment for aws and gcloud: CommandEnv plus the
+// variables that carry their credentials, e.g. in CI or with SSO profiles.
+func secretsCLIEnv() []string {
+	return loadEnvPolicy().filter(os.Environ(), secretsCLIEnvAllow...)
+}
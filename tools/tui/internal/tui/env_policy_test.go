@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestEnvNameMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		want     bool
+	}{
+		{"PATH", []string{"PATH"}, true},
+		{"PATHEXT", []string{"PATH"}, false},
+		{"LC_ALL", []string{"LC_*"}, true},
+		{"LC", []string{"LC_*"}, false},
+		{"NODE_OPTIONS", []string{"HOME", "NODE_*"}, true},
+		{"GITHUB_TOKEN", defaultEnvAllow, false},
+		{"SIXFLOW_TOKEN", defaultEnvDeny, true},
+		{"AWS_PROFILE", nil, false},
+	}
+	for _, tt := range tests {
+		if got := envNameMatches(tt.name, tt.patterns); got != tt.want {
+			t.Errorf("envNameMatches(%q, %q) = %v, want %v", tt.name, tt.patterns, got, tt.want)
+		}
+	}
+
+	// Windows environment names are case-insensitive.
+	wantFolded := runtime.GOOS == "windows"
+	if got := envNameMatches("Path", []string{"PATH"}); got != wantFolded {
+		t.Errorf("envNameMatches(%q, [PATH]) = %v, want %v", "Path", got, wantFolded)
+	}
+}
+
+func TestEnvPolicyFilter(t *testing.T) {
+	environ := []string{
+		"PATH=/usr/bin",
+		"HOME=/home/me",
+		"LC_ALL=C",
+		"GITHUB_TOKEN=ghp_x",
+		"SIXFLOW_TOKEN=secret",
+		"AWS_PROFILE=dev",
+		"MY_VAR=1",
+		"=C:=C:\\",
+		"EMPTY=",
+	}
+	tests := []struct {
+		name       string
+		policy     EnvPolicy
+		extraAllow []string
+		want       []string
+	}{
+		{
+			name:   "defaults",
+			policy: EnvPolicy{},
+			want:   []string{"PATH=/usr/bin", "HOME=/home/me", "LC_ALL=C"},
+		},
+		{
+			name:   "allow adds names",
+			policy: EnvPolicy{Allow: []string{"MY_*", "EMPTY"}},
+			want:   []string{"PATH=/usr/bin", "HOME=/home/me", "LC_ALL=C", "MY_VAR=1", "EMPTY="},
+		},
+		{
+			name:   "deny wins over allow",
+			policy: EnvPolicy{Allow: []string{"MY_VAR"}, Deny: []string{"MY_VAR", "HOME"}},
+			want:   []string{"PATH=/usr/bin", "LC_ALL=C"},
+		},
+		{
+			name:   "inherit all keeps the default deny",
+			policy: EnvPolicy{InheritAll: true, Deny: []string{"GITHUB_*"}},
+			want:   []string{"PATH=/usr/bin", "HOME=/home/me", "LC_ALL=C", "AWS_PROFILE=dev", "MY_VAR=1", "EMPTY="},
+		},
+		{
+			name:       "extra allow for the secrets CLIs",
+			policy:     EnvPolicy{},
+			extraAllow: secretsCLIEnvAllow,
+			want:       []string{"PATH=/usr/bin", "HOME=/home/me", "LC_ALL=C", "AWS_PROFILE=dev"},
+		},
+		{
+			name:   "deny cannot be lifted by allow",
+			policy: EnvPolicy{Allow: []string{"SIXFLOW_TOKEN"}},
+			want:   []string{"PATH=/usr/bin", "HOME=/home/me", "LC_ALL=C"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.filter(environ, tt.extraAllow...); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("filter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
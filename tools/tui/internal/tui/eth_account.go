@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+// secp256k1 parameters. Only public key derivation is needed, so the curve is
+// done with math/big rather than pulling in an Ethereum library.
+var (
+	secp256k1P, _  = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC2F", 16)
+	secp256k1N, _  = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+	secp256k1Gx, _ = new(big.Int).SetString("79BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798", 16)
+	secp256k1Gy, _ = new(big.Int).SetString("483ADA7726A3C4655DA4FBFC0E1108A8FD17B448A68554199C47D08FFB10D4B8", 16)
+)
+
+var ErrInvalidPrivateKey = errors.New("CRE_ETH_PRIVATE_KEY is not a valid secp256k1 private key")
+
+type curvePoint struct {
+	x, y *big.Int
+}
+
+func (p curvePoint) infinity() bool {
+	return p.x == nil
+}
+
+func curveAdd(a, b curvePoint) curvePoint {
+	if a.infinity() {
+		return b
+	}
+	if b.infinity() {
+		return a
+	}
+	mod := secp256k1P
+	var slope *big.Int
+	if a.x.Cmp(b.x) == 0 {
+		if a.y.Cmp(b.y) != 0 || a.y.Sign() == 0 {
+			return curvePoint{}
+		}
+		// Doubling: (3x²) / (2y), the curve has a = 0.
+		num := new(big.Int).Mul(a.x, a.x)
+		num.Mul(num, big.NewInt(3))
+		den := new(big.Int).Lsh(a.y, 1)
+		slope = num.Mul(num, den.ModInverse(den, mod))
+	} else {
+		num := new(big.Int).Sub(b.y, a.y)
+		den := new(big.Int).Sub(b.x, a.x)
+		den.Mod(den, mod)
+		slope = num.Mul(num, den.ModInverse(den, mod))
+	}
+	slope.Mod(slope, mod)
+	x := new(big.Int).Mul(slope, slope)
+	x.Sub(x, a.x).Sub(x, b.x).Mod(x, mod)
+	y := new(big.Int).Sub(a.x, x)
+	y.Mul(y, slope).Sub(y, a.y).Mod(y, mod)
+	return curvePoint{x: x, y: y}
+}
+
+func curveScalarBaseMult(k *big.Int) curvePoint {
+	result := curvePoint{}
+	addend := curvePoint{x: secp256k1Gx, y: secp256k1Gy}
+	for i := 0; i < k.BitLen(); i++ {
+		if k.Bit(i) == 1 {
+			result = curveAdd(result, addend)
+		}
+		addend = curveAdd(addend, addend)
+	}
+	return result
+}
+
+// PrivateKeyAddress returns the EIP-55 checksummed Ethereum address of a hex
+// private key, with or without 0x.
+func PrivateKeyAddress(privateKey string) (string, error) {
+	if !isValidPrivateKey(privateKey) {
+		return "", ErrInvalidPrivateKey
+	}
+	k, _ := new(big.Int).SetString(strings.TrimPrefix(strings.TrimSpace(privateKey), "0x"), 16)
+	if k.Sign() == 0 || k.Cmp(secp256k1N) >= 0 {
+		return "", ErrInvalidPrivateKey
+	}
+	pub := curveScalarBaseMult(k)
+	raw := make([]byte, 64)
+	pub.x.FillBytes(raw[:32])
+	pub.y.FillBytes(raw[32:])
+	return checksumAddress(keccak256(raw)[12:]), nil
+}
+
+func checksumAddress(addr []byte) string {
+	lower := hex.EncodeToString(addr)
+	hash := hex.EncodeToString(keccak256([]byte(lower)))
+	out := []byte(lower)
+	for i, c := range out {
+		if c >= 'a' && hash[i] >= '8' {
+			out[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(out)
+}
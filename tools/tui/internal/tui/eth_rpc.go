@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// rpcTimeoutEnv bounds each JSON-RPC call to a chain node.
+const (
+	rpcTimeoutEnv     = "SIXFLOW_TIMEOUT_RPC"
+	defaultRPCTimeout = 10 * time.Second
+)
+
+type rpcRequest struct {
+	JSONRPC string `json:"jsonrpc"`
+	ID      int    `json:"id"`
+	Method  string `json:"method"`
+	Params  []any  `json:"params"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// ethCall sends one JSON-RPC request to a node from project.yaml. These are
+// third-party endpoints, so the frontend client and its headers are not used.
+func ethCall(rpcURL, method string, params []any, out any) error {
+	if params == nil {
+		params = []any{}
+	}
+	body, err := json.Marshal(rpcRequest{JSONRPC: "2.0", ID: 1, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: envTimeout(rpcTimeoutEnv, defaultRPCTimeout)}
+	resp, err := client.Post(rpcURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return timeoutHint(err, rpcTimeoutEnv)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s: HTTP %d", method, resp.StatusCode)
+	}
+	var decoded rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if decoded.Error != nil {
+		return fmt.Errorf("%s: %s (code %d)", method, decoded.Error.Message, decoded.Error.Code)
+	}
+	return json.Unmarshal(decoded.Result, out)
+}
+
+func ethQuantity(rpcURL, method string, params ...any) (*big.Int, error) {
+	var hexValue string
+	if err := ethCall(rpcURL, method, params, &hexValue); err != nil {
+		return nil, err
+	}
+	value, ok := new(big.Int).SetString(strings.TrimPrefix(hexValue, "0x"), 16)
+	if !ok {
+		return nil, fmt.Errorf("%s: unexpected result %q", method, hexValue)
+	}
+	return value, nil
+}
+
+// RPCChainID asks the node which chain it serves; it doubles as the
+// reachability check.
+func RPCChainID(rpcURL string) (*big.Int, error) {
+	return ethQuantity(rpcURL, "eth_chainId")
+}
+
+// RPCBalance returns the latest balance of address in wei.
+func RPCBalance(rpcURL, address string) (*big.Int, error) {
+	return ethQuantity(rpcURL, "eth_getBalance", address, "latest")
+}
+
+// FormatWei renders a wei amount in whole native units with up to six
+// decimals, e.g. "0.012345".
+func FormatWei(wei *big.Int) string {
+	if wei == nil {
+		return "0"
+	}
+	value := new(big.Rat).SetFrac(wei, new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+	text := strings.TrimRight(value.FloatString(6), "0")
+	return strings.TrimSuffix(text, ".")
+}
@@ -0,0 +1,172 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	fileLockTimeout    = 5 * time.Second
+	fileLockRetryDelay = 50 * time.Millisecond
+	// A lock older than this is assumed to belong to a crashed process.
+	fileLockStaleAfter = 2 * time.Minute
+)
+
+var ErrFileLocked = errors.New("file is locked by another 6flow TUI process")
+
+// TUIInstance records a running TUI so a second one started on the same
+// profile can warn before both refresh tokens or sync into the same folders.
+type TUIInstance struct {
+	PID       int    `json:"pid"`
+	StartedAt string `json:"startedAt"`
+	Profile   string `json:"profile"`
+}
+
+// acquireFileLock takes an advisory lock next to path using an exclusive
+// create, which behaves the same on every platform. The returned func
+// releases it.
+func acquireFileLock(path string) (func(), error) {
+	lockPath := path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0o700); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(fileLockTimeout)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			_, _ = f.WriteString(strconv.Itoa(os.Getpid()))
+			_ = f.Close()
+			return func() { _ = os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if lockIsStale(lockPath) {
+			_ = os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%w: %s", ErrFileLocked, lockPath)
+		}
+		time.Sleep(fileLockRetryDelay)
+	}
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so readers and crashes never leave half a file behind.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+	}
+	return err
+}
+
+func lockIsStale(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	if err != nil {
+		return false
+	}
+	if time.Since(info.ModTime()) > fileLockStaleAfter {
+		return true
+	}
+	raw, err := os.ReadFile(lockPath)
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(raw)))
+	if err != nil {
+		// Another process may still be writing its pid.
+		return false
+	}
+	return pid != os.Getpid() && !processAlive(pid)
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// FindProcess opens a handle on Windows and fails for dead pids.
+		_ = proc.Release()
+		return true
+	}
+	return proc.Signal(syscall.Signal(0)) == nil
+}
+
+func instanceFilePath(profile string) string {
+	return filepath.Join(sixflowHomeDir(), "instances", profile+".json")
+}
+
+// RegisterTUIInstance marks this process as the TUI for the active profile.
+// If another live instance already holds it, that instance is returned and
+// nothing is registered.
+func RegisterTUIInstance() (*TUIInstance, func(), error) {
+	profile := ActiveAuthProfile()
+	path := instanceFilePath(profile)
+	release, err := acquireFileLock(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	if raw, err := os.ReadFile(path); err == nil {
+		var other TUIInstance
+		if json.Unmarshal(raw, &other) == nil && other.PID != os.Getpid() && processAlive(other.PID) {
+			return &other, nil, nil
+		}
+	}
+
+	self := TUIInstance{
+		PID:       os.Getpid(),
+		StartedAt: time.Now().UTC().Format(time.RFC3339),
+		Profile:   profile,
+	}
+	content, err := json.MarshalIndent(&self, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		return nil, nil, err
+	}
+	return nil, func() {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return
+		}
+		var current TUIInstance
+		if json.Unmarshal(raw, &current) == nil && current.PID == self.PID {
+			_ = os.Remove(path)
+		}
+	}, nil
+}
@@ -7,8 +7,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"path"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -23,21 +25,42 @@ type FrontendWorkflow struct {
 }
 
 type workflowsResponse struct {
-	Workflows []FrontendWorkflow `json:"workflows"`
-	Error     string             `json:"error"`
+	Workflows  []FrontendWorkflow `json:"workflows"`
+	NextCursor string             `json:"nextCursor"`
+	apiErrorBody
 }
 
+// WorkflowPage is one page of the workflow list; NextCursor is empty on the
+// last page and for frontends that return everything at once. Stale marks a
+// first page served from the on-disk cache because the frontend could not be
+// reached; FetchedAt is when that copy was taken.
+type WorkflowPage struct {
+	Workflows  []FrontendWorkflow
+	NextCursor string
+	Stale      bool
+	FetchedAt  time.Time
+}
+
+const (
+	DefaultWorkflowPageSize = 100
+	maxWorkflowPages        = 200
+)
+
 type WorkflowBundle struct {
 	FileName string
 	Content  []byte
+	// SHA256 is the hex digest announced by the frontend, empty when the
+	// frontend does not provide one.
+	SHA256          string
+	CompilerVersion string
 }
 
 type bundleDownloadResponse struct {
 	DownloadURL     string `json:"downloadUrl"`
 	FileName        string `json:"fileName"`
 	CompilerVersion string `json:"compilerVersion"`
-	Error           string `json:"error"`
-	Detail          string `json:"detail"`
+	SHA256          string `json:"sha256"`
+	apiErrorBody
 }
 
 type workflowSecretUpdateRequest struct {
@@ -46,8 +69,8 @@ type workflowSecretUpdateRequest struct {
 }
 
 type workflowSecretUpdateResponse struct {
-	OK    bool   `json:"ok"`
-	Error string `json:"error"`
+	OK bool `json:"ok"`
+	apiErrorBody
 }
 
 var ErrFrontendUnauthorized = errors.New("unauthorized")
@@ -56,23 +79,90 @@ func NormalizeBaseURL(baseURL string) string {
 	return strings.TrimRight(baseURL, "/")
 }
 
-func FetchFrontendWorkflows(baseURL, token string) ([]FrontendWorkflow, error) {
-	url := NormalizeBaseURL(baseURL) + "/api/tui/workflows"
+// CheckFrontendReachable treats any HTTP response from the web app as
+// reachable; only transport failures are reported.
+func CheckFrontendReachable(baseURL string) error {
+	client := newHTTPClient(5 * time.Second)
+	req, err := http.NewRequest(http.MethodGet, NormalizeBaseURL(baseURL), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := doFrontendRequest(client, req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// IsFrontendUnreachable reports whether err means the frontend could not be
+// reached at all, as opposed to answering with an error.
+func IsFrontendUnreachable(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) && !errors.Is(err, ErrOperationCancelled)
+}
 
-	client := &http.Client{Timeout: 20 * time.Second}
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+// FetchFrontendWorkflowPage loads one page of the workflow list. A frontend
+// that cannot be reached or answers 5xx is an error, so callers never act on
+// an outdated list.
+func FetchFrontendWorkflowPage(baseURL, token, cursor string, limit int, filter WorkflowFilter) (*WorkflowPage, error) {
+	return fetchFrontendWorkflowPage(baseURL, token, cursor, limit, filter, false)
+}
+
+// FetchCachedFrontendWorkflowPage is FetchFrontendWorkflowPage for display:
+// while the frontend is down it returns the cached first page marked Stale
+// instead of failing.
+func FetchCachedFrontendWorkflowPage(baseURL, token, cursor string, limit int, filter WorkflowFilter) (*WorkflowPage, error) {
+	return fetchFrontendWorkflowPage(baseURL, token, cursor, limit, filter, true)
+}
+
+func fetchFrontendWorkflowPage(baseURL, token, cursor string, limit int, filter WorkflowFilter, allowStale bool) (*WorkflowPage, error) {
+	if limit <= 0 {
+		limit = DefaultWorkflowPageSize
+	}
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(limit))
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	filter.apply(query)
+	endpoint := NormalizeBaseURL(baseURL) + "/api/tui/workflows?" + query.Encode()
+
+	client := newHTTPClient(listTimeout())
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := client.Do(req)
+	// Only the unfiltered first page is cached; it is what every refresh
+	// starts with.
+	cacheable := cursor == "" && limit == DefaultWorkflowPageSize && filter.IsZero()
+	var cache *workflowListCache
+	if cacheable {
+		cache = loadWorkflowCache(baseURL, token, limit)
+		if cache != nil && cache.ETag != "" {
+			req.Header.Set("If-None-Match", cache.ETag)
+		}
+	}
+
+	resp, err := doFrontendRequest(client, req)
 	if err != nil {
+		if allowStale && cache != nil {
+			return cache.page(true), nil
+		}
 		return nil, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && cache != nil {
+		return cache.page(false), nil
+	}
+	if resp.StatusCode >= 500 && allowStale && cache != nil {
+		return cache.page(true), nil
+	}
+
 	var payload workflowsResponse
 	_ = json.NewDecoder(resp.Body).Decode(&payload)
 
@@ -84,17 +174,72 @@ func FetchFrontendWorkflows(baseURL, token string) ([]FrontendWorkflow, error) {
 	}
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if payload.Error != "" {
-			return nil, errors.New(payload.Error)
-		}
-		return nil, fmt.Errorf("request failed with status %d", resp.StatusCode)
+		return nil, payload.apiError(resp.StatusCode)
 	}
 
 	if payload.Workflows == nil {
 		return nil, errors.New("invalid API response from /api/tui/workflows")
 	}
 
-	return payload.Workflows, nil
+	page := &WorkflowPage{Workflows: payload.Workflows, NextCursor: payload.NextCursor, FetchedAt: time.Now()}
+	if cacheable {
+		saveWorkflowCache(baseURL, token, limit, resp.Header.Get("ETag"), page)
+	}
+	return page, nil
+}
+
+// FetchFrontendWorkflows follows nextCursor until the whole (filtered) list is
+// loaded. It never falls back to the offline cache.
+func FetchFrontendWorkflows(baseURL, token string, filter WorkflowFilter) ([]FrontendWorkflow, error) {
+	workflows := []FrontendWorkflow{}
+	cursor := ""
+	seen := map[string]bool{}
+	for range maxWorkflowPages {
+		page, err := FetchFrontendWorkflowPage(baseURL, token, cursor, DefaultWorkflowPageSize, filter)
+		if err != nil {
+			return nil, err
+		}
+		workflows = append(workflows, page.Workflows...)
+		if page.NextCursor == "" || seen[page.NextCursor] {
+			return workflows, nil
+		}
+		seen[page.NextCursor] = true
+		cursor = page.NextCursor
+	}
+	return nil, fmt.Errorf("workflow list exceeded %d pages", maxWorkflowPages)
+}
+
+// PingFrontendSession is a cheap authenticated request used to notice a
+// revoked token or a down server between user actions. Frontends without the
+// ping endpoint are checked with the workflow list instead.
+func PingFrontendSession(baseURL, token string) error {
+	url := NormalizeBaseURL(baseURL) + "/api/tui/auth/ping"
+
+	client := newHTTPClient(10 * time.Second)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := doFrontendRequest(client, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return ErrFrontendUnauthorized
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		_, err := FetchFrontendWorkflowPage(baseURL, token, "", 1, WorkflowFilter{})
+		return err
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("ping failed with status %d", resp.StatusCode)
+	}
+	return nil
 }
 
 func parseFileNameFromDisposition(header string) string {
@@ -107,17 +252,26 @@ func parseFileNameFromDisposition(header string) string {
 }
 
 func DownloadWorkflowBundle(baseURL, token, workflowID string) (*WorkflowBundle, error) {
-	url := fmt.Sprintf("%s/api/tui/workflows/%s/bundle", NormalizeBaseURL(baseURL), workflowID)
+	return DownloadWorkflowBundleVersion(baseURL, token, workflowID, "")
+}
 
-	client := &http.Client{Timeout: 60 * time.Second}
-	req, err := http.NewRequest(http.MethodGet, url, nil)
+// DownloadWorkflowBundleVersion downloads an older compiled artifact listed
+// by FetchBundleVersions; an empty version is the current one.
+func DownloadWorkflowBundleVersion(baseURL, token, workflowID, version string) (*WorkflowBundle, error) {
+	endpoint := fmt.Sprintf("%s/api/tui/workflows/%s/bundle", NormalizeBaseURL(baseURL), workflowID)
+	if version != "" {
+		endpoint += "?version=" + url.QueryEscape(version)
+	}
+
+	client := newHTTPClient(bundleTimeout())
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := client.Do(req)
+	resp, err := doFrontendRequest(client, req)
 	if err != nil {
 		return nil, err
 	}
@@ -132,53 +286,36 @@ func DownloadWorkflowBundle(baseURL, token, workflowID string) (*WorkflowBundle,
 		return nil, ErrFrontendUnauthorized
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		message := strings.TrimSpace(metadata.Error)
-		if message == "" {
-			message = fmt.Sprintf("request failed with status %d", resp.StatusCode)
-		}
-		if strings.TrimSpace(metadata.Detail) != "" {
-			message = message + ": " + strings.TrimSpace(metadata.Detail)
-		}
-		return nil, errors.New(message)
+		return nil, metadata.apiError(resp.StatusCode)
 	}
 	if strings.TrimSpace(metadata.DownloadURL) == "" {
 		return nil, errors.New("bundle endpoint returned no downloadUrl")
 	}
 
-	zipReq, err := http.NewRequest(http.MethodGet, metadata.DownloadURL, nil)
+	content, zipHeader, err := downloadBundleResumable(client, metadata.DownloadURL, workflowID, metadata.CompilerVersion)
 	if err != nil {
 		return nil, err
 	}
-	zipReq.Header.Set("Accept", "application/zip")
-
-	zipResp, err := client.Do(zipReq)
-	if err != nil {
-		return nil, err
-	}
-	defer zipResp.Body.Close()
-	if zipResp.StatusCode < 200 || zipResp.StatusCode >= 300 {
-		return nil, fmt.Errorf("failed to fetch compiled artifact zip (status %d)", zipResp.StatusCode)
-	}
-
-	body := new(bytes.Buffer)
-	if _, err := io.Copy(body, zipResp.Body); err != nil {
-		return nil, err
-	}
 
 	fileName := strings.TrimSpace(metadata.FileName)
 	if fileName == "" {
-		fileName = parseFileNameFromDisposition(zipResp.Header.Get("Content-Disposition"))
+		fileName = parseFileNameFromDisposition(zipHeader.Get("Content-Disposition"))
 	}
 	return &WorkflowBundle{
-		FileName: fileName,
-		Content:  body.Bytes(),
+		FileName:        fileName,
+		Content:         content,
+		SHA256:          strings.ToLower(strings.TrimSpace(metadata.SHA256)),
+		CompilerVersion: strings.TrimSpace(metadata.CompilerVersion),
 	}, nil
 }
 
 func UpdateWorkflowSecretInFrontend(baseURL, token, workflowID, action, secretName string) error {
 	url := fmt.Sprintf("%s/api/tui/workflows/%s/secrets", NormalizeBaseURL(baseURL), workflowID)
 
-	normalizedSecret := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(secretName), " ", "_"))
+	normalizedSecret := NormalizeFrontendSecretName(secretName)
+	if err := ValidateEnvVarName(normalizedSecret); err != nil {
+		return err
+	}
 	payload := workflowSecretUpdateRequest{
 		Action:     strings.TrimSpace(strings.ToLower(action)),
 		SecretName: normalizedSecret,
@@ -187,8 +324,12 @@ func UpdateWorkflowSecretInFrontend(baseURL, token, workflowID, action, secretNa
 	if err != nil {
 		return err
 	}
+	if DryRun() {
+		dryRunNote("would POST %s %s", url, body)
+		return nil
+	}
 
-	client := &http.Client{Timeout: 20 * time.Second}
+	client := newHTTPClient(secretsTimeout())
 	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
 		return err
@@ -196,8 +337,9 @@ func UpdateWorkflowSecretInFrontend(baseURL, token, workflowID, action, secretNa
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
+	setIdempotencyKey(req)
 
-	resp, err := client.Do(req)
+	resp, err := doFrontendRequest(client, req)
 	if err != nil {
 		return err
 	}
@@ -210,11 +352,56 @@ func UpdateWorkflowSecretInFrontend(baseURL, token, workflowID, action, secretNa
 		return ErrFrontendUnauthorized
 	}
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		if strings.TrimSpace(result.Error) != "" {
-			return errors.New(strings.TrimSpace(result.Error))
+		apiErr := result.apiError(resp.StatusCode)
+		// A retry whose first attempt was applied finds the secret already
+		// added or already removed; that is the state we asked for.
+		switch {
+		case payload.Action == "add" && (resp.StatusCode == http.StatusConflict || apiErr.Code == APIErrorSecretExists):
+			return nil
+		case payload.Action == "remove" && apiErr.Code == APIErrorSecretNotFound:
+			return nil
 		}
-		return fmt.Errorf("request failed with status %d", resp.StatusCode)
+		return apiErr
+	}
+
+	return nil
+}
+
+var ErrRevocationUnsupported = errors.New("frontend does not support token revocation")
+
+// RevokeFrontendToken asks the frontend to invalidate the bearer token. A 401
+// means the token is already unusable, which is the outcome we want.
+func RevokeFrontendToken(baseURL, token string) error {
+	if strings.TrimSpace(token) == "" {
+		return nil
 	}
+	url := NormalizeBaseURL(baseURL) + "/api/tui/auth/revoke"
 
+	client := newHTTPClient(10 * time.Second)
+	req, err := http.NewRequest(http.MethodPost, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	setIdempotencyKey(req)
+
+	resp, err := doFrontendRequest(client, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result apiErrorBody
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		return ErrRevocationUnsupported
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return result.apiError(resp.StatusCode)
+	}
 	return nil
 }
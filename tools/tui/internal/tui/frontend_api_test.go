@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestFetchFrontendWorkflowsIgnoresOfflineCache(t *testing.T) {
+	useTempHome(t)
+	t.Setenv(retryAttemptsEnv, "1")
+
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"workflows":[{"id":"wf-1","name":"Demo","updatedAt":1,"status":"ready"}],"nextCursor":""}`))
+	}))
+	defer server.Close()
+
+	workflows, err := FetchFrontendWorkflows(server.URL, "token", WorkflowFilter{})
+	if err != nil || len(workflows) != 1 {
+		t.Fatalf("FetchFrontendWorkflows() = %v, %v; want one workflow", workflows, err)
+	}
+
+	down.Store(true)
+	tests := []struct {
+		name      string
+		fetch     func() (*WorkflowPage, error)
+		wantStale bool
+	}{
+		{
+			name: "strict page",
+			fetch: func() (*WorkflowPage, error) {
+				return FetchFrontendWorkflowPage(server.URL, "token", "", DefaultWorkflowPageSize, WorkflowFilter{})
+			},
+		},
+		{
+			name: "all pages",
+			fetch: func() (*WorkflowPage, error) {
+				workflows, err := FetchFrontendWorkflows(server.URL, "token", WorkflowFilter{})
+				if err != nil {
+					return nil, err
+				}
+				return &WorkflowPage{Workflows: workflows}, nil
+			},
+		},
+		{
+			name: "display page",
+			fetch: func() (*WorkflowPage, error) {
+				return FetchCachedFrontendWorkflowPage(server.URL, "token", "", DefaultWorkflowPageSize, WorkflowFilter{})
+			},
+			wantStale: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := tt.fetch()
+			if !tt.wantStale {
+				if err == nil {
+					t.Fatalf("fetch while the frontend is down = %+v, want an error", page)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("fetch error = %v, want the cached page", err)
+			}
+			if !page.Stale || len(page.Workflows) != 1 || page.Workflows[0].ID != "wf-1" {
+				t.Fatalf("fetch = %+v, want the stale cached page", page)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const healthCheckTimeout = 5 * time.Second
+
+// FrontendHealth is the result of the startup probe of /api/tui/health.
+// Frontends without the endpoint answer 404; they are reachable but report
+// no version, and Supported is false.
+type FrontendHealth struct {
+	Supported bool
+	Healthy   bool
+	Status    string
+	Version   string
+	Latency   time.Duration
+}
+
+type frontendHealthResponse struct {
+	Status  string `json:"status"`
+	Version string `json:"version"`
+}
+
+// CheckFrontendHealth sends one unauthenticated request, without retries, so
+// the latency reflects a single round trip. An error means the frontend could
+// not be reached at all.
+func CheckFrontendHealth(baseURL string) (*FrontendHealth, error) {
+	client := newHTTPClient(healthCheckTimeout)
+	req, err := http.NewRequest(http.MethodGet, NormalizeBaseURL(baseURL)+"/api/tui/health", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	started := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	health := &FrontendHealth{Latency: time.Since(started)}
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		health.Healthy = true
+		return health, nil
+	}
+	health.Supported = true
+	var payload frontendHealthResponse
+	_ = json.NewDecoder(resp.Body).Decode(&payload)
+	health.Status = strings.TrimSpace(payload.Status)
+	health.Version = strings.TrimSpace(payload.Version)
+	if health.Status == "" {
+		health.Status = http.StatusText(resp.StatusCode)
+	}
+	health.Healthy = resp.StatusCode >= 200 && resp.StatusCode < 300 &&
+		(payload.Status == "" || strings.EqualFold(health.Status, "ok"))
+	return health, nil
+}
@@ -0,0 +1,231 @@
+package tui
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	mathrand "math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	retryAttemptsEnv  = "SIXFLOW_HTTP_RETRIES"
+	retryBaseDelayEnv = "SIXFLOW_HTTP_RETRY_DELAY"
+
+	defaultRetryAttempts  = 3
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	maxRetryDelay         = 8 * time.Second
+
+	maxRateLimitRetries = 3
+	// Longer waits are reported instead of blocking the caller.
+	maxRetryAfter = 2 * time.Minute
+)
+
+// RetryPolicy controls how frontend requests are repeated after transient
+// failures. Only transport errors and 5xx responses are retried; 4xx answers
+// are final. POST and PATCH requests are only retried when they carry an
+// Idempotency-Key, since the first attempt may have been applied.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// FrontendRetryPolicy reads SIXFLOW_HTTP_RETRIES (total attempts, "1"
+// disables retries) and SIXFLOW_HTTP_RETRY_DELAY (first backoff, e.g.
+// "250ms").
+func FrontendRetryPolicy() RetryPolicy {
+	policy := RetryPolicy{
+		MaxAttempts: defaultRetryAttempts,
+		BaseDelay:   defaultRetryBaseDelay,
+		MaxDelay:    maxRetryDelay,
+	}
+	if raw := strings.TrimSpace(os.Getenv(retryAttemptsEnv)); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed >= 1 {
+			policy.MaxAttempts = parsed
+		}
+	}
+	if raw := strings.TrimSpace(os.Getenv(retryBaseDelayEnv)); raw != "" {
+		if parsed, err := time.ParseDuration(raw); err == nil && parsed >= 0 {
+			policy.BaseDelay = parsed
+		}
+	}
+	return policy
+}
+
+// backoff returns the full-jitter delay before the given retry (1-based).
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := 1; i < retry && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return time.Duration(mathrand.Int64N(int64(delay)) + 1)
+}
+
+func retryableStatus(status int) bool {
+	return status >= 500 && status <= 599
+}
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+// setIdempotencyKey marks a mutating request as safe to repeat. The key is set
+// once per logical operation, so every retry of req sends the same key and
+// the frontend can answer a repeat with the stored result.
+func setIdempotencyKey(req *http.Request) {
+	var raw [16]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return
+	}
+	req.Header.Set(idempotencyKeyHeader, hex.EncodeToString(raw[:]))
+}
+
+// retrySafe reports whether repeating req after a lost or failed answer
+// cannot apply it twice.
+func retrySafe(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(idempotencyKeyHeader) != ""
+}
+
+// ErrRateLimited is returned when the frontend keeps answering 429 or asks
+// for a longer wait than the client is willing to block for.
+var ErrRateLimited = errors.New("rate limited by frontend")
+
+var (
+	frontendNoticeMu sync.Mutex
+	frontendNotice   func(string)
+)
+
+// SetFrontendNotice installs a callback for progress messages such as rate
+// limit waits. It may be called from any goroutine.
+func SetFrontendNotice(fn func(string)) {
+	frontendNoticeMu.Lock()
+	frontendNotice = fn
+	frontendNoticeMu.Unlock()
+}
+
+func notifyFrontend(message string) {
+	frontendNoticeMu.Lock()
+	fn := frontendNotice
+	frontendNoticeMu.Unlock()
+	if fn != nil {
+		fn(message)
+	}
+}
+
+// parseRetryAfter accepts both forms of Retry-After: delay seconds or an
+// HTTP date.
+func parseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// doFrontendRequest sends req with the configured retry policy. Request
+// bodies must be replayable, which http.NewRequest arranges for byte
+// readers. 429 responses are retried after Retry-After on their own budget,
+// since the frontend did not process the request; transport errors and 5xx
+// answers only for requests that are retrySafe. Other failures return the
+// last response or error unchanged.
+func doFrontendRequest(client *http.Client, req *http.Request) (*http.Response, error) {
+	// Requests without their own context can be aborted with CancelOperation.
+	if req.Context() == context.Background() {
+		req = req.WithContext(OperationContext())
+	}
+	ctx := req.Context()
+	policy := FrontendRetryPolicy()
+	attempt, rateLimited := 1, 0
+	for {
+		resp, err := client.Do(req)
+		if err != nil && ctx.Err() != nil {
+			return nil, cancelledError(ctx, err)
+		}
+		if err == nil {
+			if incompatible := incompatibleResponse(resp); incompatible != nil {
+				drainResponse(resp)
+				return nil, incompatible
+			}
+		}
+		replayable := req.Body == nil || req.GetBody != nil
+
+		var wait time.Duration
+		switch {
+		case err == nil && resp.StatusCode == http.StatusTooManyRequests:
+			retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+			if !ok {
+				retryAfter = policy.backoff(rateLimited + 1)
+			}
+			rateLimited++
+			if !replayable || rateLimited > maxRateLimitRetries || retryAfter > maxRetryAfter {
+				drainResponse(resp)
+				return nil, fmt.Errorf("%w; retry after %s", ErrRateLimited, retryAfter.Round(time.Second))
+			}
+			wait = retryAfter
+			notifyFrontend(fmt.Sprintf("Frontend rate limited the request, retrying in %s...", formatRetryWait(wait)))
+		case err == nil && !retryableStatus(resp.StatusCode):
+			return resp, nil
+		default:
+			if attempt >= policy.MaxAttempts || !replayable || !retrySafe(req) {
+				return resp, err
+			}
+			attempt++
+			wait = policy.backoff(attempt - 1)
+		}
+
+		drainResponse(resp)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, cancelledError(ctx, ctx.Err())
+		}
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			req.Body = body
+		}
+	}
+}
+
+func drainResponse(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+func formatRetryWait(wait time.Duration) string {
+	if wait < time.Second {
+		return wait.Round(time.Millisecond).String()
+	}
+	return fmt.Sprintf("%ds", int(wait.Round(time.Second)/time.Second))
+}
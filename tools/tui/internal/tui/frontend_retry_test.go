@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header string
+		want   time.Duration
+		wantOK bool
+	}{
+		{header: "", want: 0, wantOK: false},
+		{header: "   ", want: 0, wantOK: false},
+		{header: "0", want: 0, wantOK: true},
+		{header: "120", want: 2 * time.Minute, wantOK: true},
+		{header: " 5 ", want: 5 * time.Second, wantOK: true},
+		{header: "-1", want: 0, wantOK: false},
+		{header: "1.5", want: 0, wantOK: false},
+		{header: "soon", want: 0, wantOK: false},
+		{header: now.Add(30 * time.Second).Format(http.TimeFormat), want: 30 * time.Second, wantOK: true},
+		{header: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, wantOK: true},
+		{header: "Sunday, 01-Mar-26 12:00:10 GMT", want: 10 * time.Second, wantOK: true},
+	}
+	for _, tt := range tests {
+		got, ok := parseRetryAfter(tt.header, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.header, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 350 * time.Millisecond}
+	tests := []struct {
+		retry int
+		max   time.Duration
+	}{
+		{retry: 1, max: 100 * time.Millisecond},
+		{retry: 2, max: 200 * time.Millisecond},
+		{retry: 3, max: 350 * time.Millisecond},
+		{retry: 10, max: 350 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 50; i++ {
+			if got := policy.backoff(tt.retry); got <= 0 || got > tt.max {
+				t.Fatalf("backoff(%d) = %v, want in (0, %v]", tt.retry, got, tt.max)
+			}
+		}
+	}
+	if got := (RetryPolicy{}).backoff(1); got != 0 {
+		t.Fatalf("backoff without a base delay = %v, want 0", got)
+	}
+}
+
+func TestRetrySafe(t *testing.T) {
+	tests := []struct {
+		method         string
+		idempotencyKey bool
+		want           bool
+	}{
+		{method: http.MethodGet, want: true},
+		{method: http.MethodHead, want: true},
+		{method: http.MethodOptions, want: true},
+		{method: http.MethodPut, want: true},
+		{method: http.MethodDelete, want: true},
+		{method: http.MethodPost, want: false},
+		{method: http.MethodPatch, want: false},
+		{method: http.MethodPost, idempotencyKey: true, want: true},
+		{method: http.MethodPatch, idempotencyKey: true, want: true},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, "https://example.test/api/tui/workflows", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.idempotencyKey {
+			setIdempotencyKey(req)
+		}
+		if got := retrySafe(req); got != tt.want {
+			t.Errorf("retrySafe(%s, key=%v) = %v, want %v", tt.method, tt.idempotencyKey, got, tt.want)
+		}
+	}
+}
+
+func TestSetIdempotencyKeyIsUnique(t *testing.T) {
+	seen := map[string]bool{}
+	for i := 0; i < 100; i++ {
+		req, err := http.NewRequest(http.MethodPost, "https://example.test/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		setIdempotencyKey(req)
+		key := req.Header.Get(idempotencyKeyHeader)
+		if len(key) != 32 {
+			t.Fatalf("Idempotency-Key = %q, want 32 hex characters", key)
+		}
+		if seen[key] {
+			t.Fatalf("Idempotency-Key %q repeated", key)
+		}
+		seen[key] = true
+	}
+}
@@ -0,0 +1,242 @@
+package tui
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// proxyEnv overrides HTTPS_PROXY/HTTP_PROXY for frontend requests; set
+	// it to "direct" to bypass an inherited proxy.
+	proxyEnv   = "SIXFLOW_PROXY"
+	noProxyEnv = "SIXFLOW_NO_PROXY"
+
+	// For self-hosted frontends behind internal PKI: extra root CAs (PEM)
+	// and an optional client certificate for mTLS.
+	caFileEnv     = "SIXFLOW_CA_FILE"
+	clientCertEnv = "SIXFLOW_CLIENT_CERT"
+	clientKeyEnv  = "SIXFLOW_CLIENT_KEY"
+)
+
+// Per-operation timeouts, overridable with a duration ("90s", "5m"); "0"
+// removes the limit.
+const (
+	listTimeoutEnv    = "SIXFLOW_TIMEOUT_LIST"
+	bundleTimeoutEnv  = "SIXFLOW_TIMEOUT_BUNDLE"
+	secretsTimeoutEnv = "SIXFLOW_TIMEOUT_SECRETS"
+
+	defaultListTimeout    = 20 * time.Second
+	defaultBundleTimeout  = 60 * time.Second
+	defaultSecretsTimeout = 20 * time.Second
+)
+
+func envTimeout(name string, fallback time.Duration) time.Duration {
+	raw := strings.TrimSpace(os.Getenv(name))
+	if raw == "" {
+		return fallback
+	}
+	if parsed, err := time.ParseDuration(raw); err == nil && parsed >= 0 {
+		return parsed
+	}
+	return fallback
+}
+
+// timeoutHint names the variable that raises the limit when err is a timeout.
+func timeoutHint(err error, env string) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%w (set %s to allow more time)", err, env)
+	}
+	return err
+}
+
+func listTimeout() time.Duration    { return envTimeout(listTimeoutEnv, defaultListTimeout) }
+func bundleTimeout() time.Duration  { return envTimeout(bundleTimeoutEnv, defaultBundleTimeout) }
+func secretsTimeout() time.Duration { return envTimeout(secretsTimeoutEnv, defaultSecretsTimeout) }
+
+var (
+	sharedTransportOnce sync.Once
+	sharedTransport     http.RoundTripper
+)
+
+// newHTTPClient returns a client for frontend requests. All clients share one
+// transport so connections are reused and the proxy and TLS settings apply
+// everywhere.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	sharedTransportOnce.Do(func() {
+		tlsConfig, err := frontendTLSConfig()
+		if err != nil {
+			// Fail every request with the configuration error instead of
+			// silently falling back to the system roots.
+			sharedTransport = failingTransport{err: err}
+			return
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.Proxy = proxyForRequest
+		transport.TLSClientConfig = tlsConfig
+		// compressionTransport negotiates encodings itself.
+		transport.DisableCompression = true
+		sharedTransport = versionTransport{base: traceTransport{base: compressionTransport{base: transport}}}
+	})
+	return &http.Client{Timeout: timeout, Transport: sharedTransport}
+}
+
+type failingTransport struct {
+	err error
+}
+
+func (t failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, t.err
+}
+
+// frontendTLSConfig builds the TLS settings from SIXFLOW_CA_FILE,
+// SIXFLOW_CLIENT_CERT and SIXFLOW_CLIENT_KEY. The CA file adds to the system
+// roots rather than replacing them. It returns nil when nothing is configured.
+func frontendTLSConfig() (*tls.Config, error) {
+	caFile := strings.TrimSpace(os.Getenv(caFileEnv))
+	certFile := strings.TrimSpace(os.Getenv(clientCertEnv))
+	keyFile := strings.TrimSpace(os.Getenv(clientKeyEnv))
+	if caFile == "" && certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", caFileEnv, err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no PEM certificates found in %s", caFileEnv, caFile)
+		}
+		config.RootCAs = pool
+	}
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, errors.New(clientCertEnv + " and " + clientKeyEnv + " must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
+
+// proxyForRequest applies SIXFLOW_PROXY/SIXFLOW_NO_PROXY when set and falls
+// back to the standard HTTP(S)_PROXY and NO_PROXY variables otherwise.
+func proxyForRequest(req *http.Request) (*url.URL, error) {
+	explicit := strings.TrimSpace(os.Getenv(proxyEnv))
+	switch strings.ToLower(explicit) {
+	case "direct", "none", "off":
+		return nil, nil
+	}
+	if raw, ok := os.LookupEnv(noProxyEnv); ok && bypassProxy(req.URL, raw) {
+		return nil, nil
+	}
+	if explicit == "" {
+		return http.ProxyFromEnvironment(req)
+	}
+	if bypassProxy(req.URL, firstEnv("NO_PROXY", "no_proxy")) {
+		return nil, nil
+	}
+	if !strings.Contains(explicit, "://") {
+		explicit = "http://" + explicit
+	}
+	return url.Parse(explicit)
+}
+
+// FrontendProxy describes the proxy used for baseURL, without credentials,
+// or returns "" for a direct connection.
+func FrontendProxy(baseURL string) string {
+	req, err := http.NewRequest(http.MethodGet, NormalizeBaseURL(baseURL), nil)
+	if err != nil {
+		return ""
+	}
+	proxy, err := proxyForRequest(req)
+	if err != nil || proxy == nil {
+		return ""
+	}
+	return proxy.Redacted()
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// bypassProxy matches target against a NO_PROXY style list: "*", domain
+// suffixes ("example.com" or ".example.com"), host:port pairs, IPs and CIDR
+// ranges. Loopback hosts never go through the proxy.
+func bypassProxy(target *url.URL, list string) bool {
+	host := strings.ToLower(target.Hostname())
+	port := target.Port()
+	if port == "" {
+		port = "80"
+		if target.Scheme == "https" {
+			port = "443"
+		}
+	}
+	ip := net.ParseIP(host)
+	if host == "localhost" || (ip != nil && ip.IsLoopback()) {
+		return true
+	}
+
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		entryHost, entryPort := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = h, p
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+		if entryIP := net.ParseIP(entryHost); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		entryHost = strings.TrimPrefix(entryHost, "*")
+		if strings.HasPrefix(entryHost, ".") {
+			if strings.HasSuffix(host, entryHost) || host == entryHost[1:] {
+				return true
+			}
+			continue
+		}
+		if host == entryHost || strings.HasSuffix(host, "."+entryHost) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package tui
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// acceptedEncodings are the response encodings the frontend client decodes.
+// zstd is not offered: the standard library has no decoder for it.
+const acceptedEncodings = "gzip, deflate"
+
+// compressionTransport asks for compressed JSON responses and decodes them,
+// which matters for large workflow lists on slow links. Bundle zips, byte
+// ranges and event streams are requested as-is: zips do not shrink, ranges
+// must address the stored bytes, and a compressor would buffer events.
+type compressionTransport struct {
+	base http.RoundTripper
+}
+
+func (t compressionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !wantsCompression(req) {
+		return t.base.RoundTrip(req)
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", acceptedEncodings)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+	if encoding != "gzip" && encoding != "deflate" {
+		return resp, nil
+	}
+	resp.Body = &decodingBody{encoding: encoding, raw: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return resp, nil
+}
+
+func wantsCompression(req *http.Request) bool {
+	if req.Method == http.MethodHead || req.Header.Get("Accept-Encoding") != "" || req.Header.Get("Range") != "" {
+		return false
+	}
+	accept := req.Header.Get("Accept")
+	return !strings.Contains(accept, "text/event-stream") && !strings.Contains(accept, "application/zip")
+}
+
+// decodingBody creates the decoder on first read, so empty bodies (304,
+// errors without content) do not fail on a missing gzip header.
+type decodingBody struct {
+	encoding string
+	raw      io.ReadCloser
+	decoder  io.Reader
+	err      error
+}
+
+func (b *decodingBody) Read(p []byte) (int, error) {
+	if b.decoder == nil && b.err == nil {
+		if b.encoding == "gzip" {
+			b.decoder, b.err = gzip.NewReader(b.raw)
+		} else {
+			b.decoder, b.err = zlib.NewReader(b.raw)
+		}
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.decoder.Read(p)
+}
+
+func (b *decodingBody) Close() error {
+	return b.raw.Close()
+}
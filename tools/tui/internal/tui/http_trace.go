@@ -0,0 +1,283 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// httpTraceEnv turns on request tracing: "1"/"true" writes to
+// ~/.6flow/http-trace.log, any other value is taken as the log file path.
+const httpTraceEnv = "SIXFLOW_HTTP_TRACE"
+
+// maxTraceBody caps how much of a JSON body is copied into the trace.
+const maxTraceBody = 4 << 10
+
+const redacted = "[redacted]"
+
+var (
+	httpTraceMu   sync.Mutex
+	httpTracePath string
+	httpTraceFile *os.File
+)
+
+func defaultHTTPTracePath() string {
+	return filepath.Join(sixflowHomeDir(), "http-trace.log")
+}
+
+// EnableHTTPTrace starts appending a line per frontend request to path, or to
+// ~/.6flow/http-trace.log when path is empty. Tokens, secret values and
+// signed URL parameters are redacted before anything is written.
+func EnableHTTPTrace(path string) error {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		path = defaultHTTPTracePath()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open HTTP trace log: %w", err)
+	}
+	httpTraceMu.Lock()
+	defer httpTraceMu.Unlock()
+	if httpTraceFile != nil {
+		httpTraceFile.Close()
+	}
+	httpTracePath = path
+	httpTraceFile = file
+	fmt.Fprintf(file, "%s trace started (client %s)\n", time.Now().Format(time.RFC3339), ClientVersion)
+	return nil
+}
+
+// ConfigureHTTPTrace enables tracing from SIXFLOW_HTTP_TRACE and returns the
+// log file in use, or "" when tracing is off.
+func ConfigureHTTPTrace() (string, error) {
+	raw := strings.TrimSpace(os.Getenv(httpTraceEnv))
+	path := raw
+	switch strings.ToLower(raw) {
+	case "", "0", "false", "no", "off":
+		return HTTPTracePath(), nil
+	case "1", "true", "yes", "on":
+		path = ""
+	}
+	if err := EnableHTTPTrace(path); err != nil {
+		return "", err
+	}
+	return HTTPTracePath(), nil
+}
+
+// HTTPTracePath returns the trace log in use, or "" when tracing is off.
+func HTTPTracePath() string {
+	httpTraceMu.Lock()
+	defer httpTraceMu.Unlock()
+	return httpTracePath
+}
+
+func writeHTTPTrace(entry string) {
+	httpTraceMu.Lock()
+	defer httpTraceMu.Unlock()
+	if httpTraceFile != nil {
+		_, _ = io.WriteString(httpTraceFile, entry)
+	}
+}
+
+// traceTransport logs method, URL, status and latency of every request when
+// tracing is on. It sits inside versionTransport so the logged headers are
+// the ones sent, and outside compressionTransport so bodies are plain JSON.
+type traceTransport struct {
+	base http.RoundTripper
+}
+
+func (t traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if HTTPTracePath() == "" {
+		return t.base.RoundTrip(req)
+	}
+	started := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	latency := time.Since(started).Round(time.Millisecond)
+
+	var entry strings.Builder
+	fmt.Fprintf(&entry, "%s %s %s", started.Format("2006-01-02T15:04:05.000Z07:00"), req.Method, redactURL(req.URL))
+	if err != nil {
+		fmt.Fprintf(&entry, " error after %s: %v\n", latency, err)
+	} else {
+		fmt.Fprintf(&entry, " %d %s\n", resp.StatusCode, latency)
+	}
+	if headers := traceHeaders(req.Header); headers != "" {
+		fmt.Fprintf(&entry, "  > %s\n", headers)
+	}
+	if body := traceRequestBody(req); body != "" {
+		fmt.Fprintf(&entry, "  > %s\n", body)
+	}
+	if err == nil && (resp.StatusCode < 200 || resp.StatusCode >= 300) {
+		if body := traceResponseBody(resp); body != "" {
+			fmt.Fprintf(&entry, "  < %s\n", body)
+		}
+	}
+	writeHTTPTrace(entry.String())
+	return resp, err
+}
+
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+}
+
+func traceHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		if name == "Accept" || name == "User-Agent" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		value := strings.Join(header.Values(name), ", ")
+		if sensitiveHeaders[name] {
+			scheme, _, found := strings.Cut(value, " ")
+			if found {
+				value = scheme + " " + redacted
+			} else {
+				value = redacted
+			}
+		}
+		parts = append(parts, name+": "+value)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// sensitiveQueryParam matches the token and signature parameters of signed
+// bundle download URLs.
+func sensitiveQueryParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, marker := range []string{"token", "sig", "credential", "secret", "key", "code"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	clean := *u
+	clean.User = nil
+	if clean.RawQuery != "" {
+		query := clean.Query()
+		for name := range query {
+			if sensitiveQueryParam(name) {
+				query.Set(name, redacted)
+			}
+		}
+		clean.RawQuery = query.Encode()
+	}
+	return clean.String()
+}
+
+// Request bodies carry login codes and secret values; error bodies only
+// carry messages, so their "code" field is kept.
+var (
+	sensitiveRequestFields  = []string{"token", "code", "verifier", "password", "passphrase", "value", "privatekey", "secret"}
+	sensitiveResponseFields = []string{"token", "verifier", "password", "passphrase", "value", "privatekey"}
+)
+
+func traceRequestBody(req *http.Request) string {
+	if req.GetBody == nil || !strings.Contains(req.Header.Get("Content-Type"), "json") {
+		return ""
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	data, _ := io.ReadAll(io.LimitReader(body, maxTraceBody+1))
+	return redactJSONBody(data, sensitiveRequestFields)
+}
+
+// traceResponseBody copies the start of an error body into the trace and
+// puts it back in front of the rest for the caller.
+func traceResponseBody(resp *http.Response) string {
+	if resp.Body == nil || !strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		return ""
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, maxTraceBody+1))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(data), resp.Body), resp.Body}
+	return redactJSONBody(data, sensitiveResponseFields)
+}
+
+func redactJSONBody(data []byte, sensitive []string) string {
+	if len(data) == 0 {
+		return ""
+	}
+	if len(data) > maxTraceBody {
+		return fmt.Sprintf("[%d+ byte body not traced]", maxTraceBody)
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "[unparsable body not traced]"
+	}
+	out, err := json.Marshal(redactJSONValue(value, sensitive))
+	if err != nil {
+		return ""
+	}
+	return string(out)
+}
+
+// redactJSONValue blanks the values of fields whose name contains one of the
+// sensitive markers, at any depth.
+func redactJSONValue(value any, sensitive []string) any {
+	switch typed := value.(type) {
+	case map[string]any:
+		for key, inner := range typed {
+			if sensitiveJSONField(key, sensitive) {
+				typed[key] = redacted
+				continue
+			}
+			typed[key] = redactJSONValue(inner, sensitive)
+		}
+		return typed
+	case []any:
+		for i, inner := range typed {
+			typed[i] = redactJSONValue(inner, sensitive)
+		}
+		return typed
+	}
+	return value
+}
+
+// sensitiveJSONField matches "secret" only as a whole name, so secret names
+// such as secretName stay readable in the trace.
+func sensitiveJSONField(key string, sensitive []string) bool {
+	key = strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+	for _, marker := range sensitive {
+		if marker == "secret" {
+			if key == "secret" || key == "clientsecret" {
+				return true
+			}
+			continue
+		}
+		if strings.Contains(key, marker) {
+			return true
+		}
+	}
+	return false
+}
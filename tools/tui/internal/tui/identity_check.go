@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TokenOrganization returns the organization name the frontend token was
+// issued for, when the frontend includes one.
+func TokenOrganization(token string) string {
+	claims := decodeJWTClaims(token)
+	for _, claim := range []string{"org_name", "organization", "org"} {
+		if value, ok := claims[claim].(string); ok && strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// IdentityMismatch compares the frontend account with the CRE CLI login and
+// describes the difference, or returns "" when they agree or cannot be
+// compared. Only emails and organization names are compared; opaque ids
+// from the two systems never match each other.
+func IdentityMismatch(token string, cre *CREWhoAmIResult) string {
+	if cre == nil {
+		return ""
+	}
+	account := strings.ToLower(TokenAccountLabel(token))
+	creEmail := strings.ToLower(strings.TrimSpace(cre.Identity))
+	if strings.Contains(account, "@") && strings.Contains(creEmail, "@") && account != creEmail {
+		return fmt.Sprintf("6flow account %s is different from CRE login %s", account, creEmail)
+	}
+	org := TokenOrganization(token)
+	if org != "" && cre.Organization != "" && !strings.EqualFold(org, cre.Organization) {
+		return fmt.Sprintf("6flow organization %q is different from CRE organization %q", org, cre.Organization)
+	}
+	return ""
+}
+
+// RecordSessionCREIdentity remembers which CRE login was used with the active
+// profile's saved session, so the next start can name it if `cre whoami`
+// fails or reports someone else.
+func RecordSessionCREIdentity(identity string) error {
+	return updateSessionFile(func(session *AuthSession) bool {
+		if session.CREIdentity == identity {
+			return false
+		}
+		session.CREIdentity = identity
+		return true
+	})
+}
+
+// updateSessionFile applies change to the saved session of the active profile
+// under its lock. Nothing is written when there is no saved session or change
+// reports no difference.
+func updateSessionFile(change func(*AuthSession) bool) error {
+	path := sessionFilePath()
+	release, err := acquireFileLock(path)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	session, err := readSessionFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if !change(session) {
+		return nil
+	}
+	content, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o600)
+}
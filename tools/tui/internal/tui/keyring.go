@@ -0,0 +1,145 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const keyringService = "6flow-tui"
+
+var errKeyringUnavailable = errors.New("no OS keyring available")
+
+// The keyring is driven through the platform's own CLI tools so no cgo or
+// D-Bus bindings are needed. Secrets are always passed on stdin, never argv.
+
+func keyringAvailable() bool {
+	switch runtime.GOOS {
+	case "darwin":
+		_, err := exec.LookPath("security")
+		return err == nil
+	case "windows":
+		_, err := exec.LookPath("powershell")
+		return err == nil
+	default:
+		if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+			return false
+		}
+		_, err := exec.LookPath("secret-tool")
+		return err == nil
+	}
+}
+
+func runKeyringTool(stdin string, name string, args ...string) (string, error) {
+	cmd := exec.Command(name, args...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	out, err := cmd.CombinedOutput()
+	trimmed := strings.TrimSpace(string(out))
+	if err != nil {
+		if trimmed == "" {
+			trimmed = err.Error()
+		}
+		return "", fmt.Errorf("%s: %s", name, trimmed)
+	}
+	return trimmed, nil
+}
+
+// securityCommandLine builds one line for `security -i`, which splits its
+// input on unquoted whitespace and unescapes backslashes itself, so every
+// argument is double-quoted with only " and \ escaped; other bytes, UTF-8
+// included, pass through as they are. A line break would end the command
+// early, so arguments holding one are refused.
+func securityCommandLine(args ...string) (string, error) {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if strings.ContainsAny(arg, "\r\n\x00") {
+			return "", errors.New("keychain values cannot contain line breaks or NUL bytes")
+		}
+		var b strings.Builder
+		b.WriteByte('"')
+		for j := 0; j < len(arg); j++ {
+			if arg[j] == '"' || arg[j] == '\\' {
+				b.WriteByte('\\')
+			}
+			b.WriteByte(arg[j])
+		}
+		b.WriteByte('"')
+		quoted[i] = b.String()
+	}
+	return strings.Join(quoted, " ") + "\n", nil
+}
+
+func powershellVaultScript(body string) string {
+	return "$ErrorActionPreference='Stop';" +
+		"[void][Windows.Security.Credentials.PasswordVault,Windows.Security.Credentials,ContentType=WindowsRuntime];" +
+		"$v=New-Object Windows.Security.Credentials.PasswordVault;" + body
+}
+
+func keyringSet(account, secret string) error {
+	if !keyringAvailable() {
+		return errKeyringUnavailable
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		command, err := securityCommandLine("add-generic-password", "-U", "-a", account, "-s", keyringService, "-w", secret)
+		if err != nil {
+			return err
+		}
+		_, err = runKeyringTool(command, "security", "-i")
+		return err
+	case "windows":
+		script := powershellVaultScript(fmt.Sprintf(
+			"try{$v.Remove($v.Retrieve('%s','%s'))}catch{};"+
+				"$v.Add((New-Object Windows.Security.Credentials.PasswordCredential('%s','%s',[Console]::In.ReadToEnd().Trim())))",
+			keyringService, account, keyringService, account))
+		_, err := runKeyringTool(secret, "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+		return err
+	default:
+		_, err := runKeyringTool(secret, "secret-tool", "store", "--label=6flow TUI session ("+account+")", "service", keyringService, "account", account)
+		return err
+	}
+}
+
+func keyringGet(account string) (string, error) {
+	return keyringLookup(keyringService, account)
+}
+
+// keyringLookup reads any service's entry, for keychain:// references in .env.
+func keyringLookup(service, account string) (string, error) {
+	if !keyringAvailable() {
+		return "", errKeyringUnavailable
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return runKeyringTool("", "security", "find-generic-password", "-a", account, "-s", service, "-w")
+	case "windows":
+		script := powershellVaultScript(fmt.Sprintf(
+			"$c=$v.Retrieve('%s','%s');$c.RetrievePassword();$c.Password", service, account))
+		return runKeyringTool("", "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	default:
+		return runKeyringTool("", "secret-tool", "lookup", "service", service, "account", account)
+	}
+}
+
+func keyringDelete(account string) error {
+	if !keyringAvailable() {
+		return errKeyringUnavailable
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		_, err := runKeyringTool("", "security", "delete-generic-password", "-a", account, "-s", keyringService)
+		return err
+	case "windows":
+		script := powershellVaultScript(fmt.Sprintf("$v.Remove($v.Retrieve('%s','%s'))", keyringService, account))
+		_, err := runKeyringTool("", "powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+		return err
+	default:
+		_, err := runKeyringTool("", "secret-tool", "clear", "service", keyringService, "account", account)
+		return err
+	}
+}
@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// splitSecurityLine splits a line the way `security -i` does: whitespace
+// separates arguments outside quotes and a backslash takes the next byte
+// literally.
+func splitSecurityLine(line string) []string {
+	args := []string{}
+	var arg strings.Builder
+	inArg, escaped := false, false
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case escaped:
+			arg.WriteByte(c)
+			escaped = false
+		case c == '\\':
+			escaped, inArg = true, true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote == 0 && (c == '"' || c == '\''):
+			quote, inArg = c, true
+		case quote == 0 && (c == ' ' || c == '\t' || c == '\n'):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
+
+func TestSecurityCommandLineRoundTrip(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{"jwt", "eyJhbGciOi.eyJleHAiOjE3MDB9.c2ln"},
+		{"non-ASCII", "pässwörd ✓ 秘密"},
+		{"quotes and backslashes", `a"b\c'd\"`},
+		{"tab and shell characters", "a\tb $HOME `x` %q"},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := []string{"add-generic-password", "-U", "-a", "dev@example.com", "-s", keyringService, "-w", tt.secret}
+			line, err := securityCommandLine(want...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasSuffix(line, "\n") || strings.Count(line, "\n") != 1 {
+				t.Fatalf("line %q is not a single command", line)
+			}
+			if got := splitSecurityLine(line); !reflect.DeepEqual(got, want) {
+				t.Fatalf("security would read %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSecurityCommandLineRefusesLineBreaks(t *testing.T) {
+	for _, secret := range []string{"a\nb", "a\rb", "a\x00b"} {
+		if _, err := securityCommandLine("-w", secret); err == nil {
+			t.Fatalf("securityCommandLine accepted %q", secret)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package tui
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/crypto/sha3"
+)
+
+// Keystores use the Web3 Secret Storage (v3) layout so they can be inspected
+// or imported by other Ethereum tooling.
+type web3Keystore struct {
+	Version int            `json:"version"`
+	ID      string         `json:"id"`
+	Address string         `json:"address,omitempty"`
+	Crypto  keystoreCrypto `json:"crypto"`
+}
+
+type keystoreCrypto struct {
+	Cipher       string             `json:"cipher"`
+	CipherText   string             `json:"ciphertext"`
+	CipherParams keystoreCipherIV   `json:"cipherparams"`
+	KDF          string             `json:"kdf"`
+	KDFParams    keystoreScryptArgs `json:"kdfparams"`
+	MAC          string             `json:"mac"`
+}
+
+type keystoreCipherIV struct {
+	IV string `json:"iv"`
+}
+
+type keystoreScryptArgs struct {
+	DKLen int    `json:"dklen"`
+	N     int    `json:"n"`
+	R     int    `json:"r"`
+	P     int    `json:"p"`
+	Salt  string `json:"salt"`
+}
+
+// keystoreScryptMaxMemory bounds the 128*N*r bytes scrypt allocates for the
+// parameters of a keystore file, twice what keystoreScryptN needs, so a
+// tampered file cannot exhaust memory or stall the unlock.
+const keystoreScryptMaxMemory = 256 << 20
+
+// check rejects scrypt parameters outside of what this tool writes, before
+// they reach scrypt.Key.
+func (a keystoreScryptArgs) check() error {
+	switch {
+	case a.N <= 1 || a.N&(a.N-1) != 0:
+		return errors.New("keystore scrypt n must be a power of two")
+	case a.R < 1 || a.P < 1 || a.P > 16:
+		return errors.New("keystore scrypt r and p are out of range")
+	case uint64(a.N)*uint64(a.R) > keystoreScryptMaxMemory/128:
+		return fmt.Errorf("keystore scrypt parameters need more than %s of memory", FormatBytes(keystoreScryptMaxMemory))
+	case a.DKLen < 32 || a.DKLen > 64:
+		return errors.New("keystore dklen must be between 32 and 64")
+	}
+	return nil
+}
+
+const (
+	keystoreFileName      = ".keystore.json"
+	keystoreScryptN       = 1 << 18
+	keystoreScryptR       = 8
+	keystoreScryptP       = 1
+	keystoreMinPassphrase = 8
+	keystorePassphraseEnv = "SIXFLOW_KEYSTORE_PASSPHRASE"
+)
+
+var (
+	ErrKeystoreLocked     = errors.New("private key is stored in an encrypted keystore; passphrase required")
+	ErrKeystorePassphrase = errors.New("could not decrypt keystore: wrong passphrase")
+)
+
+func workflowKeystorePath(workflowID, workflowName string) string {
+	return filepath.Join(localWorkflowDir(workflowID, workflowName), keystoreFileName)
+}
+
+func HasWorkflowKeystore(workflowID, workflowName string) bool {
+	exists, err := fileExists(workflowKeystorePath(workflowID, workflowName))
+	return err == nil && exists
+}
+
+func keccak256(parts ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, part := range parts {
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
+
+func randomUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+func aes128CTR(key, iv, input []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, len(input))
+	cipher.NewCTR(block, iv).XORKeyStream(out, input)
+	return out, nil
+}
+
+func encryptKeystore(privateKeyHex, passphrase string) (*web3Keystore, error) {
+	keyBytes, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(privateKeyHex), "0x"))
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 32)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	derived, err := scrypt.Key([]byte(passphrase), salt, keystoreScryptN, keystoreScryptR, keystoreScryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	cipherText, err := aes128CTR(derived[:16], iv, keyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := randomUUID()
+	if err != nil {
+		return nil, err
+	}
+	// The address is stored in the clear, as other tools do, so the wallet can
+	// be shown without the passphrase.
+	address, err := PrivateKeyAddress(privateKeyHex)
+	if err != nil {
+		return nil, err
+	}
+	return &web3Keystore{
+		Version: 3,
+		ID:      id,
+		Address: strings.ToLower(strings.TrimPrefix(address, "0x")),
+		Crypto: keystoreCrypto{
+			Cipher:       "aes-128-ctr",
+			CipherText:   hex.EncodeToString(cipherText),
+			CipherParams: keystoreCipherIV{IV: hex.EncodeToString(iv)},
+			KDF:          "scrypt",
+			KDFParams: keystoreScryptArgs{
+				DKLen: 32,
+				N:     keystoreScryptN,
+				R:     keystoreScryptR,
+				P:     keystoreScryptP,
+				Salt:  hex.EncodeToString(salt),
+			},
+			MAC: hex.EncodeToString(keccak256(derived[16:32], cipherText)),
+		},
+	}, nil
+}
+
+func decryptKeystore(ks *web3Keystore, passphrase string) (string, error) {
+	if ks.Version != 3 || ks.Crypto.Cipher != "aes-128-ctr" || ks.Crypto.KDF != "scrypt" {
+		return "", fmt.Errorf("unsupported keystore format (version %d, cipher %q, kdf %q)", ks.Version, ks.Crypto.Cipher, ks.Crypto.KDF)
+	}
+	params := ks.Crypto.KDFParams
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil {
+		return "", errors.New("keystore salt is not valid hex")
+	}
+	iv, err := hex.DecodeString(ks.Crypto.CipherParams.IV)
+	if err != nil {
+		return "", errors.New("keystore iv is not valid hex")
+	}
+	cipherText, err := hex.DecodeString(ks.Crypto.CipherText)
+	if err != nil {
+		return "", errors.New("keystore ciphertext is not valid hex")
+	}
+	mac, err := hex.DecodeString(ks.Crypto.MAC)
+	if err != nil {
+		return "", errors.New("keystore mac is not valid hex")
+	}
+	if err := params.check(); err != nil {
+		return "", err
+	}
+
+	derived, err := scrypt.Key([]byte(passphrase), salt, params.N, params.R, params.P, params.DKLen)
+	if err != nil {
+		return "", err
+	}
+	if subtle.ConstantTimeCompare(keccak256(derived[16:32], cipherText), mac) != 1 {
+		return "", ErrKeystorePassphrase
+	}
+	plain, err := aes128CTR(derived[:16], iv, cipherText)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(plain), nil
+}
+
+func readWorkflowKeystore(path string) (*web3Keystore, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var ks web3Keystore
+	if err := json.Unmarshal(raw, &ks); err != nil {
+		return nil, fmt.Errorf("keystore is not valid JSON: %w", err)
+	}
+	return &ks, nil
+}
+
+// EncryptWorkflowPrivateKey moves CRE_ETH_PRIVATE_KEY out of the workflow .env
+// into a passphrase-protected keystore next to it.
+func EncryptWorkflowPrivateKey(workflowID, workflowName, target, passphrase string) (*SecretsCommandResult, error) {
+	logs := []string{}
+	appendLog := func(msg string) { logs = append(logs, msg) }
+
+	_, _, dotEnvPath, preflightLogs, err := preflightWorkflowSecretsForWrite(workflowID, workflowName, target)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range preflightLogs {
+		appendLog(l)
+	}
+
+	if len(passphrase) < keystoreMinPassphrase {
+		return &SecretsCommandResult{Logs: logs}, fmt.Errorf("passphrase must be at least %d characters", keystoreMinPassphrase)
+	}
+	privateKey, _ := readDotEnvValue(dotEnvPath, "CRE_ETH_PRIVATE_KEY")
+	if !isValidPrivateKey(privateKey) {
+		return &SecretsCommandResult{Logs: logs}, errors.New("no valid CRE_ETH_PRIVATE_KEY in workflow .env to encrypt")
+	}
+
+	ks, err := encryptKeystore(privateKey, passphrase)
+	if err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	content, err := json.MarshalIndent(ks, "", "  ")
+	if err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	keystorePath := workflowKeystorePath(workflowID, workflowName)
+	if err := requireSecretFileUntracked(keystorePath); err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	if err := os.WriteFile(keystorePath, content, 0o600); err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	if err := removeDotEnvValue(dotEnvPath, "CRE_ETH_PRIVATE_KEY"); err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+
+	appendLog("Encrypted CRE_ETH_PRIVATE_KEY into keystore and removed it from .env.")
+	appendLog("keystore path: " + keystorePath)
+	appendLog("The passphrase will be requested before each simulation.")
+	return &SecretsCommandResult{Logs: logs}, nil
+}
+
+func DecryptWorkflowPrivateKey(workflowID, workflowName, passphrase string) (string, error) {
+	ks, err := readWorkflowKeystore(workflowKeystorePath(workflowID, workflowName))
+	if err != nil {
+		return "", err
+	}
+	return decryptKeystore(ks, passphrase)
+}
+
+// PrivateKeyEnv returns the environment entry used to hand a decrypted key to
+// a subprocess without writing it to disk.
+func PrivateKeyEnv(privateKey string) []string {
+	return []string{"CRE_ETH_PRIVATE_KEY=" + strings.TrimPrefix(strings.TrimSpace(privateKey), "0x")}
+}
@@ -0,0 +1,115 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalProjectRemoval is a synced project to delete, with the secret files
+// that go with it, relative to ProjectRoot.
+type LocalProjectRemoval struct {
+	LocalWorkflow
+	SecretFiles []string
+}
+
+type LocalProjectsRemoveResult struct {
+	Logs    []string
+	Removed []string
+}
+
+func planLocalProjectRemoval(workflow LocalWorkflow) LocalProjectRemoval {
+	return LocalProjectRemoval{LocalWorkflow: workflow, SecretFiles: projectSecretFiles(workflow.ProjectRoot)}
+}
+
+// PlanLocalProjectRemoval looks up the synced project of a workflow for
+// RemoveLocalProjects.
+func PlanLocalProjectRemoval(workflowID, workflowName string) (*LocalProjectRemoval, error) {
+	projectRoot := localWorkflowProjectRoot(workflowID, workflowName)
+	if _, err := os.Stat(projectRoot); err != nil {
+		return nil, errors.New("local workflow project not found. Run sync to local first")
+	}
+	removal := planLocalProjectRemoval(LocalWorkflow{ID: workflowID, Name: slugify(workflowName), ProjectRoot: projectRoot})
+	return &removal, nil
+}
+
+// FindOrphanedLocalProjects lists the synced projects whose workflow is no
+// longer in the frontend list, e.g. because it was deleted there. The list
+// only covers the active profile and organization, so projects synced under
+// others, or before syncs recorded them, are never orphans; skipped counts
+// them. The list must come from the frontend itself; the offline cache may be
+// out of date.
+func FindOrphanedLocalProjects(baseURL, token string) ([]LocalProjectRemoval, int, error) {
+	local, err := ListLocalWorkflows()
+	if err != nil {
+		return nil, 0, err
+	}
+	profile, organization := ActiveAuthProfile(), ActiveOrganization()
+	scoped := []LocalWorkflow{}
+	for _, workflow := range local {
+		stamp := readBundleStamp(workflow.ProjectRoot)
+		if stamp != nil && stamp.Profile == profile && stamp.Organization == organization {
+			scoped = append(scoped, workflow)
+		}
+	}
+	skipped := len(local) - len(scoped)
+	if len(scoped) == 0 {
+		return []LocalProjectRemoval{}, skipped, nil
+	}
+	workflows, err := FetchFrontendWorkflows(baseURL, token, WorkflowFilter{})
+	if err != nil {
+		if IsFrontendUnreachable(err) {
+			return nil, skipped, fmt.Errorf("%w; orphaned projects can only be found online", err)
+		}
+		return nil, skipped, err
+	}
+	known := map[string]bool{}
+	for _, workflow := range workflows {
+		known[workflow.ID] = true
+	}
+	orphans := []LocalProjectRemoval{}
+	for _, workflow := range scoped {
+		if !known[workflow.ID] {
+			orphans = append(orphans, planLocalProjectRemoval(workflow))
+		}
+	}
+	return orphans, skipped, nil
+}
+
+// RemoveLocalProjects deletes the given project directories, secret files
+// included; unlike a re-sync, no backup is kept. Each project is locked like
+// a sync so a running one is not pulled out from under it.
+func RemoveLocalProjects(removals []LocalProjectRemoval) (*LocalProjectsRemoveResult, error) {
+	result := &LocalProjectsRemoveResult{Logs: []string{}, Removed: []string{}}
+	appendLog := func(msg string) { result.Logs = append(result.Logs, msg) }
+
+	root := filepath.Clean(workflowsRootDir())
+	for _, removal := range removals {
+		projectRoot := filepath.Clean(removal.ProjectRoot)
+		if filepath.Dir(projectRoot) != root {
+			return result, fmt.Errorf("refusing to remove %s: not a synced project under %s", projectRoot, root)
+		}
+		if DryRun() {
+			dryRunNote("would remove %s", projectRoot)
+			continue
+		}
+		release, err := acquireFileLock(projectSyncLockPath(projectRoot))
+		if err != nil {
+			return result, fmt.Errorf("%s is being synced: %w", removal.Name, err)
+		}
+		err = os.RemoveAll(projectRoot)
+		release()
+		if err != nil {
+			return result, fmt.Errorf("remove %s: %w", projectRoot, err)
+		}
+		line := "Removed " + projectRoot
+		if len(removal.SecretFiles) > 0 {
+			line += " with its secret files " + strings.Join(removal.SecretFiles, ", ")
+		}
+		appendLog(line + ".")
+		result.Removed = append(result.Removed, removal.ID)
+	}
+	return result, nil
+}
@@ -0,0 +1,126 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+type LocalWorkflow struct {
+	ID          string
+	Name        string
+	ProjectRoot string
+	// WorkflowName is the CRE workflow name from workflow.yaml, empty when
+	// the project has none.
+	WorkflowName string
+	// SyncedAt is zero for projects synced before bundle stamps existed.
+	SyncedAt time.Time
+}
+
+func parseLocalWorkflowFolder(folderName string) (string, string, bool) {
+	idx := strings.LastIndex(folderName, "--")
+	if idx <= 0 || idx+2 >= len(folderName) {
+		return "", "", false
+	}
+	return folderName[idx+2:], folderName[:idx], true
+}
+
+// ListLocalWorkflows returns the workflow projects previously written by
+// SyncWorkflowToLocal. Name is the folder slug, which resolves to the same
+// local paths as the original workflow name.
+func ListLocalWorkflows() ([]LocalWorkflow, error) {
+	root := workflowsRootDir()
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []LocalWorkflow{}, nil
+		}
+		return nil, err
+	}
+
+	out := make([]LocalWorkflow, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		id, name, ok := parseLocalWorkflowFolder(entry.Name())
+		if !ok {
+			continue
+		}
+		workflow := LocalWorkflow{
+			ID:           id,
+			Name:         name,
+			ProjectRoot:  filepath.Join(root, entry.Name()),
+			WorkflowName: readLocalWorkflowName(filepath.Join(root, entry.Name(), name)),
+		}
+		if stamp := readBundleStamp(workflow.ProjectRoot); stamp != nil {
+			workflow.SyncedAt = stamp.SyncedAt
+		}
+		out = append(out, workflow)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// readLocalWorkflowName reads the workflow name of the staging target from
+// workflowDir's workflow.yaml, or of the first target that has one.
+func readLocalWorkflowName(workflowDir string) string {
+	raw, err := os.ReadFile(filepath.Join(workflowDir, "workflow.yaml"))
+	if err != nil {
+		return ""
+	}
+	var data workflowYAML
+	if err := yaml.Unmarshal(raw, &data); err != nil {
+		return ""
+	}
+	if name := strings.TrimSpace(data["staging-settings"].UserWorkflow.WorkflowName); name != "" {
+		return name
+	}
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if name := strings.TrimSpace(data[key].UserWorkflow.WorkflowName); name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// ResolveLocalWorkflow finds a synced workflow by ID, folder slug, or name.
+func ResolveLocalWorkflow(ref string) (*LocalWorkflow, error) {
+	trimmed := strings.TrimSpace(ref)
+	if trimmed == "" {
+		return nil, fmt.Errorf("workflow id or name is required")
+	}
+	workflows, err := ListLocalWorkflows()
+	if err != nil {
+		return nil, err
+	}
+
+	slug := slugify(trimmed)
+	var byName []LocalWorkflow
+	for _, wf := range workflows {
+		if wf.ID == trimmed {
+			return &wf, nil
+		}
+		if wf.Name == slug {
+			byName = append(byName, wf)
+		}
+	}
+	switch len(byName) {
+	case 0:
+		return nil, fmt.Errorf("no locally synced workflow matches %q. Run sync to local first", trimmed)
+	case 1:
+		return &byName[0], nil
+	default:
+		return nil, fmt.Errorf("%q matches %d local workflows; use the workflow id", trimmed, len(byName))
+	}
+}
@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrOperationCancelled is returned by commands and frontend requests that
+// were stopped with CancelOperation.
+var ErrOperationCancelled = errors.New("operation cancelled")
+
+var (
+	operationMu     sync.Mutex
+	operationCtx    context.Context
+	operationCancel context.CancelFunc
+)
+
+// OperationContext is the context external commands and frontend requests
+// run under. It stays valid until CancelOperation, after which new work gets
+// a fresh context.
+func OperationContext() context.Context {
+	operationMu.Lock()
+	defer operationMu.Unlock()
+	if operationCtx == nil {
+		operationCtx, operationCancel = context.WithCancel(context.Background())
+	}
+	return operationCtx
+}
+
+// CancelOperation kills running external commands and aborts in-flight
+// frontend requests.
+func CancelOperation() {
+	operationMu.Lock()
+	defer operationMu.Unlock()
+	if operationCancel != nil {
+		operationCancel()
+	}
+	operationCtx, operationCancel = nil, nil
+}
+
+// cancelledError turns the error of work stopped through ctx into
+// ErrOperationCancelled; other errors pass through.
+func cancelledError(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		return ErrOperationCancelled
+	}
+	return err
+}
+
+// ErrCommandTimeout is returned when an external command runs past its
+// CommandTimeout and is killed.
+var ErrCommandTimeout = errors.New("command timed out")
+
+// CommandTimeoutError names the command that was killed and how to allow it
+// more time.
+type CommandTimeoutError struct {
+	Command string
+	Limit   time.Duration
+	Env     string
+}
+
+func (e *CommandTimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s (set %s to allow more time)", e.Command, e.Limit, e.Env)
+}
+
+func (e *CommandTimeoutError) Unwrap() error {
+	return ErrCommandTimeout
+}
+
+// External command limits, overridable with a duration ("90s", "5m") like the
+// HTTP timeouts; "0" removes the limit.
+const (
+	installTimeoutEnv  = "SIXFLOW_TIMEOUT_INSTALL"
+	simulateTimeoutEnv = "SIXFLOW_TIMEOUT_SIMULATE"
+	deployTimeoutEnv   = "SIXFLOW_TIMEOUT_DEPLOY"
+
+	defaultInstallTimeout  = 5 * time.Minute
+	defaultSimulateTimeout = 10 * time.Minute
+	defaultDeployTimeout   = 10 * time.Minute
+)
+
+// CommandTimeout bounds how long an external command may run so a wedged
+// subprocess cannot hang the TUI. Env names the variable that overrides it.
+type CommandTimeout struct {
+	Limit time.Duration
+	Env   string
+}
+
+// InstallTimeout bounds the dependency install before a simulation.
+func InstallTimeout() CommandTimeout {
+	return CommandTimeout{Limit: envTimeout(installTimeoutEnv, defaultInstallTimeout), Env: installTimeoutEnv}
+}
+
+func SimulateTimeout() CommandTimeout {
+	return CommandTimeout{Limit: envTimeout(simulateTimeoutEnv, defaultSimulateTimeout), Env: simulateTimeoutEnv}
+}
+
+// DeployTimeout covers compiling, uploading and the registry transaction of
+// `cre workflow deploy`.
+func DeployTimeout() CommandTimeout {
+	return CommandTimeout{Limit: envTimeout(deployTimeoutEnv, defaultDeployTimeout), Env: deployTimeoutEnv}
+}
+
+// Context derives the command context from OperationContext, with the
+// deadline applied when a limit is set.
+func (t CommandTimeout) Context() (context.Context, context.CancelFunc) {
+	if t.Limit <= 0 {
+		return context.WithCancel(OperationContext())
+	}
+	return context.WithTimeout(OperationContext(), t.Limit)
+}
+
+// Err maps the error of a command run under ctx: a passed deadline becomes a
+// CommandTimeoutError, a cancel becomes ErrOperationCancelled.
+func (t CommandTimeout) Err(ctx context.Context, command string, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &CommandTimeoutError{Command: command, Limit: t.Limit, Env: t.Env}
+	}
+	return cancelledError(ctx, err)
+}
+
+// commandWaitDelay is how long a killed command may keep its output pipes
+// open, e.g. through a leftover child process, before they are closed.
+const commandWaitDelay = 5 * time.Second
+
+// commandLabel names a command for messages: the executable and its leading
+// subcommands, such as "cre workflow simulate".
+func commandLabel(name string, args []string) string {
+	parts := []string{strings.TrimSuffix(filepath.Base(name), ".exe")}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") || len(parts) == 3 {
+			break
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
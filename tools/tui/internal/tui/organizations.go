@@ -0,0 +1,100 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	organizationHeader = "X-6flow-Org-Id"
+	// organizationEnv picks the organization for runs that have no saved
+	// choice, e.g. CI with SIXFLOW_AUTH_TOKEN.
+	organizationEnv = "SIXFLOW_ORG"
+)
+
+var ErrOrganizationsUnsupported = errors.New("frontend does not list organizations")
+
+type FrontendOrganization struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+var (
+	activeOrganizationMu sync.Mutex
+	activeOrganizationID string
+)
+
+// SetActiveOrganization selects the organization sent with every frontend
+// request; "" lets the frontend use the account's default.
+func SetActiveOrganization(id string) {
+	activeOrganizationMu.Lock()
+	activeOrganizationID = strings.TrimSpace(id)
+	activeOrganizationMu.Unlock()
+}
+
+// ActiveOrganization returns the selected organization id, falling back to
+// SIXFLOW_ORG.
+func ActiveOrganization() string {
+	activeOrganizationMu.Lock()
+	id := activeOrganizationID
+	activeOrganizationMu.Unlock()
+	if id != "" {
+		return id
+	}
+	return strings.TrimSpace(os.Getenv(organizationEnv))
+}
+
+type organizationsResponse struct {
+	Organizations []FrontendOrganization `json:"organizations"`
+	apiErrorBody
+}
+
+func FetchFrontendOrganizations(baseURL, token string) ([]FrontendOrganization, error) {
+	client := newHTTPClient(listTimeout())
+	req, err := http.NewRequest(http.MethodGet, NormalizeBaseURL(baseURL)+"/api/tui/orgs", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := doFrontendRequest(client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var payload organizationsResponse
+	_ = json.NewDecoder(resp.Body).Decode(&payload)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, ErrFrontendUnauthorized
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		return nil, ErrOrganizationsUnsupported
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, payload.apiError(resp.StatusCode)
+	}
+	if payload.Organizations == nil {
+		return nil, errors.New("invalid API response from /api/tui/orgs")
+	}
+	return payload.Organizations, nil
+}
+
+// RecordSessionOrganization remembers the organization picked for the active
+// profile's account so the next start lists the same workflows.
+func RecordSessionOrganization(id, name string) error {
+	return updateSessionFile(func(session *AuthSession) bool {
+		if session.OrgID == id && session.OrgName == name {
+			return false
+		}
+		session.OrgID = id
+		session.OrgName = name
+		return true
+	})
+}
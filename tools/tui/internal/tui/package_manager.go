@@ -0,0 +1,136 @@
+package tui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var ErrNoPackageManager = errors.New("no JavaScript package manager found. Install bun (https://bun.sh) or Node.js with npm")
+
+// packageManager is a tool that can install the workflow's dependencies.
+// Lockfiles mark a project that was set up with it.
+type packageManager struct {
+	name      string
+	lockfiles []string
+	args      []string
+}
+
+// packageManagers is in order of preference: bun is what the generated
+// projects are written for, the others are fallbacks for machines without it.
+var packageManagers = []packageManager{
+	{name: "bun", lockfiles: []string{"bun.lock", "bun.lockb"}, args: []string{"install"}},
+	{name: "pnpm", lockfiles: []string{"pnpm-lock.yaml"}, args: []string{"install"}},
+	{name: "yarn", lockfiles: []string{"yarn.lock"}, args: []string{"install"}},
+	{name: "npm", lockfiles: []string{"package-lock.json"}, args: []string{"ci"}},
+}
+
+func (pm packageManager) hasLockfile(dir string) bool {
+	for _, name := range pm.lockfiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// detectPackageManager picks the installed manager whose lockfile is in dir,
+// else the first installed one. npm without a lockfile runs `npm install`
+// since `npm ci` refuses to.
+func detectPackageManager(dir string) (packageManager, string, error) {
+	var installed []packageManager
+	for _, pm := range packageManagers {
+		if _, err := exec.LookPath(pm.name); err == nil {
+			installed = append(installed, pm)
+		}
+	}
+	if len(installed) == 0 {
+		return packageManager{}, "", ErrNoPackageManager
+	}
+	for _, pm := range installed {
+		if pm.hasLockfile(dir) {
+			return pm, "found " + strings.Join(pm.lockfiles, " or "), nil
+		}
+	}
+	pm := installed[0]
+	if pm.name == "npm" {
+		pm.args = []string{"install"}
+	}
+	if pm.name == packageManagers[0].name {
+		return pm, "default", nil
+	}
+	return pm, packageManagers[0].name + " not installed", nil
+}
+
+// installStampFile records the dependency hash of the last successful
+// install. It lives in node_modules so deleting that directory also forces a
+// fresh install.
+const installStampFile = ".6flow-install.sha256"
+
+// dependencyHash covers package.json, every known lockfile and the install
+// command, so switching package manager also counts as a change.
+func dependencyHash(workflowDir string, pm packageManager) (string, error) {
+	files := []string{"package.json"}
+	for _, candidate := range packageManagers {
+		files = append(files, candidate.lockfiles...)
+	}
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", pm.name, strings.Join(pm.args, " "))
+	for _, name := range files {
+		content, err := os.ReadFile(filepath.Join(workflowDir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", err
+		}
+		sum := sha256.Sum256(content)
+		fmt.Fprintf(hash, "%s %x\n", name, sum)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func installStampPath(workflowDir string) string {
+	return filepath.Join(workflowDir, "node_modules", installStampFile)
+}
+
+// installDependencies runs the detected package manager in the workflow
+// directory, streaming its output through appendLog. It is skipped when
+// package.json and the lockfiles are unchanged since the last install.
+func installDependencies(workflowDir string, appendLog func(string)) error {
+	pm, reason, err := detectPackageManager(workflowDir)
+	if err != nil {
+		return err
+	}
+	command := pm.name + " " + strings.Join(pm.args, " ")
+	before, hashErr := dependencyHash(workflowDir, pm)
+	if hashErr == nil {
+		if stamp, err := os.ReadFile(installStampPath(workflowDir)); err == nil && strings.TrimSpace(string(stamp)) == before {
+			appendLog(fmt.Sprintf("Dependencies unchanged since the last install; skipping %s.", command))
+			return nil
+		}
+	}
+	appendLog(fmt.Sprintf("Running dependency setup: %s (%s)", command, reason))
+	_, err = runCommandStream(workflowDir, nil, nil, InstallTimeout(), func(line string, stderr bool) {
+		appendLog(StreamTag(pm.name, stderr) + line)
+	}, pm.name, pm.args...)
+	if err != nil {
+		_ = os.Remove(installStampPath(workflowDir))
+		return fmt.Errorf("%s failed: %w", command, err)
+	}
+	if DryRun() {
+		return nil
+	}
+	// Hash again: the install may have created or updated the lockfile.
+	if after, err := dependencyHash(workflowDir, pm); err == nil {
+		if err := os.WriteFile(installStampPath(workflowDir), []byte(after+"\n"), 0o644); err != nil && !os.IsNotExist(err) {
+			appendLog("Could not record the dependency install: " + err.Error())
+		}
+	}
+	return nil
+}
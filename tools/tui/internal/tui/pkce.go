@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	requirePKCEEnv      = "SIXFLOW_REQUIRE_PKCE"
+	pkceChallengeMethod = "S256"
+)
+
+type pkcePair struct {
+	Verifier  string
+	Challenge string
+}
+
+type pkceTokenResponse struct {
+	Token string `json:"token"`
+	Error string `json:"error"`
+}
+
+// newPKCEPair builds an RFC 7636 S256 verifier/challenge. Only the challenge
+// leaves the process before the code exchange.
+func newPKCEPair() (pkcePair, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return pkcePair{}, err
+	}
+	verifier := base64.RawURLEncoding.EncodeToString(raw)
+	sum := sha256.Sum256([]byte(verifier))
+	return pkcePair{
+		Verifier:  verifier,
+		Challenge: base64.RawURLEncoding.EncodeToString(sum[:]),
+	}, nil
+}
+
+// pkceRequired rejects callbacks that carry a raw token. It is opt-in until
+// every deployed frontend performs the code exchange.
+func pkceRequired() bool {
+	switch strings.ToLower(strings.TrimSpace(os.Getenv(requirePKCEEnv))) {
+	case "1", "true", "yes", "on":
+		return true
+	}
+	return false
+}
+
+func exchangePKCECode(base, code, verifier, redirectURI string) (string, error) {
+	client := newHTTPClient(20 * time.Second)
+	var resp pkceTokenResponse
+	status, err := postDeviceJSON(client, base+"/api/tui/auth/token", map[string]string{
+		"grantType":    "authorization_code",
+		"code":         code,
+		"codeVerifier": verifier,
+		"redirectUri":  redirectURI,
+	}, &resp)
+	if err != nil {
+		return "", fmt.Errorf("authorization code exchange failed: %w", err)
+	}
+	if status == http.StatusNotFound {
+		return "", errors.New("frontend does not support authorization code exchange")
+	}
+	if status < 200 || status >= 300 || strings.TrimSpace(resp.Token) == "" {
+		if strings.TrimSpace(resp.Error) != "" {
+			return "", fmt.Errorf("authorization code exchange failed: %s", resp.Error)
+		}
+		return "", fmt.Errorf("authorization code exchange failed with status %d", status)
+	}
+	return resp.Token, nil
+}
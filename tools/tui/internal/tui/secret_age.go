@@ -0,0 +1,108 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Each .env file has a sidecar, e.g. .env.staging.meta.json, recording when
+// every value in it last changed. It holds no values and is safe to share.
+const (
+	secretsMetaSuffix      = ".meta.json"
+	defaultRotateAfterDays = 90
+)
+
+type secretMeta struct {
+	RotatedAt time.Time `json:"rotatedAt"`
+}
+
+type secretsMeta struct {
+	Version int                   `json:"version"`
+	Secrets map[string]secretMeta `json:"secrets"`
+}
+
+func secretsMetaPath(dotEnvPath string) string {
+	return dotEnvPath + secretsMetaSuffix
+}
+
+// loadSecretsMeta never fails: a missing or unreadable sidecar only means
+// the ages are unknown.
+func loadSecretsMeta(dotEnvPath string) *secretsMeta {
+	meta := &secretsMeta{Version: 1, Secrets: map[string]secretMeta{}}
+	raw, err := os.ReadFile(secretsMetaPath(dotEnvPath))
+	if err != nil {
+		return meta
+	}
+	if err := json.Unmarshal(raw, meta); err != nil || meta.Secrets == nil {
+		return &secretsMeta{Version: 1, Secrets: map[string]secretMeta{}}
+	}
+	return meta
+}
+
+func saveSecretsMeta(dotEnvPath string, meta *secretsMeta) error {
+	raw, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeProjectFile(secretsMetaPath(dotEnvPath), append(raw, '\n'), 0o644)
+}
+
+// setSecretRotatedAt records when key changed in dotEnvPath; a zero time
+// forgets it. The sidecar is only bookkeeping, so callers that already wrote
+// the value ignore its errors.
+func setSecretRotatedAt(dotEnvPath, key string, rotatedAt time.Time) error {
+	meta := loadSecretsMeta(dotEnvPath)
+	if rotatedAt.IsZero() {
+		if _, ok := meta.Secrets[key]; !ok {
+			return nil
+		}
+		delete(meta.Secrets, key)
+	} else {
+		meta.Secrets[key] = secretMeta{RotatedAt: rotatedAt.UTC()}
+	}
+	return saveSecretsMeta(dotEnvPath, meta)
+}
+
+// rotatedAt returns when key last changed, or the zero time when that was
+// never recorded.
+func (m *secretsMeta) rotatedAt(key string) time.Time {
+	return m.Secrets[key].RotatedAt
+}
+
+// RotateAfter is the age from which a secret value counts as stale, set by
+// rotateAfterDays in ~/.6flow/config.json.
+func RotateAfter() time.Duration {
+	days := defaultRotateAfterDays
+	if config, err := LoadTUIConfig(); err == nil && config.RotateAfterDays > 0 {
+		days = config.RotateAfterDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// IsSecretStale reports whether a value last rotated at rotatedAt is due for
+// rotation. Unknown ages are not stale.
+func IsSecretStale(rotatedAt time.Time) bool {
+	return !rotatedAt.IsZero() && time.Since(rotatedAt) >= RotateAfter()
+}
+
+// SecretAgeLabel describes rotatedAt for the pickers, e.g. "rotated 12 days
+// ago", flagging stale values; it is "" when the age is unknown.
+func SecretAgeLabel(rotatedAt time.Time) string {
+	if rotatedAt.IsZero() {
+		return ""
+	}
+	days := int(time.Since(rotatedAt).Hours() / 24)
+	label := "rotated today"
+	switch {
+	case days == 1:
+		label = "rotated 1 day ago"
+	case days > 1:
+		label = fmt.Sprintf("rotated %d days ago", days)
+	}
+	if IsSecretStale(rotatedAt) {
+		label += " ⚠ stale"
+	}
+	return label
+}
@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const (
+	defaultGeneratedSecretLength = 32
+	minGeneratedSecretLength     = 16
+	defaultGeneratedSecretSet    = "alnum"
+
+	alnumChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+)
+
+// generatedSecretCharsets leave out whitespace, quotes, "#", "$" and "\",
+// which a .env parser could read differently.
+var generatedSecretCharsets = map[string]string{
+	"alnum":     alnumChars,
+	"hex":       "0123456789abcdef",
+	"base64url": alnumChars + "-_",
+	"symbols":   alnumChars + "-_.~!@%^*+=,:",
+}
+
+// SecretGeneratorConfig shapes generated secret values in
+// ~/.6flow/config.json.
+type SecretGeneratorConfig struct {
+	// Length is the number of characters, 32 by default.
+	Length int `json:"length,omitempty"`
+	// Charset is alnum (the default), hex, base64url or symbols, or else the
+	// characters to draw from.
+	Charset string `json:"charset,omitempty"`
+}
+
+func (c *SecretGeneratorConfig) resolve() (int, string, error) {
+	length, charset := defaultGeneratedSecretLength, defaultGeneratedSecretSet
+	if c != nil && c.Length != 0 {
+		length = c.Length
+	}
+	if c != nil && strings.TrimSpace(c.Charset) != "" {
+		charset = strings.TrimSpace(c.Charset)
+	}
+	if length < minGeneratedSecretLength || length > MaxSecretValueLength {
+		return 0, "", fmt.Errorf("generate.length in %s must be between %d and %d", tuiConfigPath(), minGeneratedSecretLength, MaxSecretValueLength)
+	}
+	if chars, ok := generatedSecretCharsets[strings.ToLower(charset)]; ok {
+		return length, chars, nil
+	}
+	seen := map[rune]bool{}
+	for _, r := range charset {
+		if r <= ' ' || r > '~' || strings.ContainsRune("#$\\\"'`", r) {
+			return 0, "", fmt.Errorf("generate.charset in %s cannot contain %q", tuiConfigPath(), r)
+		}
+		seen[r] = true
+	}
+	if len(seen) < 2 {
+		return 0, "", fmt.Errorf("generate.charset in %s needs at least two different characters", tuiConfigPath())
+	}
+	return length, charset, nil
+}
+
+// GenerateSecretValue returns a random value drawn uniformly from the
+// configured charset with crypto/rand, for webhook signing keys, API tokens
+// and the like.
+func GenerateSecretValue() (string, error) {
+	config, err := LoadTUIConfig()
+	if err != nil {
+		return "", err
+	}
+	length, chars, err := config.Generate.resolve()
+	if err != nil {
+		return "", err
+	}
+	limit := big.NewInt(int64(len(chars)))
+	var b strings.Builder
+	b.Grow(length)
+	for range length {
+		n, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return "", err
+		}
+		b.WriteByte(chars[n.Int64()])
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,150 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// A .env value can point at a secret instead of holding it:
+//
+//	API_KEY=op://vault/item/field        (1Password CLI)
+//	API_KEY=keychain://service/account   (OS keychain)
+//	API_KEY=keychain://account           (OS keychain, service 6flow-tui)
+//	API_KEY=aws-sm://name                (AWS Secrets Manager, see secrets_backend.go)
+//	API_KEY=gcp-sm://name                (GCP Secret Manager)
+//
+// References are resolved right before cre runs and passed to it through the
+// environment, which takes precedence over the .env file, so the plaintext
+// never touches disk.
+const (
+	onePasswordRefPrefix = "op://"
+	keychainRefPrefix    = "keychain://"
+)
+
+var ErrSecretReference = errors.New("cannot resolve secret reference")
+
+// SecretReferenceError names the .env key whose reference failed; the
+// reference itself is not a secret and is kept for the message.
+type SecretReferenceError struct {
+	Key       string
+	Reference string
+	Err       error
+}
+
+func (e *SecretReferenceError) Error() string {
+	return fmt.Sprintf("%s: %s (%s): %v", ErrSecretReference, e.Key, e.Reference, e.Err)
+}
+
+func (e *SecretReferenceError) Unwrap() error { return ErrSecretReference }
+
+// IsSecretReference reports whether a .env value is a reference rather than a
+// literal.
+func IsSecretReference(value string) bool {
+	value = strings.TrimSpace(value)
+	for _, prefix := range []string{onePasswordRefPrefix, keychainRefPrefix, awsSecretRefPrefix, gcpSecretRefPrefix} {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveSecretReference(reference string) (string, error) {
+	reference = strings.TrimSpace(reference)
+	switch {
+	case strings.HasPrefix(reference, onePasswordRefPrefix):
+		if _, err := exec.LookPath("op"); err != nil {
+			return "", errors.New("the 1Password CLI (op) is not installed")
+		}
+		// Output, not CombinedOutput: op may warn on stderr, e.g. about updates.
+		var stderr strings.Builder
+		cmd := exec.Command("op", "read", "--no-newline", reference)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", fmt.Errorf("op: %s", msg)
+			}
+			return "", fmt.Errorf("op: %w", err)
+		}
+		return string(out), nil
+	case strings.HasPrefix(reference, keychainRefPrefix):
+		service, account, found := strings.Cut(strings.TrimPrefix(reference, keychainRefPrefix), "/")
+		if !found {
+			service, account = keyringService, service
+		}
+		if service == "" || account == "" || strings.ContainsAny(service+account, "'\"`$") {
+			return "", errors.New("expected keychain://service/account")
+		}
+		return keyringLookup(service, account)
+	case strings.HasPrefix(reference, awsSecretRefPrefix), strings.HasPrefix(reference, gcpSecretRefPrefix):
+		return readCloudSecret(reference)
+	}
+	return "", errors.New("not a secret reference")
+}
+
+// resolveDotEnvReferences resolves every reference in the .env file and
+// returns them as KEY=value pairs for the command environment, plus the keys
+// that were resolved for logging. With all set, literal entries are included
+// too, for an encrypted .env that cre cannot read itself.
+func resolveDotEnvReferences(dotEnvPath string, all bool) ([]string, []string, error) {
+	raw, err := readDotEnvContent(dotEnvPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	entries, err := parseDotEnv(filepath.Base(dotEnvPath), raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	var env, keys []string
+	for _, entry := range entries {
+		if !IsSecretReference(entry.Value) {
+			if all && entry.Value != "" {
+				env = append(env, entry.Key+"="+entry.Value)
+			}
+			continue
+		}
+		value, err := resolveSecretReference(entry.Value)
+		if err == nil && strings.TrimSpace(value) == "" {
+			err = errors.New("resolved to an empty value")
+		}
+		if err == nil && entry.Key == "CRE_ETH_PRIVATE_KEY" && !isValidPrivateKey(value) {
+			err = errors.New("resolved value is not a valid private key")
+		}
+		if err != nil {
+			return nil, nil, &SecretReferenceError{Key: entry.Key, Reference: entry.Value, Err: err}
+		}
+		if entry.Key == "CRE_ETH_PRIVATE_KEY" {
+			env = append(env, PrivateKeyEnv(value)...)
+		} else {
+			env = append(env, entry.Key+"="+value)
+		}
+		keys = append(keys, entry.Key)
+	}
+	return env, keys, nil
+}
+
+// dotEnvRunEnv is the environment a cre run needs from the target .env:
+// resolved references, and every entry when the .env is encrypted. It logs
+// which keys came from a reference, never their values.
+func dotEnvRunEnv(dotEnvPath string, appendLog func(string)) ([]string, error) {
+	encrypted := isDotEnvEncrypted(dotEnvPath)
+	env, keys, err := resolveDotEnvReferences(dotEnvPath, encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if encrypted {
+		appendLog(fmt.Sprintf("Decrypted %s in memory; its entries are passed to cre through the environment.", filepath.Base(encryptedDotEnvPath(dotEnvPath))))
+	}
+	if len(keys) > 0 {
+		appendLog(fmt.Sprintf("Resolved %d secret reference(s) for this run: %s", len(keys), strings.Join(keys, ", ")))
+	}
+	return env, nil
+}
@@ -0,0 +1,125 @@
+package tui
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	maxSecretIDLength = 128
+	// MaxSecretValueLength leaves room for PEM keys and JSON service accounts.
+	MaxSecretValueLength = 16384
+)
+
+var (
+	secretIDPattern   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	envVarNamePattern = regexp.MustCompile(`^[A-Z_][A-Z0-9_]*$`)
+)
+
+type SecretValidationError struct {
+	Field  string
+	Reason string
+}
+
+func (e *SecretValidationError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Field, e.Reason)
+}
+
+func firstControlChar(value string) (rune, bool) {
+	for _, r := range value {
+		if unicode.IsControl(r) {
+			return r, true
+		}
+	}
+	return 0, false
+}
+
+// firstValueControlChar is firstControlChar for secret values, which may
+// span lines and hold tabs; .env escaping keeps them on one line.
+func firstValueControlChar(value string) (rune, bool) {
+	return firstControlChar(strings.NewReplacer("\n", "", "\r", "", "\t", "").Replace(value))
+}
+
+func describeRune(r rune) string {
+	switch r {
+	case '\n':
+		return "newline"
+	case '\r':
+		return "carriage return"
+	case '\t':
+		return "tab"
+	case 0:
+		return "NUL byte"
+	default:
+		return fmt.Sprintf("control character %U", r)
+	}
+}
+
+// ValidateSecretID checks a secret identifier as declared in secrets.yaml.
+func ValidateSecretID(id string) error {
+	trimmed := strings.TrimSpace(id)
+	if trimmed == "" {
+		return &SecretValidationError{Field: "secret id", Reason: "is required"}
+	}
+	if len(trimmed) > maxSecretIDLength {
+		return &SecretValidationError{Field: "secret id", Reason: fmt.Sprintf("must be at most %d characters", maxSecretIDLength)}
+	}
+	if r, ok := firstControlChar(trimmed); ok {
+		return &SecretValidationError{Field: "secret id", Reason: "contains " + describeRune(r)}
+	}
+	if !secretIDPattern.MatchString(trimmed) {
+		return &SecretValidationError{Field: "secret id", Reason: "use letters, digits, and underscores only, not starting with a digit"}
+	}
+	return nil
+}
+
+// ValidateEnvVarName enforces POSIX-style upper-case env var names so that a
+// key can never smuggle "=" or whitespace into a .env line.
+func ValidateEnvVarName(name string) error {
+	if name == "" {
+		return &SecretValidationError{Field: "env var name", Reason: "is required"}
+	}
+	if r, ok := firstControlChar(name); ok {
+		return &SecretValidationError{Field: "env var name", Reason: "contains " + describeRune(r)}
+	}
+	if !envVarNamePattern.MatchString(name) {
+		return &SecretValidationError{Field: "env var name", Reason: fmt.Sprintf("%q must match [A-Z_][A-Z0-9_]*", name)}
+	}
+	return nil
+}
+
+// ValidateSecretValue rejects empty or oversized values and control
+// characters other than newlines and tabs, which are escaped in .env.
+func ValidateSecretValue(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return &SecretValidationError{Field: "secret value", Reason: "is required"}
+	}
+	if len(value) > MaxSecretValueLength {
+		return &SecretValidationError{Field: "secret value", Reason: fmt.Sprintf("must be at most %d bytes", MaxSecretValueLength)}
+	}
+	if r, ok := firstValueControlChar(value); ok {
+		return &SecretValidationError{Field: "secret value", Reason: "contains " + describeRune(r)}
+	}
+	return nil
+}
+
+func validateDotEnvEntry(key, value string) error {
+	if key == "" || strings.ContainsAny(key, "= \t#") {
+		return &SecretValidationError{Field: "env var name", Reason: fmt.Sprintf("%q cannot be written to .env", key)}
+	}
+	if r, ok := firstControlChar(key); ok {
+		return &SecretValidationError{Field: "env var name", Reason: "contains " + describeRune(r)}
+	}
+	if r, ok := firstValueControlChar(value); ok {
+		return &SecretValidationError{Field: "secret value", Reason: "contains " + describeRune(r)}
+	}
+	return nil
+}
+
+// NormalizeFrontendSecretName mirrors the name normalization applied by the
+// frontend workflow config.
+func NormalizeFrontendSecretName(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(name), " ", "_"))
+}
@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// clipboardClearEnv sets how long a copied secret stays on the clipboard,
+	// as a Go duration; 0 leaves it there.
+	clipboardClearEnv     = "SIXFLOW_CLIPBOARD_CLEAR"
+	defaultClipboardClear = 30 * time.Second
+
+	// secretFilePrefix marks a value typed as @path, whose file contents
+	// become the secret; "@@" stands for a literal leading "@".
+	secretFilePrefix = "@"
+)
+
+// ClipboardClearDelay is how long after copying a secret value the TUI
+// clears the clipboard again.
+func ClipboardClearDelay() time.Duration {
+	return envTimeout(clipboardClearEnv, defaultClipboardClear)
+}
+
+// ResolveSecretValue returns value itself, or what it points at when it is a
+// secret reference such as op://vault/item/field.
+func ResolveSecretValue(value string) (string, error) {
+	if !IsSecretReference(value) {
+		return value, nil
+	}
+	resolved, err := resolveSecretReference(value)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %v", ErrSecretReference, strings.TrimSpace(value), err)
+	}
+	return resolved, nil
+}
+
+// ReadLocalSecretValue returns the value of a secrets.yaml secret from the
+// target .env, with references resolved.
+func ReadLocalSecretValue(workflowID, workflowName, target, secretID string) (string, error) {
+	_, secretsYamlPath, dotEnvPath, _, err := preflightWorkflowSecrets(workflowID, workflowName, target)
+	if err != nil {
+		return "", err
+	}
+	manifest, err := loadSecretsManifest(secretsYamlPath)
+	if err != nil {
+		return "", err
+	}
+	id, envVars, exists := resolveSecretByID(manifest, secretID)
+	if !exists {
+		return "", fmt.Errorf("secret %q does not exist", strings.TrimSpace(secretID))
+	}
+	value := ""
+	if len(envVars) > 0 {
+		value, _ = readDotEnvValue(dotEnvPath, strings.TrimSpace(envVars[0]))
+	}
+	if strings.TrimSpace(value) == "" {
+		return "", fmt.Errorf("%s has no value in %s", id, filepath.Base(dotEnvPath))
+	}
+	return ResolveSecretValue(value)
+}
+
+// IsSecretValueFile reports whether value names a file with @path.
+func IsSecretValueFile(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, secretFilePrefix) && !strings.HasPrefix(value, secretFilePrefix+secretFilePrefix)
+}
+
+// ExpandSecretValueFile returns value as typed, or for @path, e.g.
+// @~/keys/service-account.json, the contents of that file without trailing
+// newlines. "@@..." yields the literal "@...".
+func ExpandSecretValueFile(value string) (string, error) {
+	trimmed := strings.TrimSpace(value)
+	if strings.HasPrefix(trimmed, secretFilePrefix+secretFilePrefix) {
+		return strings.TrimPrefix(trimmed, secretFilePrefix), nil
+	}
+	if !IsSecretValueFile(trimmed) {
+		return value, nil
+	}
+	path := expandHome(strings.TrimSpace(strings.TrimPrefix(trimmed, secretFilePrefix)))
+	if path == "" {
+		return "", fmt.Errorf("expected a file path after %s", secretFilePrefix)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > MaxSecretValueLength {
+		return "", fmt.Errorf("%s is %d bytes; secret values are at most %d", path, info.Size(), MaxSecretValueLength)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(strings.ReplaceAll(string(raw), "\r\n", "\n"), "\n"), nil
+}
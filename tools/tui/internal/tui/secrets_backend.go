@@ -0,0 +1,210 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Cloud secret managers are driven through the aws and gcloud CLIs, like the
+// OS keyring, so their own credential chains and SSO logins apply as is.
+// With a backend configured, secret values written from the TUI go to the
+// manager and .env only keeps a reference, which is resolved for each run:
+//
+//	API_KEY=aws-sm://6flow-my-workflow-API_KEY
+//	API_KEY=gcp-sm://6flow-my-workflow-API_KEY
+const (
+	SecretsBackendAWS = "aws"
+	SecretsBackendGCP = "gcp"
+
+	awsSecretRefPrefix = "aws-sm://"
+	gcpSecretRefPrefix = "gcp-sm://"
+
+	defaultSecretsBackendPrefix = "6flow-"
+)
+
+var ErrSecretsBackendCLI = errors.New("secrets backend CLI not found")
+
+// SecretsBackendConfig selects a cloud secret manager in ~/.6flow/config.json.
+type SecretsBackendConfig struct {
+	// Backend is "aws" or "gcp"; empty keeps values in .env.
+	Backend string `json:"backend,omitempty"`
+	// Prefix starts every secret name, "6flow-" by default.
+	Prefix string `json:"prefix,omitempty"`
+	// Region and Profile are passed to the aws CLI when set.
+	Region  string `json:"region,omitempty"`
+	Profile string `json:"profile,omitempty"`
+	// Project is passed to gcloud when set; otherwise its default project
+	// is used.
+	Project string `json:"project,omitempty"`
+}
+
+func loadSecretsBackend() (*SecretsBackendConfig, error) {
+	config, err := LoadTUIConfig()
+	if err != nil {
+		return nil, err
+	}
+	backend := config.Secrets
+	if backend == nil || strings.TrimSpace(backend.Backend) == "" {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(backend.Backend)) {
+	case SecretsBackendAWS, SecretsBackendGCP:
+	default:
+		return nil, fmt.Errorf("unknown secrets backend %q in %s (use %q or %q)", backend.Backend, tuiConfigPath(), SecretsBackendAWS, SecretsBackendGCP)
+	}
+	return backend, nil
+}
+
+// SecretsBackendLabel names the configured secret manager for the UI, or
+// returns "" when values are kept in .env.
+func SecretsBackendLabel() string {
+	backend, err := loadSecretsBackend()
+	if err != nil || backend == nil {
+		return ""
+	}
+	return backend.label()
+}
+
+func (b *SecretsBackendConfig) kind() string {
+	return strings.ToLower(strings.TrimSpace(b.Backend))
+}
+
+func (b *SecretsBackendConfig) label() string {
+	if b.kind() == SecretsBackendGCP {
+		return "GCP Secret Manager"
+	}
+	return "AWS Secrets Manager"
+}
+
+// secretName builds a name both managers accept: letters, digits, "-" and
+// "_".
+func (b *SecretsBackendConfig) secretName(workflowName, envVar string) string {
+	prefix := b.Prefix
+	if prefix == "" {
+		prefix = defaultSecretsBackendPrefix
+	}
+	return prefix + slugify(workflowName) + "-" + envVar
+}
+
+func (b *SecretsBackendConfig) reference(name string) string {
+	if b.kind() == SecretsBackendGCP {
+		return gcpSecretRefPrefix + name
+	}
+	return awsSecretRefPrefix + name
+}
+
+func (b *SecretsBackendConfig) awsArgs(args ...string) []string {
+	if b != nil && b.Region != "" {
+		args = append(args, "--region", b.Region)
+	}
+	if b != nil && b.Profile != "" {
+		args = append(args, "--profile", b.Profile)
+	}
+	return args
+}
+
+func (b *SecretsBackendConfig) gcloudArgs(args ...string) []string {
+	if b != nil && b.Project != "" {
+		args = append(args, "--project", b.Project)
+	}
+	return append(args, "--quiet")
+}
+
+// runSecretsCLI keeps stdout separate from stderr: stdout can be the secret
+// itself and the CLIs print warnings on stderr.
+func runSecretsCLI(stdin string, name string, args ...string) (string, error) {
+	if _, err := exec.LookPath(name); err != nil {
+		return "", fmt.Errorf("%w: %s", ErrSecretsBackendCLI, name)
+	}
+	var stderr strings.Builder
+	cmd := exec.Command(name, args...)
+	cmd.Env = secretsCLIEnv()
+	cmd.Stderr = &stderr
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", name, msg)
+		}
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return string(out), nil
+}
+
+// readCloudSecret resolves an aws-sm:// or gcp-sm:// reference.
+func readCloudSecret(reference string) (string, error) {
+	backend, err := loadSecretsBackend()
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case strings.HasPrefix(reference, awsSecretRefPrefix):
+		name := strings.TrimPrefix(reference, awsSecretRefPrefix)
+		value, err := runSecretsCLI("", "aws", backend.awsArgs("secretsmanager", "get-secret-value",
+			"--secret-id", name, "--query", "SecretString", "--output", "text")...)
+		return strings.TrimSuffix(value, "\n"), err
+	case strings.HasPrefix(reference, gcpSecretRefPrefix):
+		name := strings.TrimPrefix(reference, gcpSecretRefPrefix)
+		return runSecretsCLI("", "gcloud", backend.gcloudArgs("secrets", "versions", "access", "latest", "--secret", name)...)
+	}
+	return "", errors.New("not a cloud secret reference")
+}
+
+// writeCloudSecret stores value as the new version of name, creating the
+// secret on first use. Values go through stdin or a private temp file, never
+// argv.
+func (b *SecretsBackendConfig) writeCloudSecret(name, value string) error {
+	if DryRun() {
+		dryRunNote("would store %s in %s", name, b.label())
+		return nil
+	}
+	if b.kind() == SecretsBackendGCP {
+		_, err := runSecretsCLI(value, "gcloud", b.gcloudArgs("secrets", "versions", "add", name, "--data-file", "-")...)
+		if err != nil && strings.Contains(err.Error(), "NOT_FOUND") {
+			_, err = runSecretsCLI(value, "gcloud", b.gcloudArgs("secrets", "create", name,
+				"--replication-policy", "automatic", "--data-file", "-")...)
+		}
+		return err
+	}
+
+	file, err := os.CreateTemp("", "6flow-secret-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := file.WriteString(value); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	valueArg := "file://" + file.Name()
+	_, err = runSecretsCLI("", "aws", b.awsArgs("secretsmanager", "put-secret-value",
+		"--secret-id", name, "--secret-string", valueArg)...)
+	if err != nil && strings.Contains(err.Error(), "ResourceNotFoundException") {
+		_, err = runSecretsCLI("", "aws", b.awsArgs("secretsmanager", "create-secret",
+			"--name", name, "--secret-string", valueArg)...)
+	}
+	return err
+}
+
+// storeSecretValue returns what to write to .env for envVar: the value itself,
+// or, with a backend configured, a reference to the copy stored there.
+func storeSecretValue(workflowName, envVar, value string, appendLog func(string)) (string, error) {
+	backend, err := loadSecretsBackend()
+	if err != nil || backend == nil || IsSecretReference(value) {
+		return value, err
+	}
+	name := backend.secretName(workflowName, envVar)
+	if err := backend.writeCloudSecret(name, value); err != nil {
+		return "", fmt.Errorf("store %s in %s: %w", envVar, backend.label(), err)
+	}
+	appendLog(fmt.Sprintf("Stored %s in %s as %s; .env keeps only the reference.", envVar, backend.label(), name))
+	return backend.reference(name), nil
+}
@@ -0,0 +1,122 @@
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// CloneLocalSecrets copies the secrets.yaml declarations of another synced
+// workflow into this one and, with withValues, the values from the source's
+// .env for target that this workflow's .env is missing. Existing
+// declarations and values are never overwritten, and CRE_ETH_PRIVATE_KEY is
+// left alone: each workflow keeps its own deployer key.
+func CloneLocalSecrets(sourceID, sourceName, workflowID, workflowName, target string, withValues bool) (*SecretsImportResult, error) {
+	logs := []string{}
+	appendLog := func(msg string) { logs = append(logs, msg) }
+
+	if sourceID == workflowID {
+		return nil, fmt.Errorf("cannot clone secrets of %s into itself", workflowName)
+	}
+	_, sourceSecretsYamlPath, sourceDotEnvPath, _, err := preflightWorkflowSecrets(sourceID, sourceName, target)
+	if err != nil {
+		return nil, fmt.Errorf("source workflow %s: %w", sourceName, err)
+	}
+	_, secretsYamlPath, dotEnvPath, preflightLogs, err := preflightWorkflowSecretsForWrite(workflowID, workflowName, target)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range preflightLogs {
+		appendLog(l)
+	}
+
+	sourceManifest, err := loadSecretsManifest(sourceSecretsYamlPath)
+	if err != nil {
+		return &SecretsImportResult{Logs: logs}, err
+	}
+	manifest, err := loadSecretsManifest(secretsYamlPath)
+	if err != nil {
+		return &SecretsImportResult{Logs: logs}, err
+	}
+
+	sourceMeta := loadSecretsMeta(sourceDotEnvPath)
+	changes := []SecretImportChange{}
+	values := map[string]string{}
+	declared := 0
+	for _, entry := range listLocalSecretEntries(sourceManifest, sourceDotEnvPath) {
+		if entry.ID == "CRE_ETH_PRIVATE_KEY" {
+			changes = append(changes, SecretImportChange{ID: entry.ID, EnvVar: entry.EnvVar, Action: SecretImportSkipped})
+			continue
+		}
+		change := SecretImportChange{ID: entry.ID, EnvVar: entry.EnvVar, Action: SecretImportUnchanged}
+		if id, envVars, exists := resolveSecretByID(manifest, entry.ID); exists {
+			change.ID = id
+			change.EnvVar = ""
+			if len(envVars) > 0 {
+				change.EnvVar = strings.TrimSpace(envVars[0])
+			}
+		} else {
+			if change.EnvVar == "" {
+				change.EnvVar = defaultEnvVarForSecret(entry.ID)
+			}
+			if err := ValidateEnvVarName(change.EnvVar); err != nil {
+				appendLog(fmt.Sprintf("- %s: skipped (%v)", entry.ID, err))
+				continue
+			}
+			manifest.SecretsNames[entry.ID] = []string{change.EnvVar}
+			change.Action = SecretImportCreate
+			declared++
+		}
+		if withValues && entry.HasValue && change.EnvVar != "" {
+			if current, _ := readDotEnvValue(dotEnvPath, change.EnvVar); strings.TrimSpace(current) == "" {
+				values[change.ID], _ = readDotEnvValue(sourceDotEnvPath, entry.EnvVar)
+				if change.Action == SecretImportUnchanged {
+					change.Action = SecretImportUpdate
+				}
+			}
+		}
+		changes = append(changes, change)
+	}
+
+	if declared > 0 {
+		if err := saveSecretsManifest(secretsYamlPath, manifest); err != nil {
+			return &SecretsImportResult{Logs: logs, Changes: changes}, err
+		}
+	}
+	copied := 0
+	for _, change := range changes {
+		value, ok := values[change.ID]
+		if !ok {
+			continue
+		}
+		if err := setDotEnvValue(dotEnvPath, change.EnvVar, value); err != nil {
+			return &SecretsImportResult{Logs: logs, Changes: changes}, fmt.Errorf("clone stopped at %s: %w", change.ID, err)
+		}
+		// The value is the same one, so it keeps its age.
+		if rotatedAt := sourceMeta.rotatedAt(change.EnvVar); !rotatedAt.IsZero() {
+			_ = setSecretRotatedAt(dotEnvPath, change.EnvVar, rotatedAt)
+		}
+		copied++
+	}
+
+	for _, change := range changes {
+		switch {
+		case change.Action == SecretImportSkipped:
+			appendLog(fmt.Sprintf("- %s: skipped; each workflow keeps its own key", change.ID))
+		case change.Action == SecretImportCreate && values[change.ID] != "":
+			appendLog(fmt.Sprintf("- %s => %s: declared, value copied", change.ID, change.EnvVar))
+		case change.Action == SecretImportCreate:
+			appendLog(fmt.Sprintf("- %s => %s: declared", change.ID, change.EnvVar))
+		case change.Action == SecretImportUpdate:
+			appendLog(fmt.Sprintf("- %s => %s: value copied", change.ID, change.EnvVar))
+		default:
+			appendLog(fmt.Sprintf("- %s: already present", change.ID))
+		}
+	}
+	appendLog(fmt.Sprintf("Cloned secrets from %s: %d declared, %d value(s) copied into %s.",
+		sourceName, declared, copied, filepath.Base(dotEnvPath)))
+	if declared > 0 {
+		appendLog("The frontend config is unchanged; DIFF publishes the new names.")
+	}
+	return &SecretsImportResult{Logs: logs, Changes: changes}, nil
+}
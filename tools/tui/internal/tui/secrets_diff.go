@@ -0,0 +1,189 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SecretDiffEntry is one secret name across the three places it can live:
+// declared in secrets.yaml, configured in the frontend, and given a value in
+// the target .env.
+type SecretDiffEntry struct {
+	Name string
+	// ID is the secrets.yaml ID, or the .env key for an undeclared value.
+	ID       string
+	EnvVar   string
+	Local    bool
+	Frontend bool
+	HasValue bool
+}
+
+// InSync reports whether the secret is declared, configured and has a value.
+func (e SecretDiffEntry) InSync() bool {
+	return e.Local && e.Frontend && e.HasValue
+}
+
+type SecretsDiffResult struct {
+	Logs    []string
+	Entries []SecretDiffEntry
+	// DotEnvName is the .env file the values were read from.
+	DotEnvName string
+}
+
+// DiffWorkflowSecrets lines up the local secrets.yaml, the frontend workflow
+// config and the values in the target .env. Values set in .env under a key
+// no secret maps to are listed too, since cre never reads them.
+func DiffWorkflowSecrets(baseURL, token, workflowID, workflowName, target string) (*SecretsDiffResult, error) {
+	_, secretsYamlPath, dotEnvPath, logs, err := preflightWorkflowSecrets(workflowID, workflowName, target)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := loadSecretsManifest(secretsYamlPath)
+	if err != nil {
+		return &SecretsDiffResult{Logs: logs}, err
+	}
+	remote, err := FetchWorkflowSecretNames(baseURL, token, workflowID)
+	if err != nil {
+		return &SecretsDiffResult{Logs: logs}, err
+	}
+
+	byName := map[string]*SecretDiffEntry{}
+	entries := []*SecretDiffEntry{}
+	mapped := map[string]bool{"CRE_ETH_PRIVATE_KEY": true}
+	for _, secret := range listLocalSecretEntries(manifest, dotEnvPath) {
+		mapped[secret.EnvVar] = true
+		if secret.ID == "CRE_ETH_PRIVATE_KEY" {
+			// The signing key is local-only by design.
+			continue
+		}
+		entry := &SecretDiffEntry{
+			Name:     NormalizeFrontendSecretName(secret.ID),
+			ID:       secret.ID,
+			EnvVar:   secret.EnvVar,
+			Local:    true,
+			HasValue: secret.HasValue,
+		}
+		byName[entry.Name] = entry
+		entries = append(entries, entry)
+	}
+
+	raw, err := readDotEnvContent(dotEnvPath)
+	if err != nil && !os.IsNotExist(err) {
+		return &SecretsDiffResult{Logs: logs}, err
+	}
+	dotEnvEntries, err := parseDotEnv(filepath.Base(dotEnvPath), raw)
+	if err != nil {
+		return &SecretsDiffResult{Logs: logs}, err
+	}
+	for _, dotEnvEntry := range dotEnvEntries {
+		if mapped[dotEnvEntry.Key] || strings.TrimSpace(dotEnvEntry.Value) == "" {
+			continue
+		}
+		name := NormalizeFrontendSecretName(dotEnvEntry.Key)
+		if _, ok := byName[name]; ok {
+			continue
+		}
+		entry := &SecretDiffEntry{Name: name, ID: dotEnvEntry.Key, EnvVar: dotEnvEntry.Key, HasValue: true}
+		byName[name] = entry
+		entries = append(entries, entry)
+	}
+
+	for _, name := range remote {
+		if entry, ok := byName[name]; ok {
+			entry.Frontend = true
+			continue
+		}
+		entries = append(entries, &SecretDiffEntry{Name: name, ID: name, Frontend: true})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	result := &SecretsDiffResult{Logs: logs, Entries: make([]SecretDiffEntry, 0, len(entries)), DotEnvName: filepath.Base(dotEnvPath)}
+	drifted := 0
+	for _, entry := range entries {
+		result.Entries = append(result.Entries, *entry)
+		if !entry.InSync() {
+			drifted++
+		}
+	}
+	result.Logs = append(result.Logs, fmt.Sprintf("Secrets diff: %d secret(s), %d out of sync.", len(entries), drifted))
+	return result, nil
+}
+
+// DeclareDotEnvSecret adds a secrets.yaml entry for a key that already has a
+// value in the target .env, mapping it to that key.
+func DeclareDotEnvSecret(workflowID, workflowName, target, envVar string) (*SecretsCommandResult, error) {
+	logs := []string{}
+	appendLog := func(msg string) { logs = append(logs, msg) }
+
+	_, secretsYamlPath, dotEnvPath, preflightLogs, err := preflightWorkflowSecrets(workflowID, workflowName, target)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range preflightLogs {
+		appendLog(l)
+	}
+
+	envVar = strings.TrimSpace(envVar)
+	if err := ValidateSecretID(envVar); err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	if err := ValidateEnvVarName(envVar); err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	if value, _ := readDotEnvValue(dotEnvPath, envVar); strings.TrimSpace(value) == "" {
+		return &SecretsCommandResult{Logs: logs}, fmt.Errorf("%s has no value in %s", envVar, filepath.Base(dotEnvPath))
+	}
+	manifest, err := loadSecretsManifest(secretsYamlPath)
+	if err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	if resolvedID, _, exists := resolveSecretByID(manifest, envVar); exists {
+		return &SecretsCommandResult{Logs: logs}, fmt.Errorf("secret %q already exists", resolvedID)
+	}
+	manifest.SecretsNames[envVar] = []string{envVar}
+	if err := saveSecretsManifest(secretsYamlPath, manifest); err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	appendLog(fmt.Sprintf("Declared secret %s in secrets.yaml for the existing %s value", envVar, filepath.Base(dotEnvPath)))
+	return &SecretsCommandResult{Logs: logs}, nil
+}
+
+// RemoveDotEnvSecretValue deletes a key no secret maps to from the target
+// .env.
+func RemoveDotEnvSecretValue(workflowID, workflowName, target, envVar string) (*SecretsCommandResult, error) {
+	logs := []string{}
+	appendLog := func(msg string) { logs = append(logs, msg) }
+
+	_, secretsYamlPath, dotEnvPath, preflightLogs, err := preflightWorkflowSecretsForWrite(workflowID, workflowName, target)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range preflightLogs {
+		appendLog(l)
+	}
+
+	envVar = strings.TrimSpace(envVar)
+	if envVar == "" || envVar == "CRE_ETH_PRIVATE_KEY" {
+		return &SecretsCommandResult{Logs: logs}, errors.New("refusing to remove CRE_ETH_PRIVATE_KEY here")
+	}
+	manifest, err := loadSecretsManifest(secretsYamlPath)
+	if err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	for id, envVars := range manifest.SecretsNames {
+		for _, mappedVar := range envVars {
+			if strings.TrimSpace(mappedVar) == envVar {
+				return &SecretsCommandResult{Logs: logs}, fmt.Errorf("%s is the value of secret %s; use REMOVE instead", envVar, id)
+			}
+		}
+	}
+	if err := removeDotEnvValue(dotEnvPath, envVar); err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	appendLog(fmt.Sprintf("Removed undeclared %s from %s", envVar, filepath.Base(dotEnvPath)))
+	return &SecretsCommandResult{Logs: logs}, nil
+}
@@ -0,0 +1,162 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Local secret files must never reach a git history. Sync gives every
+// project a .gitignore for them, secrets writes refuse to touch a file git
+// already tracks, and preflight warns about any that git would pick up.
+// Projects outside a git repository, or machines without git, are not
+// checked.
+
+const gitignoreHeader = "# Local secrets written by 6flow-tui; never commit them."
+
+var secretFileGitignore = []string{".env", ".env.*", keystoreFileName}
+
+var ErrSecretFileTracked = errors.New("secret file is tracked by git")
+
+// SecretFileTrackedError names the tracked file and how to untrack it.
+type SecretFileTrackedError struct {
+	Path string
+}
+
+func (e *SecretFileTrackedError) Error() string {
+	return fmt.Sprintf("%s: %s; run `git rm --cached %s` in %s and commit, then retry",
+		ErrSecretFileTracked, filepath.Base(e.Path), filepath.Base(e.Path), filepath.Dir(e.Path))
+}
+
+func (e *SecretFileTrackedError) Unwrap() error { return ErrSecretFileTracked }
+
+// gitOutput runs git in dir. ok is false when git is missing or dir is not
+// inside a work tree; exit status 1, which ls-files and check-ignore use for
+// "nothing matched", is not an error.
+func gitOutput(dir string, args ...string) (out string, ok bool) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return "", false
+	}
+	raw, err := exec.Command("git", append([]string{"-C", dir}, args...)...).Output()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return "", false
+	}
+	return string(raw), true
+}
+
+func insideGitWorkTree(dir string) bool {
+	out, ok := gitOutput(dir, "rev-parse", "--is-inside-work-tree")
+	return ok && strings.TrimSpace(out) == "true"
+}
+
+// requireSecretFileUntracked refuses a write to path while git tracks it,
+// since the next commit would publish the new value.
+func requireSecretFileUntracked(path string) error {
+	dir := filepath.Dir(path)
+	if !insideGitWorkTree(dir) {
+		return nil
+	}
+	if out, ok := gitOutput(dir, "ls-files", "--", filepath.Base(path)); ok && strings.TrimSpace(out) != "" {
+		return &SecretFileTrackedError{Path: path}
+	}
+	return nil
+}
+
+// projectSecretFiles lists the secret files in a synced project, relative to
+// projectRoot. The .meta.json age sidecars hold no values and are left out.
+func projectSecretFiles(projectRoot string) []string {
+	files := []string{}
+	for _, dir := range []string{".", "*"} {
+		for _, pattern := range secretFileGitignore {
+			matches, _ := filepath.Glob(filepath.Join(projectRoot, dir, pattern))
+			for _, match := range matches {
+				if strings.HasSuffix(match, secretsMetaSuffix) {
+					continue
+				}
+				if info, err := os.Stat(match); err != nil || info.IsDir() {
+					continue
+				}
+				if rel, err := filepath.Rel(projectRoot, match); err == nil {
+					files = append(files, rel)
+				}
+			}
+		}
+	}
+	return files
+}
+
+// secretFileGitWarnings describes every secret file in the project that git
+// tracks or does not ignore.
+func secretFileGitWarnings(projectRoot string) []string {
+	if !insideGitWorkTree(projectRoot) {
+		return nil
+	}
+	files := projectSecretFiles(projectRoot)
+	if len(files) == 0 {
+		return nil
+	}
+	tracked := map[string]bool{}
+	if out, ok := gitOutput(projectRoot, append([]string{"ls-files", "--"}, files...)...); ok {
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			if line != "" {
+				tracked[filepath.FromSlash(line)] = true
+			}
+		}
+	}
+	ignored := map[string]bool{}
+	if out, ok := gitOutput(projectRoot, append([]string{"check-ignore", "--"}, files...)...); ok {
+		for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+			if line != "" {
+				ignored[filepath.FromSlash(line)] = true
+			}
+		}
+	}
+	warnings := []string{}
+	for _, file := range files {
+		switch {
+		case tracked[file]:
+			warnings = append(warnings, fmt.Sprintf("WARNING: %s is tracked by git. Secrets writes to it are refused until you run `git rm --cached %s` and commit.", file, filepath.ToSlash(file)))
+		case !ignored[file]:
+			warnings = append(warnings, fmt.Sprintf("WARNING: %s is not ignored by git and could be committed. Add it to .gitignore.", file))
+		}
+	}
+	return warnings
+}
+
+// stageSecretsGitignore writes stagedDir/.gitignore from the previous sync's
+// one in finalDir, adding any missing secret file patterns. It reports
+// whether patterns were added.
+func stageSecretsGitignore(finalDir, stagedDir string) (bool, error) {
+	raw, err := os.ReadFile(filepath.Join(finalDir, ".gitignore"))
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	content := string(raw)
+	present := map[string]bool{}
+	for _, line := range strings.Split(content, "\n") {
+		present[strings.TrimSpace(line)] = true
+	}
+	missing := []string{}
+	for _, pattern := range secretFileGitignore {
+		if !present[pattern] {
+			missing = append(missing, pattern)
+		}
+	}
+	if len(missing) > 0 {
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		if content != "" {
+			content += "\n"
+		}
+		content += gitignoreHeader + "\n" + strings.Join(missing, "\n") + "\n"
+	}
+	if content == "" {
+		return false, nil
+	}
+	return len(missing) > 0, os.WriteFile(filepath.Join(stagedDir, ".gitignore"), []byte(content), 0o644)
+}
@@ -0,0 +1,183 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+type DotEnvEntry struct {
+	Key   string
+	Value string
+	Line  int
+}
+
+type SecretImportChange struct {
+	ID     string
+	EnvVar string
+	Action string
+}
+
+type SecretsImportResult struct {
+	Logs    []string
+	Changes []SecretImportChange
+}
+
+const (
+	SecretImportCreate    = "create"
+	SecretImportUpdate    = "update"
+	SecretImportUnchanged = "unchanged"
+	SecretImportSkipped   = "skipped"
+)
+
+// ParseDotEnvFile reads KEY=VALUE pairs, accepting the common `export KEY=...`
+// prefix and single/double quoted values, which may span several lines.
+func ParseDotEnvFile(path string) ([]DotEnvEntry, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseDotEnv(path, raw)
+}
+
+func parseDotEnv(path string, raw []byte) ([]DotEnvEntry, error) {
+	entries := []DotEnvEntry{}
+	lines := strings.Split(string(raw), "\n")
+	for idx := 0; idx < len(lines); idx++ {
+		trimmed := strings.TrimSpace(strings.TrimSuffix(lines[idx], "\r"))
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		trimmed = strings.TrimSpace(strings.TrimPrefix(trimmed, "export "))
+		key, value, ok := strings.Cut(trimmed, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, idx+1)
+		}
+		span := dotEnvEntryLines(append([]string{trimmed}, lines[idx+1:]...))
+		for _, next := range lines[idx+1 : idx+span] {
+			value += "\n" + strings.TrimSuffix(next, "\r")
+		}
+		value, err := decodeDotEnvValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, idx+1, err)
+		}
+		entries = append(entries, DotEnvEntry{Key: strings.TrimSpace(key), Value: value, Line: idx + 1})
+		idx += span - 1
+	}
+	return entries, nil
+}
+
+// ImportLocalSecrets validates every entry before writing any of them, so a
+// bad line never leaves the project half-imported.
+func ImportLocalSecrets(workflowID, workflowName, target string, entries []DotEnvEntry, dryRun bool) (*SecretsImportResult, error) {
+	logs := []string{}
+	appendLog := func(msg string) { logs = append(logs, msg) }
+
+	preflight := preflightWorkflowSecretsForWrite
+	if dryRun {
+		preflight = preflightWorkflowSecrets
+	}
+	_, secretsYamlPath, dotEnvPath, preflightLogs, err := preflight(workflowID, workflowName, target)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range preflightLogs {
+		appendLog(l)
+	}
+
+	if len(entries) == 0 {
+		return &SecretsImportResult{Logs: logs}, errors.New("import file contains no KEY=VALUE entries")
+	}
+	manifest, err := loadSecretsManifest(secretsYamlPath)
+	if err != nil {
+		return &SecretsImportResult{Logs: logs}, err
+	}
+
+	invalid := []string{}
+	seen := map[string]int{}
+	changes := make([]SecretImportChange, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Key == "CRE_ETH_PRIVATE_KEY" {
+			changes = append(changes, SecretImportChange{ID: entry.Key, EnvVar: entry.Key, Action: SecretImportSkipped})
+			continue
+		}
+		if err := ValidateSecretID(entry.Key); err != nil {
+			invalid = append(invalid, fmt.Sprintf("line %d: %v", entry.Line, err))
+			continue
+		}
+		if err := ValidateSecretValue(entry.Value); err != nil {
+			invalid = append(invalid, fmt.Sprintf("line %d (%s): %v", entry.Line, entry.Key, err))
+			continue
+		}
+		if prev, dup := seen[entry.Key]; dup {
+			invalid = append(invalid, fmt.Sprintf("line %d: %s already defined on line %d", entry.Line, entry.Key, prev))
+			continue
+		}
+		seen[entry.Key] = entry.Line
+
+		resolvedID, envVars, exists := resolveSecretByID(manifest, entry.Key)
+		change := SecretImportChange{ID: normalizeSecretID(entry.Key), Action: SecretImportCreate}
+		if exists {
+			change.ID = resolvedID
+			change.Action = SecretImportUpdate
+			if len(envVars) > 0 {
+				change.EnvVar = strings.TrimSpace(envVars[0])
+			}
+			if change.EnvVar != "" {
+				current, _ := readDotEnvValue(dotEnvPath, change.EnvVar)
+				if current == strings.TrimSpace(entry.Value) {
+					change.Action = SecretImportUnchanged
+				}
+			}
+		}
+		if change.EnvVar == "" {
+			change.EnvVar = defaultEnvVarForSecret(change.ID)
+		}
+		changes = append(changes, change)
+	}
+	if len(invalid) > 0 {
+		for _, line := range invalid {
+			appendLog("- " + line)
+		}
+		return &SecretsImportResult{Logs: logs, Changes: changes}, &SecretValidationError{
+			Field:  "import file",
+			Reason: fmt.Sprintf("%d invalid entries; nothing was imported", len(invalid)),
+		}
+	}
+
+	if dryRun {
+		appendLog(fmt.Sprintf("Dry run: %d entries checked, no files were changed.", len(changes)))
+		return &SecretsImportResult{Logs: logs, Changes: changes}, nil
+	}
+
+	values := map[string]string{}
+	for _, entry := range entries {
+		values[entry.Key] = entry.Value
+	}
+	counts := map[string]int{}
+	for _, change := range changes {
+		counts[change.Action]++
+		var result *SecretsCommandResult
+		switch change.Action {
+		case SecretImportCreate:
+			result, err = CreateLocalSecret(workflowID, workflowName, target, change.ID, values[change.ID])
+		case SecretImportUpdate:
+			result, err = UpdateLocalSecret(workflowID, workflowName, target, change.ID, values[change.ID])
+		case SecretImportSkipped:
+			appendLog("Skipped CRE_ETH_PRIVATE_KEY; set it with Secrets -> UPDATE or the keystore instead.")
+			continue
+		default:
+			continue
+		}
+		if err != nil {
+			return &SecretsImportResult{Logs: logs, Changes: changes}, fmt.Errorf("import stopped at %s: %w", change.ID, err)
+		}
+		if result != nil && len(result.Logs) > 0 {
+			appendLog(result.Logs[len(result.Logs)-1])
+		}
+	}
+	appendLog(fmt.Sprintf("Imported secrets: %d created, %d updated, %d unchanged.",
+		counts[SecretImportCreate], counts[SecretImportUpdate], counts[SecretImportUnchanged]))
+	return &SecretsImportResult{Logs: logs, Changes: changes}, nil
+}
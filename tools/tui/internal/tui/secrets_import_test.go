@@ -0,0 +1,91 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseDotEnvFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []DotEnvEntry
+		wantErr bool
+	}{
+		{
+			name:    "empty file",
+			content: "",
+			want:    []DotEnvEntry{},
+		},
+		{
+			name:    "comments and blank lines",
+			content: "# header\n\nA=1\n  # indented comment\nB=2\n",
+			want:    []DotEnvEntry{{Key: "A", Value: "1", Line: 3}, {Key: "B", Value: "2", Line: 5}},
+		},
+		{
+			name:    "export prefix and spaces",
+			content: "export TOKEN = abc \n",
+			want:    []DotEnvEntry{{Key: "TOKEN", Value: "abc", Line: 1}},
+		},
+		{
+			name:    "quoted values",
+			content: "A=\"x y\"\nB='$literal'\nC=\"a\\nb\"\n",
+			want: []DotEnvEntry{
+				{Key: "A", Value: "x y", Line: 1},
+				{Key: "B", Value: "$literal", Line: 2},
+				{Key: "C", Value: "a\nb", Line: 3},
+			},
+		},
+		{
+			name:    "value containing equals",
+			content: "URL=https://x.test/?a=b\n",
+			want:    []DotEnvEntry{{Key: "URL", Value: "https://x.test/?a=b", Line: 1}},
+		},
+		{
+			name:    "CRLF line endings",
+			content: "A=1\r\nB=2\r\n",
+			want:    []DotEnvEntry{{Key: "A", Value: "1", Line: 1}, {Key: "B", Value: "2", Line: 2}},
+		},
+		{
+			name:    "multi-line quoted value",
+			content: "KEY=\"-----BEGIN-----\nAAAA\n-----END-----\"\nNEXT=1\n",
+			want: []DotEnvEntry{
+				{Key: "KEY", Value: "-----BEGIN-----\nAAAA\n-----END-----", Line: 1},
+				{Key: "NEXT", Value: "1", Line: 4},
+			},
+		},
+		{
+			name:    "missing equals",
+			content: "A=1\nNOT_A_PAIR\n",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated quote",
+			content: "A=\"open\nB=2\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), ".env")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ParseDotEnvFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDotEnvFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("ParseDotEnvFile() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDotEnvFileMissing(t *testing.T) {
+	if _, err := ParseDotEnvFile(filepath.Join(t.TempDir(), "missing.env")); !os.IsNotExist(err) {
+		t.Fatalf("ParseDotEnvFile() error = %v, want not-exist", err)
+	}
+}
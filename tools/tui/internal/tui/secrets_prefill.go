@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SecretPrefill is a declared secret without a .env value whose variable is
+// already exported in the TUI's own environment.
+type SecretPrefill struct {
+	ID     string
+	EnvVar string
+	// Source is the environment variable the value comes from: EnvVar, or
+	// else the secret ID.
+	Source string
+}
+
+type SecretsPrefillResult struct {
+	Logs     []string
+	Prefills []SecretPrefill
+}
+
+// environmentValueFor looks a secret up in the process environment by its
+// .env name first, then by its ID.
+func environmentValueFor(entry LocalSecretEntry) (string, string, bool) {
+	for _, name := range []string{entry.EnvVar, entry.ID} {
+		if name == "" {
+			continue
+		}
+		if value, ok := os.LookupEnv(name); ok && strings.TrimSpace(value) != "" {
+			return name, value, true
+		}
+	}
+	return "", "", false
+}
+
+// planEnvPrefill lists the secrets that can be filled from the environment.
+// CRE_ETH_PRIVATE_KEY is left out: runs already read it from the environment.
+func planEnvPrefill(manifest *secretsManifest, dotEnvPath string, appendLog func(string)) []SecretPrefill {
+	prefills := []SecretPrefill{}
+	for _, entry := range listLocalSecretEntries(manifest, dotEnvPath) {
+		if entry.HasValue || entry.ID == "CRE_ETH_PRIVATE_KEY" {
+			continue
+		}
+		source, value, ok := environmentValueFor(entry)
+		if !ok {
+			continue
+		}
+		if err := ValidateSecretValue(value); err != nil {
+			appendLog(fmt.Sprintf("- %s: $%s cannot be used (%v)", entry.ID, source, err))
+			continue
+		}
+		prefills = append(prefills, SecretPrefill{ID: entry.ID, EnvVar: entry.EnvVar, Source: source})
+	}
+	return prefills
+}
+
+// PlanEnvPrefill reports which missing secrets of the target .env could be
+// filled from the environment, without writing anything.
+func PlanEnvPrefill(workflowID, workflowName, target string) (*SecretsPrefillResult, error) {
+	logs := []string{}
+	appendLog := func(msg string) { logs = append(logs, msg) }
+
+	_, secretsYamlPath, dotEnvPath, preflightLogs, err := preflightWorkflowSecrets(workflowID, workflowName, target)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range preflightLogs {
+		appendLog(l)
+	}
+	manifest, err := loadSecretsManifest(secretsYamlPath)
+	if err != nil {
+		return &SecretsPrefillResult{Logs: logs}, err
+	}
+
+	prefills := planEnvPrefill(manifest, dotEnvPath, appendLog)
+	if len(prefills) == 0 {
+		appendLog(fmt.Sprintf("No missing secret in %s is set in this environment.", filepath.Base(dotEnvPath)))
+		return &SecretsPrefillResult{Logs: logs}, nil
+	}
+	appendLog(fmt.Sprintf("Missing secrets in %s found in this environment:", filepath.Base(dotEnvPath)))
+	for _, prefill := range prefills {
+		appendLog(fmt.Sprintf("- %s => %s (from $%s)", prefill.ID, prefill.EnvVar, prefill.Source))
+	}
+	return &SecretsPrefillResult{Logs: logs, Prefills: prefills}, nil
+}
+
+// ApplyEnvPrefill writes the environment values of the confirmed secret IDs
+// into the target .env. IDs that got a value in the meantime, or whose
+// variable is no longer set, are skipped.
+func ApplyEnvPrefill(workflowID, workflowName, target string, ids []string) (*SecretsPrefillResult, error) {
+	logs := []string{}
+	appendLog := func(msg string) { logs = append(logs, msg) }
+
+	_, secretsYamlPath, dotEnvPath, preflightLogs, err := preflightWorkflowSecretsForWrite(workflowID, workflowName, target)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range preflightLogs {
+		appendLog(l)
+	}
+	manifest, err := loadSecretsManifest(secretsYamlPath)
+	if err != nil {
+		return &SecretsPrefillResult{Logs: logs}, err
+	}
+
+	applied := []SecretPrefill{}
+	for _, prefill := range planEnvPrefill(manifest, dotEnvPath, appendLog) {
+		if !slices.Contains(ids, prefill.ID) {
+			continue
+		}
+		value, _ := os.LookupEnv(prefill.Source)
+		result, err := UpdateLocalSecret(workflowID, workflowName, target, prefill.ID, value)
+		if err != nil {
+			return &SecretsPrefillResult{Logs: logs, Prefills: applied}, fmt.Errorf("prefill stopped at %s: %w", prefill.ID, err)
+		}
+		if result != nil && len(result.Logs) > 0 {
+			appendLog(result.Logs[len(result.Logs)-1])
+		}
+		applied = append(applied, prefill)
+	}
+	if skipped := len(ids) - len(applied); skipped > 0 {
+		appendLog(fmt.Sprintf("Skipped %d secret(s) that already have a value or are no longer set in this environment.", skipped))
+	}
+	appendLog(fmt.Sprintf("Prefilled %d secret(s) in %s from the environment.", len(applied), filepath.Base(dotEnvPath)))
+	return &SecretsPrefillResult{Logs: logs, Prefills: applied}, nil
+}
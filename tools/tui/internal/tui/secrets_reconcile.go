@@ -0,0 +1,143 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+)
+
+var ErrFrontendSecretsUnsupported = errors.New("frontend does not list workflow secrets")
+
+const (
+	SecretInBoth         = "both"
+	SecretOnlyLocal      = "local-only"
+	SecretOnlyInFrontend = "frontend-only"
+)
+
+type workflowSecretsResponse struct {
+	Secrets []string `json:"secrets"`
+	apiErrorBody
+}
+
+// FetchWorkflowSecretNames returns the secret names the frontend has
+// configured for a workflow, normalized like UpdateWorkflowSecretInFrontend
+// sends them. Values never leave the frontend.
+func FetchWorkflowSecretNames(baseURL, token, workflowID string) ([]string, error) {
+	endpoint := fmt.Sprintf("%s/api/tui/workflows/%s/secrets", NormalizeBaseURL(baseURL), url.PathEscape(workflowID))
+
+	client := newHTTPClient(secretsTimeout())
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := doFrontendRequest(client, req)
+	if err != nil {
+		return nil, timeoutHint(err, secretsTimeoutEnv)
+	}
+	defer resp.Body.Close()
+
+	var payload workflowSecretsResponse
+	_ = json.NewDecoder(resp.Body).Decode(&payload)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, ErrFrontendUnauthorized
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		return nil, ErrFrontendSecretsUnsupported
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, payload.apiError(resp.StatusCode)
+	}
+	if payload.Secrets == nil {
+		return nil, errors.New("invalid API response from /api/tui/workflows/{id}/secrets")
+	}
+
+	names := make([]string, 0, len(payload.Secrets))
+	seen := map[string]bool{}
+	for _, name := range payload.Secrets {
+		normalized := NormalizeFrontendSecretName(name)
+		if normalized == "" || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		names = append(names, normalized)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+type SecretReconcileEntry struct {
+	Name     string
+	Location string
+	// EnvVar and HasValue describe the local side; empty for frontend-only.
+	EnvVar   string
+	HasValue bool
+}
+
+type SecretsReconcileResult struct {
+	Logs    []string
+	Entries []SecretReconcileEntry
+}
+
+// Drifted reports whether any secret exists in only one place.
+func (r *SecretsReconcileResult) Drifted() bool {
+	for _, entry := range r.Entries {
+		if entry.Location != SecretInBoth {
+			return true
+		}
+	}
+	return false
+}
+
+// ReconcileWorkflowSecrets compares the secrets declared in the local
+// secrets.yaml with the names configured in the frontend.
+func ReconcileWorkflowSecrets(baseURL, token, workflowID, workflowName, target string) (*SecretsReconcileResult, error) {
+	local, err := ListLocalSecrets(workflowID, workflowName, target)
+	if err != nil {
+		if local != nil {
+			return &SecretsReconcileResult{Logs: local.Logs}, err
+		}
+		return nil, err
+	}
+	logs := local.Logs
+	remote, err := FetchWorkflowSecretNames(baseURL, token, workflowID)
+	if err != nil {
+		return &SecretsReconcileResult{Logs: logs}, err
+	}
+
+	byName := map[string]*SecretReconcileEntry{}
+	entries := []*SecretReconcileEntry{}
+	for _, secret := range local.Entries {
+		if secret.ID == "CRE_ETH_PRIVATE_KEY" {
+			// The signing key is local-only by design.
+			continue
+		}
+		name := NormalizeFrontendSecretName(secret.ID)
+		entry := &SecretReconcileEntry{Name: name, Location: SecretOnlyLocal, EnvVar: secret.EnvVar, HasValue: secret.HasValue}
+		byName[name] = entry
+		entries = append(entries, entry)
+	}
+	for _, name := range remote {
+		if entry, ok := byName[name]; ok {
+			entry.Location = SecretInBoth
+			continue
+		}
+		entries = append(entries, &SecretReconcileEntry{Name: name, Location: SecretOnlyInFrontend})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	result := &SecretsReconcileResult{Logs: logs, Entries: make([]SecretReconcileEntry, 0, len(entries))}
+	counts := map[string]int{}
+	for _, entry := range entries {
+		result.Entries = append(result.Entries, *entry)
+		counts[entry.Location]++
+	}
+	result.Logs = append(result.Logs, fmt.Sprintf("Secrets: %d in both, %d only in local secrets.yaml, %d only in the frontend.",
+		counts[SecretInBoth], counts[SecretOnlyLocal], counts[SecretOnlyInFrontend]))
+	return result, nil
+}
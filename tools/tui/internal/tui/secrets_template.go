@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// secretsTemplateFileName is written next to the workflow .env and is safe to
+// commit: it lists the keys a teammate has to fill in, never their values.
+const secretsTemplateFileName = ".env.example"
+
+// secretsManifestComments returns the comments written around each secret ID
+// in secrets.yaml, which yaml.Unmarshal into secretsManifest drops.
+func secretsManifestComments(secretsYamlPath string) map[string]string {
+	comments := map[string]string{}
+	raw, err := os.ReadFile(secretsYamlPath)
+	if err != nil {
+		return comments
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil || len(doc.Content) == 0 {
+		return comments
+	}
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "secretsNames" {
+			continue
+		}
+		names := root.Content[i+1]
+		for j := 0; j+1 < len(names.Content); j += 2 {
+			key, value := names.Content[j], names.Content[j+1]
+			parts := []string{key.HeadComment, key.LineComment, value.LineComment}
+			for _, item := range value.Content {
+				parts = append(parts, item.HeadComment, item.LineComment)
+			}
+			lines := []string{}
+			for _, part := range parts {
+				for _, line := range strings.Split(part, "\n") {
+					line = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(line), "#"))
+					if line != "" {
+						lines = append(lines, line)
+					}
+				}
+			}
+			if len(lines) > 0 {
+				comments[key.Value] = strings.Join(lines, "\n")
+			}
+		}
+	}
+	return comments
+}
+
+// ExportSecretsTemplate writes .env.example for the synced workflow: every
+// env var from secrets.yaml with an empty value, preceded by the comments
+// found in secrets.yaml. Values in .env are never read.
+func ExportSecretsTemplate(workflowID, workflowName, target string) (*SecretsCommandResult, error) {
+	logs := []string{}
+	appendLog := func(msg string) { logs = append(logs, msg) }
+
+	_, secretsYamlPath, dotEnvPath, preflightLogs, err := preflightWorkflowSecrets(workflowID, workflowName, target)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range preflightLogs {
+		appendLog(l)
+	}
+
+	manifest, err := loadSecretsManifest(secretsYamlPath)
+	if err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	comments := secretsManifestComments(secretsYamlPath)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Secrets for %s, generated from secrets.yaml.\n", workflowName)
+	fmt.Fprintf(&b, "# Copy to %s (one file per target) and fill in the values; never commit it.\n\n", filepath.Base(dotEnvPath))
+	b.WriteString("# Private key that signs simulations and deploys (a funded key for deploys).\n")
+	b.WriteString("CRE_ETH_PRIVATE_KEY=\n")
+	written := 0
+	for _, entry := range listLocalSecretEntries(manifest, dotEnvPath) {
+		envVar := entry.EnvVar
+		if envVar == "" {
+			appendLog(fmt.Sprintf("Skipped %s: no env var mapping in secrets.yaml.", entry.ID))
+			continue
+		}
+		b.WriteString("\n")
+		if comment := comments[entry.ID]; comment != "" {
+			for _, line := range strings.Split(comment, "\n") {
+				b.WriteString("# " + line + "\n")
+			}
+		}
+		if envVar != entry.ID {
+			b.WriteString("# secret " + entry.ID + "\n")
+		}
+		b.WriteString(envVar + "=\n")
+		written++
+	}
+
+	path := filepath.Join(filepath.Dir(dotEnvPath), secretsTemplateFileName)
+	if err := writeProjectFile(path, []byte(b.String()), 0o644); err != nil {
+		return &SecretsCommandResult{Logs: logs}, err
+	}
+	appendLog(fmt.Sprintf("Wrote %s with %d secret(s) and no values.", secretsTemplateFileName, written))
+	appendLog("template path: " + path)
+	return &SecretsCommandResult{Logs: logs}, nil
+}
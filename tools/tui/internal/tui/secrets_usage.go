@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// getSecretPattern matches the calls the compiler emits,
+// runtime.getSecret({ id: "API_KEY" }), and hand-written variants of them.
+var getSecretPattern = regexp.MustCompile("getSecret\\(\\s*\\{[^}]*?\\bid\\s*:\\s*[\"'`]([A-Za-z_][A-Za-z0-9_]*)[\"'`]")
+
+var workflowSourceExts = map[string]bool{".ts": true, ".mts": true, ".js": true, ".mjs": true}
+
+// SecretUse is one place the workflow reads a secret.
+type SecretUse struct {
+	ID string
+	// File is relative to the workflow directory; Line is 0 for config files.
+	File string
+	Line int
+}
+
+// scanSecretUses lists the secrets read by the workflow source under
+// workflowDir, skipping node_modules, and named by "...secret..." keys in its
+// config*.json files.
+func scanSecretUses(workflowDir string) ([]SecretUse, error) {
+	uses := []SecretUse{}
+	err := filepath.WalkDir(workflowDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != workflowDir && (d.Name() == "node_modules" || strings.HasPrefix(d.Name(), ".")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !workflowSourceExts[filepath.Ext(path)] {
+			return nil
+		}
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(workflowDir, path)
+		for idx, line := range strings.Split(string(raw), "\n") {
+			for _, match := range getSecretPattern.FindAllStringSubmatch(line, -1) {
+				uses = append(uses, SecretUse{ID: match[1], File: filepath.ToSlash(rel), Line: idx + 1})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	configs, err := filepath.Glob(filepath.Join(workflowDir, "config*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range configs {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var doc any
+		if json.Unmarshal(raw, &doc) != nil {
+			continue
+		}
+		for _, id := range configSecretNames(doc) {
+			uses = append(uses, SecretUse{ID: id, File: filepath.Base(path)})
+		}
+	}
+	return uses, nil
+}
+
+// configSecretNames returns the string values of keys such as "apiKeySecret"
+// or "secretName" anywhere in a config document.
+func configSecretNames(doc any) []string {
+	names := []string{}
+	switch value := doc.(type) {
+	case map[string]any:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if name, ok := value[key].(string); ok && strings.Contains(strings.ToLower(key), "secret") && secretIDPattern.MatchString(name) {
+				names = append(names, name)
+				continue
+			}
+			names = append(names, configSecretNames(value[key])...)
+		}
+	case []any:
+		for _, item := range value {
+			names = append(names, configSecretNames(item)...)
+		}
+	}
+	return names
+}
@@ -0,0 +1,167 @@
+package tui
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	authEncryptEnv        = "SIXFLOW_AUTH_ENCRYPT"
+	authPassphraseEnv     = "SIXFLOW_AUTH_PASSPHRASE"
+	sessionEncMachine     = "machine"
+	sessionEncPassphrase  = "passphrase"
+	sessionScryptN        = 1 << 15
+	sessionScryptR        = 8
+	sessionScryptP        = 1
+	sessionMinPassphrase  = 8
+	sessionEncryptionInfo = "6flow-tui session v1"
+)
+
+var ErrSessionLocked = errors.New("saved session is passphrase-encrypted; set " + authPassphraseEnv)
+
+type encryptedToken struct {
+	Salt       string `json:"salt"`
+	Nonce      string `json:"nonce"`
+	CipherText string `json:"ciphertext"`
+}
+
+var macPlatformUUIDPattern = regexp.MustCompile(`"IOPlatformUUID" = "([^"]+)"`)
+
+// machineSecret identifies this machine and user. It lives outside the home
+// directory, so a copied backup of ~/.6flow alone cannot decrypt the session.
+func machineSecret() (string, error) {
+	var id string
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := exec.Command("ioreg", "-rd1", "-c", "IOPlatformExpertDevice").Output()
+		if err == nil {
+			if match := macPlatformUUIDPattern.FindSubmatch(out); match != nil {
+				id = string(match[1])
+			}
+		}
+	case "windows":
+		out, err := exec.Command("reg", "query", `HKLM\SOFTWARE\Microsoft\Cryptography`, "/v", "MachineGuid").Output()
+		if err == nil {
+			fields := strings.Fields(string(out))
+			if len(fields) > 0 {
+				id = fields[len(fields)-1]
+			}
+		}
+	default:
+		for _, path := range []string{"/etc/machine-id", "/var/lib/dbus/machine-id"} {
+			if raw, err := os.ReadFile(path); err == nil && strings.TrimSpace(string(raw)) != "" {
+				id = strings.TrimSpace(string(raw))
+				break
+			}
+		}
+	}
+	if id == "" {
+		return "", errors.New("could not determine a machine identifier for session encryption")
+	}
+	home, _ := os.UserHomeDir()
+	return fmt.Sprintf("%s|%s|%d", id, home, os.Getuid()), nil
+}
+
+// sessionEncryptionMode returns the requested mode from SIXFLOW_AUTH_ENCRYPT,
+// or "" when the session file should stay unencrypted.
+func sessionEncryptionMode() (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv(authEncryptEnv)))
+	switch mode {
+	case "", "off", "none", "false", "0":
+		return "", nil
+	case sessionEncMachine, sessionEncPassphrase:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("%s must be machine or passphrase, got %q", authEncryptEnv, mode)
+	}
+}
+
+func sessionEncryptionSecret(mode string) (string, error) {
+	if mode == sessionEncMachine {
+		return machineSecret()
+	}
+	passphrase := os.Getenv(authPassphraseEnv)
+	if passphrase == "" {
+		return "", ErrSessionLocked
+	}
+	if len(passphrase) < sessionMinPassphrase {
+		return "", fmt.Errorf("%s must be at least %d characters", authPassphraseEnv, sessionMinPassphrase)
+	}
+	return passphrase, nil
+}
+
+func sessionAEAD(secret string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(secret), salt, sessionScryptN, sessionScryptR, sessionScryptP, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func encryptSessionToken(mode, token string) (*encryptedToken, error) {
+	secret, err := sessionEncryptionSecret(mode)
+	if err != nil {
+		return nil, err
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	aead, err := sessionAEAD(secret, salt)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := aead.Seal(nil, nonce, []byte(token), []byte(sessionEncryptionInfo))
+	return &encryptedToken{
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Nonce:      base64.StdEncoding.EncodeToString(nonce),
+		CipherText: base64.StdEncoding.EncodeToString(sealed),
+	}, nil
+}
+
+func decryptSessionToken(mode string, enc *encryptedToken) (string, error) {
+	secret, err := sessionEncryptionSecret(mode)
+	if err != nil {
+		return "", err
+	}
+	salt, errSalt := base64.StdEncoding.DecodeString(enc.Salt)
+	nonce, errNonce := base64.StdEncoding.DecodeString(enc.Nonce)
+	sealed, errText := base64.StdEncoding.DecodeString(enc.CipherText)
+	if errSalt != nil || errNonce != nil || errText != nil {
+		return "", errors.New("encrypted session is corrupted")
+	}
+	aead, err := sessionAEAD(secret, salt)
+	if err != nil {
+		return "", err
+	}
+	if len(nonce) != aead.NonceSize() {
+		return "", errors.New("encrypted session is corrupted")
+	}
+	plain, err := aead.Open(nil, nonce, sealed, []byte(sessionEncryptionInfo))
+	if err != nil {
+		if mode == sessionEncPassphrase {
+			return "", errors.New("could not decrypt saved session: wrong passphrase")
+		}
+		return "", errors.New("could not decrypt saved session on this machine")
+	}
+	return string(plain), nil
+}
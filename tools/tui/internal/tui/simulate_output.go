@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"strings"
+	"sync"
+)
+
+// SimulateSection is the part of a `cre workflow simulate` run a line of its
+// output belongs to.
+type SimulateSection int
+
+const (
+	SimulateSetup SimulateSection = iota
+	SimulateTrigger
+	SimulateCapability
+	SimulateLog
+	SimulateResult
+	SimulateError
+)
+
+var simulateSections = map[SimulateSection]struct {
+	title string
+	tag   string
+}{
+	SimulateSetup:      {"Setup", "cre"},
+	SimulateTrigger:    {"Trigger fired", "cre:trigger"},
+	SimulateCapability: {"Capability calls", "cre:capability"},
+	SimulateLog:        {"Workflow logs", "cre:log"},
+	SimulateResult:     {"Result", "cre:result"},
+	SimulateError:      {"Errors", "cre:err"},
+}
+
+// SimulateHeadingPrefix starts the heading line printed when the output moves
+// to another section.
+const SimulateHeadingPrefix = "── "
+
+const (
+	simulateResultMarker = "workflow simulation result"
+	simulateUserLogTag   = "[USER LOG]"
+	simulateEngineTag    = "[SIMULATION]"
+)
+
+// SimulateOutput sorts the lines of a simulation into sections as they
+// stream in. Both output streams may feed it concurrently.
+type SimulateOutput struct {
+	mu       sync.Mutex
+	section  SimulateSection
+	started  bool
+	inResult bool
+	result   []string
+}
+
+func NewSimulateOutput() *SimulateOutput {
+	return &SimulateOutput{}
+}
+
+// Lines returns the console lines for one line of output: a heading when the
+// section changes, then the line tagged with its section. The "Workflow
+// Simulation Result:" line itself only yields the heading.
+func (o *SimulateOutput) Lines(line string, stderr bool) []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	section, text := o.classify(line, stderr)
+	out := []string{}
+	if !o.started || section != o.section {
+		out = append(out, SimulateHeadingPrefix+simulateSections[section].title+" ──")
+		o.started = true
+		o.section = section
+	}
+	if text == "" {
+		return out
+	}
+	if section == SimulateResult {
+		o.result = append(o.result, text)
+	}
+	return append(out, "["+simulateSections[section].tag+"] "+text)
+}
+
+// Result is the text printed after "Workflow Simulation Result", or "" when
+// the run produced none.
+func (o *SimulateOutput) Result() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return strings.Join(o.result, "\n")
+}
+
+func (o *SimulateOutput) classify(line string, stderr bool) (SimulateSection, string) {
+	lower := strings.ToLower(line)
+	if idx := strings.Index(lower, simulateResultMarker); idx >= 0 {
+		o.inResult = true
+		// The value is on the same line or, when empty, on the next ones.
+		value := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line[idx+len(simulateResultMarker):]), ":"))
+		return SimulateResult, value
+	}
+	if _, after, found := strings.Cut(line, simulateUserLogTag); found {
+		o.inResult = false
+		return SimulateLog, strings.TrimSpace(after)
+	}
+	if o.inResult && !strings.Contains(line, simulateEngineTag) {
+		return SimulateResult, line
+	}
+	o.inResult = false
+	switch {
+	case strings.Contains(lower, "capability"):
+		return SimulateCapability, line
+	case strings.Contains(lower, "trigger"):
+		return SimulateTrigger, line
+	case stderr || strings.Contains(lower, "error") || strings.Contains(lower, "failed") || strings.Contains(lower, "panic"):
+		return SimulateError, line
+	}
+	return SimulateSetup, line
+}
@@ -0,0 +1,73 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// payloadDirs are searched for trigger payload fixtures, both in the workflow
+// directory and in the project root.
+var payloadDirs = []string{"payloads", "fixtures"}
+
+// IsHTTPTriggerWorkflow reports whether the workflow listens on an HTTP
+// trigger, whose simulation takes a request payload.
+func IsHTTPTriggerWorkflow(workflowID, workflowName string) bool {
+	mainTsPath := filepath.Join(localWorkflowDir(workflowID, workflowName), "main.ts")
+	raw, err := os.ReadFile(mainTsPath)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(raw), "HTTPCapability")
+}
+
+// ListSimulatePayloads returns the JSON files in the payloads/ and fixtures/
+// directories of the local workflow project, sorted by path.
+func ListSimulatePayloads(workflowID, workflowName string) ([]string, error) {
+	roots := []string{localWorkflowDir(workflowID, workflowName), localWorkflowProjectRoot(workflowID, workflowName)}
+	seen := map[string]bool{}
+	var payloads []string
+	for _, root := range roots {
+		for _, dir := range payloadDirs {
+			matches, err := filepath.Glob(filepath.Join(root, dir, "*.json"))
+			if err != nil {
+				return nil, err
+			}
+			for _, match := range matches {
+				if !seen[match] {
+					seen[match] = true
+					payloads = append(payloads, match)
+				}
+			}
+		}
+	}
+	sort.Strings(payloads)
+	return payloads, nil
+}
+
+// resolvePayloadFile checks that path is a readable JSON file and returns its
+// absolute path, which is what `cre workflow simulate` is given since it
+// runs from the project root.
+func resolvePayloadFile(path string) (string, error) {
+	abs, err := filepath.Abs(expandHome(path))
+	if err != nil {
+		return "", err
+	}
+	raw, err := os.ReadFile(abs)
+	if err != nil {
+		return "", fmt.Errorf("read payload file: %w", err)
+	}
+	if !json.Valid(raw) {
+		return "", fmt.Errorf("payload file %s is not valid JSON", filepath.Base(abs))
+	}
+	return abs, nil
+}
+
+// SimulatePayloadArgs are the `cre workflow simulate` flags that run the
+// first trigger non-interactively with the given HTTP payload file.
+func SimulatePayloadArgs(payloadPath string) []string {
+	return []string{"--non-interactive", "--trigger-index", "0", "--http-payload", payloadPath}
+}
@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"fmt"
+	"sync"
+)
+
+// syncAllConcurrency bounds the bundle downloads Sync all runs at once.
+const syncAllConcurrency = 4
+
+// SyncAllOutcome is the result of syncing one workflow in SyncAllWorkflows.
+type SyncAllOutcome struct {
+	ID        string
+	Name      string
+	OutputDir string
+	Logs      []string
+	Err       error
+}
+
+type SyncAllResult struct {
+	Logs     []string
+	Outcomes []SyncAllOutcome
+}
+
+// Failed counts the workflows that did not sync.
+func (r *SyncAllResult) Failed() int {
+	failed := 0
+	for _, outcome := range r.Outcomes {
+		if outcome.Err != nil {
+			failed++
+		}
+	}
+	return failed
+}
+
+// SyncAllWorkflows syncs every ready workflow of the frontend list into its
+// local project, a few at a time, e.g. to set up a new machine. One failure
+// does not stop the others; the outcomes keep the list order.
+func SyncAllWorkflows(baseURL, token string) (*SyncAllResult, error) {
+	workflows, err := FetchFrontendWorkflows(baseURL, token, WorkflowFilter{Status: "ready"})
+	if err != nil {
+		return nil, err
+	}
+	ready := []FrontendWorkflow{}
+	for _, workflow := range workflows {
+		if workflow.Status == "ready" {
+			ready = append(ready, workflow)
+		}
+	}
+
+	outcomes := make([]SyncAllOutcome, len(ready))
+	slots := make(chan struct{}, syncAllConcurrency)
+	var wg sync.WaitGroup
+	for i, workflow := range ready {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			outcome := SyncAllOutcome{ID: workflow.ID, Name: workflow.Name}
+			result, err := SyncWorkflowToLocal(baseURL, token, workflow.ID, workflow.Name)
+			if result != nil {
+				outcome.OutputDir = result.OutputDir
+				outcome.Logs = result.Logs
+			}
+			outcome.Err = err
+			outcomes[i] = outcome
+		}()
+	}
+	wg.Wait()
+
+	result := &SyncAllResult{Outcomes: outcomes}
+	if len(ready) == 0 {
+		result.Logs = append(result.Logs, "No ready workflow to sync; compile workflows in the frontend first.")
+		return result, nil
+	}
+	for _, outcome := range outcomes {
+		if outcome.Err != nil {
+			result.Logs = append(result.Logs, fmt.Sprintf("✗ %s: %v", outcome.Name, outcome.Err))
+			continue
+		}
+		result.Logs = append(result.Logs, fmt.Sprintf("✓ %s -> %s", outcome.Name, outcome.OutputDir))
+	}
+	result.Logs = append(result.Logs, fmt.Sprintf("Synced %d of %d ready workflow(s); %d failed.",
+		len(ready)-result.Failed(), len(ready), result.Failed()))
+	return result, nil
+}
@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A re-sync moves the project it replaces into
+// ~/.6flow/workflows/.backups/<folder>-<timestamp> instead of deleting it.
+// Local files the merge carried over live on in the new project and are not
+// in the backup. The newest backups of each workflow are kept:
+// SIXFLOW_SYNC_BACKUPS sets how many, and "0" turns backups off.
+const (
+	syncBackupsDirName  = ".backups"
+	syncBackupsKeepEnv  = "SIXFLOW_SYNC_BACKUPS"
+	defaultBackupsKept  = 5
+	syncBackupTimestamp = "20060102T150405Z"
+)
+
+func syncBackupsRootDir() string {
+	return filepath.Join(workflowsRootDir(), syncBackupsDirName)
+}
+
+func syncBackupsKept() int {
+	raw := strings.TrimSpace(os.Getenv(syncBackupsKeepEnv))
+	if raw == "" {
+		return defaultBackupsKept
+	}
+	if parsed, err := strconv.Atoi(raw); err == nil && parsed >= 0 {
+		return parsed
+	}
+	return defaultBackupsKept
+}
+
+// backupReplacedProject moves projectDir, the project a sync replaced, into
+// the backups directory under folderName. It returns the backup path, empty
+// when backups are off and projectDir was removed instead.
+func backupReplacedProject(projectDir, folderName string) (string, error) {
+	keep := syncBackupsKept()
+	if keep == 0 {
+		return "", os.RemoveAll(projectDir)
+	}
+	root := syncBackupsRootDir()
+	// Backups hold the replaced .env files.
+	if err := os.MkdirAll(root, 0o700); err != nil {
+		return "", err
+	}
+	base := folderName + "-" + time.Now().UTC().Format(syncBackupTimestamp)
+	backupDir := filepath.Join(root, base)
+	for n := 2; ; n++ {
+		exists, err := fileExists(backupDir)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			break
+		}
+		backupDir = filepath.Join(root, fmt.Sprintf("%s-%d", base, n))
+	}
+	if err := os.Rename(projectDir, backupDir); err != nil {
+		return "", err
+	}
+	return backupDir, nil
+}
+
+// pruneProjectBackups removes all but the newest backups of the project in
+// finalDir.
+func pruneProjectBackups(finalDir string) error {
+	keep := syncBackupsKept()
+	if keep == 0 {
+		return nil
+	}
+	root := syncBackupsRootDir()
+	folderName := filepath.Base(finalDir)
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return err
+	}
+	backups := []string{}
+	for _, entry := range entries {
+		rest, ok := strings.CutPrefix(entry.Name(), folderName+"-")
+		if !ok || !entry.IsDir() || len(rest) < len(syncBackupTimestamp) {
+			continue
+		}
+		// The prefix of another workflow's folder can match too; only a
+		// timestamp right after it makes the backup this project's.
+		if _, err := time.Parse(syncBackupTimestamp, rest[:len(syncBackupTimestamp)]); err != nil {
+			continue
+		}
+		backups = append(backups, entry.Name())
+	}
+	if len(backups) <= keep {
+		return nil
+	}
+	sort.Slice(backups, func(i, j int) bool { return backupOlder(backups[i], backups[j]) })
+	for _, name := range backups[:len(backups)-keep] {
+		if err := os.RemoveAll(filepath.Join(root, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backupOlder orders backup names by timestamp and then by their -N suffix,
+// which plain string order gets wrong from -10 on.
+func backupOlder(a, b string) bool {
+	stampA, nA := splitBackupSuffix(a)
+	stampB, nB := splitBackupSuffix(b)
+	if stampA != stampB {
+		return stampA < stampB
+	}
+	return nA < nB
+}
+
+func splitBackupSuffix(name string) (string, int) {
+	idx := strings.LastIndex(name, "Z-")
+	if idx < 0 {
+		return name, 1
+	}
+	n, err := strconv.Atoi(name[idx+2:])
+	if err != nil {
+		return name, 1
+	}
+	return name[:idx+1], n
+}
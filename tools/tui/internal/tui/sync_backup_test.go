@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// useTempHome points the ~/.6flow directories at a fresh temporary home.
+func useTempHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	return home
+}
+
+func TestBackupOlder(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"wf-20260101T000000Z", "wf-20260102T000000Z", true},
+		{"wf-20260102T000000Z", "wf-20260101T000000Z", false},
+		{"wf-20260101T000000Z", "wf-20260101T000000Z-2", true},
+		{"wf-20260101T000000Z-2", "wf-20260101T000000Z", false},
+		{"wf-20260101T000000Z-9", "wf-20260101T000000Z-10", true},
+		{"wf-20260101T000000Z-10", "wf-20260101T000000Z-9", false},
+		{"wf-20260101T000000Z-10", "wf-20260102T000000Z", true},
+		{"wf-20260101T000000Z", "wf-20260101T000000Z", false},
+	}
+	for _, tt := range tests {
+		if got := backupOlder(tt.a, tt.b); got != tt.want {
+			t.Errorf("backupOlder(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPruneProjectBackups(t *testing.T) {
+	existing := []string{
+		"wf-20260101T000000Z",
+		"wf-20260101T000000Z-2",
+		"wf-20260101T000000Z-10",
+		"wf-20260103T000000Z",
+		"wf-20260102T000000Z",
+		// Other workflows, including one whose folder starts with "wf-".
+		"wf-other-20260101T000000Z",
+		"other-20260101T000000Z",
+	}
+	tests := []struct {
+		name string
+		keep string
+		want []string
+	}{
+		{
+			name: "keeps the newest",
+			keep: "2",
+			want: []string{"other-20260101T000000Z", "wf-20260102T000000Z", "wf-20260103T000000Z", "wf-other-20260101T000000Z"},
+		},
+		{
+			name: "orders -N suffixes numerically",
+			keep: "4",
+			want: []string{
+				"other-20260101T000000Z", "wf-20260101T000000Z-10", "wf-20260101T000000Z-2",
+				"wf-20260102T000000Z", "wf-20260103T000000Z", "wf-other-20260101T000000Z",
+			},
+		},
+		{
+			name: "under the limit",
+			keep: "10",
+			want: append([]string{}, existing...),
+		},
+		{
+			name: "backups off leaves old ones alone",
+			keep: "0",
+			want: append([]string{}, existing...),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempHome(t)
+			t.Setenv(syncBackupsKeepEnv, tt.keep)
+			root := syncBackupsRootDir()
+			for _, name := range existing {
+				if err := os.MkdirAll(filepath.Join(root, name), 0o700); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := pruneProjectBackups(filepath.Join(workflowsRootDir(), "wf")); err != nil {
+				t.Fatalf("pruneProjectBackups() error = %v", err)
+			}
+			entries, err := os.ReadDir(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, entry := range entries {
+				got = append(got, entry.Name())
+			}
+			want := append([]string{}, tt.want...)
+			sort.Strings(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("remaining backups = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestSyncBackupsKept(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want int
+	}{
+		{"", defaultBackupsKept},
+		{"0", 0},
+		{" 3 ", 3},
+		{"-1", defaultBackupsKept},
+		{"many", defaultBackupsKept},
+	}
+	for _, tt := range tests {
+		t.Setenv(syncBackupsKeepEnv, tt.raw)
+		if got := syncBackupsKept(); got != tt.want {
+			t.Errorf("syncBackupsKept() with %q = %d, want %d", tt.raw, got, tt.want)
+		}
+	}
+}
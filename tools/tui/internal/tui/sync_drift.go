@@ -0,0 +1,65 @@
+package tui
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+)
+
+// LocalDrift lists the files of a synced project that were changed by hand
+// since the last sync, told apart by the hashes in its bundle stamp. Files
+// the bundle never had, e.g. notes or node_modules, are not drift.
+type LocalDrift struct {
+	Modified []string
+	Deleted  []string
+	// Untracked is set when the last sync recorded no hashes, so edits
+	// cannot be detected; syncing again starts tracking them.
+	Untracked bool
+}
+
+// Count is the number of files changed since the last sync.
+func (d *LocalDrift) Count() int {
+	return len(d.Modified) + len(d.Deleted)
+}
+
+// detectLocalDrift compares the files of projectRoot with the hashes the last
+// sync recorded.
+func detectLocalDrift(projectRoot string) (*LocalDrift, error) {
+	drift := &LocalDrift{Modified: []string{}, Deleted: []string{}}
+	stamp := readBundleStamp(projectRoot)
+	if stamp == nil || len(stamp.Files) == 0 {
+		drift.Untracked = true
+		return drift, nil
+	}
+	for rel, want := range stamp.Files {
+		got, err := fileSHA256(filepath.Join(projectRoot, filepath.FromSlash(rel)))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			drift.Deleted = append(drift.Deleted, rel)
+		case err != nil:
+			return nil, err
+		case got != want:
+			drift.Modified = append(drift.Modified, rel)
+		}
+	}
+	sort.Strings(drift.Modified)
+	sort.Strings(drift.Deleted)
+	return drift, nil
+}
+
+// DetectAllLocalDrift reports the hand edits of every synced project, by
+// workflow ID. Projects that cannot be read are left out.
+func DetectAllLocalDrift() (map[string]*LocalDrift, error) {
+	workflows, err := ListLocalWorkflows()
+	if err != nil {
+		return nil, err
+	}
+	drifts := map[string]*LocalDrift{}
+	for _, workflow := range workflows {
+		if drift, err := detectLocalDrift(workflow.ProjectRoot); err == nil {
+			drifts[workflow.ID] = drift
+		}
+	}
+	return drifts, nil
+}
@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func sha256Hex(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestDetectLocalDrift(t *testing.T) {
+	synced := map[string]string{
+		"project.yaml":     "name: demo\n",
+		"wf/workflow.yaml": "workflow\n",
+		"wf/main.ts":       "export {}\n",
+	}
+	tests := []struct {
+		name string
+		// stamp writes a bundle stamp; hashes records the synced files in it.
+		stamp  bool
+		hashes bool
+		edit   map[string]string
+		remove []string
+		want   *LocalDrift
+	}{
+		{
+			name: "no stamp",
+			want: &LocalDrift{Modified: []string{}, Deleted: []string{}, Untracked: true},
+		},
+		{
+			name:  "stamp without hashes",
+			stamp: true,
+			want:  &LocalDrift{Modified: []string{}, Deleted: []string{}, Untracked: true},
+		},
+		{
+			name:   "unchanged",
+			stamp:  true,
+			hashes: true,
+			want:   &LocalDrift{Modified: []string{}, Deleted: []string{}},
+		},
+		{
+			name:   "untracked files are not drift",
+			stamp:  true,
+			hashes: true,
+			edit:   map[string]string{"notes.md": "todo\n", "wf/node_modules/x.js": "x"},
+			want:   &LocalDrift{Modified: []string{}, Deleted: []string{}},
+		},
+		{
+			name:   "modified and deleted",
+			stamp:  true,
+			hashes: true,
+			edit:   map[string]string{"wf/main.ts": "console.log(1)\n", "project.yaml": "name: renamed\n"},
+			remove: []string{"wf/workflow.yaml"},
+			want: &LocalDrift{
+				Modified: []string{"project.yaml", "wf/main.ts"},
+				Deleted:  []string{"wf/workflow.yaml"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			write := func(rel, content string) {
+				path := filepath.Join(root, filepath.FromSlash(rel))
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for rel, content := range synced {
+				write(rel, content)
+			}
+			if tt.stamp {
+				stamp := BundleStamp{SHA256: "bundle"}
+				if tt.hashes {
+					stamp.Files = map[string]string{}
+					for rel, content := range synced {
+						stamp.Files[rel] = sha256Hex(content)
+					}
+				}
+				if err := writeBundleStamp(root, stamp); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for rel, content := range tt.edit {
+				write(rel, content)
+			}
+			for _, rel := range tt.remove {
+				if err := os.Remove(filepath.Join(root, filepath.FromSlash(rel))); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := detectLocalDrift(root)
+			if err != nil {
+				t.Fatalf("detectLocalDrift() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("detectLocalDrift() = %+v, want %+v", got, tt.want)
+			}
+			if got.Count() != len(tt.want.Modified)+len(tt.want.Deleted) {
+				t.Fatalf("Count() = %d", got.Count())
+			}
+		})
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -97,13 +98,16 @@ func safeJoin(base, name string) (string, error) {
 	return cleanCandidate, nil
 }
 
-func unzipToDir(zipBytes []byte, dest string) error {
+// unzipToDir extracts zipBytes under dest, writing at most maxBytes in total
+// (no limit when 0) whatever sizes the entry headers claim.
+func unzipToDir(zipBytes []byte, dest string, maxBytes uint64) error {
 	readerAt := bytes.NewReader(zipBytes)
 	zr, err := zip.NewReader(readerAt, int64(len(zipBytes)))
 	if err != nil {
 		return err
 	}
 
+	var written uint64
 	for _, f := range zr.File {
 		target, err := safeJoin(dest, f.Name)
 		if err != nil {
@@ -126,11 +130,19 @@ func unzipToDir(zipBytes []byte, dest string) error {
 			return err
 		}
 
-		content, err := io.ReadAll(rc)
+		var src io.Reader = rc
+		if maxBytes > 0 {
+			src = io.LimitReader(rc, int64(maxBytes-written)+1)
+		}
+		content, err := io.ReadAll(src)
 		_ = rc.Close()
 		if err != nil {
 			return err
 		}
+		written += uint64(len(content))
+		if maxBytes > 0 && written > maxBytes {
+			return &BundleValidationError{Violations: []string{fmt.Sprintf("bundle expands to more than %d bytes", maxBytes)}}
+		}
 
 		if err := os.WriteFile(target, content, 0o644); err != nil {
 			return err
@@ -463,53 +475,237 @@ func sanitizeDotEnvPreviewValues(dotEnvPath string) (int, error) {
 	return updatedCount, nil
 }
 
+// projectSyncLockPath is locked while sync replaces projectRoot and while a
+// secrets write rewrites one of its .env files, so neither loses the other's
+// changes. It lives next to the project, which sync removes and recreates.
+func projectSyncLockPath(projectRoot string) string {
+	return filepath.Join(filepath.Dir(projectRoot), "."+filepath.Base(projectRoot))
+}
+
 func SyncWorkflowToLocal(baseURL, token, workflowID, workflowName string) (*SyncLocalResult, error) {
+	return SyncWorkflowVersionToLocal(baseURL, token, workflowID, workflowName, "")
+}
+
+// SyncWorkflowVersionToLocal syncs a specific compiled artifact from
+// FetchBundleVersions, e.g. to reproduce a bug against an older build. An
+// empty version syncs the current one.
+func SyncWorkflowVersionToLocal(baseURL, token, workflowID, workflowName, version string) (*SyncLocalResult, error) {
 	logs := []string{}
+	bundle, err := downloadSyncBundle(baseURL, token, workflowID, version, func(msg string) { logs = append(logs, msg) })
+	if err != nil {
+		return &SyncLocalResult{Logs: logs}, err
+	}
+	return syncBundleToLocal(bundle, workflowID, workflowName, version, logs)
+}
+
+// downloadSyncBundle downloads a bundle for sync and verifies its checksum.
+func downloadSyncBundle(baseURL, token, workflowID, version string, appendLog func(string)) (*WorkflowBundle, error) {
+	bundle, err := DownloadWorkflowBundleVersion(baseURL, token, workflowID, version)
+	if err != nil {
+		return nil, err
+	}
+	if version == "" {
+		appendLog("Downloaded compiled workflow bundle.")
+	} else {
+		appendLog(fmt.Sprintf("Downloaded compiled workflow bundle version %s (compiler %s).", version, bundle.CompilerVersion))
+	}
+	if bundle.SHA256 == "" {
+		appendLog("Frontend did not provide a bundle checksum; skipping verification.")
+	} else {
+		if err := bundle.VerifyChecksum(); err != nil {
+			return nil, err
+		}
+		appendLog("Verified bundle SHA-256 checksum.")
+	}
+	return bundle, nil
+}
+
+// renameSyncedProject moves the staged project into place; tests replace it
+// to make the final step fail.
+var renameSyncedProject = os.Rename
+
+// syncBundleToLocal writes a downloaded bundle into the local project,
+// merging it over the existing one. logs holds what came before, e.g. the
+// download.
+func syncBundleToLocal(bundle *WorkflowBundle, workflowID, workflowName, version string, logs []string) (*SyncLocalResult, error) {
 	appendLog := func(msg string) {
 		logs = append(logs, msg)
 	}
 
-	bundle, err := DownloadWorkflowBundle(baseURL, token, workflowID)
+	finalDir := localWorkflowProjectRoot(workflowID, workflowName)
+	if DryRun() {
+		// Dry run only covers simulate and secrets. Staging skips its writes
+		// in dry run, so the staged project would be missing its key and
+		// settings; nothing is staged or replaced.
+		if err := validateBundleArchive(bundle.Content, defaultBundlePolicy); err != nil {
+			return &SyncLocalResult{Logs: logs}, err
+		}
+		dryRunNote("would sync %s into %s", bundle.FileName, finalDir)
+		appendLog("Dry run: the local project at " + finalDir + " was left unchanged.")
+		return &SyncLocalResult{Logs: logs}, nil
+	}
+	release, tmpDir, err := beginSyncStaging(finalDir, workflowName)
 	if err != nil {
-		return nil, err
+		return &SyncLocalResult{Logs: logs}, err
+	}
+	defer release()
+	// Once the merge moved local files into the staged project, tmpDir may
+	// hold the only copy of them; a failed replace keeps it.
+	keepStaging := false
+	defer func() {
+		if !keepStaging {
+			os.RemoveAll(tmpDir)
+		}
+	}()
+
+	stagedDir, err := stageSyncBundle(bundle, tmpDir, finalDir, workflowID, workflowName, version, appendLog)
+	if err != nil {
+		return &SyncLocalResult{Logs: logs}, err
+	}
+	previousStamp := readBundleStamp(finalDir)
+	var merged *syncMergeResult
+	if exists, _ := fileExists(finalDir); exists {
+		merged, err = mergeExistingProject(finalDir, stagedDir, previousStamp)
+		if err != nil {
+			return &SyncLocalResult{Logs: logs}, fmt.Errorf("merge existing project: %w", err)
+		}
+		if len(merged.EditedConfigs) > 0 {
+			appendLog("Kept local edits to " + strings.Join(merged.EditedConfigs, ", ") + "; the bundle's versions were not applied.")
+		}
+		if len(merged.LocalFiles) > 0 {
+			appendLog("Kept local files the bundle does not have: " + strings.Join(merged.LocalFiles, ", ") + ".")
+		}
+		if len(merged.Dropped) > 0 {
+			appendLog("Removed files the new bundle no longer has: " + strings.Join(merged.Dropped, ", ") + ".")
+		}
 	}
-	appendLog("Downloaded compiled workflow bundle.")
 
-	root := workflowsRootDir()
-	if err := os.MkdirAll(root, 0o755); err != nil {
-		return nil, err
+	// The replaced project is moved aside within tmpDir first, so a failed
+	// rename can put it back before anything is backed up or deleted.
+	replacedDir := ""
+	if merged != nil {
+		replacedDir = filepath.Join(tmpDir, "replaced")
+		if err := os.Rename(finalDir, replacedDir); err != nil {
+			if undoErr := merged.undo(); undoErr != nil {
+				keepStaging = true
+				return &SyncLocalResult{Logs: logs}, fmt.Errorf("move the replaced project aside: %w; local files are kept in %s", err, stagedDir)
+			}
+			return &SyncLocalResult{Logs: logs}, fmt.Errorf("move the replaced project aside: %w", err)
+		}
+	}
+	if err := renameSyncedProject(stagedDir, finalDir); err != nil {
+		if replacedDir == "" {
+			return &SyncLocalResult{Logs: logs}, err
+		}
+		restoreErr := os.Rename(replacedDir, finalDir)
+		if restoreErr == nil {
+			restoreErr = merged.undo()
+		}
+		if restoreErr != nil {
+			keepStaging = true
+			return &SyncLocalResult{Logs: logs}, fmt.Errorf("%w; restoring the previous project failed (%v), it is kept in %s", err, restoreErr, tmpDir)
+		}
+		appendLog("Restored the previous project.")
+		return &SyncLocalResult{Logs: logs}, err
+	}
+	if replacedDir != "" {
+		backupDir, err := backupReplacedProject(replacedDir, filepath.Base(finalDir))
+		switch {
+		case err != nil:
+			keepStaging = true
+			appendLog("Could not back up the replaced project (" + err.Error() + "); it is kept in " + replacedDir + ".")
+		case backupDir != "":
+			appendLog("Backed up the replaced project to " + backupDir + ".")
+		}
+	}
+	if err := pruneProjectBackups(finalDir); err != nil && !os.IsNotExist(err) {
+		appendLog("Could not prune old project backups: " + err.Error())
+	}
+
+	workflowDirName := slugify(workflowName)
+	entries, _ := os.ReadDir(finalDir)
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
 	}
+	sort.Strings(names)
+	appendLog("Local project written to: " + finalDir)
+	appendLog("Top-level files: " + strings.Join(names, ", "))
+	for _, warning := range secretFileGitWarnings(finalDir) {
+		appendLog(warning)
+	}
+	appendLog("To simulate:")
+	appendLog("cd " + finalDir)
+	appendLog("cre workflow simulate ./" + workflowDirName + " --target=staging-settings")
+
+	return &SyncLocalResult{OutputDir: finalDir, Logs: logs}, nil
+}
 
-	folderName := fmt.Sprintf("%s--%s", slugify(workflowName), workflowID)
-	finalDir := filepath.Join(root, folderName)
-	tmpDir, err := os.MkdirTemp(root, ".sync-*")
+// beginSyncStaging locks finalDir against other syncs and secrets writes and
+// creates the temporary directory a bundle is staged in, next to it.
+func beginSyncStaging(finalDir, workflowName string) (func(), string, error) {
+	if err := os.MkdirAll(filepath.Dir(finalDir), 0o755); err != nil {
+		return nil, "", err
+	}
+	release, err := acquireFileLock(projectSyncLockPath(finalDir))
 	if err != nil {
-		return nil, err
+		return nil, "", fmt.Errorf("another sync of %s is in progress: %w", workflowName, err)
 	}
-	defer os.RemoveAll(tmpDir)
+	tmpDir, err := os.MkdirTemp(filepath.Dir(finalDir), ".sync-*")
+	if err != nil {
+		release()
+		return nil, "", err
+	}
+	return release, tmpDir, nil
+}
 
+// stageSyncBundle extracts bundle under tmpDir and reshapes it into the
+// project sync writes, carrying over the local state of finalDir. It returns
+// the staged project directory; finalDir itself is only read.
+func stageSyncBundle(bundle *WorkflowBundle, tmpDir, finalDir, workflowID, workflowName, version string, appendLog func(string)) (string, error) {
 	zipPath := filepath.Join(tmpDir, bundle.FileName)
 	if err := os.WriteFile(zipPath, bundle.Content, 0o644); err != nil {
-		return nil, err
+		return "", err
 	}
 	appendLog("Saved bundle zip to temporary path.")
 
+	logRejection := func(err error) {
+		var rejection *BundleValidationError
+		if errors.As(err, &rejection) {
+			appendLog("Bundle failed content validation; local project left untouched:")
+			for _, violation := range rejection.Violations {
+				appendLog("- " + violation)
+			}
+		}
+	}
+	if err := validateBundleArchive(bundle.Content, defaultBundlePolicy); err != nil {
+		logRejection(err)
+		return "", err
+	}
+
 	extractedDir := filepath.Join(tmpDir, "extracted")
 	if err := os.MkdirAll(extractedDir, 0o755); err != nil {
-		return nil, err
+		return "", err
 	}
-	if err := unzipToDir(bundle.Content, extractedDir); err != nil {
-		return nil, err
+	if err := unzipToDir(bundle.Content, extractedDir, defaultBundlePolicy.MaxUncompressedBytes); err != nil {
+		logRejection(err)
+		return "", err
 	}
 	appendLog("Extracted bundle zip.")
 
+	if err := validateBundleTree(extractedDir, defaultBundlePolicy); err != nil {
+		logRejection(err)
+		return "", err
+	}
+	appendLog("Bundle passed content validation.")
+
 	projectYamlSrc, err := findFirstFile(extractedDir, "project.yaml")
 	if err != nil {
-		return nil, errors.New("bundle is missing project.yaml")
+		return "", errors.New("bundle is missing project.yaml")
 	}
 	workflowYamlSrc, err := findFirstFile(extractedDir, "workflow.yaml")
 	if err != nil {
-		return nil, errors.New("bundle is missing workflow.yaml")
+		return "", errors.New("bundle is missing workflow.yaml")
 	}
 
 	workflowSrcDir := filepath.Dir(workflowYamlSrc)
@@ -517,37 +713,47 @@ func SyncWorkflowToLocal(baseURL, token, workflowID, workflowName string) (*Sync
 	workflowDirName := slugify(workflowName)
 	workflowDir := filepath.Join(stagedDir, workflowDirName)
 	if err := os.MkdirAll(workflowDir, 0o755); err != nil {
-		return nil, err
+		return "", err
 	}
 
 	skip := map[string]bool{"project.yaml": true, "secrets.yaml": true}
 	if err := copyDirRecursive(workflowSrcDir, workflowDir, skip); err != nil {
-		return nil, err
+		return "", err
 	}
 
 	projectYamlDst := filepath.Join(stagedDir, "project.yaml")
 	if err := copyFile(projectYamlSrc, projectYamlDst); err != nil {
-		return nil, err
+		return "", err
 	}
 
 	hasSecrets := false
 	if secretsYamlSrc, err := findFirstFile(extractedDir, "secrets.yaml"); err == nil {
 		hasSecrets = true
 		if err := copyFile(secretsYamlSrc, filepath.Join(stagedDir, "secrets.yaml")); err != nil {
-			return nil, err
+			return "", err
 		}
 	}
+	keptSecrets, err := mergeSecretsManifest(filepath.Join(finalDir, "secrets.yaml"), filepath.Join(stagedDir, "secrets.yaml"))
+	if err != nil {
+		return "", fmt.Errorf("merge local secrets.yaml: %w", err)
+	}
+	if exists, _ := fileExists(filepath.Join(stagedDir, "secrets.yaml")); exists {
+		hasSecrets = true
+	}
+	if len(keptSecrets) > 0 {
+		appendLog("Kept local secret declarations in secrets.yaml: " + strings.Join(keptSecrets, ", ") + ".")
+	}
 
 	workflowYamlDst, err := findFirstFile(workflowDir, "workflow.yaml")
 	if err != nil {
-		return nil, errors.New("workflow.yaml was not copied into workflow directory")
+		return "", errors.New("workflow.yaml was not copied into workflow directory")
 	}
 	normalizedWorkflow, err := normalizeWorkflowYaml(workflowYamlDst, workflowDirName, hasSecrets)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	if err := normalizeProjectYaml(projectYamlDst); err != nil {
-		return nil, err
+		return "", err
 	}
 
 	createdStagingConfig, err := ensureConfigFile(
@@ -556,7 +762,7 @@ func SyncWorkflowToLocal(baseURL, token, workflowID, workflowName string) (*Sync
 		"",
 	)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	createdProductionConfig, err := ensureConfigFile(
 		workflowDir,
@@ -564,7 +770,7 @@ func SyncWorkflowToLocal(baseURL, token, workflowID, workflowName string) (*Sync
 		normalizedWorkflow.StagingConfigPath,
 	)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	appendLog("Reshaped workflow into CRE-compatible project structure.")
@@ -579,46 +785,106 @@ func SyncWorkflowToLocal(baseURL, token, workflowID, workflowName string) (*Sync
 	stagedDotEnvPath := filepath.Join(workflowDir, ".env")
 	preservedDotEnv, err := preserveExistingDotEnv(existingDotEnvPath, stagedDotEnvPath)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	preservedKeystore, err := preserveExistingDotEnv(
+		filepath.Join(finalDir, workflowDirName, keystoreFileName),
+		filepath.Join(workflowDir, keystoreFileName),
+	)
+	if err != nil {
+		return "", err
+	}
+	if preservedKeystore {
+		appendLog("Preserved encrypted private key keystore from previous sync.")
+	}
+	preservedEncryptedDotEnv, err := preserveExistingDotEnv(
+		encryptedDotEnvPath(existingDotEnvPath),
+		encryptedDotEnvPath(stagedDotEnvPath),
+	)
+	if err != nil {
+		return "", err
+	}
+	if preservedEncryptedDotEnv {
+		// The bundle's .env only holds placeholders; the encrypted one is
+		// the source of truth.
+		if err := os.Remove(stagedDotEnvPath); err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		appendLog("Preserved encrypted .env.enc from previous sync.")
+	}
+	// Per-target files (.env.staging, .env.production.enc, ...) are never in
+	// the bundle; carry every one of them over.
+	targetDotEnvPaths, err := filepath.Glob(existingDotEnvPath + ".*")
+	if err != nil {
+		return "", err
+	}
+	preservedTargetDotEnvs := []string{}
+	for _, existingPath := range targetDotEnvPaths {
+		name := filepath.Base(existingPath)
+		if name == filepath.Base(encryptedDotEnvPath(existingDotEnvPath)) {
+			continue
+		}
+		preserved, err := preserveExistingDotEnv(existingPath, filepath.Join(workflowDir, name))
+		if err != nil {
+			return "", err
+		}
+		if preserved {
+			preservedTargetDotEnvs = append(preservedTargetDotEnvs, name)
+		}
+	}
+	if len(preservedTargetDotEnvs) > 0 {
+		appendLog("Preserved " + strings.Join(preservedTargetDotEnvs, ", ") + " from previous sync.")
+	}
+	preservedDeployments, err := preserveExistingDotEnv(
+		filepath.Join(finalDir, deploymentsFileName),
+		filepath.Join(stagedDir, deploymentsFileName),
+	)
+	if err != nil {
+		return "", err
+	}
+	if preservedDeployments {
+		appendLog("Preserved local deployment history from previous sync.")
+	}
+	addedGitignore, err := stageSecretsGitignore(finalDir, stagedDir)
+	if err != nil {
+		return "", err
+	}
+	if addedGitignore {
+		appendLog("Added .env and keystore patterns to the project .gitignore.")
+	}
+	syncedFiles, err := hashSyncedFiles(stagedDir)
+	if err != nil {
+		return "", err
+	}
+	if err := writeBundleStamp(stagedDir, BundleStamp{
+		Version:         version,
+		SHA256:          bundleSHA256(bundle),
+		CompilerVersion: bundle.CompilerVersion,
+		SyncedAt:        time.Now().UTC(),
+		Files:           syncedFiles,
+		Profile:         ActiveAuthProfile(),
+		Organization:    ActiveOrganization(),
+	}); err != nil {
+		return "", err
 	}
 	if preservedDotEnv {
 		appendLog("Preserved existing local .env from previous sync.")
-	} else {
+	} else if !preservedKeystore && !preservedEncryptedDotEnv {
 		privateKey, _ := readDotEnvValue(stagedDotEnvPath, "CRE_ETH_PRIVATE_KEY")
 		if !isValidPrivateKey(privateKey) {
 			autoPrivateKey := demoPrivateKeyForProject(workflowID)
 			if err := setDotEnvValue(stagedDotEnvPath, "CRE_ETH_PRIVATE_KEY", autoPrivateKey); err != nil {
-				return nil, err
+				return "", err
 			}
 			appendLog("Initialized CRE_ETH_PRIVATE_KEY in local workflow .env.")
 		}
 	}
 	sanitizedCount, err := sanitizeDotEnvPreviewValues(stagedDotEnvPath)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 	if sanitizedCount > 0 {
 		appendLog(fmt.Sprintf("Cleared preview placeholders in local .env (%d variable(s)).", sanitizedCount))
 	}
-
-	if err := os.RemoveAll(finalDir); err != nil {
-		return nil, err
-	}
-	if err := os.Rename(stagedDir, finalDir); err != nil {
-		return nil, err
-	}
-
-	entries, _ := os.ReadDir(finalDir)
-	names := make([]string, 0, len(entries))
-	for _, e := range entries {
-		names = append(names, e.Name())
-	}
-	sort.Strings(names)
-	appendLog("Local project written to: " + finalDir)
-	appendLog("Top-level files: " + strings.Join(names, ", "))
-	appendLog("To simulate:")
-	appendLog("cd " + finalDir)
-	appendLog("cre workflow simulate ./" + workflowDirName + " --target=staging-settings")
-
-	return &SyncLocalResult{OutputDir: finalDir, Logs: logs}, nil
+	return stagedDir, nil
 }
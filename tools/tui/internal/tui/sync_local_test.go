@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// snapshotTree maps every path under root to its content, "/" for
+// directories.
+func snapshotTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	tree := map[string]string{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, path)
+		if d.IsDir() {
+			tree[filepath.ToSlash(rel)] = "/"
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		tree[filepath.ToSlash(rel)] = string(content)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func testSyncBundle(t *testing.T) *WorkflowBundle {
+	t.Helper()
+	return &WorkflowBundle{
+		FileName: "demo.zip",
+		Content: buildTestZip(t, []testZipEntry{
+			{name: "bundle/project.yaml", content: "staging-settings:\n  rpcs: []\n"},
+			{name: "bundle/demo/workflow.yaml", content: "staging-settings:\n  user-workflow:\n    workflow-name: demo\n"},
+			{name: "bundle/demo/package.json", content: `{"scripts": {"build": "tsc"}}`},
+			{name: "bundle/demo/main.ts", content: "export {}\n"},
+		}),
+	}
+}
+
+func TestSyncBundleToLocalDryRunLeavesProjectAlone(t *testing.T) {
+	useTempHome(t)
+	SetDryRun(true)
+	t.Cleanup(func() { SetDryRun(false) })
+
+	finalDir := localWorkflowProjectRoot("wf-1", "demo")
+	files := map[string]string{
+		"project.yaml":       "local project\n",
+		"demo/workflow.yaml": "local workflow\n",
+		"demo/.env":          "CRE_ETH_PRIVATE_KEY=abc\n",
+		"demo/notes.md":      "mine\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(finalDir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before := snapshotTree(t, workflowsRootDir())
+
+	result, err := syncBundleToLocal(testSyncBundle(t), "wf-1", "demo", "", nil)
+	if err != nil {
+		t.Fatalf("syncBundleToLocal() error = %v", err)
+	}
+	if result.OutputDir != "" {
+		t.Fatalf("OutputDir = %q, want none in dry run", result.OutputDir)
+	}
+	if after := snapshotTree(t, workflowsRootDir()); !reflect.DeepEqual(after, before) {
+		t.Fatalf("dry-run sync changed the disk:\nbefore %q\nafter  %q", before, after)
+	}
+}
+
+func TestSyncBundleToLocalRestoresProjectWhenReplaceFails(t *testing.T) {
+	useTempHome(t)
+	if _, err := syncBundleToLocal(testSyncBundle(t), "wf-1", "demo", "", nil); err != nil {
+		t.Fatalf("first sync: %v", err)
+	}
+	finalDir := localWorkflowProjectRoot("wf-1", "demo")
+	if err := os.WriteFile(filepath.Join(finalDir, "demo", "notes.md"), []byte("mine\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	before := snapshotTree(t, workflowsRootDir())
+
+	renameSyncedProject = func(string, string) error { return errors.New("rename failed") }
+	t.Cleanup(func() { renameSyncedProject = os.Rename })
+
+	if _, err := syncBundleToLocal(testSyncBundle(t), "wf-1", "demo", "", nil); err == nil {
+		t.Fatal("syncBundleToLocal() error = nil, want the rename failure")
+	}
+	if after := snapshotTree(t, workflowsRootDir()); !reflect.DeepEqual(after, before) {
+		t.Fatalf("failed sync changed the disk:\nbefore %q\nafter  %q", before, after)
+	}
+
+	renameSyncedProject = os.Rename
+	if _, err := syncBundleToLocal(testSyncBundle(t), "wf-1", "demo", "", nil); err != nil {
+		t.Fatalf("retried sync: %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(finalDir, "demo", "notes.md")); err != nil || string(content) != "mine\n" {
+		t.Fatalf("local file after the retried sync = %q, %v", content, err)
+	}
+}
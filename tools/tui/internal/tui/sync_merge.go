@@ -0,0 +1,217 @@
+package tui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A re-sync merges the new bundle over the existing project instead of
+// replacing it:
+//
+//   - .env files, the keystore and deployment history are carried over as
+//     before;
+//   - secrets.yaml keeps the declarations added locally;
+//   - config files edited since the last sync keep the local edits, told
+//     apart by the hashes the bundle stamp records for every synced file;
+//   - files the bundle never had, e.g. node_modules or notes, stay.
+//
+// Files an earlier bundle wrote and the new one dropped are removed unless
+// they were edited.
+
+// syncMergeResult lists what a merge kept, relative to the project root.
+type syncMergeResult struct {
+	EditedConfigs []string
+	LocalFiles    []string
+	Dropped       []string
+	// moved pairs each moved path in the existing project with its path in
+	// the staged one, in the order of the moves.
+	moved [][2]string
+}
+
+// undo moves the kept files back into the existing project, newest first.
+func (r *syncMergeResult) undo() error {
+	var firstErr error
+	for i := len(r.moved) - 1; i >= 0; i-- {
+		if err := os.Rename(r.moved[i][1], r.moved[i][0]); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// isSyncedFile reports whether sync tracks local edits to rel, a file that
+// comes from the bundle. secrets.yaml is merged instead, and dotfiles and
+// deployments.json hold local state that sync carries over.
+func isSyncedFile(rel string) bool {
+	name := filepath.Base(rel)
+	if strings.HasPrefix(name, ".") || name == "secrets.yaml" || name == deploymentsFileName {
+		return false
+	}
+	for _, part := range strings.Split(filepath.ToSlash(rel), "/") {
+		if part == "node_modules" {
+			return false
+		}
+	}
+	return true
+}
+
+// isSyncedConfigFile reports whether local edits to rel survive a sync: only
+// the JSON and YAML files of the bundle keep them.
+func isSyncedConfigFile(rel string) bool {
+	if !isSyncedFile(rel) {
+		return false
+	}
+	switch filepath.Ext(rel) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+func fileSHA256(path string) (string, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// hashSyncedFiles returns the hash of every bundle file sync is about to
+// write, keyed by its slash-separated path relative to stagedDir.
+func hashSyncedFiles(stagedDir string) (map[string]string, error) {
+	hashes := map[string]string{}
+	err := filepath.WalkDir(stagedDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == "node_modules" {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(stagedDir, path)
+		if err != nil || d.IsDir() || !isSyncedFile(rel) {
+			return err
+		}
+		hash, err := fileSHA256(path)
+		if err != nil {
+			return err
+		}
+		hashes[filepath.ToSlash(rel)] = hash
+		return nil
+	})
+	return hashes, err
+}
+
+// mergeSecretsManifest adds the declarations of the existing secrets.yaml
+// that the staged one lacks, keeping the local env var mapping for secrets
+// both declare. It returns the IDs only the local file had.
+func mergeSecretsManifest(existingPath, stagedPath string) ([]string, error) {
+	existing, err := loadSecretsManifest(existingPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	staged, err := loadSecretsManifest(stagedPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		staged, err = &secretsManifest{SecretsNames: map[string][]string{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	kept := []string{}
+	for id, envVars := range existing.SecretsNames {
+		if _, ok := staged.SecretsNames[id]; !ok {
+			kept = append(kept, id)
+		}
+		staged.SecretsNames[id] = envVars
+	}
+	sort.Strings(kept)
+	raw, err := yaml.Marshal(staged)
+	if err != nil {
+		return nil, err
+	}
+	return kept, os.WriteFile(stagedPath, raw, 0o644)
+}
+
+// mergeExistingProject moves what the existing project should keep into
+// stagedDir, which then replaces it. previous is the stamp of the last sync;
+// without recorded hashes, edits cannot be told apart and the bundle wins.
+// On failure every move is undone, since stagedDir is about to be deleted;
+// the result's undo does the same when the replace itself fails later.
+func mergeExistingProject(finalDir, stagedDir string, previous *BundleStamp) (*syncMergeResult, error) {
+	result := &syncMergeResult{}
+	move := func(src, dst string) error {
+		if err := os.Rename(src, dst); err != nil {
+			return err
+		}
+		result.moved = append(result.moved, [2]string{src, dst})
+		return nil
+	}
+	var synced map[string]string
+	if previous != nil {
+		synced = previous.Files
+	}
+	edited := func(path, rel string) bool {
+		want, ok := synced[filepath.ToSlash(rel)]
+		if !ok {
+			return false
+		}
+		got, err := fileSHA256(path)
+		return err == nil && got != want
+	}
+
+	var merge func(rel string) error
+	merge = func(rel string) error {
+		entries, err := os.ReadDir(filepath.Join(finalDir, rel))
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			entryRel := filepath.Join(rel, entry.Name())
+			src := filepath.Join(finalDir, entryRel)
+			dst := filepath.Join(stagedDir, entryRel)
+			if entryRel == bundleStampFile {
+				continue
+			}
+			dstInfo, err := os.Lstat(dst)
+			switch {
+			case os.IsNotExist(err):
+				if _, wasSynced := synced[filepath.ToSlash(entryRel)]; wasSynced && !edited(src, entryRel) {
+					result.Dropped = append(result.Dropped, entryRel)
+					continue
+				}
+				if err := move(src, dst); err != nil {
+					return err
+				}
+				result.LocalFiles = append(result.LocalFiles, entryRel)
+			case err != nil:
+				return err
+			case entry.IsDir() && dstInfo.IsDir():
+				if err := merge(entryRel); err != nil {
+					return err
+				}
+			case !entry.IsDir() && !dstInfo.IsDir() && isSyncedConfigFile(entryRel) && edited(src, entryRel):
+				if err := move(src, dst); err != nil {
+					return err
+				}
+				result.EditedConfigs = append(result.EditedConfigs, entryRel)
+			}
+		}
+		return nil
+	}
+	if err := merge("."); err != nil {
+		_ = result.undo()
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,275 @@
+package tui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+type SyncChangeKind string
+
+const (
+	SyncFileAdded   SyncChangeKind = "added"
+	SyncFileRemoved SyncChangeKind = "removed"
+	SyncFileChanged SyncChangeKind = "changed"
+	// SyncFileKept is a config file whose local edits the sync keeps over
+	// the bundle's version.
+	SyncFileKept SyncChangeKind = "kept"
+)
+
+// SyncFileChange is one file sync would write, remove or keep, by path
+// relative to the project root. A size is -1 when that side has no file.
+type SyncFileChange struct {
+	Path    string
+	Kind    SyncChangeKind
+	OldSize int64
+	NewSize int64
+	// LocalEdits is set when the file was edited since the last sync and
+	// the sync would overwrite or remove those edits.
+	LocalEdits bool
+}
+
+// Line describes the change for the console, e.g. "~ main.ts 1.2 KiB -> 1.4 KiB".
+func (c SyncFileChange) Line() string {
+	path := filepath.ToSlash(c.Path)
+	var line string
+	switch c.Kind {
+	case SyncFileAdded:
+		line = fmt.Sprintf("+ %s (%s)", path, FormatBytes(c.NewSize))
+	case SyncFileRemoved:
+		line = fmt.Sprintf("- %s (%s)", path, FormatBytes(c.OldSize))
+	case SyncFileKept:
+		line = fmt.Sprintf("= %s (local edits kept over the bundle's %s)", path, FormatBytes(c.NewSize))
+	default:
+		line = fmt.Sprintf("~ %s %s -> %s", path, FormatBytes(c.OldSize), FormatBytes(c.NewSize))
+	}
+	if c.LocalEdits {
+		line += "  [local edits lost]"
+	}
+	return line
+}
+
+// SyncPreview is a downloaded bundle that has not been written yet, with the
+// changes syncing it would make to the local project. ApplyWorkflowSync
+// writes it.
+type SyncPreview struct {
+	WorkflowID   string
+	WorkflowName string
+	Version      string
+	OutputDir    string
+	// Exists is false for a first sync, which has nothing to overwrite.
+	Exists bool
+	// Untracked is set when the last sync recorded no file hashes, so local
+	// edits cannot be told apart from bundle changes.
+	Untracked bool
+	Changes   []SyncFileChange
+	Logs      []string
+
+	bundle *WorkflowBundle
+}
+
+// LostEdits returns the changes that would lose local modifications. For an
+// untracked project, every overwritten bundle file might.
+func (p *SyncPreview) LostEdits() []SyncFileChange {
+	lost := []SyncFileChange{}
+	for _, change := range p.Changes {
+		untracked := p.Untracked && change.Kind == SyncFileChanged && isSyncedFile(change.Path)
+		if change.LocalEdits || untracked {
+			lost = append(lost, change)
+		}
+	}
+	return lost
+}
+
+// Summary counts the changes by kind, e.g. "2 added, 1 changed".
+func (p *SyncPreview) Summary() string {
+	counts := map[SyncChangeKind]int{}
+	for _, change := range p.Changes {
+		counts[change.Kind]++
+	}
+	parts := []string{}
+	for _, kind := range []SyncChangeKind{SyncFileAdded, SyncFileChanged, SyncFileRemoved, SyncFileKept} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[kind], kind))
+		}
+	}
+	if len(parts) == 0 {
+		return "no file changes"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// PreviewWorkflowSync downloads a bundle and stages it like a sync would,
+// then compares the result with the local project without touching it.
+func PreviewWorkflowSync(baseURL, token, workflowID, workflowName, version string) (*SyncPreview, error) {
+	preview := &SyncPreview{
+		WorkflowID:   workflowID,
+		WorkflowName: workflowName,
+		Version:      version,
+		OutputDir:    localWorkflowProjectRoot(workflowID, workflowName),
+		Logs:         []string{},
+	}
+	appendLog := func(msg string) { preview.Logs = append(preview.Logs, msg) }
+
+	bundle, err := downloadSyncBundle(baseURL, token, workflowID, version, appendLog)
+	if err != nil {
+		return preview, err
+	}
+	preview.bundle = bundle
+
+	preview.Exists, err = fileExists(preview.OutputDir)
+	if err != nil {
+		return preview, err
+	}
+	release, tmpDir, err := beginSyncStaging(preview.OutputDir, workflowName)
+	if err != nil {
+		return preview, err
+	}
+	defer release()
+	defer os.RemoveAll(tmpDir)
+
+	// Staging logs again when the sync is applied; only its failures matter
+	// here.
+	stagingLogs := []string{}
+	stagedDir, err := stageSyncBundle(bundle, tmpDir, preview.OutputDir, workflowID, workflowName, version,
+		func(msg string) { stagingLogs = append(stagingLogs, msg) })
+	if err != nil {
+		preview.Logs = append(preview.Logs, stagingLogs...)
+		return preview, err
+	}
+	if !preview.Exists {
+		return preview, nil
+	}
+	previous := readBundleStamp(preview.OutputDir)
+	preview.Untracked = previous == nil || len(previous.Files) == 0
+	preview.Changes, err = diffSyncedProject(preview.OutputDir, stagedDir, previous)
+	if err != nil {
+		return preview, err
+	}
+	return preview, nil
+}
+
+// ApplyWorkflowSync writes a previewed bundle into the local project. The
+// project is merged again, so edits made since the preview are still kept.
+func ApplyWorkflowSync(preview *SyncPreview) (*SyncLocalResult, error) {
+	if preview == nil || preview.bundle == nil {
+		return nil, errors.New("sync preview has no downloaded bundle")
+	}
+	return syncBundleToLocal(preview.bundle, preview.WorkflowID, preview.WorkflowName, preview.Version, []string{})
+}
+
+// diffSyncedProject compares the existing project in finalDir with the one
+// staged in stagedDir, the way mergeExistingProject will merge them: files
+// only the local project has stay and are not listed.
+func diffSyncedProject(finalDir, stagedDir string, previous *BundleStamp) ([]SyncFileChange, error) {
+	var synced map[string]string
+	if previous != nil {
+		synced = previous.Files
+	}
+	// edited is false without a recorded hash, as in mergeExistingProject.
+	edited := func(path, rel string) bool {
+		want, ok := synced[filepath.ToSlash(rel)]
+		if !ok {
+			return false
+		}
+		got, err := fileSHA256(path)
+		return err == nil && got != want
+	}
+	changes := []SyncFileChange{}
+
+	err := filepath.WalkDir(stagedDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(stagedDir, path)
+		if err != nil || d.IsDir() || rel == bundleStampFile {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		local := filepath.Join(finalDir, rel)
+		localInfo, err := os.Stat(local)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			changes = append(changes, SyncFileChange{Path: rel, Kind: SyncFileAdded, OldSize: -1, NewSize: info.Size()})
+			return nil
+		case err != nil:
+			return err
+		case localInfo.IsDir():
+			return nil
+		}
+		same, err := sameFileContent(local, path)
+		if err != nil || same {
+			return err
+		}
+		change := SyncFileChange{Path: rel, Kind: SyncFileChanged, OldSize: localInfo.Size(), NewSize: info.Size()}
+		switch {
+		case isSyncedConfigFile(rel) && edited(local, rel):
+			change.Kind = SyncFileKept
+		case isSyncedFile(rel):
+			change.LocalEdits = edited(local, rel)
+		}
+		changes = append(changes, change)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = filepath.WalkDir(finalDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(finalDir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		_, err = os.Lstat(filepath.Join(stagedDir, rel))
+		switch {
+		case err == nil:
+			return nil
+		case !errors.Is(err, fs.ErrNotExist):
+			return err
+		case d.IsDir():
+			// The whole directory is local and stays.
+			return filepath.SkipDir
+		}
+		if _, wasSynced := synced[filepath.ToSlash(rel)]; !wasSynced {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		// Edited files the bundle dropped stay, like local ones.
+		if !edited(path, rel) {
+			changes = append(changes, SyncFileChange{Path: rel, Kind: SyncFileRemoved, OldSize: info.Size(), NewSize: -1})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func sameFileContent(a, b string) (bool, error) {
+	rawA, err := os.ReadFile(a)
+	if err != nil {
+		return false, err
+	}
+	rawB, err := os.ReadFile(b)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(rawA, rawB), nil
+}
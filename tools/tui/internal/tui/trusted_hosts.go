@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const defaultWebHost = "https://6flow.studio"
+
+var ErrUntrustedHost = errors.New("frontend host has not been approved")
+
+// HostTrustInfo is what the user sees before approving a frontend host.
+type HostTrustInfo struct {
+	Origin      string
+	Secure      bool
+	TLSVersion  string
+	Subject     string
+	Issuer      string
+	NotAfter    time.Time
+	Fingerprint string
+	// TLSError is set when the handshake failed; the host can still be
+	// approved, but the user should know why.
+	TLSError string
+}
+
+type trustedHostEntry struct {
+	ApprovedAt  string `json:"approvedAt"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+func trustedHostsPath() string {
+	return filepath.Join(sixflowHomeDir(), "trusted-hosts.json")
+}
+
+func loadTrustedHosts() map[string]trustedHostEntry {
+	hosts := map[string]trustedHostEntry{}
+	content, err := os.ReadFile(trustedHostsPath())
+	if err != nil {
+		return hosts
+	}
+	_ = json.Unmarshal(content, &hosts)
+	return hosts
+}
+
+// IsTrustedHost reports whether tokens may be sent to baseURL. The default
+// frontend is always trusted.
+func IsTrustedHost(baseURL string) bool {
+	origin := originOf(NormalizeBaseURL(baseURL))
+	if origin == "" || origin == defaultWebHost {
+		return true
+	}
+	_, ok := loadTrustedHosts()[origin]
+	return ok
+}
+
+// TrustHost records the approval in ~/.6flow/trusted-hosts.json.
+func TrustHost(baseURL string, info *HostTrustInfo) error {
+	origin := originOf(NormalizeBaseURL(baseURL))
+	if origin == "" {
+		return fmt.Errorf("invalid frontend URL %q", baseURL)
+	}
+	path := trustedHostsPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	release, err := acquireFileLock(path)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	hosts := loadTrustedHosts()
+	entry := trustedHostEntry{ApprovedAt: time.Now().UTC().Format(time.RFC3339)}
+	if info != nil {
+		entry.Fingerprint = info.Fingerprint
+	}
+	hosts[origin] = entry
+	content, err := json.MarshalIndent(hosts, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0o600)
+}
+
+// InspectHost collects TLS details for the trust prompt without sending any
+// credentials.
+func InspectHost(baseURL string) *HostTrustInfo {
+	origin := originOf(NormalizeBaseURL(baseURL))
+	info := &HostTrustInfo{Origin: origin}
+	parsed, err := url.Parse(origin)
+	if err != nil || parsed.Scheme != "https" {
+		return info
+	}
+	info.Secure = true
+
+	host := parsed.Host
+	if parsed.Port() == "" {
+		host = net.JoinHostPort(parsed.Hostname(), "443")
+	}
+	config, err := frontendTLSConfig()
+	if err != nil {
+		info.TLSError = err.Error()
+		return info
+	}
+	if config == nil {
+		config = &tls.Config{}
+	}
+	config = config.Clone()
+	config.ServerName = parsed.Hostname()
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", host, config)
+	if err != nil {
+		info.TLSError = err.Error()
+		return info
+	}
+	defer conn.Close()
+
+	state := conn.ConnectionState()
+	info.TLSVersion = tls.VersionName(state.Version)
+	if len(state.PeerCertificates) > 0 {
+		leaf := state.PeerCertificates[0]
+		info.Subject = leaf.Subject.CommonName
+		if len(leaf.DNSNames) > 0 {
+			info.Subject = strings.Join(leaf.DNSNames, ", ")
+		}
+		info.Issuer = leaf.Issuer.CommonName
+		info.NotAfter = leaf.NotAfter
+		sum := sha256.Sum256(leaf.Raw)
+		info.Fingerprint = hex.EncodeToString(sum[:])
+	}
+	return info
+}
@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TUIConfig holds machine-wide settings from ~/.6flow/config.json that are
+// not tied to an auth profile.
+type TUIConfig struct {
+	// CREBin is the cre executable to run instead of the one on PATH.
+	CREBin string `json:"creBin,omitempty"`
+	// Env limits the environment passed to cre and the package manager.
+	Env *EnvPolicy `json:"env,omitempty"`
+	// DeployGas is the gas assumed for a deploy transaction when estimating
+	// its fee.
+	DeployGas uint64 `json:"deployGas,omitempty"`
+	// PriceURL returns the USD price of the native token as JSON; "off"
+	// disables the lookup.
+	PriceURL string `json:"priceUrl,omitempty"`
+	// Secrets keeps secret values in a cloud secret manager instead of .env.
+	Secrets *SecretsBackendConfig `json:"secrets,omitempty"`
+	// Generate sets the length and charset of generated secret values.
+	Generate *SecretGeneratorConfig `json:"generate,omitempty"`
+	// RotateAfterDays is the age at which the pickers flag a secret value
+	// as due for rotation, 90 by default.
+	RotateAfterDays int `json:"rotateAfterDays,omitempty"`
+}
+
+func tuiConfigPath() string {
+	return filepath.Join(sixflowHomeDir(), "config.json")
+}
+
+// LoadTUIConfig reads ~/.6flow/config.json. A missing file is an empty
+// config.
+func LoadTUIConfig() (TUIConfig, error) {
+	var config TUIConfig
+	content, err := os.ReadFile(tuiConfigPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return config, nil
+		}
+		return config, err
+	}
+	if err := json.Unmarshal(content, &config); err != nil {
+		return config, fmt.Errorf("parse %s: %w", tuiConfigPath(), err)
+	}
+	return config, nil
+}
+
+// expandHome resolves a leading "~/" so config values can be written the way
+// users type them in a shell.
+func expandHome(path string) string {
+	path = strings.TrimSpace(path)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
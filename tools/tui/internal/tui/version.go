@@ -0,0 +1,185 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ClientVersion is set at release time with
+// -ldflags "-X github.com/6flow/6flow-convergence/tools/tui/internal/tui.ClientVersion=<version>".
+// Development builds report "dev" and skip the minimum-version check.
+var ClientVersion = "dev"
+
+// ClientAPIVersion is the /api/tui contract this client speaks. The frontend
+// bumps its apiVersion when an endpoint changes shape incompatibly.
+const ClientAPIVersion = 1
+
+const (
+	clientVersionHeader    = "X-6flow-TUI-Version"
+	clientAPIVersionHeader = "X-6flow-TUI-API-Version"
+	minClientVersionHeader = "X-6flow-Min-TUI-Version"
+)
+
+var ErrIncompatibleClient = errors.New("TUI version is not supported by the frontend")
+
+// IncompatibleClientError explains why the frontend refused this client.
+type IncompatibleClientError struct {
+	Required string
+	Current  string
+}
+
+func (e *IncompatibleClientError) Error() string {
+	if e.Required == "" {
+		return fmt.Sprintf("frontend no longer supports TUI %s; upgrade 6flow-tui", e.Current)
+	}
+	return fmt.Sprintf("frontend requires TUI >= %s (this is %s); upgrade 6flow-tui", e.Required, e.Current)
+}
+
+func (e *IncompatibleClientError) Unwrap() error {
+	return ErrIncompatibleClient
+}
+
+// FrontendCapabilities is the answer of /api/tui/version. Frontends without
+// the endpoint predate versioning and are assumed compatible.
+type FrontendCapabilities struct {
+	APIVersion       int      `json:"apiVersion"`
+	MinClientVersion string   `json:"minClientVersion"`
+	Capabilities     []string `json:"capabilities"`
+}
+
+// Has reports whether the frontend advertises a named capability.
+func (c *FrontendCapabilities) Has(name string) bool {
+	if c == nil {
+		return false
+	}
+	for _, capability := range c.Capabilities {
+		if capability == name {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckFrontendCompatibility fetches the frontend capabilities and returns an
+// *IncompatibleClientError when this client is too old or speaks a different
+// API version. A nil result with a nil error means the frontend is unversioned.
+func CheckFrontendCompatibility(baseURL string) (*FrontendCapabilities, error) {
+	client := newHTTPClient(listTimeout())
+	req, err := http.NewRequest(http.MethodGet, NormalizeBaseURL(baseURL)+"/api/tui/version", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := doFrontendRequest(client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+		return nil, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("version check failed with status %d", resp.StatusCode)
+	}
+	var capabilities FrontendCapabilities
+	if err := json.NewDecoder(resp.Body).Decode(&capabilities); err != nil {
+		return nil, fmt.Errorf("invalid API response from /api/tui/version: %w", err)
+	}
+	if !clientVersionAtLeast(capabilities.MinClientVersion) {
+		return &capabilities, &IncompatibleClientError{Required: capabilities.MinClientVersion, Current: ClientVersion}
+	}
+	if capabilities.APIVersion > ClientAPIVersion {
+		return &capabilities, &IncompatibleClientError{Current: ClientVersion}
+	}
+	return &capabilities, nil
+}
+
+// incompatibleResponse turns a 426 Upgrade Required, or any response carrying
+// a minimum version above ours, into an *IncompatibleClientError. It may read
+// the body of a 426 response.
+func incompatibleResponse(resp *http.Response) error {
+	required := strings.TrimSpace(resp.Header.Get(minClientVersionHeader))
+	if resp.StatusCode == http.StatusUpgradeRequired {
+		if required == "" {
+			var body struct {
+				MinClientVersion string `json:"minClientVersion"`
+			}
+			raw, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+			_ = json.Unmarshal(raw, &body)
+			required = strings.TrimSpace(body.MinClientVersion)
+		}
+		return &IncompatibleClientError{Required: required, Current: ClientVersion}
+	}
+	if !clientVersionAtLeast(required) {
+		return &IncompatibleClientError{Required: required, Current: ClientVersion}
+	}
+	return nil
+}
+
+func clientVersionAtLeast(required string) bool {
+	if strings.TrimSpace(required) == "" || ClientVersion == "dev" {
+		return true
+	}
+	return compareVersions(ClientVersion, required) >= 0
+}
+
+// compareVersions orders dotted numeric versions, ignoring a leading "v" and
+// any pre-release suffix.
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(version string) []int {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if idx := strings.IndexAny(version, "-+"); idx >= 0 {
+		version = version[:idx]
+	}
+	parts := []int{}
+	for _, field := range strings.Split(version, ".") {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			break
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// versionTransport stamps every request with the client and API version so
+// the frontend can refuse or adapt to old clients, and with the selected
+// organization for multi-org accounts.
+type versionTransport struct {
+	base http.RoundTripper
+}
+
+func (t versionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(clientVersionHeader, ClientVersion)
+	req.Header.Set(clientAPIVersionHeader, strconv.Itoa(ClientAPIVersion))
+	if org := ActiveOrganization(); org != "" && req.Header.Get(organizationHeader) == "" {
+		req.Header.Set(organizationHeader, org)
+	}
+	return t.base.RoundTrip(req)
+}
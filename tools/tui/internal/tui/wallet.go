@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var ErrNoWalletKey = errors.New("no CRE_ETH_PRIVATE_KEY configured")
+
+// WalletBalance is the balance of the wallet on one chain of the target.
+type WalletBalance struct {
+	ChainName string
+	Balance   *big.Int
+	Err       error
+}
+
+// WalletInfo is the account of the workflow's CRE_ETH_PRIVATE_KEY, which
+// signs simulation writes and deploys.
+type WalletInfo struct {
+	Address string
+	// Source is ".env" or "keystore".
+	Source   string
+	Demo     bool
+	Balances []WalletBalance
+}
+
+// LoadWorkflowWallet derives the address from the .env key, or reads it from
+// the keystore, and queries its balance on every RPC the target configures.
+func LoadWorkflowWallet(workflowID, workflowName, target string) (*WalletInfo, error) {
+	workflowDir := localWorkflowDir(workflowID, workflowName)
+	wallet := &WalletInfo{Source: ".env"}
+	privateKey, _ := readDotEnvValue(workflowDotEnvPath(workflowDir, target), "CRE_ETH_PRIVATE_KEY")
+	switch {
+	case isValidPrivateKey(privateKey):
+		address, err := PrivateKeyAddress(privateKey)
+		if err != nil {
+			return nil, err
+		}
+		wallet.Address = address
+		wallet.Demo = strings.EqualFold(strings.TrimPrefix(strings.TrimSpace(privateKey), "0x"), demoPrivateKeyForProject(workflowID))
+	case HasWorkflowKeystore(workflowID, workflowName):
+		ks, err := readWorkflowKeystore(workflowKeystorePath(workflowID, workflowName))
+		if err != nil {
+			return nil, err
+		}
+		if ks.Address == "" {
+			return nil, errors.New("keystore has no address; encrypt the key again to record it")
+		}
+		wallet.Address = "0x" + strings.TrimPrefix(ks.Address, "0x")
+		wallet.Source = "keystore"
+	case IsSecretReference(privateKey):
+		return nil, errors.New("CRE_ETH_PRIVATE_KEY is a secret reference; it is resolved only for runs")
+	default:
+		return nil, ErrNoWalletKey
+	}
+
+	projectYamlPath := filepath.Join(localWorkflowProjectRoot(workflowID, workflowName), "project.yaml")
+	rpcs, err := readProjectRPCMap(projectYamlPath, target)
+	if err != nil && !os.IsNotExist(err) {
+		return wallet, err
+	}
+	chains := make([]string, 0, len(rpcs))
+	for chain, rpcURL := range rpcs {
+		if rpcURL != "" {
+			chains = append(chains, chain)
+		}
+	}
+	sort.Strings(chains)
+	for _, chain := range chains {
+		balance, err := RPCBalance(rpcs[chain], wallet.Address)
+		wallet.Balances = append(wallet.Balances, WalletBalance{ChainName: chain, Balance: balance, Err: err})
+	}
+	return wallet, nil
+}
+
+// ShortAddress keeps the first and last four hex digits.
+func (w *WalletInfo) ShortAddress() string {
+	if len(w.Address) < 12 {
+		return w.Address
+	}
+	return w.Address[:6] + "…" + w.Address[len(w.Address)-4:]
+}
+
+// Summary is a one-line view, e.g.
+// "0x2c75…5c23 (.env): 0.5 on ethereum-testnet-sepolia".
+func (w *WalletInfo) Summary() string {
+	line := fmt.Sprintf("%s (%s)", w.ShortAddress(), w.Source)
+	if w.Demo {
+		line += " demo key"
+	}
+	parts := []string{}
+	for _, balance := range w.Balances {
+		switch {
+		case balance.Err != nil:
+			parts = append(parts, "? on "+balance.ChainName)
+		case balance.Balance.Sign() == 0:
+			parts = append(parts, "unfunded on "+balance.ChainName)
+		default:
+			parts = append(parts, FormatWei(balance.Balance)+" on "+balance.ChainName)
+		}
+	}
+	if len(parts) == 0 {
+		return line + ": no RPC configured"
+	}
+	return line + ": " + strings.Join(parts, ", ")
+}
@@ -0,0 +1,94 @@
+package tui
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// workflowListCache is the last first page of /api/tui/workflows for the
+// active profile, kept so unchanged lists can be revalidated with
+// If-None-Match and shown while the frontend is unreachable.
+type workflowListCache struct {
+	BaseURL    string             `json:"baseUrl"`
+	Account    string             `json:"account"`
+	OrgID      string             `json:"orgId,omitempty"`
+	Limit      int                `json:"limit"`
+	ETag       string             `json:"etag"`
+	FetchedAt  string             `json:"fetchedAt"`
+	Workflows  []FrontendWorkflow `json:"workflows"`
+	NextCursor string             `json:"nextCursor,omitempty"`
+}
+
+// The cache sits next to the profile's session file.
+func workflowCachePath() string {
+	return filepath.Join(filepath.Dir(sessionFilePath()), "workflows-cache.json")
+}
+
+// loadWorkflowCache returns the cached page only when it was fetched from the
+// same frontend, for the same account and page size.
+func loadWorkflowCache(baseURL, token string, limit int) *workflowListCache {
+	content, err := os.ReadFile(workflowCachePath())
+	if err != nil {
+		return nil
+	}
+	var cache workflowListCache
+	if err := json.Unmarshal(content, &cache); err != nil {
+		return nil
+	}
+	if cache.BaseURL != NormalizeBaseURL(baseURL) || cache.Account != TokenAccountLabel(token) ||
+		cache.OrgID != ActiveOrganization() || cache.Limit != limit {
+		return nil
+	}
+	if cache.Workflows == nil {
+		return nil
+	}
+	return &cache
+}
+
+func saveWorkflowCache(baseURL, token string, limit int, etag string, page *WorkflowPage) {
+	cache := workflowListCache{
+		BaseURL:    NormalizeBaseURL(baseURL),
+		Account:    TokenAccountLabel(token),
+		OrgID:      ActiveOrganization(),
+		Limit:      limit,
+		ETag:       etag,
+		FetchedAt:  time.Now().UTC().Format(time.RFC3339),
+		Workflows:  page.Workflows,
+		NextCursor: page.NextCursor,
+	}
+	content, err := json.MarshalIndent(&cache, "", "  ")
+	if err != nil {
+		return
+	}
+	path := workflowCachePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return
+	}
+	// Write then rename so a concurrent reader never sees half a file.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+	}
+}
+
+// ClearWorkflowCache drops the cached list when the session is cleared.
+func ClearWorkflowCache() {
+	_ = os.Remove(workflowCachePath())
+}
+
+func (c *workflowListCache) page(stale bool) *WorkflowPage {
+	page := &WorkflowPage{Workflows: c.Workflows, Stale: stale}
+	// Later pages cannot be served from the cache, so a stale list ends here.
+	if !stale {
+		page.NextCursor = c.NextCursor
+	}
+	if parsed, err := time.Parse(time.RFC3339, c.FetchedAt); err == nil {
+		page.FetchedAt = parsed
+	}
+	return page
+}
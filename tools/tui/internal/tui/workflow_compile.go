@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	ErrCompileUnsupported = errors.New("frontend does not support compiling from the TUI")
+	ErrCompileFailed      = errors.New("workflow compilation failed")
+)
+
+const (
+	DefaultCompilePollInterval = 3 * time.Second
+	DefaultCompileTimeout      = 5 * time.Minute
+)
+
+type workflowCompileResponse struct {
+	OK bool `json:"ok"`
+	apiErrorBody
+}
+
+// RequestWorkflowCompile asks the frontend to (re)compile a workflow. The
+// compile runs asynchronously; poll FetchWorkflowStatus until it is ready. A
+// compile that is already running (409) counts as accepted.
+func RequestWorkflowCompile(baseURL, token, workflowID string) error {
+	endpoint := fmt.Sprintf("%s/api/tui/workflows/%s/compile", NormalizeBaseURL(baseURL), url.PathEscape(workflowID))
+
+	client := newHTTPClient(listTimeout())
+	req, err := http.NewRequest(http.MethodPost, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	setIdempotencyKey(req)
+
+	resp, err := doFrontendRequest(client, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result workflowCompileResponse
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return ErrFrontendUnauthorized
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		return ErrCompileUnsupported
+	case resp.StatusCode == http.StatusConflict:
+		return nil
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return result.apiError(resp.StatusCode)
+	}
+	return nil
+}
+
+// FetchWorkflowStatus returns the current list entry of one workflow, using
+// the detail endpoint when the frontend has it and the list otherwise.
+func FetchWorkflowStatus(baseURL, token, workflowID string) (*FrontendWorkflow, error) {
+	detail, err := FetchWorkflowDetail(baseURL, token, workflowID)
+	if err == nil {
+		return &detail.FrontendWorkflow, nil
+	}
+	if !errors.Is(err, ErrWorkflowDetailUnsupported) {
+		return nil, err
+	}
+	workflows, err := FetchFrontendWorkflows(baseURL, token, WorkflowFilter{})
+	if err != nil {
+		return nil, err
+	}
+	for _, wf := range workflows {
+		if wf.ID == workflowID {
+			return &wf, nil
+		}
+	}
+	return nil, fmt.Errorf("workflow %s is no longer listed by the frontend", workflowID)
+}
+
+// CompileFailed reports whether a workflow status means the last compile
+// failed rather than still running.
+func CompileFailed(status string) bool {
+	switch strings.ToLower(strings.TrimSpace(status)) {
+	case "failed", "error", "compile_failed":
+		return true
+	}
+	return false
+}
+
+// WaitForWorkflowCompiled polls until the workflow is ready, its compile
+// fails, or timeout passes. onStatus sees every status change.
+func WaitForWorkflowCompiled(baseURL, token, workflowID string, interval, timeout time.Duration, onStatus func(string)) (*FrontendWorkflow, error) {
+	deadline := time.Now().Add(timeout)
+	last := ""
+	for {
+		wf, err := FetchWorkflowStatus(baseURL, token, workflowID)
+		if err != nil {
+			return nil, err
+		}
+		if wf.Status != last {
+			last = wf.Status
+			if onStatus != nil {
+				onStatus(wf.Status)
+			}
+		}
+		switch {
+		case wf.Status == "ready":
+			return wf, nil
+		case CompileFailed(wf.Status):
+			return wf, fmt.Errorf("%w (status %s); check the workflow in the browser", ErrCompileFailed, wf.Status)
+		case time.Now().Add(interval).After(deadline):
+			return wf, fmt.Errorf("workflow is still %s after %s", wf.Status, timeout)
+		}
+		time.Sleep(interval)
+	}
+}
@@ -0,0 +1,295 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// deploymentsFileName lives in the local project root and is carried over by
+// sync, like .env.
+const deploymentsFileName = "deployments.json"
+
+var ErrDeploySecretsNotConfigured = errors.New("cannot deploy until all secrets are configured")
+
+// DeploymentRecord is one successful `cre workflow deploy` of the local
+// project.
+type DeploymentRecord struct {
+	Target          string    `json:"target"`
+	WorkflowID      string    `json:"workflowId,omitempty"`
+	ContractAddress string    `json:"contractAddress,omitempty"`
+	TxHash          string    `json:"txHash,omitempty"`
+	DeployedAt      time.Time `json:"deployedAt"`
+	// BundleVersion and BundleSHA256 identify the compiled artifact that was
+	// deployed, from the stamp the sync left.
+	BundleVersion   string `json:"bundleVersion,omitempty"`
+	BundleSHA256    string `json:"bundleSha256,omitempty"`
+	CompilerVersion string `json:"compilerVersion,omitempty"`
+	// Status is "active", "paused" or "deleted" as last set from the TUI.
+	Status    string            `json:"status,omitempty"`
+	UpdatedAt time.Time         `json:"updatedAt,omitzero"`
+	Events    []DeploymentEvent `json:"events,omitempty"`
+}
+
+// DeploymentOp is a `cre workflow` command that manages a deployed workflow.
+type DeploymentOp string
+
+const (
+	DeploymentPause    DeploymentOp = "pause"
+	DeploymentActivate DeploymentOp = "activate"
+	DeploymentDelete   DeploymentOp = "delete"
+)
+
+var deploymentOpStatus = map[DeploymentOp]string{
+	DeploymentPause:    "paused",
+	DeploymentActivate: "active",
+	DeploymentDelete:   "deleted",
+}
+
+type DeployCommandResult struct {
+	Logs []string
+	// Deployment is what the CLI reported; nil in dry run.
+	Deployment *DeploymentRecord
+	// RecordPath is the deployments file the record was appended to.
+	RecordPath string
+}
+
+func deploymentsPath(workflowID, workflowName string) string {
+	return filepath.Join(localWorkflowProjectRoot(workflowID, workflowName), deploymentsFileName)
+}
+
+// LoadDeployments returns the recorded deployments of the local project,
+// oldest first. A missing file is an empty history.
+func LoadDeployments(workflowID, workflowName string) ([]DeploymentRecord, error) {
+	content, err := os.ReadFile(deploymentsPath(workflowID, workflowName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var records []DeploymentRecord
+	if err := json.Unmarshal(content, &records); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", deploymentsFileName, err)
+	}
+	return records, nil
+}
+
+func recordDeployment(workflowID, workflowName string, record DeploymentRecord) (string, error) {
+	records, err := LoadDeployments(workflowID, workflowName)
+	if err != nil {
+		return "", err
+	}
+	return saveDeployments(workflowID, workflowName, append(records, record))
+}
+
+// setDeploymentStatus applies op to the newest record of target. It reports
+// false when the target was never deployed from this machine.
+func setDeploymentStatus(workflowID, workflowName, target string, op DeploymentOp, txHash string) (bool, error) {
+	records, err := LoadDeployments(workflowID, workflowName)
+	if err != nil {
+		return false, err
+	}
+	for i := len(records) - 1; i >= 0; i-- {
+		if records[i].Target != target {
+			continue
+		}
+		now := time.Now().UTC()
+		records[i].Status = deploymentOpStatus[op]
+		records[i].UpdatedAt = now
+		records[i].Events = append(records[i].Events, DeploymentEvent{Op: op, TxHash: txHash, At: now})
+		_, err := saveDeployments(workflowID, workflowName, records)
+		return true, err
+	}
+	return false, nil
+}
+
+func saveDeployments(workflowID, workflowName string, records []DeploymentRecord) (string, error) {
+	content, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := deploymentsPath(workflowID, workflowName)
+	return path, writeProjectFile(path, append(content, '\n'), 0o644)
+}
+
+// deployOutputFields maps the labels of the "Details:" block printed after a
+// successful deploy to the record field they fill.
+var deployOutputFields = map[string]func(*DeploymentRecord, string){
+	"workflow id":      func(r *DeploymentRecord, v string) { r.WorkflowID = v },
+	"contract address": func(r *DeploymentRecord, v string) { r.ContractAddress = v },
+	"transaction hash": func(r *DeploymentRecord, v string) { r.TxHash = v },
+	"tx hash":          func(r *DeploymentRecord, v string) { r.TxHash = v },
+}
+
+func parseDeployOutputLine(record *DeploymentRecord, line string) {
+	label, value, found := strings.Cut(line, ":")
+	if !found {
+		return
+	}
+	set, ok := deployOutputFields[strings.ToLower(strings.TrimSpace(label))]
+	value = strings.TrimSpace(value)
+	if !ok || value == "" {
+		return
+	}
+	set(record, value)
+}
+
+// RunWorkflowDeploy runs `cre workflow deploy` for the synced local project
+// after the same checks as a simulation, and appends what the CLI reports to
+// deployments.json. The caller is expected to have confirmed the deploy, so
+// the CLI's own prompt is skipped.
+func RunWorkflowDeploy(workflowID, workflowName, target string, onLog func(string)) (*DeployCommandResult, error) {
+	logs := []string{}
+	appendLog := func(msg string) {
+		logs = append(logs, msg)
+		if onLog != nil {
+			onLog(msg)
+		}
+	}
+
+	if err := requireCRECLI(); err != nil {
+		return &DeployCommandResult{Logs: logs}, err
+	}
+	run, err := checkLocalWorkflowRun(workflowID, workflowName, target, "deploy", appendLog)
+	if err != nil {
+		if errors.Is(err, ErrSecretsNotConfigured) {
+			err = ErrDeploySecretsNotConfigured
+		}
+		return &DeployCommandResult{Logs: logs}, err
+	}
+	extraEnv := run.env
+	if run.needsPassphrase {
+		keyEnv, err := keystoreKeyEnv(workflowID, workflowName)
+		if err != nil {
+			return &DeployCommandResult{Logs: logs}, err
+		}
+		extraEnv = append(extraEnv, keyEnv...)
+		appendLog("CRE_ETH_PRIVATE_KEY decrypted from keystore for this run.")
+	}
+
+	cmdArgs := []string{"workflow", "deploy", run.workflowDirName, "--target", target}
+	cmdArgs = append(cmdArgs, dotEnvRunArgs(run.workflowDirName, run.dotEnvPath)...)
+	cmdArgs = append(cmdArgs, "--yes")
+	appendLog("Running deploy: cre " + strings.Join(cmdArgs, " "))
+	record := DeploymentRecord{Target: target, Status: deploymentOpStatus[DeploymentActivate]}
+	if stamp := readBundleStamp(run.projectRoot); stamp != nil {
+		record.BundleVersion = stamp.Version
+		record.BundleSHA256 = stamp.SHA256
+		record.CompilerVersion = stamp.CompilerVersion
+	}
+	_, deployErr := runCommandStream(run.projectRoot, extraEnv, nil, DeployTimeout(), func(line string, stderr bool) {
+		parseDeployOutputLine(&record, line)
+		appendLog(StreamTag("cre", stderr) + line)
+	}, CREBinary(), cmdArgs...)
+	if deployErr != nil {
+		return &DeployCommandResult{Logs: logs}, fmt.Errorf("deploy failed: %w", deployErr)
+	}
+	if DryRun() {
+		return &DeployCommandResult{Logs: logs}, nil
+	}
+
+	record.DeployedAt = time.Now().UTC()
+	appendLog("Deploy completed.")
+	if record.WorkflowID != "" {
+		appendLog("workflow ID: " + record.WorkflowID)
+	}
+	if record.ContractAddress != "" {
+		appendLog("registry address: " + record.ContractAddress)
+	}
+	if record.TxHash != "" {
+		appendLog("transaction: " + record.TxHash)
+	}
+	result := &DeployCommandResult{Logs: logs, Deployment: &record}
+	path, err := recordDeployment(workflowID, workflowName, record)
+	if err != nil {
+		// The workflow is deployed; only the local history is missing.
+		appendLog("Could not record the deployment in " + deploymentsFileName + ": " + err.Error())
+		return result, nil
+	}
+	result.RecordPath = path
+	appendLog("Recorded in " + path)
+	return result, nil
+}
+
+// keystoreKeyEnv unlocks the workflow keystore with SIXFLOW_KEYSTORE_PASSPHRASE
+// for commands that sign registry transactions.
+func keystoreKeyEnv(workflowID, workflowName string) ([]string, error) {
+	passphrase := os.Getenv(keystorePassphraseEnv)
+	if passphrase == "" {
+		return nil, fmt.Errorf("%w (set %s)", ErrKeystoreLocked, keystorePassphraseEnv)
+	}
+	privateKey, err := DecryptWorkflowPrivateKey(workflowID, workflowName, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return PrivateKeyEnv(privateKey), nil
+}
+
+// ManageDeployment runs `cre workflow pause|activate|delete` for the workflow
+// deployed to target and records the new status in deployments.json. Like
+// RunWorkflowDeploy it expects the caller to have confirmed.
+func ManageDeployment(workflowID, workflowName, target string, op DeploymentOp, onLog func(string)) (*DeployCommandResult, error) {
+	logs := []string{}
+	appendLog := func(msg string) {
+		logs = append(logs, msg)
+		if onLog != nil {
+			onLog(msg)
+		}
+	}
+	status, ok := deploymentOpStatus[op]
+	if !ok {
+		return &DeployCommandResult{Logs: logs}, fmt.Errorf("unknown deployment operation %q", op)
+	}
+	if err := requireCRECLI(); err != nil {
+		return &DeployCommandResult{Logs: logs}, err
+	}
+	projectRoot := localWorkflowProjectRoot(workflowID, workflowName)
+	workflowDirName := slugify(workflowName)
+	workflowDir := filepath.Join(projectRoot, workflowDirName)
+	if _, err := os.Stat(workflowDir); err != nil {
+		return &DeployCommandResult{Logs: logs}, errors.New("local workflow project not found. Run sync to local first")
+	}
+	dotEnvPath := workflowDotEnvPath(workflowDir, target)
+	extraEnv, err := dotEnvRunEnv(dotEnvPath, appendLog)
+	if err != nil {
+		return &DeployCommandResult{Logs: logs}, err
+	}
+	if ready, _, _ := ensurePrivateKeyConfigured(dotEnvPath); !ready && HasWorkflowKeystore(workflowID, workflowName) {
+		env, err := keystoreKeyEnv(workflowID, workflowName)
+		if err != nil {
+			return &DeployCommandResult{Logs: logs}, err
+		}
+		extraEnv = append(extraEnv, env...)
+	}
+
+	cmdArgs := []string{"workflow", string(op), workflowDirName, "--target", target}
+	cmdArgs = append(cmdArgs, dotEnvRunArgs(workflowDirName, dotEnvPath)...)
+	cmdArgs = append(cmdArgs, "--yes")
+	appendLog("Running: cre " + strings.Join(cmdArgs, " "))
+	var reported DeploymentRecord
+	_, runErr := runCommandStream(projectRoot, extraEnv, nil, DeployTimeout(), func(line string, stderr bool) {
+		parseDeployOutputLine(&reported, line)
+		appendLog(StreamTag("cre", stderr) + line)
+	}, CREBinary(), cmdArgs...)
+	if runErr != nil {
+		return &DeployCommandResult{Logs: logs}, fmt.Errorf("%s failed: %w", op, runErr)
+	}
+	if DryRun() {
+		return &DeployCommandResult{Logs: logs}, nil
+	}
+	recorded, err := setDeploymentStatus(workflowID, workflowName, target, op, reported.TxHash)
+	switch {
+	case err != nil:
+		appendLog("Could not update " + deploymentsFileName + ": " + err.Error())
+	case !recorded:
+		appendLog(fmt.Sprintf("No local deployment of %s is recorded; %s was not updated.", target, deploymentsFileName))
+	default:
+		appendLog(fmt.Sprintf("Marked the %s deployment as %s.", target, status))
+	}
+	return &DeployCommandResult{Logs: logs}, nil
+}
@@ -0,0 +1,87 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+var ErrWorkflowDetailUnsupported = errors.New("frontend does not provide workflow details")
+
+type WorkflowNode struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	Label string `json:"label"`
+}
+
+// WorkflowDetail is the full metadata of a frontend workflow, enough to judge
+// it before syncing it locally.
+type WorkflowDetail struct {
+	FrontendWorkflow
+	Description  string          `json:"description"`
+	Nodes        []WorkflowNode  `json:"nodes"`
+	Triggers     []string        `json:"triggers"`
+	Secrets      []string        `json:"secrets"`
+	ConfigSchema json.RawMessage `json:"configSchema"`
+}
+
+// ConfigSchemaText returns the config schema indented for display, or "" when
+// the workflow declares none.
+func (d *WorkflowDetail) ConfigSchemaText() string {
+	raw := bytes.TrimSpace(d.ConfigSchema)
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, raw, "", "  "); err != nil {
+		return string(raw)
+	}
+	return out.String()
+}
+
+type workflowDetailResponse struct {
+	Workflow *WorkflowDetail `json:"workflow"`
+	apiErrorBody
+}
+
+func FetchWorkflowDetail(baseURL, token, workflowID string) (*WorkflowDetail, error) {
+	endpoint := fmt.Sprintf("%s/api/tui/workflows/%s", NormalizeBaseURL(baseURL), url.PathEscape(workflowID))
+
+	client := newHTTPClient(listTimeout())
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := doFrontendRequest(client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var payload workflowDetailResponse
+	_ = json.NewDecoder(resp.Body).Decode(&payload)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, ErrFrontendUnauthorized
+	case resp.StatusCode == http.StatusMethodNotAllowed:
+		return nil, ErrWorkflowDetailUnsupported
+	case resp.StatusCode == http.StatusNotFound:
+		if payload.hasError() {
+			return nil, payload.apiError(resp.StatusCode)
+		}
+		return nil, ErrWorkflowDetailUnsupported
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, payload.apiError(resp.StatusCode)
+	}
+	if payload.Workflow == nil || payload.Workflow.ID == "" {
+		return nil, errors.New("invalid API response from /api/tui/workflows/{id}")
+	}
+	return payload.Workflow, nil
+}
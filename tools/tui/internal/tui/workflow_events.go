@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	WorkflowEventUpdated = "workflow.updated"
+	WorkflowEventCreated = "workflow.created"
+	WorkflowEventDeleted = "workflow.deleted"
+
+	// Event payloads are small, but leave room for long workflow names.
+	maxEventLineBytes = 1 << 20
+)
+
+var ErrEventsUnsupported = errors.New("frontend does not provide workflow events")
+
+// WorkflowEvent is one change pushed by /api/tui/events. Workflow carries the
+// id plus whichever fields changed; zero values mean "unchanged".
+type WorkflowEvent struct {
+	Type     string
+	Workflow FrontendWorkflow
+}
+
+// MergeWorkflowEvent applies ev to workflows and returns the new list. Updates
+// for unknown ids are treated as creations so a missed event cannot hide a
+// workflow.
+func MergeWorkflowEvent(workflows []FrontendWorkflow, ev WorkflowEvent) []FrontendWorkflow {
+	out := make([]FrontendWorkflow, 0, len(workflows)+1)
+	found := false
+	for _, wf := range workflows {
+		if wf.ID != ev.Workflow.ID {
+			out = append(out, wf)
+			continue
+		}
+		found = true
+		if ev.Type == WorkflowEventDeleted {
+			continue
+		}
+		if ev.Workflow.Name != "" {
+			wf.Name = ev.Workflow.Name
+		}
+		if ev.Workflow.Status != "" {
+			wf.Status = ev.Workflow.Status
+		}
+		if ev.Workflow.CompilerVersion != "" {
+			wf.CompilerVersion = ev.Workflow.CompilerVersion
+		}
+		if ev.Workflow.UpdatedAt != 0 {
+			wf.UpdatedAt = ev.Workflow.UpdatedAt
+		}
+		if ev.Workflow.NodeCount != 0 {
+			wf.NodeCount = ev.Workflow.NodeCount
+		}
+		out = append(out, wf)
+	}
+	if !found && ev.Type != WorkflowEventDeleted {
+		out = append([]FrontendWorkflow{ev.Workflow}, out...)
+	}
+	return out
+}
+
+// SubscribeWorkflowEvents reads the frontend's server-sent event stream and
+// calls onEvent for every workflow change until ctx is cancelled or the
+// stream ends. Reconnecting is left to the caller.
+func SubscribeWorkflowEvents(ctx context.Context, baseURL, token string, onEvent func(WorkflowEvent)) error {
+	url := NormalizeBaseURL(baseURL) + "/api/tui/events"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+
+	// No client timeout: the stream stays open for as long as the TUI runs.
+	resp, err := newHTTPClient(0).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return ErrFrontendUnauthorized
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed:
+		return ErrEventsUnsupported
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return fmt.Errorf("event stream failed with status %d", resp.StatusCode)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		return ErrEventsUnsupported
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxEventLineBytes)
+	eventType := ""
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			// A blank line ends the event.
+			if ev, ok := parseWorkflowEvent(eventType, strings.Join(data, "\n")); ok {
+				onEvent(ev)
+			}
+			eventType = ""
+			data = nil
+		case strings.HasPrefix(line, ":"):
+			// Comment, used by servers as a keep-alive.
+		case strings.HasPrefix(line, "event:"):
+			eventType = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+func parseWorkflowEvent(eventType, data string) (WorkflowEvent, bool) {
+	switch eventType {
+	case WorkflowEventUpdated, WorkflowEventCreated, WorkflowEventDeleted:
+	default:
+		return WorkflowEvent{}, false
+	}
+	var wf FrontendWorkflow
+	if err := json.Unmarshal([]byte(data), &wf); err != nil || strings.TrimSpace(wf.ID) == "" {
+		return WorkflowEvent{}, false
+	}
+	return WorkflowEvent{Type: eventType, Workflow: wf}, true
+}
@@ -0,0 +1,113 @@
+package tui
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WorkflowFilter narrows the workflow list on the frontend so large accounts
+// do not have to be paged through and filtered locally. The zero value lists
+// everything.
+type WorkflowFilter struct {
+	Search       string
+	Status       string
+	UpdatedSince time.Time
+}
+
+var workflowFilterStatuses = []string{"ready", "draft"}
+
+func (f WorkflowFilter) IsZero() bool {
+	return f.Search == "" && f.Status == "" && f.UpdatedSince.IsZero()
+}
+
+func (f WorkflowFilter) apply(query url.Values) {
+	if f.Search != "" {
+		query.Set("search", f.Search)
+	}
+	if f.Status != "" {
+		query.Set("status", f.Status)
+	}
+	if !f.UpdatedSince.IsZero() {
+		query.Set("updatedSince", strconv.FormatInt(f.UpdatedSince.UnixMilli(), 10))
+	}
+}
+
+// Matches mirrors the server-side filter for workflows that arrive through
+// live events rather than a list request.
+func (f WorkflowFilter) Matches(wf FrontendWorkflow) bool {
+	if f.Search != "" && !strings.Contains(strings.ToLower(wf.Name), strings.ToLower(f.Search)) {
+		return false
+	}
+	if f.Status != "" && wf.Status != f.Status {
+		return false
+	}
+	if !f.UpdatedSince.IsZero() && wf.UpdatedAt < f.UpdatedSince.UnixMilli() {
+		return false
+	}
+	return true
+}
+
+// String renders the filter in the syntax accepted by ParseWorkflowFilter.
+func (f WorkflowFilter) String() string {
+	parts := []string{}
+	if f.Search != "" {
+		parts = append(parts, f.Search)
+	}
+	if f.Status != "" {
+		parts = append(parts, "status:"+f.Status)
+	}
+	if !f.UpdatedSince.IsZero() {
+		parts = append(parts, "since:"+f.UpdatedSince.Local().Format("2006-01-02"))
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseWorkflowFilter reads "<name words> status:ready since:7d". since
+// accepts a day count ("7d"), a Go duration ("12h") or a date (2006-01-02).
+func ParseWorkflowFilter(input string) (WorkflowFilter, error) {
+	var filter WorkflowFilter
+	words := []string{}
+	for _, field := range strings.Fields(input) {
+		key, value, ok := strings.Cut(field, ":")
+		switch {
+		case ok && key == "status":
+			status := strings.ToLower(value)
+			valid := false
+			for _, candidate := range workflowFilterStatuses {
+				valid = valid || candidate == status
+			}
+			if !valid {
+				return WorkflowFilter{}, fmt.Errorf("status must be one of %s, got %q", strings.Join(workflowFilterStatuses, ", "), value)
+			}
+			filter.Status = status
+		case ok && key == "since":
+			since, err := parseUpdatedSince(value, time.Now())
+			if err != nil {
+				return WorkflowFilter{}, err
+			}
+			filter.UpdatedSince = since
+		default:
+			words = append(words, field)
+		}
+	}
+	filter.Search = strings.Join(words, " ")
+	return filter, nil
+}
+
+func parseUpdatedSince(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("since must be like 7d, 12h or 2006-01-02, got %q", value)
+}
@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const DefaultWorkflowRunLimit = 20
+
+var ErrRunHistoryUnsupported = errors.New("frontend does not provide run history")
+
+// WorkflowRun is one recorded execution. Times are Unix milliseconds like
+// FrontendWorkflow.UpdatedAt; FinishedAt is 0 while the run is in progress.
+type WorkflowRun struct {
+	ID         string `json:"id"`
+	Status     string `json:"status"`
+	Trigger    string `json:"trigger"`
+	StartedAt  int64  `json:"startedAt"`
+	FinishedAt int64  `json:"finishedAt"`
+	DurationMs int64  `json:"durationMs"`
+	Error      string `json:"error"`
+}
+
+// Duration prefers the reported duration and falls back to the timestamps.
+func (r WorkflowRun) Duration() (time.Duration, bool) {
+	if r.DurationMs > 0 {
+		return time.Duration(r.DurationMs) * time.Millisecond, true
+	}
+	if r.StartedAt > 0 && r.FinishedAt >= r.StartedAt {
+		return time.Duration(r.FinishedAt-r.StartedAt) * time.Millisecond, true
+	}
+	return 0, false
+}
+
+// ErrorSummary is the first line of the run error, shortened for list rows.
+func (r WorkflowRun) ErrorSummary(max int) string {
+	summary := strings.TrimSpace(r.Error)
+	if idx := strings.IndexByte(summary, '\n'); idx >= 0 {
+		summary = strings.TrimSpace(summary[:idx])
+	}
+	if max > 3 && len(summary) > max {
+		summary = summary[:max-3] + "..."
+	}
+	return summary
+}
+
+type workflowRunsResponse struct {
+	Runs []WorkflowRun `json:"runs"`
+	apiErrorBody
+}
+
+// FetchWorkflowRuns returns the most recent executions of a workflow, newest
+// first.
+func FetchWorkflowRuns(baseURL, token, workflowID string, limit int) ([]WorkflowRun, error) {
+	if limit <= 0 {
+		limit = DefaultWorkflowRunLimit
+	}
+	endpoint := fmt.Sprintf("%s/api/tui/workflows/%s/runs?limit=%s",
+		NormalizeBaseURL(baseURL), url.PathEscape(workflowID), strconv.Itoa(limit))
+
+	client := newHTTPClient(listTimeout())
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := doFrontendRequest(client, req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var payload workflowRunsResponse
+	_ = json.NewDecoder(resp.Body).Decode(&payload)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized:
+		return nil, ErrFrontendUnauthorized
+	case resp.StatusCode == http.StatusMethodNotAllowed:
+		return nil, ErrRunHistoryUnsupported
+	case resp.StatusCode == http.StatusNotFound:
+		// A missing workflow is reported with an error body; a bare 404 means
+		// the route itself does not exist.
+		if payload.hasError() {
+			return nil, payload.apiError(resp.StatusCode)
+		}
+		return nil, ErrRunHistoryUnsupported
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		return nil, payload.apiError(resp.StatusCode)
+	}
+	if payload.Runs == nil {
+		return nil, errors.New("invalid API response from /api/tui/workflows/{id}/runs")
+	}
+	return payload.Runs, nil
+}
@@ -85,7 +85,8 @@ class Tui < Formula
 
   def install
     cd "tools/tui" do
-      system "go", "build", *std_go_args(output: bin/"${BINARY_NAME}"), "./cmd/tui"
+      ldflags = "-X github.com/6flow/6flow-convergence/tools/tui/internal/tui.ClientVersion=#{version}"
+      system "go", "build", *std_go_args(output: bin/"${BINARY_NAME}", ldflags: ldflags), "./cmd/tui"
     end
   end
 